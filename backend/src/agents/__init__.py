@@ -13,6 +13,7 @@ from .branch_flipper import BranchFlipperAgent, FlipInput
 from .harness_decoder import HarnessDecoderAgent, InputFormat
 from .coverage_analyzer import CoverageAnalyzerAgent, CoverageReport
 from .dynamic_debug import DynamicDebugAgent, DebugSession
+from .finding_explainer import FindingExplainerAgent, FindingExplanation
 
 __all__ = [
     'AgentBase',
@@ -38,6 +39,8 @@ __all__ = [
     'CoverageReport',
     'DynamicDebugAgent',
     'DebugSession',
+    'FindingExplainerAgent',
+    'FindingExplanation',
 ]
 
 
@@ -53,4 +56,5 @@ def create_agents():
         'harness_decoder': HarnessDecoderAgent(),
         'coverage_analyzer': CoverageAnalyzerAgent(),
         'dynamic_debug': DynamicDebugAgent(),
+        'finding_explainer': FindingExplainerAgent(),
     }
@@ -12,9 +12,16 @@ from enum import Enum
 from typing import Any, Callable, Dict, List, Optional, Tuple
 
 from ..llm import completion, LLMResponse, get_llm_config
+from ..llm.budget import cost_scope
+from ..llm.validation import ToolRejection, parse_json, validate_arguments
+from ..profiling import stage
+from ..untrusted import UNTRUSTED_NOTICE
 
 logger = logging.getLogger(__name__)
 
+# replies the model may get wrong in one run before the agent stops asking and keeps what it has
+MAX_REJECTED_CALLS = 5
+
 
 class AgentStatus(Enum):
     IDLE = "idle"
@@ -43,6 +50,7 @@ class AgentExecution:
     tool_calls: List[ToolCall] = field(default_factory=list)
     total_cost: float = 0.0
     total_tokens: int = 0
+    rejected_calls: int = 0  # malformed replies and tool calls that failed validation
     error: Optional[str] = None
     
     def to_dict(self) -> Dict[str, Any]:
@@ -54,6 +62,7 @@ class AgentExecution:
             "tool_calls": len(self.tool_calls),
             "total_cost": self.total_cost,
             "total_tokens": self.total_tokens,
+            "rejected_calls": self.rejected_calls,
             "error": self.error
         }
 
@@ -63,13 +72,13 @@ class AgentBase(ABC):
     def __init__(
         self,
         agent_id: str,
-        model: str = "gpt-4o-mini",
+        model: Optional[str] = None,
         temperature: float = 0.1,
         max_iterations: int = 10,
         **kwargs
     ):
         self.agent_id = agent_id
-        self.model = model
+        self.model = model or get_llm_config().default_model
         self.temperature = temperature
         self.max_iterations = max_iterations
         
@@ -93,7 +102,8 @@ class AgentBase(ABC):
         name: str,
         func: Callable,
         description: str,
-        parameters: Dict[str, Any]
+        parameters: Dict[str, Any],
+        optional: Tuple[str, ...] = ()
     ) -> None:
         self._tools[name] = func
         self._tool_schemas.append({
@@ -104,7 +114,8 @@ class AgentBase(ABC):
                 "parameters": {
                     "type": "object",
                     "properties": parameters,
-                    "required": list(parameters.keys())
+                    "required": [p for p in parameters if p not in optional],
+                    "additionalProperties": False
                 }
             }
         })
@@ -118,7 +129,8 @@ class AgentBase(ABC):
         context: Optional[Dict[str, Any]] = None
     ) -> str:
         self.execution = AgentExecution(status=AgentStatus.RUNNING)
-        self.messages = [{"role": "system", "content": self.system_prompt}]
+        # scanned code is fenced as untrusted wherever the agents quote it; the notice says what that means
+        self.messages = [{"role": "system", "content": self.system_prompt + UNTRUSTED_NOTICE}]
         
         if context:
             context_str = f"\n\nContext:\n```json\n{json.dumps(context, indent=2)}\n```"
@@ -127,7 +139,8 @@ class AgentBase(ABC):
         self.messages.append({"role": "user", "content": user_message})
         
         try:
-            result = await self._run_loop()
+            with cost_scope(step=self.agent_id), stage(f"agent: {self.agent_id}"):
+                result = await self._run_loop()
             self.execution.status = AgentStatus.COMPLETED
             self.execution.completed_at = time.time()
             return result
@@ -147,6 +160,15 @@ class AgentBase(ABC):
             self.execution.total_cost += response.cost
             self.execution.total_tokens += response.usage.get('total_tokens', 0)
             
+            if response.format_error and self.execution.rejected_calls < MAX_REJECTED_CALLS:
+                self.execution.rejected_calls += 1
+                self.messages.append({"role": "assistant", "content": response.content})
+                self.messages.append({
+                    "role": "user",
+                    "content": f"That reply could not be used: {response.format_error}. Send it again as a single valid JSON object."
+                })
+                continue
+            
             if response.tool_calls:
                 tool_results = await self._execute_tools(response.tool_calls)
                 
@@ -162,6 +184,11 @@ class AgentBase(ABC):
                         "tool_call_id": tool_call["id"],
                         "content": json.dumps(result) if not isinstance(result, str) else result
                     })
+                
+                if self.execution.rejected_calls >= MAX_REJECTED_CALLS:
+                    # the model keeps sending what cannot be accepted; keep the calls that passed
+                    logger.warning(f"Agent {self.agent_id} stopped after {self.execution.rejected_calls} rejected replies")
+                    return response.content or ""
             else:
                 self.messages.append({
                     "role": "assistant",
@@ -185,12 +212,16 @@ class AgentBase(ABC):
     async def _execute_tools(self, tool_calls: List[Dict[str, Any]]) -> List[Any]:
         results = []
         
+        schemas = {s["function"]["name"]: s["function"]["parameters"] for s in self._tool_schemas}
         for tc in tool_calls:
             func_name = tc["function"]["name"]
+            errors = []
             try:
-                args = json.loads(tc["function"]["arguments"])
-            except json.JSONDecodeError:
-                args = {}
+                args = parse_json(tc["function"]["arguments"])
+            except ValueError as e:
+                args, errors = {}, [f"arguments are {e}"]
+            if func_name in schemas and not errors:
+                args, errors = validate_arguments(schemas[func_name], args)
             
             call = ToolCall(
                 id=tc["id"],
@@ -201,7 +232,13 @@ class AgentBase(ABC):
             start_time = time.time()
             
             try:
-                if func_name in self._tools:
+                if errors:
+                    result = (f"Invalid call to {func_name}: {'; '.join(errors)}. Nothing was recorded; call {func_name} "
+                              "again with arguments that match its schema.")
+                    call.error = result
+                    call.success = False
+                    self.execution.rejected_calls += 1
+                elif func_name in self._tools:
                     func = self._tools[func_name]
                     if asyncio.iscoroutinefunction(func):
                         result = await func(**args)
@@ -215,6 +252,11 @@ class AgentBase(ABC):
                     call.error = result
                     call.success = False
                 
+            except ToolRejection as e:
+                result = f"Rejected: {e}"
+                call.error = result
+                call.success = False
+                self.execution.rejected_calls += 1
             except Exception as e:
                 result = f"Tool error: {str(e)}"
                 call.error = result
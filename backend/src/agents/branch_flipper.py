@@ -55,7 +55,7 @@ class FlipInput:
 
 class BranchFlipperAgent(AgentBase):
     
-    def __init__(self, agent_id: str = "branch_flipper", model: str = "gpt-4o-mini", **kwargs):
+    def __init__(self, agent_id: str = "branch_flipper", model: Optional[str] = None, **kwargs):
         self.flip_inputs: List[FlipInput] = []
         self._branch_context: Dict[str, Any] = {}
         self._source_code: str = ""
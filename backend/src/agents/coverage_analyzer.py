@@ -61,7 +61,7 @@ class CoverageReport:
 
 class CoverageAnalyzerAgent(AgentBase):
     
-    def __init__(self, agent_id: str = "coverage_analyzer", model: str = "gpt-4o-mini", **kwargs):
+    def __init__(self, agent_id: str = "coverage_analyzer", model: Optional[str] = None, **kwargs):
         self.reports: List[CoverageReport] = []
         self._gaps: List[CoverageGap] = []
         self._priority_functions: List[str] = []
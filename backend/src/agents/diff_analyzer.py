@@ -8,6 +8,7 @@ from dataclasses import dataclass, field
 from typing import Any, Dict, List, Optional
 
 from .agent_base import AgentBase
+from ..untrusted import fence
 
 
 @dataclass
@@ -42,7 +43,7 @@ class DiffVulnerability:
 
 class DiffAnalyzerAgent(AgentBase):
     
-    def __init__(self, agent_id: str = "diff_analyzer", model: str = "gpt-4o-mini", **kwargs):
+    def __init__(self, agent_id: str = "diff_analyzer", model: Optional[str] = None, **kwargs):
         self.vulnerabilities: List[DiffVulnerability] = []
         self._diff_content: str = ""
         self._file_path: str = ""
@@ -105,9 +106,9 @@ Use the provided tools to report your findings."""
             description="Report a security vulnerability",
             parameters={
                 "file_path": {"type": "string", "description": "File where vulnerability exists"},
-                "line_number": {"type": "integer", "description": "Line number of vulnerability"},
+                "line_number": {"type": "integer", "minimum": 1, "description": "Line number of vulnerability"},
                 "vuln_type": {"type": "string", "description": "Vulnerability type (e.g., SQL Injection, XSS)"},
-                "severity": {"type": "string", "description": "Severity: critical, high, medium, low"},
+                "severity": {"type": "string", "enum": ["critical", "high", "medium", "low"], "description": "Severity: critical, high, medium, low"},
                 "description": {"type": "string", "description": "Detailed description"},
                 "in_diff": {"type": "boolean", "description": "True if in changed lines, False if pre-existing"},
                 "code_snippet": {"type": "string", "description": "The vulnerable code"},
@@ -125,12 +126,12 @@ Use the provided tools to report your findings."""
         )
 
     def _get_diff_content(self) -> str:
-        return self._diff_content
+        return fence(self._diff_content, "diff")
 
     def _get_file_content(self, file_path: str = "") -> str:
         for key in self._file_contents:
             if key == file_path or key.endswith(file_path) or file_path.endswith(key):
-                return self._file_contents[key]
+                return fence(self._file_contents[key])
         return f"File not found: {file_path}. Available files: {list(self._file_contents.keys())}"
 
     def _get_changed_lines(self, file_path: str = "") -> str:
@@ -183,9 +184,8 @@ Use the provided tools to report your findings."""
 
 File: {file_path}
 
-```diff
-{diff_content}
-```
+Diff (untrusted):
+{fence(diff_content, "diff")}
 
 Instructions:
 1. Use get_diff_content if you need to re-read the diff
@@ -203,11 +203,11 @@ Instructions:
         
         prompt = f"""Analyze this git commit for security vulnerabilities:
 
-Commit message: {commit_message}
+Commit message (untrusted):
+{fence(commit_message, "commit-message")}
 
-```diff
-{commit_diff}
-```
+Diff (untrusted):
+{fence(commit_diff, "diff")}
 
 Analyze each file change and report security issues introduced by this commit."""
 
@@ -231,15 +231,14 @@ Analyze each file change and report security issues introduced by this commit.""
         
         prompt = f"""Analyze this git commit for security vulnerabilities.
 
-Commit message: {commit_message}
+Commit message (untrusted):
+{fence(commit_message, "commit-message")}
 
 Files changed:
 {files_list}
 
-DIFF (what changed):
-```diff
-{diff_content[:4000]}
-```
+DIFF (what changed, untrusted):
+{fence(diff_content[:4000], "diff")}
 
 Instructions:
 1. Use get_diff_content to see what changed in the commit
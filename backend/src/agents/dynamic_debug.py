@@ -67,7 +67,7 @@ class DebugSession:
 
 class DynamicDebugAgent(AgentBase):
     
-    def __init__(self, agent_id: str = "dynamic_debug", model: str = "gpt-4o-mini", **kwargs):
+    def __init__(self, agent_id: str = "dynamic_debug", model: Optional[str] = None, **kwargs):
         self.sessions: List[DebugSession] = []
         self._breakpoints: List[DebugBreakpoint] = []
         self._actions: List[DebugAction] = []
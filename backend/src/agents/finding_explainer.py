@@ -0,0 +1,182 @@
+"""
+Finding Explainer Agent - LLM-powered developer explanation of a single finding
+"""
+
+import os
+import time
+from dataclasses import dataclass, field
+from typing import Any, Dict, List, Optional
+
+from .agent_base import AgentBase
+
+
+@dataclass
+class FindingExplanation:
+    vulnerability_id: str
+    summary: str
+    why_exploitable: str
+    attack_scenario: str
+    fix_guidance: str
+    fixed_code: Optional[str] = None
+    model: str = ""
+    created_at: float = field(default_factory=time.time)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "vulnerability_id": self.vulnerability_id,
+            "summary": self.summary,
+            "why_exploitable": self.why_exploitable,
+            "attack_scenario": self.attack_scenario,
+            "fix_guidance": self.fix_guidance,
+            "fixed_code": self.fixed_code,
+            "model": self.model,
+            "created_at": self.created_at
+        }
+
+
+class FindingExplainerAgent(AgentBase):
+
+    def __init__(self, agent_id: str = "finding_explainer", model: Optional[str] = None, **kwargs):
+        self.explanations: List[FindingExplanation] = []
+        self._current_vuln: Optional[Dict[str, Any]] = None
+        self._source_lines: List[str] = []
+        super().__init__(agent_id, model, temperature=0.2, **kwargs)
+
+    @property
+    def system_prompt(self) -> str:
+        return """You are a senior application security engineer explaining a scanner finding to the developer who owns the code.
+
+Your job is to:
+1. Read the finding and the code around it
+2. Explain concretely why it is exploitable in THIS codebase (which input reaches which sink, and how)
+3. Describe a realistic attack scenario
+4. Give a fix that fits the surrounding code, with corrected code when possible
+
+Guidelines:
+- Refer to the actual function, variable, and file names
+- If the finding looks like a false positive, say so plainly and explain why
+- Keep it short and practical; avoid generic security lectures
+
+Use read_source to look at more of the file, then submit_explanation."""
+
+    def _register_tools(self) -> None:
+        self.register_tool(
+            name="read_source",
+            func=self._read_source,
+            description="Read specific lines from the file containing the finding",
+            parameters={
+                "start_line": {"type": "integer", "description": "Starting line number (1-indexed)"},
+                "end_line": {"type": "integer", "description": "Ending line number (1-indexed)"}
+            }
+        )
+
+        self.register_tool(
+            name="submit_explanation",
+            func=self._submit_explanation,
+            description="Submit the developer-oriented explanation of the finding",
+            parameters={
+                "summary": {"type": "string", "description": "One or two sentence summary of the issue"},
+                "why_exploitable": {"type": "string", "description": "Why this code is exploitable, referencing concrete names"},
+                "attack_scenario": {"type": "string", "description": "A realistic attack against this code"},
+                "fix_guidance": {"type": "string", "description": "How to fix it in this codebase"},
+                "fixed_code": {"type": "string", "description": "Corrected version of the vulnerable code"}
+            }
+        )
+
+    def _read_source(self, start_line: int, end_line: int) -> str:
+        if not self._source_lines:
+            return "Source file is not available"
+        start = max(0, start_line - 1)
+        end = min(len(self._source_lines), end_line)
+        return '\n'.join(f"{i + 1}: {self._source_lines[i]}" for i in range(start, end))
+
+    def _submit_explanation(
+        self,
+        summary: str,
+        why_exploitable: str,
+        attack_scenario: str,
+        fix_guidance: str,
+        fixed_code: str = ""
+    ) -> str:
+        if not self._current_vuln:
+            return "Error: No finding being explained"
+
+        explanation = FindingExplanation(
+            vulnerability_id=self._current_vuln.get("vuln_id", "unknown"),
+            summary=summary,
+            why_exploitable=why_exploitable,
+            attack_scenario=attack_scenario,
+            fix_guidance=fix_guidance,
+            fixed_code=fixed_code if fixed_code else None,
+            model=self.model
+        )
+        self.explanations.append(explanation)
+
+        return f"Explanation for {explanation.vulnerability_id} submitted"
+
+    async def explain_finding(self, vulnerability: Dict[str, Any], source_code: str = "") -> FindingExplanation:
+        self._current_vuln = vulnerability
+        self._source_lines = source_code.split('\n') if source_code else []
+
+        line_number = vulnerability.get("line_number", 0) or 0
+        start = max(0, line_number - 16)
+        end = min(len(self._source_lines), line_number + 15)
+        surrounding = '\n'.join(
+            f"{i + 1}: {self._source_lines[i]}" for i in range(start, end)
+        ) or vulnerability.get('code_snippet', 'No code available')
+
+        prompt = f"""Explain the following finding to the developer who owns this code:
+
+Finding ID: {vulnerability.get('vuln_id', 'unknown')}
+Rule: {vulnerability.get('rule_id') or 'LLM analysis'}
+Type: {vulnerability.get('vuln_type', 'unknown')}
+Severity: {vulnerability.get('severity', 'unknown')}
+CWE: {vulnerability.get('cwe_id', 'N/A')}
+File: {vulnerability.get('file_path', 'unknown')}
+Line: {line_number}
+Description: {vulnerability.get('description', 'No description')}
+
+Surrounding code:
+```
+{surrounding}
+```
+
+Use read_source if you need more of the file (it has {len(self._source_lines)} lines), then submit_explanation."""
+
+        result = await self.run(prompt)
+
+        if self.explanations:
+            return self.explanations[-1]
+
+        return FindingExplanation(
+            vulnerability_id=vulnerability.get("vuln_id", "unknown"),
+            summary=result or "Explanation could not be generated",
+            why_exploitable="",
+            attack_scenario="",
+            fix_guidance=vulnerability.get("remediation") or "",
+            model=self.model
+        )
+
+
+async def explain_report_finding(report: Dict[str, Any], vuln_id: str, refresh: bool = False) -> Optional[Dict[str, Any]]:
+    """Explain a finding from a saved report, caching the result in report["explanations"]"""
+    explanations = report.setdefault("explanations", {})
+    if vuln_id in explanations and not refresh:
+        return explanations[vuln_id]
+
+    vulnerability = next((v for v in report.get("vulnerabilities", []) if v.get("vuln_id") == vuln_id), None)
+    if not vulnerability:
+        return None
+
+    source_code = ""
+    file_path = vulnerability.get("file_path", "")
+    if file_path and os.path.isfile(file_path):
+        with open(file_path, 'r', encoding='utf-8', errors='ignore') as f:
+            source_code = f.read()
+
+    agent = FindingExplainerAgent()
+    explanation = await agent.explain_finding(vulnerability, source_code)
+
+    explanations[vuln_id] = explanation.to_dict()
+    report["cost"] = report.get("cost", 0.0) + (agent.execution.total_cost if agent.execution else 0)
+    return explanations[vuln_id]
@@ -52,7 +52,7 @@ class InputFormat:
 
 class HarnessDecoderAgent(AgentBase):
     
-    def __init__(self, agent_id: str = "harness_decoder", model: str = "gpt-4o-mini", **kwargs):
+    def __init__(self, agent_id: str = "harness_decoder", model: Optional[str] = None, **kwargs):
         self.formats: List[InputFormat] = []
         self._input_bytes: bytes = b""
         self._harness_code: str = ""
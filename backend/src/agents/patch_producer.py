@@ -8,6 +8,7 @@ from dataclasses import dataclass, field
 from typing import Any, Dict, List, Optional
 
 from .agent_base import AgentBase
+from ..llm.budget import finding_scope
 
 
 @dataclass
@@ -62,7 +63,7 @@ class PatchProducerAgent(AgentBase):
     def __init__(
         self,
         agent_id: str = "patch_producer",
-        model: str = "gpt-4o-mini",
+        model: Optional[str] = None,
         temperature: float = 0.2,
         **kwargs
     ):
@@ -175,7 +176,8 @@ Suggested remediation: {vulnerability.get('remediation', 'None provided')}
 Generate a secure patch and use the submit_patch tool to submit it.
 Include test cases to verify the patch works correctly."""
 
-        await self.run(prompt)
+        with finding_scope(vulnerability):
+            await self.run(prompt)
         
         if self.generated_patches:
             return self.generated_patches[-1]
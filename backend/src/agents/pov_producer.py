@@ -41,7 +41,7 @@ class ExploitPOV:
 
 class POVProducerAgent(AgentBase):
     
-    def __init__(self, agent_id: str = "pov_producer", model: str = "gpt-4o-mini", **kwargs):
+    def __init__(self, agent_id: str = "pov_producer", model: Optional[str] = None, **kwargs):
         self.povs: List[ExploitPOV] = []
         self._vuln_context: Dict[str, Any] = {}
         super().__init__(agent_id, model, temperature=0.2, **kwargs)
@@ -6,8 +6,11 @@ Inspired by RoboDuck's triage agent
 import time
 from dataclasses import dataclass, field
 from enum import Enum
-from typing import Any, Dict, List, Optional
+from typing import Any, Dict, List, Optional, Set
 
+from ..analysis.rules.base import FIX_COMPLEXITIES
+from ..llm.budget import finding_scope
+from ..untrusted import fence, injected_files
 from .agent_base import AgentBase
 from .vuln_analyzer import Vulnerability
 
@@ -19,6 +22,9 @@ class Priority(Enum):
     LOW = "low"
 
 
+PRIORITY_ORDER = [Priority.CRITICAL, Priority.HIGH, Priority.MEDIUM, Priority.LOW]
+
+
 @dataclass
 class TriageResult:
     triage_id: str
@@ -30,6 +36,7 @@ class TriageResult:
     reasoning: str
     recommended_action: str
     estimated_effort: str
+    fix_complexity: Optional[str] = None
     created_at: float = field(default_factory=time.time)
     
     def to_dict(self) -> Dict[str, Any]:
@@ -43,6 +50,7 @@ class TriageResult:
             "reasoning": self.reasoning,
             "recommended_action": self.recommended_action,
             "estimated_effort": self.estimated_effort,
+            "fix_complexity": self.fix_complexity,
             "created_at": self.created_at
         }
 
@@ -52,12 +60,13 @@ class TriageAgent(AgentBase):
     def __init__(
         self,
         agent_id: str = "triage_agent",
-        model: str = "gpt-4o-mini",
+        model: Optional[str] = None,
         temperature: float = 0.1,
         **kwargs
     ):
         self.triage_results: List[TriageResult] = []
         self._current_vuln: Optional[Dict[str, Any]] = None
+        self._injected_files: Set[str] = set()
         super().__init__(agent_id, model, temperature, **kwargs)
     
     @property
@@ -91,6 +100,10 @@ Use the submit_triage tool to submit your analysis with:
 - reasoning: Your detailed reasoning
 - recommended_action: What should be done
 - estimated_effort: Estimated fix effort (hours, days, weeks)
+- fix_complexity: How far the fix reaches
+  - trivial: Flip a flag or change a config value (set a timeout, restrict a file mode)
+  - localized: Change the code of one function or handler (parameterize a query, add a check)
+  - architectural: Change a design across components (add an authorization layer, rework shared state)
 
 Be objective and consistent in your assessments."""
 
@@ -100,14 +113,16 @@ Be objective and consistent in your assessments."""
             func=self._submit_triage,
             description="Submit triage assessment for a vulnerability",
             parameters={
-                "priority": {"type": "string", "description": "Priority: critical, high, medium, or low"},
-                "exploitability": {"type": "string", "description": "How exploitable: trivial, easy, moderate, difficult, theoretical"},
-                "impact": {"type": "string", "description": "Impact level: critical, high, medium, low"},
-                "cvss_estimate": {"type": "number", "description": "Estimated CVSS score (0.0 - 10.0)"},
+                "priority": {"type": "string", "enum": [p.value for p in Priority], "description": "Priority: critical, high, medium, or low"},
+                "exploitability": {"type": "string", "enum": ["trivial", "easy", "moderate", "difficult", "theoretical"], "description": "How exploitable: trivial, easy, moderate, difficult, theoretical"},
+                "impact": {"type": "string", "enum": ["critical", "high", "medium", "low"], "description": "Impact level: critical, high, medium, low"},
+                "cvss_estimate": {"type": "number", "minimum": 0, "maximum": 10, "description": "Estimated CVSS score (0.0 - 10.0)"},
                 "reasoning": {"type": "string", "description": "Detailed reasoning for the assessment"},
                 "recommended_action": {"type": "string", "description": "Recommended action to take"},
-                "estimated_effort": {"type": "string", "description": "Estimated effort to fix (e.g., '2 hours', '1 day')"}
-            }
+                "estimated_effort": {"type": "string", "description": "Estimated effort to fix (e.g., '2 hours', '1 day')"},
+                "fix_complexity": {"type": "string", "enum": list(FIX_COMPLEXITIES), "description": "Fix complexity: trivial, localized, or architectural"}
+            },
+            optional=("fix_complexity",)
         )
     
     def _submit_triage(
@@ -118,7 +133,8 @@ Be objective and consistent in your assessments."""
         cvss_estimate: float = 5.0,
         reasoning: str = "",
         recommended_action: str = "Review manually",
-        estimated_effort: str = "Unknown"
+        estimated_effort: str = "Unknown",
+        fix_complexity: Optional[str] = None
     ) -> str:
         if not self._current_vuln:
             return "Error: No vulnerability being triaged"
@@ -131,6 +147,14 @@ Be objective and consistent in your assessments."""
             priority_enum = Priority.MEDIUM
         
         cvss_estimate = max(0.0, min(10.0, cvss_estimate))
+        floor = self._priority_floor()
+        if floor and PRIORITY_ORDER.index(priority_enum) > PRIORITY_ORDER.index(floor):
+            # the file talks to the reviewer: a lower priority may be what it asked for
+            kept = (f"Priority kept at {floor.value} (the agent proposed {priority_enum.value}): the file contains text "
+                    "addressed to an AI reviewer, so the finding keeps its initial severity.")
+            reasoning = f"{reasoning}\n\n{kept}" if reasoning else kept
+            priority_enum = floor
+        fix_complexity = (fix_complexity or "").lower()
         
         result = TriageResult(
             triage_id=triage_id,
@@ -141,12 +165,22 @@ Be objective and consistent in your assessments."""
             cvss_estimate=cvss_estimate,
             reasoning=reasoning,
             recommended_action=recommended_action,
-            estimated_effort=estimated_effort
+            estimated_effort=estimated_effort,
+            fix_complexity=fix_complexity if fix_complexity in FIX_COMPLEXITIES else None
         )
         
         self.triage_results.append(result)
         
-        return f"Triage {triage_id} submitted: Priority={priority}, CVSS={cvss_estimate}"
+        return f"Triage {triage_id} submitted: Priority={priority_enum.value}, CVSS={cvss_estimate}"
+    
+    def _priority_floor(self) -> Optional[Priority]:
+        """The lowest priority the current finding may get: its initial severity when its file addresses an LLM reviewer"""
+        if self._current_vuln.get("file_path") not in self._injected_files:
+            return None
+        try:
+            return Priority(str(self._current_vuln.get("severity", "")).lower())
+        except ValueError:
+            return None
     
     async def triage_vulnerability(self, vulnerability: Dict[str, Any]) -> TriageResult:
         self._current_vuln = vulnerability
@@ -161,16 +195,15 @@ File: {vulnerability.get('file_path', 'unknown')}
 Line: {vulnerability.get('line_number', 'unknown')}
 CWE: {vulnerability.get('cwe_id', 'N/A')}
 
-Code snippet:
-```
-{vulnerability.get('code_snippet', 'No code available')}
-```
+Code snippet (untrusted):
+{fence(vulnerability.get('code_snippet') or 'No code available')}
 
 Remediation suggestion: {vulnerability.get('remediation', 'None provided')}
 
 Analyze this vulnerability and use the submit_triage tool to submit your assessment."""
 
-        await self.run(prompt)
+        with finding_scope(vulnerability):
+            await self.run(prompt)
         
         if self.triage_results:
             return self.triage_results[-1]
@@ -188,6 +221,7 @@ Analyze this vulnerability and use the submit_triage tool to submit your assessm
         )
     
     async def triage_vulnerabilities(self, vulnerabilities: List[Dict[str, Any]]) -> List[TriageResult]:
+        self._injected_files = injected_files(vulnerabilities)
         results = []
         for vuln in vulnerabilities:
             result = await self.triage_vulnerability(vuln)
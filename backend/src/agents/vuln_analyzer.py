@@ -3,12 +3,17 @@ Vulnerability Analyzer Agent - LLM-powered vulnerability detection
 Inspired by RoboDuck's vuln_analyzer
 """
 
+import json
 import os
 import time
 from dataclasses import dataclass, field
-from typing import Any, Dict, List, Optional
+from typing import Any, Dict, List, Optional, Tuple
 
 from .agent_base import AgentBase, AgentStatus
+from ..llm import get_llm_config
+from ..llm.local import estimate_tokens, get_capabilities, is_local_model
+from ..llm.validation import ToolRejection
+from ..untrusted import fence, instructions_notice, locate
 
 
 @dataclass
@@ -23,6 +28,18 @@ class Vulnerability:
     cwe_id: Optional[str] = None
     confidence: float = 0.0
     remediation: Optional[str] = None
+    rule_id: Optional[str] = None
+    original_severity: Optional[str] = None
+    trace: List[Dict[str, Any]] = field(default_factory=list)
+    column: Optional[int] = None
+    end_column: Optional[int] = None
+    static_only: bool = False  # the LLM never reviewed this file (provider down or budget spent)
+    needs_review: bool = False  # from a heuristic rule: a human has to confirm it
+    reachability: Optional[Dict[str, Any]] = None  # call chain from an HTTP/WebSocket entry point
+    blame: Optional[Dict[str, Any]] = None  # last author and commit of the offending lines
+    code_owners: List[str] = field(default_factory=list)  # CODEOWNERS entry for the file
+    related: List[str] = field(default_factory=list)  # vuln_ids of the findings this one is chained with
+    critical_path: Optional[str] = None  # critical_paths pattern of the project config the file falls under
     created_at: float = field(default_factory=time.time)
     
     def to_dict(self) -> Dict[str, Any]:
@@ -37,6 +54,18 @@ class Vulnerability:
             "cwe_id": self.cwe_id,
             "confidence": self.confidence,
             "remediation": self.remediation,
+            "rule_id": self.rule_id,
+            "original_severity": self.original_severity,
+            "trace": self.trace,
+            "column": self.column,
+            "end_column": self.end_column,
+            "static_only": self.static_only,
+            "needs_review": self.needs_review,
+            "reachability": self.reachability,
+            "blame": self.blame,
+            "code_owners": self.code_owners,
+            "related": self.related,
+            "critical_path": self.critical_path,
             "created_at": self.created_at
         }
 
@@ -46,7 +75,7 @@ class VulnAnalyzerAgent(AgentBase):
     def __init__(
         self,
         agent_id: str = "vuln_analyzer",
-        model: str = "gpt-4o-mini",
+        model: Optional[str] = None,
         temperature: float = 0.1,
         **kwargs
     ):
@@ -111,13 +140,14 @@ Be thorough but precise. Only report real vulnerabilities with high confidence."
             description="Report a discovered vulnerability",
             parameters={
                 "vuln_type": {"type": "string", "description": "Type of vulnerability"},
-                "severity": {"type": "string", "description": "Severity: critical, high, medium, or low"},
+                "severity": {"type": "string", "enum": ["critical", "high", "medium", "low"], "description": "Severity: critical, high, medium, or low"},
                 "description": {"type": "string", "description": "Description of the vulnerability"},
-                "line_number": {"type": "integer", "description": "Line number of the vulnerability"},
+                "line_number": {"type": "integer", "minimum": 1, "description": "Line number of the vulnerability"},
                 "code_snippet": {"type": "string", "description": "The vulnerable code snippet"},
                 "cwe_id": {"type": "string", "description": "CWE ID (e.g., CWE-89)"},
                 "remediation": {"type": "string", "description": "How to fix the vulnerability"}
-            }
+            },
+            optional=("cwe_id", "remediation")
         )
     
     def _read_source(self, start_line: int, end_line: int) -> str:
@@ -129,7 +159,7 @@ Be thorough but precise. Only report real vulnerabilities with high confidence."
         for i in range(start, end):
             result_lines.append(f"{i + 1}: {lines[i]}")
         
-        return '\n'.join(result_lines)
+        return fence('\n'.join(result_lines))
     
     def _find_pattern(self, pattern: str) -> str:
         import re
@@ -145,7 +175,7 @@ Be thorough but precise. Only report real vulnerabilities with high confidence."
             return f"Invalid regex pattern: {e}"
         
         if matches:
-            return '\n'.join(matches[:20])
+            return fence('\n'.join(matches[:20]))
         return "No matches found"
     
     def _report_vulnerability(
@@ -158,12 +188,14 @@ Be thorough but precise. Only report real vulnerabilities with high confidence."
         cwe_id: str = "",
         remediation: str = ""
     ) -> str:
+        located = locate(self._source_code, line_number, code_snippet)
+        if located is None:
+            # a location that matches nothing in the file is made up (or was planted in the code as an instruction)
+            raise ToolRejection(f"line {line_number} of {self._file_path} does not contain the reported code. Report only "
+                                "code you have read, quoting it from that line.")
+        line_number = located
         vuln_id = f"VULN-{len(self.discovered_vulnerabilities) + 1:04d}"
         
-        severity = severity.lower()
-        if severity not in ["critical", "high", "medium", "low"]:
-            severity = "medium"
-        
         confidence = 0.9 if severity in ["critical", "high"] else 0.7
         
         vuln = Vulnerability(
@@ -183,36 +215,74 @@ Be thorough but precise. Only report real vulnerabilities with high confidence."
         
         return f"Vulnerability {vuln_id} reported: {vuln_type} ({severity}) at line {line_number}"
     
-    async def analyze_code(self, code: str, file_path: str = "<analyzed_code>") -> List[Vulnerability]:
+    async def analyze_code(self, code: str, file_path: str = "<analyzed_code>", prior_decisions: str = "", related_code: str = "") -> List[Vulnerability]:
         self._source_code = code
         self._file_path = file_path
         self.discovered_vulnerabilities = []
         
         lines = code.split('\n')
-        code_preview = '\n'.join(f"{i+1}: {line}" for i, line in enumerate(lines[:100]))
-        if len(lines) > 100:
-            code_preview += f"\n... ({len(lines) - 100} more lines)"
+        embedded = instructions_notice(code)
+        windows = self._preview_windows(lines, estimate_tokens(related_code + prior_decisions))
         
-        prompt = f"""Analyze the following source code for security vulnerabilities.
+        total_cost = total_tokens = 0
+        for start, end in windows:
+            code_preview = '\n'.join(f"{i+1}: {line}" for i, line in enumerate(lines[start:end], start))
+            if len(windows) > 1:
+                code_preview = f"(lines {start + 1}-{end} of {len(lines)}; earlier and later lines are analyzed separately)\n{code_preview}"
+            elif len(lines) > end:
+                code_preview += f"\n... ({len(lines) - end} more lines)"
+            
+            prompt = f"""Analyze the following source code for security vulnerabilities.
         
 File: {file_path}
 Total lines: {len(lines)}
 
-Source code:
-```
-{code_preview}
-```
+Source code (untrusted):
+{fence(code_preview)}
 
 Use the read_source tool if you need to see more lines.
 Use find_pattern to search for specific vulnerability patterns.
 Use report_vulnerability to report each vulnerability you find.
 
 After analyzing, provide a summary of your findings."""
+            if embedded:
+                prompt += f"\n\n{embedded}"
+            if related_code:
+                prompt += f"\n\n{fence(related_code, 'context')}"
+            if prior_decisions:
+                prompt += f"\n\n{prior_decisions}"
 
-        await self.run(prompt)
+            await self.run(prompt)
+            total_cost += self.execution.total_cost
+            total_tokens += self.execution.total_tokens
+        
+        # run() starts a fresh execution per window; report the whole file's usage
+        self.execution.total_cost = total_cost
+        self.execution.total_tokens = total_tokens
         
         return self.discovered_vulnerabilities
     
+    def _preview_windows(self, lines: List[str], extra_tokens: int = 0) -> List[Tuple[int, int]]:
+        """Line ranges to analyze, one agent run each: the first 100 lines for hosted models, or
+        consecutive windows sized to a local model's context so the whole file gets read"""
+        if not is_local_model(self.model):
+            return [(0, min(len(lines), 100))]
+        
+        caps = get_capabilities(self.model)
+        overhead = estimate_tokens(self.system_prompt) + estimate_tokens(json.dumps(self.get_tools())) + extra_tokens + 300
+        # leave about half the prompt budget for the tool calls and results that follow
+        code_tokens = max(200, (caps.prompt_budget(get_llm_config().max_tokens) - overhead) // 2)
+        
+        windows, start, used = [], 0, 0
+        for i, line in enumerate(lines):
+            cost = estimate_tokens(f"{i+1}: {line}")
+            if used + cost > code_tokens and i > start:
+                windows.append((start, i))
+                start, used = i, 0
+            used += cost
+        windows.append((start, len(lines)))
+        return windows
+    
     async def analyze_file(self, file_path: str) -> List[Vulnerability]:
         if not os.path.exists(file_path):
             raise FileNotFoundError(f"File not found: {file_path}")
@@ -0,0 +1,260 @@
+"""
+Call graph - Project-wide caller/callee edges and reachability from HTTP/WebSocket entry points
+Built from the lightweight parser, so it needs no toolchain: Go calls are resolved through import aliases
+and receivers, and method calls on values of unknown type go to every method of that name in the project
+(class hierarchy analysis). This is a heuristic: it imitates go/ssa's CHA call graph from parsed source
+instead of building SSA, and no RTA pass prunes the edges. Edges may be over-approximated, and calls
+through function values, reflection, or code the parser cannot read are missed, so a function with no path
+from an entry point is probably, not provably, unreachable from the network.
+"""
+
+import os
+import re
+from collections import deque
+from dataclasses import dataclass
+from typing import Any, Dict, List, Optional, Sequence, Set
+
+from .parser import LANGUAGE_EXTENSIONS, parse_code
+from .rules.taint import strip_strings
+
+IMPORT_SPEC = re.compile(r'^\s*(?:import\s+)?(\w+|\.|_)?\s*"([^"]+)"', re.MULTILINE)
+IMPORT_BLOCK = re.compile(r'^import\s*\(([^)]*)\)', re.MULTILINE)
+IMPORT_SINGLE = re.compile(r'^import\s+((?:\w+\s+)?"[^"]+")', re.MULTILINE)
+RECEIVER = re.compile(r'func\s*\(\s*(\w+)?\s*\*?\s*(\w+)(?:\[[^\]]*\])?\s*\)')
+CALL = re.compile(r'(?<![\w.])((?:\w+\.)*)(\w+)\s*\(')
+COMMENT = re.compile(r'//[^\n]*|/\*.*?\*/', re.DOTALL)
+NOT_CALLS = frozenset("""
+    if for switch select return func go defer range make new len cap append copy delete panic recover
+    print println close complex real imag min max clear while catch def class elif not and or in
+    int int8 int16 int32 int64 uint uint8 uint16 uint32 uint64 float32 float64 string byte rune bool error
+""".split())
+
+# handler signatures of net/http, gin, echo, fiber, and gorilla/websocket
+HANDLER_PARAMS = re.compile(r'http\.ResponseWriter|\*gin\.Context|echo\.Context|\*fiber\.Ctx|\*websocket\.Conn')
+WEBSOCKET_MARKERS = re.compile(r'\*websocket\.Conn|\.Upgrade\s*\(|websocket\.Accept\s*\(')
+# functions handed to a router: HandleFunc("/x", h), r.GET("/x", h), app.get('/x', h)
+ROUTE_REGISTRATION = re.compile(
+    r'\.(?:HandleFunc|Handle|HandlerFunc|GET|POST|PUT|PATCH|DELETE|HEAD|OPTIONS|Any|Get|Post|Put|Patch|Delete|'
+    r'get|post|put|patch|delete|all|use|ws)\s*\(\s*(?:["\'`][^"\'`]*["\'`]\s*,\s*)?(?:[\w.]+\s*\(\s*)?((?:\w+\.)*\w+)\s*[,)]'
+)
+LANGUAGE_FAMILIES = {'typescript': 'javascript', 'cpp': 'c'}  # languages that call each other directly
+ROUTE_DECORATOR = re.compile(r'@\w+(?:\.\w+)*\.(route|get|post|put|patch|delete|api_route|websocket)\s*\(|@(?:Get|Post|Put|Patch|Delete|Request)Mapping\b')
+
+
+@dataclass
+class FunctionNode:
+    key: str
+    name: str
+    file_path: str
+    start_line: int
+    end_line: int
+    package: str  # directory relative to the project root (a Go package)
+    language: str = ""
+    receiver: Optional[str] = None  # receiver type of a Go method
+    receiver_var: Optional[str] = None
+
+    def label(self) -> str:
+        return f"{self.receiver}.{self.name}" if self.receiver else self.name
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {"function": self.label(), "file_path": self.file_path, "line_number": self.start_line}
+
+
+class CallGraph:
+
+    def __init__(self, root: str):
+        self.root = os.path.abspath(root)
+        self.nodes: Dict[str, FunctionNode] = {}
+        self.edges: Dict[str, Set[str]] = {}
+        self.reverse: Dict[str, Set[str]] = {}
+        self.entry_points: Dict[str, str] = {}  # key -> "http" or "websocket"
+        self.program_entries: Set[str] = set()  # Go main and init functions
+        self._paths: Optional[Dict[str, Optional[str]]] = None  # key -> predecessor on a shortest path from an entry
+        self._live: Optional[Set[str]] = None
+
+    def add_edge(self, caller: str, callee: str):
+        if caller != callee:
+            self.edges.setdefault(caller, set()).add(callee)
+            self.reverse.setdefault(callee, set()).add(caller)
+
+    def function_at(self, file_path: str, line_number: int) -> Optional[FunctionNode]:
+        """Innermost function containing the line"""
+        path = os.path.abspath(file_path)
+        matches = [
+            n for n in self.nodes.values()
+            if n.file_path == path and n.start_line <= line_number <= n.end_line
+        ]
+        return min(matches, key=lambda n: n.end_line - n.start_line, default=None)
+
+    def callers(self, key: str) -> List[FunctionNode]:
+        return [self.nodes[k] for k in sorted(self.reverse.get(key, ()))]
+
+    def callees(self, key: str) -> List[FunctionNode]:
+        return [self.nodes[k] for k in sorted(self.edges.get(key, ()))]
+
+    def reach(self, sources: Sequence[str]) -> Dict[str, Optional[str]]:
+        """Breadth-first search over call edges: reached key -> predecessor (None for the sources)"""
+        reached: Dict[str, Optional[str]] = {k: None for k in sources}
+        queue = deque(sources)
+        while queue:
+            current = queue.popleft()
+            for callee in sorted(self.edges.get(current, ())):
+                if callee not in reached:
+                    reached[callee] = current
+                    queue.append(callee)
+        return reached
+
+    def entry_path(self, key: str) -> Optional[List[FunctionNode]]:
+        """Shortest call chain from an HTTP/WebSocket entry point to the function, or None when unreachable"""
+        if self._paths is None:
+            self._paths = self.reach(sorted(self.entry_points))
+        if key not in self._paths:
+            return None
+        chain = [key]
+        while self._paths[chain[-1]] is not None:
+            chain.append(self._paths[chain[-1]])
+        return [self.nodes[k] for k in reversed(chain)]
+
+    def is_live(self, key: str) -> bool:
+        """Whether anything calls into the function from a network or program entry point"""
+        if self._live is None:
+            self._live = set(self.reach(sorted(set(self.entry_points) | self.program_entries)))
+        return key in self._live
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "functions": len(self.nodes),
+            "edges": sum(len(callees) for callees in self.edges.values()),
+            "entry_points": len(self.entry_points),
+            "program_entries": len(self.program_entries)
+        }
+
+
+def go_imports(code: str) -> Dict[str, str]:
+    """alias -> import path"""
+    specs = [block for block in IMPORT_BLOCK.findall(code)] + IMPORT_SINGLE.findall(code)
+    imports = {}
+    for spec in specs:
+        for alias, path in IMPORT_SPEC.findall(spec):
+            if alias not in ('.', '_'):
+                imports[alias or path.rstrip('/').split('/')[-1]] = path
+    return imports
+
+
+def build_call_graph(root: str, files: Sequence[str]) -> CallGraph:
+    graph = CallGraph(root)
+    sources: Dict[str, str] = {}  # node key -> body without strings and comments
+    imports: Dict[str, Dict[str, str]] = {}  # file -> alias -> import path
+
+    for path in files:
+        try:
+            with open(path, 'r', encoding='utf-8', errors='ignore') as f:
+                code = f.read()
+        except OSError:
+            continue
+        path = os.path.abspath(path)
+        package = os.path.relpath(os.path.dirname(path), graph.root)
+        imports[path] = go_imports(code) if path.endswith('.go') else {}
+        language = LANGUAGE_EXTENSIONS.get(os.path.splitext(path)[1].lower(), '')
+        lines = code.split('\n')
+
+        for member in parse_code(code, path):
+            if member.member_type != 'function':
+                continue
+            receiver = RECEIVER.match(member.signature) if path.endswith('.go') else None
+            node = FunctionNode(
+                key=f"{os.path.relpath(path, graph.root)}:{member.start_line}:{member.name}",
+                name=member.name,
+                file_path=path,
+                start_line=member.start_line,
+                end_line=member.end_line,
+                package=package,
+                language=LANGUAGE_FAMILIES.get(language, language),
+                receiver=receiver.group(2) if receiver else None,
+                receiver_var=receiver.group(1) if receiver else None
+            )
+            graph.nodes[node.key] = node
+            # the first line is the definition itself, not a call
+            sources[node.key] = strip_strings(COMMENT.sub('', member.body.split('\n', 1)[-1] if '\n' in member.body else ''))
+
+            # handlers, and functions building handler closures (middleware)
+            if HANDLER_PARAMS.search(member.body) or WEBSOCKET_MARKERS.search(member.body):
+                graph.entry_points[node.key] = "websocket" if WEBSOCKET_MARKERS.search(member.body) else "http"
+            decorator = ROUTE_DECORATOR.search('\n'.join(lines[max(0, member.start_line - 4):member.start_line - 1]))
+            if decorator:
+                graph.entry_points[node.key] = "websocket" if decorator.group(1) == "websocket" else "http"
+            if node.language == 'go' and node.name in ('main', 'init') and not node.receiver:
+                graph.program_entries.add(node.key)
+
+    by_name: Dict[str, List[FunctionNode]] = {}
+    for node in graph.nodes.values():
+        by_name.setdefault(node.name, []).append(node)
+
+    def resolve(node: FunctionNode, qualifier: str, name: str) -> List[FunctionNode]:
+        candidates = [c for c in by_name.get(name, []) if c.language == node.language]
+        if not candidates:
+            return []
+        file_imports = imports.get(node.file_path, {})
+        parts = qualifier.rstrip('.').split('.') if qualifier else []
+
+        if not parts:
+            # plain call: a function of the same package, else (other languages) any function of that name
+            local = [c for c in candidates if c.package == node.package and not c.receiver]
+            return local or ([] if node.file_path.endswith('.go') else candidates)
+        if len(parts) == 1 and parts[0] in file_imports:
+            import_path = file_imports[parts[0]]
+            return [c for c in candidates if not c.receiver and import_path.endswith(c.package.replace(os.sep, '/'))]
+        if len(parts) == 1 and node.receiver and parts[0] == node.receiver_var:
+            return [c for c in candidates if c.receiver == node.receiver and c.package == node.package]
+        # method on a value of unknown type: every method of that name
+        return [c for c in candidates if c.receiver or not c.file_path.endswith('.go')]
+
+    for key, body in sources.items():
+        node = graph.nodes[key]
+        for match in CALL.finditer(body):
+            qualifier, name = match.groups()
+            if not qualifier and name in NOT_CALLS:
+                continue
+            for callee in resolve(node, qualifier, name):
+                if callee.key != key:
+                    graph.add_edge(key, callee.key)
+
+        for match in ROUTE_REGISTRATION.finditer(body):
+            *qualifier, name = match.group(1).split('.')
+            for handler in resolve(node, '.'.join(qualifier) + '.' if qualifier else '', name):
+                graph.entry_points.setdefault(handler.key, "http")
+
+    return graph
+
+
+SEVERITY_ORDER = ["low", "medium", "high", "critical"]
+
+
+def annotate_reachability(vulnerabilities: Sequence[Any], graph: CallGraph, severity_overrides: Optional[Dict[str, str]] = None) -> int:
+    """Record each finding's call chain from an HTTP/WebSocket entry point. Go findings in code that no
+    entry point (network, main, or init) ever calls drop one severity level, unless the rule has a
+    configured severity. Returns the number of findings lowered."""
+    lowered = 0
+    for vuln in vulnerabilities:
+        node = graph.function_at(vuln.file_path, vuln.line_number)
+        if not node:
+            continue
+        chain = graph.entry_path(node.key)
+        vuln.reachability = {
+            "reachable": chain is not None,
+            "function": node.label(),
+            "entry_point": chain[0].label() if chain else None,
+            "entry_kind": graph.entry_points[chain[0].key] if chain else None,
+            "path": [n.label() for n in chain] if chain else []
+        }
+
+        # other languages have entry points (module-level code, framework wiring) the parser cannot see
+        if node.language != 'go' or graph.is_live(node.key) or not (graph.entry_points or graph.program_entries):
+            continue
+        severity = vuln.severity.lower()
+        if vuln.rule_id in (severity_overrides or {}) or severity not in SEVERITY_ORDER[1:]:
+            continue
+        vuln.reachability["severity_from"] = vuln.severity
+        vuln.severity = SEVERITY_ORDER[SEVERITY_ORDER.index(severity) - 1]
+        lowered += 1
+    return lowered
@@ -0,0 +1,145 @@
+"""
+Chained risks - Findings that together are worse than either one alone
+Each chain pairs an enabling finding with an exploitable one and says how close they must be: in the same
+function, the same file, anywhere in the project, or (for "entry") the enabling finding in the file of the
+entry point the exploitable one is reached from. Components are matched by rule id, or by CWE for LLM
+findings. Every exploitable finding that completes a chain gets one meta-finding at its location, one
+severity level above the worse component, linked both ways through related.
+
+    SSRF (CWE-918) + TLS verification disabled (CWE-295), same file
+    injection reached from a WebSocket endpoint + upgrader accepting any origin, in the endpoint's file
+    IDOR + mass assignment, same handler
+    session token accepted from the URL + session not regenerated at login, same project
+"""
+
+from dataclasses import dataclass
+from typing import Any, FrozenSet, List, Optional, Sequence, Tuple
+
+from .rules.base import StaticFinding, TraceStep
+
+RULE_ID = "chained-risk"
+SEVERITY_ORDER = ["low", "medium", "high", "critical"]
+
+
+@dataclass(frozen=True)
+class Component:
+    rules: FrozenSet[str] = frozenset()
+    cwes: FrozenSet[str] = frozenset()
+
+    def matches(self, vuln: Any) -> bool:
+        return vuln.rule_id in self.rules or (vuln.cwe_id or "").upper() in self.cwes
+
+
+@dataclass(frozen=True)
+class Chain:
+    chain_id: str
+    name: str
+    enabler: Component
+    exploit: Component
+    link: str  # "function", "file", "entry", or "project"
+    impact: str
+    entry_kind: Optional[str] = None  # for "entry": the kind of entry point the exploit must be reached from
+
+
+INJECTION = Component(
+    rules=frozenset({"command-injection", "sql-injection", "orm-injection", "nosql-injection", "ldap-injection",
+                     "xpath-injection", "cross-language-taint"}),
+    cwes=frozenset({"CWE-77", "CWE-78", "CWE-89", "CWE-90", "CWE-94", "CWE-95", "CWE-643", "CWE-943"})
+)
+
+CHAINS = [
+    Chain(
+        "ssrf-insecure-tls", "SSRF through a client that skips TLS verification",
+        enabler=Component(cwes=frozenset({"CWE-295"})),
+        exploit=Component(cwes=frozenset({"CWE-918"})),
+        link="file",
+        impact="the forged request also accepts any certificate, so an attacker on the path can read and rewrite the "
+               "responses the server trusts"
+    ),
+    Chain(
+        "websocket-cross-origin-injection", "Injection reachable from a WebSocket that accepts any origin",
+        enabler=Component(rules=frozenset({"websocket-any-origin"}), cwes=frozenset({"CWE-346", "CWE-1385"})),
+        exploit=INJECTION,
+        link="entry", entry_kind="websocket",
+        impact="any web page the victim visits can open the socket with the victim's cookies and send the payload "
+               "(cross-site WebSocket hijacking), no credentials of the attacker's own needed"
+    ),
+    Chain(
+        "idor-mass-assignment", "Another user's record overwritten with privilege fields",
+        enabler=Component(rules=frozenset({"idor"}), cwes=frozenset({"CWE-639"})),
+        exploit=Component(rules=frozenset({"mass-assignment"}), cwes=frozenset({"CWE-915"})),
+        link="function",
+        impact="the handler takes the record ID from the client without an ownership check and binds privilege "
+               "fields from the body, so a caller can change the role or balance of any account"
+    ),
+    Chain(
+        "session-fixation-via-url", "Session fixation through a session ID in the URL",
+        enabler=Component(rules=frozenset({"session-token-in-url"}), cwes=frozenset({"CWE-598"})),
+        exploit=Component(rules=frozenset({"session-fixation"}), cwes=frozenset({"CWE-384"})),
+        link="project",
+        impact="an attacker can send a link carrying a session ID they know, and the login keeps that session, so "
+               "the attacker is logged in as the victim"
+    ),
+]
+
+
+def escalate(severities: Sequence[str]) -> str:
+    worst = max((SEVERITY_ORDER.index(s.lower()) for s in severities if s and s.lower() in SEVERITY_ORDER), default=1)
+    return SEVERITY_ORDER[min(worst + 1, len(SEVERITY_ORDER) - 1)]
+
+
+def linked(chain: Chain, enabler: Any, exploit: Any, graph: Any) -> bool:
+    if chain.link == "project":
+        return True
+    if chain.link == "file":
+        return enabler.file_path == exploit.file_path
+    if graph is None:
+        return chain.link == "function" and enabler.file_path == exploit.file_path
+    exploit_node = graph.function_at(exploit.file_path, exploit.line_number)
+    if not exploit_node:
+        return False
+    if chain.link == "function":
+        enabler_node = graph.function_at(enabler.file_path, enabler.line_number)
+        return enabler_node is not None and enabler_node.key == exploit_node.key
+    path = graph.entry_path(exploit_node.key)
+    if not path or (chain.entry_kind and graph.entry_points.get(path[0].key) != chain.entry_kind):
+        return False
+    return path[0].file_path == enabler.file_path
+
+
+def find_chains(vulnerabilities: Sequence[Any], graph: Any = None) -> List[Tuple[StaticFinding, List[Any]]]:
+    """(meta-finding, [enabling findings..., exploitable finding]) for each chain completed by the findings;
+    graph is the project's CallGraph (needed for "entry" chains)"""
+    chained = []
+    for chain in CHAINS:
+        enablers = [v for v in vulnerabilities if chain.enabler.matches(v)]
+        if not enablers:
+            continue
+        for exploit in vulnerabilities:
+            if not chain.exploit.matches(exploit):
+                continue
+            components = [e for e in enablers if e is not exploit and linked(chain, e, exploit, graph)]
+            if not components:
+                continue
+            steps = [TraceStep("source", v.file_path, v.line_number, v.code_snippet) for v in components[:3]]
+            steps.append(TraceStep("sink", exploit.file_path, exploit.line_number, exploit.code_snippet))
+            names = ' and '.join(dict.fromkeys(v.vuln_type for v in components))
+            meta = StaticFinding(
+                rule_id=RULE_ID,
+                vuln_type="Chained Risk",
+                severity=escalate([v.severity for v in components + [exploit]]),
+                description=(f"{chain.name}: {exploit.vuln_type} ({exploit.vuln_id}) combined with {names} "
+                             f"({', '.join(v.vuln_id for v in components)}); {chain.impact}"),
+                file_path=exploit.file_path,
+                line_number=exploit.line_number,
+                code_snippet=exploit.code_snippet,
+                cwe_id=exploit.cwe_id,
+                confidence=min(v.confidence for v in components + [exploit]),
+                remediation=(f"Fixing any one of the linked findings breaks the chain; start with {exploit.vuln_id}: "
+                             f"{exploit.remediation or exploit.description}"),
+                trace=steps,
+                column=exploit.column,
+                end_column=exploit.end_column
+            )
+            chained.append((meta, components + [exploit]))
+    return chained
@@ -0,0 +1,363 @@
+"""
+Constant propagation - Fold Go strings built from constants, concatenation, and fmt.Sprintf
+Each assignment in a function defines a new version of its variable (SSA style); an assignment inside a
+branch or case joins with the version it may replace, and a value that grows inside a loop widens to
+unknown. A sink argument then folds to one of three results: a constant (every possible value is known,
+so it is safe), tainted (it contains user input), or unknown (dynamic, with no user input seen). A rule
+may also name sanitizers (ldap.EscapeFilter): their results, and strings built from them and constants,
+fold to escaped, which is as safe as a constant for that rule's sink.
+This is a heuristic over the parsed source, not go/ssa: versions are tracked per function from the text of
+its statements, so values passed through fields, pointers, or other functions are unknown.
+"""
+
+import re
+from dataclasses import dataclass
+from typing import Dict, FrozenSet, List, Optional, Tuple
+
+from .rules.taint import ADDRESS_OF, USER_INPUT_SOURCES, split_args, strip_strings
+
+MAX_VALUES = 16  # possible strings tracked per value before it widens to unknown
+
+CONST_DECL = re.compile(r'^\s*const\s+(\w+)\s*(?:\w+\s*)?=\s*(.+)$')
+CONST_GROUP = re.compile(r'^const\s*\(([^)]*)\)', re.MULTILINE)
+CONST_SPEC = re.compile(r'^\s*(\w+)\s*(?:\w+\s*)?=\s*(.+)$', re.MULTILINE)
+ASSIGN = re.compile(
+    r'^\s*(?:(?:var|const)\s+(?P<declared>\w+)(?:\s+[\w.\[\]*]+)?\s*=|(?P<names>\w+(?:\s*,\s*\w+)*)\s*(?P<op>:=|\+=|=))'
+    r'(?!=)\s*(?P<rhs>.+)$'
+)
+INIT_STATEMENT = re.compile(r'^\s*(?:if|switch)\s+([^;{]*:=[^;{]*);')
+KEYWORDS = frozenset("return case default go defer break continue goto fallthrough select".split())
+VAR_ZERO = re.compile(r'^\s*var\s+(\w+)\s+string\s*$')
+NUMBER = re.compile(r'-?\d+(?:\.\d+)?')
+TYPE_ASSERTION = re.compile(r'\.\([\w.*\[\]]+\)$')
+FORMAT_VERB = re.compile(r'%(?:%|[-+# 0]*\d*(?:\.\d+)?[svdqxXfgeEtTbco])')
+STRING_FUNCS = {
+    "strings.ToUpper": str.upper,
+    "strings.ToLower": str.lower,
+    "strings.TrimSpace": str.strip,
+    "strconv.Itoa": str,
+    "strconv.Quote": lambda s: '"' + s + '"',
+}
+
+
+@dataclass(frozen=True)
+class Value:
+    kind: str  # const, tainted, unknown, or escaped
+    strings: FrozenSet[str] = frozenset()  # every possible value of a constant
+    source: Optional[str] = None  # the user input or unresolved name the value depends on
+    lines: Tuple[int, ...] = ()  # where a tainted value was derived, source first
+
+    @property
+    def is_constant(self) -> bool:
+        return self.kind == "const"
+
+    @property
+    def is_tainted(self) -> bool:
+        return self.kind == "tainted"
+
+    @property
+    def is_escaped(self) -> bool:
+        return self.kind == "escaped"
+
+    @property
+    def is_safe(self) -> bool:
+        return self.kind in ("const", "escaped")
+
+
+def constant(*strings: str) -> Value:
+    return Value("const", frozenset(strings))
+
+
+def unknown(source: Optional[str] = None) -> Value:
+    return Value("unknown", source=source)
+
+
+def escaped(source: Optional[str] = None) -> Value:
+    return Value("escaped", source=source)
+
+
+def merged_lines(a: Value, b: Value) -> Tuple[int, ...]:
+    return a.lines + tuple(n for n in b.lines if n not in a.lines)
+
+
+def concat(a: Value, b: Value) -> Value:
+    if a.is_tainted or b.is_tainted:
+        first = a if a.is_tainted else b
+        return Value("tainted", source=first.source, lines=merged_lines(first, b if first is a else a))
+    if not (a.is_safe and b.is_safe):
+        return unknown(a.source if not a.is_safe else b.source)
+    if a.is_escaped or b.is_escaped:
+        return escaped(a.source if a.is_escaped else b.source)
+    strings = frozenset(x + y for x in a.strings for y in b.strings)
+    return Value("const", strings) if len(strings) <= MAX_VALUES else unknown()
+
+
+def join(a: Value, b: Value) -> Value:
+    """Value of a variable that holds either a or b (a phi node)"""
+    if a.is_tainted or b.is_tainted:
+        first = a if a.is_tainted else b
+        return Value("tainted", source=first.source, lines=merged_lines(first, b if first is a else a))
+    if not (a.is_safe and b.is_safe):
+        return unknown(a.source if not a.is_safe else b.source)
+    if a.is_escaped or b.is_escaped:
+        return escaped(a.source if a.is_escaped else b.source)
+    strings = a.strings | b.strings
+    return Value("const", strings) if len(strings) <= MAX_VALUES else unknown()
+
+
+def string_literal(token: str) -> Optional[str]:
+    if len(token) >= 2 and token[0] == token[-1] == '`':
+        return token[1:-1]
+    if len(token) >= 2 and token[0] == token[-1] == '"':
+        body = token[1:-1]
+        if re.search(r'(?<!\\)"', body):
+            return None
+        return re.sub(r'\\(.)', lambda m: {'n': '\n', 't': '\t'}.get(m.group(1), m.group(1)), body)
+    return None
+
+
+def split_concat(expr: str) -> List[str]:
+    """Operands of a top-level + chain"""
+    parts, depth, quote, current = [], 0, None, ''
+    for i, char in enumerate(expr):
+        if quote:
+            current += char
+            if char == quote and (quote == '`' or expr[i - 1] != '\\' or expr[i - 2:i] == '\\\\'):
+                quote = None
+            continue
+        if char in '"`\'':
+            quote = char
+        elif char in '([{':
+            depth += 1
+        elif char in ')]}':
+            depth -= 1
+        elif char == '+' and depth == 0:
+            parts.append(current.strip())
+            current = ''
+            continue
+        current += char
+    parts.append(current.strip())
+    return [p for p in parts if p]
+
+
+def strip_comment(line: str) -> str:
+    quote = None
+    for i, char in enumerate(line):
+        if quote:
+            if char == quote and (quote == '`' or line[i - 1] != '\\'):
+                quote = None
+        elif char in '"`\'':
+            quote = char
+        elif line.startswith('//', i):
+            return line[:i]
+    return line
+
+
+def sprintf(format_string: str, args: List[str]) -> str:
+    values = iter(args)
+
+    def verb(match: re.Match) -> str:
+        if match.group(0) == '%%':
+            return '%'
+        value = next(values, '%!(MISSING)')
+        return '"' + value + '"' if match.group(0).endswith('q') else value
+
+    return FORMAT_VERB.sub(verb, format_string)
+
+
+def package_constants(code: str) -> Dict[str, Value]:
+    """Top-level const declarations that are plain literals"""
+    env: Dict[str, Value] = {}
+    specs = [m for m in (CONST_DECL.match(line) for line in code.split('\n') if line.startswith('const')) if m]
+    for block in CONST_GROUP.findall(code):
+        specs.extend(CONST_SPEC.finditer(block))
+    for spec in specs:
+        literal = string_literal(strip_comment(spec.group(2)).strip())
+        number = NUMBER.fullmatch(strip_comment(spec.group(2)).strip())
+        if literal is not None:
+            env[spec.group(1)] = constant(literal)
+        elif number:
+            env[spec.group(1)] = constant(number.group(0))
+    return env
+
+
+class ConstantFolder:
+    """Reaching values of the variables of one function body, line by line"""
+
+    def __init__(
+        self,
+        body: str,
+        first_line: int = 1,
+        globals_: Optional[Dict[str, Value]] = None,
+        sanitizers: FrozenSet[str] = frozenset()
+    ):
+        self.first_line = first_line
+        self.lines = body.split('\n')
+        self.globals = globals_ or {}
+        self.sanitizers = sanitizers
+        self.envs: List[Dict[str, Value]] = []  # environment before each line
+        self._run()
+
+    def _run(self):
+        env: Dict[str, Value] = {}
+        defined_at: Dict[str, int] = {}  # variable -> block depth of its declaration
+        loops: List[int] = []  # depths of the enclosing for blocks
+        depth = 0
+
+        for index, raw in enumerate(self.lines):
+            self.envs.append(dict(env))
+            line = strip_comment(raw)
+            line_number = self.first_line + index
+            stripped = line.strip()
+            code = strip_strings(line)
+
+            while loops and depth <= loops[-1]:
+                loops.pop()
+            if index and stripped.startswith('for') and code.rstrip().endswith('{'):
+                loops.append(depth)
+
+            if index and USER_INPUT_SOURCES.search(line):
+                for name in ADDRESS_OF.findall(line):
+                    env[name] = Value("tainted", source=name, lines=(line_number,))
+                    defined_at.setdefault(name, depth)
+
+            init = INIT_STATEMENT.match(line)
+            zero = VAR_ZERO.match(line)
+            match = ASSIGN.match(init.group(1) if init else line) if index else None
+            names = [n.strip() for n in (match.group('declared') or match.group('names')).split(',')] if match else []
+            if zero:
+                env[zero.group(1)] = constant("")
+                defined_at[zero.group(1)] = depth
+            elif match and names[0] not in KEYWORDS:
+                operator, rhs = match.group('op') or ':=', match.group('rhs').strip()
+                value = self.fold(rhs, env, line_number)
+                if len(names) > 1 and not (value.is_tainted or value.is_escaped):
+                    value = unknown(rhs)
+                for name in names:
+                    if name == '_':
+                        continue
+                    declares = operator == ':='
+                    value_for = concat(env.get(name, unknown(name)), value) if operator == '+=' else value
+                    if value_for.is_tainted and line_number not in value_for.lines:
+                        value_for = Value("tainted", source=value_for.source, lines=value_for.lines + (line_number,))
+                    if not declares and name in env and depth > defined_at.get(name, depth):
+                        # assigned in a branch, case, or loop that may not run: either version reaches
+                        if loops and loops[-1] >= defined_at.get(name, 0) and (operator == '+=' or re.search(rf'\b{name}\b', strip_strings(rhs))):
+                            value_for = value_for if value_for.is_tainted else unknown(name)
+                        value_for = join(env[name], value_for)
+                    if declares or name not in defined_at:
+                        defined_at[name] = depth
+                    env[name] = value_for
+
+            depth += code.count('{') - code.count('}')
+
+    def env_at(self, line_number: int) -> Dict[str, Value]:
+        index = line_number - self.first_line
+        if 0 <= index < len(self.envs):
+            return self.envs[index]
+        return dict(self.envs[-1]) if self.envs else {}
+
+    def value_of(self, expr: str, line_number: int) -> Value:
+        """Fold an expression as it would evaluate on the given line"""
+        return self.fold(expr, self.env_at(line_number), line_number)
+
+    def lookup(self, name: str, env: Dict[str, Value]) -> Value:
+        if name in env:
+            return env[name]
+        if name in self.globals:
+            return self.globals[name]
+        return unknown(name)
+
+    def fold(self, expr: str, env: Dict[str, Value], line_number: int) -> Value:
+        expr = expr.strip().rstrip(';')
+        while expr.startswith('(') and expr.endswith(')') and split_concat(expr) == [expr] and len(split_args(expr[1:-1])) == 1:
+            expr = expr[1:-1].strip()
+        operands = split_concat(expr)
+        if len(operands) > 1:
+            value = self.fold(operands[0], env, line_number)
+            for operand in operands[1:]:
+                value = concat(value, self.fold(operand, env, line_number))
+            return value
+
+        sanitizer = re.match(r'^((?:\w+\.)*\w+)\s*\(.*\)$', expr, re.DOTALL)
+        if sanitizer and sanitizer.group(1) in self.sanitizers:
+            return escaped(sanitizer.group(1))
+        source = USER_INPUT_SOURCES.search(self.unsanitized(expr))
+        if source:
+            return Value("tainted", source=source.group(0).strip('.(').rstrip('('), lines=(line_number,))
+
+        literal = string_literal(expr)
+        if literal is not None:
+            return constant(literal)
+        if NUMBER.fullmatch(expr) or expr in ('true', 'false'):
+            return constant(expr)
+
+        expr = TYPE_ASSERTION.sub('', expr)
+        call = re.match(r'^((?:\w+\.)*\w+)\s*\((.*)\)$', expr, re.DOTALL)
+        if call:
+            return self.fold_call(call.group(1), split_args(call.group(2)), env, line_number)
+
+        head = re.match(r'^(\w+)(?:\.\w+|\[[^\]]*\])*$', expr)
+        if head:
+            value = self.lookup(head.group(1), env)
+            if head.group(0) == head.group(1) or value.is_tainted:
+                return value
+            return unknown(expr)
+        return self.propagate(expr, env, line_number)
+
+    def unsanitized(self, expr: str) -> str:
+        """expr with the sanitizer calls in it blanked out, so input they escape is not seen as a source"""
+        for name in self.sanitizers:
+            call = re.search(rf'(?<![\w.]){re.escape(name)}\s*\(', expr)
+            while call:
+                depth, end = 0, len(expr)
+                for pos in range(call.end() - 1, len(expr)):
+                    depth += {'(': 1, ')': -1}.get(expr[pos], 0)
+                    if depth == 0:
+                        end = pos + 1
+                        break
+                expr = expr[:call.start()] + '""' + expr[end:]
+                call = re.search(rf'(?<![\w.]){re.escape(name)}\s*\(', expr)
+        return expr
+
+    def fold_call(self, function: str, args: List[str], env: Dict[str, Value], line_number: int) -> Value:
+        if function == 'strings.Join' and len(args) == 2:
+            items = re.match(r'^\[\]string\s*\{(.*)\}$', args[0].strip(), re.DOTALL)
+            if items:
+                args = split_args(items.group(1)) + [args[1]]
+        values = [self.fold(arg, env, line_number) for arg in args]
+        receiver = env.get(function.split('.')[0]) if '.' in function else None
+        tainted = next((v for v in values + [receiver] if v and v.is_tainted), None)
+        if tainted:
+            return tainted
+        if all(v.is_safe for v in values) and any(v.is_escaped for v in values) \
+                and function in ('fmt.Sprintf', 'fmt.Sprint', 'strings.Join', 'string'):
+            return escaped(function)
+        if not all(v.is_constant for v in values):
+            return unknown(function)
+
+        if function in ('fmt.Sprintf', 'fmt.Sprint') and values:
+            combos = [[]]
+            for v in values:
+                combos = [c + [s] for c in combos for s in sorted(v.strings)]
+                if len(combos) > MAX_VALUES:
+                    return unknown(function)
+            if function == 'fmt.Sprint':
+                return constant(*(''.join(c) for c in combos))
+            return constant(*(sprintf(c[0], c[1:]) for c in combos))
+        if function in STRING_FUNCS and len(values) == 1:
+            return constant(*(STRING_FUNCS[function](s) for s in values[0].strings))
+        if function == 'string' and len(values) == 1:
+            return values[0]
+        if function == 'strings.Join' and len(values) > 2 and all(len(v.strings) == 1 for v in values):
+            *parts, separator = [next(iter(v.strings)) for v in values]
+            return constant(separator.join(parts))
+        return unknown(function)
+
+    def propagate(self, expr: str, env: Dict[str, Value], line_number: int) -> Value:
+        """Anything else (index expressions, method calls on values, composite literals) carries taint through"""
+        for name in re.findall(r'(?<![\w.])[A-Za-z_]\w*', strip_strings(expr)):
+            value = env.get(name)
+            if value and value.is_tainted:
+                return value
+        return unknown(expr)
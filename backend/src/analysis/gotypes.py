@@ -0,0 +1,443 @@
+"""
+Go types - Package-level type information for the static rules
+A toolchain-free stand-in for go/packages: the Go files of a package are read together, imports are
+resolved to their paths (including aliases), and variables, parameters, struct fields, and call results
+get package-qualified types. Detectors use it to tell database/sql's Query from an unrelated Query method,
+to follow calls through interfaces to their implementations, and to see through aliased imports.
+Anything it cannot type stays unknown, and rules fall back to their name heuristics.
+"""
+
+import os
+import re
+from dataclasses import dataclass, field
+from typing import Dict, List, Optional, Set, Tuple
+
+from .callgraph import go_imports
+from .parser import get_parser
+from .rules.taint import split_args
+
+PACKAGE_CLAUSE = re.compile(r'^package\s+(\w+)', re.MULTILINE)
+FUNC_DECL = re.compile(r'^func\s*(?:\(([^)]*)\)\s*)?(\w+)\s*(?:\[[^\]]*\])?\s*\(', re.MULTILINE)
+TYPE_SINGLE = re.compile(r'^type\s+(\w+)(?:\[[^\]]*\])?\s*=?\s*(.+)$', re.MULTILINE)
+TYPE_GROUP = re.compile(r'^type\s*\(', re.MULTILINE)
+GROUP_SPEC = re.compile(r'^\s*(\w+)(?:\[[^\]]*\])?\s*=?\s*(.+)$')
+VAR_DECL = re.compile(r'^\s*var\s+(\w+(?:\s*,\s*\w+)*)\s+([^=\n]+?)\s*(?:=.*)?$', re.MULTILINE)
+VAR_INIT = re.compile(r'^\s*var\s+(\w+)\s*=\s*(.+)$', re.MULTILINE)
+SHORT_DECL = re.compile(r'^\s*(\w+)(?:\s*,\s*(?:\w+))*\s*:?=\s*(.+)$', re.MULTILINE)
+INTERFACE_METHOD = re.compile(r'^\s*(\w+)\s*\(', re.MULTILINE)
+SELECTOR = re.compile(r'^\s*((?:\w+\.)*\w+)$')
+COMMENT = re.compile(r'//[^\n]*')
+
+BUILTIN_TYPES = frozenset("""
+    bool byte rune string error any int int8 int16 int32 int64 uint uint8 uint16 uint32 uint64 uintptr
+    float32 float64 complex64 complex128
+""".split())
+
+# result types of the library functions and methods the rules care about
+KNOWN_RESULTS: Dict[str, str] = {
+    "database/sql.Open": "database/sql.DB",
+    "database/sql.OpenDB": "database/sql.DB",
+    "github.com/jmoiron/sqlx.Open": "github.com/jmoiron/sqlx.DB",
+    "github.com/jmoiron/sqlx.Connect": "github.com/jmoiron/sqlx.DB",
+    "github.com/jmoiron/sqlx.MustConnect": "github.com/jmoiron/sqlx.DB",
+    "github.com/jmoiron/sqlx.NewDb": "github.com/jmoiron/sqlx.DB",
+    "os.Open": "os.File",
+    "os.Create": "os.File",
+    "os.OpenFile": "os.File",
+    "os.CreateTemp": "os.File",
+    "io/ioutil.TempFile": "os.File",
+}
+SQL_RESULTS = {
+    "Begin": "Tx", "BeginTx": "Tx", "Prepare": "Stmt", "PrepareContext": "Stmt", "Conn": "Conn",
+    "Query": "Rows", "QueryContext": "Rows", "QueryRow": "Row", "QueryRowContext": "Row",
+}
+for _owner in ("DB", "Tx", "Conn"):
+    for _method, _result in SQL_RESULTS.items():
+        KNOWN_RESULTS[f"database/sql.{_owner}.{_method}"] = f"database/sql.{_result}"
+for _method, _result in (("Query", "Rows"), ("QueryContext", "Rows"), ("QueryRow", "Row"), ("QueryRowContext", "Row")):
+    KNOWN_RESULTS[f"database/sql.Stmt.{_method}"] = f"database/sql.{_result}"
+KNOWN_RESULTS["github.com/jmoiron/sqlx.DB.Beginx"] = "github.com/jmoiron/sqlx.Tx"
+KNOWN_RESULTS["github.com/jmoiron/sqlx.DB.Preparex"] = "github.com/jmoiron/sqlx.Stmt"
+
+# method sets of library types, for checking which ones satisfy a project interface
+KNOWN_METHODS: Dict[str, Set[str]] = {
+    "database/sql.DB": {"Begin", "BeginTx", "Close", "Conn", "Driver", "Exec", "ExecContext", "Ping", "PingContext",
+                        "Prepare", "PrepareContext", "Query", "QueryContext", "QueryRow", "QueryRowContext",
+                        "SetConnMaxIdleTime", "SetConnMaxLifetime", "SetMaxIdleConns", "SetMaxOpenConns", "Stats"},
+    "database/sql.Tx": {"Commit", "Exec", "ExecContext", "Prepare", "PrepareContext", "Query", "QueryContext",
+                        "QueryRow", "QueryRowContext", "Rollback", "Stmt", "StmtContext"},
+    "database/sql.Conn": {"BeginTx", "Close", "ExecContext", "PingContext", "PrepareContext", "QueryContext",
+                          "QueryRowContext", "Raw"},
+    "database/sql.Stmt": {"Close", "Exec", "ExecContext", "Query", "QueryContext", "QueryRow", "QueryRowContext"},
+    "database/sql.Rows": {"Close", "ColumnTypes", "Columns", "Err", "Next", "NextResultSet", "Scan"},
+    "database/sql.Row": {"Err", "Scan"},
+    "os.File": {"Chdir", "Chmod", "Chown", "Close", "Fd", "Name", "Read", "ReadAt", "ReadDir", "Readdir", "Readdirnames",
+                "Seek", "SetDeadline", "Stat", "Sync", "Truncate", "Write", "WriteAt", "WriteString"},
+}
+
+
+@dataclass
+class TypeDecl:
+    name: str  # package-qualified
+    kind: str  # struct, interface, or named
+    fields: Dict[str, str] = field(default_factory=dict)  # field -> qualified type
+    embedded: List[str] = field(default_factory=list)  # qualified types of embedded fields
+    methods: Set[str] = field(default_factory=set)  # interface methods
+    tags: Dict[str, str] = field(default_factory=dict)  # every named field of a struct (builtin types too) -> its tag
+    underlying: Optional[str] = None  # qualified type of a named type or alias
+
+
+@dataclass
+class FuncDecl:
+    name: str
+    receiver: Optional[str]  # qualified receiver type of a method
+    params: Dict[str, str]  # parameter (and receiver) name -> qualified type
+    result: Optional[str]  # qualified type of the first result
+    start: int  # offsets of the declaration in its file
+    end: int
+
+
+@dataclass
+class FileDecls:
+    package: str
+    imports: Dict[str, str]
+    types: Dict[str, TypeDecl]
+    functions: List[FuncDecl]
+    globals: Dict[str, str]
+
+
+def qualify(type_expr: str, imports: Dict[str, str], package: str) -> Optional[str]:
+    """Package-qualified name of a named type: "*sql.DB" -> "database/sql.DB"; None for builtins and composites"""
+    expr = type_expr.strip().lstrip('*&').strip()
+    expr = re.sub(r'\[.*\]$', '', expr)  # type arguments of a generic type
+    match = re.fullmatch(r'(\w+)\.(\w+)', expr)
+    if match:
+        path = imports.get(match.group(1))
+        return f"{path}.{match.group(2)}" if path else None
+    if re.fullmatch(r'\w+', expr) and expr not in BUILTIN_TYPES:
+        return f"{package}.{expr}"
+    return None
+
+
+def package_of(qualified: str) -> str:
+    return qualified.rsplit('.', 1)[0]
+
+
+def parse_params(params: str, imports: Dict[str, str], package: str) -> Dict[str, str]:
+    """name -> qualified type for "a, b *sql.DB, c int" (names without a type take the next one)"""
+    typed: Dict[str, str] = {}
+    pending: List[str] = []
+    for param in split_args(params):
+        parts = param.strip().split(None, 1)
+        if len(parts) == 1:
+            pending.append(parts[0])
+            continue
+        names = pending + [parts[0]]
+        pending = []
+        qualified = qualify(parts[1].lstrip('.'), imports, package)
+        if qualified:
+            for name in names:
+                typed[name] = qualified
+    return typed
+
+
+def first_result(results: str, imports: Dict[str, str], package: str) -> Optional[str]:
+    results = results.strip()
+    if results.startswith('('):
+        args = split_args(results[1:results.rfind(')')])
+        if not args:
+            return None
+        parts = args[0].split(None, 1)
+        results = parts[-1]
+    return qualify(results.split('{')[0], imports, package) if results else None
+
+
+def matching_paren(code: str, open_paren: int) -> int:
+    depth = 0
+    for pos in range(open_paren, len(code)):
+        if code[pos] == '(':
+            depth += 1
+        elif code[pos] == ')':
+            depth -= 1
+            if depth == 0:
+                return pos
+    return len(code)
+
+
+def parse_type_spec(name: str, rest: str, code: str, offset: int, imports: Dict[str, str], package: str) -> TypeDecl:
+    qualified = f"{package}.{name}"
+    kind = re.match(r'(struct|interface)\s*\{', rest.strip())
+    if not kind:
+        return TypeDecl(qualified, "named", underlying=qualify(rest.split('//')[0], imports, package))
+
+    brace = code.index('{', offset)
+    body = COMMENT.sub('', code[brace + 1:get_parser()._find_brace_block_end(code, brace)])
+    decl = TypeDecl(qualified, kind.group(1))
+    if decl.kind == "interface":
+        decl.methods = set(INTERFACE_METHOD.findall(body))
+        return decl
+
+    for line in body.split('\n'):
+        tag = re.search(r'`([^`]*)`', line)
+        line = re.sub(r'`[^`]*`', '', line).strip()
+        if not line:
+            continue
+        parts = line.split(None, 1)
+        if len(parts) == 1:
+            embedded = qualify(parts[0], imports, package)
+            if embedded:
+                decl.embedded.append(embedded)
+            continue
+        names = [n.strip() for n in parts[0].rstrip(',').split(',')]
+        if ',' in parts[1] and not parts[1].startswith(('func', 'map', '[')):
+            # "a, b Type" splits after the first name
+            names += [n.strip() for n in parts[1].rsplit(None, 1)[0].split(',') if n.strip()]
+            parts[1] = parts[1].rsplit(None, 1)[-1]
+        for field_name in names:
+            decl.tags[field_name] = tag.group(1) if tag else ""
+        qualified_field = qualify(parts[1], imports, package)
+        if qualified_field:
+            for field_name in names:
+                decl.fields[field_name] = qualified_field
+    return decl
+
+
+def parse_file(code: str) -> FileDecls:
+    match = PACKAGE_CLAUSE.search(code)
+    package = match.group(1) if match else "main"
+    imports = go_imports(code)
+    types: Dict[str, TypeDecl] = {}
+
+    for match in TYPE_SINGLE.finditer(code):
+        types[match.group(1)] = parse_type_spec(match.group(1), match.group(2), code, match.start(2), imports, package)
+    for match in TYPE_GROUP.finditer(code):
+        end = matching_paren(code, match.end() - 1)
+        offset, depth = match.end(), 0
+        for line in code[match.end():end].split('\n'):
+            spec = GROUP_SPEC.match(COMMENT.sub('', line))
+            if spec and depth == 0:
+                types[spec.group(1)] = parse_type_spec(spec.group(1), spec.group(2), code, offset + spec.start(2), imports, package)
+            depth += line.count('{') - line.count('}')
+            offset += len(line) + 1
+
+    functions = []
+    for match in FUNC_DECL.finditer(code):
+        receiver = qualify(match.group(1).split()[-1], imports, package) if match.group(1) else None
+        params_end = matching_paren(code, match.end() - 1)
+        brace = code.find('{', params_end)
+        if brace < 0:
+            continue
+        params = parse_params(code[match.end():params_end], imports, package)
+        if match.group(1) and len(match.group(1).split()) == 2:
+            params[match.group(1).split()[0]] = receiver
+        functions.append(FuncDecl(
+            name=match.group(2),
+            receiver=receiver,
+            params=params,
+            result=first_result(code[params_end + 1:brace], imports, package),
+            start=match.start(),
+            end=get_parser()._find_brace_block_end(code, brace)
+        ))
+
+    # package-level variables
+    globals_: Dict[str, str] = {}
+    for match in VAR_DECL.finditer(code):
+        if match.group(0).startswith('var'):
+            qualified = qualify(match.group(2), imports, package)
+            if qualified:
+                for name in match.group(1).split(','):
+                    globals_[name.strip()] = qualified
+    return FileDecls(package, imports, types, functions, globals_)
+
+
+_file_cache: Dict[Tuple[str, float, int], FileDecls] = {}
+
+
+def load_file(path: str) -> Optional[FileDecls]:
+    try:
+        stat = os.stat(path)
+        key = (path, stat.st_mtime, stat.st_size)
+        if key not in _file_cache:
+            with open(path, 'r', encoding='utf-8', errors='ignore') as f:
+                _file_cache[key] = parse_file(f.read())
+        return _file_cache[key]
+    except OSError:
+        return None
+
+
+class GoTypes:
+    """Types of one Go file, seen together with the other files of its package"""
+
+    def __init__(self, code: str, file_path: str):
+        self.code = code
+        self.file = parse_file(code)
+        self.package = self.file.package
+        self.imports = self.file.imports
+        self.types: Dict[str, TypeDecl] = {}
+        self.functions: Dict[str, FuncDecl] = {}  # "Name" or "pkg.Type.Name" -> declaration
+        self.globals: Dict[str, str] = {}
+
+        siblings = []
+        directory = os.path.dirname(os.path.abspath(file_path))
+        if os.path.isfile(file_path):
+            for name in sorted(os.listdir(directory)):
+                path = os.path.join(directory, name)
+                if name.endswith('.go') and not name.endswith('_test.go') and os.path.abspath(file_path) != path:
+                    decls = load_file(path)
+                    if decls and decls.package == self.package:
+                        siblings.append(decls)
+        for decls in siblings + [self.file]:
+            for decl in decls.types.values():
+                self.types[decl.name] = decl
+            for function in decls.functions:
+                self.functions[f"{function.receiver}.{function.name}" if function.receiver else function.name] = function
+            self.globals.update(decls.globals)
+
+    def import_path(self, alias: str) -> Optional[str]:
+        return self.imports.get(alias)
+
+    def method_owner(self, type_name: str, method: str, seen: Optional[Set[str]] = None) -> Optional[str]:
+        """Type whose method a call resolves to, following embedded fields and named types"""
+        seen = seen or set()
+        if type_name in seen:
+            return None
+        seen.add(type_name)
+        if f"{type_name}.{method}" in self.functions or method in KNOWN_METHODS.get(type_name, ()) \
+                or f"{type_name}.{method}" in KNOWN_RESULTS:
+            return type_name
+        decl = self.types.get(type_name)
+        if not decl:
+            # a library type we know nothing about: assume it declares the method
+            return type_name if package_of(type_name) != self.package else None
+        if decl.kind == "interface":
+            return type_name if method in decl.methods else None
+        for base in decl.embedded + ([decl.underlying] if decl.underlying else []):
+            owner = self.method_owner(base, method, seen)
+            if owner:
+                return owner
+        return None
+
+    def field_type(self, type_name: str, name: str, seen: Optional[Set[str]] = None) -> Optional[str]:
+        seen = seen or set()
+        decl = self.types.get(type_name)
+        if not decl or type_name in seen:
+            return None
+        seen.add(type_name)
+        if name in decl.fields:
+            return decl.fields[name]
+        for base in decl.embedded + ([decl.underlying] if decl.underlying else []):
+            if base.rsplit('.', 1)[-1] == name:
+                return base
+            found = self.field_type(base, name, seen)
+            if found:
+                return found
+        return None
+
+    def method_set(self, type_name: str) -> Set[str]:
+        if type_name in KNOWN_METHODS:
+            return KNOWN_METHODS[type_name]
+        methods = {key.rsplit('.', 1)[1] for key in self.functions if key.startswith(type_name + '.') and key.count('.') > type_name.count('.')}
+        decl = self.types.get(type_name)
+        if decl and decl.kind == "interface":
+            return set(decl.methods)
+        for base in (decl.embedded if decl else []):
+            methods |= self.method_set(base)
+        return methods
+
+    def implementations(self, interface: str) -> List[str]:
+        """Project and known library types whose method set covers the interface"""
+        decl = self.types.get(interface)
+        if not decl or decl.kind != "interface" or not decl.methods:
+            return []
+        candidates = [t for t, d in self.types.items() if d.kind != "interface"] + list(KNOWN_METHODS)
+        return sorted(t for t in candidates if decl.methods <= self.method_set(t))
+
+    def enclosing(self, offset: int) -> Optional[FuncDecl]:
+        for function in self.file.functions:
+            if function.start <= offset <= function.end:
+                return function
+        return None
+
+    def type_of(self, expr: str, offset: int) -> Optional[str]:
+        """Qualified type of an identifier, selector chain, or call expression at an offset of the file"""
+        expr = expr.strip().lstrip('&*(').strip()
+        if not expr:
+            return None
+        function = self.enclosing(offset)
+
+        composite = re.match(r'^((?:\w+\.)?\w+)\s*\{', expr) or re.match(r'^new\s*\(\s*((?:\w+\.)?\w+)\s*\)', expr)
+        if composite:
+            return qualify(composite.group(1), self.imports, self.package)
+
+        call = re.match(r'^((?:\w+\.)*)(\w+)\s*\(', expr)
+        if call:
+            qualifier, name = call.group(1).rstrip('.'), call.group(2)
+            callee = self.callee(f"{qualifier}.{name}" if qualifier else name, offset)
+            if not callee:
+                return None
+            if callee in KNOWN_RESULTS:
+                return KNOWN_RESULTS[callee]
+            declared = self.functions.get(callee if qualifier and callee.count('.') > 1 else name)
+            return declared.result if declared else None
+
+        if not SELECTOR.match(expr):
+            return None
+        head, *rest = expr.split('.')
+        current = self.variable_type(head, function, offset)
+        for name in rest:
+            if not current:
+                return None
+            current = self.field_type(current, name)
+        return current
+
+    def variable_type(self, name: str, function: Optional[FuncDecl], offset: int) -> Optional[str]:
+        if function:
+            # the latest declaration before the offset wins
+            body = self.code[function.start:offset]
+            line_start = body.rfind('\n')
+            body = body[:line_start] if line_start >= 0 else ''
+            found = None
+            for match in VAR_DECL.finditer(body):
+                if name in [n.strip() for n in match.group(1).split(',')]:
+                    found = (match.start(), qualify(match.group(2), self.imports, self.package))
+            for pattern in (VAR_INIT, SHORT_DECL):
+                for match in pattern.finditer(body):
+                    names = [n.strip() for n in match.group(0).split('=')[0].rstrip(':').replace('var ', '').split(',')]
+                    if names and names[0] == name and (not found or match.start() > found[0]):
+                        found = (match.start(), self.type_of(match.group(2), function.start + match.start()))
+            if found:
+                return found[1]
+            if name in function.params:
+                return function.params[name]
+        if name in self.globals:
+            return self.globals[name]
+        return None
+
+    def callee(self, expr: str, offset: int) -> Optional[str]:
+        """Qualified function or method a call expression "a.b.Method" resolves to:
+        "crypto/rand.Read", "database/sql.DB.Query", "store.Querier.Query"; None when unknown"""
+        *receiver, name = expr.strip().split('.')
+        if not receiver:
+            return name if name in self.functions else None
+        if len(receiver) == 1 and receiver[0] in self.imports and not self.variable_type(receiver[0], self.enclosing(offset), offset):
+            return f"{self.imports[receiver[0]]}.{name}"
+        receiver_type = self.type_of('.'.join(receiver), offset)
+        if not receiver_type:
+            return None
+        owner = self.method_owner(receiver_type, name)
+        return f"{owner}.{name}" if owner else None
+
+    def concrete_callees(self, expr: str, offset: int) -> List[str]:
+        """Declarations a call may run: the resolved callee, or for an interface method the methods of
+        every implementation; empty when the call cannot be resolved"""
+        callee = self.callee(expr, offset)
+        if not callee:
+            return []
+        if '.' not in callee:
+            return [callee]  # a function of this package
+        owner, method = callee.rsplit('.', 1)
+        decl = self.types.get(owner)
+        if decl and decl.kind == "interface":
+            implementations = [f"{self.method_owner(t, method) or t}.{method}" for t in self.implementations(owner)]
+            return implementations or [callee]
+        return [callee]
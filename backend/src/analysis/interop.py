@@ -0,0 +1,273 @@
+"""
+Interop taint - Follow user input across language boundaries at declared interop points
+Polyglot services hand data over through queues, topics, or repo-local scripts, where no single-file rule
+can see both ends. Each interop point in .sastscan.yaml names the producing call and the consuming side:
+    interop:
+      - name: resize jobs
+        send: 'Publish\\(ctx, "resize"'        # the producing call; its arguments carry the data
+        receive: 'subscribe\\("resize"\\)'     # where the consumer gets it (an assignment or a handler def)
+      - name: thumbnail script
+        script: scripts/thumbnail.py          # a repo-local script run with exec; its argv is the data
+When a producer passes user input through the point, the consumer's code is followed from the receiving
+line to its sinks, and each hit becomes one finding with a trace that spans both files.
+"""
+
+import os
+import re
+from dataclasses import dataclass
+from typing import Any, Dict, List, Optional, Tuple
+
+from .parser import LANGUAGE_EXTENSIONS, SourceMember, parse_code
+from ..redaction import redact_findings
+from .rules.base import StaticFinding, TraceStep
+from .rules.taint import ASSIGNMENT, USER_INPUT_SOURCES, assigned_names, references, split_args, taint_origins, taint_path
+
+RULE_ID = "cross-language-taint"
+NO_SOURCES = re.compile(r'(?!)')
+
+# user input in each language's request handlers
+REQUEST_SOURCES: Dict[str, re.Pattern] = {
+    'go': USER_INPUT_SOURCES,
+    'python': re.compile(r'\brequest\.(?:args|form|values|json|data|files|cookies|headers|GET|POST|body|query_params|path_params)\b|\bget_json\s*\('),
+    'javascript': re.compile(r'\breq\.(?:body|query|params|headers|cookies)\b|\bctx\.request\.(?:body|query)\b'),
+}
+# command-line input of a script
+SCRIPT_INPUTS: Dict[str, re.Pattern] = {
+    'go': re.compile(r'\bos\.Args\b|\bflag\.Arg\(|\bos\.Stdin\b'),
+    'python': re.compile(r'\bsys\.argv\b|\bsys\.stdin\b|\bargparse\b.*parse_args\(|\binput\s*\('),
+    'javascript': re.compile(r'\bprocess\.argv\b|\bprocess\.stdin\b'),
+}
+EXEC_CALLS = re.compile(
+    r'\bexec\.Command(?:Context)?\s*\(|\bsubprocess\.\w+\s*\(|\bos\.(?:system|popen|exec\w*)\s*\('
+    r'|\b(?:child_process\.)?(?:exec|execFile|spawn)(?:Sync)?\s*\('
+)
+
+# (sink pattern, vulnerability type, CWE, index of the argument that must be tainted or None for any)
+SINKS: Dict[str, List[Tuple[re.Pattern, str, str, Optional[int]]]] = {
+    'go': [
+        (re.compile(r'\.(?:Query|QueryRow|Exec)\s*\('), "SQL Injection", "CWE-89", 0),
+        (re.compile(r'\.(?:QueryContext|QueryRowContext|ExecContext)\s*\('), "SQL Injection", "CWE-89", 1),
+        (re.compile(r'\bexec\.Command\s*\('), "Command Injection", "CWE-78", None),
+        (re.compile(r'\bos\.(?:Open|OpenFile|Create|ReadFile|WriteFile|Remove|RemoveAll)\s*\('), "Path Traversal", "CWE-22", 0),
+    ],
+    'python': [
+        (re.compile(r'\.execute(?:many)?\s*\('), "SQL Injection", "CWE-89", 0),
+        (re.compile(r'\bos\.(?:system|popen)\s*\(|\bsubprocess\.\w+\s*\(.*shell\s*=\s*True'), "Command Injection", "CWE-78", 0),
+        (re.compile(r'(?<![\w.])(?:eval|exec)\s*\('), "Code Injection", "CWE-95", 0),
+        (re.compile(r'\bpickle\.loads?\s*\(|\byaml\.load\s*\((?!.*SafeLoader)'), "Insecure Deserialization", "CWE-502", 0),
+        (re.compile(r'(?<![\w.])open\s*\('), "Path Traversal", "CWE-22", 0),
+    ],
+    'javascript': [
+        (re.compile(r'\.query\s*\('), "SQL Injection", "CWE-89", 0),
+        (re.compile(r'\b(?:child_process\.)?exec(?:Sync)?\s*\('), "Command Injection", "CWE-78", 0),
+        (re.compile(r'(?<![\w.])eval\s*\('), "Code Injection", "CWE-95", 0),
+        (re.compile(r'\bfs\.(?:readFile|writeFile|createReadStream|createWriteStream|unlink)\w*\s*\('), "Path Traversal", "CWE-22", 0),
+    ],
+}
+LANGUAGE_FAMILIES = {'typescript': 'javascript'}
+
+
+@dataclass
+class InteropPoint:
+    name: str
+    send: Optional[re.Pattern] = None
+    receive: Optional[re.Pattern] = None
+    script: Optional[str] = None  # path relative to the project root
+
+    @classmethod
+    def from_dict(cls, data: Dict[str, Any]) -> 'InteropPoint':
+        name = str(data.get('name') or data.get('script') or data.get('send') or 'interop')
+        if data.get('script'):
+            return cls(name=name, script=os.path.normpath(str(data['script'])))
+        if not (data.get('send') and data.get('receive')):
+            raise ValueError(f"interop point {name!r} needs either script, or both send and receive")
+        return cls(name=name, send=re.compile(str(data['send'])), receive=re.compile(str(data['receive'])))
+
+    def to_dict(self) -> Dict[str, Any]:
+        if self.script:
+            return {"name": self.name, "script": self.script}
+        return {"name": self.name, "send": self.send.pattern, "receive": self.receive.pattern}
+
+
+@dataclass
+class SourceFile:
+    path: str
+    language: str
+    code: str
+    lines: List[str]
+    members: List[SourceMember]
+
+    def scope(self, line_number: int) -> Tuple[str, int]:
+        """(body, first line) of the innermost function around a line, or the whole file for module-level code"""
+        enclosing = [m for m in self.members if m.member_type == 'function' and m.start_line <= line_number <= m.end_line]
+        if not enclosing:
+            return self.code, 1
+        member = min(enclosing, key=lambda m: m.end_line - m.start_line)
+        return member.body, member.start_line
+
+
+@dataclass
+class TaintedHandoff:
+    point: InteropPoint
+    file: SourceFile
+    line_number: int
+    path: List[int]  # producer lines from the user input to the handoff
+
+
+def load_sources(files: List[str]) -> List[SourceFile]:
+    sources = []
+    for path in files:
+        language = LANGUAGE_EXTENSIONS.get(os.path.splitext(path)[1].lower(), '')
+        language = LANGUAGE_FAMILIES.get(language, language)
+        if language not in SINKS:
+            continue
+        try:
+            with open(path, 'r', encoding='utf-8', errors='ignore') as f:
+                code = f.read()
+        except OSError:
+            continue
+        sources.append(SourceFile(path, language, code, code.split('\n'), parse_code(code, path)))
+    return sources
+
+
+def call_text(lines: List[str], index: int, start: int) -> str:
+    """Arguments of the call opening at or after a column, following it over continuation lines"""
+    text = '\n'.join(lines[index:index + 20])
+    open_paren = text.find('(', start)
+    if open_paren < 0:
+        return lines[index][start:]
+    depth = 0
+    for pos in range(open_paren, len(text)):
+        if text[pos] == '(':
+            depth += 1
+        elif text[pos] == ')':
+            depth -= 1
+            if depth == 0:
+                return text[open_paren + 1:pos]
+    return text[open_paren + 1:]
+
+
+def tainted_at(source: SourceFile, line_number: int, expr: str) -> Optional[List[int]]:
+    """Producer lines carrying user input into expr on the given line, or None when expr is not tainted"""
+    sources = REQUEST_SOURCES[source.language]
+    body, first_line = source.scope(line_number)
+    if sources.search(expr):
+        return [line_number]
+    origins = taint_origins(body, sources=sources)
+    name = references(expr, origins)
+    if not name:
+        return None
+    return [n for n in taint_path(origins, name, first_line) if n < line_number] + [line_number]
+
+
+def find_handoffs(point: InteropPoint, sources: List[SourceFile]) -> List[TaintedHandoff]:
+    handoffs = []
+    for source in sources:
+        for index, line in enumerate(source.lines):
+            if point.script:
+                match = EXEC_CALLS.search(line)
+                window = '\n'.join(source.lines[index:index + 5])
+                if not match or os.path.basename(point.script) not in window:
+                    continue
+                args = call_text(source.lines, index, match.start())
+            else:
+                match = point.send.search(line)
+                if not match:
+                    continue
+                args = call_text(source.lines, index, match.start())
+            path = tainted_at(source, index + 1, args)
+            if path:
+                handoffs.append(TaintedHandoff(point, source, index + 1, path))
+    return handoffs
+
+
+def receiving_lines(point: InteropPoint, sources: List[SourceFile], root: str) -> List[Tuple[SourceFile, int]]:
+    if point.script:
+        script = os.path.abspath(os.path.join(root, point.script))
+        return [(s, 1) for s in sources if os.path.abspath(s.path) == script]
+    return [(s, i + 1) for s in sources for i, line in enumerate(s.lines) if point.receive.search(line)]
+
+
+def consumer_seeds(source: SourceFile, line_number: int) -> List[str]:
+    """Names holding the received data: the assignment on the receiving line, or the handler's parameters"""
+    line = source.lines[line_number - 1]
+    assignment = ASSIGNMENT.match(line)
+    if assignment and not re.match(r'\s*(?:def|func|function|async)\b', line):
+        return sorted(assigned_names(assignment.group(1)))
+    params = re.search(r'(?:def|func|function)\s*\w*\s*\(([^)]*)\)|\(([^)]*)\)\s*=>', line)
+    if not params:
+        return []
+    names = []
+    for param in split_args(params.group(1) or params.group(2) or ''):
+        name = re.split(r'[\s:=]', param.strip().lstrip('*'), 1)[0]
+        if name and name not in ('self', 'cls', 'ctx', 'context', 'err'):
+            names.append(name)
+    return names
+
+
+def consumer_sinks(source: SourceFile, first_line: int, body: str, origins: Dict[str, Tuple[int, Optional[str]]]):
+    """(line number, sink, tainted name) for each sink reached by the received data"""
+    lines = body.split('\n')
+    for index, line in enumerate(lines):
+        for pattern, vuln_type, cwe, arg_index in SINKS[source.language]:
+            match = pattern.search(line)
+            if not match:
+                continue
+            args = split_args(call_text(lines, index, match.start()))
+            checked = args if arg_index is None else args[arg_index:arg_index + 1]
+            name = next((references(arg, origins) for arg in checked if references(arg, origins)), None)
+            if name:
+                yield first_line + index, (vuln_type, cwe), name
+
+
+def find_interop_flows(root: str, files: List[str], points: List[InteropPoint]) -> List[StaticFinding]:
+    if not points:
+        return []
+    sources = load_sources(files)
+    findings = []
+    reported = set()
+
+    for point in points:
+        handoffs = find_handoffs(point, sources)
+        if not handoffs:
+            continue
+        producer = handoffs[0]
+
+        for consumer, receive_line in receiving_lines(point, sources, root):
+            if point.script:
+                body, first_line = consumer.code, 1
+                origins = taint_origins(body, sources=SCRIPT_INPUTS[consumer.language])
+            else:
+                body, first_line = consumer.scope(receive_line)
+                body = '\n'.join(body.split('\n')[receive_line - first_line:])
+                first_line = receive_line
+                origins = taint_origins(body, sources=NO_SOURCES, seeds=consumer_seeds(consumer, receive_line))
+            if not origins:
+                continue
+
+            for sink_line, (vuln_type, cwe), name in consumer_sinks(consumer, first_line, body, origins):
+                if (consumer.path, sink_line) in reported:
+                    continue
+                reported.add((consumer.path, sink_line))
+                consumer_path = [n for n in taint_path(origins, name, first_line) if n != sink_line]
+                trace = [TraceStep("source" if i == 0 else "propagation", producer.file.path, n, producer.file.lines[n - 1].strip())
+                         for i, n in enumerate(producer.path)]
+                trace += [TraceStep("propagation", consumer.path, n, consumer.lines[n - 1].strip()) for n in consumer_path]
+                trace.append(TraceStep("sink", consumer.path, sink_line, consumer.lines[sink_line - 1].strip()))
+                origin = os.path.relpath(producer.file.path, root)
+                findings.append(StaticFinding(
+                    rule_id=RULE_ID,
+                    vuln_type=vuln_type,
+                    severity="high",
+                    description=(f"{vuln_type} in {consumer.language} code: '{name}' arrives through interop point '{point.name}' "
+                                 f"and carries user input from {producer.file.language} code at {origin}:{producer.path[0]}"),
+                    file_path=consumer.path,
+                    line_number=sink_line,
+                    code_snippet=consumer.lines[sink_line - 1].strip(),
+                    cwe_id=cwe,
+                    confidence=0.75,
+                    remediation="Treat data received from other services or processes as untrusted: validate it where it is consumed, and use parameterized queries or argument lists at the sink",
+                    trace=trace
+                ))
+                redact_findings(findings[-1:], '\n'.join(consumer.lines))
+    return findings
@@ -52,6 +52,8 @@ LANGUAGE_EXTENSIONS = {
     '.rs': 'rust',
     '.rb': 'ruby',
     '.php': 'php',
+    '.kt': 'kotlin',
+    '.kts': 'kotlin',
 }
 
 
@@ -98,7 +100,7 @@ class CodeParser:
             ],
             'c': [
                 ('function', 'function', re.compile(
-                    r'^(\s*)(?:static\s+)?(?:inline\s+)?(?:\w+\s*\*?\s+)+(\w+)\s*\([^)]*\)\s*\{',
+                    r'^(\s*)(?:static\s+)?(?:inline\s+)?(?:\w+(?:\s*\*+\s*|\s+))+(\w+)\s*\([^)]*\)\s*\{',
                     re.MULTILINE
                 )),
                 ('struct', 'struct', re.compile(
@@ -108,7 +110,7 @@ class CodeParser:
             ],
             'cpp': [
                 ('function', 'function', re.compile(
-                    r'^(\s*)(?:virtual\s+)?(?:static\s+)?(?:inline\s+)?(?:\w+\s*[*&]?\s+)+(\w+)\s*\([^)]*\)\s*(?:const)?\s*(?:override)?\s*\{',
+                    r'^(\s*)(?:virtual\s+)?(?:static\s+)?(?:inline\s+)?(?:\w+(?:\s*[*&]+\s*|\s+))+(\w+)\s*\([^)]*\)\s*(?:const)?\s*(?:override)?\s*\{',
                     re.MULTILINE
                 )),
                 ('class', 'class', re.compile(
@@ -128,7 +130,7 @@ class CodeParser:
             ],
             'go': [
                 ('function', 'func', re.compile(
-                    r'^(\s*)func\s+(?:\([^)]+\)\s+)?(\w+)\s*\([^)]*\)\s*(?:\([^)]*\)|\w+)?\s*\{',
+                    r'^(\s*)func\s+(?:\([^)]+\)\s+)?(\w+)\s*\([^)]*\)\s*(?:\([^)]*\)|[\w.*\[\]]+)?\s*\{',
                     re.MULTILINE
                 )),
                 ('struct', 'struct', re.compile(
@@ -136,6 +138,29 @@ class CodeParser:
                     re.MULTILINE
                 )),
             ],
+            'kotlin': [
+                # block bodies only; parameters may hold annotations with arguments: @RequestParam("id") id: String
+                ('function', 'fun', re.compile(
+                    r'^(\s*)(?:(?:public|private|protected|internal|override|open|final|abstract|suspend|inline|operator|infix|tailrec)\s+)*'
+                    r'fun\s+(?:<[^>]*>\s*)?(?:[\w.<>?]+\.)?(\w+)\s*\((?:[^()]|\([^()]*\))*\)\s*(?::\s*[^{=\n]+)?\{',
+                    re.MULTILINE
+                )),
+                ('class', 'class', re.compile(
+                    r'^(\s*)(?:(?:public|private|protected|internal|open|abstract|final|data|sealed|enum|inner|annotation)\s+)*'
+                    r'(?:class|object|interface)\s+(\w+)[^{\n]*(?:\((?:[^()]|\([^()]*\))*\)[^{\n]*)?\{',
+                    re.MULTILINE
+                )),
+            ],
+            'php': [
+                ('function', 'function', re.compile(
+                    r'^(\s*)(?:(?:public|private|protected|static|final|abstract)\s+)*function\s+&?(\w+)\s*\([^)]*\)\s*(?::\s*\??[\w\\|]+\s*)?\{',
+                    re.MULTILINE
+                )),
+                ('class', 'class', re.compile(
+                    r'^(\s*)(?:(?:abstract|final|readonly)\s+)*(?:class|trait|interface|enum)\s+(\w+)[^{\n]*\{',
+                    re.MULTILINE
+                )),
+            ],
         }
     
     def detect_language(self, file_path: Optional[str] = None, code: Optional[str] = None) -> str:
@@ -145,6 +170,8 @@ class CodeParser:
                 return LANGUAGE_EXTENSIONS[ext]
         
         if code:
+            if code.lstrip().startswith('<?php'):
+                return 'php'
             if 'def ' in code and ':' in code:
                 return 'python'
             elif '#include' in code:
@@ -0,0 +1,318 @@
+"""
+Entry-point inventory - The project's attack surface: every HTTP, WebSocket, and gRPC entry point
+Entry points are those of the call graph (handler signatures, functions handed to a router, route decorators)
+plus the methods of services registered on a gRPC server. Each gets the routes it is registered under, whether
+authentication guards it, and the findings in code it reaches.
+
+Authentication is judged from what is visible: auth middleware wrapping the handler at registration or
+installed with Use on its router (or one it was derived from, or the whole server), an auth decorator or
+before_request hook, an auth interceptor on the gRPC server, or an authentication or authorization check in
+the handler and the functions it calls. Without any of those a route is "unauthenticated" when its router is
+created in the same file and "unknown" when it comes from elsewhere.
+"""
+
+import os
+import re
+from dataclasses import dataclass, field
+from typing import Any, Dict, List, Optional, Sequence, Set, Tuple
+
+from .callgraph import CallGraph, FunctionNode
+from .rules.base import SourceContext, read_source
+from .rules.grpc import AUTH_INTERCEPTOR, HANDLER_AUTH, INTERCEPTOR_OPTION, NEW_SERVER, REGISTER_SERVICE
+from .rules.idor import checks_authorization, function_source
+from .rules.ratelimit import ROUTER_CREATED, ROUTER_DERIVED, SERVER_WRAP
+from .rules.taint import split_args
+
+# router.GET("/x", h), mux.HandleFunc("GET /x", h), r.With(mw).Post("/x", h), app.get('/x', auth, h)
+ROUTE_REGISTRATION = re.compile(
+    r'(?<![\w.])(\w+)((?:\.\w+\([^()\n]*(?:\([^()\n]*\)[^()\n]*)*\))*)'
+    r'\.(HandleFunc|Handle|GET|POST|PUT|PATCH|DELETE|HEAD|OPTIONS|Any|Get|Post|Put|Patch|Delete|Head|Options|Match|'
+    r'get|post|put|patch|delete|head|options|all|ws)\s*\(\s*["\'`]([^"\'`]*)["\'`]'
+)
+ROUTE_PATH = re.compile(r'^(?:([A-Z]+)\s+)?(/\S*)$')  # "/login", or "POST /login" (Go 1.22 patterns)
+ANY_METHOD = frozenset({'HandleFunc', 'Handle', 'Any', 'Match', 'all'})
+JS_ROUTER_CREATED = re.compile(r'(\w+)\s*=\s*(?:express\s*\(\s*\)|express\.Router\s*\(|(?:new\s+)?(?:Router|Hono|Koa|fastify)\s*\()')
+INLINE_HANDLER = re.compile(r'^\s*(?:func\b|function\b|async\b|\(|\w+\s*=>)')
+MIDDLEWARE_USE = re.compile(r'(\w+)\.(?:Use|use)\s*\(')
+DECORATOR = re.compile(
+    r'@\w+(?:\.\w+)*\.(route|get|post|put|patch|delete|api_route|websocket)\s*\((.*)'
+    r'|@(Get|Post|Put|Patch|Delete|Request)Mapping\b(.*)'
+)
+DECORATOR_METHODS = re.compile(r'methods\s*=\s*[\[(]([^\])]*)|method\s*=\s*(?:RequestMethod\.)?(\w+)')
+STRING = re.compile(r'["\']([^"\']*)["\']')
+
+# middleware, decorators, and interceptors named for authentication: requireAuth, jwtMiddleware, @login_required
+AUTH_NAME = re.compile(
+    r'auth(?!or)|jwt|oidc|oauth|api_?key|bearer|token|session|login_?required|logged_?in|signed_?in|current_?user'
+    r'|require_?user|credential|identity|protect|guard|secured|PreAuthorize|RolesAllowed',
+    re.IGNORECASE
+)
+# the handler itself establishes who the caller is
+AUTHENTICATES = re.compile(
+    r'\.(?:Header\.Get|GetHeader|Get|get|header)\s*\(\s*["\']Authorization["\']'
+    r'|\bjwt\.Parse\w*\s*\(|\b[vV]erify(?:Token|JWT|Jwt|Session|IDToken)\w*\s*\(|\b[aA]uthenticate\w*\s*\('
+    r'|\b(?:[cC]urrentUser|current_user|[uU]serFrom(?:Context|Request|Session)|[cC]laimsFrom\w*|[sS]essionFrom\w*)\b'
+    r'|\.Cookie\s*\(\s*"[^"]*(?:sess|token|auth)',
+)
+HOOK = re.compile(r'@\w+\.before_request\b')
+AUTH_DEPTH = 2  # levels of callees searched for an auth check in the handler
+
+
+@dataclass
+class EntryPoint:
+    kind: str  # http, websocket, or grpc
+    handler: str
+    file_path: str
+    line_number: int
+    routes: List[str] = field(default_factory=list)  # "GET /orders/{id}", "/admin.Admin/DeleteUser"
+    auth: str = "unknown"  # authenticated, unauthenticated, or unknown
+    auth_evidence: Optional[str] = None
+    findings: List[Dict[str, Any]] = field(default_factory=list)  # vuln_id, vuln_type, severity of reached findings
+    key: Optional[str] = None  # call graph node, None for inline handlers
+    end_line: Optional[int] = None
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "kind": self.kind,
+            "handler": self.handler,
+            "file_path": self.file_path,
+            "line_number": self.line_number,
+            "routes": self.routes,
+            "auth": self.auth,
+            "auth_evidence": self.auth_evidence,
+            "findings": self.findings
+        }
+
+
+@dataclass
+class Registration:
+    route: str
+    line: int
+    wrappers: str  # everything around the handler in the registration: chain, middleware arguments
+    router_lineage: List[str]
+    handler: Optional[FunctionNode]
+    end_line: int
+
+
+def handler_node(graph: CallGraph, path: str, language: str, argument: str) -> Optional[FunctionNode]:
+    """The function a router argument names (h.Login, requireAuth(listOrders) -> listOrders), preferring this file"""
+    for name in reversed(re.findall(r'(\w+)\s*(?![\w(])', argument)):
+        candidates = [n for n in graph.nodes.values() if n.name == name and n.language == language]
+        if candidates:
+            return min(candidates, key=lambda n: (n.file_path != path, os.path.dirname(n.file_path) != os.path.dirname(path)))
+    return None
+
+
+def router_lineage(router: str, parents: Dict[str, str]) -> List[str]:
+    lineage = [router]
+    while lineage[-1] in parents and parents[lineage[-1]] not in lineage:
+        lineage.append(parents[lineage[-1]])
+    return lineage
+
+
+def registrations(graph: CallGraph, path: str, ctx: SourceContext) -> Tuple[List[Registration], Set[str], Set[str], bool]:
+    """Route registrations in a file, routers with auth middleware installed, routers created here,
+    and whether the whole server is wrapped in auth middleware"""
+    found = []
+    parents = {m.group(1): m.group(2) for m in ROUTER_DERIVED.finditer(ctx.code)}
+    created = {m.group(1) for m in ROUTER_CREATED.finditer(ctx.code)} | {m.group(1) for m in JS_ROUTER_CREATED.finditer(ctx.code)}
+    created.add('http')  # DefaultServeMux
+    authenticated = {m.group(1) for m in MIDDLEWARE_USE.finditer(ctx.code) if AUTH_NAME.search(ctx.call_args(m.end() - 1))}
+    authenticated |= {m.group(1) for m in ROUTER_DERIVED.finditer(ctx.code) if AUTH_NAME.search(m.group(0).split('=', 1)[1])}
+    server_wrapped = any(AUTH_NAME.search(m.group(0)) for m in SERVER_WRAP.finditer(ctx.code))
+
+    for line, match in ctx.search(ROUTE_REGISTRATION):
+        router, chain, method, path_literal = match.groups()
+        route = ROUTE_PATH.match(path_literal)
+        if not route:
+            continue  # w.Header().Get("Accept"), params.get('id')
+        open_paren = ctx.code.index('(', match.end(3))
+        args = split_args(ctx.call_args(open_paren))
+        if len(args) < 2:
+            continue
+        verb = route.group(1) or ('ANY' if method in ANY_METHOD else 'WS' if method == 'ws' else method.upper())
+        inline = INLINE_HANDLER.match(args[-1])
+        found.append(Registration(
+            route=f"{verb} {route.group(2)}",
+            line=line,
+            # requireAuth(h.Login) wraps the handler; an inline handler's body is not a wrapper
+            wrappers=' '.join([chain] + args[1:-1] + ([] if inline else [re.sub(r'[\w.]+\W*$', '', args[-1].strip())])),
+            router_lineage=router_lineage(router, parents),
+            handler=None if inline else handler_node(graph, path, ctx.language, args[-1]),
+            end_line=ctx.line_of(open_paren + len(ctx.call_args(open_paren)) + 1)
+        ))
+    return found, authenticated, created, server_wrapped
+
+
+def decorator_routes(lines: List[str], node: FunctionNode) -> Tuple[List[str], Optional[str]]:
+    """Routes of a decorated (Flask, FastAPI, Spring) handler and the auth decorator on it, if any"""
+    routes, auth = [], None
+    for text in lines[max(0, node.start_line - 6):node.start_line - 1]:
+        match = DECORATOR.search(text)
+        if match:
+            kind, rest = (match.group(1), match.group(2)) if match.group(1) else (match.group(3), match.group(4))
+            path = next((s for s in STRING.findall(rest or '') if s.startswith('/')), '/')
+            methods = DECORATOR_METHODS.search(rest or '')
+            if kind in ('route', 'Request', 'api_route'):
+                verbs = STRING.findall(methods.group(1)) if methods and methods.group(1) else [methods.group(2)] if methods else []
+                verbs = [v.upper() for v in verbs] or (['ANY'] if kind == 'Request' else ['GET'])
+            else:
+                verbs = ['WS' if kind == 'websocket' else kind.upper()]
+            routes.extend(f"{verb} {path}" for verb in verbs)
+        elif text.strip().startswith('@') and AUTH_NAME.search(text):
+            auth = text.strip()
+    signature = lines[node.start_line - 1] if node.start_line <= len(lines) else ''
+    if not auth and re.search(r'Depends\s*\(\s*\w*(?:auth|current_user|user)', signature, re.IGNORECASE):
+        auth = re.search(r'Depends\s*\([^)]*\)', signature).group(0)
+    return routes, auth
+
+
+def handler_check(graph: CallGraph, node: FunctionNode) -> Optional[str]:
+    """Label of the handler, or a function it calls AUTH_DEPTH levels down, that authenticates the caller"""
+    frontier, seen = [node.key], {node.key}
+    for _ in range(AUTH_DEPTH):
+        frontier = [c.key for key in frontier for c in graph.callees(key) if c.key not in seen]
+        seen.update(frontier)
+    for key in [node.key] + sorted(seen - {node.key}):
+        source = function_source(graph.nodes[key])
+        if AUTHENTICATES.search(source) or checks_authorization(source):
+            return graph.nodes[key].label()
+    return None
+
+
+def grpc_entry_points(graph: CallGraph, path: str, ctx: SourceContext) -> List[EntryPoint]:
+    """The methods of each service registered on a gRPC server in the file"""
+    entries = []
+    for function in ctx.functions():
+        start, end = ctx.span(function)
+        interceptors = [ctx.call_args(m.end() - 1) for _, m in ctx.search(INTERCEPTOR_OPTION, start, end)]
+        interceptor = next((i.strip() for i in interceptors if AUTH_INTERCEPTOR.search(i)), None)
+        server_here = NEW_SERVER.search(function.body) is not None
+        for line, match in ctx.search(REGISTER_SERVICE, start, end):
+            args = split_args(ctx.call_args(match.end() - 1))
+            implementation = re.search(r'(\w+)\s*(?:\{|\()', args[1]) if len(args) > 1 else None
+            if not implementation:
+                continue
+            impl = re.sub(r'^New', '', implementation.group(1))
+            methods = [
+                n for n in graph.nodes.values()
+                if n.receiver and n.receiver.lower() == impl.lower() and n.name[:1].isupper() and n.language == 'go'
+            ]
+            if not methods:
+                # the implementation lives outside the project (generated code, another module)
+                entries.append(EntryPoint(
+                    kind="grpc", handler=f"{impl} (methods not found)", file_path=path, line_number=line, end_line=line, routes=[f"/{match.group(1)}/*"],
+                    auth="authenticated" if interceptor else "unauthenticated" if server_here else "unknown",
+                    auth_evidence=f"interceptor {interceptor[:60]}" if interceptor else None if server_here else "server created elsewhere"
+                ))
+            for method in sorted(methods, key=lambda n: (n.file_path, n.start_line)):
+                entry = EntryPoint(
+                    kind="grpc", handler=method.label(), file_path=method.file_path, line_number=method.start_line,
+                    routes=[f"/{match.group(1)}/{method.name}"], key=method.key
+                )
+                in_handler = handler_check(graph, method)
+                if interceptor:
+                    entry.auth, entry.auth_evidence = "authenticated", f"interceptor {interceptor[:60]}"
+                elif HANDLER_AUTH.search(function_source(method)) or in_handler:
+                    entry.auth, entry.auth_evidence = "authenticated", f"checked in {in_handler or method.label()}"
+                elif server_here:
+                    entry.auth, entry.auth_evidence = "unauthenticated", f"registered at {os.path.basename(path)}:{line} on a server without an auth interceptor"
+                entries.append(entry)
+    return entries
+
+
+def build_inventory(graph: CallGraph, files: Sequence[str], vulnerabilities: Sequence[Dict[str, Any]] = ()) -> List[EntryPoint]:
+    """Every entry point of the project with its routes, auth status, and the findings (report dicts) it reaches"""
+    entries: Dict[str, EntryPoint] = {}
+    inline: List[EntryPoint] = []  # inline handlers and gRPC services without visible methods: findings by line span
+    route_auth: Dict[str, List[Tuple[str, Optional[str]]]] = {}  # node key -> (status, evidence) per registration
+
+    for path in files:
+        path = os.path.abspath(path)
+        code = read_source(path)
+        if not code:
+            continue
+        ctx = SourceContext(code, path)
+        if ctx.language == 'go' and 'google.golang.org/grpc' in code:
+            for entry in grpc_entry_points(graph, path, ctx):
+                if entry.key:
+                    entries.setdefault(entry.key, entry)
+                else:
+                    inline.append(entry)
+        found, authenticated, created, server_wrapped = registrations(graph, path, ctx)
+        for registration in found:
+            wrapper = AUTH_NAME.search(registration.wrappers)
+            router = next((r for r in registration.router_lineage if r in authenticated), None)
+            if wrapper or router or server_wrapped:
+                status = ("authenticated", f"middleware {wrapper.group(0)}" if wrapper else
+                          f"middleware on router {router}" if router else "server wrapped in auth middleware")
+            elif registration.router_lineage[-1] in created:
+                status = ("unauthenticated", None)
+            else:
+                status = ("unknown", f"router {registration.router_lineage[-1]} is configured in another file")
+
+            node = registration.handler
+            if node is None:
+                entry = EntryPoint(
+                    kind="websocket" if registration.route.startswith("WS ") else "http",
+                    handler="(inline)", file_path=path, line_number=registration.line, routes=[registration.route],
+                    end_line=registration.end_line
+                )
+                entry.auth, entry.auth_evidence = status
+                inline.append(entry)
+                continue
+            entry = entries.setdefault(node.key, EntryPoint(
+                kind=graph.entry_points.get(node.key, "http"), handler=node.label(), file_path=node.file_path,
+                line_number=node.start_line, key=node.key
+            ))
+            entry.routes.append(registration.route)
+            route_auth.setdefault(node.key, []).append(status)
+
+    hooked: Dict[str, bool] = {}  # file -> has a before_request hook that authenticates
+    for key, kind in graph.entry_points.items():
+        node = graph.nodes[key]
+        entry = entries.setdefault(key, EntryPoint(
+            kind=kind, handler=node.label(), file_path=node.file_path, line_number=node.start_line, key=key
+        ))
+        if entry.kind == "grpc":
+            continue
+        lines = read_source(node.file_path).split('\n')
+        routes, decorator = decorator_routes(lines, node)
+        entry.routes.extend(r for r in routes if r not in entry.routes)
+        if node.file_path not in hooked:
+            hooked[node.file_path] = any(
+                AUTHENTICATES.search(function_source(n)) or AUTH_NAME.search(n.name)
+                for n in graph.nodes.values()
+                if n.file_path == node.file_path and HOOK.search('\n'.join(lines[max(0, n.start_line - 3):n.start_line - 1]))
+            )
+        statuses = route_auth.get(key, [])
+        in_handler = handler_check(graph, node)
+        if decorator:
+            entry.auth, entry.auth_evidence = "authenticated", f"decorator {decorator}"
+        elif hooked[node.file_path]:
+            entry.auth, entry.auth_evidence = "authenticated", "before_request hook"
+        elif in_handler:
+            entry.auth, entry.auth_evidence = "authenticated", f"checked in {in_handler}"
+        elif statuses and all(s == "authenticated" for s, _ in statuses):
+            entry.auth, entry.auth_evidence = "authenticated", statuses[0][1]
+        elif any(s == "unauthenticated" for s, _ in statuses) or (routes and not statuses):
+            entry.auth, entry.auth_evidence = "unauthenticated", None
+        elif statuses:
+            entry.auth, entry.auth_evidence = next((s, e) for s, e in statuses if s != "authenticated")
+        else:
+            entry.auth_evidence = "no route registration found"
+
+    reachable = {key: set(graph.reach([key])) for key in entries}
+    for vuln in sorted(vulnerabilities, key=lambda v: v.get("vuln_id", "")):
+        if not vuln.get("file_path") or not vuln.get("line_number"):
+            continue
+        summary = {"vuln_id": vuln.get("vuln_id"), "vuln_type": vuln.get("vuln_type"), "severity": vuln.get("severity")}
+        node = graph.function_at(vuln["file_path"], vuln["line_number"])
+        for key, entry in entries.items():
+            if node and node.key in reachable[key]:
+                entry.findings.append(summary)
+        for entry in inline:
+            if os.path.abspath(vuln["file_path"]) == entry.file_path and entry.line_number <= vuln["line_number"] <= entry.end_line:
+                entry.findings.append(summary)
+
+    return sorted(list(entries.values()) + inline, key=lambda e: (e.file_path, e.line_number, e.handler))
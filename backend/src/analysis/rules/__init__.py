@@ -0,0 +1,17 @@
+"""
+Static rules - Built-in pattern detectors for common vulnerability classes
+"""
+
+from .base import Rule, SourceContext, StaticFinding, TraceStep, get_rule, get_rules, register_rule, run_rules
+from . import binding, cookies, cors, dos, errors, grpc, headers, idor, injection, kotlin, logs, memory, nosql, panics, permissions, php, prompts, ratelimit, secrets, tempfiles, timeouts, toctou, uploads
+
+__all__ = [
+    'Rule',
+    'SourceContext',
+    'StaticFinding',
+    'TraceStep',
+    'get_rule',
+    'get_rules',
+    'register_rule',
+    'run_rules'
+]
@@ -0,0 +1,332 @@
+"""
+Rule Base - Shared types for the built-in static detectors
+Rules are regex/heuristic based and run before the LLM agents
+"""
+
+import os
+import re
+import time
+from dataclasses import dataclass, field
+from typing import Any, Dict, Iterator, List, Optional, Tuple
+
+from ..parser import SourceMember, get_parser
+from .taint import strip_strings
+
+CALL_NAME = re.compile(r'\b(\w+)\s*\(')
+MAX_MODULE_FILES = 2000
+
+# trivial: a flag or config value; localized: a change inside one function; architectural: a design change
+FIX_COMPLEXITIES = ('trivial', 'localized', 'architectural')
+
+
+@dataclass
+class TraceStep:
+    kind: str  # source, propagation, or sink
+    file_path: str
+    line_number: int
+    code: str
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "kind": self.kind,
+            "file_path": self.file_path,
+            "line_number": self.line_number,
+            "code": self.code
+        }
+
+
+@dataclass
+class StaticFinding:
+    rule_id: str
+    vuln_type: str
+    severity: str
+    description: str
+    file_path: str
+    line_number: int
+    code_snippet: str
+    cwe_id: Optional[str] = None
+    confidence: float = 0.8
+    remediation: Optional[str] = None
+    trace: List[TraceStep] = field(default_factory=list)
+    column: Optional[int] = None
+    end_column: Optional[int] = None
+    needs_review: bool = False
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "rule_id": self.rule_id,
+            "vuln_type": self.vuln_type,
+            "severity": self.severity,
+            "description": self.description,
+            "file_path": self.file_path,
+            "line_number": self.line_number,
+            "code_snippet": self.code_snippet,
+            "cwe_id": self.cwe_id,
+            "confidence": self.confidence,
+            "remediation": self.remediation,
+            "trace": [step.to_dict() for step in self.trace],
+            "column": self.column,
+            "end_column": self.end_column,
+            "needs_review": self.needs_review
+        }
+
+
+_source_cache: Dict[Tuple[str, float, int], str] = {}
+
+
+def read_source(path: str) -> str:
+    try:
+        stat = os.stat(path)
+        key = (path, stat.st_mtime, stat.st_size)
+        if key not in _source_cache:
+            with open(path, 'r', encoding='utf-8', errors='ignore') as f:
+                _source_cache[key] = f.read()
+        return _source_cache[key]
+    except OSError:
+        return ""
+
+
+class SourceContext:
+
+    def __init__(self, code: str, file_path: str = "<analyzed_code>", options: Optional[Dict[str, Any]] = None):
+        self.code = code
+        self.file_path = file_path
+        self.lines = code.split('\n')
+        self.options = options or {}
+
+        parser = get_parser()
+        self.language = parser.detect_language(file_path, code)
+        self.members: List[SourceMember] = parser.parse(code, file_path)
+        self._types = None
+        self._module_sources: Optional[List[str]] = None
+
+    @property
+    def types(self):
+        """Package-level Go type information (GoTypes), built on first use"""
+        if self._types is None and self.language == 'go':
+            from ..gotypes import GoTypes
+            self._types = GoTypes(self.code, self.file_path)
+        return self._types
+
+    def module_sources(self) -> List[str]:
+        """The Go sources of the module the file belongs to, up to its go.mod (the file alone outside a module);
+        this file comes first, as analyzed"""
+        if self._module_sources is None:
+            self._module_sources = [self.code] + self._module_files()
+        return self._module_sources
+
+    def _module_files(self) -> List[str]:
+        if not os.path.isfile(self.file_path):
+            return []
+        root = os.path.dirname(os.path.abspath(self.file_path))
+        while not os.path.isfile(os.path.join(root, 'go.mod')):
+            parent = os.path.dirname(root)
+            if parent == root:
+                return []
+            root = parent
+
+        sources = []
+        current = os.path.abspath(self.file_path)
+        for dirpath, dirnames, filenames in os.walk(root):
+            dirnames[:] = [d for d in dirnames if d not in ('vendor', 'testdata', 'node_modules') and not d.startswith('.')]
+            for name in filenames:
+                path = os.path.join(dirpath, name)
+                if name.endswith('.go') and not name.endswith('_test.go') and path != current:
+                    sources.append(read_source(path))
+                    if len(sources) >= MAX_MODULE_FILES:
+                        return sources
+        return sources
+
+    def imports_any(self, paths: Tuple[str, ...]) -> bool:
+        """Whether the Go file imports one of paths or a package below it"""
+        imported = self.types.imports.values() if self.types else []
+        return any(path == p or path.startswith(p + '/') for path in imported for p in paths)
+
+    def line_of(self, offset: int) -> int:
+        return self.code.count('\n', 0, offset) + 1
+
+    def line(self, line_number: int) -> str:
+        if 1 <= line_number <= len(self.lines):
+            return self.lines[line_number - 1]
+        return ""
+
+    def columns(self, line_number: int, match: Optional[re.Match] = None) -> Tuple[int, int]:
+        """1-based [start, end) columns of match on line_number, or of the whole statement"""
+        text = self.line(line_number)
+        first = len(text) - len(text.lstrip()) + 1
+        last = len(text.rstrip()) + 1
+        if not match or self.line_of(match.start()) != line_number:
+            return first, max(first, last)
+
+        line_start = self.code.rfind('\n', 0, match.start()) + 1
+        start = max(first, match.start() - line_start + 1)
+        end = min(last, match.end() - line_start + 1)
+        return start, max(start, end)
+
+    def search(self, pattern: re.Pattern, start: int = 0, end: Optional[int] = None) -> Iterator[Tuple[int, re.Match]]:
+        end = len(self.code) if end is None else end
+        for match in pattern.finditer(self.code, start, end):
+            yield self.line_of(match.start()), match
+
+    def block_end(self, offset: int) -> int:
+        return get_parser()._find_brace_block_end(self.code, offset)
+
+    def call_args(self, open_paren: int) -> str:
+        depth = 0
+        for pos in range(open_paren, len(self.code)):
+            char = self.code[pos]
+            if char == '(':
+                depth += 1
+            elif char == ')':
+                depth -= 1
+                if depth == 0:
+                    return self.code[open_paren + 1:pos]
+        return self.code[open_paren + 1:]
+
+    def functions(self) -> List[SourceMember]:
+        return [m for m in self.members if m.member_type == 'function']
+
+    def span(self, member: SourceMember) -> Tuple[int, int]:
+        start = sum(len(line) + 1 for line in self.lines[:member.start_line - 1])
+        return start, start + len(member.body)
+
+    def called_functions(self, member: SourceMember) -> List[SourceMember]:
+        """member and the functions of this file it calls, directly or through each other"""
+        by_name = {f.name: f for f in self.functions()}
+        called, pending = [member], [member]
+        while pending:
+            body = pending.pop().body.split('\n', 1)[-1]  # the first line is the declaration
+            for call in CALL_NAME.finditer(strip_strings(body)):
+                callee = by_name.get(call.group(1))
+                if callee and callee not in called:
+                    called.append(callee)
+                    pending.append(callee)
+        return called
+
+    def enclosing_function(self, line_number: int) -> Optional[SourceMember]:
+        for member in self.functions():
+            if member.start_line <= line_number <= member.end_line:
+                return member
+        return None
+
+
+class Rule:
+    rule_id: str = ""
+    name: str = ""
+    vuln_type: str = ""
+    severity: str = "medium"
+    cwe_id: Optional[str] = None
+    description: str = ""
+    remediation: str = ""
+    example: str = ""
+    languages: Tuple[str, ...] = ('go',)
+    # how much work the usual fix is (see FIX_COMPLEXITIES); the triage agent can override it per finding
+    fix_complexity: str = "localized"
+    # heuristic rules whose findings always need a human to confirm, whatever their confidence
+    needs_review: bool = False
+
+    def applies_to(self, ctx: SourceContext) -> bool:
+        return ctx.language in self.languages
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        raise NotImplementedError
+
+    def finding(
+        self,
+        ctx: SourceContext,
+        line_number: int,
+        description: Optional[str] = None,
+        severity: Optional[str] = None,
+        confidence: float = 0.8,
+        trace: Optional[List[int]] = None,
+        match: Optional[re.Match] = None
+    ) -> StaticFinding:
+        steps = []
+        if trace:
+            hops = [n for n in trace if n != line_number] + [line_number]
+            for i, hop in enumerate(hops):
+                kind = "sink" if i == len(hops) - 1 else "source" if i == 0 else "propagation"
+                steps.append(TraceStep(kind, ctx.file_path, hop, ctx.line(hop).strip()))
+        column, end_column = ctx.columns(line_number, match)
+
+        return StaticFinding(
+            rule_id=self.rule_id,
+            vuln_type=self.vuln_type,
+            severity=severity or self.severity,
+            description=description or self.description,
+            file_path=ctx.file_path,
+            line_number=line_number,
+            code_snippet=ctx.line(line_number).strip(),
+            cwe_id=self.cwe_id,
+            confidence=confidence,
+            remediation=self.remediation or None,
+            trace=steps,
+            column=column,
+            end_column=end_column,
+            needs_review=self.needs_review
+        )
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "rule_id": self.rule_id,
+            "name": self.name,
+            "vuln_type": self.vuln_type,
+            "severity": self.severity,
+            "cwe_id": self.cwe_id,
+            "description": self.description,
+            "remediation": self.remediation,
+            "example": self.example,
+            "languages": list(self.languages),
+            "fix_complexity": self.fix_complexity,
+            "needs_review": self.needs_review
+        }
+
+
+_RULES: Dict[str, Rule] = {}
+
+
+def register_rule(cls):
+    _RULES[cls.rule_id] = cls()
+    return cls
+
+
+def get_rules() -> List[Rule]:
+    return list(_RULES.values())
+
+
+def get_rule(rule_id: str) -> Optional[Rule]:
+    return _RULES.get(rule_id)
+
+
+def run_rules(
+    code: str,
+    file_path: str = "<analyzed_code>",
+    rule_ids: Optional[List[str]] = None,
+    options: Optional[Dict[str, Any]] = None,
+    extra_rules: Optional[List[Rule]] = None
+) -> List[StaticFinding]:
+    """Run the built-in rules, plus extra_rules (a project's rule packs), over one file; secret values in
+    snippets and traces are masked before the findings leave. Under scanner scan --profile each rule's time is
+    recorded; lazily built context (Go types, module sources) counts toward the first rule that asks for it."""
+    from ...profiling import current_profile, stage
+    from ...redaction import redact_findings
+
+    profile = current_profile()
+    with stage("parse"):
+        ctx = SourceContext(code, file_path, options)
+    findings = []
+
+    for rule in get_rules() + list(extra_rules or []):
+        if rule_ids is not None and rule.rule_id not in rule_ids:
+            continue
+        if not rule.applies_to(ctx):
+            continue
+        started = time.perf_counter()
+        found = rule.check(ctx)
+        if profile:
+            profile.add_rule(rule.rule_id, time.perf_counter() - started, len(found))
+        findings.extend(found)
+
+    redact_findings(findings, code)
+    findings.sort(key=lambda f: (f.line_number, f.rule_id))
+    return findings
@@ -0,0 +1,102 @@
+"""
+Binding rules - Request bodies bound straight into structs with privilege fields that are then saved
+The bound type is resolved through GoTypes, so the model can live in another file of the package; fields
+tagged "-" cannot be bound, and a field reset after binding (user.IsAdmin = false) no longer counts.
+"""
+
+import re
+from typing import List, Optional, Set
+
+from .base import Rule, SourceContext, StaticFinding, register_rule
+from .panics import is_handler
+from .taint import split_args
+
+# decoders of the request body or form into a destination
+REQUEST_BINDING = re.compile(
+    r'\bjson\.NewDecoder\s*\(\s*[\w.]*Body\s*\)\.Decode\s*\('
+    r'|\bjson\.Unmarshal\s*\('
+    r'|\b(?:c|ctx)\.(?:ShouldBind\w*|MustBind\w*|Bind\w*|BodyParser)\s*\('
+    r'|\b(?:schema\.NewDecoder\(\)|\w*[dD]ecoder|dec)\.Decode\s*\((?=\s*&)'
+)
+PRIVILEGE_FIELD = re.compile(
+    r'^(?:Is)?(?:Admin|Superuser|SuperUser|Staff|Moderator|Verified|EmailVerified|Approved|Banned|Internal)$'
+    r'|^(?:Role|Roles|Permissions?|Scopes?|Groups|AccessLevel|AccountType|Plan|Tier)$'
+    r'|^(?:Balance|Credits?|Quota|Discount|Price)$'
+    r'|^(?:OwnerID|OwnerId|TenantID|TenantId|OrgID|OrgId|UserID|UserId)$'
+)
+PERSIST_CALL = re.compile(
+    r'\.(?:Create|Save|Updates|Update\w*|Insert\w*|Upsert\w*|Replace\w*|FirstOrCreate|Persist\w*|Store\w*)\s*\('
+)
+FIELD_ALLOWLIST = re.compile(r'\.(?:Select|Omit)\s*\(')
+
+
+@register_rule
+class MassAssignmentRule(Rule):
+    rule_id = "mass-assignment"
+    name = "Request bound into a model with privilege fields"
+    vuln_type = "Mass Assignment"
+    severity = "high"
+    cwe_id = "CWE-915"
+    description = ("The request body is decoded straight into a model that has privilege fields (IsAdmin, Role, Balance, "
+                   "...) and the model is then saved, so a client can set those fields by adding them to the request")
+    remediation = ("Decode into a request DTO that has only the fields the client may set and copy them onto the model, "
+                   "tag privilege fields json:\"-\", or save with a field allowlist (GORM Select)")
+    example = 'var user User\njson.NewDecoder(r.Body).Decode(&user)\ndb.Create(&user)'
+
+    def applies_to(self, ctx: SourceContext) -> bool:
+        return super().applies_to(ctx) and ctx.types is not None
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+        for function in ctx.functions():
+            if not is_handler(function):
+                continue
+            start, end = ctx.span(function)
+            for line, match in ctx.search(REQUEST_BINDING, start, end):
+                args = split_args(ctx.call_args(match.end() - 1))
+                index = 1 if 'Unmarshal' in match.group(0) else 0  # json.Unmarshal(data, &v)
+                if len(args) <= index:
+                    continue
+                variable = args[index].lstrip('&').strip()
+                if not re.fullmatch(r'\w+', variable):
+                    continue
+                type_name = ctx.types.type_of(variable, match.start())
+                fields = self.privilege_fields(ctx, type_name) if type_name else []
+                if not fields:
+                    continue
+
+                after = ctx.code[match.end():end]
+                fields = [f for f in fields if not re.search(rf'\b{variable}\.{f}\s*=[^=]', after)]
+                persisted = self.persisted(ctx, variable, match.end(), end)
+                if not fields or persisted is None:
+                    continue
+                type_label = type_name.rsplit('/', 1)[-1]
+                findings.append(self.finding(
+                    ctx, line, match=match, trace=[line, persisted], confidence=0.75,
+                    description=f"{function.name} binds the request into {variable} ({type_label}) and saves it on line "
+                                f"{persisted}: the client can set {', '.join(fields)}"
+                ))
+        return findings
+
+    def privilege_fields(self, ctx: SourceContext, type_name: str, seen: Optional[Set[str]] = None) -> List[str]:
+        """Bindable privilege fields of a struct, including embedded ones"""
+        seen = seen or set()
+        decl = ctx.types.types.get(type_name)
+        if not decl or decl.kind != "struct" or type_name in seen:
+            return []
+        seen.add(type_name)
+        fields = [
+            name for name, tag in decl.tags.items()
+            if PRIVILEGE_FIELD.match(name) and not re.search(r'\b(?:json|form|schema|query):"-"', tag)
+        ]
+        for embedded in decl.embedded:
+            fields += self.privilege_fields(ctx, embedded, seen)
+        return fields
+
+    def persisted(self, ctx: SourceContext, variable: str, start: int, end: int) -> Optional[int]:
+        """Line of the first save of variable after the binding, unless it goes through a field allowlist"""
+        for line, match in ctx.search(PERSIST_CALL, start, end):
+            args = [a.strip().lstrip('&*') for a in split_args(ctx.call_args(match.end() - 1))]
+            if variable in args and not FIELD_ALLOWLIST.search(ctx.line(line)):
+                return line
+        return None
@@ -0,0 +1,199 @@
+"""
+Cookie and session rules - Missing cookie flags, session tokens in URLs, and sessions kept across login
+Covers net/http (and echo, which takes an *http.Cookie), gin, fiber, gorilla/sessions, gin-contrib/sessions,
+and scs
+"""
+
+import re
+from typing import List, Optional, Tuple
+
+from .base import Rule, SourceContext, StaticFinding, register_rule
+from .taint import split_args
+
+COOKIE_LITERAL = re.compile(r'(?:(\w+)\s*:?=\s*)?&?\b(http\.Cookie|fiber\.Cookie|sessions\.Options)\s*\{')
+GIN_SET_COOKIE = re.compile(r'\b(?:c|ctx)\.SetCookie\s*\(')
+GIN_SAME_SITE = re.compile(r'\b(?:c|ctx)\.SetSameSite\s*\(')
+FLAG_FIELDS = {
+    "Secure": re.compile(r'\bSecure\s*:\s*(?!false\b)\S'),
+    "HttpOnly": re.compile(r'\bH(?:ttp|TTP)Only\s*:\s*(?!false\b)\S'),
+    "SameSite": re.compile(r'\bSameSite\s*:\s*(?!""|http\.SameSiteDefaultMode\b)\S'),
+}
+# deleting a cookie needs no flags
+EXPIRED = re.compile(r'\bMaxAge\s*:\s*-1\b|\bExpires\s*:\s*time\.Unix\(\s*0\s*,\s*0\s*\)')
+SAME_SITE_NONE = re.compile(r'\bSameSite\s*:\s*(?:http\.SameSiteNoneMode|"[Nn]one")')
+SESSION_COOKIE_NAME = re.compile(r'sess|sid|auth|token|jwt|login|remember|csrf|xsrf|identity|user', re.IGNORECASE)
+DEFAULT_SESSION_STORE = re.compile(r'\bsessions\.New(?:CookieStore|FilesystemStore)\s*\(')
+STORE_OPTIONS = re.compile(r'\.Options\s*(?:=|\.\s*(?:Secure|HttpOnly)\s*=)|\bsessions\.Options\s*\{')
+
+SESSION_PARAMETER = (
+    r'(?:session|sessionid|session_id|sessid|sid|jsessionid|phpsessid|auth_token|authtoken|access_token|accesstoken'
+    r'|jwt|id_token|refresh_token)'
+)
+QUERY_READ = re.compile(
+    r'(?:\.URL\.Query\(\)\.Get|\.FormValue|\b(?:c|ctx)\.(?:Query|DefaultQuery|QueryParam))\s*\(\s*"' + SESSION_PARAMETER + r'"',
+    re.IGNORECASE
+)
+URL_WRITE = re.compile(r'"[^"\n]*[?&]' + SESSION_PARAMETER + r'=[^"\n]*"\s*\+|"[^"\n]*[?&]' + SESSION_PARAMETER + r'=%[sv]', re.IGNORECASE)
+URL_VALUES_SET = re.compile(r'\.(?:Set|Add)\s*\(\s*"' + SESSION_PARAMETER + r'"', re.IGNORECASE)
+
+LOGIN_FUNCTION = re.compile(r'log_?in|sign_?in|authenticate|callback|verify_?otp|verify_?mfa', re.IGNORECASE)
+AUTH_SESSION_WRITE = re.compile(
+    r'\.Values\[\s*"(?:user|uid|account|authenticated|logged|principal|subject)\w*"\s*\]\s*='
+    r'|\.(?:Put|Set)\s*\(\s*(?:[\w.()]+\s*,\s*)?"(?:user|uid|account|authenticated|logged|principal|subject)\w*"',
+    re.IGNORECASE
+)
+SESSION_REGENERATION = re.compile(
+    r'\.RenewToken\s*\(|\.Regenerate\w*\s*\(|\.ID\s*=\s*""|MaxAge\s*=\s*-1|\.Destroy\s*\(|\.Clear\s*\(\s*\)|\.New\s*\(\s*\w+\s*,\s*"'
+)
+CLIENT_SIDE_STORE = re.compile(r'\bsessions\.NewCookieStore\s*\(|\bcookie\.NewStore\s*\(|\bsecurecookie\.')
+SESSION_IMPORTS = ('github.com/gorilla/sessions', 'github.com/gin-contrib/sessions', 'github.com/alexedwards/scs')
+
+
+def missing_flags(literal: str, assigned: str = "") -> List[str]:
+    """Flags a cookie literal (plus later field assignments) does not turn on"""
+    missing = [flag for flag, pattern in FLAG_FIELDS.items() if not pattern.search(literal)]
+    return [flag for flag in missing if not re.search(rf'\.(?:{flag}|{flag.replace("Http", "HTTP")})\s*=\s*(?!false\b)\S', assigned)]
+
+
+def cookie_severity(name: Optional[str], missing: List[str]) -> Tuple[str, float]:
+    if missing == ["SameSite"]:
+        return "low", 0.6
+    if name is None or SESSION_COOKIE_NAME.search(name):
+        return "medium", 0.8
+    return "low", 0.6
+
+
+@register_rule
+class InsecureCookieRule(Rule):
+    rule_id = "insecure-cookie"
+    name = "Cookie without Secure, HttpOnly, or SameSite"
+    vuln_type = "Insecure Cookie"
+    severity = "medium"
+    fix_complexity = "trivial"
+    cwe_id = "CWE-614"
+    description = "A cookie is set without the Secure, HttpOnly, or SameSite attribute"
+    remediation = ("Set Secure (HTTPS only), HttpOnly (no script access), and SameSite (Lax, or Strict for session "
+                   "cookies) on every cookie that carries a session or token")
+    example = 'http.SetCookie(w, &http.Cookie{Name: "session", Value: token})'
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+
+        for line, match in ctx.search(COOKIE_LITERAL):
+            if ctx.code[match.start(2) - 1] in '*]':
+                continue  # []*http.Cookie{...}
+            literal = ctx.code[match.start():ctx.block_end(match.end() - 1)]
+            if EXPIRED.search(literal):
+                continue
+            variable, kind = match.groups()
+            function = ctx.enclosing_function(line)
+            assigned = ''
+            if variable and function:
+                assigned = '\n'.join(re.findall(rf'\b{re.escape(variable)}\.\w+\s*=[^\n]*', function.body))
+            missing = missing_flags(literal, assigned)
+            name = re.search(r'\bName\s*:\s*"([^"]*)"', literal)
+            if SAME_SITE_NONE.search(literal) and "Secure" in missing:
+                findings.append(self.finding(
+                    ctx, line, match=match, confidence=0.9,
+                    description=f"{kind} uses SameSite=None without Secure; browsers reject it, and without Secure it would be sent over HTTP"
+                ))
+                continue
+            if not missing:
+                continue
+            label = f"{kind} {name.group(1)!r}" if name else kind
+            severity, confidence = cookie_severity(name.group(1) if name else None, missing)
+            findings.append(self.finding(
+                ctx, line, match=match, severity=severity, confidence=confidence,
+                description=f"{label} is set without {', '.join(missing)}"
+            ))
+
+        for line, match in ctx.search(GIN_SET_COOKIE):
+            # gin: SetCookie(name, value, maxAge, path, domain, secure, httpOnly)
+            args = split_args(ctx.call_args(match.end() - 1))
+            if len(args) != 7:
+                continue
+            missing = [flag for flag, arg in (("Secure", args[5]), ("HttpOnly", args[6])) if arg.strip() == "false"]
+            function = ctx.enclosing_function(line)
+            if not (function and GIN_SAME_SITE.search(function.body)):
+                missing.append("SameSite")
+            if not missing:
+                continue
+            name = args[0].strip().strip('"')
+            severity, confidence = cookie_severity(name, missing)
+            findings.append(self.finding(
+                ctx, line, match=match, severity=severity, confidence=confidence,
+                description=f"Cookie {name} is set without {', '.join(missing)}"
+            ))
+
+        if not STORE_OPTIONS.search(ctx.code):
+            for line, match in ctx.search(DEFAULT_SESSION_STORE):
+                findings.append(self.finding(
+                    ctx, line, match=match,
+                    description="Session store keeps gorilla/sessions' default cookie options: no Secure, HttpOnly, or SameSite"
+                ))
+
+        return findings
+
+
+@register_rule
+class SessionTokenInURLRule(Rule):
+    rule_id = "session-token-in-url"
+    name = "Session token in a URL parameter"
+    vuln_type = "Session Token in URL"
+    severity = "medium"
+    cwe_id = "CWE-598"
+    description = "A session identifier or access token travels in the URL, where it is logged, cached, kept in history, and leaked through Referer"
+    remediation = ("Carry session identifiers in a Secure, HttpOnly cookie and access tokens in the Authorization header; for "
+                   "WebSocket upgrades, exchange a short-lived single-use ticket instead of the session token")
+    example = 'sid := r.URL.Query().Get("sessionid")'
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+        reported = set()
+        for pattern, what in ((QUERY_READ, "read from the query string"), (URL_WRITE, "written into a URL"),
+                              (URL_VALUES_SET, "added to URL parameters")):
+            for line, match in ctx.search(pattern):
+                if line in reported:
+                    continue
+                if pattern is URL_VALUES_SET and not re.search(r'url\.Values|\.Query\(\)', ctx.code):
+                    continue
+                reported.add(line)
+                parameter = re.search(SESSION_PARAMETER, match.group(0), re.IGNORECASE).group(0)
+                findings.append(self.finding(
+                    ctx, line, match=match,
+                    description=f"Session token parameter {parameter!r} is {what}"
+                ))
+        return findings
+
+
+@register_rule
+class SessionFixationRule(Rule):
+    rule_id = "session-fixation"
+    name = "Session not regenerated at login"
+    vuln_type = "Session Fixation"
+    severity = "medium"
+    cwe_id = "CWE-384"
+    description = "A login handler marks the existing session as authenticated without issuing a new session identifier"
+    remediation = ("Issue a new session identifier when privileges change: scs RenewToken, or with gorilla/sessions expire "
+                   "the old session (MaxAge = -1) and save a new one from store.New before setting the user")
+    example = 'session, _ := store.Get(r, "app")\nsession.Values["user_id"] = user.ID\nsession.Save(r, w)'
+
+    def applies_to(self, ctx: SourceContext) -> bool:
+        return super().applies_to(ctx) and ctx.imports_any(SESSION_IMPORTS)
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+        if CLIENT_SIDE_STORE.search(ctx.code):
+            return findings  # the whole session lives in the cookie, which changes with its contents
+
+        for function in ctx.functions():
+            if not LOGIN_FUNCTION.search(function.name) or SESSION_REGENERATION.search(function.body):
+                continue
+            start, end = ctx.span(function)
+            for line, match in ctx.search(AUTH_SESSION_WRITE, start, end):
+                findings.append(self.finding(
+                    ctx, line, match=match, confidence=0.6,
+                    description=f"{function.name} stores the authenticated user in the existing session without regenerating its identifier"
+                ))
+                break
+        return findings
@@ -0,0 +1,188 @@
+"""
+CORS / WebSocket rules - Permissive cross-origin configuration, and WebSocket upgrades without authentication
+"""
+
+import re
+from typing import List
+
+from ..parser import SourceMember
+from .base import Rule, SourceContext, StaticFinding, register_rule
+from .idor import AUTHORIZATION_CALL
+from .ratelimit import ROUTE_REGISTRATION, SERVER_WRAP
+from .taint import strip_strings
+
+
+CHECK_ORIGIN_ALWAYS_TRUE = re.compile(
+    r'CheckOrigin\s*[:=]\s*func\s*\([^)]*\)\s*bool\s*\{(?:\s*//[^\n]*)*\s*return\s+true\s*;?\s*\}'
+)
+
+CORS_CONFIG_LITERAL = re.compile(r'\bcors\.(?:Config|Options)\s*\{')
+WILDCARD_ORIGIN = re.compile(
+    r'AllowAllOrigins\s*:\s*true'
+    r'|Allow(?:ed)?Origins\s*:\s*\[\]string\s*\{\s*"\*"\s*\}'
+    r'|AllowOriginFunc\s*:\s*func\s*\([^)]*\)\s*bool\s*\{\s*return\s+true\s*\}'
+)
+ALLOW_CREDENTIALS = re.compile(r'AllowCredentials\s*:\s*true')
+
+HEADER_ALLOW_ORIGIN = re.compile(
+    r'Header\(\)\.(?:Set|Add)\(\s*"Access-Control-Allow-Origin"\s*,\s*'
+    r'("\*"|\w+\.Header\.Get\(\s*"Origin"\s*\))'
+)
+HEADER_ALLOW_CREDENTIALS = re.compile(
+    r'Header\(\)\.(?:Set|Add)\(\s*"Access-Control-Allow-Credentials"\s*,\s*"true"\s*\)'
+)
+
+# gorilla/websocket (also under gin's c.Writer, c.Request) and nhooyr.io/websocket
+WS_UPGRADE = re.compile(r'\.Upgrade\s*\(\s*[\w.]+\s*,\s*[\w.]+|\bwebsocket\.Accept\s*\(\s*[\w.]+\s*,')
+WS_MESSAGE_READ = re.compile(r'\.(?:ReadJSON|ReadMessage|NextReader)\s*\(|\bwsjson\.Read\s*\(')
+# the message picks the operation: switch msg.Type, switch m["action"], handlers[msg.Command]
+MESSAGE_FIELD = r'(?:Type|Action|Command|Cmd|Op|Method|Kind|Event)'
+MESSAGE_DISPATCH = re.compile(
+    rf'\bswitch\s+\w+(?:\.\w+)*\.{MESSAGE_FIELD}\s*\{{'
+    r'|\bswitch\s+\w+\s*\[\s*"(?:type|action|command|cmd|op|method|kind|event)"\s*\]'
+    rf'|\w+\s*\[\s*\w+\.{MESSAGE_FIELD}\s*\]'
+)
+PRIVILEGED_OPERATIONS = {
+    "run commands": re.compile(r'\bexec\.Command(?:Context)?\s*\('),
+    "query the database": re.compile(r'\b\w*(?:db|DB|Db|tx|Tx|pool|Pool|store|Store)\.(?:Query|QueryRow|Exec|Prepare)(?:Context)?\s*\('),
+    "read or write files": re.compile(
+        r'\b(?:os|ioutil)\.(?:ReadFile|WriteFile|Open|OpenFile|Create|Remove|RemoveAll|Rename|ReadDir)\s*\('
+    ),
+}
+# token and session checks in the handler before it upgrades
+WS_AUTH_CHECK = re.compile(
+    r'\.Cookie\s*\(|\.BasicAuth\s*\(|\bjwt\.Parse\w*\s*\(|\bsessions\.Default\s*\('
+    r'|\.Header\.Get\s*\(\s*"(?:Authorization|Sec-WebSocket-Protocol|X-[\w-]*(?:Token|Key|Auth)[\w-]*)"'
+    r'|\.(?:URL\.Query\(\)\.Get|FormValue)\s*\(\s*"(?:token|access_token|auth|ticket|jwt|api_key)"'
+    r'|\b(?:store|sessions?|sessionManager)\.(?:Get|GetString|GetInt|Exists|Load)\w*\s*\('
+    r'|\b\w*(?:[aA]uthenticate|[vV]alidate|[vV]erify|[cC]heck|[pP]arse)\w*(?:Token|JWT|Jwt|Session|Auth|Ticket|Claims|User|Credentials)\w*\s*\('
+    r'|\b\w*(?:[uU]ser|[cC]laims|[pP]rincipal|[aA]ccount|[sS]ession)From(?:Context|Request|Ctx)\s*\('
+    r'|\b(?:c|ctx)\.(?:MustGet|Get)\s*\(\s*"(?:user|claims|user_?id|userID|uid|principal|account)"'
+    r'|\b(?:[cC]urrentUser|[gG]etUser|[rR]equireUser|[iI]sAuthenticated|[lL]oggedIn)\w*\s*\('
+    r'|\bhttp\.Status(?:Unauthorized|Forbidden)\b'
+)
+# middleware names that authenticate: requireAuth, jwtMiddleware, sessionRequired, ...
+MIDDLEWARE_USED = re.compile(r'\b\w+\.Use\s*\(([^\n]*)')
+AUTH_MIDDLEWARE = re.compile(r'auth|jwt|session|login|bearer|protect|guard|token', re.IGNORECASE)
+
+
+@register_rule
+class WebSocketAnyOriginRule(Rule):
+    rule_id = "websocket-any-origin"
+    name = "WebSocket upgrader accepts any origin"
+    vuln_type = "Cross-Site WebSocket Hijacking"
+    severity = "high"
+    fix_complexity = "trivial"
+    cwe_id = "CWE-346"
+    description = "websocket.Upgrader CheckOrigin unconditionally returns true, so any site can open an authenticated WebSocket on behalf of a visitor"
+    remediation = "Compare the Origin header against an allowlist of trusted origins, or drop CheckOrigin to use the same-origin default"
+    example = 'websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}'
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        return [self.finding(ctx, line, match=match) for line, match in ctx.search(CHECK_ORIGIN_ALWAYS_TRUE)]
+
+
+@register_rule
+class CorsWildcardCredentialsRule(Rule):
+    rule_id = "cors-wildcard-credentials"
+    name = "CORS allows any origin with credentials"
+    vuln_type = "CORS Misconfiguration"
+    severity = "high"
+    fix_complexity = "trivial"
+    cwe_id = "CWE-942"
+    description = "CORS policy allows every origin while also allowing credentials, letting any site make authenticated cross-origin requests"
+    remediation = "Restrict allowed origins to an explicit list of trusted hosts when credentials are enabled"
+    example = 'cors.New(cors.Options{AllowedOrigins: []string{"*"}, AllowCredentials: true})'
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+
+        for line, match in ctx.search(CORS_CONFIG_LITERAL):
+            end = ctx.block_end(match.end() - 1)
+            literal = ctx.code[match.start():end]
+            if WILDCARD_ORIGIN.search(literal) and ALLOW_CREDENTIALS.search(literal):
+                findings.append(self.finding(ctx, line, match=match))
+
+        for line, match in ctx.search(HEADER_ALLOW_ORIGIN):
+            function = ctx.enclosing_function(line)
+            scope = function.body if function else ctx.code
+            if not HEADER_ALLOW_CREDENTIALS.search(scope):
+                continue
+
+            if match.group(1) == '"*"':
+                description = self.description
+            else:
+                description = "Access-Control-Allow-Origin reflects the request Origin header while credentials are allowed, which trusts every origin"
+            findings.append(self.finding(ctx, line, match=match, description=description))
+
+        return findings
+
+
+@register_rule
+class WebSocketMissingAuthRule(Rule):
+    rule_id = "websocket-missing-auth"
+    name = "WebSocket upgrade without authentication"
+    vuln_type = "Missing Authentication"
+    severity = "high"
+    cwe_id = "CWE-306"
+    description = ("A handler upgrades to a WebSocket without checking a token or session, then lets each message choose "
+                   "a privileged operation, so anyone who can reach the endpoint can run it")
+    remediation = ("Authenticate before upgrading: check the session cookie or a short-lived ticket and reject with 401, "
+                   "or register the route behind the authentication middleware; authorize each message type as well")
+    example = ('conn, _ := upgrader.Upgrade(w, r, nil)\nfor {\n    conn.ReadJSON(&msg)\n    switch msg.Type {\n'
+               '    case "command":\n        exec.Command("sh", "-c", msg.Payload).Run()\n    }\n}')
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+        if any(self.authenticates(m.group(0)) for m in SERVER_WRAP.finditer(ctx.code)):
+            return findings  # the whole server sits behind authentication
+        if any(self.authenticates(use) for use in MIDDLEWARE_USED.findall(ctx.code)):
+            return findings  # a router here installs authentication; which routes it covers is not followed
+
+        for function in ctx.functions():
+            upgrade = WS_UPGRADE.search(function.body)
+            if not upgrade:
+                continue
+            before = function.body[:upgrade.start()]
+            if WS_AUTH_CHECK.search(before) or AUTHORIZATION_CALL.search(before):
+                continue
+            handling = '\n'.join(f.body.split('\n', 1)[-1] for f in ctx.called_functions(function))
+            if not WS_MESSAGE_READ.search(handling) or not MESSAGE_DISPATCH.search(handling):
+                continue
+            operations = [what for what, pattern in PRIVILEGED_OPERATIONS.items() if pattern.search(handling)]
+            if not operations:
+                continue
+            if len(operations) > 1:
+                operations = [', '.join(operations[:-1]) + (',' if len(operations) > 2 else ''), operations[-1]]
+
+            registrations = self.registrations(ctx, function)
+            if any(self.authenticates(registration) for registration in registrations):
+                continue
+            confidence = 0.8
+            if not registrations:
+                # registered elsewhere, perhaps behind authentication
+                if any(self.authenticates(use) for source in ctx.module_sources()[1:] for use in MIDDLEWARE_USED.findall(source)):
+                    continue
+                confidence = 0.6
+
+            start, end = ctx.span(function)
+            line, match = next(ctx.search(WS_UPGRADE, start, end))
+            findings.append(self.finding(
+                ctx, line, match=match, confidence=confidence,
+                description=(f"{function.name} upgrades to a WebSocket without checking a token or session, then "
+                             f"dispatches messages that {' and '.join(operations)}")
+            ))
+        return findings
+
+    def registrations(self, ctx: SourceContext, function: SourceMember) -> List[str]:
+        """The route registrations in this file that serve function, from the router to the closing parenthesis"""
+        registrations = []
+        for match in ROUTE_REGISTRATION.finditer(ctx.code):
+            args = ctx.call_args(ctx.code.index('(', match.end(3)))
+            if re.search(rf'\b{re.escape(function.name)}\b', args):
+                registrations.append(match.group(1) + match.group(2) + args)
+        return registrations
+
+    def authenticates(self, code: str) -> bool:
+        code = strip_strings(code)  # a "/auth/ws" path authenticates nothing
+        return bool(AUTH_MIDDLEWARE.search(code) or AUTHORIZATION_CALL.search(code))
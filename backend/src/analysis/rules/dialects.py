@@ -0,0 +1,203 @@
+"""
+Dialects - Run the shared taint helpers over Kotlin, PHP, and C/C++
+The helpers in taint.py read Go-shaped code: `x = expr` assignments, "..." literals, dotted selectors, and
++ concatenation. A Dialect rewrites each line of another language into that shape, keeping line numbers:
+comments are blanked, string templates ("id = $id", "${user.id}", "{$row['id']}") become the literal plus
+each interpolated expression, PHP's $ sigils, -> and :: become plain names and dots and its . concatenation
+becomes +, Kotlin type annotations and null-safety operators are dropped, and C declarations keep only the
+declared name. A DialectRule then checks its sink calls on the rewritten code: user input in the checked
+argument is a finding traced back to its source, a string assembled from other dynamic values is a weaker
+one. Flow is followed within one function, or through the whole file for top-level PHP scripts.
+"""
+
+import re
+from dataclasses import dataclass
+from functools import lru_cache
+from typing import Callable, Dict, List, Optional, Pattern, Tuple
+
+from .base import Rule, SourceContext, StaticFinding
+from .taint import IDENTIFIER, references, split_args, strip_strings, taint_origins, taint_path
+
+LITERAL = re.compile(r'"(?:[^"\\\n]|\\.)*"|\'(?:[^\'\\\n]|\\.)*\'')
+# literals come first so that // in a URL is not taken for a comment
+COMMENT = re.compile(r'("(?:[^"\\\n]|\\.)*"|\'(?:[^\'\\\n]|\\.)*\')|//[^\n]*|/\*.*?\*/', re.DOTALL)
+# char *name = ..., const std::string &id = ..., size_t len[2] = ...
+C_DECLARATION = re.compile(
+    r'^(\s*)(?:(?:const|static|unsigned|signed|struct|enum|long|short|volatile|register)\s+)*'
+    r'[A-Za-z_]\w*(?:::\w+)*(?:<[^<>]*>)?[\s*&]+(\w+)\s*(?:\[[^\]]*\]\s*)?=(?!=)'
+)
+CONSTANT_NAME = re.compile(r'^_*[A-Z][A-Z0-9_]*$')
+
+# (sink call pattern, index of the argument checked, or None for every argument)
+Sink = Tuple[Pattern, Optional[int]]
+
+
+@dataclass(frozen=True)
+class Dialect:
+    sources: Pattern  # user input, matched on the rewritten code
+    template: Pattern  # an interpolation inside a double-quoted literal; the first group that matched is the expression
+    rewrite_code: Callable[[str], str]  # rewrites the code between literals
+    # handler parameters bound to request data (Spring @RequestParam id: String); group 1 is the name
+    parameter_sources: Optional[Pattern] = None
+
+    def rewrite_literal(self, literal: str) -> str:
+        if literal[0] == "'":
+            # single-quoted: a PHP string without interpolation, or a Kotlin or C char
+            return '"' + literal[1:-1].replace('"', '') + '"'
+        parts = [next(g for g in m.groups() if g is not None) for m in self.template.finditer(literal)]
+        if not parts:
+            return literal
+        return self.template.sub('', literal) + ''.join(f" + ({self.rewrite_line(part)})" for part in parts)
+
+    def rewrite_line(self, line: str) -> str:
+        rewritten, last = [], 0
+        for match in LITERAL.finditer(line):
+            rewritten.append(self.rewrite_code(line[last:match.start()]))
+            rewritten.append(self.rewrite_literal(match.group(0)))
+            last = match.end()
+        rewritten.append(self.rewrite_code(line[last:]))
+        return ''.join(rewritten)
+
+
+@lru_cache(maxsize=16)
+def normalized(dialect: Dialect, code: str) -> Tuple[str, ...]:
+    return tuple(dialect.rewrite_line(line) for line in blank_comments(code).split('\n'))
+
+
+def blank_comments(code: str) -> str:
+    """Comments replaced by spaces, keeping line and column numbers"""
+    return COMMENT.sub(lambda m: m.group(1) if m.group(1) is not None else re.sub(r'[^\n]', ' ', m.group(0)), code)
+
+
+def call_arguments(lines: Tuple[str, ...], index: int, open_paren: int) -> str:
+    """Arguments of the call whose parenthesis opens at a column, followed over continuation lines"""
+    text = '\n'.join(lines[index:index + 20])
+    depth = 0
+    for pos in range(open_paren, len(text)):
+        if text[pos] == '(':
+            depth += 1
+        elif text[pos] == ')':
+            depth -= 1
+            if depth == 0:
+                return text[open_paren + 1:pos]
+    return text[open_paren + 1:]
+
+
+class DialectRule(Rule):
+    """Sink rule over a dialect's rewritten code; sanitizers match whole sanitized expressions (intval($id),
+    id.toInt()), which then count as constants"""
+    dialect: Dialect
+    sinks: Tuple[Sink, ...] = ()
+    sanitizers: Optional[Pattern] = None
+    tainted_description = ""
+    dynamic_description = ""  # empty: arguments that are dynamic but not tainted are not reported
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        lines = normalized(self.dialect, ctx.code)
+        findings: Dict[int, StaticFinding] = {}
+
+        for index, line in enumerate(lines):
+            for pattern, arg_index in self.sinks:
+                for match in pattern.finditer(line):
+                    args = self.arguments(lines, index, match)
+                    checked = args if arg_index is None else args[arg_index:][:1]
+                    if not checked or self.is_safe(args):
+                        continue
+                    finding = self.judge(ctx, lines, index + 1, checked)
+                    if finding and index + 1 not in findings:
+                        findings[index + 1] = finding
+
+        return [findings[line] for line in sorted(findings)]
+
+    def arguments(self, lines: Tuple[str, ...], index: int, match: re.Match) -> List[str]:
+        return split_args(call_arguments(lines, index, match.end() - 1))
+
+    def is_safe(self, args: List[str]) -> bool:
+        """Whether the call is safe whatever its arguments hold (an allowlist option, for example)"""
+        return False
+
+    def is_dynamic(self, expr: str) -> bool:
+        stripped = strip_strings(expr)
+        return '+' in stripped and any(not CONSTANT_NAME.match(name) for name in IDENTIFIER.findall(stripped))
+
+    def scope(self, ctx: SourceContext, line_number: int) -> Tuple[int, int, str]:
+        """(first line, last line, declaration) of the innermost function around a line, or the whole file"""
+        enclosing = [f for f in ctx.functions() if f.start_line <= line_number <= f.end_line]
+        if not enclosing:
+            return 1, len(ctx.lines), ""
+        function = min(enclosing, key=lambda f: f.end_line - f.start_line)
+        return function.start_line, function.end_line, function.signature
+
+    def judge(self, ctx: SourceContext, lines: Tuple[str, ...], line_number: int, args: List[str]) -> Optional[StaticFinding]:
+        first, last, signature = self.scope(ctx, line_number)
+        seeds = self.dialect.parameter_sources.findall(signature) if self.dialect.parameter_sources else []
+        origins = taint_origins('\n'.join(lines[first - 1:last]), self.dialect.sources, seeds, self.sanitizers)
+
+        for arg in args:
+            expr = self.sanitizers.sub('0', arg) if self.sanitizers else arg
+            if self.dialect.sources.search(expr):
+                return self.finding(ctx, line_number, confidence=0.9, description=self.tainted_description, trace=[line_number])
+            name = references(expr, origins)
+            if name:
+                trace = taint_path(origins, name, first) + [line_number]
+                return self.finding(ctx, line_number, confidence=0.9, description=f"{self.tainted_description} ('{name}')", trace=trace)
+
+        if self.dynamic_description and any(self.is_dynamic(self.sanitizers.sub('0', arg) if self.sanitizers else arg) for arg in args):
+            return self.finding(ctx, line_number, severity="medium", confidence=0.5, description=self.dynamic_description)
+        return None
+
+
+def rewrite_kotlin(code: str) -> str:
+    code = re.sub(r'\b(val|var)\s+(\w+)\s*:\s*[^=\n]+?\s*=(?!=)', r'\1 \2 =', code)  # val id: String = ...
+    return code.replace('?.', '.').replace('!!', '')
+
+
+def rewrite_c(code: str) -> str:
+    code = C_DECLARATION.sub(r'\1\2 =', code.replace('->', '.'))
+    # a read into a buffer taints the buffer, as &n does for scanf("%d", &n)
+    code = re.sub(r'\b(fgets|fread|gets)\s*\(\s*(\w+)', r'\1(&\2', code)
+    return re.sub(r'\b(read|recv|recvfrom)\s*\(([^,()]*),\s*(\w+)', r'\1(\2, &\3', code)
+
+
+def rewrite_php(code: str) -> str:
+    code = re.sub(r'\.(?!=)', ' + ', code)  # concatenation; -> and :: below become the dots
+    code = re.sub(r'\?->|->|::', '.', code)
+    return re.sub(r'\$(?=[A-Za-z_])', '', code)
+
+
+KOTLIN = Dialect(
+    sources=re.compile(
+        r'\.(?:getParameter|getParameterValues|getParameterMap|getHeader|getHeaders|getQueryString|getQueryParameter'
+        r'|getStringExtra|getBundleExtra|queryParam|pathVariable|bodyToMono)\s*\('
+        r'|\b(?:request|req|httpRequest)\.(?:parameterMap|queryString|requestURI|inputStream|reader|cookies)\b'
+        r'|\bcall\.(?:parameters|receive\w*|request\.(?:queryParameters|headers|cookies))\b'
+        r'|\bintent\.(?:data|extras)\b'
+    ),
+    template=re.compile(r'(?<!\\)\$\{([^}]*)\}|(?<!\\)\$([A-Za-z_]\w*)'),
+    rewrite_code=rewrite_kotlin,
+    parameter_sources=re.compile(
+        r'@(?:RequestParam|PathVariable|RequestBody|RequestHeader|CookieValue|ModelAttribute)\b(?:\([^)]*\))?\s+(?:va[lr]\s+)?(\w+)\s*:'
+    ),
+)
+
+PHP = Dialect(
+    sources=re.compile(
+        r'\b_(?:GET|POST|REQUEST|COOKIE|FILES)\b'
+        r'|\b_SERVER\s*\[\s*"(?:HTTP_\w+|QUERY_STRING|REQUEST_URI|PATH_INFO|PHP_SELF)"'
+        r'|"php://input"'
+        r'|\brequest\.(?:input|get|query|post|all|json|cookie|header|route|only|except)\s*\('
+        r'|\brequest\.(?:query|request|cookies|headers|attributes)\.(?:get|all)\s*\('
+        r'|(?<![\w.])request\s*\(|\bInput\.get\s*\('
+    ),
+    template=re.compile(r'(?<!\\)\{\$([^}]*)\}|(?<!\\)\$\{([^}]*)\}|(?<!\\)\$([A-Za-z_]\w*(?:->\w+|\[[^\]]*\])*)'),
+    rewrite_code=rewrite_php,
+)
+
+C = Dialect(
+    sources=re.compile(
+        r'\bargv\s*\[|\b(?:getenv|secure_getenv)\s*\('
+        r'|(?<![\w.])(?:fgets|fread|gets|getline|getdelim|read|recv|recvfrom|recvmsg|scanf|fscanf)\s*\('
+    ),
+    template=re.compile(r'(?!)'),  # no interpolation
+    rewrite_code=rewrite_c,
+)
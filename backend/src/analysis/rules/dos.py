@@ -0,0 +1,115 @@
+"""
+Resource exhaustion rules - Unbounded reads and allocations sized by user input
+"""
+
+import re
+from typing import List
+
+from .base import Rule, SourceContext, StaticFinding, register_rule
+from .taint import references, split_args, taint_origins, taint_path
+
+
+BODY_READ = re.compile(
+    r'\b(?:ioutil|io)\.ReadAll\s*\(\s*(\w+)\.Body\s*\)'
+    r'|\bjson\.NewDecoder\s*\(\s*(\w+)\.Body\s*\)'
+)
+BODY_LIMIT = re.compile(r'\bhttp\.MaxBytesReader\s*\(|\bio\.LimitReader\s*\(|\bio\.LimitedReader\b')
+
+WS_UPGRADE = re.compile(r'\.Upgrade\s*\(\s*\w+\s*,\s*\w+')
+WS_READ = re.compile(r'\.(?:ReadJSON|ReadMessage|NextReader)\s*\(')
+WS_READ_LIMIT = re.compile(r'\.SetReadLimit\s*\(')
+
+SIZED_ALLOCATION = re.compile(r'\bmake\s*\(|\b(?:strings|bytes)\.Repeat\s*\(')
+
+
+@register_rule
+class UnboundedBodyReadRule(Rule):
+    rule_id = "unbounded-body-read"
+    name = "Request or response body read without a size limit"
+    vuln_type = "Resource Exhaustion"
+    severity = "medium"
+    fix_complexity = "trivial"
+    cwe_id = "CWE-400"
+    description = "A request or upstream response body is read fully into memory without a size limit, so a large body can exhaust memory"
+    remediation = "Wrap request bodies with http.MaxBytesReader and upstream bodies with io.LimitReader before reading them"
+    example = 'body, _ := ioutil.ReadAll(r.Body)'
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+
+        for function in ctx.functions():
+            if BODY_LIMIT.search(function.body):
+                continue
+            for line, match in ctx.search(BODY_READ, *ctx.span(function)):
+                owner = match.group(1) or match.group(2)
+                findings.append(self.finding(
+                    ctx, line, match=match,
+                    description=f"{owner}.Body is read without http.MaxBytesReader or io.LimitReader, so its size is unbounded"
+                ))
+
+        return findings
+
+
+@register_rule
+class WebSocketReadLimitRule(Rule):
+    rule_id = "websocket-missing-read-limit"
+    name = "WebSocket connection without a read limit"
+    vuln_type = "Resource Exhaustion"
+    severity = "medium"
+    fix_complexity = "trivial"
+    cwe_id = "CWE-770"
+    description = "WebSocket messages are read without SetReadLimit, so a client can send arbitrarily large frames"
+    remediation = "Call conn.SetReadLimit with the largest expected message size right after upgrading"
+    example = 'conn, _ := upgrader.Upgrade(w, r, nil)\nconn.ReadJSON(&msg)'
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+
+        for function in ctx.functions():
+            body = function.body
+            if not WS_UPGRADE.search(body) or not WS_READ.search(body) or WS_READ_LIMIT.search(body):
+                continue
+            for line, match in ctx.search(WS_UPGRADE, *ctx.span(function)):
+                findings.append(self.finding(ctx, line, match=match))
+                break
+
+        return findings
+
+
+@register_rule
+class UserSizedAllocationRule(Rule):
+    rule_id = "user-sized-allocation"
+    name = "Allocation sized by user input"
+    vuln_type = "Resource Exhaustion"
+    severity = "medium"
+    cwe_id = "CWE-789"
+    description = "Memory is allocated with a size taken from user input without an upper bound"
+    remediation = "Clamp user-provided sizes to a fixed maximum before allocating"
+    example = 'n, _ := strconv.Atoi(r.FormValue("n"))\nbuf := make([]byte, n)'
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+
+        for function in ctx.functions():
+            tainted = taint_origins(function.body)
+            if not tainted:
+                continue
+
+            for line, match in ctx.search(SIZED_ALLOCATION, *ctx.span(function)):
+                args = split_args(ctx.call_args(match.end() - 1))
+                size_args = args[1:] if len(args) > 1 else []
+                for arg in size_args:
+                    name = references(arg, tainted)
+                    if name and not self._bounded(function.body, name):
+                        findings.append(self.finding(
+                            ctx, line, match=match,
+                            description=f"Allocation size '{name}' comes from user input without an upper bound",
+                            trace=taint_path(tainted, name, function.start_line)
+                        ))
+                        break
+
+        return findings
+
+    def _bounded(self, body: str, name: str) -> bool:
+        pattern = rf'\b{re.escape(name)}\s*(?:>|>=)\s*\w+|\bmin\s*\([^)]*\b{re.escape(name)}\b'
+        return re.search(pattern, body) is not None
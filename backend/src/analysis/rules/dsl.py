@@ -0,0 +1,148 @@
+"""
+Rule DSL - Pattern rules declared in YAML, the format rule packs are distributed in
+
+    name: acme-go
+    version: 1.2.0
+    description: ACME's Go conventions
+    rules:
+      - id: weak-random-token          # becomes acme-go/weak-random-token
+        name: Token from math/rand
+        severity: high
+        cwe: CWE-338
+        languages: [go]
+        pattern: 'rand\\.(?:Int|Read)\\('  # or patterns: [...] (any of them)
+        pattern_not: '//\\s*nosec'        # skip lines that also match (string or list)
+        paths: ['**/auth/**']             # optional globs the file must match
+        exclude_paths: ['**/*_test.go']
+        description: ...
+        remediation: ...
+        fix_complexity: trivial           # trivial, localized (default), or architectural
+
+Patterns are Python regular expressions matched against the whole file (multiline mode); a finding is
+reported on the line where a match starts, at most once per line.
+"""
+
+import fnmatch
+import re
+from typing import Any, Dict, List, Tuple
+
+from .base import FIX_COMPLEXITIES, Rule, SourceContext, StaticFinding
+
+PACK_NAME = re.compile(r'^[a-z0-9][a-z0-9._-]*$')
+# versions become directory names under RULE_PACK_DIR, so no separators and no '..'
+PACK_VERSION = re.compile(r'^[0-9A-Za-z](?:[0-9A-Za-z+-]|\.(?!\.))*$')
+RULE_ID = re.compile(r'^[A-Za-z0-9][A-Za-z0-9._-]*$')
+SEVERITIES = ('critical', 'high', 'medium', 'low')
+
+
+class RuleDSLError(ValueError):
+    pass
+
+
+def as_list(value: Any) -> List[str]:
+    if value is None:
+        return []
+    return [str(v) for v in value] if isinstance(value, list) else [str(value)]
+
+
+def path_matches(file_path: str, globs: List[str]) -> bool:
+    path = file_path.replace('\\', '/')
+    # "**/x" should also match x at the top level, which fnmatch's "*" already spans directories for
+    return any(fnmatch.fnmatch(path, glob) or fnmatch.fnmatch(path, glob.replace('**/', '')) for glob in globs)
+
+
+class PatternRule(Rule):
+    """A rule from a rule pack: regex patterns with optional exclusions and path filters"""
+
+    def __init__(self, pack: str, data: Dict[str, Any]):
+        local_id = str(data.get('id') or '')
+        if not RULE_ID.match(local_id):
+            raise RuleDSLError(f"Rule id {local_id!r} must be letters, digits, '.', '_', or '-'")
+        where = f"{pack}/{local_id}"
+
+        severity = str(data.get('severity', 'medium')).lower()
+        if severity not in SEVERITIES:
+            raise RuleDSLError(f"{where}: severity must be one of {', '.join(SEVERITIES)}")
+        fix_complexity = str(data.get('fix_complexity', 'localized')).lower()
+        if fix_complexity not in FIX_COMPLEXITIES:
+            raise RuleDSLError(f"{where}: fix_complexity must be one of {', '.join(FIX_COMPLEXITIES)}")
+        patterns = as_list(data.get('patterns')) + as_list(data.get('pattern'))
+        if not patterns:
+            raise RuleDSLError(f"{where}: needs a pattern or patterns")
+        try:
+            self.patterns = [re.compile(p, re.MULTILINE) for p in patterns]
+            self.exclusions = [re.compile(p) for p in as_list(data.get('pattern_not'))]
+        except re.error as e:
+            raise RuleDSLError(f"{where}: invalid pattern: {e}")
+
+        self.rule_id = where
+        self.pack = pack
+        self.name = str(data.get('name') or local_id)
+        self.vuln_type = str(data.get('vuln_type') or self.name)
+        self.severity = severity
+        self.fix_complexity = fix_complexity
+        cwe = data.get('cwe') or data.get('cwe_id')
+        self.cwe_id = f"CWE-{re.sub(r'^CWE-?', '', str(cwe), flags=re.IGNORECASE)}" if cwe else None
+        self.description = str(data.get('description') or self.name)
+        self.remediation = str(data.get('remediation') or '')
+        self.example = str(data.get('example') or '')
+        self.languages = tuple(as_list(data.get('languages')) or ['go'])
+        self.confidence = float(data.get('confidence', 0.7))
+        self.paths = as_list(data.get('paths'))
+        self.exclude_paths = as_list(data.get('exclude_paths'))
+
+    def applies_to(self, ctx: SourceContext) -> bool:
+        if '*' not in self.languages and ctx.language not in self.languages:
+            return False
+        if self.paths and not path_matches(ctx.file_path, self.paths):
+            return False
+        return not path_matches(ctx.file_path, self.exclude_paths)
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+        reported = set()
+        for pattern in self.patterns:
+            for line_number, match in ctx.search(pattern):
+                if line_number in reported or any(x.search(ctx.line(line_number)) for x in self.exclusions):
+                    continue
+                reported.add(line_number)
+                findings.append(self.finding(ctx, line_number, match=match, confidence=self.confidence))
+        return findings
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {**super().to_dict(), "pack": self.pack}
+
+
+def parse_pack(data: Any) -> Tuple[Dict[str, Any], List[PatternRule]]:
+    """Pack metadata (name, version, description, author) and its rules"""
+    if not isinstance(data, dict):
+        raise RuleDSLError("A rule pack must be a mapping with name, version, and rules")
+    name = str(data.get('name') or '')
+    version = str(data.get('version') or '')
+    if not PACK_NAME.match(name):
+        raise RuleDSLError(f"Pack name {name!r} must be lowercase letters, digits, '.', '_', or '-'")
+    if not version:
+        raise RuleDSLError(f"Pack {name} has no version")
+    if not PACK_VERSION.match(version):
+        raise RuleDSLError(f"Pack {name} version {version!r} must be letters, digits, '.', '+', or '-' (e.g. 1.2.0)")
+
+    rules = []
+    for entry in data.get('rules') or []:
+        if not isinstance(entry, dict):
+            raise RuleDSLError(f"Pack {name}: each rule must be a mapping")
+        rules.append(PatternRule(name, entry))
+    if not rules:
+        raise RuleDSLError(f"Pack {name} has no rules")
+    ids = [rule.rule_id for rule in rules]
+    duplicates = sorted({i for i in ids if ids.count(i) > 1})
+    if duplicates:
+        raise RuleDSLError(f"Pack {name} defines {', '.join(duplicates)} more than once")
+
+    metadata = {
+        "name": name,
+        "version": version,
+        "description": str(data.get('description') or ''),
+        "author": str(data.get('author') or ''),
+        "rules": len(rules)
+    }
+    return metadata, rules
@@ -0,0 +1,102 @@
+"""
+Ignored error rules - Discarded errors from security-critical operations
+Scoped to a curated callee list rather than generic errcheck coverage
+"""
+
+import re
+from typing import Dict, List, Optional, Tuple
+
+from .base import Rule, SourceContext, StaticFinding, register_rule
+
+
+DB_RECEIVERS = r'(?:db|tx|stmt|rows|row|conn|pool|database|sqlDB)'
+
+# (category, callee pattern anchored at the start of the expression, severity)
+SECURITY_CALLEES: List[Tuple[str, re.Pattern, str]] = [
+    ("random number generation", re.compile(r'rand\.Read\s*\('), "high"),
+    ("cryptographic operation", re.compile(
+        r'(?:aes\.NewCipher|cipher\.New\w+|bcrypt\.\w+|scrypt\.Key|argon2\.\w+|rsa\.\w+|ecdsa\.\w+'
+        r'|x509\.Parse\w+|tls\.LoadX509KeyPair|jwt\.Parse\w*|hmac\.\w+)\s*\('
+    ), "high"),
+    ("database query", re.compile(
+        DB_RECEIVERS + r'\.(?:Query|QueryRow|QueryContext|Exec|ExecContext|Prepare|Begin|Commit|Rollback|Scan)\w*\s*\('
+    ), "medium"),
+    ("file permission change", re.compile(
+        r'(?:os\.(?:Chmod|Chown|Lchown|Setuid|Setgid)|syscall\.(?:Setuid|Setgid|Setgroups)|\w+\.Chmod)\s*\('
+    ), "medium"),
+    ("command execution", re.compile(
+        r'exec\.Command\w*\s*\(.*\)\.(?:Output|CombinedOutput|Run)\s*\('
+    ), "medium"),
+]
+
+# once type information resolves a call, these decide the category instead of the name patterns above,
+# so a Query method on a project type or math/rand.Read is not mistaken for database/sql or crypto/rand
+TYPED_CALLEES: Dict[str, re.Pattern] = {
+    "random number generation": re.compile(r'crypto/rand\.Read$'),
+    "database query": re.compile(
+        r'(?:database/sql|github\.com/jmoiron/sqlx|github\.com/jackc/pgx(?:/v\d+)?)\.\w+\.'
+        r'(?:Query|QueryRow|QueryContext|Exec|ExecContext|Prepare|Begin|Commit|Rollback|Scan)\w*$'
+    ),
+    "file permission change": re.compile(r'(?:os\.(?:Chmod|Chown|Lchown|Setuid|Setgid)|os\.File\.Chmod|syscall\.(?:Setuid|Setgid|Setgroups))$'),
+}
+CALL_CHAIN = re.compile(r'((?:\w+\.)*\w+)\s*\(')
+
+BLANK_ERROR_ASSIGNMENT = re.compile(r'^\s*(?:[\w.\[\]]+\s*,\s*)*_\s*:?=\s*(.+)$')
+EXPRESSION_STATEMENT = re.compile(r'^\s*([\w.]+\s*\(.*)$')
+
+
+def classify(expr: str, ctx: Optional[SourceContext] = None, offset: int = 0) -> Optional[Tuple[str, str]]:
+    expr = expr.strip()
+    chain = CALL_CHAIN.match(expr)
+    callees = ctx.types.concrete_callees(chain.group(1), offset) if chain and ctx and ctx.types else []
+    for category, pattern, severity in SECURITY_CALLEES:
+        typed = TYPED_CALLEES.get(category)
+        if callees and typed:
+            if any(typed.match(callee) for callee in callees):
+                return category, severity
+        elif pattern.match(expr):
+            return category, severity
+    return None
+
+
+@register_rule
+class IgnoredSecurityErrorRule(Rule):
+    rule_id = "ignored-security-error"
+    name = "Error ignored on a security-critical operation"
+    vuln_type = "Unchecked Error"
+    severity = "medium"
+    fix_complexity = "trivial"
+    cwe_id = "CWE-252"
+    description = "The error returned by a security-critical operation is discarded, so failures go unnoticed and execution continues in an unsafe state"
+    remediation = "Check the returned error and fail closed (abort the request or operation) when it is non-nil"
+    example = 'output, _ := exec.Command("sh", "-c", cmd).Output()'
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+
+        for line_number, line in enumerate(ctx.lines, start=1):
+            stripped = line.strip()
+            if stripped.startswith('//'):
+                continue
+
+            match = BLANK_ERROR_ASSIGNMENT.match(line)
+            how = "assigned to _"
+            if not match:
+                match = EXPRESSION_STATEMENT.match(line)
+                how = "never checked"
+            if not match:
+                continue
+
+            offset = sum(len(text) + 1 for text in ctx.lines[:line_number - 1]) + match.start(1)
+            result = classify(match.group(1), ctx, offset)
+            if not result:
+                continue
+
+            category, severity = result
+            findings.append(self.finding(
+                ctx, line_number,
+                description=f"Error from {category} is {how}, so a failure goes unnoticed",
+                severity=severity
+            ))
+
+        return findings
@@ -0,0 +1,207 @@
+"""
+gRPC rules - Plaintext channels and servers, and sensitive services without an auth interceptor
+Dial targets and listen addresses are constant-folded: plaintext to a loopback address or a Unix socket
+(a sidecar, a local agent, an in-memory bufconn test) is fine, to anything else it is not
+"""
+
+import re
+from typing import List, Optional
+
+from ..constants import ConstantFolder, package_constants
+from .base import Rule, SourceContext, StaticFinding, register_rule
+from .taint import split_args
+
+GRPC_IMPORT = ('google.golang.org/grpc',)
+
+INSECURE_OPTION = re.compile(r'\bgrpc\.WithInsecure\s*\(\s*\)|\binsecure\.NewCredentials\s*\(\s*\)')
+DIAL_CALL = re.compile(r'\bgrpc\.(Dial|DialContext|NewClient)\s*\(')
+NEW_SERVER = re.compile(r'\bgrpc\.NewServer\s*\(')
+SERVER_CREDENTIALS = re.compile(r'\bgrpc\.Creds\s*\(')
+INSECURE_SERVER_CREDENTIALS = re.compile(r'\bgrpc\.Creds\s*\(\s*insecure\.NewCredentials\s*\(\s*\)\s*\)')
+LISTEN_CALL = re.compile(r'\bnet\.Listen\s*\(')
+IN_MEMORY = re.compile(r'\bbufconn\.')
+
+REGISTER_SERVICE = re.compile(r'\b(?:\w+\.)?Register(\w+)Server\s*\(')
+INTERCEPTOR_OPTION = re.compile(r'\bgrpc\.(?:Chain)?(?:Unary|Stream)Interceptor\s*\(')
+# an interceptor (or the package it comes from) that reads like authentication or authorization
+AUTH_INTERCEPTOR = re.compile(r'auth|jwt|token|oidc|oauth|apikey|api_key|acl|rbac|authz|policy|opa|session|identity|mtls', re.IGNORECASE)
+SENSITIVE_SERVICE = re.compile(
+    r'admin|auth|user|account|payment|billing|secret|key|token|credential|internal|manage|config|identity|iam|wallet|order',
+    re.IGNORECASE
+)
+# auth checked inside the handlers rather than in an interceptor
+HANDLER_AUTH = re.compile(r'\bmetadata\.FromIncomingContext\s*\(|\bpeer\.FromContext\s*\(|\bcredentials\.RequestInfoFromContext\s*\(')
+
+LOOPBACK = re.compile(r'^(?:localhost|127(?:\.\d{1,3}){3}|\[?::1\]?|0:0:0:0:0:0:0:1)$', re.IGNORECASE)
+
+
+def is_local(address: str) -> bool:
+    """Whether a gRPC target or listen address stays on this host"""
+    if address.startswith(('unix:', 'unix-abstract:', '/', '@', 'bufnet')):
+        return True
+    address = re.sub(r'^(?:dns|passthrough):(?://[^/]*)?/', '', address)
+    if address.startswith('['):
+        host = address[1:address.find(']')]
+    elif address.count(':') == 1:
+        host = address.split(':')[0]  # ":50051" listens on every interface
+    else:
+        host = address
+    return bool(LOOPBACK.match(host))
+
+
+def all_local(argument: str, line: int, folder: ConstantFolder) -> Optional[bool]:
+    """True or False when every possible value of the address is known, None when it is not"""
+    value = folder.value_of(argument, line)
+    if not value.is_constant:
+        return None
+    return all(is_local(address) for address in value.strings)
+
+
+def call_argument(ctx: SourceContext, match: re.Match, index: int = 0) -> Optional[str]:
+    args = split_args(ctx.call_args(match.end() - 1))
+    return args[index] if len(args) > index else None
+
+
+@register_rule
+class GrpcInsecureClientRule(Rule):
+    rule_id = "grpc-insecure-channel"
+    name = "gRPC client connection without TLS"
+    vuln_type = "Cleartext Transmission"
+    severity = "high"
+    fix_complexity = "trivial"
+    cwe_id = "CWE-319"
+    description = "A gRPC connection to a remote target uses insecure credentials, so requests, responses, and tokens cross the network in plaintext"
+    remediation = ("Dial with grpc.WithTransportCredentials(credentials.NewTLS(cfg)) or credentials.NewClientTLSFromFile; "
+                   "keep insecure credentials for loopback, Unix socket, or bufconn targets")
+    example = 'grpc.Dial("payments.internal:443", grpc.WithTransportCredentials(insecure.NewCredentials()))'
+
+    def applies_to(self, ctx: SourceContext) -> bool:
+        return super().applies_to(ctx) and ctx.imports_any(GRPC_IMPORT)
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+        constants = package_constants(ctx.code)
+
+        for function in ctx.functions():
+            start, end = ctx.span(function)
+            options = list(ctx.search(INSECURE_OPTION, start, end))
+            if not options or IN_MEMORY.search(function.body):
+                continue
+            folder = ConstantFolder(function.body, function.start_line, constants)
+            dials = list(ctx.search(DIAL_CALL, start, end))
+
+            if not dials:
+                line, match = options[0]
+                findings.append(self.finding(
+                    ctx, line, match=match, severity="medium", confidence=0.5,
+                    description=f"{function.name} builds gRPC dial options with insecure credentials for a target chosen elsewhere"
+                ))
+                continue
+            for line, match in dials:
+                target = call_argument(ctx, match, 1 if match.group(1) == 'DialContext' else 0)
+                local = all_local(target, line, folder) if target else None
+                if local:
+                    continue
+                option_line, option = min(options, key=lambda o: abs(o[0] - line))
+                if local is None:
+                    findings.append(self.finding(
+                        ctx, option_line, match=option, severity="medium", confidence=0.6,
+                        description=f"gRPC connection to {target} uses insecure credentials; the target could not be resolved to a loopback address"
+                    ))
+                else:
+                    findings.append(self.finding(
+                        ctx, option_line, match=option, confidence=0.9,
+                        description=f"gRPC connection to {target} ({', '.join(sorted(folder.value_of(target, line).strings))}) uses insecure credentials"
+                    ))
+        return findings
+
+
+@register_rule
+class GrpcServerNoTLSRule(Rule):
+    rule_id = "grpc-server-no-tls"
+    name = "gRPC server without TLS credentials"
+    vuln_type = "Cleartext Transmission"
+    severity = "medium"
+    fix_complexity = "trivial"
+    cwe_id = "CWE-319"
+    description = "A gRPC server is created without TLS credentials and listens on a non-loopback address"
+    remediation = ("Pass grpc.Creds(credentials.NewTLS(cfg)) (with ClientAuth for mTLS) to grpc.NewServer; if a service mesh "
+                   "terminates TLS in a sidecar, listen on loopback only")
+    example = 'lis, _ := net.Listen("tcp", ":50051")\ngrpc.NewServer().Serve(lis)'
+
+    def applies_to(self, ctx: SourceContext) -> bool:
+        return super().applies_to(ctx) and ctx.imports_any(GRPC_IMPORT)
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+        constants = package_constants(ctx.code)
+
+        for function in ctx.functions():
+            start, end = ctx.span(function)
+            servers = list(ctx.search(NEW_SERVER, start, end))
+            if not servers:
+                continue
+            insecure = INSECURE_SERVER_CREDENTIALS.search(function.body)
+            if SERVER_CREDENTIALS.search(function.body) and not insecure:
+                continue
+            folder = ConstantFolder(function.body, function.start_line, constants)
+            listens = [(line, call_argument(ctx, match, 1)) for line, match in ctx.search(LISTEN_CALL, start, end)]
+            if listens and all(address and all_local(address, line, folder) for line, address in listens):
+                continue
+
+            line, match = servers[0]
+            address = next((a for _, a in listens if a), None)
+            where = f" and listens on {address}" if address else ""
+            credentials = "insecure credentials" if insecure else "no TLS credentials"
+            findings.append(self.finding(
+                ctx, line, match=match, confidence=0.7 if listens else 0.5,
+                description=f"gRPC server in {function.name} is created with {credentials}{where}"
+            ))
+        return findings
+
+
+@register_rule
+class GrpcMissingAuthInterceptorRule(Rule):
+    rule_id = "grpc-missing-auth-interceptor"
+    name = "Sensitive gRPC service without an auth interceptor"
+    vuln_type = "Missing Authentication"
+    severity = "high"
+    cwe_id = "CWE-306"
+    description = "A sensitive gRPC service is registered on a server with no authentication interceptor, so every RPC is callable unauthenticated"
+    remediation = ("Install an authenticating interceptor for every RPC with grpc.ChainUnaryInterceptor and grpc.ChainStreamInterceptor "
+                   "(e.g. go-grpc-middleware auth.UnaryServerInterceptor), so a new method cannot ship without the check")
+    example = 's := grpc.NewServer()\npb.RegisterAdminServer(s, &adminServer{})'
+
+    def applies_to(self, ctx: SourceContext) -> bool:
+        return super().applies_to(ctx) and ctx.imports_any(GRPC_IMPORT)
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+        if HANDLER_AUTH.search(ctx.code):
+            return findings
+
+        for function in ctx.functions():
+            start, end = ctx.span(function)
+            if not NEW_SERVER.search(function.body):
+                continue  # the server's options are not visible here
+            interceptors = [
+                ctx.call_args(match.end() - 1) for _, match in ctx.search(INTERCEPTOR_OPTION, start, end)
+            ]
+            if any(AUTH_INTERCEPTOR.search(i) for i in interceptors):
+                continue
+
+            for line, match in ctx.search(REGISTER_SERVICE, start, end):
+                service = match.group(1)
+                if not SENSITIVE_SERVICE.search(service):
+                    continue
+                if interceptors:
+                    findings.append(self.finding(
+                        ctx, line, match=match, severity="medium", confidence=0.4,
+                        description=f"{service} service is registered on a server whose interceptors do not look like authentication"
+                    ))
+                else:
+                    findings.append(self.finding(
+                        ctx, line, match=match, confidence=0.6,
+                        description=f"{service} service is registered on a server with no interceptors, so no RPC is authenticated"
+                    ))
+        return findings
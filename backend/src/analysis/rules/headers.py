@@ -0,0 +1,174 @@
+"""
+Header rules - User input in response, redirect, and mail headers, and HTML servers without security headers
+Security headers are usually set once, by middleware next to the server setup, for handlers that live in other
+packages; so that rule reads the whole Go module (up to go.mod) and reports once per server setup
+"""
+
+import re
+from typing import Dict, List, Set
+
+from .base import Rule, SourceContext, StaticFinding, register_rule
+from .taint import ASSIGNMENT, references, split_args, taint_origins, taint_path
+
+
+CRLF_SANITIZERS = re.compile(
+    r'strings\.(?:ReplaceAll|Replace|NewReplacer|Map)\('
+    r'|url\.(?:QueryEscape|PathEscape)\('
+    r'|textproto\.TrimString\('
+    r'|mime\.(?:QEncoding|BEncoding)\.Encode\('
+)
+CRLF_GUARD = r'strings\.(?:ContainsAny|ContainsRune|IndexAny)\(\s*{name}\b'
+
+HEADER_WRITE = re.compile(r'\.Header\(\)\.(?:Set|Add)\s*\(')
+HEADER_INDEX = re.compile(r'\.Header\(\)\[[^\]]+\]\s*=\s*(.+)')
+REDIRECT = re.compile(r'\bhttp\.Redirect\s*\(')
+SEND_MAIL = re.compile(r'\bsmtp\.SendMail\s*\(')
+MAIL_HEADER_LITERAL = re.compile(r'"(?:To|From|Cc|Bcc|Subject|Reply-To):')
+
+# (sink pattern, index of the argument that becomes a header value, label)
+ARG_SINKS = [
+    (HEADER_WRITE, 1, "response header"),
+    (REDIRECT, 2, "redirect Location header"),
+    (SEND_MAIL, 4, "SMTP message headers"),
+]
+
+
+@register_rule
+class HeaderInjectionRule(Rule):
+    rule_id = "header-injection"
+    name = "User input in HTTP or mail headers"
+    vuln_type = "HTTP Header Injection"
+    severity = "medium"
+    cwe_id = "CWE-113"
+    description = "User-controlled input is written into a header without removing CR/LF characters, allowing header or response splitting"
+    remediation = "Reject or strip \\r and \\n from user input before using it in header values, redirect targets, or mail headers"
+    example = 'w.Header().Set("Content-Language", r.URL.Query().Get("lang"))'
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+
+        for function in ctx.functions():
+            origins = taint_origins(function.body, sanitizers=CRLF_SANITIZERS)
+            tainted = {
+                name for name in origins
+                if not re.search(CRLF_GUARD.format(name=re.escape(name)), function.body)
+            }
+            if not tainted:
+                continue
+
+            start, end = ctx.span(function)
+            mail_reported = False
+
+            for line, match in ctx.search(MAIL_HEADER_LITERAL, start, end):
+                assignment = ASSIGNMENT.match(ctx.line(line))
+                expr = assignment.group(2) if assignment else ctx.line(line)
+                name = references(expr, tainted)
+                if name and not CRLF_SANITIZERS.search(expr):
+                    mail_reported = True
+                    findings.append(self.finding(
+                        ctx, line, match=match,
+                        description=f"User-controlled '{name}' is concatenated into a mail header without CR/LF stripping",
+                        trace=taint_path(origins, name, function.start_line)
+                    ))
+
+            for pattern, index, label in ARG_SINKS:
+                if pattern is SEND_MAIL and mail_reported:
+                    continue
+                for line, match in ctx.search(pattern, start, end):
+                    args = split_args(ctx.call_args(match.end() - 1))
+                    if len(args) <= index or CRLF_SANITIZERS.search(args[index]):
+                        continue
+                    name = references(args[index], tainted)
+                    if name:
+                        findings.append(self.finding(
+                            ctx, line, match=match,
+                            description=f"User-controlled '{name}' flows into the {label} without CR/LF stripping",
+                            trace=taint_path(origins, name, function.start_line)
+                        ))
+
+            for line, match in ctx.search(HEADER_INDEX, start, end):
+                name = references(match.group(1), tainted)
+                if name:
+                    findings.append(self.finding(
+                        ctx, line, match=match,
+                        description=f"User-controlled '{name}' flows into the response header without CR/LF stripping",
+                        trace=taint_path(origins, name, function.start_line)
+                    ))
+
+        return findings
+
+
+SERVER_SETUP = re.compile(
+    r'\b(?:\w+\.)?ListenAndServe(?:TLS)?\s*\(|(?<!\*)\bhttp\.Server\s*\{'
+    r'|\bgin\.(?:New|Default)\s*\(\s*\)|\becho\.New\s*\(\s*\)|\bfiber\.New\s*\('
+)
+SERVES_HTML = re.compile(
+    r'"html/template"|\.ExecuteTemplate\s*\(|\bc\.HTML\s*\(|\.LoadHTML(?:Glob|Files)\s*\(|"text/html'
+    r'|\bhttp\.FileServer\s*\(|\btempl\.Handler\s*\(|\.Render\s*\(\s*\w+\s*,\s*"'
+)
+# header -> explicit header names, then middleware options and defaults that set it
+SECURITY_HEADERS: Dict[str, re.Pattern] = {
+    "Content-Security-Policy": re.compile(
+        r'"Content-Security-Policy"|\bContentSecurityPolicy\s*:\s*"[^"]+"|\bsecure\.DefaultConfig\s*\('
+    ),
+    "Strict-Transport-Security": re.compile(
+        r'"Strict-Transport-Security"|\bSTSSeconds\s*:\s*[1-9]|\bHSTSMaxAge\s*:\s*[1-9]|\bsecure\.DefaultConfig\s*\('
+    ),
+    "X-Content-Type-Options": re.compile(
+        r'"X-Content-Type-Options"|\bContentTypeNosniff\s*:\s*(?:true|"nosniff")|\bmiddleware\.Secure\s*\(\s*\)'
+        r'|\bhelmet\.New\s*\(\s*\)|\bsecure\.DefaultConfig\s*\('
+    ),
+    "frame-ancestors or X-Frame-Options": re.compile(
+        r'"X-Frame-Options"|frame-ancestors|\bFrameDeny\s*:\s*true|\bCustomFrameOptionsValue\s*:|\bXFrameOptions\s*:\s*"[^"]+"'
+        r'|\bmiddleware\.Secure\s*\(\s*\)|\bhelmet\.New\s*\(\s*\)|\bsecure\.DefaultConfig\s*\('
+    ),
+}
+
+
+@register_rule
+class MissingSecurityHeadersRule(Rule):
+    rule_id = "missing-security-headers"
+    name = "HTML server without security headers"
+    vuln_type = "Missing Security Headers"
+    severity = "medium"
+    fix_complexity = "trivial"
+    cwe_id = "CWE-693"
+    description = ("A server that renders HTML sets no Content-Security-Policy, Strict-Transport-Security, "
+                   "X-Content-Type-Options, or frame-ancestors / X-Frame-Options, leaving XSS, downgrade, MIME-sniffing, "
+                   "and clickjacking defenses off")
+    remediation = ("Add security header middleware at the server setup (unrolled/secure, gin-contrib/secure, echo "
+                   "middleware.SecureWithConfig, fiber helmet) or a wrapper that sets the headers on every response: a "
+                   "CSP with frame-ancestors, HSTS, and X-Content-Type-Options: nosniff")
+    example = 'http.HandleFunc("/", renderDashboard)\nlog.Fatal(http.ListenAndServe(":8080", nil))'
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+        setups = list(ctx.search(SERVER_SETUP))
+        if not setups:
+            return findings
+        sources = ctx.module_sources()
+        if not any(SERVES_HTML.search(source) for source in sources):
+            return findings  # JSON APIs do not need a CSP or framing protection
+
+        missing = [
+            header for header, pattern in SECURITY_HEADERS.items()
+            if not any(pattern.search(source) for source in sources)
+        ]
+        if not missing:
+            return findings
+
+        # one finding per server setup: the first setup call in each function
+        seen: Set[str] = set()
+        for line, match in setups:
+            function = ctx.enclosing_function(line)
+            key = function.name if function else ""
+            if key in seen:
+                continue
+            seen.add(key)
+            only_hsts = missing == ["Strict-Transport-Security"]
+            findings.append(self.finding(
+                ctx, line, match=match,
+                severity="low" if only_hsts else None, confidence=0.6 if only_hsts else 0.7,
+                description=f"Server renders HTML but no response sets {', '.join(missing)}"
+            ))
+        return findings
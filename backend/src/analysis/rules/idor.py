@@ -0,0 +1,185 @@
+"""
+IDOR rules - Records fetched or changed by a client-supplied ID with no ownership check
+Whether a record belongs to the caller depends on the data model, so this is a heuristic and every finding
+is marked for human review. A handler is reported when an ID read from the path or query string reaches a
+lookup by primary key (WHERE id = ?, a GORM finder, a FindByID-style repository method, a Mongo _id filter)
+and neither the query, the handler, nor the functions it calls in the same file compare the record with the
+caller or call an authorization check. Project scans then drop the findings whose call chain has such a
+check in another file (drop_authorized).
+"""
+
+import linecache
+import re
+from typing import Any, List, Optional, Sequence, Set, Tuple
+
+from ..constants import ConstantFolder, package_constants
+from .base import Rule, SourceContext, StaticFinding, register_rule
+from .injection import SQL_CALL, query_index
+from .taint import references, split_args, taint_origins, taint_path
+
+RULE_ID = "idor"
+
+# request values named like a record ID: c.Param("id"), chi.URLParam(r, "orderID"), mux.Vars(r)["id"], ...
+ID_READ = re.compile(
+    r'(?:\b(?:c|ctx)\.(?:Param|Params|ParamsInt|Query|DefaultQuery|QueryParam)|\bchi\.URLParam|\.PathValue'
+    r'|\.URL\.Query\(\)\.Get|\.FormValue)\s*\((?:\s*\w+\s*,)?\s*"(\w*?(?:_id|_uuid|Id|ID|UUID)|id|uuid)"'
+    r'|\b(?:mux\.Vars\(\s*\w+\s*\)|\w*[vV]ars)\s*\[\s*"(\w*?(?:_id|_uuid|Id|ID|UUID)|id|uuid)"\s*\]'
+)
+# SQL by primary key: WHERE id = ? (also $1, @id, :id)
+PRIMARY_KEY_QUERY = re.compile(r'\bWHERE\s+(?:\w+\.)?(?:id|uuid)\s*=\s*(?:\?|\$\d+|@\w+|:\w+)', re.IGNORECASE)
+MUTATING_QUERY = re.compile(r'^\s*(?:UPDATE|DELETE)\b', re.IGNORECASE)
+# GORM: db.First(&order, id), db.Delete(&order, id), db.Where("id = ?", id)
+GORM_BY_ID = re.compile(r'\.(First|Take|Last|Find|Delete)\s*\(')
+GORM_WHERE_ID = re.compile(r'\.Where\s*\(\s*"(?:\w+\.)?(?:id|uuid)\s*=\s*\?"\s*,')
+REPOSITORY_BY_ID = re.compile(r'\.((?:Get|Find|Fetch|Load|Read|Lookup|Delete|Remove|Update|Archive)\w*By(?:ID|Id|UUID))\s*\(')
+MONGO_ID_FILTER = re.compile(r'"_id"\s*[:,]\s*([^,}\n]+)')
+MUTATING_CALL = re.compile(r'^(?:Delete|Remove|Update|Archive)|\.(?:Delete\w*|Update\w*|Replace\w*|Remove\w*)\s*\(')
+
+OWNER_FIELDS = r'(?:Owner|User|Tenant|Account|Org|Organization|Author|Creator|Customer|CreatedBy)'
+# the record compared with the caller, or the query scoped to the caller
+OWNERSHIP_CHECK = re.compile(
+    rf'\.{OWNER_FIELDS}(?:ID|Id|UUID)?\s*[!=]=|[!=]=\s*[\w.()]*\.{OWNER_FIELDS}(?:ID|Id|UUID)\b'
+    r'|\b(?:user|owner|tenant|account|org|organization|author|creator|customer)_?id\s*=\s*(?:\?|\$\d+|@\w+|:\w+)'
+    r'|\bcreated_by\s*=\s*(?:\?|\$\d+|@\w+|:\w+)'
+    r'|"(?:user|owner|tenant|account|org|author|customer)_?id"\s*:'
+    rf'|\b{OWNER_FIELDS}(?:ID|Id|UUID)\s*:\s*\w',
+    re.IGNORECASE
+)
+AUTHORIZATION_CALL = re.compile(
+    r'\b(?:[aA]uthori[sz]e\w*|[cC]an(?:Access|View|Read|Edit|Write|Update|Delete|Modify|Manage)\w*'
+    r'|[cC]heck(?:Access|Permission|Owner|Ownership|Authorization)\w*|[hH]as(?:Permission|Access|Role)\w*'
+    r'|[rR]equire(?:Permission|Role|Owner|Ownership)\w*|[iI]sOwner\w*|[eE]nsure(?:Owner|Access)\w*'
+    r'|[vV]erify(?:Owner|Ownership|Access)\w*|[oO]wnedBy|[bB]elongsTo)\s*\('
+    r'|\.Enforce\s*\(|\bIsAdmin\s*\('
+)
+CALLER = r'(?:user|claims|session|principal|current|caller|viewer|me|account|auth)'
+
+
+def checks_authorization(code: str) -> bool:
+    return bool(OWNERSHIP_CHECK.search(code) or AUTHORIZATION_CALL.search(code))
+
+
+def compares_with_caller(body: str, names: Set[str]) -> bool:
+    """Whether the requested ID itself is compared with the caller: if id != claims.UserID"""
+    for name in names:
+        comparison = re.search(
+            rf'\b{re.escape(name)}\s*[!=]=\s*[\w.()]*{CALLER}|{CALLER}[\w.()]*\s*[!=]=\s*{re.escape(name)}\b',
+            body, re.IGNORECASE
+        )
+        if comparison:
+            return True
+    return False
+
+
+@register_rule
+class IDORRule(Rule):
+    rule_id = RULE_ID
+    name = "Record accessed by client-supplied ID without an ownership check"
+    vuln_type = "Insecure Direct Object Reference"
+    severity = "medium"
+    cwe_id = "CWE-639"
+    needs_review = True
+    description = ("A handler loads or changes a record by an ID taken from the request without checking that the record "
+                   "belongs to the caller, so any user can reach another user's records by changing the ID")
+    remediation = ("Scope the lookup to the caller (WHERE id = ? AND owner_id = ?) or compare the loaded record's owner with "
+                   "the authenticated user before returning or changing it; keep the check in one authorization helper "
+                   "every handler goes through")
+    example = 'id := c.Param("id")\ndb.First(&order, id)\nc.JSON(200, order)'
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+        if not ID_READ.search(ctx.code):
+            return findings
+        constants = package_constants(ctx.code)
+
+        for function in ctx.functions():
+            if not ID_READ.search(function.body):
+                continue
+            origins = taint_origins(function.body, sources=ID_READ)
+            if compares_with_caller(function.body, set(origins)) or self.authorized(ctx, function):
+                continue
+            folder = ConstantFolder(function.body, function.start_line, constants)
+            start, end = ctx.span(function)
+
+            for line, match, argument, mutates in self.lookups(ctx, start, end, folder):
+                name = references(argument, origins)
+                if not name and not ID_READ.search(argument):
+                    continue
+                trace = taint_path(origins, name, function.start_line) if name else []
+                what = "changes" if mutates else "loads"
+                findings.append(self.finding(
+                    ctx, line, match=match, severity="high" if mutates else None, confidence=0.4,
+                    trace=trace + [line],
+                    description=f"{function.name} {what} a record by the client-supplied ID {argument.strip()} with no "
+                                "ownership or authorization check in the handler or the functions it calls"
+                ))
+                break  # one finding per handler
+        return findings
+
+    def lookups(self, ctx: SourceContext, start: int, end: int, folder: ConstantFolder) -> List[Tuple[int, re.Match, str, bool]]:
+        """(line, match, ID argument, whether it changes the record) of each lookup by primary key"""
+        lookups = []
+        for line, match in ctx.search(SQL_CALL, start, end):
+            args = split_args(ctx.call_args(match.end() - 1))
+            index = query_index(match.group(2))
+            if len(args) <= index + 1:
+                continue
+            query = folder.value_of(args[index], line)
+            texts = query.strings if query.is_constant else frozenset({args[index]})
+            if any(PRIMARY_KEY_QUERY.search(q) and not OWNERSHIP_CHECK.search(q) for q in texts):
+                mutates = any(MUTATING_QUERY.match(q.strip('"`')) for q in texts)
+                lookups.extend((line, match, arg, mutates) for arg in args[index + 1:])
+
+        for line, match in ctx.search(GORM_BY_ID, start, end):
+            args = split_args(ctx.call_args(match.end() - 1))
+            if len(args) == 2 and args[0].startswith('&') and not args[1].startswith(('"', '`')):
+                lookups.append((line, match, args[1], match.group(1) == 'Delete'))
+        for line, match in ctx.search(GORM_WHERE_ID, start, end):
+            args = split_args(ctx.call_args(match.end() - 1))
+            statement = ctx.line(line)
+            if len(args) == 2 and not OWNERSHIP_CHECK.search(statement):
+                lookups.append((line, match, args[1], bool(MUTATING_CALL.search(statement))))
+
+        for line, match in ctx.search(REPOSITORY_BY_ID, start, end):
+            for arg in split_args(ctx.call_args(match.end() - 1)):
+                lookups.append((line, match, arg, bool(MUTATING_CALL.match(match.group(1)))))
+        for line, match in ctx.search(MONGO_ID_FILTER, start, end):
+            lookups.append((line, match, match.group(1), bool(MUTATING_CALL.search(ctx.line(line)))))
+
+        return sorted(lookups, key=lambda lookup: lookup[0])
+
+    def authorized(self, ctx: SourceContext, function) -> bool:
+        """Whether the function, or a function of this file it calls (transitively), checks ownership"""
+        return any(checks_authorization(f.body.split('\n', 1)[-1]) for f in ctx.called_functions(function))
+
+
+def function_source(node: Any) -> str:
+    linecache.checkcache(node.file_path)
+    return ''.join(linecache.getlines(node.file_path)[node.start_line:node.end_line])
+
+
+def authorized_in_chain(graph: Any, file_path: str, line_number: int, depth: int = 2) -> Optional[str]:
+    """Label of a function on the finding's call chain (from its entry point, plus what each of those calls,
+    depth levels down) that checks ownership or authorization; None when there is none"""
+    node = graph.function_at(file_path, line_number)
+    if not node:
+        return None
+    chain = graph.entry_path(node.key) or [node]
+    frontier = [n.key for n in chain]
+    seen: Set[str] = set(frontier)
+    for _ in range(depth):
+        frontier = [c.key for key in frontier for c in graph.callees(key) if c.key not in seen]
+        seen.update(frontier)
+    for key in sorted(seen - {node.key}):
+        if checks_authorization(function_source(graph.nodes[key])):
+            return graph.nodes[key].label()
+    return None
+
+
+def drop_authorized(vulnerabilities: Sequence[Any], graph: Any) -> List[Any]:
+    """Drop IDOR findings whose handler reaches an ownership or authorization check in another file,
+    which the per-file rule cannot see. graph is the project's CallGraph."""
+    return [
+        v for v in vulnerabilities
+        if v.rule_id != RULE_ID or not authorized_in_chain(graph, v.file_path, v.line_number)
+    ]
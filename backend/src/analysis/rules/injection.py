@@ -0,0 +1,220 @@
+"""
+Injection rules - SQL and ORM queries, shell commands, LDAP filters, and XPath expressions assembled from
+dynamic strings
+Sink arguments are constant-folded first, so a query built only from constants (or through the sink's
+escaping function) is proven safe, one containing user input is reported with its derivation, and anything
+else is a weaker finding
+"""
+
+import re
+from typing import FrozenSet, List, Optional
+
+from ..constants import ConstantFolder, Value, package_constants
+from .base import Rule, SourceContext, StaticFinding, register_rule
+from .errors import DB_RECEIVERS, TYPED_CALLEES
+from .taint import split_args
+
+
+SQL_CALL = re.compile(
+    r'(?<![\w.])((?:\w+\.)*\w+)\.(Query|QueryRow|QueryContext|QueryRowContext|Exec|ExecContext|Prepare|PrepareContext'
+    r'|Get|GetContext|Select|SelectContext|Queryx|QueryRowx|QueryxContext|QueryRowxContext|MustExec|MustExecContext'
+    r'|NamedExec|NamedExecContext|NamedQuery|NamedQueryContext|PrepareNamed|PrepareNamedContext|Preparex|PreparexContext)\s*\('
+)
+SQL_RECEIVER = re.compile(rf'(?:\w+\.)*{DB_RECEIVERS}$')
+# GORM methods whose first argument is SQL (Where also takes a struct or map, which is parameterized), and
+# the finders whose inline conditions are: db.First(&user, "id = " + id)
+GORM_CALL = re.compile(
+    r'(?:(?<![\w.])((?:\w+\.)*\w+)|\))\.(Raw|Exec|Where|Or|Not|Having|Joins|Order|Group|Select|Distinct|Pluck|Table'
+    r'|First|Last|Take|Find)\s*\('
+)
+GORM_IMPORTS = ('gorm.io/gorm', 'github.com/jinzhu/gorm')
+GORM_FINDERS = ('First', 'Last', 'Take', 'Find')
+NOT_A_STRING = re.compile(r'^(?:&|map\[|\[\]|[\w.]+\s*\{|\d+$)')
+COMMAND_CALL = re.compile(r'\bexec\.(Command|CommandContext)\s*\(')
+# go-ldap: NewSearchRequest(baseDN, scope, deref, sizeLimit, timeLimit, typesOnly, filter, attributes, controls)
+LDAP_SEARCH = re.compile(r'\bldap\.NewSearchRequest\s*\(')
+LDAP_FILTER_INDEX = 6
+# antchfu/xpath and the xmlquery / htmlquery helpers built on it
+XPATH_CALL = re.compile(r'\b(xpath\.(?:Compile|MustCompile|CompileWithNS)|(?:xmlquery|htmlquery)\.(?:Find|FindOne|Query|QueryAll|FindEach|FindEachWithBreak))\s*\(')
+NUMERIC_CONVERSIONS = frozenset({"strconv.Atoi", "strconv.ParseInt", "strconv.ParseUint", "strconv.ParseFloat", "strconv.ParseBool"})
+SHELLS = re.compile(r'(?:.*/)?(?:sh|bash|zsh|dash|ksh|cmd(?:\.exe)?|powershell(?:\.exe)?|pwsh)$')
+
+
+def query_index(method: str) -> int:
+    """Position of the query among the arguments: after a context, and after the destination for sqlx Get/Select"""
+    index = 1 if method.endswith('Context') else 0
+    return index + 1 if method.startswith(('Get', 'Select')) else index
+
+
+def describe(value: Value) -> str:
+    return f" ({value.source})" if value.source else ""
+
+
+class FoldingRule(Rule):
+    """Shared sink handling: constant (or escaped) arguments are safe, tainted ones definite, the rest uncertain"""
+    sanitizers: FrozenSet[str] = frozenset()
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+        constants = package_constants(ctx.code)
+
+        for function in ctx.functions():
+            folder = None
+            start, end = ctx.span(function)
+            for line, match in ctx.search(self.sink, start, end):
+                argument = self.argument(ctx, match)
+                if argument is None:
+                    continue
+                folder = folder or ConstantFolder(function.body, function.start_line, constants, self.sanitizers)
+                value = folder.value_of(argument, line)
+                if value.is_safe:
+                    continue
+                findings.append(self.report(ctx, line, match, value))
+
+        return findings
+
+    def argument(self, ctx: SourceContext, match: re.Match) -> Optional[str]:
+        raise NotImplementedError
+
+    def report(self, ctx: SourceContext, line: int, match: re.Match, value: Value) -> StaticFinding:
+        if value.is_tainted:
+            return self.finding(
+                ctx, line, match=match, confidence=0.9,
+                description=self.tainted_description + describe(value),
+                trace=list(value.lines)
+            )
+        return self.finding(
+            ctx, line, match=match, severity="medium", confidence=0.5,
+            description=self.dynamic_description + describe(value)
+        )
+
+
+@register_rule
+class SQLInjectionRule(FoldingRule):
+    rule_id = "sql-injection"
+    name = "SQL query built from dynamic strings"
+    vuln_type = "SQL Injection"
+    severity = "high"
+    cwe_id = "CWE-89"
+    description = "A SQL query is assembled by concatenation or formatting instead of bind parameters"
+    remediation = "Pass user values as query arguments (? or $1 placeholders); choose identifiers such as table or column names from a fixed allowlist"
+    example = 'db.Query("SELECT * FROM users WHERE name = \'" + r.FormValue("name") + "\'")'
+    sink = SQL_CALL
+    tainted_description = "SQL query is built from user input"
+    dynamic_description = "SQL query is built from values that could not be resolved to constants"
+
+    def argument(self, ctx: SourceContext, match: re.Match) -> Optional[str]:
+        receiver, method = match.groups()
+        callees = ctx.types.concrete_callees(f"{receiver}.{method}", match.start()) if ctx.types else []
+        if callees:
+            if not any(TYPED_CALLEES["database query"].match(c) or c.startswith("github.com/jmoiron/sqlx.") for c in callees):
+                return None
+        elif not SQL_RECEIVER.match(receiver):
+            return None
+        args = split_args(ctx.call_args(match.end() - 1))
+        index = query_index(method)
+        return args[index] if len(args) > index else None
+
+
+@register_rule
+class CommandInjectionRule(FoldingRule):
+    rule_id = "command-injection"
+    name = "Shell command built from dynamic strings"
+    vuln_type = "Command Injection"
+    severity = "high"
+    cwe_id = "CWE-78"
+    description = "A program path or shell script passed to exec.Command is assembled from dynamic strings"
+    remediation = "Run a fixed program with user values as separate arguments instead of through sh -c, and validate them against an allowlist"
+    example = 'exec.Command("sh", "-c", "ping -c 4 " + r.URL.Query().Get("host"))'
+    sink = COMMAND_CALL
+    tainted_description = "Command is built from user input"
+    dynamic_description = "Command is built from values that could not be resolved to constants"
+
+    def argument(self, ctx: SourceContext, match: re.Match) -> Optional[str]:
+        args = split_args(ctx.call_args(match.end() - 1))
+        if match.group(1) == "CommandContext":
+            args = args[1:]
+        if not args:
+            return None
+        program = args[0].strip().strip('"`')
+        # sh -c SCRIPT: the script is what matters; otherwise the program path itself
+        if SHELLS.match(program) and len(args) > 2 and args[1].strip().strip('"`') in ('-c', '/c', '/C', '-Command'):
+            return args[2]
+        return args[0]
+
+
+@register_rule
+class ORMInjectionRule(FoldingRule):
+    rule_id = "orm-injection"
+    name = "GORM query clause built from dynamic strings"
+    vuln_type = "SQL Injection"
+    severity = "high"
+    cwe_id = "CWE-89"
+    description = ("A string passed to a GORM method that splices it into SQL (Raw, Where, Order, Group, Select, "
+                   "inline finder conditions) is assembled from dynamic strings")
+    remediation = ("Keep the SQL in a constant with ? placeholders and pass values as the following arguments "
+                   "(db.Where(\"name = ?\", name)), or pass a struct or map; Order, Group, Select, and Table are never "
+                   "parameterized, so pick column names from a fixed allowlist")
+    example = 'db.Order(r.URL.Query().Get("sort")).Find(&users)'
+    sink = GORM_CALL
+    sanitizers = NUMERIC_CONVERSIONS  # db.First(&user, id) with an int id is a primary key lookup
+    tainted_description = "GORM query clause is built from user input"
+    dynamic_description = "GORM query clause is built from values that could not be resolved to constants"
+
+    def applies_to(self, ctx: SourceContext) -> bool:
+        return super().applies_to(ctx) and ctx.imports_any(GORM_IMPORTS)
+
+    def argument(self, ctx: SourceContext, match: re.Match) -> Optional[str]:
+        receiver, method = match.groups()
+        callees = ctx.types.concrete_callees(f"{receiver}.{method}", match.start()) if receiver and ctx.types else []
+        if callees:
+            if not any(c.startswith(tuple(f"{path}.DB." for path in GORM_IMPORTS)) for c in callees):
+                return None
+        elif method == "Exec" and receiver and SQL_RECEIVER.match(receiver):
+            return None  # reported by sql-injection
+        args = split_args(ctx.call_args(match.end() - 1))
+        index = 1 if method in GORM_FINDERS else 0
+        if len(args) <= index or NOT_A_STRING.match(args[index].strip()):
+            return None
+        return args[index]
+
+
+@register_rule
+class LDAPInjectionRule(FoldingRule):
+    rule_id = "ldap-injection"
+    name = "LDAP search filter built from dynamic strings"
+    vuln_type = "LDAP Injection"
+    severity = "high"
+    cwe_id = "CWE-90"
+    description = "An LDAP search filter is assembled from dynamic strings without ldap.EscapeFilter"
+    remediation = "Escape every value inserted into a filter with ldap.EscapeFilter (and DN components with ldap.EscapeDN)"
+    example = 'ldap.NewSearchRequest(baseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false, "(uid="+r.FormValue("user")+")", nil, nil)'
+    sink = LDAP_SEARCH
+    sanitizers = frozenset({"ldap.EscapeFilter"})
+    tainted_description = "LDAP filter is built from user input"
+    dynamic_description = "LDAP filter is built from values that could not be resolved to constants"
+
+    def argument(self, ctx: SourceContext, match: re.Match) -> Optional[str]:
+        args = split_args(ctx.call_args(match.end() - 1))
+        return args[LDAP_FILTER_INDEX] if len(args) > LDAP_FILTER_INDEX else None
+
+
+@register_rule
+class XPathInjectionRule(FoldingRule):
+    rule_id = "xpath-injection"
+    name = "XPath expression built from dynamic strings"
+    vuln_type = "XPath Injection"
+    severity = "high"
+    cwe_id = "CWE-643"
+    description = "An XPath expression is assembled from dynamic strings; Go's XPath libraries have no escaping or variable binding"
+    remediation = ("Query with a constant expression and compare user values in Go, or validate them against a strict "
+                   "pattern (e.g. ^[A-Za-z0-9_-]+$) before building the expression")
+    example = 'xmlquery.FindOne(doc, "//user[name=\'"+r.FormValue("name")+"\']")'
+    sink = XPATH_CALL
+    tainted_description = "XPath expression is built from user input"
+    dynamic_description = "XPath expression is built from values that could not be resolved to constants"
+
+    def argument(self, ctx: SourceContext, match: re.Match) -> Optional[str]:
+        args = split_args(ctx.call_args(match.end() - 1))
+        index = 0 if match.group(1).startswith('xpath.') else 1
+        return args[index] if len(args) > index else None
@@ -0,0 +1,125 @@
+"""
+Kotlin rules - SQL and shell commands built from user input, and unsafe deserialization, in Kotlin backends
+(Spring, Ktor, JDBC/JPA, Exposed) and Android apps (SQLiteDatabase, intents)
+Request data is followed through assignments within each function (see dialects.py), including Spring
+handler parameters annotated @RequestParam, @PathVariable, @RequestBody, @RequestHeader, or @CookieValue.
+"""
+
+import re
+from typing import List, Tuple
+
+from .base import SourceContext, StaticFinding, register_rule
+from .dialects import KOTLIN, DialectRule, call_arguments
+from .injection import SHELLS
+from .taint import IDENTIFIER, split_args, strip_strings
+
+# numbers and UUIDs cannot carry SQL or shell syntax
+NUMERIC = re.compile(
+    r'(?:[\w.]|\[[^\]]*\])+\.(?:toInt|toLong|toIntOrNull|toLongOrNull|toDouble|toBoolean)\(\)'
+    r'|\b(?:Integer\.parseInt|Long\.parseLong|UUID\.fromString)\s*\([^()]*\)'
+)
+SQL_METHODS = re.compile(
+    r'\.(?:rawQuery|execSQL|executeQuery|executeUpdate|executeLargeUpdate|addBatch|prepareStatement|prepareCall'
+    r'|createQuery|createNativeQuery|queryForObject|queryForList|queryForMap|queryForRowSet)\s*\('
+)
+# query/update/execute are SQL only on a database-looking receiver (Android's ContentResolver.query takes a URI)
+SQL_RECEIVER_METHODS = re.compile(
+    r'(?<![\w.])(?:\w*(?:[Jj]dbc|[Tt]emplate|[Ss]tatement|[Ss]tmt|[Cc]onnection|[Dd]atabase)\w*|conn|db|database|em|entityManager)'
+    r'\.(?:query|update|batchUpdate|execute)\s*\('
+)
+EXPOSED_EXEC = re.compile(r'(?<![\w.])exec\s*\(')
+COLLECTION_BUILDERS = re.compile(r'^(?:listOf|arrayOf|mutableListOf|arrayListOf)\s*\((.*)\)$', re.DOTALL)
+INPUT_FILTER = re.compile(r'\bObjectInputFilter\b|\bsetObjectInputFilter\s*\(|\.readObject\s*\(\s*\)\s*as\?\s*\w+\s*\?:\s*throw')
+XSTREAM_ALLOWLIST = re.compile(r'\.(?:allowTypes|allowTypesByWildcard|allowTypesByRegExp|addPermission|setupDefaultSecurity)\s*\(')
+POLYMORPHIC_TYPING = re.compile(
+    r'\.(?:enableDefaultTyping|enableDefaultTypingAsProperty|activateDefaultTyping|activateDefaultTypingAsProperty)\s*\('
+    r'|JsonTypeInfo\.Id\.(?:CLASS|MINIMAL_CLASS)\b'
+)
+
+
+@register_rule
+class KotlinSQLInjectionRule(DialectRule):
+    rule_id = "kotlin-sql-injection"
+    name = "Kotlin SQL query built from user input"
+    vuln_type = "SQL Injection"
+    severity = "high"
+    cwe_id = "CWE-89"
+    description = "A SQL or JPQL query is assembled from user input with string templates or concatenation"
+    remediation = ("Use bind parameters: ? placeholders with PreparedStatement or JdbcTemplate arguments, selectionArgs for "
+                   "rawQuery, setParameter for JPA; pick identifiers such as column names from a fixed allowlist")
+    example = 'db.rawQuery("SELECT * FROM notes WHERE title = \'${intent.getStringExtra("q")}\'", null)'
+    languages = ('kotlin',)
+    dialect = KOTLIN
+    sinks = ((SQL_METHODS, 0), (SQL_RECEIVER_METHODS, 0), (EXPOSED_EXEC, 0))
+    sanitizers = NUMERIC
+    tainted_description = "SQL query is built from user input"
+    dynamic_description = "SQL query is built with string templates or concatenation from values that are not constants"
+
+
+@register_rule
+class KotlinCommandInjectionRule(DialectRule):
+    rule_id = "kotlin-command-injection"
+    name = "Kotlin shell command built from user input"
+    vuln_type = "Command Injection"
+    severity = "high"
+    cwe_id = "CWE-78"
+    description = "A command run with Runtime.exec or ProcessBuilder is assembled from user input"
+    remediation = "Run a fixed program with user values as separate arguments instead of through sh -c, and validate them against an allowlist"
+    example = 'ProcessBuilder("sh", "-c", "ping -c 4 " + call.parameters["host"]).start()'
+    languages = ('kotlin',)
+    dialect = KOTLIN
+    sinks = ((re.compile(r'\bRuntime\.getRuntime\(\)\.exec\s*\('), 0), (re.compile(r'(?<![\w.])ProcessBuilder\s*\('), 0))
+    sanitizers = NUMERIC
+    tainted_description = "Command is built from user input"
+    dynamic_description = "Command is built with string templates or concatenation from values that are not constants"
+
+    def arguments(self, lines: Tuple[str, ...], index: int, match: re.Match) -> List[str]:
+        """The program path, or the script when the program is a shell run with -c; arguments may come as
+        one listOf/arrayOf"""
+        args = split_args(call_arguments(lines, index, match.end() - 1))
+        builder = COLLECTION_BUILDERS.match(args[0].strip()) if len(args) == 1 else None
+        if builder:
+            args = split_args(builder.group(1))
+        if not args:
+            return []
+        program = args[0].strip().strip('"')
+        if SHELLS.match(program) and len(args) > 2 and args[1].strip().strip('"') in ('-c', '/c', '/C', '-Command'):
+            return [args[2]]
+        return [args[0]]
+
+
+@register_rule
+class KotlinDeserializationRule(DialectRule):
+    rule_id = "kotlin-deserialization"
+    name = "Kotlin deserialization of untrusted data"
+    vuln_type = "Insecure Deserialization"
+    severity = "high"
+    cwe_id = "CWE-502"
+    description = ("Java serialization (ObjectInputStream) or XStream reads objects of any class, or Jackson polymorphic "
+                   "typing lets the input name the class: a gadget chain on the classpath turns that into code execution")
+    remediation = ("Exchange plain data (JSON into fixed DTO classes) instead; where Java serialization must stay, set an "
+                   "ObjectInputFilter allowlist, call XStream.allowTypes, and drop Jackson default typing")
+    example = 'ObjectInputStream(request.inputStream).readObject()'
+    languages = ('kotlin',)
+    dialect = KOTLIN
+    sinks = ((re.compile(r'(?<![\w.])ObjectInputStream\s*\('), 0), (re.compile(r'\.fromXML\s*\('), 0))
+    tainted_description = "Object stream is deserialized from user input"
+    dynamic_description = "Object stream is deserialized without a class allowlist"
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = [f for f in super().check(ctx) if not self.filtered(ctx, f)]
+        for line, match in ctx.search(POLYMORPHIC_TYPING):
+            findings.append(self.finding(
+                ctx, line, match=match, confidence=0.8,
+                description="Jackson polymorphic typing lets the JSON name the class to instantiate"
+            ))
+        return sorted(findings, key=lambda f: f.line_number)
+
+    def filtered(self, ctx: SourceContext, finding: StaticFinding) -> bool:
+        if 'fromXML' in finding.code_snippet:
+            return bool(XSTREAM_ALLOWLIST.search(ctx.code))
+        return bool(INPUT_FILTER.search(ctx.code))
+
+    def is_dynamic(self, expr: str) -> bool:
+        # any stream that is not a literal: its bytes come from a file, socket, cache, or request
+        return bool(IDENTIFIER.search(strip_strings(expr)))
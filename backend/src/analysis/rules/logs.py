@@ -0,0 +1,131 @@
+"""
+Logging rules - Sensitive data written to logs and CRLF log injection
+"""
+
+import re
+from typing import List, Set
+
+from .base import Rule, SourceContext, StaticFinding, register_rule
+from .taint import ASSIGNMENT, IDENTIFIER, assigned_names, strip_strings, taint_origins, taint_path
+
+
+DEFAULT_SENSITIVE_FIELD_NAMES = [
+    "password", "passwd", "secret", "token", "apikey", "privatekey",
+    "credential", "ssn", "creditcard", "cardnumber", "cvv", "authorization",
+    "sessionid", "cookie"
+]
+
+LOG_CALL = re.compile(
+    r'\b(?:log|logger|logrus|zap|sugar|slog|l)\.'
+    r'(?:Print|Fatal|Panic|Info|Debug|Warn|Error|Trace|Log|With)\w*\s*\('
+)
+FIELD_KEY = re.compile(r'(?:\bzap\.\w+|\.WithField|\bslog\.\w+)\(\s*"([^"]+)"')
+LOG_SANITIZERS = re.compile(r'strings\.(?:ReplaceAll|Replace|NewReplacer)\(|strconv\.Quote\(|%q')
+
+
+def _normalize(name: str) -> str:
+    return name.lower().replace('_', '').replace('-', '')
+
+
+def is_sensitive_name(name: str, sensitive_names: List[str]) -> bool:
+    normalized = _normalize(name)
+    return any(term in normalized for term in sensitive_names)
+
+
+def sensitive_field_names(ctx: SourceContext) -> List[str]:
+    names = ctx.options.get("sensitive_field_names") or DEFAULT_SENSITIVE_FIELD_NAMES
+    return [_normalize(n) for n in names]
+
+
+def sensitive_variables(body: str, sensitive_names: List[str]) -> Set[str]:
+    sensitive = set()
+
+    for _ in range(2):
+        for line in body.split('\n'):
+            match = ASSIGNMENT.match(line)
+            if not match:
+                continue
+            lhs, rhs = match.groups()
+            literals = re.findall(r'"([^"]*)"', rhs)
+            identifiers = re.findall(r'[A-Za-z_]\w*', strip_strings(rhs))
+            if (any(is_sensitive_name(lit, sensitive_names) for lit in literals)
+                    or any(is_sensitive_name(i, sensitive_names) or i in sensitive for i in identifiers)):
+                sensitive |= assigned_names(lhs)
+
+    return sensitive
+
+
+@register_rule
+class SensitiveDataLoggedRule(Rule):
+    rule_id = "sensitive-data-logged"
+    name = "Sensitive data written to logs"
+    vuln_type = "Sensitive Data in Logs"
+    severity = "medium"
+    cwe_id = "CWE-532"
+    description = "Credentials, tokens, or personal data flow into a log call and end up in log storage"
+    remediation = "Remove the value from the log call or replace it with a redacted placeholder"
+    example = 'log.Printf("login user=%s password=%s", user, password)'
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        sensitive_names = sensitive_field_names(ctx)
+        findings = []
+
+        for line, match in ctx.search(LOG_CALL):
+            args = ctx.call_args(match.end() - 1)
+            function = ctx.enclosing_function(line)
+            sensitive = sensitive_variables(function.body, sensitive_names) if function else set()
+
+            leaked = None
+            for key in FIELD_KEY.findall(args):
+                if is_sensitive_name(key, sensitive_names):
+                    leaked = key
+                    break
+
+            if not leaked:
+                for token in re.findall(r'[A-Za-z_]\w*', strip_strings(args)):
+                    if token in sensitive or is_sensitive_name(token, sensitive_names):
+                        leaked = token
+                        break
+
+            if leaked:
+                findings.append(self.finding(
+                    ctx, line, match=match,
+                    description=f"Sensitive value '{leaked}' is written to the log"
+                ))
+
+        return findings
+
+
+@register_rule
+class LogInjectionRule(Rule):
+    rule_id = "log-injection"
+    name = "User input logged without CR/LF neutralization"
+    vuln_type = "Log Injection"
+    severity = "low"
+    cwe_id = "CWE-117"
+    description = "User-controlled input is written to the log without stripping CR/LF, allowing forged log entries"
+    remediation = "Strip or escape \\r and \\n from user input (strings.ReplaceAll or %q) before logging it"
+    example = 'log.Printf("search for %s", r.URL.Query().Get("q"))'
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+
+        for function in ctx.functions():
+            tainted = taint_origins(function.body)
+            if not tainted:
+                continue
+
+            for line, match in ctx.search(LOG_CALL, *ctx.span(function)):
+                args = ctx.call_args(match.end() - 1)
+                if LOG_SANITIZERS.search(args):
+                    continue
+                names = {m.group(0) for m in IDENTIFIER.finditer(strip_strings(args))}
+                hit = sorted(names & tainted.keys())
+                if hit:
+                    findings.append(self.finding(
+                        ctx, line, match=match,
+                        description=f"User-controlled '{hit[0]}' is logged without CR/LF neutralization",
+                        trace=taint_path(tainted, hit[0], function.start_line)
+                    ))
+
+        return findings
@@ -0,0 +1,311 @@
+"""
+Memory-safety rules - Unbounded copies, format strings, overflowing allocation sizes, and use-after-free in C and C++
+Calls are checked on the C dialect's rewritten code (see dialects.py): data read from argv, the environment,
+stdin, a file, or a socket reaching the call is a finding traced back to the read, any other value that is
+not a constant a weaker one. Frees are followed line by line to the end of their function, without aliasing
+or loops, so those findings always need review.
+"""
+
+import re
+from dataclasses import dataclass
+from typing import List, Optional, Tuple
+
+from .base import Rule, SourceContext, StaticFinding, register_rule
+from .dialects import C, CONSTANT_NAME, DialectRule, call_arguments, normalized
+from .taint import IDENTIFIER, STRING_LITERAL, split_args, strip_strings
+
+# %[flags][width][.precision][length]conversion
+FORMAT_SPEC = re.compile(r'%[-+ #0\']*(\d+|\*)?(?:\.(\d*|\*))?(?:hh|h|ll|l|L|q|j|z|t)?([diouxXeEfFgGaAcspn%])')
+# scanf conversions without a width read a whole word or set, however long
+UNBOUNDED_SCAN = re.compile(r'%(?:hh|h|ll|l|L)?(?:s|\[)')
+LOCALIZED = re.compile(r'\b(?:_|N_|gettext|dgettext|dcgettext|ngettext)\s*\([^()]*\)')
+LENGTHS = re.compile(r'\b(?:strlen|wcslen|strnlen)\s*\([^()]*\)')
+SIZEOF = re.compile(r'\bsizeof\s*\([^()]*\)|\bsizeof\s+\w+')
+CAST = re.compile(r'\(\s*(?:const\s+|unsigned\s+|signed\s+)*\w+(?:\s*\*)*\s*\)(?=\s*[\w(])')
+MULTIPLICATION = re.compile(r'[\w)\]]\s*\*\s*[\w(]')
+OVERFLOW_CHECK = re.compile(r'\b(?:SIZE_MAX|INT_MAX|UINT_MAX|SSIZE_MAX)\b|\b__builtin_\w*mul_overflow\s*\(|\bckd_mul\s*\(')
+
+COPY_CALLS = re.compile(r'(?<![\w.])(strcpy|strcat|stpcpy|wcscpy|wcscat|lstrcpy[AW]?|lstrcat[AW]?|sprintf|vsprintf)\s*\(')
+GETS = re.compile(r'(?<![\w.])gets\s*\(')
+SCAN_CALLS = re.compile(r'(?<![\w.])(scanf|fscanf|sscanf)\s*\(')
+# index of the format argument
+FORMAT_CALLS = {
+    'printf': 0, 'wprintf': 0, 'fprintf': 1, 'fwprintf': 1, 'dprintf': 1, 'sprintf': 1, 'snprintf': 2,
+    'syslog': 1, 'err': 1, 'errx': 1, 'warn': 0, 'warnx': 0,
+}
+# index of the size argument; calloc and reallocarray check their multiplication
+ALLOCATION_CALLS = {
+    'malloc': 0, 'xmalloc': 0, 'g_malloc': 0, 'alloca': 0, 'kmalloc': 0, 'kzalloc': 0, 'vmalloc': 0,
+    'realloc': 1, 'xrealloc': 1, 'g_realloc': 1,
+}
+
+FREE = re.compile(
+    r'(?<![\w.])(?:free|cfree|kfree|g_free|OPENSSL_free)\s*\(\s*(?:\(\s*\w+\s*\*\s*\)\s*)?([A-Za-z_]\w*(?:\.\w+)*)\s*\)'
+    r'|(?<![\w.])delete\s*(?:\[\s*\]\s*)?([A-Za-z_]\w*(?:\.\w+)*)\s*;'
+)
+EXITS = re.compile(r'\b(?:return|goto|break|continue|exit|_exit|abort|longjmp|siglongjmp|throw)\b')
+CONDITION = re.compile(r'^\s*(?:\}\s*)?(?:else\b|if\s*\()')
+
+
+def is_constant(expr: str) -> bool:
+    """A literal, a localized literal, or a macro"""
+    stripped = strip_strings(LOCALIZED.sub('""', expr))
+    return all(CONSTANT_NAME.match(name) for name in IDENTIFIER.findall(stripped))
+
+
+def literal_text(expr: str) -> str:
+    return ''.join(m.group(0)[1:-1] for m in STRING_LITERAL.finditer(expr))
+
+
+def unbounded_strings(fmt: str) -> List[int]:
+    """Positions of the values a printf format writes with %s and no precision, which copy a whole string"""
+    positions, position = [], 0
+    for spec in FORMAT_SPEC.finditer(fmt):
+        width, precision, conversion = spec.groups()
+        if conversion == '%':
+            continue
+        position += (width == '*') + (precision == '*')
+        if conversion == 's' and precision is None:
+            positions.append(position)
+        position += 1
+    return positions
+
+
+@register_rule
+class UnboundedCopyRule(DialectRule):
+    rule_id = "c-unbounded-copy"
+    name = "Unbounded string copy"
+    vuln_type = "Buffer Overflow"
+    severity = "high"
+    cwe_id = "CWE-120"
+    description = "A string of unchecked length is copied into a buffer by strcpy, strcat, sprintf, gets, or scanf %s"
+    remediation = ("Copy with the destination size: snprintf, strlcpy/strlcat, or a checked strlen first; read with fgets "
+                   "and give scanf conversions a width (%63s)")
+    example = 'char name[64];\nstrcpy(name, argv[1]);'
+    languages = ('c', 'cpp')
+    dialect = C
+    sinks = ((COPY_CALLS, None),)
+    sanitizers = LENGTHS
+    tainted_description = "Input of any length is copied into a buffer"
+    dynamic_description = "A string of unchecked length is copied into a buffer"
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = super().check(ctx)
+        lines = normalized(C, ctx.code)
+        for index, line in enumerate(lines):
+            if GETS.search(line):
+                findings.append(self.finding(
+                    ctx, index + 1, severity="critical", confidence=0.95,
+                    description="gets() reads a line of any length into a fixed buffer"
+                ))
+            for match in SCAN_CALLS.finditer(line):
+                args = split_args(call_arguments(lines, index, match.end() - 1))
+                fmt = args[0 if match.group(1) == 'scanf' else 1:][:1]
+                if fmt and UNBOUNDED_SCAN.search(literal_text(fmt[0])):
+                    findings.append(self.finding(
+                        ctx, index + 1, confidence=0.85,
+                        description=f"{match.group(1)}() reads a string without a width into a fixed buffer"
+                    ))
+                    break
+        return sorted(findings, key=lambda f: f.line_number)
+
+    def arguments(self, lines: Tuple[str, ...], index: int, match: re.Match) -> List[str]:
+        """The copied source, or the values a sprintf format writes with an unbounded %s"""
+        args = split_args(call_arguments(lines, index, match.end() - 1))
+        if len(args) < 2:
+            return []
+        if match.group(1) not in ('sprintf', 'vsprintf'):
+            return [args[1]]
+        fmt, values = args[1], args[2:]
+        if not is_constant(fmt):
+            return [fmt] + values
+        positions = unbounded_strings(literal_text(fmt))
+        if match.group(1) == 'vsprintf':
+            return values if positions else []
+        return [values[p] for p in positions if p < len(values)]
+
+    def is_dynamic(self, expr: str) -> bool:
+        return not is_constant(expr)
+
+    def judge(self, ctx: SourceContext, lines: Tuple[str, ...], line_number: int, args: List[str]) -> Optional[StaticFinding]:
+        # a source whose length the function measures (a check, or a buffer sized from it) is bounded
+        first, _, _ = self.scope(ctx, line_number)
+        before = '\n'.join(lines[first - 1:line_number - 1])
+        unmeasured = [arg for arg in args if not re.search(rf'\b(?:strlen|strnlen)\s*\(\s*{re.escape(arg.strip())}\s*[,)]', before)]
+        return super().judge(ctx, lines, line_number, unmeasured) if unmeasured else None
+
+
+@register_rule
+class FormatStringRule(DialectRule):
+    rule_id = "c-format-string"
+    name = "Non-literal format string"
+    vuln_type = "Format String"
+    severity = "high"
+    cwe_id = "CWE-134"
+    description = "The format argument of printf, syslog, or a relative is not a literal, so %n and %s in it read and write memory"
+    remediation = 'Pass a literal format and the value as an argument: printf("%s", msg)'
+    example = 'printf(argv[1]);'
+    languages = ('c', 'cpp')
+    dialect = C
+    sinks = ((re.compile(r'(?<![\w.])(' + '|'.join(FORMAT_CALLS) + r')\s*\('), None),)
+    sanitizers = LOCALIZED
+    tainted_description = "Format string comes from input"
+    dynamic_description = "Format string is not a literal"
+
+    def arguments(self, lines: Tuple[str, ...], index: int, match: re.Match) -> List[str]:
+        args = split_args(call_arguments(lines, index, match.end() - 1))
+        return args[FORMAT_CALLS[match.group(1)]:][:1]
+
+    def is_dynamic(self, expr: str) -> bool:
+        return not is_constant(expr)
+
+
+@register_rule
+class AllocationOverflowRule(DialectRule):
+    rule_id = "c-allocation-overflow"
+    name = "Allocation size can overflow"
+    vuln_type = "Integer Overflow"
+    severity = "high"
+    cwe_id = "CWE-190"
+    description = ("A size passed to malloc or realloc is computed with unchecked arithmetic: when it wraps, the buffer "
+                   "is smaller than the writes that follow")
+    remediation = ("Use calloc(count, size) or reallocarray, or check count > SIZE_MAX / size (or __builtin_mul_overflow) "
+                   "before multiplying; bound sizes read from input")
+    example = 'uint32_t count = read_u32(packet);\nitem_t *items = malloc(count * sizeof(item_t));'
+    languages = ('c', 'cpp')
+    dialect = C
+    sinks = ((re.compile(r'(?<![\w.])(' + '|'.join(ALLOCATION_CALLS) + r')\s*\('), None),)
+    sanitizers = LENGTHS
+    tainted_description = "Allocation size is computed from input without an overflow check"
+    dynamic_description = "Allocation size is multiplied without an overflow check"
+
+    def arguments(self, lines: Tuple[str, ...], index: int, match: re.Match) -> List[str]:
+        """The size, when it is computed: a plain size read from input is user-sized-allocation's concern"""
+        args = split_args(call_arguments(lines, index, match.end() - 1))
+        size = args[ALLOCATION_CALLS[match.group(1)]:][:1]
+        computed = CAST.sub('', strip_strings(size[0])) if size else ''
+        return size if MULTIPLICATION.search(computed) or '+' in computed else []
+
+    def is_dynamic(self, expr: str) -> bool:
+        stripped = CAST.sub('', SIZEOF.sub('1', strip_strings(expr)))
+        return bool(MULTIPLICATION.search(stripped)) and not is_constant(stripped)
+
+    def judge(self, ctx: SourceContext, lines: Tuple[str, ...], line_number: int, args: List[str]) -> Optional[StaticFinding]:
+        first, last, _ = self.scope(ctx, line_number)
+        if OVERFLOW_CHECK.search('\n'.join(lines[first - 1:last])):
+            return None
+        return super().judge(ctx, lines, line_number, args)
+
+
+@dataclass
+class Dangling:
+    name: str
+    freed: int
+    used: int
+    double_free: bool
+    conditional: bool  # the free only happens on some paths to the use
+
+
+def dangling_pointers(ctx: SourceContext) -> List[Dangling]:
+    lines = normalized(C, ctx.code)
+    found = []
+    for function in ctx.functions():
+        body = [strip_strings(line) for line in lines[function.start_line - 1:function.end_line]]
+        for offset, line in enumerate(body):
+            for match in FREE.finditer(line):
+                name = match.group(1) or match.group(2)
+                use = next_use(body[offset + 1:], name, line[match.end():])
+                if not use:
+                    continue
+                index, double_free, conditional = use
+                freed = function.start_line + offset
+                # as written: packet->payload, not the rewritten packet.payload
+                spelled = re.search(r'\s*(?:\.|->)\s*'.join(map(re.escape, name.split('.'))), ctx.line(freed))
+                found.append(Dangling(
+                    spelled.group(0) if spelled else name, freed, freed + 1 + index, double_free,
+                    conditional or bool(CONDITION.match(line[:match.start()]))
+                ))
+    return found
+
+
+def next_use(rest: List[str], name: str, tail: str) -> Optional[Tuple[int, bool, bool]]:
+    """(index in rest, freed again, conditional) of the first use of a freed name, or None when the name is
+    reassigned first or the block that freed it always leaves the function or loop"""
+    prefixes = [re.escape('.'.join(name.split('.')[:i])) for i in range(1, name.count('.') + 2)]
+    reassigned = re.compile(r'(?<![\w.])(?:' + '|'.join(prefixes) + r')\s*=(?!=)|&\s*(?:' + '|'.join(prefixes) + r')\b')
+    pointer = re.compile(rf'(?<![\w.]){re.escape(name)}(?!\w)')
+    null_test = re.compile(rf'{re.escape(name)}\s*[!=]=\s*(?:NULL|nullptr|0)\b|\b(?:NULL|nullptr)\s*[!=]=\s*{re.escape(name)}')
+
+    if reassigned.search(tail) or EXITS.search(tail):
+        return None
+    depth = tail.count('{') - tail.count('}')
+    left, in_else = depth < 0, False
+    for index, line in enumerate(rest):
+        if in_else and depth < 0 and not line.lstrip().startswith('else'):
+            in_else = False
+        if not in_else:
+            if reassigned.search(line):
+                return None
+            if any((m.group(1) or m.group(2)) == name for m in FREE.finditer(line)):
+                return index, True, left
+            if pointer.search(null_test.sub('', line)):
+                return index, False, left
+            if not left and EXITS.search(line):
+                return None
+        lowest = depth
+        for char in line:
+            depth += {'{': 1, '}': -1}.get(char, 0)
+            lowest = min(lowest, depth)
+        if lowest < 0 and not left:
+            left = True
+            # the else branch of the block that freed runs instead of it
+            following = rest[index + 1].lstrip() if index + 1 < len(rest) else ''
+            in_else = bool(re.search(r'\}\s*else\b', line)) or following.startswith('else')
+    return None
+
+
+@register_rule
+class UseAfterFreeRule(Rule):
+    rule_id = "c-use-after-free"
+    name = "Use after free"
+    vuln_type = "Use After Free"
+    severity = "high"
+    cwe_id = "CWE-416"
+    description = "A pointer is used after the memory it points to was freed"
+    remediation = "Set the pointer to NULL right after free, and free only once the last use is done"
+    example = 'free(session);\nlog_session(session->id);'
+    languages = ('c', 'cpp')
+    needs_review = True
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        return [
+            self.finding(
+                ctx, d.used, confidence=0.6 if d.conditional else 0.8, trace=[d.freed, d.used],
+                description=f"'{d.name}' is used after it was freed on line {d.freed}"
+                + (" on some paths" if d.conditional else "")
+            )
+            for d in dangling_pointers(ctx) if not d.double_free
+        ]
+
+
+@register_rule
+class DoubleFreeRule(Rule):
+    rule_id = "c-double-free"
+    name = "Double free"
+    vuln_type = "Double Free"
+    severity = "high"
+    cwe_id = "CWE-415"
+    description = "The same pointer is freed twice, which corrupts the allocator's free lists"
+    remediation = "Set the pointer to NULL right after free, and give each allocation a single owner that frees it"
+    example = 'free(buf);\nif (err) {\n    free(buf);\n}'
+    languages = ('c', 'cpp')
+    needs_review = True
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        return [
+            self.finding(
+                ctx, d.used, confidence=0.6 if d.conditional else 0.8, trace=[d.freed, d.used],
+                description=f"'{d.name}' is freed again after line {d.freed}" + (" on some paths" if d.conditional else "")
+            )
+            for d in dangling_pointers(ctx) if d.double_free
+        ]
@@ -0,0 +1,188 @@
+"""
+NoSQL injection rules - MongoDB filters a client can shape
+Unlike SQL, a Mongo query is a document, so the injection is structural: a filter decoded straight from the
+request, or a filter value taken from an untyped request map, lets the client send operators
+({"$ne": ""}, {"$where": ...}) where a plain value was expected. String values are not a risk on their own;
+$where (server-side JavaScript) and $regex are, when built from dynamic strings.
+"""
+
+import re
+from typing import Dict, List, Optional, Set, Tuple
+
+from ..constants import ConstantFolder, package_constants
+from .base import Rule, SourceContext, StaticFinding, register_rule
+from .taint import split_args
+
+MONGO_IMPORTS = ('go.mongodb.org/mongo-driver', 'gopkg.in/mgo.v2', 'github.com/globalsign/mgo')
+
+# mongo-driver methods take a context first; mgo's take the selector first
+COLLECTION_CALL = re.compile(
+    r'\.(Find|FindOne|FindOneAndUpdate|FindOneAndReplace|FindOneAndDelete|UpdateOne|UpdateMany|ReplaceOne'
+    r'|DeleteOne|DeleteMany|CountDocuments|Aggregate|Distinct|Pipe|Remove|RemoveAll|Update|UpdateAll|Upsert)\s*\('
+)
+UPDATE_METHODS = ('FindOneAndUpdate', 'UpdateOne', 'UpdateMany', 'Update', 'UpdateAll', 'Upsert')
+CONTEXT_ARG = re.compile(r'^(?:\w*[cC]tx|context\.\w+\(\)|[\w.]*Context\(\))$')
+BSON_LITERAL = re.compile(r'\b(?:bson|primitive)\.(M|D|E)\s*\{')
+COMPOSITE = re.compile(r'^(?:&?(?:bson|primitive)\.|\[\]|map\[|\{)')
+USER_MAP_INDEX = re.compile(r'^(\w+)\s*\[')
+
+# maps that can hold operators once decoded
+MAP_DECLARATION = re.compile(
+    r'\bvar\s+(\w+)\s+(?:bson|primitive)\.[MD]\b'
+    r'|\bvar\s+(\w+)\s+map\[string\](?:interface\{\}|any)'
+    r'|\b(\w+)\s*:=\s*(?:(?:bson|primitive)\.[MD]\s*\{\s*\}|map\[string\](?:interface\{\}|any)\s*\{\s*\}'
+    r'|make\(\s*map\[string\](?:interface\{\}|any)\s*\))'
+)
+HANDLER_PARAMS = re.compile(r'\*http\.Request\b|\*gin\.Context\b|\becho\.Context\b|\*fiber\.Ctx\b')
+# request bodies decoded into a variable outside the taint sources: gin/echo binding, json.Unmarshal in a handler
+REQUEST_DECODE = re.compile(r'\.(?:ShouldBind|Bind)(?:JSON|With)?\(\s*&(\w+)|\bjson\.Unmarshal\([^,]+,\s*&(\w+)\s*\)')
+
+JAVASCRIPT_OPERATORS = ('$where', '$function', '$accumulator')
+
+
+def literal_entries(body: str) -> List[Tuple[str, str, int]]:
+    """(key, value, offset in body) of each top-level entry of a bson.M, bson.D, or bson.E literal"""
+    entries = []
+    cursor = 0
+    body = re.sub(r'(?m)(?:^|(?<=\s))//[^\n]*', lambda m: ' ' * len(m.group(0)), body)  # keep offsets
+    for part in split_args(body):
+        offset = body.find(part, cursor)
+        cursor = offset + len(part)
+        element = part.strip()
+        if element.startswith('{') and element.endswith('}'):
+            element = element[1:-1]  # bson.D{{"name", value}} or {Key: "name", Value: value}
+        fields = split_args(element)
+        if len(fields) == 2 and all(re.match(r'^(?:Key|Value)\s*:', f) for f in fields):
+            key, value = (f.split(':', 1)[1].strip() for f in fields)
+        elif len(fields) == 2:
+            key, value = fields
+        else:
+            key, colon, value = element.partition(':')
+            if not colon:
+                continue
+        entries.append((key.strip(), value.strip(), offset))
+    return entries
+
+
+@register_rule
+class NoSQLInjectionRule(Rule):
+    rule_id = "nosql-injection"
+    name = "MongoDB query shaped by the client"
+    vuln_type = "NoSQL Injection"
+    severity = "high"
+    cwe_id = "CWE-943"
+    description = "A MongoDB filter comes from the request as a document, so the client can inject query operators"
+    remediation = ("Decode the request into a struct with typed fields and build the filter from those fields "
+                   "(bson.M{\"name\": req.Name}); never pass a decoded map as a filter, build $where from input, "
+                   "or use input in $regex without regexp.QuoteMeta")
+    example = 'var filter bson.M\njson.NewDecoder(r.Body).Decode(&filter)\nusers.Find(ctx, filter)'
+
+    def applies_to(self, ctx: SourceContext) -> bool:
+        return super().applies_to(ctx) and ctx.imports_any(MONGO_IMPORTS)
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+        constants = package_constants(ctx.code)
+
+        for function in ctx.functions():
+            start, end = ctx.span(function)
+            folder = ConstantFolder(function.body, function.start_line, constants, frozenset({"regexp.QuoteMeta"}))
+            user_maps = self.user_maps(function.body, function.start_line, folder)
+            reported: Set[int] = set()
+
+            for line, match in ctx.search(COLLECTION_CALL, start, end):
+                args = [a.strip() for a in split_args(ctx.call_args(match.end() - 1))]
+                index = 1 if args and CONTEXT_ARG.match(args[0]) else 0
+                index += 1 if match.group(1) == 'Distinct' and index else 0
+                documents = args[index:index + 2] if match.group(1) in UPDATE_METHODS else args[index:index + 1]
+                for document in documents:
+                    name = document.lstrip('&')
+                    if name in user_maps and line not in reported:
+                        reported.add(line)
+                        decoded = user_maps[name]
+                        findings.append(self.finding(
+                            ctx, line, match=match, confidence=0.85, trace=[decoded, line],
+                            description=f"{name} is decoded from the request (line {decoded}) and used as a query "
+                                        "document: the client can send operators such as {\"$ne\": \"\"}"
+                        ))
+
+            for line, match in ctx.search(BSON_LITERAL, start, end):
+                body_start = match.end()
+                body = ctx.code[body_start:ctx.block_end(match.end() - 1) - 1]
+                if match.group(1) == 'E':
+                    body = '{' + body + '}'
+                for key, value, offset in literal_entries(body):
+                    entry_line = ctx.line_of(body_start + offset)
+                    if entry_line in reported:
+                        continue
+                    finding = self.entry_finding(ctx, entry_line, key, value, folder, user_maps)
+                    if finding:
+                        reported.add(entry_line)
+                        findings.append(finding)
+
+        return findings
+
+    def user_maps(self, body: str, first_line: int, folder: ConstantFolder) -> Dict[str, int]:
+        """Untyped maps of this function filled from the request -> line they are decoded on"""
+        declared = {next(n for n in m.groups() if n) for m in MAP_DECLARATION.finditer(body)}
+        handler = bool(HANDLER_PARAMS.search(body.split('{', 1)[0]))
+        decoded = {}
+        for index, line in enumerate(body.split('\n')[1:], 1):
+            line_number = first_line + index
+            env = folder.env_at(line_number + 1)
+            for name in declared - set(decoded):
+                if env.get(name) and env[name].is_tainted:
+                    decoded[name] = line_number
+            if handler:
+                for match in REQUEST_DECODE.finditer(line):
+                    name = match.group(1) or match.group(2)
+                    if name in declared:
+                        decoded.setdefault(name, line_number)
+        return decoded
+
+    def entry_finding(
+        self,
+        ctx: SourceContext,
+        line: int,
+        key: str,
+        value: str,
+        folder: ConstantFolder,
+        user_maps: Dict[str, int]
+    ) -> Optional[StaticFinding]:
+        operator = key.strip('"`')
+        if COMPOSITE.match(value):
+            return None  # nested documents are checked on their own
+
+        indexed = USER_MAP_INDEX.match(value)
+        if indexed and indexed.group(1) in user_maps:
+            return self.finding(
+                ctx, line, confidence=0.8, trace=[user_maps[indexed.group(1)], line],
+                description=f"Filter value {value} comes from an untyped request map, so it can be an operator "
+                            "document instead of a plain value"
+            )
+        if not re.match(r'^["`]', key):
+            key_value = folder.value_of(key, line)
+            if key_value.is_tainted:
+                return self.finding(
+                    ctx, line, confidence=0.85, trace=list(key_value.lines),
+                    description=f"Filter field name comes from user input ({key_value.source}): the client "
+                                "chooses the field or an operator"
+                )
+            return None
+
+        if operator in JAVASCRIPT_OPERATORS or operator == '$regex':
+            value_of = folder.value_of(value, line)
+            if value_of.is_safe or (operator == '$regex' and not value_of.is_tainted):
+                return None
+            what = "regular expression" if operator == '$regex' else "server-side JavaScript"
+            if value_of.is_tainted:
+                return self.finding(
+                    ctx, line, confidence=0.9, trace=list(value_of.lines),
+                    severity="medium" if operator == '$regex' else None,
+                    description=f"{operator} {what} is built from user input ({value_of.source})"
+                )
+            return self.finding(
+                ctx, line, severity="medium", confidence=0.5,
+                description=f"{operator} {what} is built from values that could not be resolved to constants"
+            )
+        return None
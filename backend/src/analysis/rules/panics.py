@@ -0,0 +1,138 @@
+"""
+Panic path rules - Unchecked slice bounds and panics reachable from handlers
+"""
+
+import re
+from typing import Dict, List, Set
+
+from ..parser import SourceMember
+from .base import Rule, SourceContext, StaticFinding, register_rule
+from .taint import ASSIGNMENT, split_args, taint_origins, taint_path
+
+
+SIGNATURE_PARAMS = re.compile(r'func\s+(?:\([^)]*\)\s*)?\w+\s*\(([^)]*)\)')
+SLICEABLE_TYPE = re.compile(r'^(?:\[\][\w.*]+|string)$')
+SLICE_EXPR = re.compile(r'\b(\w+)\[([^\[\]:]*):([^\[\]:]*)\]')
+SPLIT_INDEX = re.compile(r'\bstrings\.(?:Split|SplitN|Fields)\s*\([^)]*\)\[\s*[1-9]\d*\s*\]')
+
+HANDLER_PARAMS = re.compile(r'http\.ResponseWriter|\*gin\.Context|echo\.Context|\*fiber\.Ctx')
+TYPE_ASSERTION = re.compile(r'\.\(\s*(?!type\b)[\w.*\[\]{}]+\s*\)')
+PANIC_CALL = re.compile(r'(?<![\w.])panic\s*\(|\blog\.(?:Fatal|Panic)\w*\s*\(')
+
+
+def signature_params(member: SourceMember) -> Dict[str, str]:
+    match = SIGNATURE_PARAMS.search(member.signature)
+    if not match:
+        return {}
+
+    params = {}
+    pending = []
+    for part in split_args(match.group(1)):
+        words = part.split(None, 1)
+        if len(words) == 1:
+            pending.append(words[0])
+            continue
+        for name in pending + [words[0]]:
+            params[name] = words[1].strip()
+        pending = []
+    return params
+
+
+def is_handler(member: SourceMember) -> bool:
+    return bool(HANDLER_PARAMS.search(member.signature))
+
+
+def reachable_from_handlers(ctx: SourceContext) -> Set[str]:
+    functions = {f.name: f for f in ctx.functions()}
+    reachable = {name for name, f in functions.items() if is_handler(f)}
+
+    frontier = list(reachable)
+    while frontier:
+        caller = functions[frontier.pop()]
+        for name in functions:
+            if name not in reachable and re.search(rf'(?<![\w.]){re.escape(name)}\s*\(', caller.body):
+                reachable.add(name)
+                frontier.append(name)
+    return reachable
+
+
+@register_rule
+class UncheckedSliceBoundsRule(Rule):
+    rule_id = "unchecked-slice-bounds"
+    name = "Slice bounds not checked against input length"
+    vuln_type = "Out-of-Bounds Slice"
+    severity = "medium"
+    cwe_id = "CWE-129"
+    description = "A slice or index operation uses fixed bounds on externally sized input without checking its length first, which panics on short input"
+    remediation = "Check len() of the input before slicing, or clamp the bound with min(len(x), n)"
+    example = 'func processBuffer(data []byte) { copy(result, data[:20]) }'
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+
+        for function in ctx.functions():
+            params = {
+                name for name, type_ in signature_params(function).items()
+                if SLICEABLE_TYPE.match(type_)
+            }
+            external = taint_origins(function.body, seeds=params)
+            start, end = ctx.span(function)
+
+            for line, match in ctx.search(SLICE_EXPR, start, end):
+                name, low, high = match.groups()
+                if name not in external or (not low.strip() and not high.strip()):
+                    continue
+                if 'len(' in low + high or re.search(rf'\blen\(\s*{re.escape(name)}\s*\)', function.body):
+                    continue
+                findings.append(self.finding(
+                    ctx, line, match=match,
+                    description=f"'{name}' is sliced as [{low}:{high}] without checking len({name}), which panics on short input",
+                    trace=taint_path(external, name, function.start_line)
+                ))
+
+            for line, match in ctx.search(SPLIT_INDEX, start, end):
+                findings.append(self.finding(
+                    ctx, line, match=match,
+                    description="The result of a string split is indexed without checking how many parts were produced"
+                ))
+
+        return findings
+
+
+@register_rule
+class HandlerPanicRule(Rule):
+    rule_id = "handler-panic"
+    name = "Panic reachable from a request handler"
+    vuln_type = "Denial of Service"
+    severity = "medium"
+    cwe_id = "CWE-248"
+    description = "A panic can be triggered while serving a request, which aborts the connection or crashes the server"
+    remediation = "Use the two-value form of type assertions and return errors instead of calling panic or log.Fatal in request paths"
+    example = 'cmd := msg.Payload.(string)'
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+        reachable = reachable_from_handlers(ctx)
+
+        for function in ctx.functions():
+            if function.name not in reachable:
+                continue
+            start, end = ctx.span(function)
+
+            for line, match in ctx.search(TYPE_ASSERTION, start, end):
+                assignment = ASSIGNMENT.match(ctx.line(line))
+                if assignment and ',' in assignment.group(1):
+                    continue
+                findings.append(self.finding(
+                    ctx, line, match=match,
+                    description=f"Single-value type assertion in {function.name} panics when the value has an unexpected type"
+                ))
+
+            for line, match in ctx.search(PANIC_CALL, start, end):
+                findings.append(self.finding(
+                    ctx, line, match=match,
+                    description=f"{match.group(0).rstrip('(').strip()} in {function.name} is reachable from a request handler",
+                    severity="high" if match.group(0).startswith('log.') else None
+                ))
+
+        return findings
@@ -0,0 +1,118 @@
+"""
+File permission rules - World-writable modes and loosely protected credential files
+"""
+
+import re
+from typing import List, Optional
+
+from .base import Rule, SourceContext, StaticFinding, register_rule
+from .taint import split_args
+
+
+# call pattern -> index of the permission argument
+PERMISSION_CALLS = [
+    (re.compile(r'\bos\.OpenFile\s*\('), 2),
+    (re.compile(r'\b(?:os|ioutil)\.WriteFile\s*\('), 2),
+    (re.compile(r'\bos\.(?:Chmod|Mkdir|MkdirAll)\s*\('), 1),
+    (re.compile(r'(?<![\w.])(?!os\.)\w+\.Chmod\s*\('), 0),
+]
+CREATE_CALL = re.compile(r'\bos\.Create\s*\(')
+
+SENSITIVE_PATH = re.compile(
+    r'key|\.pem|\.crt|\.p12|\.pfx|id_rsa|id_ed25519|cred|secret|token|passw|\.env|\.netrc|kubeconfig|/etc/|\.ssh',
+    re.IGNORECASE
+)
+CREDENTIAL_PATH = re.compile(
+    r'key|\.pem|\.p12|\.pfx|id_rsa|id_ed25519|cred|secret|token|passw|\.env|\.netrc|kubeconfig',
+    re.IGNORECASE
+)
+
+
+def parse_mode(expr: str) -> Optional[int]:
+    expr = expr.strip()
+    if re.fullmatch(r'(?:os|fs)\.ModePerm', expr):
+        return 0o777
+    match = re.fullmatch(r'0[oO]?([0-7]{3,4})', expr)
+    if match:
+        return int(match.group(1), 8)
+    return None
+
+
+def _permission_calls(ctx: SourceContext):
+    for pattern, index in PERMISSION_CALLS:
+        for line, match in ctx.search(pattern):
+            args = split_args(ctx.call_args(match.end() - 1))
+            if len(args) <= index:
+                continue
+            mode = parse_mode(args[index])
+            if mode is None:
+                continue
+            if index == 0 and ctx.types:
+                # file.Chmod(mode): only an *os.File, once its type is known
+                callees = ctx.types.concrete_callees(match.group(0).rstrip('( \t'), match.start())
+                if callees and 'os.File.Chmod' not in callees:
+                    continue
+            path = args[0] if index > 0 else match.group(0).split('.')[0]
+            yield line, path, mode
+
+
+@register_rule
+class WorldWritablePermissionRule(Rule):
+    rule_id = "world-writable-permissions"
+    name = "World-writable file or directory mode"
+    vuln_type = "Insecure File Permissions"
+    severity = "medium"
+    fix_complexity = "trivial"
+    cwe_id = "CWE-732"
+    description = "A file or directory is created or changed with a world-writable mode, so any local user can modify it"
+    remediation = "Use the narrowest mode that works, typically 0600/0644 for files and 0700/0755 for directories"
+    example = 'os.MkdirAll("/var/app/uploads", 0777)'
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+
+        for line, path, mode in _permission_calls(ctx):
+            if not mode & 0o002:
+                continue
+            sensitive = SENSITIVE_PATH.search(path) is not None
+            findings.append(self.finding(
+                ctx, line,
+                description=f"{path} is given world-writable mode {mode:#o}" + (" on a sensitive path" if sensitive else ""),
+                severity="high" if sensitive else None
+            ))
+
+        return findings
+
+
+@register_rule
+class CredentialFilePermissionRule(Rule):
+    rule_id = "credential-file-permissions"
+    name = "Credential file readable by other users"
+    vuln_type = "Insecure File Permissions"
+    severity = "high"
+    fix_complexity = "trivial"
+    cwe_id = "CWE-732"
+    description = "A key or credential file is written with a mode broader than 0600, exposing it to other local users"
+    remediation = "Write keys and credentials with mode 0600 (or 0400) and keep their directories at 0700"
+    example = 'ioutil.WriteFile("server.key", pem, 0644)'
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+
+        for line, path, mode in _permission_calls(ctx):
+            if mode & 0o002 or not CREDENTIAL_PATH.search(path) or not mode & 0o077:
+                continue
+            findings.append(self.finding(
+                ctx, line,
+                description=f"Credential file {path} is written with mode {mode:#o} instead of 0600"
+            ))
+
+        for line, match in ctx.search(CREATE_CALL):
+            args = split_args(ctx.call_args(match.end() - 1))
+            if args and CREDENTIAL_PATH.search(args[0]):
+                findings.append(self.finding(
+                    ctx, line, match=match,
+                    description=f"Credential file {args[0]} is created with os.Create, which uses mode 0666 before umask"
+                ))
+
+        return findings
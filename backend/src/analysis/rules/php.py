@@ -0,0 +1,129 @@
+"""
+PHP rules - SQL, shell commands, evaluated code, included files, and unserialize() fed with user input
+Sources are the request superglobals ($_GET, $_POST, $_REQUEST, $_COOKIE, $_FILES, client-set $_SERVER
+entries, php://input) and the Laravel and Symfony request objects. Flow is followed within each function,
+and through the whole file for top-level scripts (see dialects.py).
+"""
+
+import re
+from typing import List, Tuple
+
+from .base import register_rule
+from .dialects import PHP, DialectRule
+from .taint import IDENTIFIER, strip_strings
+
+# casts and number conversions cannot carry SQL or shell syntax
+NUMERIC = r'\b(?:intval|floatval|boolval|abs)\s*\([^()]*\)|\(\s*(?:int|integer|float|double|bool|boolean)\s*\)\s*(?:[\w.]|\[[^\]]*\])+'
+SQL_ESCAPES = re.compile(
+    NUMERIC + r'|\b(?:mysqli_real_escape_string|pg_escape_string|pg_escape_literal|sqlite_escape_string)\s*\([^()]*\)'
+    r'|\.(?:real_escape_string|quote)\s*\([^()]*\)'
+)
+SHELL_ESCAPES = re.compile(NUMERIC + r'|\bescapeshellarg\s*\([^()]*\)')
+# basename() keeps an included path inside its directory
+INCLUDE_ESCAPES = re.compile(NUMERIC + r'|\bbasename\s*\([^()]*\)')
+
+
+@register_rule
+class PHPSQLInjectionRule(DialectRule):
+    rule_id = "php-sql-injection"
+    name = "PHP SQL query built from user input"
+    vuln_type = "SQL Injection"
+    severity = "high"
+    cwe_id = "CWE-89"
+    description = "A SQL query is assembled from user input by interpolation or concatenation"
+    remediation = ("Use prepared statements with bound parameters (PDO prepare/execute, mysqli bind_param, Laravel "
+                   "bindings such as whereRaw('id = ?', [$id])); pick identifiers such as column names from a fixed allowlist")
+    example = '$db->query("SELECT * FROM users WHERE id = " . $_GET[\'id\']);'
+    languages = ('php',)
+    dialect = PHP
+    sinks = (
+        (re.compile(r'(?<![\w.])(?:mysql_query|sqlite_query)\s*\('), 0),
+        (re.compile(r'(?<![\w.])(?:mysqli_query|mysqli_multi_query|mysqli_real_query|mysqli_prepare)\s*\('), 1),
+        (re.compile(r'(?<![\w.])(?:pg_query|pg_send_query)\s*\('), -1),
+        (re.compile(r'(?<!request)\.(?:query|exec|prepare|multi_query|real_query)\s*\('), 0),
+        (re.compile(r'\bDB\.(?:select|selectOne|insert|update|delete|statement|unprepared|raw)\s*\('), 0),
+        (re.compile(r'\.(?:whereRaw|orWhereRaw|havingRaw|orHavingRaw|orderByRaw|selectRaw|groupByRaw)\s*\('), 0),
+    )
+    sanitizers = SQL_ESCAPES
+    tainted_description = "SQL query is built from user input"
+    dynamic_description = "SQL query is built by interpolation or concatenation from values that are not constants"
+
+
+@register_rule
+class PHPCommandInjectionRule(DialectRule):
+    rule_id = "php-command-injection"
+    name = "PHP shell command built from user input"
+    vuln_type = "Command Injection"
+    severity = "high"
+    cwe_id = "CWE-78"
+    description = "A command passed to exec, system, shell_exec, passthru, popen, or proc_open is assembled from user input"
+    remediation = "Quote every user value with escapeshellarg (or pass an argument array to proc_open), and validate it against an allowlist"
+    example = 'system("ping -c 4 " . $_GET[\'host\']);'
+    languages = ('php',)
+    dialect = PHP
+    sinks = ((re.compile(r'(?<![\w.])(?:exec|system|passthru|shell_exec|popen|proc_open|pcntl_exec)\s*\('), 0),)
+    sanitizers = SHELL_ESCAPES
+    tainted_description = "Command is built from user input"
+    dynamic_description = "Command is built by interpolation or concatenation from values that are not constants"
+
+
+@register_rule
+class PHPCodeInjectionRule(DialectRule):
+    rule_id = "php-code-injection"
+    name = "PHP code evaluated from user input"
+    vuln_type = "Code Injection"
+    severity = "critical"
+    cwe_id = "CWE-95"
+    description = "User input reaches eval, assert, or create_function and runs as PHP code"
+    remediation = "Never evaluate input as code: map the input to a fixed set of functions or values instead"
+    example = 'eval(\'$result = \' . $_POST[\'expr\'] . \';\');'
+    languages = ('php',)
+    dialect = PHP
+    sinks = ((re.compile(r'(?<![\w.])(?:eval|assert|create_function)\s*\('), None),)
+    sanitizers = re.compile(NUMERIC)
+    tainted_description = "PHP code is built from user input"
+
+
+@register_rule
+class PHPFileInclusionRule(DialectRule):
+    rule_id = "php-file-inclusion"
+    name = "PHP file included from a user-controlled path"
+    vuln_type = "File Inclusion"
+    severity = "critical"
+    cwe_id = "CWE-98"
+    description = "include or require loads a path built from user input, which can run other local files or, with allow_url_include, remote code"
+    remediation = "Map the input to a fixed list of files (a match or array lookup) instead of building the path from it"
+    example = 'include "pages/" . $_GET[\'page\'] . ".php";'
+    languages = ('php',)
+    dialect = PHP
+    sinks = ((re.compile(r'(?<![\w."$])(?:include|require)(?:_once)?\b'), 0),)
+    sanitizers = INCLUDE_ESCAPES
+    tainted_description = "Included path is built from user input"
+
+    def arguments(self, lines: Tuple[str, ...], index: int, match: re.Match) -> List[str]:
+        # a statement, not a call: the path runs to the end of the statement
+        return [lines[index][match.end():].split(';')[0]]
+
+
+@register_rule
+class PHPDeserializationRule(DialectRule):
+    rule_id = "php-deserialization"
+    name = "PHP unserialize() of untrusted data"
+    vuln_type = "Insecure Deserialization"
+    severity = "high"
+    cwe_id = "CWE-502"
+    description = ("unserialize() instantiates any class named in its input and runs its magic methods (__wakeup, "
+                   "__destruct), so untrusted input can trigger a gadget chain")
+    remediation = "Exchange data as JSON (json_decode), or pass ['allowed_classes' => false] (or a short class list) to unserialize"
+    example = '$prefs = unserialize($_COOKIE[\'prefs\']);'
+    languages = ('php',)
+    dialect = PHP
+    sinks = ((re.compile(r'(?<![\w.])unserialize\s*\('), 0),)
+    tainted_description = "unserialize() is called on user input"
+    dynamic_description = "unserialize() is called without allowed_classes on data that is not a constant"
+
+    def is_safe(self, args: List[str]) -> bool:
+        return any('allowed_classes' in arg for arg in args[1:])
+
+    def is_dynamic(self, expr: str) -> bool:
+        return bool(IDENTIFIER.search(strip_strings(expr)))
@@ -0,0 +1,140 @@
+"""
+Rate limiting rules - Authentication and expensive endpoints registered without rate limiting
+A route counts as limited when a limiter wraps it at registration, is installed with Use on its router or one
+the router was derived from (Group, Route, With, PathPrefix), wraps the whole server, or is consulted inside
+the handler. Routers created in another file fall back to whether the module installs a limiter anywhere.
+Covers net/http, gorilla/mux, chi, gin, echo, and fiber with x/time/rate, tollbooth, httprate, ulule/limiter,
+throttled, redis_rate, echo RateLimiter, and fiber limiter.
+"""
+
+import re
+from typing import Dict, List, Optional, Set, Tuple
+
+from ..parser import SourceMember
+from .base import Rule, SourceContext, StaticFinding, register_rule
+from .taint import split_args
+
+ROUTE_REGISTRATION = re.compile(
+    r'(?<![\w.])(\w+)((?:\.\w+\([^()\n]*(?:\([^()\n]*\)[^()\n]*)*\))*)'
+    r'\.(HandleFunc|Handle|GET|POST|PUT|PATCH|DELETE|Any|Get|Post|Put|Patch|Delete|Match|Add)\s*\(\s*"([^"]*)"'
+)
+ROUTER_DERIVED = re.compile(r'(\w+)\s*:?=\s*(\w+)\.(?:Group|Route|PathPrefix|With|Mount)\b[^\n]*')
+ROUTER_CREATED = re.compile(
+    r'(\w+)\s*:?=\s*(?:gin\.(?:New|Default)|echo\.New|fiber\.New|chi\.NewRouter|mux\.NewRouter|http\.NewServeMux'
+    r'|httprouter\.New)\s*\('
+)
+ROUTE_PATH = re.compile(r'^(?:[A-Z]+\s+)?/')  # "/login", or "POST /login" (Go 1.22 patterns)
+MIDDLEWARE_USE = re.compile(r'(\w+)\.Use\s*\(')
+SERVER_WRAP = re.compile(r'\bListenAndServe(?:TLS)?\s*\([^\n]*|\bHandler\s*:\s*[^\n]*')
+RATE_LIMITER = re.compile(
+    r'rate_?limit|limiter|throttl|tollbooth|httprate|redis_rate|\brate\.NewLimiter\b|\.Allow\s*\(\s*\)|\.Wait\s*\(',
+    re.IGNORECASE
+)
+
+AUTH_ENDPOINT = re.compile(
+    r'log_?in|sign_?in|sign-in|sign_?up|sign-up|register|auth(?!or)|token|oauth|password|passwd|reset|forgot|otp|mfa|2fa'
+    r'|verify|magic-?link|session',
+    re.IGNORECASE
+)
+EXPENSIVE_CALLS = {
+    "runs a command": re.compile(r'\bexec\.Command(?:Context)?\s*\('),
+    "fetches an external URL": re.compile(
+        r'\bhttp\.(?:Get|Head|Post|PostForm|NewRequest\w*)\s*\(|\b\w*[cC]lient\.(?:Get|Head|Post|PostForm|Do)\s*\('
+        r'|\.Do\s*\(\s*req\w*\s*\)|\bnet\.Dial\w*\s*\('
+    ),
+    "sends mail": re.compile(r'\bsmtp\.SendMail\s*\('),
+}
+
+
+@register_rule
+class MissingRateLimitRule(Rule):
+    rule_id = "missing-rate-limit"
+    name = "Sensitive endpoint without rate limiting"
+    vuln_type = "Missing Rate Limiting"
+    severity = "medium"
+    cwe_id = "CWE-799"
+    description = ("An authentication or expensive endpoint is registered without rate limiting, so clients can brute-force "
+                   "credentials and reset codes or exhaust the server and the services it calls")
+    remediation = ("Put a limiter in front of the route or its group: httprate (chi), tollbooth or x/time/rate (net/http), "
+                   "ulule/limiter (gin), echo middleware.RateLimiter, fiber limiter.New; key login and reset limits by "
+                   "account as well as by IP")
+    example = 'r.POST("/login", h.Login)'
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+        registrations = list(ctx.search(ROUTE_REGISTRATION))
+        if not registrations:
+            return findings
+        if any(RATE_LIMITER.search(m.group(0)) for m in SERVER_WRAP.finditer(ctx.code)):
+            return findings  # the whole server sits behind a limiter
+
+        parents = {m.group(1): (m.group(2), m.group(0)) for m in ROUTER_DERIVED.finditer(ctx.code)}
+        created = {m.group(1) for m in ROUTER_CREATED.finditer(ctx.code)}
+        if re.search(r'\bhttp\.ListenAndServe(?:TLS)?\s*\([^\n]*,\s*nil\s*\)', ctx.code):
+            created.add('http')  # DefaultServeMux, served from this file
+        limited_routers = {
+            m.group(1) for m in MIDDLEWARE_USE.finditer(ctx.code) if RATE_LIMITER.search(ctx.call_args(m.end() - 1))
+        }
+        functions = {f.name: f for f in ctx.functions()}
+        module_limited = None
+
+        for line, match in registrations:
+            router, chain, method, path = match.groups()
+            if not ROUTE_PATH.match(path):
+                continue  # w.Header().Get("Accept"), values.Add("id", ...)
+            args = ctx.call_args(ctx.code.index('(', match.end(3)))
+            if RATE_LIMITER.search(chain) or RATE_LIMITER.search(args):
+                continue
+
+            handler = self.handler(args, functions)
+            handler_code = args if handler is None else '\n'.join(
+                f.body.split('\n', 1)[-1] for f in ctx.called_functions(handler)
+            )
+            if RATE_LIMITER.search(handler_code):
+                continue
+            reason = self.sensitivity(path, handler.name if handler else "", handler_code)
+            if not reason:
+                continue
+
+            lineage = self.lineage(router, parents)
+            if any(r in limited_routers or RATE_LIMITER.search(parents.get(r, ("", ""))[1]) for r in lineage):
+                continue
+            confidence = 0.7
+            if lineage[-1] not in created:
+                # the root router comes from elsewhere, where a limiter may be installed
+                if module_limited is None:
+                    module_limited = any(
+                        RATE_LIMITER.search(source) and (MIDDLEWARE_USE.search(source) or SERVER_WRAP.search(source))
+                        for source in ctx.module_sources()[1:]
+                    )
+                if module_limited:
+                    continue
+                confidence = 0.5
+            findings.append(self.finding(
+                ctx, line, match=match, confidence=confidence,
+                description=f"{method} {path} {reason} and is registered without rate limiting"
+            ))
+        return findings
+
+    def handler(self, args: str, functions: Dict[str, SourceMember]) -> Optional[SourceMember]:
+        """The same-file function a registration routes to, if any"""
+        parts = split_args(args)
+        for part in reversed(parts[1:]):
+            name = re.match(r'^(?:&?\w+\.)*(\w+)(?:\s*\(\s*\w*\s*\))?$', part.strip())
+            if name and name.group(1) in functions:
+                return functions[name.group(1)]
+        return None
+
+    def sensitivity(self, path: str, handler_name: str, handler_code: str) -> Optional[str]:
+        if AUTH_ENDPOINT.search(path) or AUTH_ENDPOINT.search(handler_name):
+            return "handles authentication"
+        return next((what for what, pattern in EXPENSIVE_CALLS.items() if pattern.search(handler_code)), None)
+
+    def lineage(self, router: str, parents: Dict[str, Tuple[str, str]]) -> List[str]:
+        """router, the router it was derived from, and so on up to the root"""
+        lineage: List[str] = [router]
+        seen: Set[str] = {router}
+        while lineage[-1] in parents and parents[lineage[-1]][0] not in seen:
+            lineage.append(parents[lineage[-1]][0])
+            seen.add(lineage[-1])
+        return lineage
@@ -0,0 +1,293 @@
+"""
+Secret rules - Literal credentials in Go, Kotlin, and PHP source and in .env, YAML, JSON, TOML, and properties files
+Values are classified before reporting: references (${VAR}, $(VAR), {{ .Values.x }}, !Ref, vault:...) and
+placeholders are not secrets, a reference with a literal fallback (${DB_PASSWORD:-hunter2}) reports the fallback,
+and well-known token formats (cloud keys, private keys) are reported whatever key they sit under.
+"""
+
+import os
+import re
+from typing import Iterator, List, Optional, Tuple
+
+from .base import Rule, SourceContext, StaticFinding, register_rule
+
+
+CONFIG_EXTENSIONS = {'.yaml': 'yaml', '.yml': 'yaml', '.json': 'json', '.toml': 'toml', '.properties': 'properties'}
+LOCKFILES = ('package-lock.json', 'npm-shrinkwrap.json', 'composer.lock', 'pipfile.lock')
+# example/template files are meant to hold placeholders; only unmistakable tokens are reported there
+EXAMPLE_FILE = re.compile(r'[._-](?:example|sample|template|tmpl|dist|defaults?)(?:[._-]|$)')
+# test fixtures are full of fake credentials
+TEST_SOURCE = re.compile(
+    r'_test\.(?:go|py|c|cpp)$|Tests?\.(?:kt|php|java)$|(?:^|/)test_\w+\.py$|\.(?:test|spec)\.[jt]sx?$'
+    r'|(?:^|/)src/test/|(?:^|/)tests?/|(?:^|/)__tests__/'
+)
+
+TOKEN_FORMATS = [
+    ("AWS access key", re.compile(r'\b(?:AKIA|ASIA)[0-9A-Z]{16}\b')),
+    ("GitHub token", re.compile(r'\bgh[pousr]_[A-Za-z0-9]{36,}\b|\bgithub_pat_[A-Za-z0-9_]{60,}\b')),
+    ("GitLab token", re.compile(r'\bglpat-[A-Za-z0-9_-]{20,}\b')),
+    ("Slack token", re.compile(r'\bxox[abposr]-[A-Za-z0-9-]{10,}\b')),
+    ("Stripe secret key", re.compile(r'\b[sr]k_live_[A-Za-z0-9]{20,}\b')),
+    ("Google API key", re.compile(r'\bAIza[0-9A-Za-z_-]{35}\b')),
+    ("private key", re.compile(r'-----BEGIN (?:[A-Z]+ )?PRIVATE KEY-----')),
+]
+URL_CREDENTIALS = re.compile(r'\b[a-z][a-z0-9+.-]*://([^/\s:@"\']+):([^/\s@"\']+)@[^\s"\']+', re.IGNORECASE)
+
+SECRET_WORDS = {'password', 'passwd', 'pwd', 'passphrase', 'secret', 'secrets', 'token', 'apikey', 'credential', 'credentials', 'privatekey'}
+SECRET_PAIRS = {('api', 'key'), ('access', 'key'), ('private', 'key'), ('secret', 'key'), ('signing', 'key'), ('encryption', 'key'),
+                ('master', 'key'), ('client', 'secret'), ('auth', 'token'), ('auth', 'key'), ('session', 'key'), ('pass', 'word')}
+# the key names a secret without holding it: my_password_file, token_ttl, secret_name, api_key_header
+NON_SECRET_SUFFIXES = {'file', 'path', 'dir', 'name', 'env', 'var', 'ref', 'id', 'url', 'uri', 'endpoint', 'header', 'type', 'ttl',
+                       'timeout', 'length', 'len', 'size', 'min', 'max', 'enabled', 'required', 'policy', 'field', 'param', 'mode',
+                       'format', 'algorithm', 'version', 'count', 'expiry', 'expires', 'lifetime', 'provider', 'prefix', 'location',
+                       'arn', 'hash', 'store', 'source', 'regex', 'pattern', 'rotation'}
+
+REFERENCE = re.compile(
+    r'^(?:'
+    r'\$[A-Za-z_]\w*'                        # $VAR
+    r'|\$\([A-Za-z_][\w.]*\)'                # Kubernetes $(VAR)
+    r'|\$\{\{.*\}\}'                         # GitHub Actions ${{ secrets.X }}
+    r'|\{\{.*\}\}'                           # Helm / Jinja / Go templates
+    r'|<%=?.*%>'                             # ERB
+    r'|#\{.*\}'                              # Spring EL / Ruby interpolation
+    r'|%\(\w+\)s|%[A-Za-z_]\w*%'             # Python config interpolation, Windows %VAR%
+    r'|@[\w.-]+@'                            # Maven resource filtering
+    r'|ENC\[.*\]'                            # SOPS-encrypted value
+    r'|!\w+.*'                               # YAML tags: !Ref, !Sub, !GetAtt, !vault, !env_var, !secret
+    r')$'
+    r'|^(?:env|vault|secret|secrets|ssm|sops|awskms|gcpkms|file|ref\+\w+|op|akv|secretsmanager|keyvault):'
+    r'|^arn:aws:(?:secretsmanager|ssm|kms):'
+    r'|^projects/[^/]+/secrets/'
+)
+# ${VAR}, ${VAR:-fallback}, ${VAR-fallback}, ${VAR:fallback} (Spring), ${VAR:?error}
+PLACEHOLDER_REFERENCE = re.compile(r'^\$\{([A-Za-z_][\w.-]*)(?:(:?-|:(?![?=+]))(.*))?(?::?[?=+].*)?\}$')
+PLACEHOLDER = re.compile(
+    r'^(?:change[\s_-]?me|changeit|replace[\s_-]?me|your[\s_-].*|.*[\s_-]here|x{3,}|\*{3,}|\.{3,}|<[^>]*>|\[[^\]]*\]'
+    r'|todo|tbd|fixme|placeholder|dummy|fake|example|sample|redacted|none|null|nil|undefined|~|true|false|yes|no|on|off'
+    r'|secret|password|token|\S*(?:example|dummy|placeholder)\S*)$',
+    re.IGNORECASE
+)
+
+
+def key_words(key: str) -> List[str]:
+    key = re.sub(r'([a-z0-9])([A-Z])', r'\1_\2', key.strip('"\''))
+    return [w for w in re.split(r'[^a-z0-9]+', key.lower()) if w]
+
+
+def is_secret_key(key: str) -> bool:
+    words = key_words(key)
+    if not words or words[-1] in NON_SECRET_SUFFIXES or 'public' in words:
+        return False
+    return bool(SECRET_WORDS & set(words)) or any(pair in SECRET_PAIRS for pair in zip(words, words[1:]))
+
+
+def unquote(value: str) -> str:
+    value = value.strip()
+    if len(value) >= 2 and value[0] in '"\'' and value[-1] == value[0]:
+        return value[1:-1]
+    return value
+
+
+def strip_inline_comment(value: str, markers: str = '#') -> str:
+    """Drop a trailing " # comment" outside quotes"""
+    quote = None
+    for i, char in enumerate(value):
+        if quote:
+            quote = None if char == quote else quote
+        elif char in '"\'':
+            quote = char
+        elif char in markers and (i == 0 or value[i - 1] in ' \t'):
+            return value[:i].rstrip()
+    return value
+
+
+def literal_secret(value: str) -> Optional[str]:
+    """The literal credential in a value: None for references, placeholders, and trivial values; for a
+    reference with a fallback, the fallback if that is itself a literal"""
+    value = unquote(value)
+    if len(value) < 4 or re.fullmatch(r'[\d.,:_-]+', value) or ' ' in value.strip():
+        return None
+    placeholder = PLACEHOLDER_REFERENCE.match(value)
+    if placeholder:
+        return literal_secret(placeholder.group(3)) if placeholder.group(3) else None
+    if REFERENCE.search(value) or PLACEHOLDER.match(value):
+        return None
+    return value
+
+
+def url_password(text: str) -> Optional[re.Match]:
+    for match in URL_CREDENTIALS.finditer(text):
+        password = match.group(2)
+        if literal_secret(password) and not password.startswith(('$', '{', '%')):
+            return match
+    return None
+
+
+def config_format(file_path: str) -> Optional[str]:
+    name = os.path.basename(file_path).lower()
+    if name in LOCKFILES:
+        return None
+    if name == '.env' or name.startswith('.env.') or name.endswith('.env'):
+        return 'env'
+    return CONFIG_EXTENSIONS.get(os.path.splitext(name)[1])
+
+
+def is_example_file(file_path: str) -> bool:
+    return bool(EXAMPLE_FILE.search(os.path.basename(file_path).lower()))
+
+
+ENV_ENTRY = re.compile(r'^\s*(?:export\s+)?([A-Za-z_][\w.-]*)\s*=\s*(.*)$')
+PROPERTIES_ENTRY = re.compile(r'^\s*([^#!\s=:][^=:\s]*)\s*[=:]\s*(.*)$')
+TOML_ENTRY = re.compile(r'^\s*("[^"]+"|\'[^\']+\'|[\w.-]+)\s*=\s*(.*)$')
+YAML_ENTRY = re.compile(r'^(\s*)(?:-\s+)?("[^"]+"|\'[^\']+\'|[\w.-]+)\s*:(?:\s+(.*))?$')
+YAML_LIST_ASSIGNMENT = re.compile(r'^\s*-\s+["\']?([A-Za-z_]\w*)=(.*?)["\']?\s*$')  # compose: - DB_PASSWORD=...
+JSON_ENTRY = re.compile(r'"((?:[^"\\]|\\.)+)"\s*:\s*("(?:[^"\\]|\\.)*")')
+
+
+def config_entries(ctx: SourceContext, fmt: str) -> Iterator[Tuple[int, str, str]]:
+    """(line, key, raw value) pairs of a config file; Kubernetes env lists (- name: X / value: Y) report X"""
+    env_name: Optional[Tuple[int, str]] = None
+    for number, line in enumerate(ctx.lines, 1):
+        if fmt == 'json':
+            for match in JSON_ENTRY.finditer(line):
+                yield number, match.group(1), match.group(2)
+            continue
+        if re.match(r'^\s*(?:#|;|!|//)', line) or not line.strip():
+            continue
+        if fmt == 'env':
+            match = ENV_ENTRY.match(line)
+            if match:
+                yield number, match.group(1), strip_inline_comment(match.group(2))
+        elif fmt == 'properties':
+            match = PROPERTIES_ENTRY.match(line)
+            if match:
+                yield number, match.group(1), match.group(2).strip()
+        elif fmt == 'toml':
+            match = TOML_ENTRY.match(line)
+            if match:
+                yield number, match.group(1), strip_inline_comment(match.group(2))
+        elif fmt == 'yaml':
+            assignment = YAML_LIST_ASSIGNMENT.match(line)
+            if assignment:
+                yield number, assignment.group(1), assignment.group(2)
+                continue
+            match = YAML_ENTRY.match(line)
+            if not match or not match.group(3):
+                continue
+            indent, key, value = len(match.group(1)), match.group(2).strip('"\''), strip_inline_comment(match.group(3))
+            if key == 'name' and line.lstrip().startswith('-'):
+                env_name = (indent, unquote(value))
+                continue
+            if key == 'value' and env_name and indent >= env_name[0]:
+                key = env_name[1]
+            yield number, key, value
+
+
+@register_rule
+class HardcodedSecretRule(Rule):
+    rule_id = "hardcoded-secret"
+    name = "Credential hardcoded in source"
+    vuln_type = "Hardcoded Credentials"
+    severity = "high"
+    cwe_id = "CWE-798"
+    description = "A password, token, or key is written into the source code, so everyone with access to the repository or binary has it"
+    remediation = "Load the credential at runtime from the environment or a secret manager, and rotate the exposed value"
+    example = 'const dbPassword = "s3cr3t-Pa55"'
+    languages = ('go', 'kotlin', 'php', 'python', 'javascript', 'typescript', 'java', 'c', 'cpp')
+
+    ASSIGNED = re.compile(r'(?<![\w.])(\w+)\s*(?::=|=|:)\s*(?:\[\]byte\()?"([^"\\\n]*(?:\\.[^"\\\n]*)*)"')
+    # Kotlin typed declarations and PHP's other forms: val password: String = "...", $password = '...',
+    # 'password' => '...', define('DB_PASSWORD', '...')
+    DECLARED = re.compile(
+        r'(?:(?<![\w.])(\w+)\s*:\s*String\??\s*=|\$(\w+)\s*=|[\'"](\w+)[\'"]\s*=>|\bdefine\(\s*[\'"](\w+)[\'"]\s*,)'
+        r'\s*([\'"])((?:(?!\5)[^\\\n]|\\.)*)\5'
+    )
+    # Python, JavaScript, and Java: either quote, attributes (app.secret_key = '...'), and object or dict keys
+    QUOTED = re.compile(
+        r'(?<![\w$])(?:[A-Za-z_$][\w$]*\.)*(?:([A-Za-z_]\w*)|[\'"](\w+)[\'"])\s*(?:=|:)\s*'
+        r'([\'"])((?:(?!\3)[^\\\n]|\\.)*)\3'
+    )
+    # C and C++: #define ADMIN_PASSWORD "..."
+    DEFINE = re.compile(r'^[ \t]*#[ \t]*define[ \t]+(\w+)[ \t]+"([^"\\\n]*(?:\\.[^"\\\n]*)*)"', re.MULTILINE)
+    STRING = re.compile(r'"([^"\\\n]*(?:\\.[^"\\\n]*)*)"|`([^`]*)`|\'([^\'\\\n]*(?:\\.[^\'\\\n]*)*)\'')
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        if TEST_SOURCE.search(ctx.file_path.replace(os.sep, '/')):
+            return []
+        findings = {}
+
+        for line, match in ctx.search(self.STRING):
+            literal = next(group for group in match.groups() if group is not None)
+            token = next((name for name, pattern in TOKEN_FORMATS if pattern.search(literal)), None)
+            if token:
+                findings[line] = self.finding(ctx, line, match=match, confidence=0.9, description=f"{token} is hardcoded in a string literal")
+            elif url_password(literal):
+                findings[line] = self.finding(ctx, line, match=match, description="Connection URL embeds a literal password")
+
+        for line, match in ctx.search(self.ASSIGNED):
+            name, value = match.groups()
+            if line in findings or not is_secret_key(name) or not literal_secret(value):
+                continue
+            findings[line] = self.finding(ctx, line, match=match, confidence=0.7, description=f"{name} is assigned a literal credential")
+
+        declared = []
+        if ctx.language in ('kotlin', 'php'):
+            declared = [(line, next(g for g in match.groups()[:4] if g is not None), match.group(6), match)
+                        for line, match in ctx.search(self.DECLARED)]
+        elif ctx.language in ('python', 'javascript', 'typescript', 'java'):
+            declared = [(line, match.group(1) or match.group(2), match.group(4), match) for line, match in ctx.search(self.QUOTED)]
+        elif ctx.language in ('c', 'cpp'):
+            declared = [(line, match.group(1), match.group(2), match) for line, match in ctx.search(self.DEFINE)]
+        for line, name, value, match in declared:
+            # password = '{password}' inside an f-string or template is interpolation, not a credential
+            if line in findings or not is_secret_key(name) or not literal_secret(value) or value.startswith(('{', '${', '%')):
+                continue
+            findings[line] = self.finding(ctx, line, match=match, confidence=0.7, description=f"{name} is assigned a literal credential")
+
+        return [findings[line] for line in sorted(findings)]
+
+
+@register_rule
+class ConfigSecretRule(Rule):
+    rule_id = "config-secret"
+    name = "Credential stored in a configuration file"
+    vuln_type = "Hardcoded Credentials"
+    severity = "high"
+    fix_complexity = "trivial"
+    cwe_id = "CWE-798"
+    description = "A configuration file holds a literal password, token, or key instead of a reference to the environment or a secret store"
+    remediation = "Replace the value with a reference (${VAR}, a Kubernetes secretKeyRef, a vault/secret-manager lookup), keep real values out of version control, and rotate the exposed one"
+    example = 'DB_PASSWORD=s3cr3t-Pa55  (in .env; DB_PASSWORD=${DB_PASSWORD} is fine)'
+    languages = ('env', 'yaml', 'json', 'toml', 'properties')
+
+    def applies_to(self, ctx: SourceContext) -> bool:
+        return config_format(ctx.file_path) is not None
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        fmt = config_format(ctx.file_path)
+        example = is_example_file(ctx.file_path)
+        findings = {}
+
+        for number, line in enumerate(ctx.lines, 1):
+            token = next((name for name, pattern in TOKEN_FORMATS if pattern.search(line)), None)
+            if token:
+                findings[number] = self.finding(ctx, number, confidence=0.9, description=f"{token} is stored in {fmt} config")
+            elif not example:
+                match = url_password(line)
+                if match:
+                    findings[number] = self.finding(ctx, number, description=f"Connection URL in {fmt} config embeds a literal password")
+
+        if not example:
+            for number, key, value in config_entries(ctx, fmt):
+                if number in findings or not is_secret_key(key):
+                    continue
+                secret = literal_secret(value)
+                if not secret:
+                    continue
+                fallback = " as the fallback of a ${...} reference" if unquote(value).startswith('${') else ""
+                findings[number] = self.finding(
+                    ctx, number, confidence=0.7,
+                    description=f"{key} in {fmt} config is a literal credential{fallback}, not a reference"
+                )
+
+        return [findings[line] for line in sorted(findings)]
@@ -0,0 +1,137 @@
+"""
+Taint helpers - Intra-function propagation of user-controlled values
+Tracks simple assignments line by line; no aliasing or interprocedural flow
+"""
+
+import re
+from typing import Dict, Iterable, List, Optional, Set, Tuple
+
+USER_INPUT_SOURCES = re.compile(
+    r'\.(?:FormValue|PostFormValue)\('
+    r'|\.URL\.Query\(\)'
+    r'|\.URL\.(?:Path|RawQuery)\b'
+    r'|\.Header\.Get\('
+    r'|\.(?:Form|PostForm|MultipartForm)\b'
+    r'|\bmux\.Vars\('
+    r'|\bchi\.URLParam\('
+    r'|\b(?:c|ctx)\.(?:Query|DefaultQuery|Param|PostForm|GetHeader|FormValue|QueryParam)\('
+    r'|\.ReadJSON\(|\.ReadMessage\('
+    r'|\bjson\.NewDecoder\(\s*\w+\.Body\s*\)'
+    r'|\bos\.Args\b'
+)
+
+ASSIGNMENT = re.compile(r'^\s*(?:var\s+)?([\w\s,.]+?)\s*(?::=|=)\s*(.+)$')
+STRING_LITERAL = re.compile(r'"(?:[^"\\\n]|\\.)*"|`[^`]*`')
+IDENTIFIER = re.compile(r'(?<![\w.])[A-Za-z_]\w*')
+ADDRESS_OF = re.compile(r'&(\w+)')
+
+
+def strip_strings(expr: str) -> str:
+    return STRING_LITERAL.sub('""', expr)
+
+
+def references(expr: str, names: Iterable[str]) -> Optional[str]:
+    names = set(names)
+    if not names:
+        return None
+    for match in IDENTIFIER.finditer(strip_strings(expr)):
+        if match.group(0) in names:
+            return match.group(0)
+    return None
+
+
+def assigned_names(lhs: str) -> Set[str]:
+    names = set()
+    for part in lhs.split(','):
+        name = part.strip().split('.')[0].split()[-1] if part.strip() else ''
+        if name and name not in ('_', 'err'):
+            names.add(name)
+    return names
+
+
+def split_args(args: str) -> List[str]:
+    parts = []
+    depth = 0
+    current = ''
+    quote = None
+
+    for char in args:
+        if quote:
+            current += char
+            if char == quote and not current.endswith('\\' + quote):
+                quote = None
+            continue
+        if char in '"`\'':
+            quote = char
+        elif char in '([{':
+            depth += 1
+        elif char in ')]}':
+            depth -= 1
+        elif char == ',' and depth == 0:
+            parts.append(current.strip())
+            current = ''
+            continue
+        current += char
+
+    if current.strip():
+        parts.append(current.strip())
+    return parts
+
+
+def taint_origins(
+    body: str,
+    sources: re.Pattern = USER_INPUT_SOURCES,
+    seeds: Iterable[str] = (),
+    sanitizers: Optional[re.Pattern] = None
+) -> Dict[str, Tuple[int, Optional[str]]]:
+    """Map each tainted name to (body line index, name it was derived from)"""
+    origins = {name: (0, None) for name in seeds}
+    lines = body.split('\n')
+
+    for _ in range(2):
+        for index, line in enumerate(lines):
+            if sources.search(line):
+                for name in ADDRESS_OF.findall(line):
+                    origins.setdefault(name, (index, None))
+
+            match = ASSIGNMENT.match(line)
+            if not match:
+                continue
+            lhs, rhs = match.groups()
+            if sanitizers and sanitizers.search(rhs):
+                for name in assigned_names(lhs):
+                    origins.pop(name, None)
+                continue
+
+            parent = None if sources.search(rhs) else references(rhs, origins)
+            if parent or sources.search(rhs):
+                for name in assigned_names(lhs):
+                    origins.setdefault(name, (index, parent))
+
+    return origins
+
+
+def tainted_names(
+    body: str,
+    sources: re.Pattern = USER_INPUT_SOURCES,
+    seeds: Iterable[str] = (),
+    sanitizers: Optional[re.Pattern] = None
+) -> Set[str]:
+    return set(taint_origins(body, sources, seeds, sanitizers))
+
+
+def taint_path(origins: Dict[str, Tuple[int, Optional[str]]], name: str, first_line: int = 1) -> List[int]:
+    """Line numbers from the source to the assignment of name, offset by first_line"""
+    path = []
+    seen = set()
+
+    while name in origins and name not in seen:
+        seen.add(name)
+        index, parent = origins[name]
+        if first_line + index not in path:
+            path.append(first_line + index)
+        if parent is None:
+            break
+        name = parent
+
+    return path[::-1]
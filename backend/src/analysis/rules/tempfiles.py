@@ -0,0 +1,102 @@
+"""
+Temporary file rules - Predictable temp paths and secrets left in temp files
+"""
+
+import re
+from typing import List
+
+from .base import Rule, SourceContext, StaticFinding, register_rule
+from .logs import is_sensitive_name, sensitive_field_names, sensitive_variables
+from .taint import ASSIGNMENT, assigned_names, strip_strings
+
+
+TEMPDIR_FIXED_NAME = re.compile(
+    r'\b(?:filepath|path)\.Join\s*\(\s*os\.TempDir\(\)\s*,\s*"[^"]+"'
+    r'|\bos\.TempDir\(\)\s*\+\s*"[^"]+"'
+)
+FILE_CREATE = re.compile(r'\b(?:os\.(?:Create|OpenFile|WriteFile|Mkdir|MkdirAll)|ioutil\.WriteFile)\s*\(\s*"(/tmp/[^"*]+)"')
+TEMP_CREATE = re.compile(r'\b(?:os\.CreateTemp|ioutil\.TempFile)\s*\(')
+TEMP_WRITE = r'(?:\b{name}\.(?:Write|WriteString|WriteAt)\s*\(|\bfmt\.Fprint\w*\s*\(\s*{name}\s*,|\bio\.Copy\s*\(\s*{name}\s*,)'
+TEMP_REMOVE = r'\bos\.Remove(?:All)?\s*\(\s*(?:{name}\.Name\(\)|\w+\s*\))'
+
+
+@register_rule
+class PredictableTempFileRule(Rule):
+    rule_id = "predictable-temp-file"
+    name = "Predictable temporary file path"
+    vuln_type = "Insecure Temporary File"
+    severity = "medium"
+    fix_complexity = "trivial"
+    cwe_id = "CWE-377"
+    description = "A temporary file uses a fixed name in the shared temp directory, so another local user can pre-create or symlink it"
+    remediation = "Use os.CreateTemp or os.MkdirTemp, which pick an unpredictable name and create the file exclusively"
+    example = 'f, _ := os.Create(filepath.Join(os.TempDir(), "session.dat"))'
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+
+        for line, match in ctx.search(TEMPDIR_FIXED_NAME):
+            findings.append(self.finding(
+                ctx, line, match=match,
+                description="A fixed file name is joined onto os.TempDir(), giving a predictable temp path"
+            ))
+
+        for line, match in ctx.search(FILE_CREATE):
+            findings.append(self.finding(
+                ctx, line, match=match,
+                description=f"File is created at the hardcoded temp path {match.group(1)}"
+            ))
+
+        return findings
+
+
+@register_rule
+class TempFileSecretRule(Rule):
+    rule_id = "temp-file-secret-not-removed"
+    name = "Secret written to a temp file that is never removed"
+    vuln_type = "Insecure Temporary File"
+    severity = "medium"
+    fix_complexity = "trivial"
+    cwe_id = "CWE-459"
+    description = "Sensitive data is written to a temporary file that is never removed, leaving it on disk after use"
+    remediation = "defer os.Remove(f.Name()) right after creating the temp file, or avoid writing secrets to disk"
+    example = 'f, _ := os.CreateTemp("", "cfg")\nf.WriteString(apiToken)'
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+        sensitive_names = sensitive_field_names(ctx)
+
+        for function in ctx.functions():
+            start, end = ctx.span(function)
+            sensitive = sensitive_variables(function.body, sensitive_names)
+
+            for line, match in ctx.search(TEMP_CREATE, start, end):
+                assignment = ASSIGNMENT.match(ctx.line(line))
+                if not assignment:
+                    continue
+                handles = assigned_names(assignment.group(1))
+                if not handles:
+                    continue
+                handle = sorted(handles)[0]
+
+                write = re.compile(TEMP_WRITE.format(name=re.escape(handle)))
+                leaked = None
+                for write_line, _ in ctx.search(write, start, end):
+                    for token in re.findall(r'[A-Za-z_]\w*', strip_strings(ctx.line(write_line))):
+                        if token in sensitive or is_sensitive_name(token, sensitive_names):
+                            leaked = token
+                            break
+                    if leaked:
+                        break
+                if not leaked:
+                    continue
+
+                removal = TEMP_REMOVE.format(name=re.escape(handle))
+                if re.search(removal, function.body):
+                    continue
+                findings.append(self.finding(
+                    ctx, line, match=match,
+                    description=f"Temp file '{handle}' receives sensitive value '{leaked}' but is never removed"
+                ))
+
+        return findings
@@ -0,0 +1,88 @@
+"""
+Timeout rules - HTTP servers and clients without timeouts
+"""
+
+import re
+from typing import List
+
+from .base import Rule, SourceContext, StaticFinding, register_rule
+
+
+SERVER_LITERAL = re.compile(r'(?<!\*)\bhttp\.Server\s*\{')
+SERVER_TIMEOUTS = re.compile(r'\b(?:ReadTimeout|ReadHeaderTimeout|WriteTimeout|IdleTimeout)\s*:')
+PACKAGE_LISTEN = re.compile(r'\bhttp\.(?:ListenAndServe|ListenAndServeTLS|Serve|ServeTLS)\s*\(')
+
+CLIENT_LITERAL = re.compile(r'(?<!\*)\bhttp\.Client\s*\{')
+CLIENT_TIMEOUT = re.compile(r'\bTimeout\s*:')
+DEFAULT_CLIENT_CALL = re.compile(r'\bhttp\.(?:Get|Head|Post|PostForm)\s*\(|\bhttp\.DefaultClient\.\w+\s*\(')
+CONTEXT_DEADLINE = re.compile(r'\bcontext\.With(?:Timeout|Deadline)\s*\(')
+
+
+@register_rule
+class HttpServerTimeoutRule(Rule):
+    rule_id = "http-server-no-timeout"
+    name = "HTTP server without timeouts"
+    vuln_type = "Missing Timeout"
+    severity = "medium"
+    fix_complexity = "trivial"
+    cwe_id = "CWE-400"
+    description = "The HTTP server has no read, write, or idle timeouts, so slow clients (Slowloris) can hold connections open indefinitely"
+    remediation = "Serve through an http.Server with ReadHeaderTimeout, ReadTimeout, WriteTimeout, and IdleTimeout set"
+    example = 'log.Fatal(http.ListenAndServe(":8080", nil))'
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+
+        for line, match in ctx.search(SERVER_LITERAL):
+            literal = ctx.code[match.start():ctx.block_end(match.end() - 1)]
+            if not SERVER_TIMEOUTS.search(literal):
+                findings.append(self.finding(
+                    ctx, line, match=match,
+                    description="http.Server is configured without ReadTimeout, WriteTimeout, or IdleTimeout"
+                ))
+
+        for line, match in ctx.search(PACKAGE_LISTEN):
+            findings.append(self.finding(
+                ctx, line, match=match,
+                description=f"{match.group(0).rstrip('(').strip()} uses a server with no timeouts and cannot be configured with any"
+            ))
+
+        return findings
+
+
+@register_rule
+class HttpClientTimeoutRule(Rule):
+    rule_id = "http-client-no-timeout"
+    name = "HTTP client without timeout"
+    vuln_type = "Missing Timeout"
+    severity = "low"
+    fix_complexity = "trivial"
+    cwe_id = "CWE-400"
+    description = "Outbound HTTP requests have no timeout or context deadline, so a slow upstream can tie up goroutines and connections indefinitely"
+    remediation = "Set http.Client.Timeout or issue requests with a context created by context.WithTimeout"
+    example = 'client := &http.Client{Transport: tr}'
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+
+        for line, match in ctx.search(CLIENT_LITERAL):
+            literal = ctx.code[match.start():ctx.block_end(match.end() - 1)]
+            if CLIENT_TIMEOUT.search(literal):
+                continue
+            function = ctx.enclosing_function(line)
+            where = f" in {function.name}" if function else ""
+            findings.append(self.finding(
+                ctx, line, match=match,
+                description=f"http.Client is created{where} without a Timeout"
+            ))
+
+        for line, match in ctx.search(DEFAULT_CLIENT_CALL):
+            function = ctx.enclosing_function(line)
+            if function and CONTEXT_DEADLINE.search(function.body):
+                continue
+            findings.append(self.finding(
+                ctx, line, match=match,
+                description=f"{match.group(0).rstrip('(').strip()} uses http.DefaultClient, which has no timeout"
+            ))
+
+        return findings
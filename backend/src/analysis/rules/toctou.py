@@ -0,0 +1,141 @@
+"""
+TOCTOU rules - Check-then-use races on files and shared state
+"""
+
+import re
+from typing import List, Set
+
+from .base import Rule, SourceContext, StaticFinding, register_rule
+from .taint import split_args
+
+
+FILE_CHECK = re.compile(r'\bos\.(?:Stat|Lstat)\s*\(')
+FILE_USE = re.compile(
+    r'\bos\.(?:Open|OpenFile|Create|Remove|RemoveAll|Rename|Chmod|Chown|WriteFile|ReadFile|Symlink|Link)\s*\('
+    r'|\bioutil\.(?:WriteFile|ReadFile)\s*\('
+)
+
+TOP_LEVEL_VAR = re.compile(r'^var\s+([\w\s,]+?)(?:\s+[\w.*\[\]]+)?\s*(?:=.*)?$')
+VAR_BLOCK_START = re.compile(r'^var\s*\(\s*$')
+SYNC_TYPE = re.compile(r'\b(?:sync|atomic)\.\w+')
+GUARDED = re.compile(r'\.(?:Lock|RLock)\s*\(\s*\)|\batomic\.\w+|\bsync\.Once\b')
+IF_STATEMENT = re.compile(r'^[ \t]*(?:}\s*else\s+)?if\s+(.+?)\s*\{[ \t]*$', re.MULTILINE)
+
+
+def package_variables(ctx: SourceContext) -> Set[str]:
+    names = set()
+    in_block = False
+    depth = 0
+
+    for line in ctx.lines:
+        if in_block:
+            declaration = line.strip()
+            if depth == 0 and declaration == ')':
+                in_block = False
+                continue
+            if depth == 0 and declaration and not declaration.startswith('//') and not SYNC_TYPE.search(declaration):
+                head = declaration.split('=')[0].split(None, 1)
+                if head:
+                    names.update(n.strip() for n in head[0].split(',') if n.strip())
+            depth += line.count('{') - line.count('}')
+            continue
+
+        if VAR_BLOCK_START.match(line):
+            in_block = True
+            continue
+
+        match = TOP_LEVEL_VAR.match(line)
+        if match and not SYNC_TYPE.search(line):
+            names.update(n.strip() for n in match.group(1).split(',') if n.strip())
+
+    return names
+
+
+@register_rule
+class FileCheckThenUseRule(Rule):
+    rule_id = "toctou-file-check"
+    name = "File checked then used"
+    vuln_type = "TOCTOU Race Condition"
+    severity = "medium"
+    cwe_id = "CWE-367"
+    description = "A file path is checked with os.Stat and then used in a separate call, leaving a window where the file can be replaced"
+    remediation = "Open the file once and operate on the handle (f.Stat, O_EXCL/O_NOFOLLOW flags) instead of re-resolving the path"
+    example = 'if _, err := os.Stat(path); os.IsNotExist(err) { f, _ := os.Create(path) }'
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+
+        for function in ctx.functions():
+            start, end = ctx.span(function)
+            checks = []
+            for line, match in ctx.search(FILE_CHECK, start, end):
+                args = split_args(ctx.call_args(match.end() - 1))
+                if args:
+                    checks.append((line, args[0]))
+
+            for check_line, path in checks:
+                for use_line, match in ctx.search(FILE_USE, start, end):
+                    args = split_args(ctx.call_args(match.end() - 1))
+                    if use_line <= check_line or not args or args[0] != path:
+                        continue
+                    findings.append(self.finding(
+                        ctx, use_line, match=match,
+                        description=(
+                            f"'{path}' is checked at line {check_line} and used at line {use_line}; "
+                            f"the file can change in the {use_line - check_line}-line window between check and use"
+                        )
+                    ))
+                    break
+
+        return findings
+
+
+@register_rule
+class SharedStateCheckThenActRule(Rule):
+    rule_id = "toctou-shared-state"
+    name = "Shared state checked then modified without synchronization"
+    vuln_type = "Race Condition"
+    severity = "high"
+    fix_complexity = "architectural"
+    cwe_id = "CWE-362"
+    description = "A package-level variable is checked and then modified without a lock or atomic operation, so concurrent requests can both pass the check"
+    remediation = "Guard the check and the update with the same sync.Mutex, or use an atomic compare-and-swap"
+    example = 'if balance >= amount { balance -= amount }'
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+        shared = package_variables(ctx)
+        if not shared:
+            return findings
+
+        for function in ctx.functions():
+            if GUARDED.search(function.body):
+                continue
+            start, end = ctx.span(function)
+
+            for check_line, match in ctx.search(IF_STATEMENT, start, end):
+                condition = match.group(1)
+                checked = [n for n in shared if re.search(rf'(?<![\w.]){re.escape(n)}\b', condition)]
+                if not checked:
+                    continue
+
+                block_end = ctx.block_end(match.end() - 1)
+                for name in checked:
+                    mutation = re.compile(
+                        rf'(?<![\w.]){re.escape(name)}(?:\[[^\]]*\])?\s*(?:[-+*/]?=(?!=)|\+\+|--)'
+                        rf'|\bdelete\(\s*{re.escape(name)}\b'
+                    )
+                    hit = mutation.search(ctx.code, match.end(), block_end)
+                    if not hit:
+                        continue
+                    use_line = ctx.line_of(hit.start())
+                    findings.append(self.finding(
+                        ctx, check_line, match=match,
+                        description=(
+                            f"'{name}' is checked at line {check_line} and modified at line {use_line} in {function.name} "
+                            f"without synchronization; concurrent calls can both pass the check"
+                        )
+                    ))
+                    break
+
+        return findings
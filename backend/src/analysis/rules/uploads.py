@@ -0,0 +1,110 @@
+"""
+Upload rules - Multipart uploads stored under the client's filename or without validation and size limits
+Covers net/http (FormFile, MultipartForm, MultipartReader), gin and echo (FormFile, SaveUploadedFile), and
+fiber (SaveFile). Checks count when they happen in the handler or in a function of the same file it calls.
+"""
+
+import re
+from typing import List, Optional
+
+from .base import Rule, SourceContext, StaticFinding, register_rule
+from .dos import BODY_LIMIT
+from .taint import references, split_args, taint_origins, taint_path
+
+UPLOAD_SOURCE = re.compile(
+    r'\.FormFile\s*\(|\.MultipartForm\b|\.MultipartReader\s*\(|\.NextPart\s*\('
+    r'|\*multipart\.FileHeader\b|\*multipart\.Part\b'
+)
+CLIENT_FILENAME = re.compile(r'\.Filename\b|\.FileName\s*\(\s*\)')
+CLIENT_CONTENT_TYPE = re.compile(r'\.Header\.Get\(\s*"Content-Type"\s*\)|\.Header\[\s*"Content-Type"\s*\]')
+# writes and the index of their path argument
+FILE_WRITE = re.compile(
+    r'\b(os\.Create|os\.OpenFile|os\.WriteFile|ioutil\.WriteFile|afero\.WriteFile)\s*\('
+    r'|\.(SaveUploadedFile|SaveFile)\s*\('
+)
+# reducing the name to its last element (or replacing it) keeps the write inside the upload directory
+PATH_SANITIZER = re.compile(r'\b(?:filepath|path)\.(?:Base|Ext)\s*\(|\buuid\.|\bxid\.|\bulid\.|\bksuid\.')
+CONTAINMENT_CHECK = re.compile(
+    r'\bfilepath\.IsLocal\s*\(|\bfilepath\.Rel\s*\(|\bstrings\.HasPrefix\s*\([^)]*(?:Clean|Abs)\b|\bos\.OpenRoot\s*\('
+    r'|\.OpenInRoot\s*\(|\bsecurejoin\.'
+)
+TYPE_VALIDATION = re.compile(
+    r'\b(?:filepath|path)\.Ext\s*\(|\bhttp\.DetectContentType\s*\(|\bmimetype\.Detect\w*\s*\(|\bfiletype\.\w+\s*\('
+    r'|\bstrings\.HasSuffix\s*\(\s*(?:strings\.ToLower\s*\()?[\w.]*(?:Filename|FileName\(\)|[nN]ame)\b'
+)
+CONTENT_SNIFFING = re.compile(r'\bhttp\.DetectContentType\s*\(|\bmimetype\.Detect\w*\s*\(|\bfiletype\.\w+\s*\(')
+SIZE_LIMIT = re.compile(BODY_LIMIT.pattern + r'|\.Size\s*>|\bBodyLimit\b|\bMaxRequestBodySize\b')
+
+
+@register_rule
+class UnrestrictedUploadRule(Rule):
+    rule_id = "unrestricted-file-upload"
+    name = "File upload without name, type, or size restrictions"
+    vuln_type = "Unrestricted File Upload"
+    severity = "medium"
+    cwe_id = "CWE-434"
+    description = ("An uploaded file is written to disk under the client's filename, or without checking its type "
+                   "and size, so a client can overwrite files, plant executable content, or fill the disk")
+    remediation = ("Store uploads under a generated name (or filepath.Base of the client name) inside a fixed directory, "
+                   "check the extension against an allowlist and the content with http.DetectContentType instead of "
+                   "the client's Content-Type, and cap the request with http.MaxBytesReader")
+    example = 'file, header, _ := r.FormFile("upload")\ndst, _ := os.Create("uploads/" + header.Filename)\nio.Copy(dst, file)'
+
+    def check(self, ctx: SourceContext) -> List[StaticFinding]:
+        findings = []
+        if not UPLOAD_SOURCE.search(ctx.code):
+            return findings
+        file_limited = bool(re.search(r'\bBodyLimit\b|\bMaxRequestBodySize\b', ctx.code))  # router middleware
+
+        for function in ctx.functions():
+            if not UPLOAD_SOURCE.search(function.body):
+                continue
+            start, end = ctx.span(function)
+            writes = list(ctx.search(FILE_WRITE, start, end))
+            if not writes:
+                continue
+            checked = '\n'.join(f.body.split('\n', 1)[-1] for f in ctx.called_functions(function))
+            origins = taint_origins(function.body, sources=CLIENT_FILENAME, sanitizers=PATH_SANITIZER)
+
+            missing = []
+            if not TYPE_VALIDATION.search(checked):
+                missing.append("an extension or content type check")
+            elif CLIENT_CONTENT_TYPE.search(checked) and not CONTENT_SNIFFING.search(checked):
+                missing.append("content sniffing (it trusts the client's Content-Type)")
+            if not (file_limited or SIZE_LIMIT.search(checked)):
+                missing.append("a size limit")
+
+            for line, match in writes:
+                path = self.path_argument(ctx, match)
+                named = None
+                if path and not CONTAINMENT_CHECK.search(checked):
+                    named = self.client_named(path, origins)
+                if named:
+                    trace = taint_path(origins, named, function.start_line) if named in origins else []
+                    also = f"; it also lacks {' and '.join(missing)}" if missing else ""
+                    findings.append(self.finding(
+                        ctx, line, match=match, severity="high", confidence=0.85, trace=trace + [line],
+                        description=f"{function.name} writes an upload to {path}, built from the client-provided filename "
+                                    f"without filepath.Base or a containment check{also}"
+                    ))
+                elif missing:
+                    findings.append(self.finding(
+                        ctx, line, match=match, confidence=0.6,
+                        description=f"{function.name} stores an upload without {' or '.join(missing)}"
+                    ))
+                break  # one finding per handler
+        return findings
+
+    def path_argument(self, ctx: SourceContext, match: re.Match) -> Optional[str]:
+        args = split_args(ctx.call_args(match.end() - 1))
+        index = 1 if match.group(2) else 0  # SaveUploadedFile(header, dst), SaveFile(header, path)
+        return args[index] if len(args) > index else None
+
+    def client_named(self, path: str, origins) -> Optional[str]:
+        """The name (or inline expression) through which path carries the client's filename"""
+        if PATH_SANITIZER.search(path):
+            return None
+        inline = CLIENT_FILENAME.search(path)
+        if inline:
+            return inline.group(0).lstrip('.')
+        return references(path, origins)
@@ -0,0 +1,184 @@
+"""
+Archive input - Unpack a source tarball or zip into a temporary sandbox and scan the contents
+Extraction is bounded by entry count and unpacked size (counted while copying, not taken from headers),
+and only regular files under the sandbox are written: links, devices, paths escaping it, and anything inside a
+.git directory (whose config could name programs for git to run) are skipped.
+"""
+
+import hashlib
+import logging
+import os
+import shutil
+import stat
+import tarfile
+import tempfile
+import zipfile
+from dataclasses import dataclass, field
+from typing import IO, Any, Dict, Iterator, List, Optional, Tuple
+
+from .config.settings import get_settings
+from .images import safe_member_path
+from .isolation import is_git_metadata, mark_untrusted, release_untrusted
+from .reports import relativize_paths
+
+logger = logging.getLogger(__name__)
+
+ARCHIVE_SUFFIXES = ('.tar', '.tar.gz', '.tgz', '.tar.bz2', '.tbz2', '.tar.xz', '.txz', '.zip')
+COPY_CHUNK = 1024 * 1024
+
+
+class ArchiveError(Exception):
+    pass
+
+
+@dataclass
+class ArchiveInfo:
+    path: str
+    sha256: str = ""
+    format: str = ""
+    entries: int = 0
+    files: int = 0
+    bytes: int = 0
+    skipped: List[str] = field(default_factory=list)  # links, devices, git metadata, unsafe and conflicting paths
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "path": self.path,
+            "sha256": self.sha256,
+            "format": self.format,
+            "entries": self.entries,
+            "files": self.files,
+            "bytes": self.bytes,
+            "skipped": self.skipped[:50]
+        }
+
+
+def is_archive(path: str) -> bool:
+    return os.path.isfile(path) and path.lower().endswith(ARCHIVE_SUFFIXES)
+
+
+def file_digest(path: str) -> str:
+    digest = hashlib.sha256()
+    with open(path, 'rb') as f:
+        for chunk in iter(lambda: f.read(COPY_CHUNK), b''):
+            digest.update(chunk)
+    return digest.hexdigest()
+
+
+def tar_members(path: str) -> Iterator[Tuple[str, str, Optional[IO[bytes]]]]:
+    """(name, kind, stream) per entry; kind is file, dir, or other"""
+    with tarfile.open(path, 'r:*') as archive:
+        for member in archive:
+            kind = "file" if member.isfile() else "dir" if member.isdir() else "other"
+            yield member.name, kind, archive.extractfile(member) if kind == "file" else None
+
+
+def zip_members(path: str) -> Iterator[Tuple[str, str, Optional[IO[bytes]]]]:
+    with zipfile.ZipFile(path) as archive:
+        for info in archive.infolist():
+            mode = info.external_attr >> 16
+            if info.is_dir():
+                kind = "dir"
+            elif stat.S_IFMT(mode) and not stat.S_ISREG(mode):
+                kind = "other"  # symlinks stored by Info-ZIP
+            else:
+                kind = "file"
+            if kind != "file":
+                yield info.filename, kind, None
+                continue
+            with archive.open(info) as stream:
+                yield info.filename, kind, stream
+
+
+def copy_bounded(source: IO[bytes], destination: str, budget: int) -> int:
+    """Copy at most budget bytes; raises once the entry would exceed it"""
+    written = 0
+    with open(destination, 'wb') as f:
+        while True:
+            chunk = source.read(COPY_CHUNK)
+            if not chunk:
+                return written
+            written += len(chunk)
+            if written > budget:
+                raise ArchiveError("unpacked size exceeds the limit")
+            f.write(chunk)
+
+
+def extract_archive(path: str, destination: str, max_bytes: Optional[int] = None, max_entries: Optional[int] = None) -> ArchiveInfo:
+    settings = get_settings()
+    max_bytes = max_bytes or settings.archive_max_bytes
+    max_entries = max_entries or settings.archive_max_entries
+    info = ArchiveInfo(path=os.path.abspath(path), sha256=file_digest(path))
+
+    if zipfile.is_zipfile(path):
+        info.format, members = "zip", zip_members(path)
+    elif tarfile.is_tarfile(path):
+        info.format, members = "tar", tar_members(path)
+    else:
+        raise ArchiveError(f"{path} is not a tar or zip archive")
+
+    try:
+        for name, kind, stream in members:
+            info.entries += 1
+            if info.entries > max_entries:
+                raise ArchiveError(f"more than {max_entries} entries (ARCHIVE_MAX_ENTRIES)")
+            relative = safe_member_path(name)
+            if kind == "dir":
+                continue
+            if not relative or kind != "file" or stream is None or is_git_metadata(relative.replace(os.sep, '/')):
+                info.skipped.append(name)
+                continue
+            target = os.path.join(destination, relative)
+            try:
+                os.makedirs(os.path.dirname(target), exist_ok=True)
+                info.bytes += copy_bounded(stream, target, max_bytes - info.bytes)
+            except ArchiveError:
+                raise ArchiveError(f"unpacks to more than {max_bytes} bytes (ARCHIVE_MAX_BYTES)")
+            except OSError:
+                # clashes with an earlier entry: "a" as a file, then "a/b" (or the other way round)
+                info.skipped.append(name)
+                continue
+            info.files += 1
+    except (tarfile.TarError, zipfile.BadZipFile, EOFError) as e:
+        raise ArchiveError(f"{path} is corrupt: {e}")
+
+    if info.skipped:
+        logger.info(f"{path}: skipped {len(info.skipped)} link, device, git metadata, unsafe, or conflicting entries")
+    return info
+
+
+class ArchiveSandbox:
+    """Context manager: the archive unpacked into a fresh temp directory, removed on exit"""
+
+    def __init__(self, path: str):
+        self.path = path
+        self.root = ""
+        self.info: Optional[ArchiveInfo] = None
+
+    def open(self) -> "ArchiveSandbox":
+        self.root = tempfile.mkdtemp(prefix="sastscan-archive-")
+        mark_untrusted(self.root)
+        try:
+            self.info = extract_archive(self.path, self.root)
+        except BaseException:
+            self.close()
+            raise
+        return self
+
+    def close(self):
+        if self.root:
+            shutil.rmtree(self.root, ignore_errors=True)
+            release_untrusted(self.root)
+
+    def __enter__(self) -> "ArchiveSandbox":
+        return self.open()
+
+    def __exit__(self, *exc):
+        self.close()
+        return False
+
+    def relocate(self, report: Dict[str, Any]):
+        """Point the report at the archive instead of the sandbox, with archive-relative finding paths"""
+        relativize_paths(report, self.root)
+        report["target"] = self.info.path
+        report["archive"] = self.info.to_dict()
@@ -0,0 +1,215 @@
+"""
+Scan attestations - in-toto statements that a commit (or archive, or image) was scanned, by which scanner
+and rules, with which result, so supply-chain policy can require a clean scan before release
+Usage: scanner scan --attest scan.intoto.json [--sign]   or   scanner attest <report-id> -o scan.intoto.json
+
+The statement is wrapped in a DSSE envelope (payloadType application/vnd.in-toto+json). With --sign,
+cosign signs the envelope's pre-authentication encoding: with ATTESTATION_SIGNING_KEY (a key file or KMS
+URI) or, without one, keyless through Fulcio, in which case the signing certificate is kept with the
+signature.
+"""
+
+import base64
+import hashlib
+import json
+import os
+import subprocess
+import tempfile
+import time
+from typing import Any, Dict, List, Optional
+
+from .config.offline import require_network
+from .config.policy import SEVERITIES
+from .config.settings import get_settings
+from .isolation import git_command
+from .remote import RemoteRepo
+
+STATEMENT_TYPE = "https://in-toto.io/Statement/v1"
+PAYLOAD_TYPE = "application/vnd.in-toto+json"
+PREDICATE_TYPE = "urn:sastscan:scan-result:v1"
+
+
+class AttestationError(Exception):
+    pass
+
+
+def git_source(target: str) -> Optional[Dict[str, Any]]:
+    """The commit a checkout is at, or None when target is not in a git work tree"""
+    directory = target if os.path.isdir(target) else os.path.dirname(target)
+
+    def git(*args: str) -> str:
+        result = subprocess.run(git_command(args), cwd=directory, capture_output=True, text=True, timeout=30)
+        return result.stdout.strip() if result.returncode == 0 else ""
+
+    try:
+        commit = git('rev-parse', 'HEAD')
+        if not commit:
+            return None
+        origin = git('remote', 'get-url', 'origin')
+        return {
+            "uri": RemoteRepo(origin).display_url if origin else os.path.abspath(directory),
+            "commit": commit,
+            "branch": git('symbolic-ref', '--short', '-q', 'HEAD') or None,
+            # tracked changes the commit does not describe
+            "dirty": bool(git('status', '--porcelain', '--untracked-files=no'))
+        }
+    except (OSError, subprocess.SubprocessError):
+        return None
+
+
+def scanner_metadata(project_config: Dict[str, Any], model: Optional[str] = None) -> Dict[str, Any]:
+    """Which scanner and rules produced a report; recorded at scan time so a later attestation is exact"""
+    from .analysis.rules import get_rules
+
+    disabled = set(project_config.get("disabled_rules") or [])
+    rules = sorted(rule.rule_id for rule in get_rules() if rule.rule_id not in disabled)
+    packs = [
+        {"name": pack["name"], "version": pack["version"], "spec": pack["spec"], "sha256": pack.get("sha256")}
+        for pack in project_config.get("rule_packs") or []
+    ]
+    return {
+        "name": get_settings().app_name,
+        "version": get_settings().app_version,
+        "model": model,
+        "rules": rules,
+        "rule_packs": packs,
+        # one value to pin the rule set against: built-in ids, scanner version, and pack contents
+        "rules_digest": hashlib.sha256(json.dumps([get_settings().app_version, rules, packs], sort_keys=True).encode()).hexdigest()
+    }
+
+
+def subjects(report: Dict[str, Any]) -> List[Dict[str, Any]]:
+    """What the attestation is about: the scanned commit, source archive, or container image"""
+    if report.get("image"):
+        image = report["image"]
+        algorithm, _, digest = (image.get("digest") or "").partition(':')
+        if digest:
+            return [{"name": image["reference"], "digest": {algorithm: digest}}]
+    if report.get("archive"):
+        archive = report["archive"]
+        return [{"name": os.path.basename(archive["path"]), "digest": {"sha256": archive["sha256"]}}]
+    source = report.get("remote") or report.get("source")
+    if source and source.get("commit"):
+        return [{"name": source.get("url") or source.get("uri"), "digest": {"gitCommit": source["commit"]}}]
+    raise AttestationError(f"Report {report['session_id']} has no commit, archive, or image digest to attest "
+                           "(scan a git checkout, a source archive, or an image)")
+
+
+def report_digest(session_id: str) -> str:
+    from .reports import report_path
+
+    with open(report_path(session_id), 'rb') as f:
+        return hashlib.sha256(f.read()).hexdigest()
+
+
+def timestamp(value: Optional[float]) -> Optional[str]:
+    return time.strftime('%Y-%m-%dT%H:%M:%SZ', time.gmtime(value)) if value else None
+
+
+def build_statement(report: Dict[str, Any]) -> Dict[str, Any]:
+    """An in-toto Statement for a saved, completed report"""
+    if report.get("status") != "completed":
+        raise AttestationError(f"Report {report['session_id']} did not complete; only completed scans can be attested")
+
+    vulnerabilities = report.get("vulnerabilities", [])
+    by_severity = {severity: 0 for severity in SEVERITIES}
+    for vuln in vulnerabilities:
+        by_severity[vuln.get("severity", "medium")] = by_severity.get(vuln.get("severity", "medium"), 0) + 1
+    gate = report.get("gate")
+    source = report.get("remote") or report.get("source") or {}
+
+    predicate = {
+        "scanner": report.get("scanner") or {"name": get_settings().app_name, "version": get_settings().app_version},
+        "source": {
+            "uri": source.get("url") or source.get("uri") or report.get("target"),
+            "commit": source.get("commit"),
+            "ref": source.get("ref") or source.get("branch"),
+            "dirty": source.get("dirty", False)
+        },
+        "scan": {
+            "session_id": report["session_id"],
+            "mode": "llm" if (report.get("scanner") or {}).get("model") else "static",
+            "degraded": bool(report.get("degraded")),  # the LLM dropped out and the rest ran static-only
+            "merge_base": (report.get("baseline") or {}).get("merge_base"),  # findings before it did not gate
+            "started_on": timestamp(report.get("started_at")),
+            "finished_on": timestamp(report.get("completed_at"))
+        },
+        "result": {
+            # a scan with no gate configured passes only when it found nothing
+            "passed": gate["passed"] if gate else not vulnerabilities,
+            "fail_on": gate["fail_on"] if gate else None,
+            "blocking": len(gate["blocking"]) if gate else len(vulnerabilities),
+            "findings": len(vulnerabilities),
+            "by_severity": by_severity,
+            "report": {"name": f"{report['session_id']}.json", "digest": {"sha256": report_digest(report["session_id"])}}
+        }
+    }
+    return {
+        "_type": STATEMENT_TYPE,
+        "subject": subjects(report),
+        "predicateType": PREDICATE_TYPE,
+        "predicate": predicate
+    }
+
+
+def pae(payload_type: str, payload: bytes) -> bytes:
+    """DSSE pre-authentication encoding: what a signature actually covers"""
+    kind = payload_type.encode()
+    return b"DSSEv1 %d %s %d %s" % (len(kind), kind, len(payload), payload)
+
+
+def envelope(statement: Dict[str, Any]) -> Dict[str, Any]:
+    payload = json.dumps(statement, sort_keys=True, separators=(',', ':')).encode()
+    return {"payloadType": PAYLOAD_TYPE, "payload": base64.b64encode(payload).decode(), "signatures": []}
+
+
+def sign_envelope(dsse: Dict[str, Any], key: Optional[str] = None) -> Dict[str, Any]:
+    """Add a cosign signature over the envelope; keyless (Fulcio certificate, Rekor entry) without a key"""
+    settings = get_settings()
+    key = key or settings.attestation_signing_key
+    require_network("Signing an attestation")
+
+    with tempfile.TemporaryDirectory(prefix="sastscan-attest-") as tmp:
+        blob, signature, certificate = (os.path.join(tmp, name) for name in ("payload", "payload.sig", "payload.pem"))
+        with open(blob, 'wb') as f:
+            f.write(pae(dsse["payloadType"], base64.b64decode(dsse["payload"])))
+        command = [settings.cosign_path, "sign-blob", "--yes", "--output-signature", signature]
+        command += ["--key", key] if key else ["--output-certificate", certificate]
+        try:
+            result = subprocess.run(command + [blob], capture_output=True, text=True, timeout=300)
+        except FileNotFoundError:
+            raise AttestationError(f"cosign not found ({settings.cosign_path}); install it or set COSIGN_PATH")
+        except subprocess.TimeoutExpired:
+            raise AttestationError("cosign sign-blob timed out")
+        if result.returncode != 0:
+            raise AttestationError(f"cosign sign-blob failed: {result.stderr.strip()[-500:]}")
+
+        with open(signature, 'r') as f:
+            entry = {"keyid": key or "", "sig": f.read().strip()}
+        if not key and os.path.isfile(certificate):
+            with open(certificate, 'r') as f:
+                entry["cert"] = f.read().strip()
+    dsse["signatures"].append(entry)
+    return dsse
+
+
+def write_attestation(report: Dict[str, Any], path: str, sign: bool = False) -> Dict[str, Any]:
+    """Build (and optionally sign) the attestation for a saved report and write it to path"""
+    dsse = envelope(build_statement(report))
+    if sign:
+        sign_envelope(dsse)
+    with open(path, 'w') as f:
+        json.dump(dsse, f, indent=2)
+    return dsse
+
+
+
+def describe(dsse: Dict[str, Any]) -> str:
+    statement = json.loads(base64.b64decode(dsse["payload"]))
+    subject = statement["subject"][0]
+    digest = ', '.join(f"{k}:{v[:12]}" for k, v in subject["digest"].items())
+    result = statement["predicate"]["result"]
+    verdict = "passed" if result["passed"] else f"FAILED ({result['blocking']} blocking)"
+    signed = f"signed ({len(dsse['signatures'])} signature(s))" if dsse["signatures"] else "unsigned"
+    dirty = " [uncommitted changes]" if statement["predicate"]["source"]["dirty"] else ""
+    return f"{subject['name']} {digest}{dirty}: {verdict}, {result['findings']} finding(s), {signed}"
@@ -0,0 +1,15 @@
+"""
+Auth - API keys with scopes for server mode, and the audit log of who requested what
+"""
+
+from .keys import SCOPES, ApiKey, AuditLog, AuthError, KeyStore, get_audit_log, get_key_store
+
+__all__ = [
+    'SCOPES',
+    'ApiKey',
+    'AuditLog',
+    'AuthError',
+    'KeyStore',
+    'get_audit_log',
+    'get_key_store',
+]
@@ -0,0 +1,99 @@
+"""
+Auth dependencies - FastAPI scope checks and per-request audit logging
+"""
+
+from typing import Any, Dict, Optional
+
+from fastapi import HTTPException, Request, WebSocket
+
+from ..config.settings import get_settings
+from .keys import ApiKey, get_audit_log, get_key_store
+
+
+class Principal:
+    """Caller of one request; endpoints attach scan details that end up in the audit entry"""
+
+    def __init__(self, key: Optional[ApiKey]):
+        self.key = key
+        self.details: Dict[str, Any] = {}
+
+    @property
+    def name(self) -> str:
+        return self.key.name if self.key else "anonymous"
+
+    def note(self, **details):
+        self.details.update(details)
+
+    def can_access(self, project_id: Optional[str]) -> bool:
+        return self.key is None or self.key.can_access(project_id)
+
+
+def request_token(headers, query_params=None) -> Optional[str]:
+    authorization = headers.get("authorization", "")
+    if authorization.lower().startswith("bearer "):
+        return authorization[7:].strip()
+    return headers.get("x-api-key") or (query_params or {}).get("api_key")
+
+
+def authenticate(headers, query_params=None) -> Optional[ApiKey]:
+    settings = get_settings()
+    store = get_key_store()
+
+    token = request_token(headers, query_params)
+    if token:
+        return store.verify(token)
+
+    # mTLS is terminated by the proxy in front of the server, which forwards the verified client subject
+    if settings.mtls_subject_header:
+        subject = headers.get(settings.mtls_subject_header)
+        if subject:
+            return store.verify_certificate(subject)
+    return None
+
+
+def require_scope(scope: str):
+    """Dependency: no-op unless AUTH_ENABLED; otherwise a valid key with the scope is required"""
+
+    async def dependency(request: Request) -> Principal:
+        if not get_settings().auth_enabled:
+            return Principal(None)
+
+        action = f"{request.method} {request.url.path}"
+        key = authenticate(request.headers)
+        if not key:
+            get_audit_log().record(None, action, 401, client=request.client.host if request.client else None)
+            raise HTTPException(status_code=401, detail="Valid API key required", headers={"WWW-Authenticate": "Bearer"})
+        if not key.allows(scope):
+            get_audit_log().record(key, action, 403, scope=scope)
+            raise HTTPException(status_code=403, detail=f"API key lacks the '{scope}' scope")
+
+        principal = Principal(key)
+        request.state.principal = principal
+        return principal
+
+    return dependency
+
+
+async def audit_requests(request: Request, call_next):
+    """HTTP middleware: log every authenticated request once the response status is known"""
+    response = await call_next(request)
+    principal = getattr(request.state, "principal", None)
+    if principal and principal.key:
+        get_audit_log().record(
+            principal.key,
+            f"{request.method} {request.url.path}",
+            response.status_code,
+            client=request.client.host if request.client else None,
+            **principal.details
+        )
+    return response
+
+
+async def authorize_websocket(websocket: WebSocket, scope: str = "read") -> bool:
+    """Browsers cannot set headers on WebSockets, so the key may also come from ?api_key="""
+    if not get_settings().auth_enabled:
+        return True
+    key = authenticate(websocket.headers, websocket.query_params)
+    allowed = bool(key and key.allows(scope))
+    get_audit_log().record(key, f"WS {websocket.url.path}", 101 if allowed else 403)
+    return allowed
@@ -0,0 +1,217 @@
+"""
+API keys - Scoped keys for server mode, with rotation and an append-only audit log
+"""
+
+import hashlib
+import hmac
+import json
+import os
+import secrets
+import threading
+import time
+from dataclasses import asdict, dataclass, field
+from typing import Any, Dict, List, Optional, Tuple
+
+KEY_PREFIX = "sast"
+SCOPES = ["scan", "read", "admin"]  # admin implies every other scope
+
+
+class AuthError(Exception):
+    pass
+
+
+def hash_secret(secret: str) -> str:
+    return hashlib.sha256(secret.encode()).hexdigest()
+
+
+@dataclass
+class ApiKey:
+    key_id: str
+    name: str
+    key_hash: str = ""
+    scopes: List[str] = field(default_factory=lambda: ["read"])
+    created_at: float = 0.0
+    expires_at: Optional[float] = None
+    revoked_at: Optional[float] = None
+    last_used_at: Optional[float] = None
+    rotated_to: Optional[str] = None
+    cert_subject: Optional[str] = None  # client certificate subject accepted instead of the key (mTLS)
+    projects: Optional[List[str]] = None  # restrict the key to these project ids; None means all
+
+    def can_access(self, project_id: Optional[str]) -> bool:
+        return self.projects is None or "admin" in self.scopes or project_id in self.projects
+
+    def allows(self, scope: str) -> bool:
+        return "admin" in self.scopes or scope in self.scopes
+
+    def active(self, now: Optional[float] = None) -> bool:
+        now = now or time.time()
+        if self.revoked_at and self.revoked_at <= now:
+            return False
+        return not (self.expires_at and self.expires_at <= now)
+
+    def to_dict(self) -> Dict[str, Any]:
+        data = asdict(self)
+        data.pop("key_hash")
+        data["active"] = self.active()
+        return data
+
+
+class KeyStore:
+    """API keys persisted as JSON; only a SHA-256 of each secret is stored"""
+
+    def __init__(self, path: str):
+        self.path = path
+        self._lock = threading.Lock()
+
+    def _read(self) -> Dict[str, ApiKey]:
+        if not os.path.exists(self.path):
+            return {}
+        with open(self.path, 'r') as f:
+            data = json.load(f)
+        return {k["key_id"]: ApiKey(**k) for k in data.get("keys", [])}
+
+    def _write(self, keys: Dict[str, ApiKey]):
+        directory = os.path.dirname(os.path.abspath(self.path))
+        os.makedirs(directory, exist_ok=True)
+        tmp = f"{self.path}.tmp"
+        with open(tmp, 'w') as f:
+            json.dump({"keys": [asdict(k) for k in keys.values()]}, f, indent=2)
+        os.chmod(tmp, 0o600)
+        os.replace(tmp, self.path)
+
+    def list(self) -> List[ApiKey]:
+        return sorted(self._read().values(), key=lambda k: k.created_at)
+
+    def get(self, key_id: str) -> Optional[ApiKey]:
+        return self._read().get(key_id)
+
+    def create(
+        self,
+        name: str,
+        scopes: List[str],
+        expires_in: Optional[float] = None,
+        cert_subject: Optional[str] = None,
+        projects: Optional[List[str]] = None
+    ) -> Tuple[ApiKey, str]:
+        """Create a key; the returned token is the only time the secret is available"""
+        unknown = [s for s in scopes if s not in SCOPES]
+        if unknown or not scopes:
+            raise AuthError(f"Invalid scopes {unknown or scopes} (choose from {', '.join(SCOPES)})")
+
+        key_id = secrets.token_hex(4)
+        secret = secrets.token_urlsafe(32)
+        now = time.time()
+        key = ApiKey(
+            key_id=key_id,
+            name=name,
+            key_hash=hash_secret(secret),
+            scopes=list(dict.fromkeys(scopes)),
+            created_at=now,
+            expires_at=now + expires_in if expires_in else None,
+            cert_subject=cert_subject,
+            projects=projects or None
+        )
+        with self._lock:
+            keys = self._read()
+            keys[key_id] = key
+            self._write(keys)
+        return key, f"{KEY_PREFIX}_{key_id}_{secret}"
+
+    def rotate(self, key_id: str, grace_seconds: float = 0) -> Tuple[ApiKey, str]:
+        """Issue a replacement key with the same name, scopes, and expiry (rotating never extends a key's life);
+        the old key expires after the grace period, or at its own expiry if that comes first"""
+        old = self.get(key_id)
+        if not old or not old.active():
+            raise AuthError(f"No active key {key_id}")
+
+        remaining = old.expires_at - time.time() if old.expires_at else None
+        new, token = self.create(old.name, old.scopes, expires_in=remaining, cert_subject=old.cert_subject, projects=old.projects)
+        with self._lock:
+            keys = self._read()
+            grace_end = time.time() + grace_seconds
+            expires_at = keys[key_id].expires_at
+            keys[key_id].expires_at = min(expires_at, grace_end) if expires_at else grace_end
+            keys[key_id].rotated_to = new.key_id
+            keys[key_id].cert_subject = None
+            self._write(keys)
+        return new, token
+
+    def revoke(self, key_id: str) -> ApiKey:
+        with self._lock:
+            keys = self._read()
+            if key_id not in keys:
+                raise AuthError(f"No key {key_id}")
+            keys[key_id].revoked_at = keys[key_id].revoked_at or time.time()
+            self._write(keys)
+            return keys[key_id]
+
+    def verify(self, token: str) -> Optional[ApiKey]:
+        parts = token.strip().split('_', 2)
+        if len(parts) != 3 or parts[0] != KEY_PREFIX:
+            return None
+        key = self.get(parts[1])
+        if not key or not key.active() or not hmac.compare_digest(key.key_hash, hash_secret(parts[2])):
+            return None
+        self._touch(key)
+        return key
+
+    def verify_certificate(self, subject: str) -> Optional[ApiKey]:
+        for key in self.list():
+            if key.cert_subject and key.cert_subject == subject.strip() and key.active():
+                self._touch(key)
+                return key
+        return None
+
+    def _touch(self, key: ApiKey):
+        now = time.time()
+        if key.last_used_at and now - key.last_used_at < 60:
+            return
+        with self._lock:
+            keys = self._read()
+            if key.key_id in keys:
+                keys[key.key_id].last_used_at = now
+                self._write(keys)
+
+
+class AuditLog:
+    """Append-only JSON lines: who (key) did what (method, path, scan) and the outcome"""
+
+    def __init__(self, path: str):
+        self.path = path
+        self._lock = threading.Lock()
+
+    def record(self, key: Optional[ApiKey], action: str, status: int, **details):
+        entry = {
+            "time": time.time(),
+            "key_id": key.key_id if key else None,
+            "key_name": key.name if key else None,
+            "action": action,
+            "status": status,
+            **{k: v for k, v in details.items() if v is not None}
+        }
+        directory = os.path.dirname(os.path.abspath(self.path))
+        os.makedirs(directory, exist_ok=True)
+        with self._lock, open(self.path, 'a') as f:
+            f.write(json.dumps(entry) + '\n')
+
+    def tail(self, limit: int = 100, key_id: Optional[str] = None) -> List[Dict[str, Any]]:
+        if not os.path.exists(self.path):
+            return []
+        with open(self.path, 'r') as f:
+            entries = [json.loads(line) for line in f if line.strip()]
+        if key_id:
+            entries = [e for e in entries if e.get("key_id") == key_id]
+        return entries[-limit:]
+
+
+def get_key_store() -> KeyStore:
+    from ..config.settings import get_settings
+
+    return KeyStore(get_settings().api_keys_file)
+
+
+def get_audit_log() -> AuditLog:
+    from ..config.settings import get_settings
+
+    return AuditLog(get_settings().audit_log_file)
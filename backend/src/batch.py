@@ -0,0 +1,289 @@
+"""
+Batch scans - Scan a manifest of repositories and roll their reports up into an organization-level aggregate
+Usage: scanner batch repos.yaml [--parallel N]
+
+    parallel: 4                       # repos scanned at once (default 1)
+    repos:
+      - url: https://github.com/acme/payments
+        ref: release-1.4              # branch, tag, or commit (default branch if omitted)
+      - path: /srv/checkouts/billing  # local checkout or source archive
+        name: billing
+
+Each repo gets its own saved report; the aggregate (totals by severity, top rules, worst repos) is saved
+under analysis-reports/batches/.
+"""
+
+import asyncio
+import json
+import logging
+import os
+import re
+import time
+from dataclasses import dataclass, field
+from typing import Any, Dict, List, Optional
+
+import yaml
+
+from .archives import ARCHIVE_SUFFIXES
+from .config.offline import OfflineError, require_network
+from .config.policy import SEVERITIES
+from .formatters import finding_rule_id
+from .reports import REPORTS_DIR, evaluate_gate, load_report, save_report
+
+logger = logging.getLogger(__name__)
+
+BATCHES_DIR = os.path.join(REPORTS_DIR, 'batches')
+TOP_LIMIT = 10
+
+
+class BatchError(ValueError):
+    pass
+
+
+@dataclass
+class BatchRepo:
+    name: str
+    target: str  # local directory, source archive, or remote URL (with @ref)
+
+
+def default_name(spec: str) -> str:
+    """payments for https://host/acme/payments.git@main or /srv/payments; payments-1.4 for payments-1.4.tar.gz"""
+    name = spec.rstrip('/').split('/')[-1].split(':')[-1].split('@')[0]
+    for suffix in ('.git',) + ARCHIVE_SUFFIXES:
+        if name.lower().endswith(suffix):
+            return name[:-len(suffix)]
+    return name
+
+
+@dataclass
+class BatchManifest:
+    repos: List[BatchRepo] = field(default_factory=list)
+    parallel: int = 1
+
+    @classmethod
+    def from_dict(cls, data: Dict[str, Any], base_dir: str = ".") -> "BatchManifest":
+        repos: List[BatchRepo] = []
+        names = set()
+        for entry in data.get("repos") or []:
+            if isinstance(entry, str):
+                entry = {"url": entry} if "://" in entry or entry.startswith("git@") else {"path": entry}
+            if not isinstance(entry, dict):
+                raise BatchError(f"Invalid repo entry: {entry!r}")
+            if entry.get("url"):
+                target = entry["url"] + (f"@{entry['ref']}" if entry.get("ref") else "")
+            elif entry.get("path"):
+                target = os.path.join(base_dir, os.path.expanduser(entry["path"]))
+            else:
+                raise BatchError("Each repo needs a url or path")
+
+            name = entry.get("name") or default_name(entry.get("url") or entry["path"])
+            unique, n = name, 2
+            while unique in names:
+                unique, n = f"{name}-{n}", n + 1
+            names.add(unique)
+            repos.append(BatchRepo(unique, target))
+        if not repos:
+            raise BatchError("No repos in the manifest")
+        return cls(repos=repos, parallel=max(1, int(data.get("parallel", 1))))
+
+
+def load_manifest(path: str) -> BatchManifest:
+    with open(path, 'r') as f:
+        try:
+            data = yaml.safe_load(f) or {}  # JSON is valid YAML
+        except yaml.YAMLError as e:
+            raise BatchError(f"{path} is not valid YAML or JSON: {e}")
+    if isinstance(data, list):
+        data = {"repos": data}
+    return BatchManifest.from_dict(data, os.path.dirname(os.path.abspath(path)))
+
+
+@dataclass
+class RepoResult:
+    repo: BatchRepo
+    session_id: str
+    status: str = "failed"
+    error: Optional[str] = None
+    report: Optional[Dict[str, Any]] = None
+
+    def to_dict(self) -> Dict[str, Any]:
+        vulnerabilities = (self.report or {}).get("vulnerabilities", [])
+        gate = (self.report or {}).get("gate")
+        return {
+            "name": self.repo.name,
+            "target": self.repo.target,
+            "session_id": self.session_id,
+            "status": self.status,
+            "error": self.error,
+            "findings": len(vulnerabilities),
+            "by_severity": severity_counts(vulnerabilities),
+            "gate_passed": gate["passed"] if gate else None
+        }
+
+
+def severity_counts(vulnerabilities: List[Dict[str, Any]]) -> Dict[str, int]:
+    counts = {severity: 0 for severity in SEVERITIES}
+    for vuln in vulnerabilities:
+        severity = vuln.get("severity", "medium")
+        counts[severity] = counts.get(severity, 0) + 1
+    return counts
+
+
+def open_sandbox(target: str):
+    """The unpacked archive or remote checkout to scan instead of target, or None for a local directory"""
+    from .archives import ArchiveSandbox, is_archive
+    from .remote import RemoteCheckout, parse_remote
+
+    if is_archive(target):
+        return ArchiveSandbox(target).open()
+    remote = parse_remote(target)
+    if remote:
+        require_network("Scanning a remote repository")
+        return RemoteCheckout(remote).open()
+    if not os.path.isdir(target):
+        raise BatchError(f"Not a directory, archive, or repository URL: {target}")
+    return None
+
+
+async def scan_repo(repo: BatchRepo, batch_id: str, static: bool, fail_on: Optional[str]) -> RepoResult:
+    from .archives import ArchiveError
+    from .remote import RemoteError
+
+    slug = re.sub(r'[^\w-]', '_', repo.name)
+    session_id = f"{batch_id}_{slug}"
+    logger.info(f"[{session_id}] Scanning {repo.name} ({repo.target})")
+    result = RepoResult(repo, session_id)
+    try:
+        sandbox = await asyncio.to_thread(open_sandbox, repo.target)
+    except (ArchiveError, RemoteError, BatchError, OfflineError, OSError) as e:
+        result.error = str(e)
+        logger.error(f"[{session_id}] {repo.name}: {e}")
+        return result
+
+    root = sandbox.root if sandbox else os.path.abspath(repo.target)
+    try:
+        if static:
+            from .main import run_static_scan
+
+            report = await asyncio.to_thread(run_static_scan, session_id, root)
+            if sandbox:
+                sandbox.relocate(report)
+        else:
+            from .main import run_analysis_pipeline
+
+            await run_analysis_pipeline(session_id, "project", root, notify=False, sandbox=sandbox)
+            report = load_report(session_id) or {"status": "failed", "errors": ["No report written"]}
+    except Exception as e:
+        result.error = str(e)
+        logger.error(f"[{session_id}] {repo.name}: scan failed: {e}")
+        return result
+    finally:
+        if sandbox:
+            sandbox.close()
+
+    if report.get("status") == "completed":
+        evaluate_gate(report, fail_on)
+    report["batch"] = {"name": repo.name, "batch_id": batch_id}
+    save_report(report)
+    result.report = report
+    result.status = report.get("status", "failed")
+    if result.status != "completed":
+        result.error = '; '.join(report.get("errors", [])) or "unknown error"
+    logger.info(f"[{session_id}] {repo.name}: {len(report.get('vulnerabilities', []))} finding(s)")
+    return result
+
+
+def aggregate(batch_id: str, results: List[RepoResult], started_at: float) -> Dict[str, Any]:
+    """Organization-level roll-up: totals by severity, the rules with the most findings, and the repos with
+    the most severe findings (ordered by critical, then high, medium, and low counts)"""
+    repos = [result.to_dict() for result in results]
+    scanned = [result for result in results if result.status == "completed"]
+
+    by_severity = {severity: 0 for severity in SEVERITIES}
+    rules: Dict[str, Dict[str, Any]] = {}
+    for result in scanned:
+        for vuln in result.report.get("vulnerabilities", []):
+            severity = vuln.get("severity", "medium")
+            by_severity[severity] = by_severity.get(severity, 0) + 1
+            rule_id = finding_rule_id(vuln)
+            entry = rules.setdefault(rule_id, {"rule_id": rule_id, "findings": 0, "repos": set(), "by_severity": {}})
+            entry["findings"] += 1
+            entry["repos"].add(result.repo.name)
+            entry["by_severity"][severity] = entry["by_severity"].get(severity, 0) + 1
+
+    top_rules = sorted(rules.values(), key=lambda r: (-r["findings"], -len(r["repos"]), r["rule_id"]))[:TOP_LIMIT]
+    worst = sorted(
+        (repo for repo in repos if repo["status"] == "completed" and repo["findings"]),
+        key=lambda repo: tuple(-repo["by_severity"].get(severity, 0) for severity in SEVERITIES) + (repo["name"],)
+    )[:TOP_LIMIT]
+
+    return {
+        "batch_id": batch_id,
+        "started_at": started_at,
+        "completed_at": time.time(),
+        "totals": {
+            "repos": len(results),
+            "scanned": len(scanned),
+            "failed": len(results) - len(scanned),
+            "findings": sum(by_severity.values()),
+            "by_severity": by_severity,
+            "gate_failed": len([repo for repo in repos if repo["gate_passed"] is False])
+        },
+        "top_rules": [{**rule, "repos": sorted(rule["repos"])} for rule in top_rules],
+        "worst_repos": [{"name": repo["name"], "session_id": repo["session_id"], "findings": repo["findings"], "by_severity": repo["by_severity"]} for repo in worst],
+        "repos": repos
+    }
+
+
+async def run_batch(manifest: BatchManifest, static: bool, parallel: Optional[int] = None, fail_on: Optional[str] = None) -> Dict[str, Any]:
+    started_at = time.time()
+    batch_id = f"batch_{int(started_at)}"
+    limit = asyncio.Semaphore(parallel or manifest.parallel)
+
+    async def bounded(repo: BatchRepo) -> RepoResult:
+        async with limit:
+            return await scan_repo(repo, batch_id, static, fail_on)
+
+    results = await asyncio.gather(*(bounded(repo) for repo in manifest.repos))
+    report = aggregate(batch_id, list(results), started_at)
+    save_aggregate(report)
+    return report
+
+
+def save_aggregate(report: Dict[str, Any]) -> str:
+    os.makedirs(BATCHES_DIR, exist_ok=True)
+    path = os.path.join(BATCHES_DIR, f"{report['batch_id']}.json")
+    with open(path, 'w') as f:
+        json.dump(report, f, indent=2)
+    return path
+
+
+def render_aggregate(report: Dict[str, Any]) -> str:
+    totals = report["totals"]
+
+    def severities(counts: Dict[str, int]) -> str:
+        return ', '.join(f"{counts.get(s, 0)} {s}" for s in SEVERITIES)
+
+    lines = [
+        f"Batch {report['batch_id']}: {totals['scanned']}/{totals['repos']} repo(s) scanned, {totals['findings']} finding(s)",
+        f"  {severities(totals['by_severity'])}",
+        ""
+    ]
+    if report["worst_repos"]:
+        lines.append("Worst repos:")
+        for repo in report["worst_repos"]:
+            lines.append(f"  {repo['name']:<30} {repo['findings']:>5}  ({severities(repo['by_severity'])})")
+        lines.append("")
+    if report["top_rules"]:
+        lines.append("Top rules:")
+        for rule in report["top_rules"]:
+            lines.append(f"  {rule['rule_id']:<30} {rule['findings']:>5}  in {len(rule['repos'])} repo(s)")
+        lines.append("")
+    lines.append("Repos:")
+    for repo in report["repos"]:
+        if repo["status"] == "completed":
+            gate = "" if repo["gate_passed"] is None else ("  gate passed" if repo["gate_passed"] else "  GATE FAILED")
+            lines.append(f"  {repo['name']:<30} {repo['findings']:>5}  {repo['session_id']}{gate}")
+        else:
+            lines.append(f"  {repo['name']:<30}  FAILED  {repo['error'] or 'unknown error'}")
+    return '\n'.join(lines) + '\n'
@@ -0,0 +1,305 @@
+"""
+Benchmark - Score the scanner against labeled vulnerability corpora (precision/recall per rule and CWE)
+Supported datasets:
+    OWASP Benchmark  expectedresults-*.csv next to the test case sources (one case per test file)
+    Juliet-style     CWE<id>_*.<ext> files whose bad*/good* functions are the positive/negative cases
+    Manifest         labels.json or labels.csv with file, cwe, vulnerable, and optionally function
+"""
+
+import csv
+import json
+import os
+import re
+import time
+from dataclasses import dataclass, field
+from typing import Any, Dict, Iterable, List, Optional, Tuple
+
+from .analysis.rules import SourceContext, get_rule, get_rules, run_rules
+from .formatters import finding_rule_id
+
+JULIET_FILE = re.compile(r'^CWE-?(\d+)_')
+JULIET_FUNCTION = re.compile(r'^(?:bad|good)', re.IGNORECASE)
+MANIFEST_NAMES = ('labels.json', 'labels.csv')
+
+
+class BenchmarkError(Exception):
+    pass
+
+
+def normalize_cwe(value: Any) -> Optional[str]:
+    match = re.search(r'(\d+)', str(value or ''))
+    return f"CWE-{int(match.group(1))}" if match else None
+
+
+@dataclass
+class LabeledCase:
+    case_id: str
+    file_path: str
+    cwe: str
+    vulnerable: bool
+    function: Optional[str] = None  # None: the whole file is the case
+
+
+@dataclass
+class Metrics:
+    tp: int = 0
+    fp: int = 0
+    fn: int = 0
+    tn: int = 0
+
+    def add(self, flagged: bool, vulnerable: bool):
+        if flagged and vulnerable:
+            self.tp += 1
+        elif flagged:
+            self.fp += 1
+        elif vulnerable:
+            self.fn += 1
+        else:
+            self.tn += 1
+
+    @property
+    def precision(self) -> Optional[float]:
+        return self.tp / (self.tp + self.fp) if self.tp + self.fp else None
+
+    @property
+    def recall(self) -> Optional[float]:
+        return self.tp / (self.tp + self.fn) if self.tp + self.fn else None
+
+    @property
+    def f1(self) -> Optional[float]:
+        p, r = self.precision, self.recall
+        return 2 * p * r / (p + r) if p and r else None
+
+    def to_dict(self) -> Dict[str, Any]:
+        def rounded(value):
+            return round(value, 4) if value is not None else None
+
+        return {
+            "tp": self.tp, "fp": self.fp, "fn": self.fn, "tn": self.tn,
+            "precision": rounded(self.precision),
+            "recall": rounded(self.recall),
+            "f1": rounded(self.f1)
+        }
+
+
+def find_sources(root: str) -> Dict[str, str]:
+    """file stem -> path, for matching OWASP test names to their source files"""
+    sources = {}
+    for directory, dirs, files in os.walk(root):
+        dirs[:] = [d for d in dirs if not d.startswith('.')]
+        for name in files:
+            stem, ext = os.path.splitext(name)
+            if ext and ext not in ('.csv', '.json', '.xml', '.txt', '.md'):
+                sources.setdefault(stem, os.path.join(directory, name))
+    return sources
+
+
+def load_owasp(root: str, expected: str) -> List[LabeledCase]:
+    sources = find_sources(root)
+    cases = []
+    with open(expected, 'r', newline='') as f:
+        for row in csv.reader(f):
+            if not row or row[0].lstrip().startswith('#') or len(row) < 4:
+                continue
+            name, _category, vulnerable, cwe = (c.strip() for c in row[:4])
+            if name in sources and normalize_cwe(cwe):
+                cases.append(LabeledCase(name, sources[name], normalize_cwe(cwe), vulnerable.lower() == 'true'))
+    return cases
+
+
+def load_juliet(root: str) -> List[LabeledCase]:
+    cases = []
+    for directory, dirs, files in os.walk(root):
+        dirs[:] = [d for d in dirs if not d.startswith('.')]
+        for name in sorted(files):
+            match = JULIET_FILE.match(name)
+            if not match:
+                continue
+            path = os.path.join(directory, name)
+            with open(path, 'r', encoding='utf-8', errors='ignore') as f:
+                ctx = SourceContext(f.read(), path)
+            for member in ctx.functions():
+                if JULIET_FUNCTION.match(member.name):
+                    cases.append(LabeledCase(
+                        f"{name}:{member.name}", path, f"CWE-{int(match.group(1))}",
+                        member.name.lower().startswith('bad'), member.name
+                    ))
+    return cases
+
+
+def load_manifest(root: str, manifest: str) -> List[LabeledCase]:
+    if manifest.endswith('.json'):
+        with open(manifest, 'r') as f:
+            rows = json.load(f)
+        rows = rows.get("cases", []) if isinstance(rows, dict) else rows
+    else:
+        with open(manifest, 'r', newline='') as f:
+            rows = list(csv.DictReader(f))
+
+    cases = []
+    for i, row in enumerate(rows):
+        cwe = normalize_cwe(row.get("cwe"))
+        if not row.get("file") or not cwe:
+            raise BenchmarkError(f"{manifest} case {i + 1} needs file and cwe")
+        vulnerable = row.get("vulnerable")
+        if isinstance(vulnerable, str):
+            vulnerable = vulnerable.strip().lower() in ('true', '1', 'yes')
+        path = os.path.join(root, row["file"])
+        cases.append(LabeledCase(row.get("id") or f"{row['file']}:{row.get('function') or i + 1}", path, cwe, bool(vulnerable), row.get("function") or None))
+    return cases
+
+
+def load_dataset(path: str) -> Tuple[str, List[LabeledCase]]:
+    """Detect the dataset layout and return (format, labeled cases)"""
+    if not os.path.isdir(path):
+        raise BenchmarkError(f"Dataset is not a directory: {path}")
+
+    for name in MANIFEST_NAMES:
+        if os.path.isfile(os.path.join(path, name)):
+            return "manifest", load_manifest(path, os.path.join(path, name))
+
+    expected = sorted(
+        os.path.join(directory, name)
+        for directory, _, files in os.walk(path)
+        for name in files if name.startswith('expectedresults') and name.endswith('.csv')
+    )
+    if expected:
+        return "owasp", load_owasp(path, expected[-1])
+
+    cases = load_juliet(path)
+    if cases:
+        return "juliet", cases
+
+    raise BenchmarkError(f"No labels found in {path}: expected {' or '.join(MANIFEST_NAMES)}, an OWASP expectedresults-*.csv, or Juliet CWE<id>_* files")
+
+
+def static_findings(files: Iterable[str]) -> List[Dict[str, Any]]:
+    findings = []
+    for path in sorted(set(files)):
+        with open(path, 'r', encoding='utf-8', errors='ignore') as f:
+            code = f.read()
+        findings.extend(f.to_dict() for f in run_rules(code, path))
+    return findings
+
+
+def finding_cwe(finding: Dict[str, Any]) -> Optional[str]:
+    rule = get_rule(finding.get("rule_id") or "")
+    return normalize_cwe(finding.get("cwe_id") or (rule.cwe_id if rule else None))
+
+
+@dataclass
+class BenchmarkResult:
+    dataset: str
+    dataset_format: str
+    mode: str
+    model: Optional[str] = None
+    cases: int = 0
+    overall: Metrics = field(default_factory=Metrics)
+    by_rule: Dict[str, Metrics] = field(default_factory=dict)
+    by_cwe: Dict[str, Metrics] = field(default_factory=dict)
+    unlabeled_findings: int = 0  # findings whose CWE has no labeled cases in the dataset
+    duration: float = 0.0
+    created_at: float = field(default_factory=time.time)
+
+    def to_dict(self) -> Dict[str, Any]:
+        from .config.settings import get_settings
+
+        return {
+            "dataset": self.dataset,
+            "dataset_format": self.dataset_format,
+            "mode": self.mode,
+            "model": self.model,
+            "scanner_version": get_settings().app_version,
+            "rules": sorted(r.rule_id for r in get_rules()),
+            "created_at": self.created_at,
+            "duration": round(self.duration, 2),
+            "cases": self.cases,
+            "overall": self.overall.to_dict(),
+            "by_rule": {k: v.to_dict() for k, v in sorted(self.by_rule.items())},
+            "by_cwe": {k: v.to_dict() for k, v in sorted(self.by_cwe.items())},
+            "unlabeled_findings": self.unlabeled_findings
+        }
+
+
+def score(cases: List[LabeledCase], findings: List[Dict[str, Any]], result: BenchmarkResult) -> BenchmarkResult:
+    """A case counts as flagged for a rule when the rule reports its CWE in the case's file (and function)"""
+    contexts: Dict[str, SourceContext] = {}
+
+    def function_at(path: str, line: int) -> Optional[str]:
+        if path not in contexts:
+            with open(path, 'r', encoding='utf-8', errors='ignore') as f:
+                contexts[path] = SourceContext(f.read(), path)
+        member = contexts[path].enclosing_function(line)
+        return member.name if member else None
+
+    # (file, function or None, cwe) -> rules that flagged it
+    hits: Dict[Tuple[str, Optional[str], str], set] = {}
+    labeled_cwes = {c.cwe for c in cases}
+    rule_cwes: Dict[str, str] = {r.rule_id: normalize_cwe(r.cwe_id) for r in get_rules() if r.cwe_id}
+
+    for finding in findings:
+        cwe = finding_cwe(finding)
+        if cwe not in labeled_cwes:
+            result.unlabeled_findings += 1
+            continue
+        key = finding_rule_id(finding)
+        rule_cwes.setdefault(key, cwe)
+        path = os.path.abspath(finding.get("file_path", ""))
+        for function in (None, function_at(path, finding.get("line_number", 0))):
+            hits.setdefault((path, function, cwe), set()).add(key)
+
+    for case in cases:
+        flagged_by = hits.get((os.path.abspath(case.file_path), case.function, case.cwe), set())
+        result.overall.add(bool(flagged_by), case.vulnerable)
+        result.by_cwe.setdefault(case.cwe, Metrics()).add(bool(flagged_by), case.vulnerable)
+        for rule_id, cwe in rule_cwes.items():
+            if cwe == case.cwe:
+                result.by_rule.setdefault(rule_id, Metrics()).add(rule_id in flagged_by, case.vulnerable)
+
+    result.cases = len(cases)
+    return result
+
+
+async def run_benchmark(dataset: str, llm: bool = False, model: Optional[str] = None) -> BenchmarkResult:
+    started = time.time()
+    dataset_format, cases = load_dataset(dataset)
+    result = BenchmarkResult(dataset=os.path.abspath(dataset), dataset_format=dataset_format, mode="llm" if llm else "static")
+
+    if llm:
+        from .config.offline import require_network
+        from .llm import get_llm_config
+        from .main import run_analysis_pipeline
+        from .reports import load_report
+
+        require_network("Benchmarking the LLM pipeline")
+        config = get_llm_config()
+        if not config.has_any_key():
+            raise BenchmarkError("--llm needs an LLM API key (OPENAI_API_KEY, ANTHROPIC_API_KEY, or GOOGLE_API_KEY)")
+        if model:
+            config.default_model = model
+        result.model = config.default_model
+
+        session_id = f"benchmark_{int(started)}"
+        await run_analysis_pipeline(session_id, "project", os.path.abspath(dataset), notify=False)
+        findings = (load_report(session_id) or {}).get("vulnerabilities", [])
+    else:
+        findings = static_findings(c.file_path for c in cases)
+
+    score(cases, findings, result)
+    result.duration = time.time() - started
+    return result
+
+
+def compare(current: Dict[str, Any], previous: Dict[str, Any]) -> List[Tuple[str, str, Optional[float], Optional[float]]]:
+    """(scope, metric, previous, current) for every precision/recall that changed between two saved results"""
+    changes = []
+    scopes = [("overall", current["overall"], previous.get("overall", {}))]
+    for group in ("by_rule", "by_cwe"):
+        for key, metrics in current.get(group, {}).items():
+            scopes.append((key, metrics, previous.get(group, {}).get(key, {})))
+
+    for scope, now, before in scopes:
+        for metric in ("precision", "recall"):
+            if now.get(metric) != before.get(metric):
+                changes.append((scope, metric, before.get(metric), now.get(metric)))
+    return changes
@@ -0,0 +1,173 @@
+"""
+Blame attribution - Last author and commit of each finding's lines, for assigning remediation work
+A file is blamed once per scan however many findings it has; files outside a git checkout are left unattributed.
+"""
+
+import logging
+import os
+import subprocess
+import time
+from dataclasses import dataclass
+from typing import Any, Dict, List, Optional, Sequence, Tuple
+
+from .codeowners import UNOWNED, finding_teams
+from .isolation import git_command
+
+logger = logging.getLogger(__name__)
+
+UNCOMMITTED = "0" * 40
+UNATTRIBUTED = "unattributed"
+GROUP_BY = ("owner", "team")  # last author per git blame, or CODEOWNERS team
+
+
+@dataclass
+class BlameLine:
+    commit: str
+    author: str
+    email: str
+    time: int
+    summary: str
+    boundary: bool = False  # the oldest commit blame can see: a root commit, or the cut-off of a shallow clone
+
+    def to_dict(self) -> Dict[str, Any]:
+        if self.commit == UNCOMMITTED:
+            return {"commit": None, "author": None, "email": None, "date": None, "summary": "Not committed yet", "uncommitted": True}
+        return {
+            "commit": self.commit,
+            "author": self.author,
+            "email": self.email,
+            "date": time.strftime('%Y-%m-%d', time.gmtime(self.time)),
+            "summary": self.summary,
+            "uncommitted": False
+        }
+
+
+def parse_porcelain(output: str) -> Dict[int, BlameLine]:
+    """git blame --line-porcelain: a header per line ("<sha> <orig> <final>"), its commit fields, then a tab and the text"""
+    lines: Dict[int, BlameLine] = {}
+    fields: Dict[str, str] = {}
+    header: Optional[Tuple[str, int]] = None
+
+    for raw in output.split('\n'):
+        if header is None:
+            parts = raw.split()
+            if len(parts) >= 3 and len(parts[0]) == 40:
+                header = (parts[0], int(parts[2]))
+        elif raw.startswith('\t'):
+            commit, final = header
+            lines[final] = BlameLine(
+                commit=commit,
+                author=fields.get('author', ''),
+                email=fields.get('author-mail', '').strip('<>'),
+                time=int(fields.get('author-time', '0') or 0),
+                summary=fields.get('summary', ''),
+                boundary='boundary' in fields
+            )
+            fields = {}
+            header = None
+        else:
+            key, _, value = raw.partition(' ')
+            fields[key] = value
+    return lines
+
+
+class Blamer:
+    """Per-scan cache of whole-file blames"""
+
+    def __init__(self):
+        self.files: Dict[str, Optional[Dict[int, BlameLine]]] = {}
+
+    def blame_file(self, file_path: str) -> Optional[Dict[int, BlameLine]]:
+        path = os.path.abspath(file_path)
+        if path not in self.files:
+            self.files[path] = None
+            if os.path.isfile(path):
+                try:
+                    result = subprocess.run(
+                        git_command(['blame', '--line-porcelain', '--no-textconv', '--', os.path.basename(path)]),
+                        cwd=os.path.dirname(path), capture_output=True, text=True, timeout=120
+                    )
+                except (OSError, subprocess.TimeoutExpired) as e:
+                    logger.debug(f"git blame {path}: {e}")
+                    return None
+                # untracked files and paths outside a checkout fail here; they stay unattributed
+                if result.returncode == 0:
+                    lines = parse_porcelain(result.stdout)
+                    if self.is_shallow(os.path.dirname(path)):
+                        # in a shallow clone the boundary commit only stands in for the missing history
+                        lines = {n: line for n, line in lines.items() if not line.boundary}
+                    self.files[path] = lines
+        return self.files[path]
+
+    def is_shallow(self, directory: str) -> bool:
+        try:
+            result = subprocess.run(git_command(['rev-parse', '--is-shallow-repository']), cwd=directory,
+                                    capture_output=True, text=True, timeout=30)
+        except (OSError, subprocess.TimeoutExpired):
+            return False
+        return result.stdout.strip() == 'true'
+
+    def blame(self, file_path: str, start: int, end: int) -> Optional[BlameLine]:
+        """The most recent change among lines start..end: whoever last touched the offending code"""
+        lines = self.blame_file(file_path)
+        if not lines:
+            return None
+        touched = [lines[n] for n in range(start, end + 1) if n in lines]
+        if not touched:
+            return None
+        committed = [line for line in touched if line.commit != UNCOMMITTED]
+        if len(committed) < len(touched):
+            return next(line for line in touched if line.commit == UNCOMMITTED)
+        return max(committed, key=lambda line: line.time)
+
+
+def finding_lines(vuln: Any) -> Tuple[int, int]:
+    """The offending lines: the reported line plus however many lines its snippet spans"""
+    start = max(1, vuln.line_number or 1)
+    snippet = (vuln.code_snippet or '').strip('\n')
+    return start, start + min(snippet.count('\n'), 20)
+
+
+def annotate_blame(vulnerabilities: Sequence[Any], blamer: Optional[Blamer] = None) -> int:
+    """Record the last author and commit of each finding's lines. Returns the number of findings attributed."""
+    blamer = blamer or Blamer()
+    attributed = 0
+    for vuln in vulnerabilities:
+        if not vuln.file_path or vuln.file_path.startswith('<'):
+            continue
+        line = blamer.blame(vuln.file_path, *finding_lines(vuln))
+        if line:
+            vuln.blame = line.to_dict()
+            attributed += 1
+    return attributed
+
+
+def finding_owner(vuln: Dict[str, Any]) -> str:
+    blame = vuln.get("blame") or {}
+    if blame.get("uncommitted"):
+        return "uncommitted"
+    if blame.get("author"):
+        return f"{blame['author']} <{blame['email']}>" if blame.get("email") else blame["author"]
+    return UNATTRIBUTED
+
+
+def group_findings(vulnerabilities: List[Dict[str, Any]], group_by: str) -> List[Tuple[str, List[Dict[str, Any]]]]:
+    """Findings per owner or team, largest groups first and unattributed/unowned ones last; a finding
+    with several CODEOWNERS teams appears under each"""
+    if group_by not in GROUP_BY:
+        raise ValueError(f"Unknown grouping: {group_by} (choose from {', '.join(GROUP_BY)})")
+    groups: Dict[str, List[Dict[str, Any]]] = {}
+    for vuln in vulnerabilities:
+        for key in (finding_teams(vuln) if group_by == "team" else [finding_owner(vuln)]):
+            groups.setdefault(key, []).append(vuln)
+    return sorted(groups.items(), key=lambda item: (item[0] in (UNATTRIBUTED, UNOWNED), -len(item[1]), item[0]))
+
+
+def owner_summary(vulnerabilities: List[Dict[str, Any]]) -> Dict[str, Dict[str, Any]]:
+    summary = {}
+    for owner, findings in group_findings(vulnerabilities, "owner"):
+        by_severity: Dict[str, int] = {}
+        for vuln in findings:
+            by_severity[vuln.get("severity", "unknown")] = by_severity.get(vuln.get("severity", "unknown"), 0) + 1
+        summary[owner] = {"findings": len(findings), "by_severity": by_severity, "vuln_ids": [v.get("vuln_id") for v in findings]}
+    return summary
@@ -0,0 +1,97 @@
+"""
+Scan checkpoints - Per-file progress of a running LLM project scan, so an interrupted scan can resume
+Each analyzed file's findings and LLM cost are saved as soon as the file is done; resuming with the same
+session id reuses them for files whose content has not changed and only analyzes the rest.
+"""
+
+import hashlib
+import json
+import os
+import time
+from dataclasses import asdict, dataclass, field
+from typing import Any, Dict, List, Optional
+
+from .reports import REPORTS_DIR
+
+CHECKPOINTS_DIR = os.path.join(REPORTS_DIR, 'checkpoints')
+
+
+def content_digest(text: str) -> str:
+    return hashlib.sha256(text.encode('utf-8', errors='ignore')).hexdigest()
+
+
+@dataclass
+class FileCheckpoint:
+    digest: str
+    vulnerabilities: List[Dict[str, Any]] = field(default_factory=list)
+    cost: float = 0.0
+
+
+@dataclass
+class ScanCheckpoint:
+    session_id: str
+    target: str
+    config_digest: str = ""
+    files: Dict[str, FileCheckpoint] = field(default_factory=dict)
+    created_at: float = field(default_factory=time.time)
+    updated_at: float = field(default_factory=time.time)
+
+    @staticmethod
+    def path_for(session_id: str) -> str:
+        return os.path.join(CHECKPOINTS_DIR, f"{session_id}.json")
+
+    @classmethod
+    def load(cls, session_id: str) -> Optional['ScanCheckpoint']:
+        path = cls.path_for(session_id)
+        if not os.path.exists(path):
+            return None
+        with open(path, 'r') as f:
+            data = json.load(f)
+        files = {p: FileCheckpoint(**entry) for p, entry in data.pop("files", {}).items()}
+        return cls(**data, files=files)
+
+    @classmethod
+    def start(cls, session_id: str, target: str, config: Dict[str, Any]) -> 'ScanCheckpoint':
+        """Resume the session's checkpoint, or begin a new one; progress made under a different
+        scan config is dropped since its findings may no longer apply"""
+        config_digest = content_digest(json.dumps(config, sort_keys=True))
+        checkpoint = cls.load(session_id)
+        if not checkpoint or checkpoint.target != target:
+            checkpoint = cls(session_id=session_id, target=target)
+        if checkpoint.config_digest != config_digest:
+            checkpoint.files.clear()
+            checkpoint.config_digest = config_digest
+        return checkpoint
+
+    def lookup(self, file_path: str, code: str) -> Optional[FileCheckpoint]:
+        entry = self.files.get(file_path)
+        return entry if entry and entry.digest == content_digest(code) else None
+
+    def record(self, file_path: str, code: str, vulnerabilities: List[Dict[str, Any]], cost: float = 0.0):
+        self.files[file_path] = FileCheckpoint(content_digest(code), vulnerabilities, cost)
+        self.save()
+
+    def save(self):
+        os.makedirs(CHECKPOINTS_DIR, exist_ok=True)
+        self.updated_at = time.time()
+        path = self.path_for(self.session_id)
+        tmp = f"{path}.tmp"
+        with open(tmp, 'w') as f:
+            json.dump(asdict(self), f)
+        os.replace(tmp, path)
+
+    def discard(self):
+        path = self.path_for(self.session_id)
+        if os.path.exists(path):
+            os.remove(path)
+
+
+def list_checkpoints() -> List[ScanCheckpoint]:
+    """Unfinished scans, newest first"""
+    if not os.path.exists(CHECKPOINTS_DIR):
+        return []
+    checkpoints = [
+        ScanCheckpoint.load(name[:-len('.json')])
+        for name in os.listdir(CHECKPOINTS_DIR) if name.endswith('.json')
+    ]
+    return sorted((c for c in checkpoints if c), key=lambda c: c.updated_at, reverse=True)
@@ -0,0 +1,1047 @@
+"""
+Scanner CLI - Command-line access to the static rules and analysis reports
+Usage: python -m src.cli <command> [options]  (or scripts/scanner)
+"""
+
+import argparse
+import asyncio
+import difflib
+import json
+import os
+import sys
+from typing import Any, Dict, List, Optional, Union
+
+from .analysis.rules import get_rule, get_rules
+from .blame import GROUP_BY
+from .config.offline import OfflineError, is_offline, require_network
+from .formatters import FORMATTERS, render_report
+from .ranking import SORT_ORDERS
+from .reports import evaluate_gate, find_finding, list_report_ids, load_report, save_report
+from .schema import SCHEMA_FILE
+
+EXIT_DEGRADED = 3  # scan finished, but the LLM dropped out and some files got static rules only
+
+
+def catalog_rules() -> List:
+    """Built-in rules plus the rule packs pinned by the project in the current directory"""
+    from .rulepacks import RulePackError, project_pack_rules
+
+    try:
+        return get_rules() + project_pack_rules(os.getcwd())
+    except (RulePackError, OSError, ValueError) as e:
+        print(f"Rule packs not loaded: {e}", file=sys.stderr)
+        return get_rules()
+
+
+def cmd_rules_list(args: argparse.Namespace) -> int:
+    rules = sorted(catalog_rules(), key=lambda r: r.rule_id)
+
+    if args.json:
+        print(json.dumps([r.to_dict() for r in rules], indent=2))
+        return 0
+
+    width = max(len(r.rule_id) for r in rules)
+    print(f"{'RULE':<{width}}  {'SEVERITY':<8}  {'CWE':<8}  NAME")
+    for rule in rules:
+        print(f"{rule.rule_id:<{width}}  {rule.severity:<8}  {rule.cwe_id or '-':<8}  {rule.name}")
+    return 0
+
+
+def cmd_rules_explain(args: argparse.Namespace) -> int:
+    rule = get_rule(args.rule_id) or next((r for r in catalog_rules() if r.rule_id == args.rule_id), None)
+    if not rule:
+        print(f"Unknown rule: {args.rule_id} (see 'scanner rules list')", file=sys.stderr)
+        return 1
+
+    if args.json:
+        print(json.dumps(rule.to_dict(), indent=2))
+        return 0
+
+    print(f"{rule.rule_id} - {rule.name}")
+    print(f"Type:      {rule.vuln_type}")
+    print(f"Severity:  {rule.severity} (default)")
+    print(f"CWE:       {rule.cwe_id or '-'}")
+    print(f"Languages: {', '.join(rule.languages)}")
+    print(f"Fix:       {rule.fix_complexity} (default)")
+    if rule.needs_review:
+        print("Review:    every finding needs human review")
+    print()
+    print(rule.description)
+    if rule.example:
+        print()
+        print("Example:")
+        for line in rule.example.split('\n'):
+            print(f"    {line}")
+    if rule.remediation:
+        print()
+        print(f"Remediation: {rule.remediation}")
+    return 0
+
+
+def cmd_rules_install(args: argparse.Namespace) -> int:
+    from .config.project import PROJECT_CONFIG_NAMES
+    from .rulepacks import RulePackError, install_pack, project_pins, write_pin
+
+    project = os.path.abspath(args.project)
+    try:
+        config_path, pins = project_pins(project)
+    except (RulePackError, OSError, ValueError) as e:
+        print(str(e), file=sys.stderr)
+        return 1
+    base_dir = os.path.dirname(config_path) if config_path else project
+    specs = args.packs or pins
+    if not specs:
+        print("No rule packs given and none pinned in the project config", file=sys.stderr)
+        return 1
+
+    failed = 0
+    for spec in specs:
+        try:
+            pack = install_pack(spec, allow_unsigned=args.allow_unsigned, base_dir=base_dir)
+        except (RulePackError, OfflineError, OSError) as e:
+            print(f"{spec}: {e}", file=sys.stderr)
+            failed += 1
+            continue
+        signed = f"signed by {pack.signed_by}" if pack.signed_by else "local" if "path" in pack.source else "UNSIGNED"
+        print(f"Installed {pack.metadata['name']}@{pack.metadata['version']}: {len(pack.rules)} rule(s), {signed}")
+        if args.packs and not args.no_pin:
+            config_path = write_pin(config_path or os.path.join(project, PROJECT_CONFIG_NAMES[0]), pack)
+            print(f"Pinned {pack.spec} in {config_path}")
+    return 1 if failed else 0
+
+
+def cmd_rules_packs(args: argparse.Namespace) -> int:
+    from .config.settings import get_settings
+    from .rulepacks import RulePackError, installed_packs, load_pinned_packs, project_pins
+
+    try:
+        config_path, pins = project_pins(os.path.abspath(args.project))
+    except (RulePackError, OSError, ValueError) as e:
+        print(str(e), file=sys.stderr)
+        return 1
+    packs, errors = load_pinned_packs(pins, os.path.dirname(config_path)) if pins else ([], [])
+    installed = [{"directory": directory, **record} for directory, record in installed_packs()]
+
+    if args.json:
+        print(json.dumps({"config": config_path, "pinned": [p.to_dict() for p in packs], "errors": errors, "installed": installed}, indent=2))
+        return 1 if errors else 0
+
+    print(f"Pinned in {config_path or '(no project config)'}:")
+    for pack in packs:
+        signed = f"signed by {pack.signed_by}" if pack.signed_by else "local" if "path" in pack.source else "unsigned"
+        label = f"{pack.metadata['name']}@{pack.metadata['version']}"
+        source = "" if pack.spec == label else f"  ({pack.spec})"
+        print(f"  {label:<30} {len(pack.rules):>3} rule(s)  {signed}{source}")
+    for error in errors:
+        print(f"  ! {error}")
+    if not pins:
+        print("  none")
+    print(f"\nInstalled under {get_settings().rule_pack_dir}:")
+    for record in installed:
+        print(f"  {record['spec']:<40} {'signed by ' + record['signed_by'] if record.get('signed_by') else 'unsigned'}")
+    if not installed:
+        print("  none")
+    return 1 if errors else 0
+
+
+def cmd_explain(args: argparse.Namespace) -> int:
+    report, vulnerability = find_finding(args.finding_id, args.report)
+    if not vulnerability:
+        where = f"report {args.report}" if args.report else "any saved report"
+        print(f"Finding {args.finding_id} not found in {where}", file=sys.stderr)
+        return 1
+
+    from .agents.finding_explainer import explain_report_finding
+    from .llm import get_llm_config
+
+    cached = args.finding_id in report.get("explanations", {}) and not args.refresh
+    if not cached:
+        require_network("Explaining a finding")
+    if not cached and not get_llm_config().has_any_key():
+        print("No LLM API key configured (set OPENAI_API_KEY or ANTHROPIC_API_KEY)", file=sys.stderr)
+        return 1
+
+    explanation = asyncio.run(explain_report_finding(report, args.finding_id, refresh=args.refresh))
+    if not cached:
+        save_report(report)
+
+    if args.json:
+        print(json.dumps(explanation, indent=2))
+        return 0
+
+    print(f"{args.finding_id} - {vulnerability.get('vuln_type')} ({vulnerability.get('severity')})")
+    print(f"{vulnerability.get('file_path')}:{vulnerability.get('line_number')}  [report {report['session_id']}]")
+    print()
+    print(explanation["summary"])
+    for title, key in (("Why it is exploitable", "why_exploitable"),
+                       ("Attack scenario", "attack_scenario"),
+                       ("How to fix", "fix_guidance")):
+        if explanation.get(key):
+            print()
+            print(f"{title}:")
+            print(explanation[key])
+    if explanation.get("fixed_code"):
+        print()
+        print("Suggested code:")
+        for line in explanation["fixed_code"].split('\n'):
+            print(f"    {line}")
+    return 0
+
+
+def write_output(output: Union[str, bytes], fmt: str, path: Optional[str]) -> int:
+    """Write a rendered report to path or stdout; binary formats (pdf) are not written to a terminal"""
+    binary = isinstance(output, bytes)
+    if path:
+        with open(path, 'wb' if binary else 'w') as f:
+            f.write(output)
+        print(f"Wrote {fmt} report to {path}", file=sys.stderr)
+    elif binary:
+        if sys.stdout.isatty():
+            print(f"The {fmt} report is binary; write it to a file with --output", file=sys.stderr)
+            return 1
+        sys.stdout.buffer.write(output)
+    else:
+        sys.stdout.write(output if output.endswith('\n') else output + '\n')
+    return 0
+
+
+def cmd_report(args: argparse.Namespace) -> int:
+    session_id = args.report_id or next(iter(list_report_ids()), None)
+    report = load_report(session_id) if session_id else None
+    if not report:
+        print(f"Report not found: {session_id or '(no saved reports)'}", file=sys.stderr)
+        return 1
+
+    if args.team:
+        from .codeowners import team_report
+
+        report = team_report(report, [args.team])
+    try:
+        output = render_report(report, args.format, context_lines=args.context_lines, group_by=args.group_by, sort_by=args.sort)
+    except ValueError as e:
+        print(str(e), file=sys.stderr)
+        return 1
+    return write_output(output, args.format, args.output)
+
+
+def scan_archive(args: argparse.Namespace) -> int:
+    from .archives import ArchiveError, ArchiveSandbox
+
+    if args.new_only or args.base:
+        print("--new-only and --base compare against a git checkout; an archive has no history", file=sys.stderr)
+        return 1
+    sandbox = ArchiveSandbox(args.path)
+    try:
+        sandbox.open()
+    except (ArchiveError, OSError) as e:
+        print(f"Could not unpack {args.path}: {e}", file=sys.stderr)
+        return 1
+    try:
+        print(f"Archive {args.path}: {sandbox.info.files} file(s), {sandbox.info.bytes} bytes unpacked"
+              + (f", {len(sandbox.info.skipped)} link/unsafe entries skipped" if sandbox.info.skipped else ""), file=sys.stderr)
+        return cmd_scan(args, sandbox)
+    finally:
+        sandbox.close()
+
+
+def scan_remote(args: argparse.Namespace) -> int:
+    from .remote import RemoteCheckout, RemoteError, parse_remote
+
+    if args.new_only or args.base:
+        print("--new-only and --base need the branch history; a remote scan fetches a single commit", file=sys.stderr)
+        return 1
+    require_network("Scanning a remote repository")
+    checkout = RemoteCheckout(parse_remote(args.path))
+    try:
+        checkout.open()
+    except (RemoteError, OSError) as e:
+        print(f"Could not fetch {checkout.repo}: {e}", file=sys.stderr)
+        return 1
+    try:
+        print(f"Fetched {checkout.repo} at {checkout.commit[:12]}", file=sys.stderr)
+        return cmd_scan(args, checkout)
+    finally:
+        checkout.close()
+
+
+def cmd_scan(args: argparse.Namespace, sandbox=None) -> int:
+    import time
+
+    from .config.settings import get_settings
+    from .llm import get_llm_config
+
+    if args.sign and not args.attest:
+        print("--sign needs --attest FILE", file=sys.stderr)
+        return 1
+    if args.max_cost is not None and args.max_cost <= 0:
+        print("--max-cost must be more than 0", file=sys.stderr)
+        return 1
+    if sandbox is None and not args.image and not args.resume:
+        from .archives import is_archive
+        from .remote import is_remote
+
+        if is_archive(args.path):
+            return scan_archive(args)
+        if is_remote(args.path):
+            return scan_remote(args)
+    # an unpacked archive or remote checkout, removed once the scan returns
+    target = sandbox.root if sandbox else os.path.abspath(args.path)
+    session_id = f"scan_{int(time.time())}"
+    image = None
+    if args.image:
+        from .images import ImageError, extract_image
+
+        try:
+            image = extract_image(args.image, args.platform)
+        except (ImageError, OSError, ValueError, KeyError) as e:
+            print(f"Could not read image {args.image}: {e}", file=sys.stderr)
+            return 1
+        target = image.root
+        print(f"Image {args.image} ({image.digest}): {len(image.source_files)} source, {len(image.config_files)} config, "
+              f"{len(image.bytecode_files)} bytecode file(s) under {', '.join(image.app_roots) or '/'}", file=sys.stderr)
+    if args.resume:
+        from .checkpoints import ScanCheckpoint
+
+        require_network("Resuming an LLM scan")
+        checkpoint = ScanCheckpoint.load(args.resume)
+        if not checkpoint:
+            print(f"No checkpoint for scan {args.resume} (it finished, or never analyzed a file)", file=sys.stderr)
+            return 1
+        if args.static or not get_llm_config().has_any_key():
+            print("Resuming needs the LLM pipeline; static scans are not checkpointed", file=sys.stderr)
+            return 1
+        session_id, target = checkpoint.session_id, checkpoint.target
+        print(f"Resuming scan {session_id}: {len(checkpoint.files)} file(s) already analyzed", file=sys.stderr)
+
+    if not os.path.isdir(target):
+        print(f"Not a directory: {target}", file=sys.stderr)
+        return 1
+    if args.policy:
+        get_settings().policy_source = args.policy
+
+    from .profiling import format_profile, profile_scan
+
+    try:
+        with profile_scan(session_id, target, enabled=args.profile) as profile:
+            if get_llm_config().has_any_key() and not args.static and not is_offline():
+                from .main import run_analysis_pipeline
+
+                if not args.resume:
+                    print(f"Scan {session_id} (if interrupted, continue with: scanner scan --resume {session_id})", file=sys.stderr)
+                asyncio.run(run_analysis_pipeline(session_id, "project", target, notify=False, max_cost=args.max_cost))
+                report = load_report(session_id) or {}
+            else:
+                from .main import run_static_scan
+
+                report = run_static_scan(session_id, target)
+    except ValueError as e:
+        print(f"Scan failed: {e}", file=sys.stderr)
+        return 1
+    if profile:
+        report["profile"] = profile.to_dict()
+        print('\n'.join(format_profile(report["profile"])), file=sys.stderr)
+
+    if report.get("status") != "completed":
+        print(f"Scan failed: {'; '.join(report.get('errors', [])) or 'unknown error'}", file=sys.stderr)
+        return 1
+
+    if image:
+        report["image"] = image.to_dict()
+    if sandbox:
+        sandbox.relocate(report)
+    if args.new_only or args.base:
+        from .main import run_static_scan
+        from .mergebase import MergeBaseError, compare_with_merge_base
+
+        try:
+            compare_with_merge_base(report, target, args.base, run_static_scan)
+        except (MergeBaseError, OSError, ValueError) as e:
+            print(f"Could not compare with the merge-base: {e}", file=sys.stderr)
+            return 1
+    gate = evaluate_gate(report, args.fail_on)
+    trend = None
+    if args.history_db:
+        try:
+            trend = asyncio.run(record_history(report, args.history_db, args.history_target or history_target(args.path, target)))
+        except Exception as e:
+            print(f"Could not update the scan history {args.history_db}: {e}", file=sys.stderr)
+            return 1
+    elif (report.get("project_config") or {}).get("trend_gates"):
+        print("trend_gates are configured but not checked: pass --history-db to compare with the scan history", file=sys.stderr)
+    save_report(report)
+
+    output = render_report(report, args.format, sort_by=args.sort)
+    if write_output(output, args.format, args.output):
+        return 1
+    if args.attest and write_attestation_file(report, args.attest, args.sign):
+        return 1
+
+    if (gate and not gate["passed"]) or (trend and not trend["passed"]):
+        return 1
+    if report.get("degraded"):
+        degraded = report["degraded"]
+        print(f"LLM {degraded['reason']} during {degraded['step']}; finished with static rules only ({degraded['error']})", file=sys.stderr)
+        if degraded["static_only_files"]:
+            print(f"Once the LLM is available, analyze the remaining files with: scanner scan --resume {report['session_id']}", file=sys.stderr)
+        return EXIT_DEGRADED
+    return 0
+
+
+def history_target(path: str, root: str) -> str:
+    """The name a project's scans are recorded under: its origin URL and branch (as the daemon records
+    them) when scanning a git checkout, otherwise the given path"""
+    from .attestation import git_source
+
+    source = git_source(root) if os.path.isdir(path) else None
+    if source and source["uri"] != os.path.abspath(root):
+        return f"{source['uri']}#{source['branch']}" if source["branch"] else source["uri"]
+    return os.path.abspath(path) if os.path.isdir(path) else path
+
+
+async def record_history(report: Dict[str, Any], path: str, target: str) -> Optional[Dict[str, Any]]:
+    """Check the trend gates against the history store, then record this scan in it"""
+    from .database import Database
+    from .trends import evaluate_trend_gates
+
+    db = Database(path)
+    await db.initialize()
+    try:
+        trend = await evaluate_trend_gates(report, db, target)
+        await db.record_session(report, target=target)
+        return trend
+    finally:
+        await db.close()
+
+
+def write_attestation_file(report: Dict[str, Any], path: str, sign: bool) -> int:
+    from .attestation import AttestationError, describe, write_attestation
+
+    try:
+        dsse = write_attestation(report, path, sign=sign)
+    except (AttestationError, OfflineError, OSError) as e:
+        print(f"Could not write the attestation: {e}", file=sys.stderr)
+        return 1
+    print(f"Wrote attestation to {path}: {describe(dsse)}", file=sys.stderr)
+    return 0
+
+
+def cmd_attest(args: argparse.Namespace) -> int:
+    session_id = args.report_id or next(iter(list_report_ids()), None)
+    report = load_report(session_id) if session_id else None
+    if not report:
+        print(f"Report not found: {session_id or '(no saved reports)'}", file=sys.stderr)
+        return 1
+    return write_attestation_file(report, args.output or f"{report['session_id']}.intoto.json", args.sign)
+
+
+def cmd_export(args: argparse.Namespace) -> int:
+    from .integrations import TrackerError, export_findings, get_tracker
+
+    session_id = args.report_id or next(iter(list_report_ids()), None)
+    report = load_report(session_id) if session_id else None
+    if not report:
+        print(f"Report not found: {session_id or '(no saved reports)'}", file=sys.stderr)
+        return 1
+    require_network("Exporting to an issue tracker")
+
+    try:
+        actions = export_findings(report, get_tracker(args.tracker), include_unconfirmed=args.all, dry_run=args.dry_run)
+    except (TrackerError, OSError) as e:
+        print(f"Export to {args.tracker} failed: {e}", file=sys.stderr)
+        return 1
+
+    if args.json:
+        print(json.dumps(actions, indent=2))
+        return 0
+    counts = {}
+    for action in actions:
+        counts[action["action"]] = counts.get(action["action"], 0) + 1
+        if action["action"] != "unchanged":
+            issue = f" {action['key']}" if action.get("key") else ""
+            reason = f" ({action['reason']})" if action.get("reason") else ""
+            print(f"{action['action']:<9}{issue} {action.get('finding') or action['fingerprint']}{reason} {action.get('url') or ''}".rstrip())
+    summary = ', '.join(f"{n} {a}" for a, n in counts.items()) or "nothing to export (no confirmed findings; see scanner triage, or pass --all)"
+    print(f"{'Would export' if args.dry_run else 'Exported'} report {report['session_id']} to {args.tracker}: {summary}")
+    return 0
+
+
+def cmd_fix(args: argparse.Namespace) -> int:
+    if args.open_pr:
+        require_network("Opening a pull request")
+    session_id = args.report_id or next(iter(list_report_ids()), None)
+    report = load_report(session_id) if session_id else None
+    if not report:
+        print(f"Report not found: {session_id or '(no saved reports)'}", file=sys.stderr)
+        return 1
+
+    wanted = set(args.finding) if args.finding else None
+    patched = {p.get("vulnerability_id") for p in report.get("patches", [])}
+
+    if args.generate:
+        from .agents import PatchProducerAgent
+        from .llm import get_llm_config
+
+        missing = [
+            v for v in report.get("vulnerabilities", [])
+            if v.get("vuln_id") not in patched and (wanted is None or v.get("vuln_id") in wanted)
+        ]
+        if missing:
+            require_network("Generating patches")
+            if not get_llm_config().has_any_key():
+                print("No LLM API key configured (set OPENAI_API_KEY or ANTHROPIC_API_KEY)", file=sys.stderr)
+                return 1
+            producer = PatchProducerAgent()
+            patches = asyncio.run(producer.generate_patches(missing))
+            offset = len(report.get("patches", []))
+            for i, patch in enumerate(patches):
+                patch.patch_id = f"PATCH-{offset + i + 1:04d}"
+            report.setdefault("patches", []).extend(p.to_dict() for p in patches)
+            report["cost"] = report.get("cost", 0.0) + (producer.execution.total_cost if producer.execution else 0)
+            save_report(report)
+
+    patches = [p for p in report.get("patches", []) if wanted is None or p.get("vulnerability_id") in wanted]
+    if not patches:
+        print(f"No patches in report {report['session_id']} (use --generate to create them)", file=sys.stderr)
+        return 1
+
+    if not args.apply:
+        for patch in patches:
+            print(f"# {patch['patch_id']} for {patch['vulnerability_id']} ({patch.get('patch_type')}, {patch.get('confidence', 0):.0%}): {patch.get('patch_description', '')}")
+            path = patch['file_path'].lstrip('/')
+            sys.stdout.writelines(difflib.unified_diff(
+                [line + '\n' for line in patch.get("original_code", "").split('\n')],
+                [line + '\n' for line in patch.get("patched_code", "").split('\n')],
+                fromfile=f"a/{path}",
+                tofile=f"b/{path}"
+            ))
+            print()
+        return 0
+
+    from .fixes import FixApplier, FixApplyError
+
+    applier = FixApplier(branch=args.branch, validate=not args.no_validate, min_confidence=args.min_confidence)
+    try:
+        results = applier.apply(report, sorted(wanted) if wanted else None)
+    except FixApplyError as e:
+        print(f"Cannot apply fixes: {e}", file=sys.stderr)
+        return 1
+
+    report["fix_results"] = [r.to_dict() for r in results]
+    save_report(report)
+
+    for result in results:
+        commit = f" {result.commit[:12]}" if result.commit else ""
+        print(f"{result.vulnerability_id:<10} {result.status:<18}{commit}  {result.message}")
+
+    committed = sum(1 for r in results if r.status == "committed")
+    if committed:
+        print(f"\n{committed} fix(es) committed on branch {args.branch} for review")
+
+    if committed and args.open_pr:
+        from .integrations import PullRequestError, open_fix_pull_request
+
+        try:
+            pull_request = open_fix_pull_request(
+                report, report["fix_results"], applier.root, args.branch, applier.base_branch, remote=args.remote
+            )
+        except PullRequestError as e:
+            print(f"Could not open pull request: {e}", file=sys.stderr)
+            return 1
+        report.setdefault("pull_requests", []).append(pull_request.to_dict())
+        save_report(report)
+        print(f"Opened {pull_request.provider} pull request: {pull_request.url}")
+
+    return 0 if committed == len(results) else 2
+
+
+def cmd_daemon(args: argparse.Namespace) -> int:
+    import logging
+
+    from .daemon import DaemonConfigError, ScanDaemon, load_daemon_config
+
+    logging.basicConfig(level=logging.INFO, format="%(asctime)s %(levelname)s %(message)s")
+    require_network("The scan daemon (it fetches each repository before scanning)")
+    try:
+        config = load_daemon_config(args.config)
+    except (OSError, DaemonConfigError) as e:
+        print(f"Invalid daemon config: {e}", file=sys.stderr)
+        return 1
+
+    daemon = ScanDaemon(config)
+    try:
+        asyncio.run(daemon.run_once() if args.once else daemon.run_forever())
+    except KeyboardInterrupt:
+        pass
+    return 0
+
+
+def cmd_batch(args: argparse.Namespace) -> int:
+    import logging
+
+    from .batch import BatchError, load_manifest, render_aggregate, run_batch
+    from .llm import get_llm_config
+
+    logging.basicConfig(level=logging.INFO, format="%(asctime)s %(levelname)s %(message)s")
+    try:
+        manifest = load_manifest(args.manifest)
+    except (OSError, BatchError) as e:
+        print(f"Invalid batch manifest: {e}", file=sys.stderr)
+        return 1
+
+    static = args.static or not get_llm_config().has_any_key() or is_offline()
+    report = asyncio.run(run_batch(manifest, static, parallel=args.parallel, fail_on=args.fail_on))
+    output = json.dumps(report, indent=2) if args.format == "json" else render_aggregate(report)
+    if args.output:
+        with open(args.output, 'w') as f:
+            f.write(output)
+        print(f"Wrote {args.format} aggregate to {args.output}", file=sys.stderr)
+    else:
+        sys.stdout.write(output if output.endswith('\n') else output + '\n')
+    return 1 if report["totals"]["failed"] or report["totals"]["gate_failed"] else 0
+
+
+def cmd_routes(args: argparse.Namespace) -> int:
+    import time
+
+    from .analysis.callgraph import build_call_graph
+    from .analysis.routes import build_inventory
+    from .main import collect_project_files, run_static_scan
+
+    if args.report:
+        report = load_report(args.report)
+        if not report:
+            print(f"Report not found: {args.report}", file=sys.stderr)
+            return 1
+        target = args.path or report.get("target", "")
+    else:
+        target = args.path or "."
+    target = os.path.abspath(target)
+    try:
+        files = collect_project_files(target)
+    except ValueError as e:
+        print(str(e), file=sys.stderr)
+        return 1
+    if not args.report:
+        report = run_static_scan(f"routes_{int(time.time())}", target)
+
+    entries = build_inventory(build_call_graph(target, files), files, report.get("vulnerabilities", []))
+    if args.unauthenticated:
+        entries = [e for e in entries if e.auth != "authenticated"]
+    if args.json:
+        print(json.dumps([e.to_dict() for e in entries], indent=2))
+        return 0
+
+    for entry in entries:
+        location = f"{os.path.relpath(entry.file_path, target)}:{entry.line_number}"
+        print(f"{', '.join(entry.routes) or '(no route)'}  [{entry.kind}]  {entry.handler}  {location}")
+        print(f"    auth: {entry.auth}" + (f" ({entry.auth_evidence})" if entry.auth_evidence else ""))
+        if entry.findings:
+            listed = ', '.join(f"{f['vuln_id']} {f['vuln_type']} ({f['severity']})" for f in entry.findings[:5])
+            more = f", +{len(entry.findings) - 5} more" if len(entry.findings) > 5 else ""
+            print(f"    findings: {listed}{more}")
+    by_auth = {status: sum(1 for e in entries if e.auth == status) for status in ("unauthenticated", "unknown", "authenticated")}
+    exposed = sum(1 for e in entries if e.auth != "authenticated" and e.findings)
+    print(f"\n{len(entries)} entry points: " + ', '.join(f"{n} {status}" for status, n in by_auth.items() if n)
+          + f"; {exposed} not known to be authenticated reach findings")
+    return 0
+
+
+def cmd_doctor(args: argparse.Namespace) -> int:
+    from .doctor import STATUSES, run_checks
+
+    checks = run_checks(os.path.abspath(args.project), providers=not args.no_providers)
+    failed = any(c.status == "fail" for c in checks)
+    if args.json:
+        print(json.dumps([c.to_dict() for c in checks], indent=2))
+        return 1 if failed else 0
+
+    width = max(len(c.name) for c in checks)
+    for group in dict.fromkeys(c.group for c in checks):
+        print(f"{group}:")
+        for check in (c for c in checks if c.group == group):
+            print(f"  {check.status:<5} {check.name:<{width}}  {check.detail}")
+        print()
+    counts = {status: sum(1 for c in checks if c.status == status) for status in STATUSES}
+    print(', '.join(f"{n} {status}" for status, n in counts.items() if n))
+    return 1 if failed else 0
+
+
+def cmd_schema(args: argparse.Namespace) -> int:
+    with open(SCHEMA_FILE, 'r') as f:
+        sys.stdout.write(f.read())
+    return 0
+
+
+def print_key(key, token: Optional[str] = None):
+    from datetime import datetime
+
+    def when(ts):
+        return datetime.fromtimestamp(ts).strftime("%Y-%m-%d %H:%M") if ts else "-"
+
+    state = "active" if key.active() else ("revoked" if key.revoked_at else "expired")
+    projects = f"  projects {','.join(key.projects)}" if key.projects else ""
+    print(f"{key.key_id}  {key.name:<20} {','.join(key.scopes):<16} {state:<8} expires {when(key.expires_at)}  last used {when(key.last_used_at)}{projects}")
+    if token:
+        print(f"\nToken (shown once, store it now):\n{token}")
+
+
+def cmd_keys(args: argparse.Namespace) -> int:
+    from .auth import AuthError, get_key_store
+
+    store = get_key_store()
+    try:
+        if args.keys_command == "create":
+            key, token = store.create(
+                args.name,
+                args.scope or ["read"],
+                expires_in=args.expires_days * 86400 if args.expires_days else None,
+                cert_subject=args.cert_subject,
+                projects=args.project
+            )
+            print_key(key, token)
+        elif args.keys_command == "rotate":
+            key, token = store.rotate(args.key_id, grace_seconds=args.grace_hours * 3600)
+            print(f"{args.key_id} replaced; it stops working in {args.grace_hours:g}h")
+            print_key(key, token)
+        elif args.keys_command == "revoke":
+            print_key(store.revoke(args.key_id))
+        else:
+            keys = store.list()
+            if not keys:
+                print(f"No API keys in {store.path}")
+            for key in keys:
+                print_key(key)
+    except AuthError as e:
+        print(str(e), file=sys.stderr)
+        return 1
+    return 0
+
+
+def cmd_audit(args: argparse.Namespace) -> int:
+    from datetime import datetime
+
+    from .auth import get_audit_log
+
+    for entry in get_audit_log().tail(args.limit, key_id=args.key):
+        if args.json:
+            print(json.dumps(entry))
+            continue
+        extra = ' '.join(f"{k}={v}" for k, v in entry.items() if k not in ("time", "key_id", "key_name", "action", "status"))
+        who = f"{entry.get('key_name')} ({entry.get('key_id')})" if entry.get("key_id") else "unauthenticated"
+        print(f"{datetime.fromtimestamp(entry['time']):%Y-%m-%d %H:%M:%S}  {entry['status']}  {who:<30} {entry['action']}  {extra}".rstrip())
+    return 0
+
+
+def cmd_triage(args: argparse.Namespace) -> int:
+    from .triage import TriageError, get_triage_store
+
+    store = get_triage_store()
+    try:
+        if args.triage_command == "mark":
+            report, vulnerability = find_finding(args.finding_id, args.report)
+            if not vulnerability:
+                where = f"report {args.report}" if args.report else "any saved report"
+                print(f"Finding {args.finding_id} not found in {where}", file=sys.stderr)
+                return 1
+            verdict = "false_positive" if args.false_positive else "confirmed"
+            decision = store.record(report, vulnerability, verdict, args.reason, decided_by=os.environ.get("USER"))
+            vulnerability["triage"] = {"decision_id": decision.decision_id, "verdict": decision.verdict, "reason": decision.reason}
+            if report.get("gate"):
+                evaluate_gate(report, report["gate"]["fail_on"])
+            save_report(report)
+            print(f"{decision.decision_id}: {args.finding_id} marked {verdict} for {decision.repo}")
+        elif args.triage_command == "forget":
+            decision = store.delete(args.decision_id)
+            print(f"Forgot {decision.decision_id} ({decision.verdict} on {decision.file_path})")
+        else:
+            decisions = store.list(args.repo, args.verdict)
+            if args.json:
+                print(json.dumps([d.to_dict() for d in decisions], indent=2))
+                return 0
+            if not decisions:
+                print(f"No triage decisions in {store.path}")
+            for d in decisions:
+                print(f"{d.decision_id}  {d.verdict:<14} {d.rule_id or d.vuln_type}  {d.file_path}  [{d.repo}]")
+                print(f"    {d.reason}")
+    except TriageError as e:
+        print(str(e), file=sys.stderr)
+        return 1
+    return 0
+
+
+def cmd_selftest(args: argparse.Namespace) -> int:
+    from .selftest import run_selftest
+
+    unknown = [r for r in args.rule or [] if not get_rule(r)]
+    if unknown:
+        print(f"Unknown rule(s): {', '.join(unknown)}", file=sys.stderr)
+        return 1
+
+    report = run_selftest(args.paths or None, rule_ids=args.rule)
+    if args.json:
+        print(json.dumps(report.to_dict(), indent=2))
+        return 0 if report.passed(strict=args.strict) else 1
+
+    for result in sorted(report.rules.values(), key=lambda r: r.rule_id):
+        status = "ok" if result.passed else "FAIL"
+        if not result.covered:
+            status = "no fixtures"
+        print(f"{result.rule_id:<32} {len(result.true_positives):>3} TP {len(result.known_negatives):>3} TN  {status}")
+        for label, locations in (("missed", result.missed), ("false positive", result.false_positives), ("unannotated finding", result.unexpected)):
+            for path, line in locations:
+                print(f"    {label}: {path}:{line}")
+    for path, line, rule_id in report.unknown_rules:
+        print(f"Unknown rule in annotation: {path}:{line} {rule_id}")
+
+    total_tp = sum(len(r.true_positives) for r in report.rules.values())
+    total_tn = sum(len(r.known_negatives) for r in report.rules.values())
+    verdict = "passed" if report.passed(strict=args.strict) else "FAILED"
+    print(f"\nSelftest {verdict}: {len(report.rules)} rules, {len(report.files)} fixture files, {total_tp} expected findings, {total_tn} known negatives")
+    if report.uncovered:
+        print(f"Rules without fixtures: {', '.join(report.uncovered)}")
+    return 0 if report.passed(strict=args.strict) else 1
+
+
+def cmd_benchmark(args: argparse.Namespace) -> int:
+    from .benchmark import BenchmarkError, compare, run_benchmark
+
+    try:
+        result = asyncio.run(run_benchmark(args.dataset, llm=args.llm, model=args.model)).to_dict()
+    except BenchmarkError as e:
+        print(str(e), file=sys.stderr)
+        return 1
+
+    if args.output:
+        with open(args.output, 'w') as f:
+            json.dump(result, f, indent=2)
+    if args.json:
+        print(json.dumps(result, indent=2))
+        return 0
+
+    def percent(value):
+        return f"{value * 100:5.1f}%" if value is not None else "    -"
+
+    def row(label, metrics):
+        print(f"{label:<32} {metrics['tp']:>4} {metrics['fp']:>4} {metrics['fn']:>4} {metrics['tn']:>4}  "
+              f"{percent(metrics['precision'])}  {percent(metrics['recall'])}  {percent(metrics['f1'])}")
+
+    mode = f"llm ({result['model']})" if result["model"] else result["mode"]
+    print(f"Benchmark {result['dataset']} [{result['dataset_format']}], {result['cases']} cases, {mode}, {result['duration']}s\n")
+    print(f"{'':<32} {'TP':>4} {'FP':>4} {'FN':>4} {'TN':>4}  {'prec':>6}  {'recall':>6}  {'F1':>6}")
+    for rule_id, metrics in result["by_rule"].items():
+        row(rule_id, metrics)
+    print()
+    for cwe, metrics in result["by_cwe"].items():
+        row(cwe, metrics)
+    row("overall", result["overall"])
+    if result["unlabeled_findings"]:
+        print(f"\n{result['unlabeled_findings']} finding(s) for CWEs the dataset does not label were not scored")
+
+    if args.compare:
+        with open(args.compare, 'r') as f:
+            previous = json.load(f)
+        changes = compare(result, previous)
+        print(f"\nCompared with {args.compare} (scanner {previous.get('scanner_version')}, {previous.get('model') or previous.get('mode')}):")
+        for scope, metric, before, now in changes:
+            print(f"  {scope:<30} {metric:<9} {percent(before)} -> {percent(now)}")
+        if not changes:
+            print("  no change")
+    return 0
+
+
+def build_parser() -> argparse.ArgumentParser:
+    parser = argparse.ArgumentParser(prog="scanner", description="Agentic Ethical Hacker scanner")
+    parser.add_argument("--offline", action="store_true", help="Make no network calls: static rules only, cached policy; fail if a command needs the network (also OFFLINE=true)")
+    commands = parser.add_subparsers(dest="command", required=True)
+
+    rules = commands.add_parser("rules", help="Browse the static rule catalog and install rule packs")
+    rules_commands = rules.add_subparsers(dest="rules_command", required=True)
+
+    rules_list = rules_commands.add_parser("list", help="List every rule with its default severity")
+    rules_list.add_argument("--json", action="store_true", help="Print rule metadata as JSON")
+    rules_list.set_defaults(func=cmd_rules_list)
+
+    rules_explain = rules_commands.add_parser("explain", help="Show description, example, and remediation for a rule")
+    rules_explain.add_argument("rule_id")
+    rules_explain.add_argument("--json", action="store_true", help="Print rule metadata as JSON")
+    rules_explain.set_defaults(func=cmd_rules_explain)
+
+    rules_install = rules_commands.add_parser("install", help="Install signed rule packs and pin them in the project config")
+    rules_install.add_argument("packs", nargs="*", metavar="PACK", help="<name>[@<version>], git+<repo-url>#<path>[@<ref>], or a local pack directory (default: every pack the project pins)")
+    rules_install.add_argument("--project", default=".", help="Project whose .sastscan.yaml pins the packs (default: current directory)")
+    rules_install.add_argument("--allow-unsigned", action="store_true", help="Install packs without a signature from a trusted key")
+    rules_install.add_argument("--no-pin", action="store_true", help="Install without adding the pack to the project config")
+    rules_install.set_defaults(func=cmd_rules_install)
+
+    rules_packs = rules_commands.add_parser("packs", help="Show the rule packs the project pins and those installed")
+    rules_packs.add_argument("--project", default=".", help="Project directory (default: current directory)")
+    rules_packs.add_argument("--json", action="store_true", help="Print as JSON")
+    rules_packs.set_defaults(func=cmd_rules_packs)
+
+    selftest = commands.add_parser("selftest", help="Check every rule against the annotated fixture corpus (test-vul/)")
+    selftest.add_argument("paths", nargs="*", help="Fixture files or directories (default: test-vul/)")
+    selftest.add_argument("--rule", action="append", help="Only check this rule (repeatable)")
+    selftest.add_argument("--strict", action="store_true", help="Also fail when a rule has no expected findings in the fixtures")
+    selftest.add_argument("--json", action="store_true", help="Print results as JSON")
+    selftest.set_defaults(func=cmd_selftest)
+
+    benchmark = commands.add_parser("benchmark", help="Measure precision/recall per rule on a labeled corpus (OWASP Benchmark, Juliet, or labels manifest)")
+    benchmark.add_argument("dataset", help="Dataset directory")
+    benchmark.add_argument("--llm", action="store_true", help="Run the full agent pipeline instead of the static rules")
+    benchmark.add_argument("--model", help="LLM model to benchmark (with --llm; default: DEFAULT_LLM_MODEL)")
+    benchmark.add_argument("--output", "-o", help="Save the results as JSON for later --compare")
+    benchmark.add_argument("--compare", metavar="RESULTS", help="Show metric changes against a previously saved results file")
+    benchmark.add_argument("--json", action="store_true", help="Print results as JSON")
+    benchmark.set_defaults(func=cmd_benchmark)
+
+    report = commands.add_parser("report", help="Render a saved report as text, JSON, SARIF, or HTML")
+    report.add_argument("report_id", nargs="?", help="Report session id (default: newest report)")
+    report.add_argument("--format", "-f", choices=list(FORMATTERS), default="text", help="Output format")
+    report.add_argument("--output", "-o", help="Write to a file instead of stdout")
+    report.add_argument("--context-lines", "-C", type=int, default=0, metavar="N", help="Show N lines of source around each finding")
+    report.add_argument("--group-by", choices=list(GROUP_BY), help="Group text/HTML findings by owner (last author per git blame) or team (CODEOWNERS)")
+    report.add_argument("--sort", choices=list(SORT_ORDERS), default="severity", help="Order findings by severity or by fix ROI (risk removed per unit of fix effort)")
+    report.add_argument("--team", help="Only findings owned by this CODEOWNERS team or user, e.g. @acme/payments (\"unowned\" for the rest)")
+    report.set_defaults(func=cmd_report)
+
+    scan = commands.add_parser("scan", help="Scan a project and exit non-zero when findings reach the fail-on threshold")
+    scan.add_argument("path", nargs="?", default=".", help="Project directory, a .tar(.gz/.bz2/.xz)/.tgz/.zip source archive, or a repository URL with an optional @ref (default: current directory)")
+    scan.add_argument("--format", "-f", choices=list(FORMATTERS), default="text", help="Output format")
+    scan.add_argument("--output", "-o", help="Write to a file instead of stdout")
+    scan.add_argument("--sort", choices=list(SORT_ORDERS), default="severity", help="Order findings by severity or by fix ROI (risk removed per unit of fix effort)")
+    scan.add_argument("--fail-on", choices=["critical", "high", "medium", "low"], help="Fail on findings at or above this severity (the policy's threshold still applies if stricter)")
+    scan.add_argument("--policy", help="Org policy to inherit: path, http(s) URL, or git+<repo-url>#<path>[@<ref>] (default: POLICY_SOURCE)")
+    scan.add_argument("--static", action="store_true", help="Run the static rules only, even if an LLM API key is configured")
+    scan.add_argument("--max-cost", type=float, metavar="USD", help="Stop making LLM calls once the scan has spent this much; the remaining files get the static rules (continue later with --resume)")
+    scan.add_argument("--resume", metavar="SCAN_ID", help="Continue an interrupted scan, reusing the files it already analyzed")
+    scan.add_argument("--image", metavar="REF", help="Scan the application inside a container image: registry reference, OCI layout directory, or image tarball")
+    scan.add_argument("--new-only", action="store_true", help="Gate only on findings introduced since the merge-base with the main branch")
+    scan.add_argument("--base", metavar="REF", help="Branch to take the merge-base with for --new-only (default: origin/HEAD, origin/main, origin/master, main, or master)")
+    scan.add_argument("--platform", help="Platform to pick from a multi-arch image (default: IMAGE_PLATFORM, linux/amd64)")
+    scan.add_argument("--attest", metavar="FILE", help="Write an in-toto attestation of the scanned commit and result to FILE")
+    scan.add_argument("--sign", action="store_true", help="Sign the attestation with cosign (ATTESTATION_SIGNING_KEY, or keyless)")
+    scan.add_argument("--history-db", metavar="PATH", help="Record the scan in this SQLite history store and check the project's trend_gates against earlier scans in it")
+    scan.add_argument("--history-target", metavar="NAME", help="Name the scans are recorded under in --history-db (default: origin URL#branch of the checkout, or the scanned path)")
+    scan.add_argument("--profile", action="store_true", help="Record CPU and heap profiles and per-stage, per-rule, and per-file timings in the report's profile block")
+    scan.set_defaults(func=cmd_scan)
+
+    routes = commands.add_parser("routes", help="List the HTTP, WebSocket, and gRPC entry points with their auth status and the findings they reach")
+    routes.add_argument("path", nargs="?", help="Project directory (default: current directory, or the target of --report)")
+    routes.add_argument("--report", metavar="REPORT_ID", help="Take findings from a saved report instead of running the static rules")
+    routes.add_argument("--unauthenticated", action="store_true", help="Only entry points not known to be authenticated")
+    routes.add_argument("--json", action="store_true", help="Print the inventory as JSON")
+    routes.set_defaults(func=cmd_routes)
+
+    attest = commands.add_parser("attest", help="Write an in-toto attestation for a saved report")
+    attest.add_argument("report_id", nargs="?", help="Report session id (default: newest report)")
+    attest.add_argument("--output", "-o", help="Attestation file (default: <report-id>.intoto.json)")
+    attest.add_argument("--sign", action="store_true", help="Sign the attestation with cosign (ATTESTATION_SIGNING_KEY, or keyless)")
+    attest.set_defaults(func=cmd_attest)
+
+    fix = commands.add_parser("fix", help="Show or apply the patches generated for a report")
+    fix.add_argument("report_id", nargs="?", help="Report session id (default: newest report)")
+    fix.add_argument("--finding", action="append", help="Only this finding id (repeatable)")
+    fix.add_argument("--generate", action="store_true", help="Generate patches with the LLM for findings that have none")
+    fix.add_argument("--apply", action="store_true", help="Apply patches and commit each fix on a new branch")
+    fix.add_argument("--branch", default="scanner/fixes", help="Branch to create for --apply (default: scanner/fixes)")
+    fix.add_argument("--no-validate", action="store_true", help="Skip the check before committing each fix (gofmt/parse, or go build/test with CODE_EXECUTION=sandbox|host)")
+    fix.add_argument("--open-pr", action="store_true", help="Push the branch and open a GitHub PR / GitLab MR (with --apply)")
+    fix.add_argument("--remote", default="origin", help="Git remote to push to for --open-pr (default: origin)")
+    fix.add_argument("--min-confidence", type=float, default=0.5, help="Skip patches below this confidence (default: 0.5)")
+    fix.set_defaults(func=cmd_fix)
+
+    export = commands.add_parser("export", help="File confirmed findings in an issue tracker, update their issues, and close the issues of fixed findings")
+    export.add_argument("report_id", nargs="?", help="Report session id (default: newest report)")
+    export.add_argument("--tracker", choices=["jira", "webhook"], required=True, help="Jira (JIRA_URL, JIRA_PROJECT, JIRA_API_TOKEN) or a generic webhook (TRACKER_WEBHOOK_URL)")
+    export.add_argument("--all", action="store_true", help="Export every finding not triaged as a false positive, not only confirmed ones")
+    export.add_argument("--dry-run", action="store_true", help="Show what would be created, updated, and closed; the tracker is searched but not changed")
+    export.add_argument("--json", action="store_true", help="Print the actions as JSON")
+    export.set_defaults(func=cmd_export)
+
+    daemon = commands.add_parser("daemon", help="Pull and scan configured repositories on a cron schedule")
+    daemon.add_argument("--config", "-c", default="daemon.yaml", help="Daemon config file (default: daemon.yaml)")
+    daemon.add_argument("--once", action="store_true", help="Scan every configured repo once and exit")
+    daemon.set_defaults(func=cmd_daemon)
+
+    batch = commands.add_parser("batch", help="Scan a manifest of repositories and print an organization-level aggregate")
+    batch.add_argument("manifest", help="YAML or JSON manifest with a repos list (url with optional ref, or path)")
+    batch.add_argument("--parallel", "-j", type=int, metavar="N", help="Repos to scan at once (default: the manifest's parallel, or 1)")
+    batch.add_argument("--static", action="store_true", help="Run the static rules only, even if an LLM API key is configured")
+    batch.add_argument("--fail-on", choices=["critical", "high", "medium", "low"], help="Exit non-zero if any repo has findings at or above this severity")
+    batch.add_argument("--format", "-f", choices=["text", "json"], default="text", help="Aggregate output format")
+    batch.add_argument("--output", "-o", help="Write the aggregate to a file instead of stdout")
+    batch.set_defaults(func=cmd_batch)
+
+    doctor = commands.add_parser("doctor", help="Check LLM provider connectivity, cache health, and config validity")
+    doctor.add_argument("--project", default=".", help="Project whose .sastscan.yaml and rule packs to check (default: current directory)")
+    doctor.add_argument("--no-providers", action="store_true", help="Skip the requests to the LLM and embedding providers")
+    doctor.add_argument("--json", action="store_true", help="Print the checks as JSON")
+    doctor.set_defaults(func=cmd_doctor)
+
+    schema = commands.add_parser("schema", help="Print the JSON Schema for the native report format")
+    schema.set_defaults(func=cmd_schema)
+
+    explain = commands.add_parser("explain", help="Explain why a reported finding is exploitable and how to fix it")
+    explain.add_argument("finding_id", help="Finding id from a saved report, e.g. VULN-0001 or SAST-0001")
+    explain.add_argument("--report", help="Report session id (default: newest report containing the finding)")
+    explain.add_argument("--refresh", action="store_true", help="Ignore the cached explanation and ask the LLM again")
+    explain.add_argument("--json", action="store_true", help="Print the explanation as JSON")
+    explain.set_defaults(func=cmd_explain)
+
+    triage = commands.add_parser("triage", help="Record confirmed / false-positive decisions that later scans of the repo reuse")
+    triage_commands = triage.add_subparsers(dest="triage_command", required=True)
+    triage_mark = triage_commands.add_parser("mark", help="Record a decision on a reported finding")
+    triage_mark.add_argument("finding_id", help="Finding id from a saved report, e.g. VULN-0001 or SAST-0001")
+    verdict = triage_mark.add_mutually_exclusive_group(required=True)
+    verdict.add_argument("--false-positive", "--fp", action="store_true", help="Not a real issue; stop blocking on it")
+    verdict.add_argument("--confirm", action="store_true", help="A real issue")
+    triage_mark.add_argument("--reason", required=True, help="Why; shown to the analyzer in later scans")
+    triage_mark.add_argument("--report", help="Report session id (default: newest report containing the finding)")
+    triage_list = triage_commands.add_parser("list", help="List recorded decisions")
+    triage_list.add_argument("--repo", help="Only decisions for this project id or scanned directory")
+    triage_list.add_argument("--verdict", choices=["confirmed", "false_positive"])
+    triage_list.add_argument("--json", action="store_true", help="Print decisions as JSON")
+    triage_forget = triage_commands.add_parser("forget", help="Delete a decision")
+    triage_forget.add_argument("decision_id")
+    triage.set_defaults(func=cmd_triage)
+
+    keys = commands.add_parser("keys", help="Manage API keys for server mode (AUTH_ENABLED=true)")
+    keys_commands = keys.add_subparsers(dest="keys_command", required=True)
+    keys_commands.add_parser("list", help="List keys with scopes, expiry, and last use")
+    keys_create = keys_commands.add_parser("create", help="Create a key and print its token once")
+    keys_create.add_argument("name", help="Who or what uses the key, e.g. ci-pipeline")
+    keys_create.add_argument("--scope", action="append", choices=["scan", "read", "admin"], help="Grant a scope (repeatable; default: read)")
+    keys_create.add_argument("--expires-days", type=float, help="Expire the key after this many days")
+    keys_create.add_argument("--cert-subject", help="Also accept this mTLS client certificate subject for the key")
+    keys_create.add_argument("--project", action="append", help="Restrict the key to this project id (repeatable; default: all)")
+    keys_rotate = keys_commands.add_parser("rotate", help="Issue a replacement key; the old one expires after a grace period")
+    keys_rotate.add_argument("key_id")
+    keys_rotate.add_argument("--grace-hours", type=float, default=24, help="Keep the old key working this long (default: 24)")
+    keys_revoke = keys_commands.add_parser("revoke", help="Revoke a key immediately")
+    keys_revoke.add_argument("key_id")
+    keys.set_defaults(func=cmd_keys)
+
+    audit = commands.add_parser("audit", help="Show the server-mode audit log of who requested which scans")
+    audit.add_argument("--limit", "-n", type=int, default=50, help="Number of entries (default: 50)")
+    audit.add_argument("--key", help="Only entries for this key id")
+    audit.add_argument("--json", action="store_true", help="Print entries as JSON lines")
+    audit.set_defaults(func=cmd_audit)
+
+    return parser
+
+
+def main(argv: Optional[List[str]] = None) -> int:
+    args = build_parser().parse_args(argv)
+    if args.offline:
+        from .config.settings import get_settings
+
+        get_settings().offline = True
+    try:
+        return args.func(args)
+    except OfflineError as e:
+        print(str(e), file=sys.stderr)
+        return 1
+
+
+if __name__ == "__main__":
+    sys.exit(main())
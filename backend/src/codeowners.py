@@ -0,0 +1,177 @@
+"""
+Code owners - Attach the responsible team from CODEOWNERS to each finding, for per-team reports and notifications
+Patterns follow GitHub/GitLab CODEOWNERS rules: gitignore-style globs, and the last matching line wins.
+"""
+
+import logging
+import os
+import re
+from dataclasses import dataclass
+from typing import Any, Dict, List, Optional, Sequence
+
+logger = logging.getLogger(__name__)
+
+# searched in GitHub's order; GitLab also reads .gitlab/CODEOWNERS
+CODEOWNERS_PATHS = ('.github/CODEOWNERS', 'CODEOWNERS', 'docs/CODEOWNERS', '.gitlab/CODEOWNERS')
+UNOWNED = "unowned"
+GITLAB_SECTION = re.compile(r'^\^?\[[^\]]+\](?:\[\d+\])?(?:\s+(.*))?$')
+
+
+@dataclass
+class OwnerRule:
+    pattern: str
+    owners: List[str]
+    regex: re.Pattern
+    line: int
+
+
+def compile_pattern(pattern: str) -> re.Pattern:
+    """A CODEOWNERS glob as a regex over repo-relative paths: a leading or inner slash anchors it to the
+    root, a bare name matches at any depth, and a matched directory covers everything beneath it"""
+    anchored = pattern.startswith('/') or '/' in pattern.rstrip('/')
+    body = pattern.strip('/')
+    # "docs/*" owns the files directly in docs/, not its subdirectories (GitHub semantics)
+    shallow = body.endswith('/*')
+
+    parts = []
+    i = 0
+    while i < len(body):
+        if body.startswith('**/', i):
+            parts.append('(?:.*/)?')
+            i += 3
+        elif body.startswith('**', i):
+            parts.append('.*')
+            i += 2
+        elif body[i] == '*':
+            parts.append('[^/]*')
+            i += 1
+        elif body[i] == '?':
+            parts.append('[^/]')
+            i += 1
+        elif body[i] == '\\' and i + 1 < len(body):
+            parts.append(re.escape(body[i + 1]))
+            i += 2
+        else:
+            parts.append(re.escape(body[i]))
+            i += 1
+
+    prefix = '' if anchored else '(?:.*/)?'
+    suffix = '' if shallow else '(?:/.*)?'
+    return re.compile(f'^{prefix}{"".join(parts)}{suffix}$')
+
+
+class CodeOwners:
+
+    def __init__(self, rules: List[OwnerRule], path: Optional[str] = None, root: Optional[str] = None):
+        self.rules = rules
+        self.path = path
+        self.root = root
+
+    @classmethod
+    def parse(cls, text: str, path: Optional[str] = None, root: Optional[str] = None) -> "CodeOwners":
+        rules = []
+        section_owners: List[str] = []
+        for number, raw in enumerate(text.splitlines(), 1):
+            line = re.sub(r'(?<!\\)#.*$', '', raw).strip()
+            if not line:
+                continue
+            section = GITLAB_SECTION.match(line)
+            if section:
+                # GitLab: "[Section] @default-owners" applies to entries without their own owners
+                section_owners = (section.group(1) or '').split()
+                continue
+            fields = re.split(r'(?<!\\)\s+', line)
+            pattern, owners = fields[0], fields[1:] or section_owners
+            try:
+                rules.append(OwnerRule(pattern, owners, compile_pattern(pattern), number))
+            except re.error as e:
+                logger.warning(f"Ignoring CODEOWNERS line {number} ({pattern}): {e}")
+        return cls(rules, path, root)
+
+    def owners_of(self, rel_path: str) -> List[str]:
+        """Owners of a repo-relative path; a matching line without owners leaves the path unowned"""
+        rel_path = rel_path.replace(os.sep, '/').lstrip('/')
+        for rule in reversed(self.rules):
+            if rule.regex.match(rel_path):
+                return list(rule.owners)
+        return []
+
+    def owners_of_file(self, file_path: str) -> List[str]:
+        if not self.root or not file_path or file_path.startswith('<'):
+            return []
+        rel_path = os.path.relpath(os.path.abspath(file_path), self.root)
+        if rel_path.startswith('..'):
+            return []
+        return self.owners_of(rel_path)
+
+
+def find_repo_root(target: str) -> str:
+    directory = os.path.abspath(target if os.path.isdir(target) else os.path.dirname(target))
+    start = directory
+    while True:
+        if os.path.exists(os.path.join(directory, '.git')):
+            return directory
+        parent = os.path.dirname(directory)
+        if parent == directory:
+            # not a checkout (an extracted archive or image): CODEOWNERS at the target itself still counts
+            return start
+        directory = parent
+
+
+def load_codeowners(target: Optional[str]) -> Optional[CodeOwners]:
+    if not target or not os.path.exists(target):
+        return None
+    root = find_repo_root(target)
+    for name in CODEOWNERS_PATHS:
+        path = os.path.join(root, name)
+        if os.path.isfile(path):
+            try:
+                with open(path, 'r', encoding='utf-8', errors='ignore') as f:
+                    return CodeOwners.parse(f.read(), path, root)
+            except OSError as e:
+                logger.warning(f"Could not read {path}: {e}")
+                return None
+    return None
+
+
+def annotate_code_owners(vulnerabilities: Sequence[Any], codeowners: CodeOwners) -> int:
+    """Record each finding's CODEOWNERS entry. Returns the number of findings with an owner."""
+    owned = 0
+    for vuln in vulnerabilities:
+        vuln.code_owners = codeowners.owners_of_file(vuln.file_path)
+        owned += bool(vuln.code_owners)
+    return owned
+
+
+def finding_teams(vuln: Dict[str, Any]) -> List[str]:
+    return vuln.get("code_owners") or [UNOWNED]
+
+
+def owned_by(vuln: Dict[str, Any], teams: Sequence[str]) -> bool:
+    wanted = {team.lower() for team in teams}
+    return any(team.lower() in wanted for team in finding_teams(vuln))
+
+
+def team_summary(vulnerabilities: List[Dict[str, Any]]) -> Dict[str, Dict[str, Any]]:
+    summary: Dict[str, Dict[str, Any]] = {}
+    for vuln in vulnerabilities:
+        for team in finding_teams(vuln):
+            entry = summary.setdefault(team, {"findings": 0, "by_severity": {}})
+            entry["findings"] += 1
+            severity = vuln.get("severity", "unknown")
+            entry["by_severity"][severity] = entry["by_severity"].get(severity, 0) + 1
+    return summary
+
+
+def team_report(report: Dict[str, Any], teams: Sequence[str]) -> Dict[str, Any]:
+    """A copy of the report with only the findings those teams own ("unowned" for the rest)"""
+    findings = [v for v in report.get("vulnerabilities", []) if owned_by(v, teams)]
+    by_severity: Dict[str, int] = {}
+    for vuln in findings:
+        by_severity[vuln.get("severity", "unknown")] = by_severity.get(vuln.get("severity", "unknown"), 0) + 1
+    return {
+        **report,
+        "vulnerabilities": findings,
+        "summary": {**report.get("summary", {}), "total_vulnerabilities": len(findings), "by_severity": by_severity},
+        "team": ', '.join(teams)
+    }
@@ -0,0 +1,76 @@
+"""
+Compliance mapping - Findings grouped by OWASP Top 10 (2021) category and CWE Top 25 (2023) entry, by CWE
+Categories follow OWASP's CWE list for each Top 10 category, trimmed to the CWEs findings commonly carry. A few
+CWEs the rules report that OWASP does not list are placed with the closest listed weakness: CWE-943 (NoSQL
+injection) and CWE-1427 (prompt injection) under Injection, CWE-1385 (WebSocket origin) with CWE-346, CWE-732
+(permissions) with CWE-276, and CWE-693 (missing security headers) under Misconfiguration. Findings with any
+other CWE count as "other". The mapping only says which categories findings fall under. It does not say
+whether a requirement is met.
+"""
+
+from typing import Any, Dict, List
+
+from .config.policy import SEVERITIES
+
+OWASP_TOP_10 = {
+    "A01:2021": ("Broken Access Control", {"CWE-22", "CWE-23", "CWE-200", "CWE-276", "CWE-284", "CWE-285", "CWE-352",
+                                          "CWE-377", "CWE-601", "CWE-639", "CWE-668", "CWE-732", "CWE-862", "CWE-863"}),
+    "A02:2021": ("Cryptographic Failures", {"CWE-261", "CWE-319", "CWE-321", "CWE-326", "CWE-327", "CWE-328",
+                                           "CWE-330", "CWE-338", "CWE-916"}),
+    "A03:2021": ("Injection", {"CWE-74", "CWE-77", "CWE-78", "CWE-79", "CWE-89", "CWE-90", "CWE-94", "CWE-95",
+                              "CWE-113", "CWE-643", "CWE-917", "CWE-943", "CWE-1427"}),
+    "A04:2021": ("Insecure Design", {"CWE-209", "CWE-256", "CWE-434", "CWE-501", "CWE-522", "CWE-598", "CWE-799",
+                                    "CWE-840"}),
+    "A05:2021": ("Security Misconfiguration", {"CWE-16", "CWE-611", "CWE-614", "CWE-693", "CWE-942", "CWE-1004"}),
+    "A06:2021": ("Vulnerable and Outdated Components", {"CWE-937", "CWE-1035", "CWE-1104"}),
+    "A07:2021": ("Identification and Authentication Failures", {"CWE-287", "CWE-295", "CWE-297", "CWE-306",
+                                                               "CWE-307", "CWE-346", "CWE-384", "CWE-613",
+                                                               "CWE-798", "CWE-1385"}),
+    "A08:2021": ("Software and Data Integrity Failures", {"CWE-345", "CWE-494", "CWE-502", "CWE-829", "CWE-915"}),
+    "A09:2021": ("Security Logging and Monitoring Failures", {"CWE-117", "CWE-223", "CWE-532", "CWE-778"}),
+    "A10:2021": ("Server-Side Request Forgery", {"CWE-918"}),
+}
+
+CWE_TOP_25 = [
+    "CWE-787", "CWE-79", "CWE-89", "CWE-416", "CWE-78", "CWE-20", "CWE-125", "CWE-22", "CWE-352", "CWE-434",
+    "CWE-862", "CWE-476", "CWE-287", "CWE-190", "CWE-502", "CWE-77", "CWE-119", "CWE-798", "CWE-918", "CWE-306",
+    "CWE-362", "CWE-269", "CWE-94", "CWE-863", "CWE-276",
+]
+
+OTHER = "other"
+
+
+def normalize_cwe(cwe_id: Any) -> str:
+    cwe = str(cwe_id or "").strip().upper()
+    return f"CWE-{cwe}" if cwe.isdigit() else cwe
+
+
+def owasp_category(cwe_id: Any) -> str:
+    cwe = normalize_cwe(cwe_id)
+    return next((category for category, (_, cwes) in OWASP_TOP_10.items() if cwe in cwes), OTHER)
+
+
+def compliance_summary(vulnerabilities: List[Dict[str, Any]]) -> Dict[str, Any]:
+    """Finding counts by severity per OWASP Top 10 category (every category listed, "other" last when used)
+    and per CWE Top 25 entry hit, ranked as in the list"""
+    def counts() -> Dict[str, Any]:
+        return {"findings": 0, "by_severity": {s: 0 for s in SEVERITIES}, "cwes": []}
+
+    owasp = {category: {"name": name, **counts()} for category, (name, _) in OWASP_TOP_10.items()}
+    top_25: Dict[str, Dict[str, Any]] = {}
+    for vuln in vulnerabilities:
+        cwe = normalize_cwe(vuln.get("cwe_id"))
+        category = owasp_category(cwe)
+        entries = [owasp.setdefault(category, {"name": "Not in the OWASP Top 10", **counts()})]
+        if cwe in CWE_TOP_25:
+            entries.append(top_25.setdefault(cwe, {"rank": CWE_TOP_25.index(cwe) + 1, **counts()}))
+        for entry in entries:
+            entry["findings"] += 1
+            if vuln.get("severity") in entry["by_severity"]:
+                entry["by_severity"][vuln["severity"]] += 1
+            if cwe and cwe not in entry["cwes"]:
+                entry["cwes"].append(cwe)
+    return {
+        "owasp_top_10": owasp,
+        "cwe_top_25": dict(sorted(top_25.items(), key=lambda item: item[1]["rank"]))
+    }
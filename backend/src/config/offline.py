@@ -0,0 +1,20 @@
+"""
+Offline mode - Guarantee a run makes no network calls, for air-gapped environments
+Enabled with `scanner --offline` or OFFLINE=true: scans use the static rules and their bundled metadata,
+remote policies come from the local cache, and features that need the network fail with OfflineError.
+"""
+
+from .settings import get_settings
+
+
+class OfflineError(RuntimeError):
+    pass
+
+
+def is_offline() -> bool:
+    return get_settings().offline
+
+
+def require_network(feature: str):
+    if is_offline():
+        raise OfflineError(f"{feature} needs network access, which offline mode (--offline / OFFLINE=true) forbids")
@@ -0,0 +1,203 @@
+"""
+Org policy - Central scanner policy that project configs inherit from and may only tighten
+"""
+
+import hashlib
+import logging
+import os
+import re
+import subprocess
+import time
+from dataclasses import dataclass, field
+from typing import Any, Dict, List, Optional
+
+import yaml
+
+from ..isolation import git_command
+from .offline import is_offline
+from .settings import get_settings
+
+logger = logging.getLogger(__name__)
+
+SEVERITIES = ('critical', 'high', 'medium', 'low')
+WINDOW_UNITS = {'h': 3600, 'd': 86400, 'w': 7 * 86400}
+
+
+class PolicyError(ValueError):
+    pass
+
+
+def severity_rank(severity: Optional[str]) -> int:
+    """0 is the most severe; unknown severities rank below low"""
+    return SEVERITIES.index(severity) if severity in SEVERITIES else len(SEVERITIES)
+
+
+def cache_path(source: str) -> str:
+    directory = os.path.expanduser(get_settings().policy_cache_dir)
+    return os.path.join(directory, hashlib.sha1(source.encode()).hexdigest()[:16])
+
+
+def fetch_git(source: str, directory: str) -> str:
+    """git+<repo-url>#<path>[@<ref>]: shallow clone of the policy repo, kept in the cache"""
+    repo, _, path = source[len("git+"):].partition('#')
+    path, _, ref = path.partition('@')
+    if not repo or not path:
+        raise PolicyError(f"Git policy source needs a file path: git+<repo-url>#<path>[@<ref>], got {source}")
+
+    checkout = f"{directory}.git"
+
+    def git(*args: str, cwd: Optional[str] = None):
+        result = subprocess.run(git_command(args), cwd=cwd, capture_output=True, text=True, timeout=120)
+        if result.returncode != 0:
+            raise PolicyError(f"git {args[0]} {repo} failed: {result.stderr.strip()}")
+
+    if os.path.isdir(os.path.join(checkout, '.git')):
+        git('fetch', '--quiet', '--depth', '1', 'origin', ref or 'HEAD', cwd=checkout)
+        git('reset', '--quiet', '--hard', 'FETCH_HEAD', cwd=checkout)
+    else:
+        git('clone', '--quiet', '--depth', '1', *(['--branch', ref] if ref else []), repo, checkout)
+
+    with open(os.path.join(checkout, path), 'r') as f:
+        return f.read()
+
+
+def fetch_url(source: str) -> str:
+    import httpx
+
+    response = httpx.get(source, timeout=30, follow_redirects=True)
+    if response.status_code >= 400:
+        raise PolicyError(f"Fetching policy {source} failed: HTTP {response.status_code}")
+    return response.text
+
+
+def fetch_policy_text(source: str, base_dir: Optional[str] = None) -> str:
+    """Read a policy from a local path, an http(s) URL, or a git repo; remote copies are cached and
+    the cached copy is used when the source is unreachable (or, in offline mode, always)"""
+    if not source.startswith(("http://", "https://", "git+")):
+        path = os.path.join(base_dir or os.getcwd(), os.path.expanduser(source))
+        try:
+            with open(path, 'r') as f:
+                return f.read()
+        except OSError as e:
+            raise PolicyError(f"Cannot read policy {path}: {e}")
+
+    cached = cache_path(source)
+    if is_offline() and not os.path.exists(cached):
+        raise PolicyError(f"Policy {source} is remote and not cached; fetch it once online or point at a local copy for offline runs")
+    fresh = os.path.exists(cached) and (is_offline() or time.time() - os.path.getmtime(cached) < get_settings().policy_cache_seconds)
+    if not fresh:
+        try:
+            os.makedirs(os.path.dirname(cached), exist_ok=True)
+            text = fetch_git(source, cached) if source.startswith("git+") else fetch_url(source)
+            with open(cached, 'w') as f:
+                f.write(text)
+            return text
+        except Exception as e:
+            if not os.path.exists(cached):
+                raise PolicyError(f"Cannot fetch policy {source}: {e}")
+            logger.warning(f"Cannot refresh policy {source} ({e}); using the cached copy")
+
+    with open(cached, 'r') as f:
+        return f.read()
+
+
+@dataclass
+class TrendGate:
+    """Severity budget: findings at or above severity may grow by at most max_increase over the window,
+    measured against the scan history rather than as an absolute threshold"""
+    severity: str
+    window: str = "7d"
+    max_increase: int = 0
+
+    @property
+    def window_seconds(self) -> int:
+        match = re.match(r'^(\d+)([hdw])$', self.window)
+        return int(match.group(1)) * WINDOW_UNITS[match.group(2)]
+
+    @classmethod
+    def from_dict(cls, data: Any, source: str) -> 'TrendGate':
+        if not isinstance(data, dict):
+            raise PolicyError(f"Invalid trend gate {data!r} in {source}: expected a mapping")
+        severity = str(data.get('severity', '')).lower()
+        if severity not in SEVERITIES:
+            raise PolicyError(f"Invalid trend gate severity {severity!r} in {source}: must be one of {', '.join(SEVERITIES)}")
+        window = str(data.get('window', cls.window)).strip().lower()
+        if not re.match(r'^[1-9]\d*[hdw]$', window):
+            raise PolicyError(f"Invalid trend gate window {window!r} in {source}: use hours, days, or weeks, e.g. 24h, 7d, 2w")
+        try:
+            max_increase = int(data.get('max_increase', cls.max_increase))
+        except (TypeError, ValueError):
+            max_increase = -1
+        if max_increase < 0:
+            raise PolicyError(f"Invalid trend gate max_increase {data.get('max_increase')!r} in {source}: must be 0 or more")
+        return cls(severity=severity, window=window, max_increase=max_increase)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {"severity": self.severity, "window": self.window, "max_increase": self.max_increase}
+
+
+@dataclass
+class Policy:
+    source: str
+    required_rules: List[str] = field(default_factory=list)  # ["all"] locks every rule on
+    fail_on: Optional[str] = None
+    severity_overrides: Dict[str, str] = field(default_factory=dict)  # minimum severities
+    sensitive_field_names: List[str] = field(default_factory=list)
+    trend_gates: List[TrendGate] = field(default_factory=list)
+
+    @classmethod
+    def from_dict(cls, data: Dict[str, Any], source: str) -> 'Policy':
+        required = data.get('required_rules') or []
+        if isinstance(required, str):
+            required = [required]
+
+        fail_on = str(data['fail_on']).lower() if data.get('fail_on') else None
+        if fail_on and fail_on not in SEVERITIES:
+            raise PolicyError(f"Invalid fail_on {fail_on!r} in policy {source}")
+
+        overrides = {}
+        for rule_id, severity in (data.get('severity_overrides') or {}).items():
+            severity = str(severity).lower()
+            if severity not in SEVERITIES:
+                raise PolicyError(f"Invalid severity {rule_id}={severity} in policy {source}")
+            overrides[str(rule_id)] = severity
+
+        return cls(
+            source=source,
+            required_rules=[str(r) for r in required],
+            fail_on=fail_on,
+            severity_overrides=overrides,
+            sensitive_field_names=list(data.get('sensitive_field_names') or []),
+            trend_gates=[TrendGate.from_dict(gate, f"policy {source}") for gate in data.get('trend_gates') or []]
+        )
+
+    def requires(self, rule_id: str) -> bool:
+        return "all" in self.required_rules or rule_id in self.required_rules
+
+    def floor(self, rule_id: str) -> Optional[str]:
+        """Lowest severity a project may give a rule: the policy's override, or the rule's default if required"""
+        from ..analysis.rules import get_rule
+
+        rule = get_rule(rule_id)
+        candidates = [self.severity_overrides.get(rule_id)]
+        if rule and self.requires(rule_id):
+            candidates.append(rule.severity)
+        candidates = [c for c in candidates if c]
+        return min(candidates, key=severity_rank) if candidates else None
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "source": self.source,
+            "required_rules": self.required_rules,
+            "fail_on": self.fail_on,
+            "severity_overrides": self.severity_overrides,
+            "sensitive_field_names": self.sensitive_field_names,
+            "trend_gates": [gate.to_dict() for gate in self.trend_gates]
+        }
+
+
+def load_policy(source: str, base_dir: Optional[str] = None) -> Policy:
+    data = yaml.safe_load(fetch_policy_text(source, base_dir)) or {}
+    if not isinstance(data, dict):
+        raise PolicyError(f"Invalid policy {source}: expected a mapping at the top level")
+    return Policy.from_dict(data, source)
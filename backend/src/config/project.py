@@ -0,0 +1,254 @@
+"""
+Project configuration - Per-project scanner settings loaded from .sastscan.yaml
+"""
+
+import logging
+import os
+import re
+from dataclasses import dataclass, field
+from typing import Any, Dict, List, Optional
+
+import yaml
+
+from .policy import SEVERITIES, Policy, PolicyError, TrendGate, load_policy, severity_rank
+from .settings import get_settings
+
+logger = logging.getLogger(__name__)
+
+PROJECT_CONFIG_NAMES = ('.sastscan.yaml', '.sastscan.yml')
+
+
+@dataclass
+class ProjectConfig:
+    path: Optional[str] = None
+    severity_overrides: Dict[str, str] = field(default_factory=dict)
+    sensitive_field_names: Optional[List[str]] = None
+    disabled_rules: List[str] = field(default_factory=list)
+    fail_on: Optional[str] = None
+    interop: List[Any] = field(default_factory=list)  # InteropPoints for cross-language taint
+    policy: Optional[Policy] = None
+    policy_violations: List[str] = field(default_factory=list)  # project settings the policy overruled
+    rule_packs: List[str] = field(default_factory=list)  # pinned pack specs, e.g. acme-go@1.2.0
+    packs: List[Any] = field(default_factory=list)  # InstalledPacks loaded for the pins
+    pack_errors: List[str] = field(default_factory=list)
+    critical_paths: List[str] = field(default_factory=list)  # CODEOWNERS-style globs, e.g. payments/
+    trend_gates: List[TrendGate] = field(default_factory=list)  # severity budgets checked against scan history
+
+    @classmethod
+    def from_dict(cls, data: Dict[str, Any], path: Optional[str] = None) -> 'ProjectConfig':
+        overrides = {}
+        for rule_id, severity in (data.get('severity_overrides') or {}).items():
+            severity = str(severity).lower()
+            if severity not in SEVERITIES:
+                logger.warning(f"Ignoring severity override {rule_id}={severity}: must be one of {', '.join(SEVERITIES)}")
+                continue
+            overrides[str(rule_id)] = severity
+
+        fail_on = str(data['fail_on']).lower() if data.get('fail_on') else None
+        if fail_on and fail_on not in SEVERITIES:
+            logger.warning(f"Ignoring fail_on={fail_on}: must be one of {', '.join(SEVERITIES)}")
+            fail_on = None
+
+        from ..analysis.interop import InteropPoint
+
+        interop = []
+        for entry in data.get('interop') or []:
+            try:
+                interop.append(InteropPoint.from_dict(entry if isinstance(entry, dict) else {}))
+            except (ValueError, re.error) as e:
+                logger.warning(f"Ignoring interop point: {e}")
+
+        trend_gates = []
+        for entry in data.get('trend_gates') or []:
+            try:
+                trend_gates.append(TrendGate.from_dict(entry, path or "project config"))
+            except PolicyError as e:
+                logger.warning(f"Ignoring trend gate: {e}")
+
+        return cls(
+            path=path,
+            severity_overrides=overrides,
+            sensitive_field_names=data.get('sensitive_field_names'),
+            disabled_rules=[str(r) for r in data.get('disabled_rules') or []],
+            fail_on=fail_on,
+            interop=interop,
+            rule_packs=[str(p) for p in data.get('rule_packs') or []],
+            critical_paths=[str(p) for p in data.get('critical_paths') or []],
+            trend_gates=trend_gates
+        )
+
+    def enforce(self, policy: Policy):
+        """Inherit from an org policy: project settings may tighten it but never loosen it"""
+        from ..analysis.rules import get_rule
+        from ..analysis.rules.logs import DEFAULT_SENSITIVE_FIELD_NAMES
+
+        self.policy = policy
+
+        for rule_id in [r for r in self.disabled_rules if policy.requires(r)]:
+            self.disabled_rules.remove(rule_id)
+            self.policy_violations.append(f"{rule_id} is required by policy and cannot be disabled")
+
+        for rule_id, severity in policy.severity_overrides.items():
+            rule = get_rule(rule_id) or next((r for r in self.pack_rules if r.rule_id == rule_id), None)
+            if rule_id not in self.severity_overrides and rule and severity_rank(severity) < severity_rank(rule.severity):
+                self.severity_overrides[rule_id] = severity
+
+        for rule_id, severity in list(self.severity_overrides.items()):
+            floor = policy.floor(rule_id)
+            if floor and severity_rank(severity) > severity_rank(floor):
+                self.severity_overrides[rule_id] = floor
+                self.policy_violations.append(f"{rule_id} cannot be lowered below {floor} (project set {severity})")
+
+        if policy.fail_on:
+            if self.fail_on and severity_rank(self.fail_on) < severity_rank(policy.fail_on):
+                self.policy_violations.append(f"fail_on cannot be looser than {policy.fail_on} (project set {self.fail_on})")
+                self.fail_on = policy.fail_on
+            self.fail_on = self.fail_on or policy.fail_on
+
+        # every gate applies, so a project can add budgets but not drop the policy's
+        self.trend_gates = policy.trend_gates + [g for g in self.trend_gates if g not in policy.trend_gates]
+
+        if policy.sensitive_field_names:
+            names = self.sensitive_field_names or get_settings().sensitive_field_names or DEFAULT_SENSITIVE_FIELD_NAMES
+            self.sensitive_field_names = list(dict.fromkeys(list(names) + policy.sensitive_field_names))
+
+        for violation in self.policy_violations:
+            logger.warning(f"Policy {policy.source}: {violation}")
+
+    def load_rule_packs(self, base_dir: str):
+        from ..rulepacks import load_pinned_packs
+
+        self.packs, self.pack_errors = load_pinned_packs(self.rule_packs, base_dir)
+
+    @property
+    def pack_rules(self) -> List[Any]:
+        return [rule for pack in self.packs for rule in pack.rules]
+
+    def apply_severity(self, rule_id: Optional[str], severity: str) -> str:
+        if rule_id and rule_id in self.severity_overrides:
+            return self.severity_overrides[rule_id]
+        return severity
+
+    def critical_path(self, file_path: str, root: str) -> Optional[str]:
+        """The critical_paths pattern the file falls under; patterns are relative to the directory of the
+        config file, or to root without one"""
+        if not self.critical_paths or not file_path or file_path.startswith('<'):
+            return None
+        from ..codeowners import compile_pattern
+
+        base = os.path.dirname(self.path) if self.path else root
+        rel_path = os.path.relpath(os.path.abspath(file_path), os.path.abspath(base)).replace(os.sep, '/')
+        if rel_path.startswith('..'):
+            return None
+        return next((p for p in self.critical_paths if compile_pattern(p).match(rel_path)), None)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "path": self.path,
+            "severity_overrides": self.severity_overrides,
+            "sensitive_field_names": self.sensitive_field_names,
+            "disabled_rules": self.disabled_rules,
+            "fail_on": self.fail_on,
+            "interop": [point.to_dict() for point in self.interop],
+            "rule_packs": [pack.to_dict() for pack in self.packs],
+            "critical_paths": self.critical_paths,
+            "trend_gates": [gate.to_dict() for gate in self.trend_gates],
+            "rule_pack_errors": self.pack_errors,
+            "policy": {**self.policy.to_dict(), "violations": self.policy_violations} if self.policy else None
+        }
+
+
+def find_project_config(target: str) -> Optional[str]:
+    directory = os.path.abspath(target if os.path.isdir(target) else os.path.dirname(target))
+
+    while True:
+        for name in PROJECT_CONFIG_NAMES:
+            candidate = os.path.join(directory, name)
+            if os.path.isfile(candidate):
+                return candidate
+        if os.path.isdir(os.path.join(directory, '.git')):
+            return None
+        parent = os.path.dirname(directory)
+        if parent == directory:
+            return None
+        directory = parent
+
+
+def merge_config_data(base: Dict[str, Any], overrides: Dict[str, Any]) -> Dict[str, Any]:
+    merged = {**base, **overrides}
+    if base.get('severity_overrides') or overrides.get('severity_overrides'):
+        merged['severity_overrides'] = {**(base.get('severity_overrides') or {}), **(overrides.get('severity_overrides') or {})}
+    return merged
+
+
+def inside_repo(reference: str, base_dir: str) -> bool:
+    """Whether a path named in a repo's config is relative and stays inside the repo once resolved"""
+    if os.path.isabs(reference) or reference.startswith('~'):
+        return False
+    base = os.path.realpath(base_dir)
+    resolved = os.path.realpath(os.path.join(base, reference))
+    return resolved == base or resolved.startswith(base + os.sep)
+
+
+def is_remote_source(source: str) -> bool:
+    return source.startswith(("http://", "https://", "git+"))
+
+
+def repo_extends(source: str, path: str) -> Optional[str]:
+    """The policy a repo's own config extends, as far as the repo may choose it: a local policy has to be
+    inside the repo, and code from elsewhere (an archive, remote checkout, or image) may not name a URL"""
+    from ..isolation import untrusted_root
+
+    if not is_remote_source(source):
+        if not inside_repo(source, os.path.dirname(path)):
+            raise PolicyError(f"{path}: extends {source!r} must be a relative path inside the repository")
+        return source
+    if untrusted_root(path):
+        logger.warning(f"{path}: ignoring extends {source!r}; a scanned archive, remote repository, or image may not fetch a policy")
+        return None
+    return source
+
+
+def load_project_config(target: Optional[str], overrides: Optional[Dict[str, Any]] = None) -> ProjectConfig:
+    """Project config from .sastscan.yaml (plus server-side overrides), tightened by the org policy it
+    extends; POLICY_SOURCE applies a policy to every project, even ones without a config file"""
+    path = find_project_config(target) if target and os.path.exists(target) else None
+    data = {}
+    overrides = overrides or {}
+
+    if path:
+        with open(path, 'r') as f:
+            data = yaml.safe_load(f) or {}
+        if not isinstance(data, dict):
+            raise ValueError(f"Invalid project config {path}: expected a mapping at the top level")
+
+    data = merge_config_data(data, overrides)
+    config = ProjectConfig.from_dict(data, path)
+    if config.rule_packs:
+        # local pins are relative to the config file
+        base_dir = os.path.dirname(path) if path else os.getcwd()
+        refused = []
+        if path and 'rule_packs' not in overrides:
+            from ..rulepacks import RulePackError, parse_spec
+
+            for pin in config.rule_packs:
+                try:
+                    local = parse_spec(pin, base_dir).kind == "local"
+                except RulePackError:
+                    continue  # reported when the pins are loaded
+                if local and not inside_repo(pin, base_dir):
+                    refused.append(pin)
+        config.rule_packs = [pin for pin in config.rule_packs if pin not in refused]
+        config.load_rule_packs(base_dir)
+        for pin in refused:
+            error = f"{pin}: a local rule pack pinned by the repository must be a relative path inside it"
+            logger.warning(f"Rule pack: {error}")
+            config.pack_errors.append(error)
+
+    # the server-wide policy wins so a repo cannot opt out by pointing "extends" elsewhere
+    source = get_settings().policy_source or overrides.get('extends')
+    if not source and data.get('extends') and path:
+        source = repo_extends(str(data['extends']), path)
+    if source:
+        config.enforce(load_policy(source, os.path.dirname(path) if path else None))
+    return config
@@ -46,6 +46,90 @@ class Settings(BaseSettings):
     enable_infer: bool = True
     enable_clang: bool = True
     enable_pattern_analysis: bool = True
+    enable_blame: bool = True  # attribute findings to the last author via git blame
+    redact_secrets: bool = True  # mask secret values in reports and LLM prompts (see src/redaction.py)
+    sensitive_field_names: Optional[list] = None  # overrides the log rule defaults
+    
+    # Org policy (path, http(s) URL, or git+<repo-url>#<path>[@<ref>]) every project config inherits from
+    policy_source: Optional[str] = None
+    policy_cache_dir: str = "~/.sastscan/policy"
+    policy_cache_seconds: int = 3600
+    
+    # Container image scanning (scanner scan --image)
+    image_cache_dir: str = "~/.sastscan/images"
+    image_platform: str = "linux/amd64"
+    registry_username: Optional[str] = None
+    registry_password: Optional[str] = None
+    
+    # Source archive scanning (scanner scan app.tar.gz): limits on what one archive may unpack to
+    archive_max_bytes: int = 500 * 1024 * 1024
+    archive_max_entries: int = 100000
+    
+    # Remote repository scanning (scanner scan https://github.com/org/repo@ref); fetched with the tokens below
+    remote_clone_timeout: int = 600
+    
+    # Rule packs (scanner rules install): registry, local cache, and the Ed25519 keys packs must be signed by
+    rule_registry_url: Optional[str] = None
+    rule_registry_token: Optional[str] = None
+    rule_pack_dir: str = "~/.sastscan/rule-packs"
+    rule_pack_keys_file: str = "~/.sastscan/rule-pack-keys"  # "<key-name> <base64 public key>" per line
+    
+    # Scan attestations (scanner scan --attest --sign): cosign key file or KMS URI; unset signs keyless via Fulcio
+    attestation_signing_key: Optional[str] = None
+    cosign_path: str = "cosign"
+    
+    # Code hosting (fix pull requests, remote repository scans)
+    github_token: Optional[str] = None
+    github_api_url: Optional[str] = None  # GitHub Enterprise API, e.g. https://ghe.example.com/api/v3
+    gitlab_token: Optional[str] = None
+    gitlab_url: str = "https://gitlab.com"
+    gitlab_hosts: list = []  # other self-managed GitLab hosts GITLAB_TOKEN may be sent to (exact host names)
+    report_base_url: Optional[str] = None  # public URL of this server, used in links back to reports
+    
+    # Notifications (YAML file with a "notifications" list of slack/teams/webhook/email channels)
+    notifications_file: Optional[str] = None
+    smtp_host: Optional[str] = None
+    smtp_port: int = 587
+    smtp_username: Optional[str] = None
+    smtp_password: Optional[str] = None
+    smtp_from: Optional[str] = None
+    smtp_starttls: bool = True
+    
+    # Issue trackers (scanner export --tracker jira|webhook); filed issues are remembered in tracker_file
+    jira_url: Optional[str] = None  # e.g. https://acme.atlassian.net
+    jira_project: Optional[str] = None  # project key, e.g. SEC
+    jira_email: Optional[str] = None  # Jira Cloud account for jira_api_token; unset sends the token as a Server/DC PAT
+    jira_api_token: Optional[str] = None
+    jira_issue_type: str = "Bug"
+    jira_done_transition: str = "Done"  # workflow transition used to close issues of fixed findings
+    tracker_webhook_url: Optional[str] = None
+    tracker_file: str = "tracker.json"
+    
+    # Server-mode auth (manage keys with scripts/scanner keys)
+    auth_enabled: bool = False
+    api_keys_file: str = "api_keys.json"
+    audit_log_file: str = "audit.log"
+    mtls_subject_header: Optional[str] = None  # e.g. X-SSL-Client-S-DN, set by the TLS-terminating proxy
+    projects_file: str = "projects.json"
+    triage_file: str = "triage.json"
+    
+    # Context retrieval for the analyzer agent (any litellm embedding model; unset uses a built-in lexical embedding)
+    retrieval_enabled: bool = True
+    embedding_model: Optional[str] = None
+    retrieval_context_chars: int = 6000
+    
+    # Air-gapped runs: no network calls at all (static rules only, cached policy)
+    offline: bool = False
+    
+    # Building or running scanned code (go build/test for fix validation; see src/isolation.py):
+    # "off" never does, "sandbox" uses a locked-down container, "host" runs it directly (trusted code only)
+    code_execution: str = "off"
+    sandbox_runtime: Optional[str] = None  # docker or podman; unset picks whichever is installed
+    sandbox_image: str = "golang:1.22"
+    sandbox_seccomp_profile: Optional[str] = None  # replaces the runtime's default profile
+    sandbox_memory: str = "2g"
+    sandbox_cpus: float = 2
+    sandbox_gomodcache: Optional[str] = None  # host module cache mounted read-only (the sandbox has no network)
     
     # Security settings
     allowed_origins: list = ["*"]
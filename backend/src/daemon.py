@@ -0,0 +1,273 @@
+"""
+Scan Daemon - Pull configured repositories on a cron schedule, scan them, and record history
+Usage: scanner daemon --config daemon.yaml
+"""
+
+import asyncio
+import logging
+import os
+import re
+import subprocess
+import time
+from dataclasses import dataclass, field
+from datetime import datetime, timedelta
+from typing import Any, Dict, List, Optional, Set
+
+import yaml
+
+from .isolation import git_command
+from .notifications import Notifier, ScanSummary
+from .remote import git_env
+from .reports import finding_fingerprint, load_report, report_link, save_report
+from .trends import describe_trend_gate, evaluate_trend_gates
+
+logger = logging.getLogger(__name__)
+
+CRON_FIELDS = [(0, 59), (0, 23), (1, 31), (1, 12), (0, 7)]  # minute hour day month weekday (0 and 7 are Sunday)
+CRON_SEARCH_DAYS = 28 * 366  # the calendar repeats every 28 years, so any schedule that ever fires does within that
+
+
+class DaemonConfigError(ValueError):
+    pass
+
+
+class CronSchedule:
+    """Five-field cron expression (minute hour day month weekday); supports *, lists, ranges, and steps.
+    As in cron, when both day and weekday are restricted a day matching either one fires"""
+
+    def __init__(self, expression: str):
+        self.expression = expression
+        parts = expression.split()
+        if len(parts) != 5:
+            raise DaemonConfigError(f"Cron schedule needs 5 fields: {expression!r}")
+        self.fields = [self._parse(part, low, high) for part, (low, high) in zip(parts, CRON_FIELDS)]
+        if 7 in self.fields[4]:
+            self.fields[4] = (self.fields[4] - {7}) | {0}
+        self.any_day, self.any_weekday = parts[2].startswith('*'), parts[4].startswith('*')
+
+    def _parse(self, part: str, low: int, high: int) -> Set[int]:
+        values = set()
+        for item in part.split(','):
+            match = re.match(r'^(\*|\d+)(?:-(\d+))?(?:/(\d+))?$', item)
+            if not match:
+                raise DaemonConfigError(f"Invalid cron field {item!r} in {self.expression!r}")
+            start, end, step = match.groups()
+            if start == '*':
+                first, last = low, high
+            else:
+                first = int(start)
+                last = int(end) if end else (high if step else first)
+            if first < low or last > high or first > last:
+                raise DaemonConfigError(f"Cron field {item!r} is out of range {low}-{high}")
+            values.update(range(first, last + 1, int(step) if step else 1))
+        return values
+
+    def matches_day(self, moment: datetime) -> bool:
+        _, _, day, month, weekday = self.fields
+        if moment.month not in month:
+            return False
+        day_match, weekday_match = moment.day in day, (moment.weekday() + 1) % 7 in weekday
+        if self.any_day or self.any_weekday:
+            return day_match and weekday_match
+        return day_match or weekday_match
+
+    def matches(self, moment: datetime) -> bool:
+        minute, hour = self.fields[:2]
+        return moment.minute in minute and moment.hour in hour and self.matches_day(moment)
+
+    def next_after(self, moment: datetime) -> datetime:
+        start = moment.replace(second=0, microsecond=0) + timedelta(minutes=1)
+        times = sorted((h, m) for h in self.fields[1] for m in self.fields[0])
+        day = start.replace(hour=0, minute=0)
+        for _ in range(CRON_SEARCH_DAYS):
+            if self.matches_day(day):
+                for hour, minute in times:
+                    candidate = day.replace(hour=hour, minute=minute)
+                    if candidate >= start:
+                        return candidate
+            day += timedelta(days=1)
+        raise DaemonConfigError(f"Cron schedule {self.expression!r} never fires")
+
+
+@dataclass
+class RepoJob:
+    name: str
+    url: str
+    branch: str
+    schedule: CronSchedule
+    path: Optional[str] = None  # existing checkout to pull instead of cloning
+    next_run: Optional[datetime] = None
+
+    @property
+    def target(self) -> str:
+        return f"{self.url}#{self.branch}"
+
+
+@dataclass
+class DaemonConfig:
+    jobs: List[RepoJob] = field(default_factory=list)
+    workdir: str = os.path.expanduser("~/.sastscan/repos")
+    database: str = "vulnerability_analysis.db"
+    notifications: List[Dict[str, Any]] = field(default_factory=list)
+    poll_seconds: int = 30
+
+    @classmethod
+    def from_dict(cls, data: Dict[str, Any]) -> "DaemonConfig":
+        default_schedule = data.get("schedule", "0 2 * * *")
+        jobs = []
+        for repo in data.get("repos") or []:
+            url = repo.get("url") or repo.get("path")
+            if not url:
+                raise DaemonConfigError("Each repo needs a url or path")
+            name = repo.get("name") or re.sub(r'\.git$', '', url.rstrip('/').split('/')[-1].split(':')[-1])
+            for branch in repo.get("branches") or [repo.get("branch", "main")]:
+                jobs.append(RepoJob(
+                    name=name,
+                    url=url,
+                    branch=branch,
+                    schedule=CronSchedule(repo.get("schedule", default_schedule)),
+                    path=repo.get("path")
+                ))
+        if not jobs:
+            raise DaemonConfigError("No repos configured")
+
+        notifications = list(data.get("notifications") or [])
+        if data.get("notify_webhook"):
+            notifications.append({"type": "webhook", "webhook_url": data["notify_webhook"]})
+
+        return cls(
+            jobs=jobs,
+            workdir=os.path.expanduser(data.get("workdir", cls.workdir)),
+            database=data.get("database", cls.database),
+            notifications=notifications,
+            poll_seconds=int(data.get("poll_seconds", cls.poll_seconds))
+        )
+
+
+def load_daemon_config(path: str) -> DaemonConfig:
+    with open(path, 'r') as f:
+        return DaemonConfig.from_dict(yaml.safe_load(f) or {})
+
+
+def run_git(args: List[str], cwd: Optional[str] = None, env: Optional[Dict[str, str]] = None) -> str:
+    result = subprocess.run(git_command(args), cwd=cwd, env=env, capture_output=True, text=True, timeout=600)
+    if result.returncode != 0:
+        raise RuntimeError(f"git {' '.join(args)} failed: {result.stderr.strip()}")
+    return result.stdout.strip()
+
+
+class ScanDaemon:
+
+    def __init__(self, config: DaemonConfig):
+        self.config = config
+        self.db = None
+        try:
+            self.notifier = Notifier.from_config(config.notifications)
+        except ValueError as e:
+            raise DaemonConfigError(str(e))
+
+    def checkout(self, job: RepoJob) -> str:
+        if job.path:
+            run_git(['fetch', '--quiet', 'origin', job.branch], job.path)
+            run_git(['checkout', '--quiet', job.branch], job.path)
+            run_git(['merge', '--ff-only', '--quiet', 'FETCH_HEAD'], job.path)
+            return job.path
+
+        directory = os.path.join(self.config.workdir, job.name, re.sub(r'[^\w.-]', '_', job.branch))
+        env = git_env(job.url)  # GITHUB_TOKEN/GITLAB_TOKEN for private repos
+        if not os.path.isdir(os.path.join(directory, '.git')):
+            os.makedirs(os.path.dirname(directory), exist_ok=True)
+            run_git(['clone', '--quiet', '--single-branch', '--branch', job.branch, job.url, directory], env=env)
+        else:
+            run_git(['fetch', '--quiet', 'origin', job.branch], directory, env)
+            run_git(['reset', '--quiet', '--hard', 'FETCH_HEAD'], directory)
+        return directory
+
+    async def scan(self, session_id: str, checkout: str) -> Dict[str, Any]:
+        from .llm import get_llm_config
+        from .main import run_analysis_pipeline, run_static_scan
+
+        if get_llm_config().has_any_key():
+            await run_analysis_pipeline(session_id, "project", checkout, notify=False)
+            return load_report(session_id) or {}
+
+        logger.info(f"[{session_id}] No LLM API key configured; running static rules only")
+        report = run_static_scan(session_id, checkout)
+        save_report(report)
+        return report
+
+    async def run_job(self, job: RepoJob) -> Optional[Dict[str, Any]]:
+        slug = re.sub(r'[^\w-]', '_', f"{job.name}_{job.branch}")
+        session_id = f"daemon_{slug}_{int(time.time())}"
+        logger.info(f"[{session_id}] Scanning {job.target}")
+
+        try:
+            checkout = await asyncio.to_thread(self.checkout, job)
+            commit = await asyncio.to_thread(run_git, ['rev-parse', 'HEAD'], checkout)
+        except Exception as e:
+            logger.error(f"[{session_id}] Could not update {job.target}: {e}")
+            return None
+
+        try:
+            report = await self.scan(session_id, checkout)
+        except Exception as e:
+            logger.error(f"[{session_id}] Scan of {job.target} failed: {e}")
+            return None
+        if not report:
+            return None
+        report["daemon"] = {"repo": job.url, "branch": job.branch, "commit": commit}
+
+        if report.get("status") != "completed":
+            # a failed scan reports no findings, which must not mark every open finding fixed
+            await self.db.record_session(report, target=job.target)
+            save_report(report)
+            logger.error(f"[{session_id}] Scan of {job.target} {report.get('status', 'failed')}: " + "; ".join(report.get("errors") or []))
+            return report
+
+        findings = {finding_fingerprint(v, checkout): v for v in report.get("vulnerabilities", [])}
+        await self.db.record_session(report, target=job.target)
+        changes = await self.db.record_findings(job.target, session_id, findings)
+        report["history"] = {"new": len(changes["new"]), "fixed": len(changes["fixed"])}
+        trend = await evaluate_trend_gates(report, self.db, job.target)
+        if trend and not trend["passed"]:
+            logger.warning(f"[{session_id}] Trend gate failed: " + "; ".join(describe_trend_gate(r) for r in trend["gates"] if not r["passed"]))
+        save_report(report)
+
+        logger.info(f"[{session_id}] {len(findings)} findings ({len(changes['new'])} new, {len(changes['fixed'])} fixed)")
+        if not changes["baseline"] or (trend and not trend["passed"]):
+            await self.notifier.notify(ScanSummary.from_report(report, changes, report_link(report)))
+        return report
+
+    async def start(self):
+        from .database import Database
+
+        self.db = Database(self.config.database)
+        await self.db.initialize()
+
+    async def run_once(self):
+        await self.start()
+        try:
+            for job in self.config.jobs:
+                await self.run_job(job)
+        finally:
+            await self.db.close()
+
+    async def run_forever(self):
+        await self.start()
+        now = datetime.now()
+        for job in self.config.jobs:
+            job.next_run = job.schedule.next_after(now)
+            logger.info(f"{job.target} scheduled '{job.schedule.expression}', next run {job.next_run:%Y-%m-%d %H:%M}")
+
+        try:
+            while True:
+                for job in self.config.jobs:
+                    if datetime.now() >= job.next_run:
+                        try:
+                            await self.run_job(job)
+                        except Exception as e:  # one broken job (or history store hiccup) must not stop the others
+                            logger.exception(f"Scheduled scan of {job.target} failed: {e}")
+                        job.next_run = job.schedule.next_after(datetime.now())
+                await asyncio.sleep(self.config.poll_seconds)
+        finally:
+            await self.db.close()
@@ -271,6 +271,26 @@ class Database:
             )
         """)
         
+        # Finding history per scan target, used to tell new findings from known ones
+        await self.connection.execute("""
+            CREATE TABLE IF NOT EXISTS finding_history (
+                id INTEGER PRIMARY KEY AUTOINCREMENT,
+                target TEXT NOT NULL,
+                fingerprint TEXT NOT NULL,
+                vuln_type TEXT NOT NULL,
+                severity TEXT NOT NULL,
+                file_path TEXT NOT NULL,
+                line_number INTEGER NOT NULL,
+                rule_id TEXT,
+                status TEXT NOT NULL,
+                first_seen_session TEXT NOT NULL,
+                last_seen_session TEXT NOT NULL,
+                first_seen_at REAL NOT NULL,
+                last_seen_at REAL NOT NULL,
+                UNIQUE (target, fingerprint)
+            )
+        """)
+        
         # Agent events table for audit trail
         await self.connection.execute("""
             CREATE TABLE IF NOT EXISTS agent_events (
@@ -440,6 +460,86 @@ class Database:
         
         return sessions
     
+    async def get_session_before(self, target: str, before: float) -> Optional[SessionRecord]:
+        """Latest completed session of a target that started at or before the given time"""
+        cursor = await self.connection.execute(
+            "SELECT * FROM sessions WHERE target = ? AND status = 'completed' AND started_at <= ? ORDER BY started_at DESC LIMIT 1",
+            (target, before)
+        )
+        row = await cursor.fetchone()
+        
+        if row:
+            return SessionRecord(
+                id=row[0], session_id=row[1], analysis_type=row[2], target=row[3],
+                status=row[4], started_at=row[5], completed_at=row[6],
+                total_vulnerabilities=row[7], total_patches=row[8], total_cost=row[9],
+                metadata=row[10] if row[10] else "{}"
+            )
+        return None
+    
+    async def record_session(self, report: Dict[str, Any], target: Optional[str] = None):
+        """Insert or update the session row for a finished report"""
+        from ..trends import trend_counts
+
+        await self.connection.execute("""
+            INSERT OR REPLACE INTO sessions (
+                session_id, analysis_type, target, status, started_at, completed_at,
+                total_vulnerabilities, total_patches, total_cost, metadata
+            ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        """, (
+            report["session_id"], report.get("analysis_type", ""), target or report.get("target", ""),
+            report.get("status", ""), report.get("started_at", time.time()), report.get("completed_at"),
+            len(report.get("vulnerabilities", [])), len(report.get("patches", [])), report.get("cost", 0.0),
+            json.dumps({"summary": report.get("summary", {}), "trend_counts": trend_counts(report)})
+        ))
+        await self.connection.commit()
+    
+    async def record_findings(
+        self,
+        target: str,
+        session_id: str,
+        findings: Dict[str, Dict[str, Any]]
+    ) -> Dict[str, List[Dict[str, Any]]]:
+        """Store fingerprinted findings for a target; return which are new and which were fixed.
+        The first scan of a target is a baseline: everything is new."""
+        now = time.time()
+        cursor = await self.connection.execute(
+            "SELECT fingerprint, status, vuln_type, severity, file_path, line_number, rule_id FROM finding_history WHERE target = ?",
+            (target,)
+        )
+        known = {row[0]: row for row in await cursor.fetchall()}
+        
+        new = [vuln for fp, vuln in findings.items() if fp not in known or known[fp][1] == "fixed"]
+        fixed = [
+            {"vuln_type": row[2], "severity": row[3], "file_path": row[4], "line_number": row[5], "rule_id": row[6]}
+            for fp, row in known.items() if row[1] == "open" and fp not in findings
+        ]
+        
+        for fp, vuln in findings.items():
+            await self.connection.execute("""
+                INSERT INTO finding_history (
+                    target, fingerprint, vuln_type, severity, file_path, line_number, rule_id,
+                    status, first_seen_session, last_seen_session, first_seen_at, last_seen_at
+                ) VALUES (?, ?, ?, ?, ?, ?, ?, 'open', ?, ?, ?, ?)
+                ON CONFLICT (target, fingerprint) DO UPDATE SET
+                    status = 'open', severity = excluded.severity, file_path = excluded.file_path,
+                    line_number = excluded.line_number, last_seen_session = excluded.last_seen_session,
+                    last_seen_at = excluded.last_seen_at
+            """, (
+                target, fp, vuln.get("vuln_type", ""), vuln.get("severity", ""), vuln.get("file_path", ""),
+                vuln.get("line_number", 0), vuln.get("rule_id"), session_id, session_id, now, now
+            ))
+        
+        gone = [fp for fp, row in known.items() if row[1] == "open" and fp not in findings]
+        if gone:
+            await self.connection.executemany(
+                "UPDATE finding_history SET status = 'fixed', last_seen_session = ?, last_seen_at = ? WHERE target = ? AND fingerprint = ?",
+                [(session_id, now, target, fp) for fp in gone]
+            )
+        
+        await self.connection.commit()
+        return {"new": new, "fixed": fixed, "baseline": not known}
+    
     # Agent events for audit trail
     async def log_agent_event(self, session_id: str, agent_id: str, event_type: str, data: Dict[str, Any]):
         """Log agent event for audit trail"""
@@ -0,0 +1,365 @@
+"""
+Doctor - Self-diagnostics for scans that are slow, fail, or silently do less than expected (scanner doctor)
+Three groups of checks, each ok, warn, fail, or skip:
+
+- config: scanner settings, the project's .sastscan.yaml (unknown keys, rules and severities that do not
+  exist, rule packs, the org policy), the notifications file, and the server-mode stores
+- cache: the report store, scan checkpoints, embeddings indexes, the org policy cache, installed rule packs,
+  and the image cache; unreadable entries are reported, nothing is deleted
+- provider: one minimal request to the configured LLM models and the embedding model, with its latency
+  (skipped offline or with --no-providers)
+"""
+
+import asyncio
+import hashlib
+import json
+import os
+import shutil
+import time
+from dataclasses import dataclass
+from typing import Any, Dict, List, Optional
+
+import yaml
+
+from .config.offline import is_offline
+from .config.settings import get_settings
+
+STATUSES = ("ok", "warn", "fail", "skip")
+PROVIDER_TIMEOUT = 30
+PROJECT_CONFIG_KEYS = ("extends", "severity_overrides", "sensitive_field_names", "disabled_rules", "fail_on",
+                       "interop", "rule_packs", "critical_paths")
+
+
+@dataclass
+class Check:
+    group: str  # config, cache, or provider
+    name: str
+    status: str  # ok, warn, fail, or skip
+    detail: str
+    seconds: Optional[float] = None
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "group": self.group,
+            "name": self.name,
+            "status": self.status,
+            "detail": self.detail,
+            "seconds": round(self.seconds, 3) if self.seconds is not None else None
+        }
+
+
+def directory_size(path: str) -> int:
+    total = 0
+    for root, _, files in os.walk(path):
+        for name in files:
+            try:
+                total += os.path.getsize(os.path.join(root, name))
+            except OSError:
+                pass
+    return total
+
+
+def mib(size: int) -> str:
+    return f"{size / (1024 * 1024):.1f} MiB"
+
+
+def unreadable_json(directory: str) -> List[str]:
+    """Names of the .json files in directory that do not parse"""
+    broken = []
+    for name in sorted(os.listdir(directory)):
+        if not name.endswith('.json'):
+            continue
+        try:
+            with open(os.path.join(directory, name), 'r') as f:
+                json.load(f)
+        except (OSError, ValueError):
+            broken.append(name)
+    return broken
+
+
+def unmatched_patterns(patterns: List[str], base: str) -> List[str]:
+    """The CODEOWNERS-style patterns no file under base matches"""
+    from .codeowners import compile_pattern
+
+    remaining = {pattern: compile_pattern(pattern) for pattern in patterns}
+    for root, dirs, files in os.walk(base):
+        dirs[:] = [d for d in dirs if d != '.git']
+        for name in files:
+            rel_path = os.path.relpath(os.path.join(root, name), base).replace(os.sep, '/')
+            for pattern in [p for p, regex in remaining.items() if regex.match(rel_path)]:
+                del remaining[pattern]
+            if not remaining:
+                return []
+    return list(remaining)
+
+
+def check_settings() -> List[Check]:
+    from .isolation import IsolationError, code_execution_mode, sandbox_runtime
+    from .llm import get_llm_config
+
+    checks = []
+    try:
+        mode = code_execution_mode()
+        if mode == "sandbox":
+            checks.append(Check("config", "code execution", "ok", f"sandbox ({sandbox_runtime()}, {get_settings().sandbox_image})"))
+        else:
+            checks.append(Check("config", "code execution", "warn" if mode == "host" else "ok",
+                                "host: fix validation builds and runs scanned code unsandboxed" if mode == "host" else "off"))
+    except IsolationError as e:
+        checks.append(Check("config", "code execution", "fail", str(e)))
+
+    config = get_llm_config()
+    if is_offline():
+        checks.append(Check("config", "llm", "ok", "offline: static rules only"))
+    elif config.has_any_key():
+        checks.append(Check("config", "llm", "ok", f"default model {config.default_model}"))
+    else:
+        checks.append(Check("config", "llm", "warn", "no API key or local model configured: scans run the static rules only"))
+
+    missing = [tool for tool in ("git", "gofmt") if not shutil.which(tool)]
+    checks.append(Check("config", "tools", "warn" if missing else "ok",
+                        f"not on the PATH: {', '.join(missing)}" if missing else "git, gofmt"))
+    return checks
+
+
+def check_project_config(project_dir: str) -> List[Check]:
+    from .analysis.chains import RULE_ID as CHAIN_RULE_ID
+    from .analysis.interop import RULE_ID as INTEROP_RULE_ID
+    from .analysis.rules import get_rule
+    from .config.policy import SEVERITIES, PolicyError
+    from .config.project import find_project_config, load_project_config
+
+    path = find_project_config(project_dir)
+    if not path:
+        checks = [Check("config", "project config", "ok", f"none for {project_dir}; built-in defaults")]
+    else:
+        try:
+            with open(path, 'r') as f:
+                data = yaml.safe_load(f) or {}
+        except (OSError, yaml.YAMLError) as e:
+            return [Check("config", "project config", "fail", f"{path}: {e}")]
+        if not isinstance(data, dict):
+            return [Check("config", "project config", "fail", f"{path}: expected a mapping at the top level")]
+
+        problems = [f"unknown key {key}" for key in data if key not in PROJECT_CONFIG_KEYS]
+        for rule_id, severity in (data.get('severity_overrides') or {}).items():
+            if str(severity).lower() not in SEVERITIES:
+                problems.append(f"severity_overrides {rule_id}={severity} is not a severity (ignored)")
+        if data.get('fail_on') and str(data['fail_on']).lower() not in SEVERITIES:
+            problems.append(f"fail_on={data['fail_on']} is not a severity (ignored)")
+        for pattern in unmatched_patterns([str(p) for p in data.get('critical_paths') or []], os.path.dirname(path)):
+            problems.append(f"critical_paths {pattern} matches no file")
+        checks = [Check("config", "project config", "warn" if problems else "ok", f"{path}: {'; '.join(problems)}" if problems else path)]
+
+    try:
+        config = load_project_config(project_dir)
+    except PolicyError as e:
+        return checks + [Check("config", "org policy", "fail", str(e))]
+    except (OSError, ValueError, yaml.YAMLError) as e:
+        return checks + [Check("config", "project config", "fail", str(e))]
+
+    pack_rules = {rule.rule_id for rule in config.pack_rules} | {CHAIN_RULE_ID, INTEROP_RULE_ID}
+    unknown = [r for r in list(config.disabled_rules) + list(config.severity_overrides) if not get_rule(r) and r not in pack_rules]
+    if unknown:
+        checks.append(Check("config", "rule ids", "warn", f"no such rule: {', '.join(sorted(set(unknown)))}"))
+    if config.rule_packs:
+        checks.append(Check("config", "rule packs", "fail" if config.pack_errors else "ok",
+                            '; '.join(config.pack_errors) if config.pack_errors else
+                            f"{len(config.packs)} pinned, {len(config.pack_rules)} rule(s)"))
+    if config.policy:
+        detail = f"{config.policy.source}" + (f"; overruled: {'; '.join(config.policy_violations)}" if config.policy_violations else "")
+        checks.append(Check("config", "org policy", "warn" if config.policy_violations else "ok", detail))
+    return checks
+
+
+def check_files() -> List[Check]:
+    """The notifications file and the server-mode JSON stores, when they exist"""
+    from .notifications import Notifier
+
+    settings = get_settings()
+    checks = []
+    if settings.notifications_file:
+        try:
+            with open(settings.notifications_file, 'r') as f:
+                data = yaml.safe_load(f) or {}
+            notifier = Notifier.from_config(data.get("notifications", []))
+            checks.append(Check("config", "notifications", "ok", f"{settings.notifications_file}: {len(notifier.channels)} channel(s)"))
+        except (OSError, ValueError, AttributeError, yaml.YAMLError) as e:
+            checks.append(Check("config", "notifications", "fail", f"{settings.notifications_file}: {e} (scans send nothing)"))
+
+    for name, path in (("api keys", settings.api_keys_file), ("projects", settings.projects_file), ("triage", settings.triage_file)):
+        if not os.path.exists(path):
+            if name == "api keys" and settings.auth_enabled:
+                checks.append(Check("config", name, "fail", f"AUTH_ENABLED is set but {path} does not exist; create a key with: scanner keys create"))
+            continue
+        try:
+            with open(path, 'r') as f:
+                json.load(f)
+            checks.append(Check("config", name, "ok", path))
+        except (OSError, ValueError) as e:
+            checks.append(Check("config", name, "fail", f"{path}: {e}"))
+    return checks
+
+
+def check_caches() -> List[Check]:
+    from .checkpoints import CHECKPOINTS_DIR
+    from .config.policy import cache_path
+    from .reports import REPORTS_DIR
+    from .retrieval import INDEX_DIR, embedding_model
+    from .rulepacks import PACK_FILE, RulePackError, installed_packs
+
+    settings = get_settings()
+    checks = []
+
+    if os.path.isdir(REPORTS_DIR):
+        writable = os.access(REPORTS_DIR, os.W_OK)
+        broken = unreadable_json(REPORTS_DIR)
+        status = "fail" if not writable else "warn" if broken else "ok"
+        detail = f"{os.path.abspath(REPORTS_DIR)}: {mib(directory_size(REPORTS_DIR))}"
+        detail += "" if writable else "; not writable"
+        detail += f"; unreadable: {', '.join(broken[:5])}" if broken else ""
+        checks.append(Check("cache", "reports", status, detail))
+    else:
+        checks.append(Check("cache", "reports", "ok", f"{os.path.abspath(REPORTS_DIR)} (created on the first scan)"))
+
+    if os.path.isdir(CHECKPOINTS_DIR):
+        names = [n for n in os.listdir(CHECKPOINTS_DIR) if n.endswith('.json')]
+        broken = unreadable_json(CHECKPOINTS_DIR)
+        detail = f"{len(names)} interrupted scan(s), {mib(directory_size(CHECKPOINTS_DIR))}"
+        checks.append(Check("cache", "checkpoints", "warn" if broken else "ok",
+                            detail + (f"; unreadable (resume fails): {', '.join(broken[:5])}" if broken else "")))
+
+    if os.path.isdir(INDEX_DIR):
+        stale, broken, count = 0, [], 0
+        model = embedding_model()
+        for name in sorted(os.listdir(INDEX_DIR)):
+            if not name.endswith('.json'):
+                continue
+            count += 1
+            try:
+                with open(os.path.join(INDEX_DIR, name), 'r') as f:
+                    index = json.load(f)
+                if index.get("model") != model:
+                    stale += 1
+            except (OSError, ValueError):
+                broken.append(name)
+        detail = f"{count} index(es), {mib(directory_size(INDEX_DIR))}"
+        if stale:
+            detail += f"; {stale} built with another embedding model (re-embedded on the next scan)"
+        if broken:
+            detail += f"; unreadable (rebuilt on the next scan): {', '.join(broken[:5])}"
+        checks.append(Check("cache", "embeddings", "warn" if stale or broken else "ok", detail))
+
+    source = settings.policy_source
+    if source and source.startswith(('http://', 'https://', 'git+')):
+        cached = cache_path(source)
+        if os.path.exists(cached):
+            age = time.time() - os.path.getmtime(cached)
+            fresh = age < settings.policy_cache_seconds
+            checks.append(Check("cache", "policy", "ok", f"{source} cached {age / 60:.0f} min ago" + ("" if fresh else "; refetched on the next scan")))
+        else:
+            checks.append(Check("cache", "policy", "fail" if is_offline() else "warn",
+                                f"{source} is not cached" + ("; offline scans fail" if is_offline() else "; fetched on the next scan")))
+
+    try:
+        packs = installed_packs()
+        modified = []
+        for directory, record in packs:
+            with open(os.path.join(directory, PACK_FILE), 'rb') as f:
+                if hashlib.sha256(f.read()).hexdigest() != record.get("sha256"):
+                    modified.append(record.get("spec", directory))
+        if packs:
+            checks.append(Check("cache", "rule packs", "fail" if modified else "ok",
+                                f"modified after install (reinstall): {', '.join(modified)}" if modified else
+                                f"{len(packs)} installed under {settings.rule_pack_dir}"))
+    except (OSError, ValueError, RulePackError) as e:
+        checks.append(Check("cache", "rule packs", "fail", f"{settings.rule_pack_dir}: {e}"))
+
+    images = os.path.expanduser(settings.image_cache_dir)
+    if os.path.isdir(images):
+        checks.append(Check("cache", "images", "ok", f"{images}: {mib(directory_size(images))}"))
+    return checks
+
+
+def provider_of(model: str) -> str:
+    from .llm.local import is_local_model
+
+    if is_local_model(model):
+        return model.split('/', 1)[0]
+    if model.startswith(('claude', 'anthropic/')):
+        return "anthropic"
+    if model.startswith(('gemini', 'vertex_ai/')):
+        return "google"
+    return "openai"
+
+
+async def ping_model(model: str) -> Check:
+    from litellm import acompletion
+
+    from .llm.local import completion_kwargs, detect_llamacpp, detect_ollama, is_local_model
+
+    started = time.perf_counter()
+    try:
+        if is_local_model(model):
+            from .llm import get_llm_config
+
+            llm = get_llm_config()
+            detect = detect_llamacpp if model.startswith("llamacpp/") else detect_ollama
+            caps = await asyncio.to_thread(detect, model, llm.llamacpp_base_url if model.startswith("llamacpp/") else llm.ollama_base_url, llm.local_num_ctx)
+            kwargs = completion_kwargs(model, caps)
+            detail = f"{caps.context_window} token context, tools={caps.supports_tools}"
+        else:
+            kwargs, detail = {"model": model}, ""
+        await asyncio.wait_for(acompletion(messages=[{"role": "user", "content": "ping"}], max_tokens=1, **kwargs), PROVIDER_TIMEOUT)
+    except Exception as e:
+        return Check("provider", model, "fail", f"{type(e).__name__}: {str(e)[:200]}", time.perf_counter() - started)
+    elapsed = time.perf_counter() - started
+    status = "warn" if elapsed > 10 else "ok"
+    return Check("provider", model, status, f"{provider_of(model)}, {elapsed:.2f}s" + (f", {detail}" if detail else "") + (" (slow)" if status == "warn" else ""), elapsed)
+
+
+async def ping_embedding(model: str) -> Check:
+    from .retrieval import embed_texts
+
+    started = time.perf_counter()
+    try:
+        vectors = await asyncio.wait_for(embed_texts(["ping"], model), PROVIDER_TIMEOUT)
+    except Exception as e:
+        return Check("provider", f"embedding {model}", "fail", f"{type(e).__name__}: {str(e)[:200]} (scans fall back to the lexical embedding)", time.perf_counter() - started)
+    elapsed = time.perf_counter() - started
+    return Check("provider", f"embedding {model}", "ok", f"{len(vectors[0])} dimensions, {elapsed:.2f}s", elapsed)
+
+
+def check_providers() -> List[Check]:
+    from .llm import get_llm_config
+
+    if is_offline():
+        return [Check("provider", "llm", "skip", "offline")]
+    config = get_llm_config()
+    if not config.has_any_key():
+        return [Check("provider", "llm", "skip", "no API key or local model configured")]
+
+    keys = {"openai": config.openai_api_key, "anthropic": config.anthropic_api_key, "google": config.google_api_key}
+    # the default model, then one fallback per other provider with a key (local models never fall back)
+    models = [config.default_model]
+    if provider_of(config.default_model) in keys:
+        for model in config.fallback_models:
+            if keys.get(provider_of(model)) and provider_of(model) not in {provider_of(m) for m in models}:
+                models.append(model)
+
+    async def run() -> List[Check]:
+        checks = list(await asyncio.gather(*(ping_model(m) for m in models)))
+        if get_settings().retrieval_enabled and get_settings().embedding_model:
+            checks.append(await ping_embedding(get_settings().embedding_model))
+        return checks
+
+    return asyncio.run(run())
+
+
+def run_checks(project_dir: str, providers: bool = True) -> List[Check]:
+    checks = check_settings() + check_project_config(project_dir) + check_files() + check_caches()
+    if providers:
+        checks += check_providers()
+    else:
+        checks.append(Check("provider", "llm", "skip", "--no-providers"))
+    return checks
@@ -0,0 +1,292 @@
+"""
+Executive report - A paginated PDF for stakeholders (scanner report --format pdf)
+Four sections: a summary (open findings by severity, gate, baseline, reachability, critical paths, chained
+risks), the trend over the last scans of the same project or target from the saved reports, the top risks
+with their fixes, and the OWASP Top 10 / CWE Top 25 mapping of the open findings. Findings triaged as false
+positives are left out everywhere and only counted.
+"""
+
+import os
+import time
+from typing import Any, Dict, List, Optional
+
+from .compliance import OTHER, compliance_summary
+from .config.policy import SEVERITIES
+from .pdf import MARGIN, PAGE_HEIGHT, PAGE_WIDTH, Color, PdfDocument, text_width, truncate, wrap
+from .reports import finding_fingerprint, report_history
+
+TOP_RISKS = 10
+TREND_SCANS = 10
+
+SEVERITY_COLORS: Dict[str, Color] = {
+    "critical": (0.600, 0.106, 0.106),
+    "high": (0.863, 0.149, 0.149),
+    "medium": (0.851, 0.467, 0.024),
+    "low": (0.145, 0.388, 0.922),
+}
+TEXT: Color = (0.122, 0.161, 0.216)
+MUTED: Color = (0.420, 0.447, 0.502)
+RULE: Color = (0.898, 0.906, 0.922)
+PASSED: Color = (0.082, 0.502, 0.239)
+
+CONTENT_WIDTH = PAGE_WIDTH - 2 * MARGIN
+BOTTOM = PAGE_HEIGHT - MARGIN - 20  # room for the footer
+
+
+def open_findings(report: Dict[str, Any]) -> List[Dict[str, Any]]:
+    return [v for v in report.get("vulnerabilities", []) if (v.get("triage") or {}).get("verdict") != "false_positive"]
+
+
+def severity_counts(vulnerabilities: List[Dict[str, Any]]) -> Dict[str, int]:
+    counts = {s: 0 for s in SEVERITIES}
+    for vuln in vulnerabilities:
+        if vuln.get("severity") in counts:
+            counts[vuln["severity"]] += 1
+    return counts
+
+
+def scan_date(report: Dict[str, Any], fmt: str = "%Y-%m-%d %H:%M UTC") -> str:
+    started = report.get("started_at")
+    return time.strftime(fmt, time.gmtime(started)) if started else "unknown date"
+
+
+def scan_root(report: Dict[str, Any]) -> Optional[str]:
+    target = report.get("target")
+    return target if isinstance(target, str) and os.path.isabs(target) else None
+
+
+def fingerprints(report: Dict[str, Any]) -> set:
+    return {finding_fingerprint(v, scan_root(report)) for v in open_findings(report)}
+
+
+class Layout:
+    """Flows blocks down the pages of a PdfDocument, starting a new page when a block does not fit"""
+
+    def __init__(self, pdf: PdfDocument):
+        self.pdf = pdf
+        self.y = MARGIN
+
+    def need(self, height: float):
+        if self.y + height > BOTTOM:
+            self.pdf.new_page()
+            self.y = MARGIN
+
+    def space(self, height: float):
+        self.y += height
+
+    def heading(self, text: str):
+        self.need(60)
+        self.space(8)
+        self.pdf.text(MARGIN, self.y + 14, text, 15, "bold", TEXT)
+        self.y += 22
+        self.pdf.line(MARGIN, self.y, PAGE_WIDTH - MARGIN, self.y, RULE, 1)
+        self.space(12)
+
+    def paragraph(self, text: str, size: float = 10, font: str = "regular", color: Color = TEXT, indent: float = 0,
+                  max_lines: Optional[int] = None):
+        lines = wrap(text, CONTENT_WIDTH - indent, size, font)
+        if max_lines and len(lines) > max_lines:
+            lines = lines[:max_lines - 1] + [truncate(' '.join(lines[max_lines - 1:]), CONTENT_WIDTH - indent, size, font)]
+        for line in lines:
+            self.need(size * 1.4)
+            self.pdf.text(MARGIN + indent, self.y + size, line, size, font, color)
+            self.y += size * 1.4
+
+    def table(self, columns: List[tuple], rows: List[List[Any]], size: float = 9):
+        """columns: (title, width, align) with align "left" or "right"; the header repeats on each page"""
+        def row(values: List[Any], font: str, color: Color):
+            x = MARGIN
+            for (_, width, align), value in zip(columns, values):
+                text = truncate(str(value), width - 6, size, font)
+                offset = width - 6 - text_width(text, size, font) if align == "right" else 0
+                self.pdf.text(x + offset, self.y + size, text, size, font, color)
+                x += width
+            self.y += size * 1.7
+
+        def header():
+            row([title for title, _, _ in columns], "bold", MUTED)
+            self.pdf.line(MARGIN, self.y - size * 0.5, MARGIN + sum(w for _, w, _ in columns), self.y - size * 0.5, RULE)
+
+        self.need(size * 1.7 * 2)
+        header()
+        for values in rows:
+            if self.y + size * 1.7 > BOTTOM:
+                self.need(BOTTOM)
+                header()
+            row(values, "regular", TEXT)
+
+
+def summary_section(layout: Layout, report: Dict[str, Any], findings: List[Dict[str, Any]]):
+    pdf = layout.pdf
+    layout.heading("Summary")
+    counts = severity_counts(findings)
+    tile = (CONTENT_WIDTH - 3 * 10) / 4
+    layout.need(62)
+    for i, severity in enumerate(SEVERITIES):
+        x = MARGIN + i * (tile + 10)
+        pdf.rect(x, layout.y, tile, 56, SEVERITY_COLORS[severity])
+        pdf.text(x + 10, layout.y + 32, str(counts[severity]), 24, "bold", (1, 1, 1))
+        pdf.text(x + 10, layout.y + 48, severity.upper(), 9, "bold", (1, 1, 1))
+    layout.space(72)
+
+    facts = [f"{len(findings)} open finding(s) in {report.get('files_analyzed', 0)} file(s)."]
+    false_positives = len(report.get("vulnerabilities", [])) - len(findings)
+    if false_positives:
+        facts[0] = facts[0][:-1] + f"; {false_positives} triaged as false positives are not counted."
+    gate = report.get("gate")
+    if gate:
+        verdict = "passed" if gate["passed"] else f"FAILED with {len(gate['blocking'])} blocking finding(s)"
+        facts.append(f"Release gate (fail on {gate['fail_on']} and above): {verdict}.")
+    baseline = report.get("baseline")
+    if baseline:
+        facts.append(f"{baseline['new']} new since the baseline, {baseline['existing']} already known, {baseline['fixed']} fixed.")
+    reachable = sum(1 for v in findings if (v.get("reachability") or {}).get("reachable"))
+    if any(v.get("reachability") for v in findings):
+        facts.append(f"{reachable} finding(s) are reachable from an HTTP, WebSocket, or gRPC entry point.")
+    critical = sum(1 for v in findings if v.get("critical_path"))
+    if critical:
+        facts.append(f"{critical} finding(s) are in critical paths (payments, auth, and the like) and were raised one severity level.")
+    chains = sum(1 for v in findings if v.get("rule_id") == "chained-risk")
+    if chains:
+        facts.append(f"{chains} chained risk(s): findings that together are worse than either alone.")
+    if report.get("degraded"):
+        facts.append(f"The AI review was cut short ({report['degraded']['reason']}); {report['degraded']['static_only_files']} file(s) "
+                     "were checked with the static rules only.")
+    for fact in facts:
+        layout.paragraph(f"- {fact}", 10)
+        layout.space(2)
+
+
+def trend_section(layout: Layout, report: Dict[str, Any]):
+    pdf = layout.pdf
+    layout.heading("Trend")
+    history = report_history(report, TREND_SCANS)
+    if len(history) < 2:
+        layout.paragraph("This is the first saved scan of this project; the trend starts with the next scan.", 10, color=MUTED)
+        return
+
+    totals = [severity_counts(open_findings(r)) for r in history]
+    highest = max(max(sum(c.values()) for c in totals), 1)
+    chart_height, label_height = 120, 14
+    layout.need(chart_height + label_height + 20)
+    slot = CONTENT_WIDTH / len(history)
+    bar = min(36.0, slot * 0.6)
+    base = layout.y + chart_height
+    pdf.line(MARGIN, base, PAGE_WIDTH - MARGIN, base, MUTED)
+    for i, counts in enumerate(totals):
+        x = MARGIN + i * slot + (slot - bar) / 2
+        top = base
+        for severity in reversed(SEVERITIES):
+            height = chart_height * counts[severity] / highest
+            if height:
+                top -= height
+                pdf.rect(x, top, bar, height, SEVERITY_COLORS[severity])
+        total = str(sum(counts.values()))
+        pdf.text(x + (bar - text_width(total, 8)) / 2, top - 3, total, 8, "bold", TEXT)
+        label = scan_date(history[i], "%m-%d")
+        pdf.text(x + (bar - text_width(label, 8)) / 2, base + 11, label, 8, "regular", MUTED)
+    layout.space(chart_height + label_height + 12)
+
+    rows = []
+    previous = None
+    for scan, counts in zip(history, totals):
+        current = fingerprints(scan)
+        change = f"+{len(current - previous)} / -{len(previous - current)}" if previous is not None else ""
+        rows.append([scan_date(scan), scan.get("session_id", ""), *[counts[s] for s in SEVERITIES], sum(counts.values()), change])
+        previous = current
+    layout.table([("Scanned", 110, "left"), ("Session", 125, "left"), ("Critical", 45, "right"), ("High", 40, "right"),
+                  ("Medium", 45, "right"), ("Low", 35, "right"), ("Total", 40, "right"), ("New / fixed", 55, "right")], rows)
+
+
+def top_risks_section(layout: Layout, report: Dict[str, Any], findings: List[Dict[str, Any]]):
+    pdf = layout.pdf
+    layout.heading("Top Risks")
+    if not findings:
+        layout.paragraph("No open findings.", 10, color=MUTED)
+        return
+    for number, vuln in enumerate(findings[:TOP_RISKS], start=1):
+        severity = vuln.get("severity", "")
+        layout.need(60)
+        label = severity.upper()
+        pdf.rect(MARGIN, layout.y, text_width(label, 8, "bold") + 10, 13, SEVERITY_COLORS.get(severity, MUTED))
+        pdf.text(MARGIN + 5, layout.y + 9.5, label, 8, "bold", (1, 1, 1))
+        title_x = MARGIN + text_width(label, 8, "bold") + 18
+        title = f"{number}. {vuln.get('vuln_type', '')} ({vuln.get('vuln_id', '')})"
+        pdf.text(title_x, layout.y + 10, truncate(title, PAGE_WIDTH - MARGIN - title_x, 11, "bold"), 11, "bold", TEXT)
+        layout.space(18)
+        file_path = vuln.get("file_path", "")
+        if scan_root(report) and os.path.isabs(file_path):
+            file_path = os.path.relpath(file_path, scan_root(report))
+        layout.paragraph(f"{file_path}:{vuln.get('line_number', '')}", 8.5, "mono", MUTED)
+
+        tags = []
+        if (vuln.get("reachability") or {}).get("reachable"):
+            tags.append(f"reachable from {vuln['reachability'].get('entry_kind', 'an')} entry point")
+        if vuln.get("critical_path"):
+            tags.append(f"critical path {vuln['critical_path']}")
+        if vuln.get("code_owners"):
+            tags.append(f"owned by {' '.join(vuln['code_owners'])}")
+        if vuln.get("cwe_id"):
+            tags.append(str(vuln["cwe_id"]))
+        if tags:
+            layout.paragraph('; '.join(tags), 8.5, color=MUTED)
+        layout.paragraph(vuln.get("description", ""), 9.5, max_lines=4)
+        if vuln.get("remediation"):
+            layout.paragraph(f"Fix: {vuln['remediation']}", 9.5, color=TEXT, max_lines=3)
+        layout.space(10)
+    if len(findings) > TOP_RISKS:
+        layout.paragraph(f"{len(findings) - TOP_RISKS} more finding(s) are in the full report.", 9, color=MUTED)
+
+
+def compliance_section(layout: Layout, findings: List[Dict[str, Any]]):
+    layout.heading("Compliance Mapping")
+    layout.paragraph("Open findings by OWASP Top 10 (2021) category and CWE Top 25 (2023) entry, mapped by CWE. "
+                     "The mapping shows where findings fall, not whether a requirement is met.", 9, color=MUTED)
+    layout.space(6)
+    summary = compliance_summary(findings)
+    columns = [("Category", 265, "left"), ("Findings", 55, "right"), ("Critical", 45, "right"),
+               ("High", 40, "right"), ("Medium", 45, "right"), ("Low", 45, "right")]
+    layout.table(columns, [
+        [f"{category} {entry['name']}" if category != OTHER else entry["name"], entry["findings"],
+         *[entry["by_severity"][s] for s in SEVERITIES]]
+        for category, entry in summary["owasp_top_10"].items()
+    ])
+    layout.space(12)
+    if summary["cwe_top_25"]:
+        layout.table([("CWE Top 25", 265, "left")] + columns[1:], [
+            [f"#{entry['rank']} {cwe}", entry["findings"], *[entry["by_severity"][s] for s in SEVERITIES]]
+            for cwe, entry in summary["cwe_top_25"].items()
+        ])
+    else:
+        layout.paragraph("No open finding is in the CWE Top 25.", 9, color=MUTED)
+
+
+def render_pdf(report: Dict[str, Any], context_lines: int = 0, sort_by: str = "severity") -> bytes:
+    from .formatters import report_target, sorted_vulnerabilities
+
+    scanner = report.get("scanner") or {}
+    pdf = PdfDocument(title=f"Security report: {report_target(report)}", author=scanner.get("name", ""))
+    layout = Layout(pdf)
+    pdf.text(MARGIN, layout.y + 22, "Security Report", 22, "bold", TEXT)
+    layout.space(34)
+    layout.paragraph(report_target(report), 11, "bold")
+    details = [scan_date(report), f"session {report.get('session_id', '')}"]
+    if scanner.get("version"):
+        details.append(f"{scanner.get('name', 'scanner')} {scanner['version']}")
+    if (report.get("source") or {}).get("commit"):
+        details.append(f"commit {report['source']['commit'][:12]}")
+    layout.paragraph(" | ".join(details), 9, color=MUTED)
+
+    findings = [v for v in sorted_vulnerabilities(report, sort_by) if (v.get("triage") or {}).get("verdict") != "false_positive"]
+    summary_section(layout, report, findings)
+    trend_section(layout, report)
+    top_risks_section(layout, report, findings)
+    compliance_section(layout, findings)
+
+    generated = time.strftime("%Y-%m-%d", time.gmtime())
+    for page in range(pdf.page_count):
+        footer_y = PAGE_HEIGHT - MARGIN + 10
+        pdf.text(MARGIN, footer_y, f"{report.get('session_id', '')} - generated {generated}", 8, "regular", MUTED, page=page)
+        label = f"Page {page + 1} of {pdf.page_count}"
+        pdf.text(PAGE_WIDTH - MARGIN - text_width(label, 8), footer_y, label, 8, "regular", MUTED, page=page)
+    return pdf.to_bytes()
@@ -0,0 +1,224 @@
+"""
+Fix applier - Apply generated patches on a review branch, one validated commit per finding
+"""
+
+import ast
+import logging
+import os
+import subprocess
+from dataclasses import dataclass
+from typing import Any, Dict, List, Optional
+
+from .config.offline import is_offline
+from .isolation import IsolationError, git_command, may_execute, run_toolchain
+
+logger = logging.getLogger(__name__)
+
+
+class FixApplyError(Exception):
+    pass
+
+
+@dataclass
+class FixResult:
+    patch_id: str
+    vulnerability_id: str
+    file_path: str
+    status: str  # committed, not_applied, validation_failed, skipped
+    message: str = ""
+    commit: Optional[str] = None
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "patch_id": self.patch_id,
+            "vulnerability_id": self.vulnerability_id,
+            "file_path": self.file_path,
+            "status": self.status,
+            "message": self.message,
+            "commit": self.commit
+        }
+
+
+def git(args: List[str], cwd: str) -> subprocess.CompletedProcess:
+    return subprocess.run(git_command(args), cwd=cwd, capture_output=True, text=True, timeout=60)
+
+
+def repo_root(path: str) -> Optional[str]:
+    directory = path if os.path.isdir(path) else os.path.dirname(os.path.abspath(path))
+    result = git(['rev-parse', '--show-toplevel'], directory)
+    return result.stdout.strip() if result.returncode == 0 else None
+
+
+def apply_patch_text(source: str, original: str, patched: str) -> Optional[str]:
+    if not original.strip():
+        return None
+    if original in source:
+        return source.replace(original, patched, 1)
+
+    # LLM patches often lose the original indentation; match line by line ignoring it
+    source_lines = source.split('\n')
+    original_lines = [line.strip() for line in original.strip('\n').split('\n')]
+    for i in range(len(source_lines) - len(original_lines) + 1):
+        window = [line.strip() for line in source_lines[i:i + len(original_lines)]]
+        if window != original_lines:
+            continue
+        indent = source_lines[i][:len(source_lines[i]) - len(source_lines[i].lstrip())]
+        replacement = [
+            indent + line.strip() if line.strip() else ''
+            for line in patched.strip('\n').split('\n')
+        ]
+        return '\n'.join(source_lines[:i] + replacement + source_lines[i + len(original_lines):])
+    return None
+
+
+def validation_commands(root: str, file_path: str) -> List[Dict[str, Any]]:
+    """Checks for a patched file. Steps that build or run the project ("executes") need CODE_EXECUTION;
+    without it Go files are only parsed with gofmt."""
+    if not file_path.endswith('.go'):
+        return []
+    if not may_execute():
+        return [{"cmd": ['gofmt', '-e', '-l', file_path], "cwd": os.path.dirname(file_path), "executes": False}]
+
+    directory = os.path.dirname(file_path)
+    while directory.startswith(root):
+        if os.path.isfile(os.path.join(directory, 'go.mod')):
+            return [
+                {"cmd": ['go', 'build', './...'], "cwd": directory, "executes": True},
+                {"cmd": ['go', 'test', './...'], "cwd": directory, "executes": True},
+            ]
+        if directory == root:
+            break
+        directory = os.path.dirname(directory)
+    return [{"cmd": ['go', 'vet', os.path.basename(file_path)], "cwd": os.path.dirname(file_path), "executes": True}]
+
+
+def syntax_error(file_path: str, source: str) -> Optional[str]:
+    """Parse-only check of a patched Python file, in process (running python in the repository would
+    import modules from it)"""
+    if not file_path.endswith('.py'):
+        return None
+    try:
+        ast.parse(source, file_path)
+    except SyntaxError as e:
+        return f"line {e.lineno}: {e.msg}"
+    return None
+
+
+def run_check(step: Dict[str, Any], root: str) -> subprocess.CompletedProcess:
+    env = {"GOPROXY": "off"} if is_offline() else None  # no module downloads offline
+    try:
+        if step["executes"]:
+            return run_toolchain(step["cmd"], step["cwd"], root, env=env)
+        return subprocess.run(step["cmd"], cwd=step["cwd"], capture_output=True, text=True, timeout=600)
+    except (OSError, subprocess.TimeoutExpired, IsolationError) as e:
+        return subprocess.CompletedProcess(step["cmd"], 1, "", str(e))
+
+
+class FixApplier:
+
+    def __init__(self, branch: str = "scanner/fixes", validate: bool = True, min_confidence: float = 0.5):
+        self.branch = branch
+        self.validate = validate
+        self.min_confidence = min_confidence
+        self.root: Optional[str] = None
+        self.base_branch: Optional[str] = None
+
+    def apply(self, report: Dict[str, Any], vuln_ids: Optional[List[str]] = None) -> List[FixResult]:
+        patches = [
+            p for p in report.get("patches", [])
+            if vuln_ids is None or p.get("vulnerability_id") in vuln_ids
+        ]
+        if not patches:
+            return []
+
+        root = repo_root(patches[0]["file_path"])
+        if not root:
+            raise FixApplyError(f"{patches[0]['file_path']} is not inside a git repository")
+        if git(['status', '--porcelain', '--untracked-files=no'], root).stdout.strip():
+            raise FixApplyError(f"Working tree at {root} has uncommitted changes; commit or stash them first")
+
+        original_branch = git(['rev-parse', '--abbrev-ref', 'HEAD'], root).stdout.strip()
+        if original_branch == "HEAD":  # detached (e.g. a CI checkout): return to the commit itself
+            original_branch = git(['rev-parse', 'HEAD'], root).stdout.strip()
+            self.root, self.base_branch = root, None
+        else:
+            self.root, self.base_branch = root, original_branch
+        created = git(['checkout', '-b', self.branch], root)
+        if created.returncode != 0:
+            raise FixApplyError(f"Could not create branch {self.branch}: {created.stderr.strip()}")
+
+        vulns = {v.get("vuln_id"): v for v in report.get("vulnerabilities", [])}
+        results = []
+        try:
+            for patch in patches:
+                results.append(self._apply_one(root, patch, vulns.get(patch.get("vulnerability_id"), {}), report))
+        finally:
+            git(['checkout', original_branch], root)
+            if not any(r.status == "committed" for r in results):
+                git(['branch', '-D', self.branch], root)
+
+        return results
+
+    def _apply_one(self, root: str, patch: Dict[str, Any], vuln: Dict[str, Any], report: Dict[str, Any]) -> FixResult:
+        file_path = os.path.abspath(patch["file_path"])
+        result = FixResult(
+            patch_id=patch.get("patch_id", ""),
+            vulnerability_id=patch.get("vulnerability_id", ""),
+            file_path=file_path,
+            status="skipped"
+        )
+
+        if patch.get("confidence", 0) < self.min_confidence:
+            result.message = f"Patch confidence {patch.get('confidence', 0):.0%} is below {self.min_confidence:.0%}"
+            return result
+        if not file_path.startswith(root + os.sep) or not os.path.isfile(file_path):
+            result.message = f"File is not part of {root}"
+            return result
+
+        with open(file_path, 'r') as f:
+            source = f.read()
+        patched = apply_patch_text(source, patch.get("original_code", ""), patch.get("patched_code", ""))
+        if patched is None or patched == source:
+            result.status = "not_applied"
+            result.message = "Original code not found in the current file"
+            return result
+
+        with open(file_path, 'w') as f:
+            f.write(patched)
+
+        if self.validate:
+            error = syntax_error(file_path, patched)
+            if error:
+                git(['checkout', '--', file_path], root)
+                result.status = "validation_failed"
+                result.message = f"Patched file does not parse: {error}"
+                return result
+            for step in validation_commands(root, file_path):
+                check = run_check(step, root)
+                if check.returncode != 0:
+                    git(['checkout', '--', file_path], root)
+                    result.status = "validation_failed"
+                    result.message = f"{' '.join(step['cmd'])} failed: {(check.stderr or check.stdout).strip()[-500:]}"
+                    return result
+
+        relative = os.path.relpath(file_path, root)
+        subject = f"Fix {result.vulnerability_id}: {vuln.get('vuln_type', 'security issue')} in {relative}"
+        if vuln.get("line_number"):
+            subject += f":{vuln['line_number']}"
+        body = patch.get("patch_description", "")
+        if report.get("session_id"):
+            body += f"\n\nScanner report: {report['session_id']}"
+
+        git(['add', '--', file_path], root)
+        committed = git(['commit', '-m', subject, '-m', body.strip()], root)
+        if committed.returncode != 0:
+            git(['checkout', 'HEAD', '--', file_path], root)
+            result.status = "not_applied"
+            result.message = f"git commit failed: {committed.stderr.strip()}"
+            return result
+
+        result.status = "committed"
+        result.commit = git(['rev-parse', 'HEAD'], root).stdout.strip()
+        result.message = subject
+        logger.info(f"Committed {result.vulnerability_id} on {self.branch} as {result.commit[:12]}")
+        return result
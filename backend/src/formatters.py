@@ -0,0 +1,412 @@
+"""
+Report formatters - Render saved analysis reports as text, SARIF, HTML, or a PDF executive report (bytes)
+"""
+
+import html
+import json
+import os
+import re
+from functools import lru_cache
+from typing import Any, Callable, Dict, List, Optional, Tuple, Union
+
+from .analysis.rules import get_rule
+from .blame import group_findings
+from .ranking import SORT_ORDERS
+from .redaction import redact, redact_report
+from .trends import describe_trend_gate
+
+SEVERITY_ORDER = {"critical": 0, "high": 1, "medium": 2, "low": 3}
+SARIF_LEVELS = {"critical": "error", "high": "error", "medium": "warning", "low": "note"}
+SARIF_SCHEMA = "https://json.schemastore.org/sarif-2.1.0.json"
+
+
+def sorted_vulnerabilities(report: Dict[str, Any], sort_by: str = "severity") -> List[Dict[str, Any]]:
+    """By severity, or by fix ROI rank ("roi"; unranked findings, e.g. false positives, go last)"""
+    def severity_key(v: Dict[str, Any]) -> Tuple[int, str, int]:
+        return SEVERITY_ORDER.get(v.get("severity"), 4), v.get("file_path", ""), v.get("line_number", 0)
+
+    if sort_by == "roi":
+        return sorted(
+            report.get("vulnerabilities", []),
+            key=lambda v: ((v.get("fix_priority") or {}).get("rank") or float("inf"), severity_key(v))
+        )
+    return sorted(report.get("vulnerabilities", []), key=severity_key)
+
+
+def report_target(report: Dict[str, Any]) -> str:
+    return str(report.get("target") or report.get("project_path") or report.get("analysis_type", ""))
+
+
+@lru_cache(maxsize=64)
+def source_lines(file_path: str) -> Optional[Tuple[str, ...]]:
+    if not file_path or not os.path.isfile(file_path):
+        return None
+    with open(file_path, 'r', encoding='utf-8', errors='ignore') as f:
+        return tuple(redact(f.read()).split('\n'))
+
+
+def code_context(vuln: Dict[str, Any], context_lines: int = 0) -> List[Tuple[int, str, Optional[Tuple[int, int]]]]:
+    """(line number, text, highlighted 0-based column range or None) around the finding"""
+    line_number = vuln.get("line_number") or 0
+    lines = source_lines(vuln.get("file_path", ""))
+    if not lines or not 1 <= line_number <= len(lines):
+        snippet = vuln.get("code_snippet") or ""
+        return [(line_number, snippet, (0, len(snippet)))] if snippet else []
+
+    text = lines[line_number - 1]
+    column = vuln.get("column") or len(text) - len(text.lstrip()) + 1
+    end_column = vuln.get("end_column") or len(text.rstrip()) + 1
+    highlight = (column - 1, max(column, end_column) - 1)
+
+    start = max(1, line_number - context_lines)
+    end = min(len(lines), line_number + context_lines)
+    return [
+        (n, lines[n - 1], highlight if n == line_number else None)
+        for n in range(start, end + 1)
+    ]
+
+
+def finding_rule_id(vuln: Dict[str, Any]) -> str:
+    if vuln.get("rule_id"):
+        return vuln["rule_id"]
+    return "llm-" + re.sub(r'[^a-z0-9]+', '-', vuln.get("vuln_type", "finding").lower()).strip('-')
+
+
+def priority_label(priority: Dict[str, Any]) -> str:
+    return f"#{priority['rank']}, {priority['fix_complexity']} fix (score {priority['score']:g}, estimated by {priority['estimated_by']})"
+
+
+def blame_label(blame: Dict[str, Any]) -> str:
+    if blame.get("uncommitted"):
+        return "uncommitted changes"
+    return f"{blame['author']} in {blame['commit'][:10]} on {blame['date']} ({blame['summary']})"
+
+
+def render_text(report: Dict[str, Any], context_lines: int = 0, group_by: Optional[str] = None, sort_by: str = "severity") -> str:
+    vulnerabilities = sorted_vulnerabilities(report, sort_by)
+    lines = [
+        f"Report {report.get('session_id')}: {report_target(report)}",
+        f"{len(vulnerabilities)} finding(s)" + (f" owned by {report['team']}" if report.get("team") else ""),
+    ]
+
+    policy = (report.get("project_config") or {}).get("policy")
+    if policy:
+        lines.append(f"Policy: {policy['source']}")
+        lines.extend(f"  overruled: {violation}" for violation in policy.get("violations", []))
+    degraded = report.get("degraded")
+    if degraded:
+        lines.append(f"Degraded: LLM {degraded['reason']} during {degraded['step']}, {degraded['static_only_files']} file(s) checked with static rules only")
+    costs = report.get("costs")
+    if costs and costs.get("calls"):
+        lines.extend(cost_lines(costs))
+    baseline = report.get("baseline")
+    if baseline:
+        since = f"merge-base {baseline['merge_base'][:12]} ({baseline['base_ref']})" if baseline.get("merge_base") else f"baseline {baseline.get('session_id')}"
+        lines.append(f"Since {since}: {baseline['new']} new, {baseline['existing']} existing, {baseline['fixed']} fixed")
+    gate = report.get("gate")
+    if gate:
+        verdict = "passed" if gate["passed"] else f"FAILED ({len(gate['blocking'])} blocking)"
+        lines.append(f"Gate (fail on {gate['fail_on']} and above): {verdict}")
+    trend = report.get("trend_gate")
+    if trend:
+        lines.append(f"Trend gate ({trend['target']}): {'passed' if trend['passed'] else 'FAILED'}")
+        lines.extend(f"  {describe_trend_gate(result)}" for result in trend["gates"])
+
+    groups = group_findings(vulnerabilities, group_by) if group_by else [(None, vulnerabilities)]
+    for group, findings in groups:
+        if group:
+            lines.append("")
+            lines.append(f"== {group}: {len(findings)} finding(s)")
+        for vuln in findings:
+            lines.append("")
+            lines.extend(text_finding(vuln, context_lines))
+    return '\n'.join(lines) + '\n'
+
+
+def cost_lines(costs: Dict[str, Any], top: int = 3) -> List[str]:
+    """The report's LLM cost: total, limit, and the most expensive models, steps, files, and rules"""
+    limit = ""
+    if costs.get("max_cost") is not None:
+        limit = f", limit ${costs['max_cost']:.2f}" + (" reached" if costs.get("limit_reached") else "")
+    lines = [f"Cost: ${costs['total']:.4f} for {costs['calls']} LLM call(s), {costs['tokens']:,} tokens{limit}"]
+    for key, label in (("by_model", "models"), ("by_step", "steps"), ("by_file", "files"), ("by_rule", "rules")):
+        entries = list((costs.get(key) or {}).items())
+        if entries:
+            shown = ', '.join(f"{name} ${entry['cost']:.4f}" for name, entry in entries[:top])
+            lines.append(f"  {label}: {shown}" + (f" (+{len(entries) - top} more)" if len(entries) > top else ""))
+    return lines
+
+
+def text_finding(vuln: Dict[str, Any], context_lines: int = 0) -> List[str]:
+    lines = []
+    static_only = " [static-only]" if vuln.get("static_only") else ""
+    existing = " [existing]" if vuln.get("in_baseline") else ""
+    review = " [needs review]" if vuln.get("needs_review") else ""
+    lines.append(f"[{vuln.get('severity', '?').upper()}] {vuln.get('vuln_id')} {vuln.get('vuln_type')} ({finding_rule_id(vuln)}){static_only}{existing}{review}")
+    lines.append(f"  {vuln.get('file_path')}:{vuln.get('line_number')}")
+    lines.append(f"  {vuln.get('description')}")
+    if vuln.get("triage"):
+        lines.append(f"  Triaged {vuln['triage']['verdict'].replace('_', ' ')}: {vuln['triage']['reason']}")
+    if vuln.get("fix_priority"):
+        lines.append(f"  Fix priority: {priority_label(vuln['fix_priority'])}")
+    reachability = vuln.get("reachability")
+    if reachability and reachability["reachable"]:
+        lines.append(f"  Reachable from {reachability['entry_kind']} entry point: {' -> '.join(reachability['path'])}")
+    elif reachability and reachability.get("severity_from"):
+        lines.append(f"  Unreachable: nothing calls {reachability['function']} (lowered from {reachability['severity_from']})")
+    if vuln.get("critical_path"):
+        lines.append(f"  Critical path: {vuln['critical_path']}" + (f" (raised from {vuln['original_severity']})" if vuln.get("original_severity") else ""))
+    if vuln.get("related"):
+        lines.append(f"  Related: {', '.join(vuln['related'])}")
+    if vuln.get("code_owners"):
+        lines.append(f"  Owners: {' '.join(vuln['code_owners'])}")
+    blame = vuln.get("blame")
+    if blame:
+        lines.append(f"  Last changed: {blame_label(blame)}")
+
+    context = code_context(vuln, context_lines)
+    width = len(str(context[-1][0])) if context else 0
+    for number, text, highlight in context:
+        marker = '>' if highlight else ' '
+        lines.append(f"  {marker} {number:>{width}} | {text}")
+        if highlight:
+            start, end = highlight
+            padding = ''.join(c if c == '\t' else ' ' for c in text[:start])
+            lines.append(f"    {' ' * width} | {padding}{'^' * max(1, end - start)}")
+
+    trace = vuln.get("trace") or []
+    if trace:
+        lines.append("  Trace:")
+        for i, step in enumerate(trace, 1):
+            lines.append(f"    {i}. {step['kind']:<11} {step['file_path']}:{step['line_number']}  {step['code']}")
+
+    if vuln.get("remediation"):
+        lines.append(f"  Fix: {vuln['remediation']}")
+    return lines
+
+
+def sarif_location(file_path: str, line_number: int, snippet: str = "") -> Dict[str, Any]:
+    region: Dict[str, Any] = {"startLine": max(1, line_number or 1)}
+    if snippet:
+        region["snippet"] = {"text": snippet}
+    return {
+        "physicalLocation": {
+            "artifactLocation": {"uri": file_path},
+            "region": region
+        }
+    }
+
+
+def render_sarif(report: Dict[str, Any], context_lines: int = 0, sort_by: str = "severity") -> str:
+    vulnerabilities = sorted_vulnerabilities(report, sort_by)
+    rules: Dict[str, Dict[str, Any]] = {}
+    results = []
+
+    for vuln in vulnerabilities:
+        rule_id = finding_rule_id(vuln)
+        if rule_id not in rules:
+            rule = get_rule(rule_id)
+            descriptor: Dict[str, Any] = {
+                "id": rule_id,
+                "name": rule.name if rule else vuln.get("vuln_type", ""),
+                "shortDescription": {"text": rule.name if rule else vuln.get("vuln_type", "")},
+                "properties": {"tags": ["security"] + ([vuln["cwe_id"]] if vuln.get("cwe_id") else [])}
+            }
+            if rule:
+                descriptor["fullDescription"] = {"text": rule.description}
+                descriptor["help"] = {"text": rule.remediation}
+            rules[rule_id] = descriptor
+
+        location = sarif_location(vuln.get("file_path", ""), vuln.get("line_number", 0), vuln.get("code_snippet", ""))
+        if vuln.get("column"):
+            location["physicalLocation"]["region"]["startColumn"] = vuln["column"]
+            location["physicalLocation"]["region"]["endColumn"] = vuln.get("end_column") or vuln["column"]
+        context = code_context(vuln, context_lines) if context_lines else []
+        if context:
+            location["physicalLocation"]["contextRegion"] = {
+                "startLine": context[0][0],
+                "endLine": context[-1][0],
+                "snippet": {"text": '\n'.join(text for _, text, _ in context)}
+            }
+
+        result: Dict[str, Any] = {
+            "ruleId": rule_id,
+            "level": SARIF_LEVELS.get(vuln.get("severity"), "warning"),
+            "message": {"text": vuln.get("description", "")},
+            "locations": [location],
+            "properties": {"vulnId": vuln.get("vuln_id"), "severity": vuln.get("severity"), "confidence": vuln.get("confidence")}
+        }
+
+        if vuln.get("needs_review"):
+            result["properties"]["needsReview"] = True
+        if (vuln.get("triage") or {}).get("verdict") == "false_positive":
+            result["suppressions"] = [{"kind": "external", "justification": vuln["triage"]["reason"]}]
+
+        trace = vuln.get("trace") or []
+        if trace:
+            result["codeFlows"] = [{
+                "threadFlows": [{
+                    "locations": [
+                        {
+                            "location": {
+                                **sarif_location(step["file_path"], step["line_number"], step["code"]),
+                                "message": {"text": step["kind"]}
+                            }
+                        }
+                        for step in trace
+                    ]
+                }]
+            }]
+
+        results.append(result)
+
+    sarif = {
+        "$schema": SARIF_SCHEMA,
+        "version": "2.1.0",
+        "runs": [{
+            "tool": {
+                "driver": {
+                    "name": "Agentic Ethical Hacker",
+                    "rules": list(rules.values())
+                }
+            },
+            "results": results
+        }]
+    }
+    return json.dumps(sarif, indent=2)
+
+
+HTML_STYLE = """
+body { font-family: -apple-system, sans-serif; margin: 2rem; color: #1f2937; }
+.finding { border: 1px solid #e5e7eb; border-radius: 6px; padding: 1rem; margin-bottom: 1rem; }
+.severity { font-weight: bold; text-transform: uppercase; }
+.critical { color: #991b1b; } .high { color: #dc2626; } .medium { color: #d97706; } .low { color: #2563eb; }
+pre, code { background: #f3f4f6; font-family: monospace; }
+pre { padding: 0.5rem; overflow-x: auto; }
+ol.trace li { margin-bottom: 0.25rem; }
+.lineno { color: #9ca3af; user-select: none; }
+.hit { background: #fef3c7; display: block; }
+mark { background: #fca5a5; }
+.kind { display: inline-block; width: 7rem; color: #6b7280; }
+h2.group { border-bottom: 2px solid #e5e7eb; padding-bottom: 0.25rem; margin-top: 2rem; }
+.meta { color: #6b7280; font-size: 0.9rem; }
+"""
+
+
+def render_html(report: Dict[str, Any], context_lines: int = 0, group_by: Optional[str] = None, sort_by: str = "severity") -> str:
+    vulnerabilities = sorted_vulnerabilities(report, sort_by)
+    e = html.escape
+    title = f"Report {report.get('session_id', '')}"
+    parts = [
+        "<!DOCTYPE html>",
+        f"<html><head><meta charset=\"utf-8\"><title>{e(title)}</title><style>{HTML_STYLE}</style></head><body>",
+        f"<h1>{e(title)}</h1>",
+        f"<p>{e(report_target(report))} &mdash; {len(vulnerabilities)} finding(s)"
+        + (f" owned by {e(report['team'])}" if report.get("team") else "") + "</p>",
+    ]
+    costs = report.get("costs")
+    if costs and costs.get("calls"):
+        parts.append(f"<p class=\"meta\">{'<br>'.join(e(line.strip()) for line in cost_lines(costs))}</p>")
+
+    groups = group_findings(vulnerabilities, group_by) if group_by else [(None, vulnerabilities)]
+    for group, findings in groups:
+        if group:
+            parts.append(f"<h2 class=\"group\">{e(group)} &mdash; {len(findings)} finding(s)</h2>")
+        parts.extend(html_finding(vuln, context_lines) for vuln in findings)
+
+    parts.append("</body></html>")
+    return '\n'.join(parts) + '\n'
+
+
+def html_finding(vuln: Dict[str, Any], context_lines: int = 0) -> str:
+    e = html.escape
+    parts = []
+    severity = vuln.get("severity", "")
+    parts.append("<div class=\"finding\">")
+    parts.append(
+        f"<h2><span class=\"severity {e(severity)}\">{e(severity)}</span> "
+        f"{e(vuln.get('vuln_id', ''))} {e(vuln.get('vuln_type', ''))} <small>({e(finding_rule_id(vuln))})</small></h2>"
+    )
+    parts.append(f"<p><code>{e(vuln.get('file_path', ''))}:{vuln.get('line_number', '')}</code></p>")
+    parts.append(f"<p>{e(vuln.get('description', ''))}</p>")
+    if vuln.get("needs_review"):
+        parts.append("<p><strong>Needs human review:</strong> heuristic finding, confirm before acting on it</p>")
+    if vuln.get("fix_priority"):
+        parts.append(f"<p><strong>Fix priority:</strong> {e(priority_label(vuln['fix_priority']))}</p>")
+    if vuln.get("critical_path"):
+        raised = f" (raised from {vuln['original_severity']})" if vuln.get("original_severity") else ""
+        parts.append(f"<p><strong>Critical path:</strong> <code>{e(vuln['critical_path'])}</code>{e(raised)}</p>")
+    if vuln.get("related"):
+        parts.append(f"<p><strong>Related:</strong> {e(', '.join(vuln['related']))}</p>")
+    if vuln.get("code_owners"):
+        parts.append(f"<p><strong>Owners:</strong> {e(' '.join(vuln['code_owners']))}</p>")
+    if vuln.get("blame"):
+        parts.append(f"<p><strong>Last changed:</strong> {e(blame_label(vuln['blame']))}</p>")
+    context = code_context(vuln, context_lines)
+    if context:
+        width = len(str(context[-1][0]))
+        rows = []
+        for number, text, highlight in context:
+            lineno = f"<span class=\"lineno\">{number:>{width}} </span>"
+            if highlight:
+                start, end = highlight
+                marked = f"{e(text[:start])}<mark>{e(text[start:end])}</mark>{e(text[end:])}"
+                rows.append(f"<span class=\"hit\">{lineno}{marked}</span>")
+            else:
+                rows.append(f"{lineno}{e(text)}\n")
+        parts.append(f"<pre>{''.join(rows)}</pre>")
+
+    trace = vuln.get("trace") or []
+    if trace:
+        parts.append("<h3>Trace</h3><ol class=\"trace\">")
+        for step in trace:
+            parts.append(
+                f"<li><span class=\"kind\">{e(step['kind'])}</span>"
+                f"<code>{e(step['file_path'])}:{step['line_number']}</code> <code>{e(step['code'])}</code></li>"
+            )
+        parts.append("</ol>")
+
+    if vuln.get("remediation"):
+        parts.append(f"<p><strong>Fix:</strong> {e(vuln['remediation'])}</p>")
+    parts.append("</div>")
+    return '\n'.join(parts)
+
+
+GROUPED_FORMATS = ("text", "html")
+
+def render_json(report: Dict[str, Any], context_lines: int = 0, sort_by: str = "severity") -> str:
+    if sort_by != "severity":
+        report = {**report, "vulnerabilities": sorted_vulnerabilities(report, sort_by)}
+    return json.dumps(report, indent=2)
+
+
+def render_pdf(report: Dict[str, Any], context_lines: int = 0, sort_by: str = "severity") -> bytes:
+    from .executive import render_pdf as render_executive_pdf
+
+    return render_executive_pdf(report, context_lines=context_lines, sort_by=sort_by)
+
+
+FORMATTERS: Dict[str, Callable[..., Union[str, bytes]]] = {
+    "json": render_json,
+    "text": render_text,
+    "sarif": render_sarif,
+    "html": render_html,
+    "pdf": render_pdf,
+}
+
+
+def render_report(
+    report: Dict[str, Any],
+    fmt: str = "text",
+    context_lines: int = 0,
+    group_by: Optional[str] = None,
+    sort_by: str = "severity"
+) -> Union[str, bytes]:
+    if fmt not in FORMATTERS:
+        raise ValueError(f"Unknown format: {fmt} (choose from {', '.join(FORMATTERS)})")
+    if sort_by not in SORT_ORDERS:
+        raise ValueError(f"Unknown sort order: {sort_by} (choose from {', '.join(SORT_ORDERS)})")
+    if group_by and fmt not in GROUPED_FORMATS:
+        raise ValueError(f"Grouping applies to {' and '.join(GROUPED_FORMATS)} reports only")
+    options = {"group_by": group_by} if group_by else {}
+    return FORMATTERS[fmt](redact_report(report), context_lines=max(0, context_lines), sort_by=sort_by, **options)
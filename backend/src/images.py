@@ -0,0 +1,433 @@
+"""
+Container images - Pull or open an OCI image and extract the application it ships
+Accepts a registry reference (ghcr.io/acme/api:1.4, nginx@sha256:...), an OCI image layout directory, or a
+`docker save` / OCI archive tarball. Layers are applied in order (whiteouts included), but only files that
+look like application code, bytecode, or configuration outside the OS and dependency directories are kept,
+so the scanner runs over what the team shipped rather than the base image.
+"""
+
+import base64
+import hashlib
+import json
+import logging
+import os
+import re
+import shutil
+import tarfile
+from dataclasses import dataclass, field
+from typing import Any, Dict, IO, List, Optional, Tuple
+
+import httpx
+
+from .config.offline import require_network
+from .config.settings import get_settings
+
+logger = logging.getLogger(__name__)
+
+MANIFEST_TYPES = [
+    "application/vnd.oci.image.index.v1+json",
+    "application/vnd.oci.image.manifest.v1+json",
+    "application/vnd.docker.distribution.manifest.list.v2+json",
+    "application/vnd.docker.distribution.manifest.v2+json",
+]
+INDEX_TYPES = ("application/vnd.oci.image.index.v1+json", "application/vnd.docker.distribution.manifest.list.v2+json")
+DOCKER_HUB = "registry-1.docker.io"
+
+SOURCE_EXTENSIONS = ('.py', '.js', '.ts', '.jsx', '.tsx', '.c', '.cpp', '.h', '.hpp', '.java', '.go', '.rs', '.rb', '.php', '.kt', '.kts')
+BYTECODE_EXTENSIONS = ('.pyc', '.class', '.jar', '.war', '.ear', '.dll', '.wasm')
+CONFIG_EXTENSIONS = ('.yaml', '.yml', '.json', '.toml', '.ini', '.cfg', '.conf', '.properties', '.env', '.xml')
+CONFIG_NAMES = ('.env', 'Dockerfile', 'Procfile', 'requirements.txt', 'package.json', 'go.mod', 'pom.xml', 'Gemfile')
+# the base image's territory: skipped unless the image's working directory or entrypoint points inside
+SYSTEM_DIRS = ('bin/', 'boot/', 'dev/', 'etc/', 'lib/', 'lib32/', 'lib64/', 'libx32/', 'proc/', 'run/', 'sbin/', 'sys/',
+               'usr/', 'var/lib/', 'var/cache/', 'var/log/', 'tmp/')
+DEPENDENCY_DIRS = ('site-packages', 'dist-packages', 'node_modules', '__pycache__', '.cache', '.npm', 'go/pkg/mod', '.m2', '.gradle')
+APP_DIR_HINTS = ('usr/src/', 'usr/local/src/', 'usr/share/nginx/html/', 'var/www/')
+MAX_FILE_BYTES = 2 * 1024 * 1024
+
+
+class ImageError(RuntimeError):
+    pass
+
+
+@dataclass
+class ImageReference:
+    registry: str
+    repository: str
+    reference: str  # tag or digest
+
+    def __str__(self) -> str:
+        separator = '@' if self.reference.startswith('sha256:') else ':'
+        return f"{self.registry}/{self.repository}{separator}{self.reference}"
+
+
+def parse_reference(ref: str) -> ImageReference:
+    """registry/repository[:tag][@digest], with Docker Hub defaults for short names"""
+    name, digest = (ref.split('@', 1) + [None])[:2]
+    tag = None
+    last = name.rsplit('/', 1)[-1]
+    if ':' in last:
+        name, tag = name.rsplit(':', 1)
+    parts = name.split('/')
+    if len(parts) > 1 and ('.' in parts[0] or ':' in parts[0] or parts[0] == 'localhost'):
+        registry, repository = parts[0], '/'.join(parts[1:])
+    else:
+        registry, repository = DOCKER_HUB, name
+    if registry in ('docker.io', 'index.docker.io'):
+        registry = DOCKER_HUB
+    if registry == DOCKER_HUB and '/' not in repository:
+        repository = f"library/{repository}"
+    if not re.fullmatch(r'[a-z0-9]+(?:[._/-][a-z0-9]+)*', repository):
+        raise ImageError(f"Invalid image reference: {ref}")
+    return ImageReference(registry, repository, digest or tag or "latest")
+
+
+@dataclass
+class ImageInventory:
+    reference: str
+    digest: Optional[str] = None
+    platform: Optional[str] = None
+    layers: int = 0
+    working_dir: Optional[str] = None
+    entrypoint: List[str] = field(default_factory=list)
+    app_roots: List[str] = field(default_factory=list)
+    source_files: List[str] = field(default_factory=list)
+    bytecode_files: List[str] = field(default_factory=list)
+    config_files: List[str] = field(default_factory=list)
+    root: str = ""  # extracted filesystem
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "reference": self.reference,
+            "digest": self.digest,
+            "platform": self.platform,
+            "layers": self.layers,
+            "working_dir": self.working_dir,
+            "entrypoint": self.entrypoint,
+            "app_roots": self.app_roots,
+            "source_files": len(self.source_files),
+            "bytecode_files": self.bytecode_files,
+            "config_files": len(self.config_files)
+        }
+
+    @classmethod
+    def from_dict(cls, data: Dict[str, Any]) -> 'ImageInventory':
+        return cls(**data)
+
+
+def registry_credentials(registry: str) -> Optional[Tuple[str, str]]:
+    settings = get_settings()
+    if settings.registry_username and settings.registry_password:
+        return settings.registry_username, settings.registry_password
+    try:
+        with open(os.path.expanduser("~/.docker/config.json")) as f:
+            auths = json.load(f).get("auths", {})
+    except (OSError, ValueError):
+        return None
+    for key in (registry, f"https://{registry}", "https://index.docker.io/v1/" if registry == DOCKER_HUB else None):
+        auth = (auths.get(key) or {}).get("auth") if key else None
+        if auth:
+            user, _, password = base64.b64decode(auth).decode().partition(':')
+            return user, password
+    return None
+
+
+class RegistryClient:
+    """Just enough of the OCI distribution API to read manifests and blobs, with bearer-token auth"""
+
+    def __init__(self, ref: ImageReference):
+        self.ref = ref
+        self.base = f"{'http' if ref.registry.startswith('localhost') else 'https'}://{ref.registry}/v2/{ref.repository}"
+        self.client = httpx.Client(timeout=60, follow_redirects=True)
+        self.token: Optional[str] = None
+
+    def _request(self, path: str, accept: Optional[List[str]] = None, stream: bool = False) -> httpx.Response:
+        headers = {"Accept": ", ".join(accept)} if accept else {}
+        for _ in range(2):
+            if self.token:
+                headers["Authorization"] = f"Bearer {self.token}"
+            request = self.client.build_request("GET", f"{self.base}/{path}", headers=headers)
+            response = self.client.send(request, stream=stream)
+            if response.status_code != 401 or self.token:
+                break
+            response.close()
+            self.token = self._authenticate(response.headers.get("WWW-Authenticate", ""))
+        if response.status_code >= 400:
+            response.close()
+            raise ImageError(f"{self.ref}: registry answered {response.status_code} for {path}")
+        return response
+
+    def _authenticate(self, challenge: str) -> str:
+        params = dict(re.findall(r'(\w+)="([^"]*)"', challenge))
+        if not challenge.lower().startswith("bearer") or "realm" not in params:
+            raise ImageError(f"{self.ref}: registry requires authentication (set REGISTRY_USERNAME and REGISTRY_PASSWORD)")
+        query = {k: v for k, v in params.items() if k in ("service", "scope")}
+        query.setdefault("scope", f"repository:{self.ref.repository}:pull")
+        credentials = registry_credentials(self.ref.registry)
+        response = self.client.get(params["realm"], params=query, auth=credentials)
+        if response.status_code >= 400:
+            raise ImageError(f"{self.ref}: could not get a registry token ({response.status_code})")
+        data = response.json()
+        return data.get("token") or data.get("access_token")
+
+    def manifest(self, reference: str) -> Tuple[Dict[str, Any], str]:
+        response = self._request(f"manifests/{reference}", accept=MANIFEST_TYPES)
+        digest = response.headers.get("Docker-Content-Digest") or f"sha256:{hashlib.sha256(response.content).hexdigest()}"
+        return response.json(), digest
+
+    def blob(self, digest: str, destination: str):
+        response = self._request(f"blobs/{digest}", stream=True)
+        sha = hashlib.sha256()
+        with open(destination, 'wb') as f:
+            for chunk in response.iter_bytes():
+                sha.update(chunk)
+                f.write(chunk)
+        response.close()
+        if f"sha256:{sha.hexdigest()}" != digest:
+            os.remove(destination)
+            raise ImageError(f"{self.ref}: blob {digest} failed its digest check")
+
+
+def select_platform(index: Dict[str, Any], platform: str) -> Dict[str, Any]:
+    os_name, _, arch = platform.partition('/')
+    arch, _, variant = arch.partition('/')
+    candidates = [m for m in index.get("manifests", []) if (m.get("platform") or {}).get("os") == os_name
+                  and m["platform"].get("architecture") == arch and (not variant or m["platform"].get("variant") == variant)]
+    if not candidates:
+        available = sorted({f"{(m.get('platform') or {}).get('os')}/{(m.get('platform') or {}).get('architecture')}" for m in index.get("manifests", [])})
+        raise ImageError(f"No {platform} image in the index (available: {', '.join(available)})")
+    return candidates[0]
+
+
+class ImageSource:
+    """Manifests and blobs from a registry, an OCI layout directory, or an image tarball"""
+
+    def __init__(self, ref: str, workdir: str):
+        self.ref = ref
+        self.workdir = workdir
+        self.registry: Optional[RegistryClient] = None
+        self.layout: Optional[str] = None
+        self.archive: Optional[tarfile.TarFile] = None
+        self.members: Dict[str, tarfile.TarInfo] = {}
+
+        if os.path.isdir(ref):
+            if not os.path.isfile(os.path.join(ref, "oci-layout")) and not os.path.isfile(os.path.join(ref, "index.json")):
+                raise ImageError(f"{ref} is not an OCI image layout (no oci-layout or index.json)")
+            self.layout = ref
+        elif os.path.isfile(ref):
+            self.archive = tarfile.open(ref, 'r:*')
+            # tar and skopeo write "./index.json", docker save writes "index.json"
+            self.members = {os.path.normpath(m.name): m for m in self.archive.getmembers() if m.isfile()}
+        else:
+            require_network("Pulling a container image")
+            self.registry = RegistryClient(parse_reference(ref))
+
+    def read_json(self, name: str) -> Any:
+        if self.layout:
+            with open(os.path.join(self.layout, name)) as f:
+                return json.load(f)
+        return json.load(self.extract(name))
+
+    def has(self, name: str) -> bool:
+        if self.layout:
+            return os.path.exists(os.path.join(self.layout, name))
+        return name in self.members
+
+    def extract(self, name: str) -> IO[bytes]:
+        member = self.members.get(os.path.normpath(name))
+        if member is None:
+            raise ImageError(f"{self.ref}: {name} missing from the archive")
+        return self.archive.extractfile(member)
+
+    def blob_path(self, digest: str) -> str:
+        return f"blobs/{digest.replace(':', '/')}"
+
+    def open_blob(self, digest_or_path: str) -> IO[bytes]:
+        """A readable stream for a blob (by digest) or an archive member (by path)"""
+        name = self.blob_path(digest_or_path) if digest_or_path.startswith("sha256:") else digest_or_path
+        if self.registry:
+            destination = os.path.join(self.workdir, digest_or_path.replace(':', '_'))
+            if not os.path.exists(destination):
+                self.registry.blob(digest_or_path, destination)
+            return open(destination, 'rb')
+        if self.layout:
+            return open(os.path.join(self.layout, name), 'rb')
+        return self.extract(name)
+
+    def resolve(self, platform: str) -> Tuple[str, Dict[str, Any], List[str]]:
+        """(manifest digest, image config, layer blobs in order)"""
+        if self.registry:
+            manifest, digest = self.registry.manifest(self.registry.ref.reference)
+            if manifest.get("mediaType") in INDEX_TYPES or "manifests" in manifest:
+                manifest, digest = self.registry.manifest(select_platform(manifest, platform)["digest"])
+            with self.open_blob(manifest["config"]["digest"]) as f:
+                config = json.load(f)
+            return digest, config, [layer["digest"] for layer in manifest.get("layers", [])]
+
+        if self.has("manifest.json") and not self.has("index.json"):
+            # docker save: manifest.json lists the config and layer paths inside the archive
+            entry = self.read_json("manifest.json")[0]
+            with self.open_blob(entry["Config"]) as f:
+                config_bytes = f.read()
+            return f"sha256:{hashlib.sha256(config_bytes).hexdigest()}", json.loads(config_bytes), entry["Layers"]
+
+        index = self.read_json("index.json")
+        descriptor = index["manifests"][0] if len(index.get("manifests", [])) == 1 else select_platform(index, platform)
+        with self.open_blob(descriptor["digest"]) as f:
+            manifest = json.load(f)
+        if "manifests" in manifest:
+            descriptor = select_platform(manifest, platform)
+            with self.open_blob(descriptor["digest"]) as f:
+                manifest = json.load(f)
+        with self.open_blob(manifest["config"]["digest"]) as f:
+            config = json.load(f)
+        return descriptor["digest"], config, [layer["digest"] for layer in manifest.get("layers", [])]
+
+
+def safe_member_path(name: str) -> Optional[str]:
+    path = os.path.normpath(name.lstrip('/')).replace(os.sep, '/')
+    if path in ('.', '') or path.startswith('../') or path == '..':
+        return None
+    return path
+
+
+def classify(path: str) -> Optional[str]:
+    name = os.path.basename(path)
+    extension = os.path.splitext(name)[1].lower()
+    if extension in SOURCE_EXTENSIONS:
+        return "source"
+    if extension in BYTECODE_EXTENSIONS:
+        return "bytecode"
+    if extension in CONFIG_EXTENSIONS or name in CONFIG_NAMES:
+        return "config"
+    return None
+
+
+def is_app_path(path: str, app_roots: List[str]) -> bool:
+    if any(f"/{d}/" in f"/{path}" for d in DEPENDENCY_DIRS):
+        return False
+    if any(path.startswith(root.rstrip('/') + '/') for root in app_roots if root):
+        return True
+    if path.startswith(APP_DIR_HINTS):
+        return True
+    return not path.startswith(SYSTEM_DIRS)
+
+
+def app_roots_from_config(config: Dict[str, Any]) -> List[str]:
+    settings = config.get("config") or {}
+    roots = []
+    working_dir = (settings.get("WorkingDir") or "").strip('/')
+    if working_dir:
+        roots.append(working_dir)
+    for arg in (settings.get("Entrypoint") or []) + (settings.get("Cmd") or []):
+        if isinstance(arg, str) and arg.startswith('/') and classify(arg) in ("source", "bytecode"):
+            roots.append(os.path.dirname(arg).strip('/'))
+    return [r for r in dict.fromkeys(roots) if r]
+
+
+def make_room(root: str, path: str, member: tarfile.TarInfo, kept: Dict[str, str]):
+    """A later layer's entry replaces what earlier layers left at its path (OCI overwrite rules): a file where
+    one of its parent directories goes, a directory where a non-directory goes, a file where anything but a
+    file goes"""
+    parts = path.split('/')
+    for depth in range(1, len(parts)):
+        ancestor = '/'.join(parts[:depth])
+        if ancestor in kept:
+            os.remove(os.path.join(root, ancestor))
+            del kept[ancestor]
+    target = os.path.join(root, path)
+    if os.path.isdir(target) and not member.isdir():
+        shutil.rmtree(target)
+        for existing in [p for p in kept if p.startswith(path + '/')]:
+            del kept[existing]
+    elif path in kept and not member.isfile():
+        os.remove(target)
+        del kept[path]
+
+
+def apply_layer(stream: IO[bytes], root: str, app_roots: List[str], kept: Dict[str, str]):
+    """Extract the application files of one layer over the previous ones, honoring whiteouts"""
+    if stream.read(4) == b'\x28\xb5\x2f\xfd':
+        raise ImageError("zstd-compressed layers are not supported; re-export the image with gzip layers")
+    stream.seek(0)
+
+    with tarfile.open(fileobj=stream, mode='r:*') as layer:
+        for member in layer:
+            path = safe_member_path(member.name)
+            if not path:
+                continue
+            directory, name = os.path.split(path)
+            if name == '.wh..wh..opq':
+                for existing in [p for p in kept if p.startswith(directory + '/')]:
+                    os.remove(os.path.join(root, existing))
+                    del kept[existing]
+                continue
+            if name.startswith('.wh.'):
+                removed = os.path.join(directory, name[len('.wh.'):]) if directory else name[len('.wh.'):]
+                for existing in [p for p in kept if p == removed or p.startswith(removed + '/')]:
+                    os.remove(os.path.join(root, existing))
+                    del kept[existing]
+                continue
+            make_room(root, path, member, kept)
+            if not member.isfile() or member.size > MAX_FILE_BYTES:
+                continue
+            kind = classify(path)
+            if not kind or not is_app_path(path, app_roots):
+                continue
+            destination = os.path.join(root, path)
+            os.makedirs(os.path.dirname(destination), exist_ok=True)
+            source = layer.extractfile(member)
+            if source is None:
+                continue
+            with open(destination, 'wb') as f:
+                shutil.copyfileobj(source, f)
+            kept[path] = kind
+
+
+def image_cache_dir() -> str:
+    return os.path.expanduser(get_settings().image_cache_dir)
+
+
+def extract_image(ref: str, platform: Optional[str] = None) -> ImageInventory:
+    """Pull or open an image and extract its application files; results are cached per image digest"""
+    platform = platform or get_settings().image_platform
+    downloads = os.path.join(image_cache_dir(), "blobs")
+    os.makedirs(downloads, exist_ok=True)
+    source = ImageSource(ref, downloads)
+    digest, config, layers = source.resolve(platform)
+
+    workdir = os.path.join(image_cache_dir(), digest.replace(':', '_'))
+    inventory_file = os.path.join(workdir, "inventory.json")
+    if os.path.isfile(inventory_file):
+        with open(inventory_file) as f:
+            inventory = ImageInventory.from_dict(json.load(f))
+        inventory.reference = ref
+        logger.info(f"Using the cached extraction of {ref} ({digest})")
+        return inventory
+
+    root = os.path.join(workdir, "rootfs")
+    shutil.rmtree(workdir, ignore_errors=True)
+    os.makedirs(root)
+    app_roots = app_roots_from_config(config)
+    kept: Dict[str, str] = {}
+    for layer in layers:
+        with source.open_blob(layer) as stream:
+            apply_layer(stream, root, app_roots, kept)
+
+    settings = config.get("config") or {}
+    inventory = ImageInventory(
+        reference=ref,
+        digest=digest,
+        platform=f"{config.get('os', '')}/{config.get('architecture', '')}".strip('/') or None,
+        layers=len(layers),
+        working_dir=settings.get("WorkingDir") or None,
+        entrypoint=list(settings.get("Entrypoint") or []) + list(settings.get("Cmd") or []),
+        app_roots=['/' + r for r in app_roots],
+        source_files=sorted('/' + p for p, kind in kept.items() if kind == "source"),
+        bytecode_files=sorted('/' + p for p, kind in kept.items() if kind == "bytecode"),
+        config_files=sorted('/' + p for p, kind in kept.items() if kind == "config"),
+        root=root
+    )
+    with open(inventory_file, 'w') as f:
+        json.dump({**inventory.to_dict(), "source_files": inventory.source_files, "config_files": inventory.config_files, "root": root}, f, indent=2)
+    logger.info(f"Extracted {len(kept)} application files from {len(layers)} layers of {ref}")
+    return inventory
@@ -0,0 +1,16 @@
+"""
+Integrations - Connections to external services (code hosting, issue trackers, notifications)
+"""
+
+from .pull_requests import PullRequest, PullRequestError, open_fix_pull_request
+from .trackers import TRACKERS, TrackerError, export_findings, get_tracker
+
+__all__ = [
+    'PullRequest',
+    'PullRequestError',
+    'open_fix_pull_request',
+    'TRACKERS',
+    'TrackerError',
+    'export_findings',
+    'get_tracker',
+]
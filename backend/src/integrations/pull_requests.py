@@ -0,0 +1,198 @@
+"""
+Fix Pull Requests - Open a GitHub pull request or GitLab merge request for a batch of applied fixes
+"""
+
+import logging
+import re
+from dataclasses import dataclass, field
+from typing import Any, Dict, List, Optional, Tuple
+from urllib.parse import quote, urlparse
+
+import httpx
+
+from ..config.settings import get_settings
+from ..fixes import git
+from ..redaction import redact
+from ..reports import report_link
+
+logger = logging.getLogger(__name__)
+
+SEVERITY_ORDER = ["critical", "high", "medium", "low"]
+REMOTE_URL = re.compile(r'^(?:https?://(?:[^@/]+@)?|ssh://(?:[^@/]+@)?|[^@/]+@)([^/:]+)(?::\d+)?[/:](.+?)(?:\.git)?/?$')
+
+
+class PullRequestError(Exception):
+    pass
+
+
+@dataclass
+class PullRequest:
+    provider: str
+    url: str
+    number: int
+    branch: str
+    base: str
+    labels: List[str] = field(default_factory=list)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "provider": self.provider,
+            "url": self.url,
+            "number": self.number,
+            "branch": self.branch,
+            "base": self.base,
+            "labels": self.labels
+        }
+
+
+def parse_remote(url: str) -> Tuple[str, str]:
+    match = REMOTE_URL.match(url.strip())
+    if not match:
+        raise PullRequestError(f"Unrecognized git remote URL: {url}")
+    return match.group(1), match.group(2)
+
+
+def severity_labels(findings: List[Dict[str, Any]]) -> List[str]:
+    severities = {f.get("severity") for f in findings}
+    return ["security"] + [f"severity: {s}" for s in SEVERITY_ORDER if s in severities]
+
+
+def build_description(report: Dict[str, Any], results: List[Dict[str, Any]]) -> str:
+    vulns = {v.get("vuln_id"): v for v in report.get("vulnerabilities", [])}
+    patches = {p.get("patch_id"): p for p in report.get("patches", [])}
+    committed = [r for r in results if r.get("status") == "committed"]
+
+    lines = [
+        f"Automated security fixes from scanner report [`{report.get('session_id')}`]({report_link(report)}).",
+        "",
+        "Each finding is fixed in its own commit. Please review every change before merging.",
+        "",
+        "| Finding | Severity | Type | Location | Rule |",
+        "|---|---|---|---|---|",
+    ]
+    for result in committed:
+        vuln = vulns.get(result["vulnerability_id"], {})
+        location = f"`{vuln.get('file_path', result['file_path'])}:{vuln.get('line_number', '')}`"
+        lines.append(
+            f"| {result['vulnerability_id']} | {vuln.get('severity', '')} | {vuln.get('vuln_type', '')} "
+            f"| {location} | {vuln.get('rule_id') or 'LLM'} |"
+        )
+
+    for result in committed:
+        vuln = vulns.get(result["vulnerability_id"], {})
+        patch = patches.get(result["patch_id"], {})
+        lines += [
+            "",
+            f"### {result['vulnerability_id']}: {vuln.get('vuln_type', '')}",
+            "",
+            vuln.get("description", ""),
+            "",
+            f"**Fix:** {patch.get('patch_description', '')} (commit {(result.get('commit') or '')[:12]})",
+        ]
+
+    skipped = [r for r in results if r.get("status") != "committed"]
+    if skipped:
+        lines += ["", "### Not included", ""]
+        lines += [f"- {r['vulnerability_id']}: {r['status']} - {r.get('message', '')}" for r in skipped]
+
+    return redact('\n'.join(lines)) + '\n'
+
+
+class GitHubProvider:
+    name = "github"
+
+    def __init__(self, token: str, api_url: str = "https://api.github.com"):
+        self.api_url = api_url.rstrip('/')
+        self.headers = {"Authorization": f"Bearer {token}", "Accept": "application/vnd.github+json"}
+
+    def create(self, project: str, head: str, base: str, title: str, body: str, labels: List[str]) -> PullRequest:
+        with httpx.Client(headers=self.headers, timeout=30) as client:
+            response = client.post(f"{self.api_url}/repos/{project}/pulls", json={
+                "title": title, "head": head, "base": base, "body": body
+            })
+            if response.status_code >= 400:
+                raise PullRequestError(f"GitHub refused the pull request: {response.status_code} {response.text[:300]}")
+            pr = response.json()
+
+            labeled = client.post(f"{self.api_url}/repos/{project}/issues/{pr['number']}/labels", json={"labels": labels})
+            if labeled.status_code >= 400:
+                logger.warning(f"Could not label pull request #{pr['number']}: {labeled.status_code}")
+
+        return PullRequest(self.name, pr["html_url"], pr["number"], head, base, labels)
+
+
+class GitLabProvider:
+    name = "gitlab"
+
+    def __init__(self, token: str, url: str = "https://gitlab.com"):
+        self.api_url = f"{url.rstrip('/')}/api/v4"
+        self.headers = {"PRIVATE-TOKEN": token}
+
+    def create(self, project: str, head: str, base: str, title: str, body: str, labels: List[str]) -> PullRequest:
+        with httpx.Client(headers=self.headers, timeout=30) as client:
+            response = client.post(f"{self.api_url}/projects/{quote(project, safe='')}/merge_requests", json={
+                "title": title,
+                "source_branch": head,
+                "target_branch": base,
+                "description": body,
+                "labels": ','.join(labels),
+                "remove_source_branch": True
+            })
+            if response.status_code >= 400:
+                raise PullRequestError(f"GitLab refused the merge request: {response.status_code} {response.text[:300]}")
+            mr = response.json()
+
+        return PullRequest(self.name, mr["web_url"], mr["iid"], head, base, labels)
+
+
+def get_provider(host: str):
+    from ..remote import gitlab_hosts
+
+    settings = get_settings()
+    gitlab_host = urlparse(settings.gitlab_url).hostname
+    github_host = urlparse(settings.github_api_url).hostname if settings.github_api_url else None
+    host = host.lower()
+
+    if host in ("github.com", github_host):
+        if not settings.github_token:
+            raise PullRequestError("GITHUB_TOKEN is not configured")
+        return GitHubProvider(settings.github_token, settings.github_api_url or "https://api.github.com")
+    if host in gitlab_hosts():
+        if not settings.gitlab_token:
+            raise PullRequestError("GITLAB_TOKEN is not configured")
+        return GitLabProvider(settings.gitlab_token, settings.gitlab_url if host == gitlab_host else f"https://{host}")
+    raise PullRequestError(f"No pull request integration for {host} (add self-managed GitLab hosts to GITLAB_HOSTS)")
+
+
+def open_fix_pull_request(
+    report: Dict[str, Any],
+    results: List[Dict[str, Any]],
+    root: str,
+    branch: str,
+    base: Optional[str],
+    remote: str = "origin",
+    provider: Optional[Any] = None
+) -> PullRequest:
+    committed = [r for r in results if r.get("status") == "committed"]
+    if not committed:
+        raise PullRequestError("No committed fixes to open a pull request for")
+    if not base:
+        raise PullRequestError("The fixes were applied on a detached HEAD; check out the branch the pull request should target first")
+
+    remote_url = git(['remote', 'get-url', remote], root)
+    if remote_url.returncode != 0:
+        raise PullRequestError(f"Git remote '{remote}' is not configured")
+    host, project = parse_remote(remote_url.stdout)
+    provider = provider or get_provider(host)
+
+    pushed = git(['push', '--set-upstream', remote, branch], root)
+    if pushed.returncode != 0:
+        raise PullRequestError(f"git push failed: {pushed.stderr.strip()}")
+
+    vulns = {v.get("vuln_id"): v for v in report.get("vulnerabilities", [])}
+    findings = [vulns.get(r["vulnerability_id"], {}) for r in committed]
+    title = f"Security fixes for {len(committed)} finding(s) from scan {report.get('session_id')}"
+
+    pull_request = provider.create(project, branch, base, title, build_description(report, results), severity_labels(findings))
+    logger.info(f"Opened {provider.name} pull request {pull_request.url}")
+    return pull_request
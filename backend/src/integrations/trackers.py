@@ -0,0 +1,324 @@
+"""
+Issue Trackers - File confirmed findings as Jira issues (or send them to a generic webhook), keep the
+issues in step with later scans, and close them once their findings disappear
+Filed issues are remembered by finding fingerprint, so re-exporting a scan updates issues instead of
+duplicating them; Jira issues also carry a fingerprint label, so a lost store is rebuilt from Jira itself.
+"""
+
+import json
+import logging
+import os
+import threading
+import time
+from dataclasses import asdict, dataclass
+from typing import Any, Dict, List, Optional, Tuple
+
+import httpx
+
+from ..config.settings import get_settings
+from ..redaction import redact, redact_report
+from ..reports import finding_fingerprint, report_link
+from ..triage import relative_path, repo_key, report_root
+
+logger = logging.getLogger(__name__)
+
+TRACKERS = ("jira", "webhook")
+
+
+class TrackerError(Exception):
+    pass
+
+
+@dataclass
+class TrackedIssue:
+    tracker: str
+    repo: str
+    fingerprint: str
+    key: str
+    url: str = ""
+    status: str = "open"  # open, closed
+    severity: str = ""
+    location: str = ""  # file:line when last filed or updated
+    session_id: Optional[str] = None  # last report that exported it
+    created_at: float = 0.0
+    updated_at: float = 0.0
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+class TrackerStore:
+    """Issues filed per tracker and repo, persisted as JSON next to the triage store"""
+
+    def __init__(self, path: str):
+        self.path = path
+        self._lock = threading.Lock()
+
+    def _read(self) -> List[TrackedIssue]:
+        if not os.path.exists(self.path):
+            return []
+        with open(self.path, 'r') as f:
+            data = json.load(f)
+        return [TrackedIssue(**issue) for issue in data.get("issues", [])]
+
+    def _write(self, issues: List[TrackedIssue]):
+        os.makedirs(os.path.dirname(os.path.abspath(self.path)), exist_ok=True)
+        tmp = f"{self.path}.tmp"
+        with open(tmp, 'w') as f:
+            json.dump({"issues": [issue.to_dict() for issue in issues]}, f, indent=2)
+        os.replace(tmp, self.path)
+
+    def for_repo(self, tracker: str, repo: str) -> Dict[str, TrackedIssue]:
+        """fingerprint -> newest issue filed for it"""
+        issues = sorted(
+            (i for i in self._read() if i.tracker == tracker and i.repo == repo),
+            key=lambda i: i.created_at
+        )
+        return {issue.fingerprint: issue for issue in issues}
+
+    def save(self, changed: List[TrackedIssue]):
+        # a webhook receiver that returns no key gets the fingerprint as key, which two repos can share
+        keys = {(i.tracker, i.repo, i.key) for i in changed}
+        with self._lock:
+            issues = [i for i in self._read() if (i.tracker, i.repo, i.key) not in keys]
+            self._write(issues + changed)
+
+
+def get_tracker_store() -> TrackerStore:
+    return TrackerStore(get_settings().tracker_file)
+
+
+def location(vuln: Dict[str, Any], root: str) -> str:
+    return f"{relative_path(vuln.get('file_path', ''), root)}:{vuln.get('line_number', 0)}"
+
+
+def issue_title(vuln: Dict[str, Any], root: str) -> str:
+    return f"[{vuln.get('severity', 'unknown')}] {vuln.get('vuln_type', 'Finding')} in {location(vuln, root)}"
+
+
+def issue_description(report: Dict[str, Any], vuln: Dict[str, Any], root: str, fingerprint: str) -> str:
+    """Plain text that reads the same in Jira's wiki markup and in webhook consumers, with secrets masked"""
+    lines = [
+        vuln.get("description", ""),
+        "",
+        f"Location: {location(vuln, root)}",
+        f"Severity: {vuln.get('severity', '')}",
+        f"Rule: {vuln.get('rule_id') or 'LLM analysis'}",
+    ]
+    if vuln.get("cwe_id"):
+        lines.append(f"CWE: {vuln['cwe_id']}")
+    if (vuln.get("triage") or {}).get("reason"):
+        lines.append(f"Triage: {vuln['triage']['verdict']} - {vuln['triage']['reason']}")
+    if vuln.get("code_snippet"):
+        lines += ["", "{noformat}", vuln["code_snippet"].rstrip(), "{noformat}"]
+    if vuln.get("fix_suggestion"):
+        lines += ["", f"Suggested fix: {vuln['fix_suggestion']}"]
+    lines += [
+        "",
+        f"Reported by scan {report.get('session_id')}: {report_link(report)}",
+        f"Finding fingerprint: {fingerprint}",
+    ]
+    return redact('\n'.join(lines))
+
+
+class JiraTracker:
+    """Jira Cloud (email + API token) or Server/Data Center (personal access token) through REST API v2"""
+    name = "jira"
+
+    def __init__(self, url: str, project: str, token: str, email: Optional[str] = None,
+                 issue_type: str = "Bug", done_transition: str = "Done"):
+        self.api_url = f"{url.rstrip('/')}/rest/api/2"
+        self.browse_url = f"{url.rstrip('/')}/browse"
+        self.project = project
+        self.issue_type = issue_type
+        self.done_transition = done_transition
+        self.auth = (email, token) if email else None
+        self.headers = {} if email else {"Authorization": f"Bearer {token}"}
+
+    def request(self, method: str, path: str, **kwargs) -> Any:
+        try:
+            with httpx.Client(auth=self.auth, headers=self.headers, timeout=30) as client:
+                response = client.request(method, f"{self.api_url}{path}", **kwargs)
+        except httpx.HTTPError as e:
+            raise TrackerError(f"Cannot reach Jira at {self.api_url}: {e}")
+        if response.status_code >= 400:
+            raise TrackerError(f"Jira refused {method} {path}: {response.status_code} {response.text[:300]}")
+        return response.json() if response.content else None
+
+    @staticmethod
+    def label(fingerprint: str) -> str:
+        return f"sast-{fingerprint}"
+
+    def labels(self, vuln: Dict[str, Any], fingerprint: str) -> List[str]:
+        return ["security", self.label(fingerprint), f"severity-{vuln.get('severity', 'unknown')}"]
+
+    def find(self, fingerprint: str) -> Optional[Tuple[str, str, bool]]:
+        """An issue filed earlier for the finding (key, url, still open), found by its fingerprint label"""
+        jql = f'project = "{self.project}" AND labels = "{self.label(fingerprint)}" ORDER BY created DESC'
+        result = self.request("GET", "/search", params={"jql": jql, "fields": "status", "maxResults": 1})
+        issues = result.get("issues") or []
+        if not issues:
+            return None
+        category = ((issues[0]["fields"].get("status") or {}).get("statusCategory") or {}).get("key")
+        key = issues[0]["key"]
+        return key, f"{self.browse_url}/{key}", category != "done"
+
+    def create(self, title: str, description: str, vuln: Dict[str, Any], fingerprint: str) -> Tuple[str, str]:
+        issue = self.request("POST", "/issue", json={"fields": {
+            "project": {"key": self.project},
+            "issuetype": {"name": self.issue_type},
+            "summary": title,
+            "description": description,
+            "labels": self.labels(vuln, fingerprint)
+        }})
+        return issue["key"], f"{self.browse_url}/{issue['key']}"
+
+    def update(self, key: str, title: str, description: str, vuln: Dict[str, Any], fingerprint: str):
+        self.request("PUT", f"/issue/{key}", json={"fields": {
+            "summary": title,
+            "description": description,
+            "labels": self.labels(vuln, fingerprint)
+        }})
+
+    def close(self, key: str, comment: str):
+        self.request("POST", f"/issue/{key}/comment", json={"body": comment})
+        transitions = self.request("GET", f"/issue/{key}/transitions").get("transitions") or []
+        done = next((t for t in transitions if t["name"].lower() == self.done_transition.lower()), None)
+        if not done:
+            names = ', '.join(t["name"] for t in transitions) or "none"
+            raise TrackerError(f"Jira issue {key} has no '{self.done_transition}' transition (available: {names}); set JIRA_DONE_TRANSITION")
+        self.request("POST", f"/issue/{key}/transitions", json={"transition": {"id": done["id"]}})
+
+
+class WebhookTracker:
+    """POSTs opened/updated/closed events; a JSON response with a key (and url) names the receiver's issue"""
+    name = "webhook"
+
+    def __init__(self, url: str):
+        self.url = url
+
+    def send(self, event: str, payload: Dict[str, Any]) -> Dict[str, Any]:
+        try:
+            with httpx.Client(timeout=30) as client:
+                response = client.post(self.url, json={"event": event, **payload})
+        except httpx.HTTPError as e:
+            raise TrackerError(f"Cannot reach the tracker webhook: {e}")
+        if response.status_code >= 400:
+            raise TrackerError(f"Tracker webhook refused the {event} event: {response.status_code} {response.text[:300]}")
+        try:
+            body = response.json()
+        except ValueError:
+            return {}
+        return body if isinstance(body, dict) else {}
+
+    def find(self, fingerprint: str) -> Optional[Tuple[str, str, bool]]:
+        return None  # the store is the only record of what the receiver filed
+
+    def create(self, title: str, description: str, vuln: Dict[str, Any], fingerprint: str) -> Tuple[str, str]:
+        body = self.send("opened", {"fingerprint": fingerprint, "title": title, "description": description, "finding": vuln})
+        return str(body.get("key") or fingerprint), str(body.get("url") or "")
+
+    def update(self, key: str, title: str, description: str, vuln: Dict[str, Any], fingerprint: str):
+        self.send("updated", {"key": key, "fingerprint": fingerprint, "title": title, "description": description, "finding": vuln})
+
+    def close(self, key: str, comment: str):
+        self.send("closed", {"key": key, "comment": comment})
+
+
+def get_tracker(name: str):
+    settings = get_settings()
+    if name == "jira":
+        missing = [env for env, value in (("JIRA_URL", settings.jira_url), ("JIRA_PROJECT", settings.jira_project),
+                                          ("JIRA_API_TOKEN", settings.jira_api_token)) if not value]
+        if missing:
+            raise TrackerError(f"{', '.join(missing)} not configured")
+        return JiraTracker(settings.jira_url, settings.jira_project, settings.jira_api_token, settings.jira_email,
+                           settings.jira_issue_type, settings.jira_done_transition)
+    if name == "webhook":
+        if not settings.tracker_webhook_url:
+            raise TrackerError("TRACKER_WEBHOOK_URL is not configured")
+        return WebhookTracker(settings.tracker_webhook_url)
+    raise TrackerError(f"Unknown tracker {name!r} (choose from {', '.join(TRACKERS)})")
+
+
+def exportable(vuln: Dict[str, Any], include_unconfirmed: bool) -> bool:
+    verdict = (vuln.get("triage") or {}).get("verdict")
+    return verdict == "confirmed" or (include_unconfirmed and verdict != "false_positive")
+
+
+def export_findings(
+    report: Dict[str, Any],
+    tracker,
+    store: Optional[TrackerStore] = None,
+    include_unconfirmed: bool = False,
+    dry_run: bool = False
+) -> List[Dict[str, Any]]:
+    """Create issues for new confirmed findings, update ones whose severity or location changed, and
+    close issues whose findings are no longer reported (fixed, or triaged as false positives).
+    Closing only follows completed project scans, since a file or diff report does not show what was fixed
+    and a failed scan reports nothing at all.
+    Progress is saved even when the tracker fails part way, so a retry does not file duplicates.
+    A dry run still searches the tracker but creates, updates, closes, and saves nothing."""
+    store = store or get_tracker_store()
+    report = redact_report(report)  # findings go out in webhook events and issue text
+    repo, root = repo_key(report), report_root(report)
+    filed = store.for_repo(tracker.name, repo)
+    session_id = report.get("session_id")
+    now = time.time()
+    actions, changed = [], []
+
+    present = {}
+    for vuln in report.get("vulnerabilities", []):
+        present.setdefault(finding_fingerprint(vuln, root), vuln)
+
+    try:
+        for fingerprint, vuln in present.items():
+            if not exportable(vuln, include_unconfirmed):
+                continue
+            issue = filed.get(fingerprint)
+            if issue is None:  # a read, so dry runs search too and do not report a known issue as new
+                existing = tracker.find(fingerprint)
+                if existing:  # filed before this store existed, or from another machine
+                    key, url, still_open = existing
+                    issue = TrackedIssue(tracker.name, repo, fingerprint, key, url, "open" if still_open else "closed", created_at=now)
+
+            title, description = issue_title(vuln, root), issue_description(report, vuln, root, fingerprint)
+            action = {"finding": vuln.get("vuln_id"), "fingerprint": fingerprint}
+            if issue and issue.status == "open":
+                if (issue.severity, issue.location) == (vuln.get("severity", ""), location(vuln, root)):
+                    actions.append({**action, "action": "unchanged", "key": issue.key, "url": issue.url})
+                    continue
+                if not dry_run:
+                    tracker.update(issue.key, title, description, vuln, fingerprint)
+                action.update(action="updated", key=issue.key, url=issue.url)
+            else:
+                if issue:  # the finding came back after its issue was closed: file a fresh one that points at it
+                    description += f"\nPreviously filed as {issue.key}, closed when the finding disappeared."
+                key, url = tracker.create(title, description, vuln, fingerprint) if not dry_run else ("", "")
+                issue = TrackedIssue(tracker.name, repo, fingerprint, key, url, created_at=now)
+                action.update(action="created", key=key, url=url)
+                if not dry_run:
+                    logger.info(f"Filed {tracker.name} issue {key} for {vuln.get('vuln_id')}")
+
+            issue.status, issue.severity, issue.location = "open", vuln.get("severity", ""), location(vuln, root)
+            issue.session_id, issue.updated_at = session_id, now
+            changed.append(issue)
+            actions.append(action)
+
+        if report.get("analysis_type") == "project" and report.get("status") == "completed":
+            for fingerprint, issue in filed.items():
+                vuln = present.get(fingerprint)
+                if issue.status != "open" or (vuln and (vuln.get("triage") or {}).get("verdict") != "false_positive"):
+                    continue
+                reason = "triaged as a false positive" if vuln else "no longer reported"
+                if not dry_run:
+                    tracker.close(issue.key, f"Closed automatically: the finding is {reason} as of scan {session_id} ({report_link(report)}).")
+                    logger.info(f"Closed {tracker.name} issue {issue.key}: finding {reason}")
+                issue.status, issue.session_id, issue.updated_at = "closed", session_id, now
+                changed.append(issue)
+                actions.append({"finding": vuln.get("vuln_id") if vuln else None, "fingerprint": fingerprint,
+                                "action": "closed", "key": issue.key, "url": issue.url, "reason": reason})
+    finally:
+        if changed and not dry_run:
+            store.save(changed)
+    return actions
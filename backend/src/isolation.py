@@ -0,0 +1,141 @@
+"""
+Isolation - Scanned code is read, never built or run, unless the operator opts in
+The rules, the call graph, and the agents treat scanned files as text. The few steps that could run code
+from a scanned repository go through this module:
+
+- Toolchain commands that build or run the code (go build / go test when validating a generated fix run the
+  module's cgo, generated files, and tests). These are the only ones: scans never load go/packages, since
+  type resolution and the call graph read source text. CODE_EXECUTION decides: "off" (the default) refuses them and
+  fix validation falls back to parse-only checks; "sandbox" runs them in a throwaway container with no
+  network, the source mounted read-only, all capabilities dropped, no-new-privileges, the runtime's seccomp
+  profile (or SANDBOX_SECCOMP_PROFILE), and memory/CPU/process limits; "host" runs them directly, for
+  trusted code only.
+- git, which honors the repository's own config: core.fsmonitor and hooks name programs to run. Every git
+  call goes through git_command(), which switches those off. Archives are unpacked without their .git
+  directories, so a scanned tarball cannot bring its own git config.
+- The project config of code from elsewhere (an unpacked archive, a remote checkout, or an image): its
+  .sastscan.yaml may not make the scanner fetch a policy from a URL; see config/project.py.
+"""
+
+import os
+import shutil
+import subprocess
+import uuid
+from typing import Dict, List, Optional, Set
+
+from .config.settings import get_settings
+
+CODE_EXECUTION_MODES = ("off", "sandbox", "host")
+SANDBOX_RUNTIMES = ("docker", "podman")
+SANDBOX_SOURCE = "/src"
+
+# temp directories holding unpacked archives and remote checkouts while they are scanned
+UNTRUSTED_ROOTS: Set[str] = set()
+
+# config a repository can set to make git run a program; forced off for every call
+GIT_SAFETY = [
+    "-c", "core.fsmonitor=false",
+    "-c", f"core.hooksPath={os.devnull}",
+    "-c", "protocol.ext.allow=never",
+]
+
+
+class IsolationError(RuntimeError):
+    pass
+
+
+def git_command(args: List[str]) -> List[str]:
+    return ["git", *GIT_SAFETY, *args]
+
+
+def is_git_metadata(relative_path: str) -> bool:
+    """Whether a path (relative, '/'-separated) is git metadata: a .git directory or anything inside one, or a
+    .git file at any depth (a submodule/worktree gitfile, which can point git at another repository)"""
+    return ".git" in relative_path.split("/")
+
+
+def mark_untrusted(root: str):
+    UNTRUSTED_ROOTS.add(os.path.realpath(root))
+
+
+def release_untrusted(root: str):
+    UNTRUSTED_ROOTS.discard(os.path.realpath(root))
+
+
+def untrusted_root(path: str) -> Optional[str]:
+    """The unpacked archive, remote checkout, or extracted image (IMAGE_CACHE_DIR) a path is inside, if any"""
+    path = os.path.realpath(path)
+    roots = UNTRUSTED_ROOTS | {os.path.realpath(os.path.expanduser(get_settings().image_cache_dir))}
+    return next((root for root in roots if path == root or path.startswith(root + os.sep)), None)
+
+
+def code_execution_mode() -> str:
+    mode = (get_settings().code_execution or "off").lower()
+    if mode not in CODE_EXECUTION_MODES:
+        raise IsolationError(f"CODE_EXECUTION={mode} is not one of {', '.join(CODE_EXECUTION_MODES)}")
+    return mode
+
+
+def may_execute() -> bool:
+    """Whether steps that build or run scanned code are enabled (sandboxed or not)"""
+    return code_execution_mode() != "off"
+
+
+def sandbox_runtime() -> str:
+    configured = get_settings().sandbox_runtime
+    for runtime in ([configured] if configured else SANDBOX_RUNTIMES):
+        if shutil.which(runtime):
+            return runtime
+    raise IsolationError(f"CODE_EXECUTION=sandbox needs {configured or 'docker or podman'} on the PATH")
+
+
+def sandbox_command(cmd: List[str], cwd: str, root: str, env: Optional[Dict[str, str]] = None, name: str = "") -> List[str]:
+    """cmd run in a locked-down container with root mounted read-only at /src and cwd as the working directory"""
+    settings = get_settings()
+    root = os.path.abspath(root)
+    relative = os.path.relpath(os.path.abspath(cwd), root)
+    if relative.startswith('..'):
+        raise IsolationError(f"{cwd} is outside the sandboxed tree {root}")
+
+    command = [
+        sandbox_runtime(), "run", "--rm",
+        *(["--name", name] if name else []),
+        "--network", "none",
+        "--read-only",
+        "--tmpfs", "/tmp:rw,exec,size=1g",
+        "--cap-drop", "ALL",
+        "--security-opt", "no-new-privileges",
+        "--pids-limit", "256",
+        "--memory", settings.sandbox_memory,
+        "--cpus", str(settings.sandbox_cpus),
+        "--user", f"{os.getuid()}:{os.getgid()}" if hasattr(os, "getuid") else "65534:65534",
+        "-v", f"{root}:{SANDBOX_SOURCE}:ro",
+        "-w", os.path.join(SANDBOX_SOURCE, relative).replace(os.sep, '/'),
+        # build caches live in the tmpfs; modules come from the vendor directory or the mounted cache
+        "-e", "HOME=/tmp", "-e", "GOCACHE=/tmp/gocache", "-e", "GOPATH=/tmp/go", "-e", "GOPROXY=off",
+        "-e", "GOFLAGS=-buildvcs=false",
+    ]
+    if settings.sandbox_seccomp_profile:
+        command += ["--security-opt", f"seccomp={os.path.expanduser(settings.sandbox_seccomp_profile)}"]
+    if settings.sandbox_gomodcache:
+        command += ["-v", f"{os.path.expanduser(settings.sandbox_gomodcache)}:/gomodcache:ro", "-e", "GOMODCACHE=/gomodcache"]
+    for variable, value in (env or {}).items():
+        command += ["-e", f"{variable}={value}"]
+    return command + [settings.sandbox_image, *cmd]
+
+
+def run_toolchain(cmd: List[str], cwd: str, root: str, timeout: int = 600,
+                  env: Optional[Dict[str, str]] = None) -> subprocess.CompletedProcess:
+    """Run a command that builds or executes code under root, as CODE_EXECUTION allows; env adds variables"""
+    mode = code_execution_mode()
+    if mode == "off":
+        raise IsolationError(f"{' '.join(cmd)} would build or run scanned code; set CODE_EXECUTION=sandbox (or host for trusted code)")
+    if mode == "host":
+        return subprocess.run(cmd, cwd=cwd, capture_output=True, text=True, timeout=timeout, env={**os.environ, **(env or {})})
+    name = f"sastscan-{uuid.uuid4().hex[:12]}"
+    try:
+        return subprocess.run(sandbox_command(cmd, cwd, root, env, name), capture_output=True, text=True, timeout=timeout)
+    except subprocess.TimeoutExpired:
+        # killing the client leaves the container running
+        subprocess.run([sandbox_runtime(), "kill", name], capture_output=True, timeout=60)
+        raise
@@ -0,0 +1,93 @@
+"""
+Scan budget - Token and cost accounting per scan, with an optional spending limit
+A scan opens a CostLedger with track_costs(); every LLM call made while it is open (in the same task, or in
+tasks started from it) is recorded by model, by agent step, and by the file and rule it was made for, as
+labeled with cost_scope(). Once the recorded cost reaches the ledger's max_cost the client refuses further
+calls with LLMUnavailableError("budget"), so the pipeline finishes the scan with static rules only, as it
+does when the provider's own budget runs out.
+"""
+
+import contextvars
+from contextlib import contextmanager
+from dataclasses import dataclass, field
+from typing import Any, Dict, Iterator, Optional
+
+_ledger: contextvars.ContextVar[Optional["CostLedger"]] = contextvars.ContextVar("cost_ledger", default=None)
+_scope: contextvars.ContextVar[Dict[str, str]] = contextvars.ContextVar("cost_scope", default={})
+
+# breakdowns in the report: scope label -> report key
+BREAKDOWNS = {"model": "by_model", "step": "by_step", "file": "by_file", "rule": "by_rule"}
+
+
+@dataclass
+class CostLedger:
+    max_cost: Optional[float] = None
+    cost: float = 0.0
+    tokens: int = 0
+    calls: int = 0
+    refused_calls: int = 0
+    totals: Dict[str, Dict[str, Dict[str, Any]]] = field(default_factory=lambda: {key: {} for key in BREAKDOWNS.values()})
+
+    @property
+    def exhausted(self) -> bool:
+        return self.max_cost is not None and self.cost >= self.max_cost
+
+    def record(self, model: str, usage: Dict[str, int], cost: float):
+        tokens = usage.get("total_tokens", 0)
+        self.cost += cost
+        self.tokens += tokens
+        self.calls += 1
+        labels = {**_scope.get(), "model": model}
+        for label, key in BREAKDOWNS.items():
+            if labels.get(label):
+                entry = self.totals[key].setdefault(labels[label], {"cost": 0.0, "tokens": 0, "calls": 0})
+                entry["cost"] += cost
+                entry["tokens"] += tokens
+                entry["calls"] += 1
+
+    def to_dict(self) -> Dict[str, Any]:
+        def ordered(entries: Dict[str, Dict[str, Any]]) -> Dict[str, Dict[str, Any]]:
+            return {
+                name: {**entry, "cost": round(entry["cost"], 6)}
+                for name, entry in sorted(entries.items(), key=lambda item: (-item[1]["cost"], -item[1]["tokens"], item[0]))
+            }
+
+        return {
+            "total": round(self.cost, 6),
+            "tokens": self.tokens,
+            "calls": self.calls,
+            "max_cost": self.max_cost,
+            "limit_reached": self.exhausted,
+            "refused_calls": self.refused_calls,
+            **{key: ordered(entries) for key, entries in self.totals.items()}
+        }
+
+
+def current_ledger() -> Optional[CostLedger]:
+    return _ledger.get()
+
+
+@contextmanager
+def track_costs(max_cost: Optional[float] = None) -> Iterator[CostLedger]:
+    """Record the LLM calls made inside the block in a new ledger, refusing calls once max_cost is spent"""
+    ledger = CostLedger(max_cost=max_cost)
+    token = _ledger.set(ledger)
+    try:
+        yield ledger
+    finally:
+        _ledger.reset(token)
+
+
+@contextmanager
+def cost_scope(**labels: Optional[str]) -> Iterator[None]:
+    """Attribute the LLM calls made inside the block to labels (file=, rule=, step=); inner scopes add to outer ones"""
+    token = _scope.set({**_scope.get(), **{k: v for k, v in labels.items() if v}})
+    try:
+        yield
+    finally:
+        _scope.reset(token)
+
+
+def finding_scope(vulnerability: Dict[str, Any]):
+    """cost_scope for LLM work on one finding (a report dict)"""
+    return cost_scope(file=vulnerability.get("file_path"), rule=vulnerability.get("rule_id") or vulnerability.get("vuln_type"))
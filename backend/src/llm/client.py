@@ -12,11 +12,72 @@ import litellm
 from litellm import acompletion
 
 from .config import get_llm_config, MODEL_COSTS, LLMConfig
+from .local import (
+    completion_kwargs, emulate_tool_messages, fit_messages, get_capabilities,
+    is_local_model, parse_emulated_reply
+)
+from ..config.offline import require_network
+from ..redaction import redact_messages
+from .budget import current_ledger
 
 logger = logging.getLogger(__name__)
 
 litellm.set_verbose = False
 
+UNREACHABLE_ERRORS = ('APIConnectionError', 'Timeout', 'ServiceUnavailableError', 'InternalServerError', 'AuthenticationError')
+MAX_RETRY_AFTER = 60  # seconds; a longer Retry-After is capped rather than stalling the scan
+QUOTA_MARKERS = ('insufficient_quota', 'exceeded your current quota', 'credit balance', 'budget')
+
+
+class LLMUnavailableError(Exception):
+    """Every configured model failed because the provider is unreachable or the budget is spent;
+    callers can finish the run without the LLM"""
+
+    def __init__(self, reason: str, message: str):
+        super().__init__(message)
+        self.reason = reason  # "unreachable" or "budget"
+
+
+def llm_outage(error: Exception) -> Optional[str]:
+    """Why an LLM call failed, if the cause will not go away on the next request"""
+    if isinstance(error, LLMUnavailableError):
+        return error.reason
+    text = str(error).lower()
+    if type(error).__name__ == 'BudgetExceededError' or any(marker in text for marker in QUOTA_MARKERS):
+        return "budget"
+    if type(error).__name__ in UNREACHABLE_ERRORS or isinstance(error, (ConnectionError, asyncio.TimeoutError)):
+        return "unreachable"
+    return None
+
+
+def is_rate_limit(error: Exception) -> bool:
+    """A transient 429; quota and billing errors share the status code but do not clear up with time"""
+    return type(error).__name__ == 'RateLimitError' and llm_outage(error) != "budget"
+
+
+def retry_after(error: Exception, attempt: int, backoff: float) -> float:
+    """Seconds to wait before retrying a rate-limited call: the provider's Retry-After, or exponential backoff"""
+    headers = getattr(getattr(error, 'response', None), 'headers', None) or {}
+    try:
+        return min(float(headers.get('retry-after')), MAX_RETRY_AFTER)
+    except (TypeError, ValueError):
+        return backoff * (2 ** attempt)
+
+
+async def rate_limited(call, retries: int, backoff: float):
+    """Await call(), retrying rate limits; once the retries run out the provider counts as unreachable"""
+    for attempt in range(retries + 1):
+        try:
+            return await call()
+        except Exception as e:
+            if not is_rate_limit(e):
+                raise
+            if attempt == retries:
+                raise LLMUnavailableError("unreachable", f"still rate limited after {retries} retries: {e}") from e
+            delay = retry_after(e, attempt, backoff)
+            logger.warning(f"LLM rate limited, retrying in {delay:.0f}s ({attempt + 1}/{retries})")
+            await asyncio.sleep(delay)
+
 
 @dataclass
 class LLMResponse:
@@ -27,6 +88,7 @@ class LLMResponse:
     finish_reason: str = "stop"
     latency: float = 0.0
     cost: float = 0.0
+    format_error: Optional[str] = None  # an emulated tool call that could not be parsed
     
     def to_dict(self) -> Dict[str, Any]:
         return {
@@ -36,7 +98,8 @@ class LLMResponse:
             "tool_calls": self.tool_calls,
             "finish_reason": self.finish_reason,
             "latency": self.latency,
-            "cost": self.cost
+            "cost": self.cost,
+            "format_error": self.format_error
         }
 
 
@@ -69,89 +132,130 @@ class LLMClient:
         tools: Optional[List[Dict[str, Any]]] = None,
         tool_choice: Optional[str] = None,
     ) -> LLMResponse:
+        require_network("LLM analysis")
+        ledger = current_ledger()
+        if ledger and ledger.exhausted:
+            ledger.refused_calls += 1
+            raise LLMUnavailableError("budget", f"scan cost limit of ${ledger.max_cost:.2f} reached (${ledger.cost:.4f} spent)")
         model = model or self.config.default_model
         temperature = temperature if temperature is not None else self.config.temperature
         max_tokens = max_tokens or self.config.max_tokens
         
-        async with self._semaphore:
-            start_time = time.time()
+        start_time = time.time()
+        
+        try:
+            kwargs = {
+                "model": model,
+                "messages": redact_messages(messages),
+                "temperature": temperature,
+                "max_tokens": max_tokens,
+                "timeout": self.config.request_timeout,
+            }
             
-            try:
-                kwargs = {
-                    "model": model,
-                    "messages": messages,
-                    "temperature": temperature,
-                    "max_tokens": max_tokens,
-                    "timeout": self.config.request_timeout,
-                }
-                
-                if tools:
-                    kwargs["tools"] = tools
-                if tool_choice:
-                    kwargs["tool_choice"] = tool_choice
-                
-                response = await acompletion(**kwargs)
-                
-                latency = time.time() - start_time
-                
-                message = response.choices[0].message
-                content = message.content or ""
-                
-                tool_calls = None
-                if hasattr(message, 'tool_calls') and message.tool_calls:
-                    tool_calls = [
-                        {
-                            "id": tc.id,
-                            "type": tc.type,
-                            "function": {
-                                "name": tc.function.name,
-                                "arguments": tc.function.arguments
-                            }
+            if tools:
+                kwargs["tools"] = tools
+            if tool_choice:
+                kwargs["tool_choice"] = tool_choice
+            
+            emulated = False
+            if is_local_model(model):
+                caps = await asyncio.to_thread(get_capabilities, model)
+                kwargs.update(completion_kwargs(model, caps))
+                kwargs["max_tokens"] = caps.reply_tokens(max_tokens)
+                if tools and not caps.supports_tools:
+                    # no native tool calling: describe the tools and ask for JSON instead
+                    emulated = True
+                    kwargs["messages"] = emulate_tool_messages(kwargs["messages"], tools)
+                    kwargs.pop("tools")
+                    kwargs.pop("tool_choice", None)
+                    if caps.supports_json:
+                        kwargs["response_format"] = {"type": "json_object"}
+                kwargs["messages"] = [
+                    {k: v for k, v in m.items() if k != "emulated_tool_result"}
+                    for m in fit_messages(kwargs["messages"], caps.prompt_budget(max_tokens))
+                ]
+            
+            response = await rate_limited(
+                lambda: self._request(kwargs), self.config.rate_limit_retries, self.config.rate_limit_backoff
+            )
+            
+            latency = time.time() - start_time
+            
+            message = response.choices[0].message
+            content = message.content or ""
+            
+            tool_calls = None
+            format_error = None
+            if hasattr(message, 'tool_calls') and message.tool_calls:
+                tool_calls = [
+                    {
+                        "id": tc.id,
+                        "type": tc.type,
+                        "function": {
+                            "name": tc.function.name,
+                            "arguments": tc.function.arguments
                         }
-                        for tc in message.tool_calls
-                    ]
-                
-                usage = {
-                    "prompt_tokens": response.usage.prompt_tokens,
-                    "completion_tokens": response.usage.completion_tokens,
-                    "total_tokens": response.usage.total_tokens
-                }
-                
-                cost = self._calculate_cost(model, usage)
-                self.total_cost += cost
-                self.total_requests += 1
-                
-                logger.info(f"LLM call: model={model}, tokens={usage['total_tokens']}, cost=${cost:.4f}, latency={latency:.2f}s")
-                
-                return LLMResponse(
-                    content=content,
-                    model=model,
-                    usage=usage,
-                    tool_calls=tool_calls,
-                    finish_reason=response.choices[0].finish_reason,
-                    latency=latency,
-                    cost=cost
-                )
-                
-            except Exception as e:
-                logger.error(f"LLM error: {e}")
-                
-                for fallback_model in self.config.fallback_models:
-                    if fallback_model != model:
-                        try:
-                            logger.info(f"Trying fallback model: {fallback_model}")
-                            return await self.completion(
-                                messages=messages,
-                                model=fallback_model,
-                                temperature=temperature,
-                                max_tokens=max_tokens,
-                                tools=tools,
-                                tool_choice=tool_choice
-                            )
-                        except:
-                            continue
-                
-                raise
+                    }
+                    for tc in message.tool_calls
+                ]
+            elif emulated:
+                reply = parse_emulated_reply(content)
+                content, tool_calls, format_error = reply["content"], reply["tool_calls"], reply["error"]
+            
+            usage = {
+                "prompt_tokens": response.usage.prompt_tokens,
+                "completion_tokens": response.usage.completion_tokens,
+                "total_tokens": response.usage.total_tokens
+            }
+            
+            cost = self._calculate_cost(model, usage)
+            self.total_cost += cost
+            self.total_requests += 1
+            if ledger:
+                ledger.record(model, usage, cost)
+            
+            logger.info(f"LLM call: model={model}, tokens={usage['total_tokens']}, cost=${cost:.4f}, latency={latency:.2f}s")
+            
+            return LLMResponse(
+                content=content,
+                model=model,
+                usage=usage,
+                tool_calls=tool_calls,
+                finish_reason=response.choices[0].finish_reason,
+                latency=latency,
+                cost=cost,
+                format_error=format_error
+            )
+            
+        except Exception as e:
+            logger.error(f"LLM error: {e}")
+            
+            # code sent to a self-hosted model should not silently go to a cloud provider instead
+            fallback_models = [] if is_local_model(model) else self.config.fallback_models
+            for fallback_model in fallback_models:
+                if fallback_model != model:
+                    try:
+                        logger.info(f"Trying fallback model: {fallback_model}")
+                        return await self.completion(
+                            messages=messages,
+                            model=fallback_model,
+                            temperature=temperature,
+                            max_tokens=max_tokens,
+                            tools=tools,
+                            tool_choice=tool_choice
+                        )
+                    except:
+                        continue
+            
+            reason = llm_outage(e)
+            if reason:
+                raise LLMUnavailableError(reason, str(e)) from e
+            raise
+    
+    async def _request(self, kwargs: Dict[str, Any]) -> Any:
+        # the slot is held per request, so a rate-limit backoff does not keep other requests waiting
+        async with self._semaphore:
+            return await acompletion(**kwargs)
     
     def _calculate_cost(self, model: str, usage: Dict[str, int]) -> float:
         if model not in MODEL_COSTS:
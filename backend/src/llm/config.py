@@ -25,6 +25,14 @@ class LLMConfig:
     
     max_concurrent_requests: int = 10
     request_timeout: int = 120
+    # a 429 is retried with exponential backoff; the scan degrades only once the retries run out
+    rate_limit_retries: int = 4
+    rate_limit_backoff: float = 2.0  # seconds before the first retry, doubled each time
+    
+    # self-hosted models, named ollama/<model> or llamacpp/<model>
+    ollama_base_url: str = "http://localhost:11434"
+    llamacpp_base_url: str = "http://localhost:8080"
+    local_num_ctx: Optional[int] = None  # overrides the detected context window
     
     @classmethod
     def from_env(cls) -> 'LLMConfig':
@@ -35,8 +43,18 @@ class LLMConfig:
             default_model=os.getenv('DEFAULT_LLM_MODEL', 'gpt-4o-mini'),
             temperature=float(os.getenv('LLM_TEMPERATURE', '0.1')),
             max_tokens=int(os.getenv('LLM_MAX_TOKENS', '4096')),
+            ollama_base_url=os.getenv('OLLAMA_BASE_URL', 'http://localhost:11434'),
+            llamacpp_base_url=os.getenv('LLAMACPP_BASE_URL', 'http://localhost:8080'),
+            local_num_ctx=int(os.environ['LOCAL_LLM_NUM_CTX']) if os.getenv('LOCAL_LLM_NUM_CTX') else None,
+            rate_limit_retries=int(os.getenv('LLM_RATE_LIMIT_RETRIES', '4')),
+            rate_limit_backoff=float(os.getenv('LLM_RATE_LIMIT_BACKOFF', '2.0')),
         )
     
+    @property
+    def uses_local_model(self) -> bool:
+        from .local import is_local_model
+        return is_local_model(self.default_model)
+    
     def get_available_models(self) -> List[str]:
         models = []
         if self.openai_api_key:
@@ -45,10 +63,13 @@ class LLMConfig:
             models.extend(['claude-3-5-sonnet-20241022', 'claude-3-haiku-20240307'])
         if self.google_api_key:
             models.extend(['gemini/gemini-1.5-pro', 'gemini/gemini-1.5-flash'])
+        if self.uses_local_model:
+            models.insert(0, self.default_model)
         return models
     
     def has_any_key(self) -> bool:
-        return any([self.openai_api_key, self.anthropic_api_key, self.google_api_key])
+        """Whether the LLM pipeline can run; local models need no key"""
+        return self.uses_local_model or any([self.openai_api_key, self.anthropic_api_key, self.google_api_key])
 
 
 _config: Optional[LLMConfig] = None
@@ -0,0 +1,225 @@
+"""
+Local models - Ollama and llama.cpp backends with capability detection and prompt sizing
+Models are named ollama/<model> (Ollama) or llamacpp/<model> (llama.cpp server, OpenAI-compatible API).
+Each server is asked once for the model's context window and tool-calling support; prompts are then
+trimmed to fit, and models without native tool calling get the tools through JSON-mode prompting.
+"""
+
+import json
+import logging
+import re
+from dataclasses import dataclass
+from typing import Any, Dict, List, Optional
+
+import httpx
+
+from .config import get_llm_config
+from .validation import parse_json
+
+logger = logging.getLogger(__name__)
+
+LOCAL_PREFIXES = ("ollama/", "ollama_chat/", "llamacpp/")
+CHARS_PER_TOKEN = 3.5  # conservative for code; local tokenizers are not available to us
+FALLBACK_CONTEXT = 4096  # used when the server cannot be asked
+
+
+def is_local_model(model: Optional[str]) -> bool:
+    return bool(model) and model.startswith(LOCAL_PREFIXES)
+
+
+def estimate_tokens(text: str) -> int:
+    return int(len(text) / CHARS_PER_TOKEN) + 1
+
+
+@dataclass
+class ModelCapabilities:
+    model: str
+    provider: str  # "ollama" or "llamacpp"
+    context_window: int = FALLBACK_CONTEXT
+    supports_tools: bool = False
+    supports_json: bool = False
+    detected: bool = False  # False when the server could not be reached and defaults are in use
+
+    def prompt_budget(self, max_tokens: int) -> int:
+        """Tokens left for the prompt after reserving room for the reply"""
+        return max(512, self.context_window - self.reply_tokens(max_tokens))
+
+    def reply_tokens(self, max_tokens: int) -> int:
+        return min(max_tokens, self.context_window // 4)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "model": self.model,
+            "provider": self.provider,
+            "context_window": self.context_window,
+            "supports_tools": self.supports_tools,
+            "supports_json": self.supports_json,
+            "detected": self.detected
+        }
+
+
+def model_name(model: str) -> str:
+    return model.split('/', 1)[1]
+
+
+def detect_ollama(model: str, base_url: str, num_ctx: Optional[int]) -> ModelCapabilities:
+    caps = ModelCapabilities(model=model, provider="ollama", supports_json=True)
+    response = httpx.post(f"{base_url.rstrip('/')}/api/show", json={"model": model_name(model), "name": model_name(model)}, timeout=10)
+    response.raise_for_status()
+    info = response.json()
+
+    trained = next((v for k, v in (info.get("model_info") or {}).items() if k.endswith(".context_length")), None)
+    configured = re.search(r'^num_ctx\s+(\d+)', info.get("parameters") or "", re.MULTILINE)
+    # Ollama only allocates num_ctx tokens (a small default) unless asked for more, so we ask
+    # for num_ctx on every call: the configured value, capped by what the model was trained on
+    window = num_ctx or (int(configured.group(1)) if configured else 8192)
+    caps.context_window = min(window, int(trained)) if trained else window
+
+    if "capabilities" in info:
+        caps.supports_tools = "tools" in info["capabilities"]
+    else:
+        caps.supports_tools = ".Tools" in (info.get("template") or "")
+    caps.detected = True
+    return caps
+
+
+def detect_llamacpp(model: str, base_url: str, num_ctx: Optional[int]) -> ModelCapabilities:
+    caps = ModelCapabilities(model=model, provider="llamacpp")
+    response = httpx.get(f"{base_url.rstrip('/')}/props", timeout=10)
+    response.raise_for_status()
+    props = response.json()
+
+    settings = props.get("default_generation_settings") or {}
+    n_ctx = settings.get("n_ctx") or props.get("n_ctx")
+    caps.context_window = min(num_ctx, n_ctx) if num_ctx and n_ctx else (n_ctx or num_ctx or FALLBACK_CONTEXT)
+    # the server's JSON-schema grammar backs response_format; tool calls need a template that renders tools
+    caps.supports_json = True
+    caps.supports_tools = "tools" in (props.get("chat_template") or "")
+    caps.detected = True
+    return caps
+
+
+_capabilities: Dict[str, ModelCapabilities] = {}
+
+
+def get_capabilities(model: str) -> ModelCapabilities:
+    """Ask the local server about a model once per process; fall back to small, safe defaults"""
+    if model in _capabilities:
+        return _capabilities[model]
+
+    config = get_llm_config()
+    try:
+        if model.startswith("llamacpp/"):
+            caps = detect_llamacpp(model, config.llamacpp_base_url, config.local_num_ctx)
+        else:
+            caps = detect_ollama(model, config.ollama_base_url, config.local_num_ctx)
+        logger.info(f"Local model {model}: {caps.context_window} token context, tools={caps.supports_tools}, json={caps.supports_json}")
+    except Exception as e:
+        provider = "llamacpp" if model.startswith("llamacpp/") else "ollama"
+        caps = ModelCapabilities(model=model, provider=provider, context_window=config.local_num_ctx or FALLBACK_CONTEXT)
+        logger.warning(f"Could not detect capabilities of {model} ({e}); assuming {caps.context_window} tokens without tool calling")
+
+    _capabilities[model] = caps
+    return caps
+
+
+def completion_kwargs(model: str, caps: ModelCapabilities) -> Dict[str, Any]:
+    """litellm arguments that route a local model to its server"""
+    config = get_llm_config()
+    if caps.provider == "llamacpp":
+        return {"model": f"openai/{model_name(model)}", "api_base": f"{config.llamacpp_base_url.rstrip('/')}/v1", "api_key": "sk-no-key"}
+    return {"model": model if model.startswith("ollama_chat/") else f"ollama_chat/{model_name(model)}",
+            "api_base": config.ollama_base_url, "num_ctx": caps.context_window}
+
+
+def message_tokens(messages: List[Dict[str, Any]]) -> int:
+    return sum(estimate_tokens(str(m.get("content") or "")) + estimate_tokens(json.dumps(m.get("tool_calls") or "")) for m in messages)
+
+
+def fit_messages(messages: List[Dict[str, Any]], budget: int) -> List[Dict[str, Any]]:
+    """Shorten the oldest tool results, then the longest other messages, until the prompt fits"""
+    fitted = [dict(m) for m in messages]
+    if message_tokens(fitted) <= budget:
+        return fitted
+
+    original = [str(m.get("content") or "") for m in fitted]
+    kept = [len(content) for content in original]
+
+    def shorten(i: int, keep_chars: int):
+        if kept[i] > keep_chars:
+            kept[i] = keep_chars
+            fitted[i]["content"] = original[i][:keep_chars] + f"\n... [{len(original[i]) - keep_chars} characters trimmed to fit the model's context window]"
+
+    for i, message in enumerate(fitted[:-1]):
+        if message.get("role") == "tool" or message.get("emulated_tool_result"):
+            shorten(i, 400)
+            if message_tokens(fitted) <= budget:
+                return fitted
+
+    # then the longest messages, sparing the system prompt and latest message while anything else is left
+    while message_tokens(fitted) > budget:
+        candidates = [i for i in range(1, len(fitted) - 1) if kept[i] > 400] or [i for i in range(len(fitted)) if kept[i] > 400]
+        if not candidates:
+            break
+        longest = max(candidates, key=lambda i: kept[i])
+        excess_chars = int((message_tokens(fitted) - budget) * CHARS_PER_TOKEN) + 200
+        shorten(longest, max(400, kept[longest] - excess_chars))
+    return fitted
+
+
+def tool_instructions(tools: List[Dict[str, Any]]) -> str:
+    lines = [
+        "You can call these tools. Reply with a single JSON object and nothing else:",
+        '  {"tool_calls": [{"name": "<tool>", "arguments": {...}}]} to call one or more tools, or',
+        '  {"content": "<your final answer>"} when you are done.',
+        "Tools:"
+    ]
+    for tool in tools:
+        function = tool.get("function", tool)
+        params = ', '.join(f"{name}: {spec.get('type', 'string')}" for name, spec in function.get("parameters", {}).get("properties", {}).items())
+        lines.append(f"- {function['name']}({params}): {function.get('description', '')}")
+    return '\n'.join(lines)
+
+
+def emulate_tool_messages(messages: List[Dict[str, Any]], tools: List[Dict[str, Any]]) -> List[Dict[str, Any]]:
+    """Rewrite a tool-calling conversation for a model without native tool support"""
+    converted = []
+    for message in messages:
+        if message.get("role") == "system":
+            converted.append({"role": "system", "content": f"{message['content']}\n\n{tool_instructions(tools)}"})
+        elif message.get("role") == "assistant" and message.get("tool_calls"):
+            calls = [{"name": tc["function"]["name"], "arguments": json.loads(tc["function"]["arguments"] or "{}")} for tc in message["tool_calls"]]
+            converted.append({"role": "assistant", "content": json.dumps({"tool_calls": calls})})
+        elif message.get("role") == "tool":
+            converted.append({"role": "user", "content": f"Tool result ({message.get('tool_call_id')}):\n{message.get('content')}", "emulated_tool_result": True})
+        else:
+            converted.append(message)
+    return converted
+
+
+def parse_emulated_reply(content: str) -> Dict[str, Any]:
+    """(content, tool_calls, error) from a JSON-mode reply. Prose is treated as a final answer; a reply that
+    tries to call tools but is not valid JSON sets error, so the agent can ask for it again."""
+    match = re.search(r'\{.*\}', content or "", re.DOTALL)
+    try:
+        data = parse_json(match.group(0)) if match else {}
+    except ValueError as error:
+        if 'tool_calls' in content:
+            return {"content": content, "tool_calls": None, "error": f"the reply is {error}"}
+        data = {}
+
+    calls = data.get("tool_calls") if isinstance(data, dict) else None
+    if not calls:
+        return {"content": data.get("content", content) if isinstance(data, dict) else content, "tool_calls": None, "error": None}
+    return {
+        "content": "",
+        "error": None,
+        "tool_calls": [
+            {
+                "id": f"call_{i}",
+                "type": "function",
+                "function": {"name": call.get("name", ""), "arguments": json.dumps(call.get("arguments") or {})}
+            }
+            for i, call in enumerate(calls) if isinstance(call, dict)
+        ]
+    }
@@ -0,0 +1,99 @@
+"""
+Output validation - Check what the LLM returns against the JSON schema of the tool it calls
+Models wrap JSON in code fences, leave trailing commas, send "12" for 12 or "High" for "high", and leave out
+fields. parse_json() repairs the syntax slips that have one reading, validate_arguments() coerces unambiguous
+type slips and lists everything it cannot fix; the agent sends those errors back so the model can call the
+tool again (AgentBase), and stops asking after a few rejected calls, keeping only what passed.
+"""
+
+import json
+import re
+from typing import Any, Dict, List, Tuple
+
+TRAILING_COMMA = re.compile(r',\s*([}\]])')
+CODE_FENCE = re.compile(r'^\s*```[\w-]*\s*\n?|\n?\s*```\s*$')
+INTEGER = re.compile(r'^\s*[-+]?\d+\s*$')
+NUMBER = re.compile(r'^\s*[-+]?(?:\d+\.?\d*|\.\d+)(?:[eE][-+]?\d+)?\s*$')
+
+
+class ToolRejection(Exception):
+    """Raised by a tool when a call is well-formed but its content cannot be accepted (a finding on a line that
+    does not exist); the message goes back to the model and counts as a rejected call"""
+
+
+def parse_json(text: str) -> Any:
+    """The JSON value in text, allowing a surrounding code fence and trailing commas; ValueError otherwise"""
+    text = CODE_FENCE.sub('', (text or '').strip())
+    if not text:
+        return {}
+    try:
+        return json.loads(text)
+    except json.JSONDecodeError as error:
+        try:
+            return json.loads(TRAILING_COMMA.sub(r'\1', text))
+        except json.JSONDecodeError:
+            raise ValueError(f"not valid JSON ({error.msg} at position {error.pos})") from error
+
+
+def coerce(value: Any, spec: Dict[str, Any]) -> Tuple[Any, str]:
+    """(value converted to the spec's type, '') or (value, why it does not fit)"""
+    kind = spec.get("type", "string")
+    if kind == "integer":
+        if isinstance(value, bool):
+            return value, "expected an integer"
+        if isinstance(value, float) and value.is_integer():
+            value = int(value)
+        elif isinstance(value, str) and INTEGER.match(value):
+            value = int(value)
+        if not isinstance(value, int):
+            return value, "expected an integer"
+    elif kind == "number":
+        if isinstance(value, str) and NUMBER.match(value):
+            value = float(value)
+        if isinstance(value, bool) or not isinstance(value, (int, float)):
+            return value, "expected a number"
+    elif kind == "boolean":
+        if isinstance(value, str) and value.strip().lower() in ("true", "false"):
+            value = value.strip().lower() == "true"
+        if not isinstance(value, bool):
+            return value, "expected true or false"
+    elif kind == "string":
+        if isinstance(value, (int, float)) and not isinstance(value, bool):
+            value = str(value)
+        if not isinstance(value, str):
+            return value, "expected a string"
+    elif kind == "array" and not isinstance(value, list):
+        return value, "expected an array"
+    elif kind == "object" and not isinstance(value, dict):
+        return value, "expected an object"
+
+    if "enum" in spec:
+        match = next((option for option in spec["enum"] if str(option).lower() == str(value).strip().lower()), None)
+        if match is None:
+            return value, f"expected one of {', '.join(map(str, spec['enum']))}"
+        value = match
+    if "minimum" in spec and isinstance(value, (int, float)) and value < spec["minimum"]:
+        return value, f"must be at least {spec['minimum']}"
+    if "maximum" in spec and isinstance(value, (int, float)) and value > spec["maximum"]:
+        return value, f"must be at most {spec['maximum']}"
+    return value, ""
+
+
+def validate_arguments(schema: Dict[str, Any], arguments: Any) -> Tuple[Dict[str, Any], List[str]]:
+    """(arguments coerced to the object schema, errors); properties the schema does not define are dropped"""
+    if not isinstance(arguments, dict):
+        return {}, ["arguments must be a JSON object"]
+    properties = schema.get("properties", {})
+    valid, errors = {}, []
+    for name in schema.get("required", []):
+        if arguments.get(name) is None:
+            errors.append(f"{name}: missing")
+    for name, value in arguments.items():
+        if name not in properties or value is None:
+            continue
+        value, error = coerce(value, properties[name])
+        if error:
+            errors.append(f"{name}: {error}, got {json.dumps(value)[:60]}")
+        else:
+            valid[name] = value
+    return valid, errors
@@ -10,27 +10,66 @@ import os
 import subprocess
 import time
 from contextlib import asynccontextmanager
-from typing import Any, Dict, List, Optional, Tuple
+from typing import Any, Callable, Dict, List, Optional, Tuple
 
-from fastapi import FastAPI, HTTPException, BackgroundTasks, WebSocket, WebSocketDisconnect
+from fastapi import FastAPI, HTTPException, BackgroundTasks, Depends, WebSocket, WebSocketDisconnect
 from fastapi.middleware.cors import CORSMiddleware
-from fastapi.responses import JSONResponse
+from fastapi.responses import HTMLResponse, JSONResponse, PlainTextResponse, Response
 import uvicorn
 
 from .agents import (
     VulnAnalyzerAgent, TriageAgent, PatchProducerAgent, DiffAnalyzerAgent,
     POVProducerAgent, DynamicDebugAgent, CoverageAnalyzerAgent,
-    BranchFlipperAgent, HarnessDecoderAgent, create_agents
+    BranchFlipperAgent, HarnessDecoderAgent, Vulnerability, create_agents
 )
-from .llm import get_llm_config, get_client
+from .llm import LLMUnavailableError, get_llm_config, get_client
+from .llm.budget import CostLedger, cost_scope, finding_scope, track_costs
+from .llm.local import get_capabilities
 from .analysis import parse_file, parse_code
+from .analysis.callgraph import SEVERITY_ORDER, annotate_reachability, build_call_graph
+from .analysis.chains import RULE_ID as CHAIN_RULE_ID, find_chains
+from .analysis.interop import RULE_ID as INTEROP_RULE_ID, find_interop_flows
+from .analysis.rules import StaticFinding, get_rule, get_rules, run_rules
+from .analysis.rules.idor import drop_authorized
+from .analysis.rules.secrets import config_format
+from .config.offline import OfflineError, is_offline, require_network
+from .config.settings import get_settings
+from .config.project import ProjectConfig, load_project_config
 from .services import get_status_service
+from .formatters import FORMATTERS, render_report
+from .schema import REPORT_SCHEMA_VERSION, load_schema
+from .notifications import ScanSummary, diff_against_previous, load_notifier
+from .ranking import rank_findings
+from .redaction import redact_report
+from .reports import REPORTS_DIR, STATS_FILE, evaluate_gate, load_report, report_link, save_report
+from .auth import AuthError, get_audit_log, get_key_store
+from .attestation import git_source, scanner_metadata
+from .blame import annotate_blame, owner_summary
+from .codeowners import annotate_code_owners, load_codeowners, owned_by, team_report, team_summary
+from .remote import RemoteCheckout, RemoteError, RemoteRepo, parse_remote
+from .projects import Project, ProjectError, attach_project, get_project_store, project_reports, report_summary
+from .checkpoints import ScanCheckpoint
+from .profiling import stage, timed_file
+from .retrieval import CONFIG_EXTENSIONS, build_index, context_budget
+from .isolation import git_command
+from .untrusted import verify_locations
+from .triage import VERDICTS, TriageError, apply_triage, get_triage_store, prompt_context, related_decisions, report_root
+from .auth.dependencies import Principal, audit_requests, authorize_websocket, require_scope
 
 logging.basicConfig(level=logging.INFO)
 logger = logging.getLogger(__name__)
 
-REPORTS_DIR = os.path.join(os.path.dirname(__file__), '..', 'analysis-reports')
-STATS_FILE = os.path.join(os.path.dirname(__file__), '..', 'analysis-reports', 'stats.json')
+CODE_EXTENSIONS = ('.py', '.js', '.ts', '.jsx', '.tsx', '.c', '.cpp', '.h', '.hpp', '.java', '.go', '.rs', '.kt', '.kts', '.php')
+SKIPPED_DIRS = ('node_modules', 'venv', '__pycache__', 'dist', 'build')
+
+
+
+def require_llm(feature: str):
+    """503 when offline mode forbids the LLM calls a request needs"""
+    try:
+        require_network(feature)
+    except OfflineError as e:
+        raise HTTPException(status_code=503, detail=str(e))
 
 
 def get_git_diff(path: str) -> Tuple[bool, Optional[str]]:
@@ -44,7 +83,7 @@ def get_git_diff(path: str) -> Tuple[bool, Optional[str]]:
             file_arg = None
         
         result = subprocess.run(
-            ['git', 'rev-parse', '--git-dir'],
+            git_command(['rev-parse', '--git-dir']),
             cwd=repo_dir,
             capture_output=True,
             text=True,
@@ -53,7 +92,7 @@ def get_git_diff(path: str) -> Tuple[bool, Optional[str]]:
         if result.returncode != 0:
             return False, None
         
-        diff_cmd = ['git', 'diff', 'HEAD']
+        diff_cmd = git_command(['diff', '--no-ext-diff', '--no-textconv', 'HEAD'])
         if file_arg:
             diff_cmd.append(file_arg)
         
@@ -67,7 +106,7 @@ def get_git_diff(path: str) -> Tuple[bool, Optional[str]]:
         
         diff_content = diff_result.stdout.strip()
         if not diff_content:
-            staged_cmd = ['git', 'diff', '--cached']
+            staged_cmd = git_command(['diff', '--no-ext-diff', '--no-textconv', '--cached'])
             if file_arg:
                 staged_cmd.append(file_arg)
             staged_result = subprocess.run(
@@ -84,6 +123,205 @@ def get_git_diff(path: str) -> Tuple[bool, Optional[str]]:
         return False, None
 
 
+def run_static_rules(code: str, file_path: str, start_index: int = 0, project_config: Optional[ProjectConfig] = None) -> List[Vulnerability]:
+    """Run the built-in static rules and convert hits into vulnerabilities"""
+    settings = get_settings()
+    if not settings.enable_pattern_analysis:
+        return []
+    
+    project_config = project_config or ProjectConfig()
+    options = {
+        "sensitive_field_names": project_config.sensitive_field_names or settings.sensitive_field_names
+    }
+    
+    rule_ids = None
+    if project_config.disabled_rules:
+        rule_ids = [r.rule_id for r in get_rules() + project_config.pack_rules if r.rule_id not in project_config.disabled_rules]
+    
+    return [
+        finding_to_vulnerability(finding, start_index + i, project_config)
+        for i, finding in enumerate(run_rules(code, file_path, rule_ids=rule_ids, options=options, extra_rules=project_config.pack_rules))
+    ]
+
+
+def run_interop_rules(target: str, files: List[str], start_index: int = 0, project_config: Optional[ProjectConfig] = None) -> List[Vulnerability]:
+    """Follow user input across the project's declared interop points (queues, repo-local scripts)"""
+    project_config = project_config or ProjectConfig()
+    if not get_settings().enable_pattern_analysis or not project_config.interop or INTEROP_RULE_ID in project_config.disabled_rules:
+        return []
+    return [
+        finding_to_vulnerability(finding, start_index + i, project_config)
+        for i, finding in enumerate(find_interop_flows(target, files, project_config.interop))
+    ]
+
+
+def run_chain_rules(vulnerabilities: List[Vulnerability], graph: Any = None, project_config: Optional[ProjectConfig] = None) -> List[Vulnerability]:
+    """Meta-findings for findings that compound into a worse risk, linked with them through related;
+    returns vulnerabilities with the meta-findings added"""
+    project_config = project_config or ProjectConfig()
+    if CHAIN_RULE_ID in project_config.disabled_rules:
+        return vulnerabilities
+    start_index = max((int(v.vuln_id[5:]) for v in vulnerabilities if v.vuln_id.startswith("SAST-") and v.vuln_id[5:].isdigit()), default=0)
+    chained = []
+    for i, (finding, components) in enumerate(find_chains(vulnerabilities, graph)):
+        meta = finding_to_vulnerability(finding, start_index + i, project_config)
+        meta.reachability = components[-1].reachability
+        meta.critical_path = components[-1].critical_path
+        meta.related = [v.vuln_id for v in components]
+        for v in components:
+            v.related.append(meta.vuln_id)
+        chained.append(meta)
+    return vulnerabilities + chained
+
+
+def escalate_critical_paths(vulnerabilities: List[Vulnerability], project_config: ProjectConfig, root: str) -> int:
+    """Raise findings in the project's critical_paths one severity level and record the pattern they fall
+    under, which also routes them to security notification channels. Returns the number raised."""
+    raised = 0
+    for vuln in vulnerabilities:
+        vuln.critical_path = project_config.critical_path(vuln.file_path, root)
+        severity = vuln.severity.lower()
+        if not vuln.critical_path or severity not in SEVERITY_ORDER[:-1]:
+            continue
+        vuln.original_severity = vuln.original_severity or vuln.severity
+        vuln.severity = SEVERITY_ORDER[SEVERITY_ORDER.index(severity) + 1]
+        raised += 1
+    return raised
+
+
+def run_config_rules(target: str, start_index: int = 0, project_config: Optional[ProjectConfig] = None) -> Tuple[List[Vulnerability], int]:
+    """Secret detection over the project's .env, YAML, JSON, TOML, and properties files.
+    Returns the findings and the number of files checked."""
+    config_files = collect_project_files(target, match=config_format)
+    vulnerabilities = []
+    for file_path in config_files:
+        try:
+            if os.path.getsize(file_path) > get_settings().max_file_size:
+                continue
+            with open(file_path, 'r', encoding='utf-8', errors='ignore') as f:
+                code = f.read()
+            vulnerabilities.extend(run_static_rules(code, file_path, start_index + len(vulnerabilities), project_config))
+        except OSError as e:
+            logger.warning(f"Could not read {file_path}: {e}")
+    return vulnerabilities, len(config_files)
+
+
+def finding_to_vulnerability(finding: StaticFinding, index: int, project_config: ProjectConfig) -> Vulnerability:
+    severity = project_config.apply_severity(finding.rule_id, finding.severity)
+    return Vulnerability(
+        vuln_id=f"SAST-{index + 1:04d}",
+        vuln_type=finding.vuln_type,
+        severity=severity,
+        description=finding.description,
+        file_path=finding.file_path,
+        line_number=finding.line_number,
+        code_snippet=finding.code_snippet,
+        cwe_id=finding.cwe_id,
+        confidence=finding.confidence,
+        remediation=finding.remediation,
+        rule_id=finding.rule_id,
+        original_severity=finding.severity if severity != finding.severity else None,
+        trace=[step.to_dict() for step in finding.trace],
+        column=finding.column,
+        end_column=finding.end_column,
+        needs_review=finding.needs_review
+    )
+
+
+def attribute_findings(report: Dict[str, Any], vulnerabilities: List[Vulnerability], target: str):
+    """git blame each finding's lines when the target is a checkout, and attach its CODEOWNERS team;
+    report["owners"] and report["code_owners"] group them for assignment"""
+    if get_settings().enable_blame and annotate_blame(vulnerabilities):
+        report["owners"] = owner_summary([v.to_dict() for v in vulnerabilities])
+    codeowners = load_codeowners(target)
+    if codeowners:
+        annotate_code_owners(vulnerabilities, codeowners)
+        report["code_owners"] = {
+            "path": os.path.relpath(codeowners.path, codeowners.root),
+            "teams": team_summary([v.to_dict() for v in vulnerabilities])
+        }
+
+
+def collect_project_files(target: str, extensions: Tuple[str, ...] = CODE_EXTENSIONS, match: Optional[Callable[[str], bool]] = None) -> List[str]:
+    """List the code files (or other files by extension or name match) of a project, skipping hidden, vendored, and build directories"""
+    if not os.path.isdir(target):
+        raise ValueError(f"Project path is not a directory: {target}")
+    
+    files_to_analyze = []
+    for root, dirs, files in os.walk(target):
+        dirs[:] = [d for d in dirs if not d.startswith('.') and d not in SKIPPED_DIRS]
+        for file in files:
+            if match(file) if match else file.endswith(extensions):
+                files_to_analyze.append(os.path.join(root, file))
+    return files_to_analyze
+
+
+def run_static_scan(session_id: str, target: str, project: Optional[Project] = None) -> Dict[str, Any]:
+    """Scan a project with the static rules only (no LLM agents)"""
+    project_config = project.scan_config() if project else load_project_config(target)
+    report = {
+        "schema_version": REPORT_SCHEMA_VERSION,
+        "session_id": session_id,
+        "analysis_type": "project",
+        "target": target,
+        "started_at": time.time(),
+        "status": "running",
+        "vulnerabilities": [],
+        "project_config": project_config.to_dict(),
+        "scanner": scanner_metadata(project_config.to_dict()),
+        "source": git_source(target),
+        "summary": {},
+        "cost": 0.0,
+        "errors": []
+    }
+    
+    vulnerabilities = []
+    with stage("collect files"):
+        files = collect_project_files(target)
+    with stage("call graph"):
+        graph = build_call_graph(target, files)
+    for file_path in files:
+        try:
+            with timed_file(file_path):
+                with open(file_path, 'r', encoding='utf-8', errors='ignore') as f:
+                    code = f.read()
+                vulnerabilities.extend(run_static_rules(code, file_path, len(vulnerabilities), project_config))
+        except Exception as file_error:
+            report["errors"].append(f"{file_path}: {file_error}")
+    with stage("interop"):
+        vulnerabilities.extend(run_interop_rules(target, files, len(vulnerabilities), project_config))
+    with stage("config files"):
+        config_vulnerabilities, report["config_files_analyzed"] = run_config_rules(target, len(vulnerabilities), project_config)
+    vulnerabilities.extend(config_vulnerabilities)
+    
+    with stage("reachability"):
+        vulnerabilities = drop_authorized(vulnerabilities, graph)
+        annotate_reachability(vulnerabilities, graph, project_config.severity_overrides)
+    escalate_critical_paths(vulnerabilities, project_config, target)
+    with stage("chains"):
+        vulnerabilities = run_chain_rules(vulnerabilities, graph, project_config)
+    with stage("attribution"):
+        attribute_findings(report, vulnerabilities, target)
+    report["call_graph"] = graph.to_dict()
+    report["vulnerabilities"] = [v.to_dict() for v in vulnerabilities]
+    report["files_analyzed"] = len(files)
+    report["summary"] = {"total_vulnerabilities": len(vulnerabilities), "by_severity": {}}
+    for vuln in vulnerabilities:
+        report["summary"]["by_severity"][vuln.severity] = report["summary"]["by_severity"].get(vuln.severity, 0) + 1
+    report["severity_overrides"] = [
+        {"vuln_id": v.vuln_id, "rule_id": v.rule_id, "from": v.original_severity, "to": v.severity}
+        for v in vulnerabilities if v.original_severity
+    ]
+    report["status"] = "completed"
+    report["completed_at"] = time.time()
+    if project:
+        attach_project(report, project)
+    apply_triage(report)
+    rank_findings(report, project_config.pack_rules)
+    evaluate_gate(report)
+    return report
+
+
 def load_stats() -> Dict[str, Any]:
     """Load stats from file"""
     if os.path.exists(STATS_FILE):
@@ -125,8 +363,13 @@ async def lifespan(app: FastAPI):
     os.makedirs(REPORTS_DIR, exist_ok=True)
     
     config = get_llm_config()
-    if config.has_any_key():
+    if is_offline():
+        logger.info("Offline mode: LLM analysis, notifications, and remote policy refresh are disabled")
+    elif config.has_any_key():
         logger.info(f"LLM configured with models: {config.get_available_models()}")
+        if config.uses_local_model:
+            caps = get_capabilities(config.default_model)
+            logger.info(f"Local model {caps.model} via {caps.provider}: {caps.context_window} token context, native tools={caps.supports_tools}")
     else:
         logger.warning("No LLM API keys configured. Set OPENAI_API_KEY, ANTHROPIC_API_KEY, or GOOGLE_API_KEY")
     
@@ -151,6 +394,7 @@ app.add_middleware(
     allow_methods=["*"],
     allow_headers=["*"],
 )
+app.middleware("http")(audit_requests)
 
 
 @app.get("/")
@@ -175,15 +419,17 @@ async def health_check():
         "timestamp": time.time(),
         "llm": {
             "configured": config.has_any_key(),
+            "offline": is_offline(),
             "default_model": config.default_model,
+            "local_model": get_capabilities(config.default_model).to_dict() if config.uses_local_model and not is_offline() else None,
             "available_models": config.get_available_models(),
             "total_cost": client.total_cost,
             "total_requests": client.total_requests
         },
         "agents": {
-            "vuln_analyzer": {"agent_id": "vuln_analyzer", "status": "available", "model": "gpt-4o-mini", "available_tools": 3, "temperature": 0.1},
-            "triage_agent": {"agent_id": "triage_agent", "status": "available", "model": "gpt-4o-mini", "available_tools": 1, "temperature": 0.1},
-            "patch_producer": {"agent_id": "patch_producer", "status": "available", "model": "gpt-4o-mini", "available_tools": 1, "temperature": 0.2}
+            "vuln_analyzer": {"agent_id": "vuln_analyzer", "status": "available", "model": config.default_model, "available_tools": 3, "temperature": 0.1},
+            "triage_agent": {"agent_id": "triage_agent", "status": "available", "model": config.default_model, "available_tools": 1, "temperature": 0.1},
+            "patch_producer": {"agent_id": "patch_producer", "status": "available", "model": config.default_model, "available_tools": 1, "temperature": 0.2}
         },
         "stats": stats
     }
@@ -192,6 +438,10 @@ async def health_check():
 @app.websocket("/ws/status")
 async def websocket_status(websocket: WebSocket):
     """WebSocket endpoint for real-time status updates"""
+    if not await authorize_websocket(websocket):
+        await websocket.close(code=1008)
+        return
+
     status_service = get_status_service()
     await status_service.connect(websocket)
     
@@ -208,8 +458,9 @@ async def websocket_status(websocket: WebSocket):
 
 
 @app.post("/api/v1/analysis/start")
-async def start_analysis(request: Dict[str, Any], background_tasks: BackgroundTasks):
+async def start_analysis(request: Dict[str, Any], background_tasks: BackgroundTasks, principal: Principal = Depends(require_scope("scan"))):
     """Start vulnerability analysis"""
+    require_llm("Full analysis")
     config = get_llm_config()
     if not config.has_any_key():
         raise HTTPException(
@@ -220,11 +471,23 @@ async def start_analysis(request: Dict[str, Any], background_tasks: BackgroundTa
     analysis_type = request.get("type", "file")
     target = request.get("target")
     session_id = request.get("session_id", f"session_{int(time.time())}")
+    project = None
+    
+    if request.get("project_id"):
+        project = get_accessible_project(request["project_id"], principal)
+        analysis_type = request.get("type", "project")
+        target = target or project.target
     
     if not target:
         raise HTTPException(status_code=400, detail="Target is required")
     
-    background_tasks.add_task(run_analysis_pipeline, session_id, analysis_type, target)
+    principal.note(session_id=session_id, target=target, project_id=project.project_id if project else None)
+    remote = None if project else parse_remote(target)
+    if remote:
+        analysis_type = "project"
+        background_tasks.add_task(run_remote_analysis, session_id, remote)
+    else:
+        background_tasks.add_task(run_analysis_pipeline, session_id, analysis_type, target, project=project)
     
     return {
         "session_id": session_id,
@@ -234,12 +497,21 @@ async def start_analysis(request: Dict[str, Any], background_tasks: BackgroundTa
     }
 
 
-async def run_analysis_pipeline(session_id: str, analysis_type: str, target: str):
-    """Run the full analysis pipeline"""
+async def run_analysis_pipeline(session_id: str, analysis_type: str, target: str, notify: bool = True, project: Optional[Project] = None,
+                                sandbox: Optional[RemoteCheckout] = None, max_cost: Optional[float] = None):
+    """Run the full analysis pipeline; target is sandbox.root when scanning a temporary remote checkout. Once the
+    scan's LLM calls have cost max_cost (USD), no more are made and the rest of the scan uses the static rules."""
+    with track_costs(max_cost) as ledger:
+        await analysis_pipeline(session_id, analysis_type, target, notify, project, sandbox, ledger)
+
+
+async def analysis_pipeline(session_id: str, analysis_type: str, target: str, notify: bool, project: Optional[Project],
+                            sandbox: Optional[RemoteCheckout], ledger: CostLedger):
     logger.info(f"Starting analysis pipeline for session {session_id}")
     status = get_status_service()
     
     report = {
+        "schema_version": REPORT_SCHEMA_VERSION,
         "session_id": session_id,
         "analysis_type": analysis_type,
         "target": target,
@@ -257,26 +529,38 @@ async def run_analysis_pipeline(session_id: str, analysis_type: str, target: str
         "errors": []
     }
     
+    async def degrade(error: LLMUnavailableError, step: str):
+        """The LLM is gone for the rest of this run: finish it with the static rules only"""
+        if report.get("degraded"):
+            return
+        report["degraded"] = {"reason": error.reason, "error": str(error), "step": step, "static_only_files": 0}
+        logger.warning(f"[{session_id}] LLM {error.reason} during {step}, continuing with static rules only: {error}")
+        await status.emit_step(session_id, step, "degraded", f"LLM {error.reason}; continuing with static rules only", {"reason": error.reason})
+    
     try:
         await status.emit_analysis_started(session_id, target)
         
         is_git, git_diff = get_git_diff(target) if analysis_type in ("file", "project") else (False, None)
+        if project:
+            project_config = project.scan_config()
+        elif analysis_type in ("file", "project"):
+            project_config = load_project_config(target)
+        else:
+            project_config = ProjectConfig()
+        report["project_config"] = project_config.to_dict()
+        report["scanner"] = scanner_metadata(report["project_config"], get_llm_config().default_model)
+        if analysis_type in ("file", "project"):
+            report["source"] = git_source(target)
         diff_vulnerabilities = []
+        triage_root = report_root(report) if analysis_type in ("file", "project") else ""
+        prior_decisions = get_triage_store().for_repo(project.project_id if project else triage_root) if triage_root else {}
         
         all_vulnerabilities = []
         files_to_analyze = []
         
         if analysis_type == "project":
-            CODE_EXTENSIONS = ('.py', '.js', '.ts', '.jsx', '.tsx', '.c', '.cpp', '.h', '.hpp', '.java', '.go', '.rs')
-            
-            if not os.path.isdir(target):
-                raise ValueError(f"Project path is not a directory: {target}")
-            
-            for root, dirs, files in os.walk(target):
-                dirs[:] = [d for d in dirs if not d.startswith('.') and d not in ('node_modules', 'venv', '__pycache__', 'dist', 'build')]
-                for file in files:
-                    if file.endswith(CODE_EXTENSIONS):
-                        files_to_analyze.append(os.path.join(root, file))
+            with stage("collect files"):
+                files_to_analyze = collect_project_files(target)
             
             await status.emit_step(session_id, "scanner", "completed", f"Found {len(files_to_analyze)} code files", {"file_count": len(files_to_analyze)})
             logger.info(f"[{session_id}] Found {len(files_to_analyze)} files to analyze")
@@ -285,9 +569,26 @@ async def run_analysis_pipeline(session_id: str, analysis_type: str, target: str
             if git_diff:
                 await status.emit_step(session_id, "diff_analyzer", "started", "Analyzing git diff in parallel...")
                 diff_analyzer = DiffAnalyzerAgent()
-                diff_task = asyncio.create_task(diff_analyzer.analyze_diff(git_diff, target))
+                with cost_scope(file="(git diff)"):
+                    diff_task = asyncio.create_task(diff_analyzer.analyze_diff(git_diff, target))
             
             vuln_analyzer = VulnAnalyzerAgent()
+            static_vulnerabilities = []
+            analysis_cost = 0.0
+            rejected_calls = 0
+            with stage("call graph"):
+                graph = build_call_graph(target, files_to_analyze)
+            report["call_graph"] = graph.to_dict()
+            repo_index = None
+            if get_settings().retrieval_enabled:
+                try:
+                    with stage("retrieval index"):
+                        repo_index = await build_index(target, files_to_analyze, collect_project_files(target, CONFIG_EXTENSIONS))
+                    await status.emit_step(session_id, "retrieval", "completed", f"Indexed {len(repo_index.chunks)} code chunks", {"chunks": len(repo_index.chunks), "model": repo_index.model})
+                except Exception as index_error:
+                    logger.warning(f"[{session_id}] Could not build the embeddings index, analyzing without retrieved context: {index_error}")
+            checkpoint = ScanCheckpoint.start(session_id, target, report["project_config"])
+            report["resumed_files"] = 0
             
             for i, file_path in enumerate(files_to_analyze):
                 try:
@@ -299,7 +600,37 @@ async def run_analysis_pipeline(session_id: str, analysis_type: str, target: str
                     if len(code.strip()) < 10:
                         continue
                     
-                    file_vulns = await vuln_analyzer.analyze_code(code, file_path)
+                    saved = checkpoint.lookup(file_path, code)
+                    if saved:
+                        file_vulns = [Vulnerability(**v) for v in saved.vulnerabilities]
+                        static_vulnerabilities.extend(v for v in file_vulns if v.rule_id)
+                        analysis_cost += saved.cost
+                        report["resumed_files"] += 1
+                    else:
+                        with timed_file(file_path):
+                            static_vulns = run_static_rules(code, file_path, len(static_vulnerabilities), project_config)
+                        static_vulnerabilities.extend(static_vulns)
+                        
+                        file_vulns = static_vulns
+                        if not report.get("degraded"):
+                            try:
+                                with cost_scope(file=os.path.relpath(file_path, target)), timed_file(file_path):
+                                    related_code = await repo_index.context_for(code, file_path, static_vulns, context_budget(), graph) if repo_index else ""
+                                    file_vulns = static_vulns + await vuln_analyzer.analyze_code(
+                                        code, file_path, prompt_context(related_decisions(prior_decisions, file_path, triage_root)), related_code
+                                    )
+                            except LLMUnavailableError as llm_error:
+                                await degrade(llm_error, "vuln_analyzer")
+                        
+                        if report.get("degraded"):
+                            for v in static_vulns:
+                                v.static_only = True
+                            report["degraded"]["static_only_files"] += 1
+                        else:
+                            file_cost = vuln_analyzer.execution.total_cost if vuln_analyzer.execution else 0
+                            analysis_cost += file_cost
+                            rejected_calls += vuln_analyzer.execution.rejected_calls if vuln_analyzer.execution else 0
+                            checkpoint.record(file_path, code, [v.to_dict() for v in file_vulns], file_cost)
                     all_vulnerabilities.extend(file_vulns)
                     
                     if file_vulns:
@@ -310,8 +641,18 @@ async def run_analysis_pipeline(session_id: str, analysis_type: str, target: str
                     logger.warning(f"[{session_id}] Error analyzing {file_path}: {file_error}")
                     continue
             
+            with stage("interop"):
+                interop_vulnerabilities = run_interop_rules(target, files_to_analyze, len(static_vulnerabilities), project_config)
+            with stage("config files"):
+                config_vulnerabilities, report["config_files_analyzed"] = run_config_rules(
+                    target, len(static_vulnerabilities) + len(interop_vulnerabilities), project_config
+                )
+            all_vulnerabilities.extend(interop_vulnerabilities + config_vulnerabilities)
+            for v in interop_vulnerabilities + config_vulnerabilities:
+                await status.emit_vulnerability_found(session_id, v.to_dict())
+            
             vulnerabilities = all_vulnerabilities
-            report["cost"] += vuln_analyzer.execution.total_cost if vuln_analyzer.execution else 0
+            report["cost"] += analysis_cost
             report["files_analyzed"] = len(files_to_analyze)
             
             if diff_task:
@@ -321,10 +662,24 @@ async def run_analysis_pipeline(session_id: str, analysis_type: str, target: str
                     await status.emit_step(session_id, "diff_analyzer", "completed", f"Found {len(diff_vulnerabilities)} diff issues", {"count": len(diff_vulnerabilities)})
                     for dv in diff_vulnerabilities:
                         await status.emit_vulnerability_found(session_id, dv.to_dict())
+                except LLMUnavailableError as llm_error:
+                    await degrade(llm_error, "diff_analyzer")
                 except Exception as diff_err:
                     logger.warning(f"[{session_id}] Diff analysis error: {diff_err}")
             
-            vulnerabilities = all_vulnerabilities + diff_vulnerabilities
+            vulnerabilities, unverified = verify_locations(drop_authorized(all_vulnerabilities, graph) + diff_vulnerabilities, target)
+            report["llm_validation"] = {"rejected_calls": rejected_calls, "unverified_dropped": unverified}
+            with stage("reachability"):
+                lowered = annotate_reachability(vulnerabilities, graph, project_config.severity_overrides)
+            if lowered:
+                logger.info(f"[{session_id}] {lowered} findings unreachable from any entry point were lowered one severity level")
+            raised = escalate_critical_paths(vulnerabilities, project_config, target)
+            if raised:
+                logger.info(f"[{session_id}] {raised} findings in critical paths were raised one severity level")
+            with stage("chains"):
+                vulnerabilities = run_chain_rules(vulnerabilities, graph, project_config)
+            with stage("attribution"):
+                attribute_findings(report, vulnerabilities, target)
             report["vulnerabilities"] = [v.to_dict() for v in vulnerabilities]
             
             await status.emit_step(session_id, "vuln_analyzer", "completed", f"Found {len(vulnerabilities)} total vulnerabilities in {len(files_to_analyze)} files", {"count": len(vulnerabilities)})
@@ -350,7 +705,17 @@ async def run_analysis_pipeline(session_id: str, analysis_type: str, target: str
             await status.emit_step(session_id, "vuln_analyzer", "started", "Analyzing code for vulnerabilities...")
             logger.info(f"[{session_id}] Step 1: Vulnerability Analysis")
             vuln_analyzer = VulnAnalyzerAgent()
-            code_vulnerabilities = await vuln_analyzer.analyze_code(code, file_path)
+            code_vulnerabilities = run_static_rules(code, file_path, project_config=project_config)
+            try:
+                with cost_scope(file=os.path.basename(file_path) if analysis_type == "file" else None):
+                    code_vulnerabilities += await vuln_analyzer.analyze_code(
+                        code, file_path, prompt_context(related_decisions(prior_decisions, file_path, triage_root))
+                    )
+            except LLMUnavailableError as llm_error:
+                await degrade(llm_error, "vuln_analyzer")
+                for v in code_vulnerabilities:
+                    v.static_only = True
+                report["degraded"]["static_only_files"] = 1
             
             report["cost"] += vuln_analyzer.execution.total_cost if vuln_analyzer.execution else 0
             
@@ -364,111 +729,128 @@ async def run_analysis_pipeline(session_id: str, analysis_type: str, target: str
                     await status.emit_step(session_id, "diff_analyzer", "completed", f"Found {len(diff_vulnerabilities)} diff issues", {"count": len(diff_vulnerabilities)})
                     for dv in diff_vulnerabilities:
                         await status.emit_vulnerability_found(session_id, dv.to_dict())
+                except LLMUnavailableError as llm_error:
+                    await degrade(llm_error, "diff_analyzer")
                 except Exception as diff_err:
                     logger.warning(f"[{session_id}] Diff analysis error: {diff_err}")
             
-            vulnerabilities = code_vulnerabilities + diff_vulnerabilities
+            vulnerabilities, unverified = verify_locations(
+                code_vulnerabilities + diff_vulnerabilities, target, code if analysis_type != "file" else None
+            )
+            report["llm_validation"] = {
+                "rejected_calls": vuln_analyzer.execution.rejected_calls if vuln_analyzer.execution else 0,
+                "unverified_dropped": unverified
+            }
+            if analysis_type == "file":
+                escalate_critical_paths(vulnerabilities, project_config, os.path.dirname(os.path.abspath(file_path)))
+            vulnerabilities = run_chain_rules(vulnerabilities, project_config=project_config)
+            if analysis_type == "file":
+                attribute_findings(report, vulnerabilities, file_path)
             report["vulnerabilities"] = [v.to_dict() for v in vulnerabilities]
             
             await status.emit_step(session_id, "vuln_analyzer", "completed", f"Found {len(vulnerabilities)} vulnerabilities", {"count": len(vulnerabilities)})
             logger.info(f"[{session_id}] Found {len(vulnerabilities)} vulnerabilities")
         
-        if vulnerabilities:
-            await status.emit_step(session_id, "triage_agent", "started", "Triaging vulnerabilities...")
-            logger.info(f"[{session_id}] Step 2: Triage")
-            triage_agent = TriageAgent()
-            triage_results = await triage_agent.triage_vulnerabilities(
-                [v.to_dict() for v in vulnerabilities]
-            )
-            
-            report["triage_results"] = [t.to_dict() for t in triage_results]
-            report["cost"] += triage_agent.execution.total_cost if triage_agent.execution else 0
-            
-            high_priority = [t for t in triage_results if t.priority.value in ["critical", "high"]]
-            await status.emit_step(session_id, "triage_agent", "completed", f"{len(high_priority)} high priority vulnerabilities", {"high_priority": len(high_priority)})
-            logger.info(f"[{session_id}] {len(high_priority)} high priority vulnerabilities")
-            
-            if high_priority:
-                await status.emit_step(session_id, "patch_producer", "started", "Generating patches for high priority vulnerabilities...")
-                logger.info(f"[{session_id}] Step 3: Patch Generation")
-                patch_producer = PatchProducerAgent()
-                
-                high_priority_vulns = [
-                    v.to_dict() for v in vulnerabilities 
-                    if any(t.vulnerability_id == v.vuln_id and t.priority.value in ["critical", "high"] 
-                           for t in triage_results)
-                ]
-                
-                patches = await patch_producer.generate_patches(high_priority_vulns)
-                report["patches"] = [p.to_dict() for p in patches]
-                report["cost"] += patch_producer.execution.total_cost if patch_producer.execution else 0
-                
-                await status.emit_step(session_id, "patch_producer", "completed", f"Generated {len(patches)} patches", {"count": len(patches)})
-                logger.info(f"[{session_id}] Generated {len(patches)} patches")
-                
-                await status.emit_step(session_id, "pov_producer", "started", "Generating proof-of-concept exploits...")
-                logger.info(f"[{session_id}] Step 4: POV Generation")
-                pov_producer = POVProducerAgent()
-                
-                all_povs = []
-                for vuln in high_priority_vulns:
-                    try:
-                        povs = await pov_producer.generate_pov(vuln)
-                        all_povs.extend([p.to_dict() for p in povs])
-                    except Exception as pov_error:
-                        logger.warning(f"[{session_id}] POV generation error for {vuln.get('vuln_id')}: {pov_error}")
-                
-                report["povs"] = all_povs
-                report["cost"] += pov_producer.execution.total_cost if pov_producer.execution else 0
-                
-                await status.emit_step(session_id, "pov_producer", "completed", f"Generated {len(all_povs)} POCs", {"count": len(all_povs)})
-                logger.info(f"[{session_id}] Generated {len(all_povs)} POCs")
-                
-                await status.emit_step(session_id, "dynamic_debug", "started", "Creating debug sessions...")
-                logger.info(f"[{session_id}] Step 5: Debug Session Planning")
-                dynamic_debug = DynamicDebugAgent()
-                
-                all_debug_sessions = []
-                for vuln in high_priority_vulns:
-                    try:
-                        debug_session = await dynamic_debug.plan_debug_session(vuln, code if 'code' in dir() else "")
-                        if debug_session:
-                            all_debug_sessions.append(debug_session.to_dict())
-                    except Exception as debug_error:
-                        logger.warning(f"[{session_id}] Debug session error for {vuln.get('vuln_id')}: {debug_error}")
-                
-                report["debug_sessions"] = all_debug_sessions
-                report["cost"] += dynamic_debug.execution.total_cost if dynamic_debug.execution else 0
-                
-                await status.emit_step(session_id, "dynamic_debug", "completed", f"Created {len(all_debug_sessions)} debug sessions", {"count": len(all_debug_sessions)})
-                logger.info(f"[{session_id}] Created {len(all_debug_sessions)} debug sessions")
-                
-                await status.emit_step(session_id, "branch_flipper", "started", "Generating targeted fuzzing inputs...")
-                logger.info(f"[{session_id}] Step 6: Fuzzing Input Generation")
-                branch_flipper = BranchFlipperAgent()
+        try:
+            if vulnerabilities and not report.get("degraded"):
+                await status.emit_step(session_id, "triage_agent", "started", "Triaging vulnerabilities...")
+                logger.info(f"[{session_id}] Step 2: Triage")
+                triage_agent = TriageAgent()
+                triage_results = await triage_agent.triage_vulnerabilities(
+                    [v.to_dict() for v in vulnerabilities]
+                )
                 
-                all_flip_inputs = []
-                for vuln in high_priority_vulns[:5]:
-                    try:
-                        vuln_context = {
-                            "branch_id": vuln.get("vuln_id"),
-                            "line_number": vuln.get("line_number"),
-                            "condition": vuln.get("vuln_type"),
-                            "vulnerability": vuln.get("description"),
-                            "current_value": False,
-                            "target_value": True
-                        }
-                        source = code if 'code' in dir() else vuln.get("code_snippet", "")
-                        flip_inputs = await branch_flipper.generate_flip_input(vuln_context, source, [])
-                        all_flip_inputs.extend([f.to_dict() for f in flip_inputs])
-                    except Exception as flip_err:
-                        logger.warning(f"[{session_id}] Flip input error for {vuln.get('vuln_id')}: {flip_err}")
+                report["triage_results"] = [t.to_dict() for t in triage_results]
+                report["cost"] += triage_agent.execution.total_cost if triage_agent.execution else 0
                 
-                report["flip_inputs"] = all_flip_inputs
-                report["cost"] += branch_flipper.execution.total_cost if branch_flipper.execution else 0
+                high_priority = [t for t in triage_results if t.priority.value in ["critical", "high"]]
+                await status.emit_step(session_id, "triage_agent", "completed", f"{len(high_priority)} high priority vulnerabilities", {"high_priority": len(high_priority)})
+                logger.info(f"[{session_id}] {len(high_priority)} high priority vulnerabilities")
                 
-                await status.emit_step(session_id, "branch_flipper", "completed", f"Generated {len(all_flip_inputs)} fuzzing inputs", {"count": len(all_flip_inputs)})
-                logger.info(f"[{session_id}] Generated {len(all_flip_inputs)} fuzzing inputs")
+                if high_priority:
+                    await status.emit_step(session_id, "patch_producer", "started", "Generating patches for high priority vulnerabilities...")
+                    logger.info(f"[{session_id}] Step 3: Patch Generation")
+                    patch_producer = PatchProducerAgent()
+                    
+                    high_priority_vulns = [
+                        v.to_dict() for v in vulnerabilities 
+                        if any(t.vulnerability_id == v.vuln_id and t.priority.value in ["critical", "high"] 
+                               for t in triage_results)
+                    ]
+                    
+                    patches = await patch_producer.generate_patches(high_priority_vulns)
+                    report["patches"] = [p.to_dict() for p in patches]
+                    report["cost"] += patch_producer.execution.total_cost if patch_producer.execution else 0
+                    
+                    await status.emit_step(session_id, "patch_producer", "completed", f"Generated {len(patches)} patches", {"count": len(patches)})
+                    logger.info(f"[{session_id}] Generated {len(patches)} patches")
+                    
+                    await status.emit_step(session_id, "pov_producer", "started", "Generating proof-of-concept exploits...")
+                    logger.info(f"[{session_id}] Step 4: POV Generation")
+                    pov_producer = POVProducerAgent()
+                    
+                    all_povs = []
+                    for vuln in high_priority_vulns:
+                        try:
+                            with finding_scope(vuln):
+                                povs = await pov_producer.generate_pov(vuln)
+                            all_povs.extend([p.to_dict() for p in povs])
+                        except Exception as pov_error:
+                            logger.warning(f"[{session_id}] POV generation error for {vuln.get('vuln_id')}: {pov_error}")
+                    
+                    report["povs"] = all_povs
+                    report["cost"] += pov_producer.execution.total_cost if pov_producer.execution else 0
+                    
+                    await status.emit_step(session_id, "pov_producer", "completed", f"Generated {len(all_povs)} POCs", {"count": len(all_povs)})
+                    logger.info(f"[{session_id}] Generated {len(all_povs)} POCs")
+                    
+                    await status.emit_step(session_id, "dynamic_debug", "started", "Creating debug sessions...")
+                    logger.info(f"[{session_id}] Step 5: Debug Session Planning")
+                    dynamic_debug = DynamicDebugAgent()
+                    
+                    all_debug_sessions = []
+                    for vuln in high_priority_vulns:
+                        try:
+                            debug_session = await dynamic_debug.plan_debug_session(vuln, code if 'code' in dir() else "")
+                            if debug_session:
+                                all_debug_sessions.append(debug_session.to_dict())
+                        except Exception as debug_error:
+                            logger.warning(f"[{session_id}] Debug session error for {vuln.get('vuln_id')}: {debug_error}")
+                    
+                    report["debug_sessions"] = all_debug_sessions
+                    report["cost"] += dynamic_debug.execution.total_cost if dynamic_debug.execution else 0
+                    
+                    await status.emit_step(session_id, "dynamic_debug", "completed", f"Created {len(all_debug_sessions)} debug sessions", {"count": len(all_debug_sessions)})
+                    logger.info(f"[{session_id}] Created {len(all_debug_sessions)} debug sessions")
+                    
+                    await status.emit_step(session_id, "branch_flipper", "started", "Generating targeted fuzzing inputs...")
+                    logger.info(f"[{session_id}] Step 6: Fuzzing Input Generation")
+                    branch_flipper = BranchFlipperAgent()
+                    
+                    all_flip_inputs = []
+                    for vuln in high_priority_vulns[:5]:
+                        try:
+                            vuln_context = {
+                                "branch_id": vuln.get("vuln_id"),
+                                "line_number": vuln.get("line_number"),
+                                "condition": vuln.get("vuln_type"),
+                                "vulnerability": vuln.get("description"),
+                                "current_value": False,
+                                "target_value": True
+                            }
+                            source = code if 'code' in dir() else vuln.get("code_snippet", "")
+                            flip_inputs = await branch_flipper.generate_flip_input(vuln_context, source, [])
+                            all_flip_inputs.extend([f.to_dict() for f in flip_inputs])
+                        except Exception as flip_err:
+                            logger.warning(f"[{session_id}] Flip input error for {vuln.get('vuln_id')}: {flip_err}")
+                    
+                    report["flip_inputs"] = all_flip_inputs
+                    report["cost"] += branch_flipper.execution.total_cost if branch_flipper.execution else 0
+                    
+                    await status.emit_step(session_id, "branch_flipper", "completed", f"Generated {len(all_flip_inputs)} fuzzing inputs", {"count": len(all_flip_inputs)})
+                    logger.info(f"[{session_id}] Generated {len(all_flip_inputs)} fuzzing inputs")
+        except LLMUnavailableError as llm_error:
+            await degrade(llm_error, "triage_agent")
         
         if analysis_type != "project" and 'code' in dir() and code:
             await status.emit_step(session_id, "coverage_analyzer", "started", "Analyzing code coverage gaps...")
@@ -508,9 +890,16 @@ async def run_analysis_pipeline(session_id: str, analysis_type: str, target: str
             report["summary"]["by_severity"][severity] = \
                 report["summary"]["by_severity"].get(severity, 0) + 1
         
+        report["severity_overrides"] = [
+            {"vuln_id": v.vuln_id, "rule_id": v.rule_id, "from": v.original_severity, "to": v.severity}
+            for v in vulnerabilities if getattr(v, "original_severity", None)
+        ]
+        
         report["status"] = "completed"
         await status.emit_analysis_completed(session_id, report["summary"])
         report["completed_at"] = time.time()
+        if not report.get("degraded"):
+            ScanCheckpoint(session_id, target).discard()
         
     except Exception as e:
         logger.error(f"[{session_id}] Analysis error: {e}")
@@ -519,18 +908,57 @@ async def run_analysis_pipeline(session_id: str, analysis_type: str, target: str
         report["completed_at"] = time.time()
         await status.emit_analysis_failed(session_id, str(e))
     
-    report_path = os.path.join(REPORTS_DIR, f"{session_id}.json")
-    with open(report_path, 'w') as f:
-        json.dump(report, f, indent=2)
+    if sandbox:
+        sandbox.relocate(report)
+    if project:
+        attach_project(report, project)
+    apply_triage(report)
+    if report["status"] == "completed":
+        rank_findings(report, project_config.pack_rules)
+        evaluate_gate(report)
+    
+    report["costs"] = ledger.to_dict()
+    
+    report_path = save_report(report)
     
     if report["status"] == "completed":
         update_stats_from_report(report)
+        
+        notifier = load_notifier(get_settings().notifications_file) if notify else None
+        if notifier:
+            summary = ScanSummary.from_report(report, diff_against_previous(report), report_link(report))
+            await notifier.notify(summary)
     
     logger.info(f"[{session_id}] Analysis complete. Report saved to {report_path}")
 
 
+async def run_remote_analysis(session_id: str, repo: RemoteRepo, notify: bool = True):
+    """Fetch a repository the server has no checkout of, analyze it, and remove the temporary checkout"""
+    checkout = RemoteCheckout(repo)
+    try:
+        await asyncio.to_thread(checkout.open)
+    except (RemoteError, OSError) as e:
+        logger.error(f"[{session_id}] Could not fetch {repo}: {e}")
+        save_report({
+            "session_id": session_id,
+            "analysis_type": "project",
+            "target": str(repo),
+            "started_at": time.time(),
+            "completed_at": time.time(),
+            "status": "failed",
+            "vulnerabilities": [],
+            "errors": [f"Could not fetch {repo}: {e}"]
+        })
+        await get_status_service().emit_analysis_failed(session_id, str(e))
+        return
+    try:
+        await run_analysis_pipeline(session_id, "project", checkout.root, notify=notify, sandbox=checkout)
+    finally:
+        checkout.close()
+
+
 @app.get("/api/v1/analysis/{session_id}/status")
-async def get_analysis_status(session_id: str):
+async def get_analysis_status(session_id: str, principal: Principal = Depends(require_scope("read"))):
     """Get analysis status"""
     report_path = os.path.join(REPORTS_DIR, f"{session_id}.json")
     
@@ -544,40 +972,169 @@ async def get_analysis_status(session_id: str):
     with open(report_path, 'r') as f:
         report = json.load(f)
     
-    return report
+    if not principal.can_access(report.get("project_id")):
+        raise HTTPException(status_code=404, detail="Report not found")
+    return redact_report(report)
 
 
 @app.get("/api/v1/reports")
-async def list_reports():
-    """List all report filenames"""
+async def list_reports(project: Optional[str] = None, principal: Principal = Depends(require_scope("read"))):
+    """List all report filenames, or only those of one project"""
     if not os.path.exists(REPORTS_DIR):
         return {"reports": []}
     
     reports = [f.replace('.json', '') for f in os.listdir(REPORTS_DIR) if f.endswith('.json')]
     reports.sort(reverse=True)
     
+    restricted = principal.key is not None and not principal.can_access(None)
+    if project or restricted:
+        if project and not principal.can_access(project):
+            raise HTTPException(status_code=404, detail="Project not found")
+        reports = [
+            name for name in reports
+            if (report := load_report(name)) and principal.can_access(report.get("project_id"))
+            and (not project or report.get("project_id") == project)
+        ]
+    
     return {"reports": reports}
 
 
 @app.get("/api/v1/reports/{report_name}")
-async def get_report(report_name: str):
-    """Get full report content"""
-    report_path = os.path.join(REPORTS_DIR, f"{report_name}.json")
-    
-    if not os.path.exists(report_path):
+async def get_report(report_name: str, team: Optional[str] = None, principal: Principal = Depends(require_scope("read"))):
+    """Get full report content, optionally only the findings one CODEOWNERS team owns"""
+    report = load_report(report_name)
+    if not report or not principal.can_access(report.get("project_id")):
         raise HTTPException(status_code=404, detail="Report not found")
-    
-    with open(report_path, 'r') as f:
-        return json.load(f)
+    report = redact_report(report)  # reports saved before redaction
+    return team_report(report, [team]) if team else report
+
+
+@app.get("/api/v1/reports/{report_name}/export")
+async def export_report(report_name: str, format: str = "sarif", context_lines: int = 0, group_by: Optional[str] = None, team: Optional[str] = None, sort: str = "severity", principal: Principal = Depends(require_scope("read"))):
+    """Render a report as text, JSON, SARIF, HTML, or a PDF executive report"""
+    if format not in FORMATTERS:
+        raise HTTPException(status_code=400, detail=f"Unknown format: {format}")
+
+    report = load_report(report_name)
+    if not report or not principal.can_access(report.get("project_id")):
+        raise HTTPException(status_code=404, detail="Report not found")
+
+    if team:
+        report = team_report(report, [team])
+    try:
+        output = render_report(report, format, context_lines=context_lines, group_by=group_by, sort_by=sort)
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    if format == "pdf":
+        return Response(output, media_type="application/pdf", headers={"Content-Disposition": f'attachment; filename="{report_name}.pdf"'})
+    if format == "html":
+        return HTMLResponse(output)
+    if format in ("json", "sarif"):
+        return JSONResponse(json.loads(output))
+    return PlainTextResponse(output)
+
+
+@app.post("/api/v1/reports/{report_name}/findings/{vuln_id}/explain")
+async def explain_finding(report_name: str, vuln_id: str, refresh: bool = False, principal: Principal = Depends(require_scope("scan"))):
+    """Explain why a finding is exploitable and how to fix it, cached in the report"""
+    from .agents.finding_explainer import explain_report_finding
+
+    report = load_report(report_name)
+    if not report or not principal.can_access(report.get("project_id")):
+        raise HTTPException(status_code=404, detail="Report not found")
+
+    cached = vuln_id in report.get("explanations", {}) and not refresh
+    if not cached:
+        require_llm("Explaining a finding")
+    if not cached and not get_llm_config().has_any_key():
+        raise HTTPException(status_code=503, detail="No LLM API key configured")
 
+    explanation = await explain_report_finding(report, vuln_id, refresh=refresh)
+    if not explanation:
+        raise HTTPException(status_code=404, detail="Finding not found in report")
 
-@app.get("/api/v1/stats")
+    if not cached:
+        save_report(report)
+    return {"vuln_id": vuln_id, "cached": cached, "explanation": explanation}
+
+
+@app.post("/api/v1/reports/{report_name}/findings/{vuln_id}/triage")
+async def triage_finding(report_name: str, vuln_id: str, request: Dict[str, Any], principal: Principal = Depends(require_scope("scan"))):
+    """Record a confirmed / false_positive decision; later scans of the repo carry it over and show it to the analyzer"""
+    report = load_report(report_name)
+    if not report or not principal.can_access(report.get("project_id")):
+        raise HTTPException(status_code=404, detail="Report not found")
+
+    vuln = next((v for v in report.get("vulnerabilities", []) if v.get("vuln_id") == vuln_id), None)
+    if not vuln:
+        raise HTTPException(status_code=404, detail="Finding not found in report")
+
+    try:
+        decision = get_triage_store().record(report, vuln, request.get("verdict", ""), request.get("reason", ""), decided_by=principal.name)
+    except TriageError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+
+    vuln["triage"] = {"decision_id": decision.decision_id, "verdict": decision.verdict, "reason": decision.reason}
+    rank_findings(report)
+    if report.get("gate"):
+        evaluate_gate(report, report["gate"]["fail_on"])
+    save_report(report)
+    principal.note(decision_id=decision.decision_id, verdict=decision.verdict)
+    return decision.to_dict()
+
+
+@app.get("/api/v1/triage")
+async def list_triage_decisions(repo: Optional[str] = None, verdict: Optional[str] = None, principal: Principal = Depends(require_scope("read"))):
+    """List triage decisions, optionally for one repo (project id or scanned directory)"""
+    if verdict and verdict not in VERDICTS:
+        raise HTTPException(status_code=400, detail=f"Unknown verdict: {verdict}")
+    decisions = [
+        d for d in get_triage_store().list(repo, verdict)
+        if principal.can_access(d.repo)
+    ]
+    return {"decisions": [d.to_dict() for d in decisions], "total": len(decisions)}
+
+
+@app.delete("/api/v1/triage/{decision_id}")
+async def delete_triage_decision(decision_id: str, principal: Principal = Depends(require_scope("admin"))):
+    """Forget a triage decision; the finding is reported normally again from the next scan"""
+    try:
+        decision = get_triage_store().delete(decision_id)
+    except TriageError as e:
+        raise HTTPException(status_code=404, detail=str(e))
+    principal.note(decision_id=decision_id)
+    return decision.to_dict()
+
+
+@app.get("/api/v1/schema", dependencies=[Depends(require_scope("read"))])
+async def get_report_schema():
+    """Get the JSON Schema for the native report format"""
+    return load_schema()
+
+
+@app.get("/api/v1/rules", dependencies=[Depends(require_scope("read"))])
+async def list_rules():
+    """List the built-in static rule catalog"""
+    rules = sorted(get_rules(), key=lambda r: r.rule_id)
+    return {"rules": [r.to_dict() for r in rules], "total": len(rules)}
+
+
+@app.get("/api/v1/rules/{rule_id}", dependencies=[Depends(require_scope("read"))])
+async def explain_rule(rule_id: str):
+    """Get full metadata for a single rule"""
+    rule = get_rule(rule_id)
+    if not rule:
+        raise HTTPException(status_code=404, detail="Rule not found")
+    return rule.to_dict()
+
+
+@app.get("/api/v1/stats", dependencies=[Depends(require_scope("read"))])
 async def get_stats():
     """Get aggregate stats"""
     return load_stats()
 
 
-@app.post("/api/v1/stats/rebuild")
+@app.post("/api/v1/stats/rebuild", dependencies=[Depends(require_scope("admin"))])
 async def rebuild_stats():
     """Rebuild stats by reading all reports"""
     stats = {
@@ -614,6 +1171,225 @@ async def rebuild_stats():
     return {"message": "Stats rebuilt", "stats": stats}
 
 
+@app.get("/api/v1/keys", dependencies=[Depends(require_scope("admin"))])
+async def list_api_keys():
+    """List API keys (secrets are never returned after creation)"""
+    return {"keys": [k.to_dict() for k in get_key_store().list()]}
+
+
+@app.post("/api/v1/keys")
+async def create_api_key(request: Dict[str, Any], principal: Principal = Depends(require_scope("admin"))):
+    """Create an API key; the token is only shown in this response"""
+    expires_days = request.get("expires_days")
+    try:
+        key, token = get_key_store().create(
+            request.get("name") or "unnamed",
+            request.get("scopes") or ["read"],
+            expires_in=float(expires_days) * 86400 if expires_days else None,
+            cert_subject=request.get("cert_subject"),
+            projects=request.get("projects")
+        )
+    except AuthError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    principal.note(created_key=key.key_id)
+    return {"key": key.to_dict(), "token": token}
+
+
+@app.post("/api/v1/keys/{key_id}/rotate")
+async def rotate_api_key(key_id: str, grace_hours: float = 24, principal: Principal = Depends(require_scope("admin"))):
+    """Replace a key; the old one keeps working for grace_hours"""
+    try:
+        key, token = get_key_store().rotate(key_id, grace_seconds=grace_hours * 3600)
+    except AuthError as e:
+        raise HTTPException(status_code=404, detail=str(e))
+    principal.note(rotated_key=key_id, created_key=key.key_id)
+    return {"key": key.to_dict(), "token": token, "replaces": key_id}
+
+
+@app.delete("/api/v1/keys/{key_id}")
+async def revoke_api_key(key_id: str, principal: Principal = Depends(require_scope("admin"))):
+    """Revoke a key immediately"""
+    try:
+        key = get_key_store().revoke(key_id)
+    except AuthError as e:
+        raise HTTPException(status_code=404, detail=str(e))
+    principal.note(revoked_key=key_id)
+    return {"key": key.to_dict()}
+
+
+@app.get("/api/v1/audit", dependencies=[Depends(require_scope("admin"))])
+async def get_audit_entries(limit: int = 100, key_id: Optional[str] = None):
+    """Recent audit log entries, optionally for one key"""
+    return {"entries": get_audit_log().tail(limit, key_id=key_id)}
+
+
+def get_accessible_project(project_id: str, principal: Principal) -> Project:
+    project = get_project_store().get(project_id)
+    if not project or not principal.can_access(project_id):
+        raise HTTPException(status_code=404, detail="Project not found")
+    return project
+
+
+@app.get("/api/v1/projects")
+async def list_projects(principal: Principal = Depends(require_scope("read"))):
+    """List registered projects with their latest completed scan"""
+    projects = []
+    for project in get_project_store().list():
+        if not principal.can_access(project.project_id):
+            continue
+        completed = project_reports(project.project_id, status="completed")
+        projects.append({**project.to_dict(), "latest": report_summary(completed[0]) if completed else None})
+    return {"projects": projects, "total": len(projects)}
+
+
+@app.post("/api/v1/projects")
+async def create_project(request: Dict[str, Any], principal: Principal = Depends(require_scope("admin"))):
+    """Register a repository checkout as a project"""
+    if not request.get("name") or not request.get("target"):
+        raise HTTPException(status_code=400, detail="name and target are required")
+    try:
+        project = get_project_store().create(
+            request["name"],
+            request["target"],
+            repo_url=request.get("repo_url"),
+            config=request.get("config"),
+            project_id=request.get("project_id")
+        )
+    except ProjectError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    principal.note(project_id=project.project_id)
+    return project.to_dict()
+
+
+@app.get("/api/v1/projects/{project_id}")
+async def get_project(project_id: str, principal: Principal = Depends(require_scope("read"))):
+    """Get a project with its effective scan config and latest completed scan"""
+    project = get_accessible_project(project_id, principal)
+    completed = project_reports(project_id, status="completed")
+    return {
+        **project.to_dict(),
+        "effective_config": project.scan_config().to_dict(),
+        "latest": report_summary(completed[0]) if completed else None
+    }
+
+
+@app.patch("/api/v1/projects/{project_id}")
+async def update_project(project_id: str, request: Dict[str, Any], principal: Principal = Depends(require_scope("admin"))):
+    """Change a project's name, checkout path, repo URL, or config overrides"""
+    get_accessible_project(project_id, principal)
+    try:
+        project = get_project_store().update(
+            project_id,
+            name=request.get("name"),
+            target=request.get("target"),
+            repo_url=request.get("repo_url"),
+            config=request.get("config")
+        )
+    except ProjectError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    principal.note(project_id=project_id)
+    return project.to_dict()
+
+
+@app.delete("/api/v1/projects/{project_id}")
+async def delete_project(project_id: str, principal: Principal = Depends(require_scope("admin"))):
+    """Unregister a project; its reports are kept"""
+    get_accessible_project(project_id, principal)
+    project = get_project_store().delete(project_id)
+    principal.note(project_id=project_id)
+    return {"deleted": project.to_dict()}
+
+
+def run_project_static_scan(session_id: str, project: Project):
+    report = run_static_scan(session_id, project.target, project)
+    save_report(report)
+    update_stats_from_report(report)
+
+
+@app.post("/api/v1/projects/{project_id}/scans")
+async def start_project_scan(project_id: str, background_tasks: BackgroundTasks, principal: Principal = Depends(require_scope("scan"))):
+    """Scan a project's checkout with its config; static rules only when no LLM key is configured"""
+    project = get_accessible_project(project_id, principal)
+    session_id = f"{project_id}_{int(time.time())}"
+    principal.note(session_id=session_id, target=project.target, project_id=project_id)
+
+    if get_llm_config().has_any_key() and not is_offline():
+        background_tasks.add_task(run_analysis_pipeline, session_id, "project", project.target, project=project)
+        mode = "full"
+    else:
+        background_tasks.add_task(run_project_static_scan, session_id, project)
+        mode = "static"
+
+    return {"session_id": session_id, "project_id": project_id, "status": "started", "mode": mode}
+
+
+@app.get("/api/v1/projects/{project_id}/reports")
+async def list_project_reports(project_id: str, status: Optional[str] = None, limit: int = 50, principal: Principal = Depends(require_scope("read"))):
+    """Scan history of a project, newest first"""
+    get_accessible_project(project_id, principal)
+    reports = project_reports(project_id, status=status)
+    return {"project_id": project_id, "reports": [report_summary(r) for r in reports[:limit]], "total": len(reports)}
+
+
+@app.get("/api/v1/projects/{project_id}/findings")
+async def list_project_findings(
+    project_id: str,
+    severity: Optional[str] = None,
+    rule_id: Optional[str] = None,
+    file: Optional[str] = None,
+    new_only: bool = False,
+    team: Optional[str] = None,
+    session_id: Optional[str] = None,
+    principal: Principal = Depends(require_scope("read"))
+):
+    """Findings of the latest completed scan (or one session), filtered by severity, rule, file, CODEOWNERS team, or baseline"""
+    get_accessible_project(project_id, principal)
+    if session_id:
+        report = load_report(session_id)
+        if not report or report.get("project_id") != project_id:
+            raise HTTPException(status_code=404, detail="Report not found")
+    else:
+        completed = project_reports(project_id, status="completed")
+        if not completed:
+            return {"project_id": project_id, "session_id": None, "findings": [], "total": 0}
+        report = completed[0]
+
+    findings = report.get("vulnerabilities", [])
+    if severity:
+        wanted = set(severity.lower().split(','))
+        findings = [v for v in findings if v.get("severity") in wanted]
+    if rule_id:
+        findings = [v for v in findings if v.get("rule_id") == rule_id]
+    if file:
+        findings = [v for v in findings if file in v.get("file_path", "")]
+    if new_only:
+        findings = [v for v in findings if not v.get("in_baseline")]
+    if team:
+        findings = [v for v in findings if owned_by(v, [team])]
+
+    return {"project_id": project_id, "session_id": report.get("session_id"), "findings": findings, "total": len(findings)}
+
+
+@app.put("/api/v1/projects/{project_id}/baseline")
+async def set_project_baseline(project_id: str, request: Optional[Dict[str, Any]] = None, principal: Principal = Depends(require_scope("admin"))):
+    """Accept a scan as the project's baseline (default: latest completed scan); later scans flag only new findings"""
+    get_accessible_project(project_id, principal)
+    session_id = (request or {}).get("session_id")
+    if session_id:
+        report = load_report(session_id)
+        if not report or report.get("project_id") != project_id or report.get("status") != "completed":
+            raise HTTPException(status_code=400, detail="Baseline must be a completed scan of this project")
+    else:
+        completed = project_reports(project_id, status="completed")
+        if not completed:
+            raise HTTPException(status_code=400, detail="Project has no completed scans")
+        session_id = completed[0]["session_id"]
+
+    project = get_project_store().update(project_id, baseline_session_id=session_id)
+    principal.note(project_id=project_id, session_id=session_id)
+    return project.to_dict()
+
+
 def get_commit_diff(project_path: str, commit_id: str, compare_to: str = None) -> Tuple[Optional[str], Optional[str], Optional[Dict[str, str]], Optional[List[int]]]:
     """Get diff, commit message, full file contents, and changed line numbers"""
     try:
@@ -621,7 +1397,7 @@ def get_commit_diff(project_path: str, commit_id: str, compare_to: str = None) -
             return None, None, None, None
         
         result = subprocess.run(
-            ['git', 'rev-parse', '--git-dir'],
+            git_command(['rev-parse', '--git-dir']),
             cwd=project_path,
             capture_output=True,
             text=True,
@@ -631,11 +1407,11 @@ def get_commit_diff(project_path: str, commit_id: str, compare_to: str = None) -
             return None, None, None, None
         
         if compare_to:
-            diff_cmd = ['git', 'diff', compare_to, commit_id]
-            files_cmd = ['git', 'diff', '--name-only', compare_to, commit_id]
+            diff_cmd = git_command(['diff', '--no-ext-diff', '--no-textconv', compare_to, commit_id])
+            files_cmd = git_command(['diff', '--name-only', compare_to, commit_id])
         else:
-            diff_cmd = ['git', 'show', commit_id, '--format=', '--patch']
-            files_cmd = ['git', 'show', commit_id, '--format=', '--name-only']
+            diff_cmd = git_command(['show', '--no-ext-diff', '--no-textconv', commit_id, '--format=', '--patch'])
+            files_cmd = git_command(['show', commit_id, '--format=', '--name-only'])
         
         diff_result = subprocess.run(
             diff_cmd,
@@ -647,7 +1423,7 @@ def get_commit_diff(project_path: str, commit_id: str, compare_to: str = None) -
         diff_content = diff_result.stdout.strip()
         
         msg_result = subprocess.run(
-            ['git', 'log', '-1', '--format=%B', commit_id],
+            git_command(['log', '-1', '--format=%B', commit_id]),
             cwd=project_path,
             capture_output=True,
             text=True,
@@ -706,8 +1482,9 @@ def parse_diff_line_numbers(diff_content: str) -> Dict[str, List[int]]:
 
 
 @app.post("/api/v1/analysis/diff")
-async def analyze_diff(request: Dict[str, Any], background_tasks: BackgroundTasks):
+async def analyze_diff(request: Dict[str, Any], background_tasks: BackgroundTasks, principal: Principal = Depends(require_scope("scan"))):
     """Analyze a git commit for security issues"""
+    require_llm("Commit analysis")
     config = get_llm_config()
     if not config.has_any_key():
         raise HTTPException(
@@ -731,6 +1508,7 @@ async def analyze_diff(request: Dict[str, Any], background_tasks: BackgroundTask
     if not diff_content:
         raise HTTPException(status_code=400, detail="Could not get diff for commit. Check project path and commit ID.")
     
+    principal.note(session_id=session_id, target=f"{project_path}@{commit_id}")
     background_tasks.add_task(run_diff_analysis, session_id, diff_content, project_path, commit_message, commit_id, file_contents, changed_lines)
     
     return {
@@ -749,6 +1527,7 @@ async def run_diff_analysis(session_id: str, diff_content: str, project_path: st
     changed_lines = changed_lines or {}
     
     report = {
+        "schema_version": REPORT_SCHEMA_VERSION,
         "session_id": session_id,
         "analysis_type": "diff",
         "project_path": project_path,
@@ -797,9 +1576,9 @@ async def run_diff_analysis(session_id: str, diff_content: str, project_path: st
         report["completed_at"] = time.time()
         await status.emit_analysis_failed(session_id, str(e))
     
-    report_path = os.path.join(REPORTS_DIR, f"{session_id}.json")
-    with open(report_path, 'w') as f:
-        json.dump(report, f, indent=2)
+    report["costs"] = ledger.to_dict()
+    
+    save_report(report)
     
     if report["status"] == "completed":
         update_stats_from_report(report)
@@ -808,8 +1587,9 @@ async def run_diff_analysis(session_id: str, diff_content: str, project_path: st
 
 
 @app.post("/api/v1/analysis/corpus")
-async def analyze_corpus(request: Dict[str, Any], background_tasks: BackgroundTasks):
+async def analyze_corpus(request: Dict[str, Any], background_tasks: BackgroundTasks, principal: Principal = Depends(require_scope("scan"))):
     """Analyze fuzzer corpus inputs to decode their format"""
+    require_llm("Corpus analysis")
     config = get_llm_config()
     if not config.has_any_key():
         raise HTTPException(status_code=503, detail="No LLM API keys configured")
@@ -821,6 +1601,7 @@ async def analyze_corpus(request: Dict[str, Any], background_tasks: BackgroundTa
     if not inputs:
         raise HTTPException(status_code=400, detail="At least one input is required")
     
+    principal.note(session_id=session_id)
     background_tasks.add_task(run_corpus_analysis, session_id, inputs, harness_code)
     
     return {
@@ -836,6 +1617,7 @@ async def run_corpus_analysis(session_id: str, inputs: List[str], harness_code:
     status = get_status_service()
     
     report = {
+        "schema_version": REPORT_SCHEMA_VERSION,
         "session_id": session_id,
         "analysis_type": "corpus",
         "started_at": time.time(),
@@ -894,14 +1676,14 @@ async def run_corpus_analysis(session_id: str, inputs: List[str], harness_code:
         report["completed_at"] = time.time()
         await status.emit_analysis_failed(session_id, str(e))
     
-    report_path = os.path.join(REPORTS_DIR, f"{session_id}.json")
-    with open(report_path, 'w') as f:
-        json.dump(report, f, indent=2)
+    report["costs"] = ledger.to_dict()
+    
+    save_report(report)
     
     logger.info(f"[{session_id}] Corpus analysis complete")
 
 
-@app.get("/agents/status")
+@app.get("/agents/status", dependencies=[Depends(require_scope("read"))])
 async def get_agents_status():
     """Get status of all agents"""
     agents = create_agents()
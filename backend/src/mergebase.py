@@ -0,0 +1,135 @@
+"""
+Merge-base gating - Fail a branch scan only on findings the branch introduced
+The merge-base with the main branch is scanned from `git archive` (the checkout is never touched) and its
+findings are matched by fingerprint; findings without a stable fingerprint match (LLM findings, whose
+wording changes between runs) count as new only when they sit on lines the branch changed.
+"""
+
+import io
+import logging
+import os
+import re
+import subprocess
+import tarfile
+import tempfile
+import time
+from typing import Any, Callable, Dict, List, Optional, Set, Tuple
+
+from .isolation import git_command
+from .reports import finding_fingerprint
+
+logger = logging.getLogger(__name__)
+
+DEFAULT_BASES = ('origin/HEAD', 'origin/main', 'origin/master', 'main', 'master')
+HUNK = re.compile(r'^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@')
+
+
+class MergeBaseError(Exception):
+    pass
+
+
+def git(args: List[str], cwd: str, text: bool = True) -> Any:
+    result = subprocess.run(git_command(args), cwd=cwd, capture_output=True, text=text, timeout=600)
+    if result.returncode != 0:
+        stderr = result.stderr if text else result.stderr.decode(errors='replace')
+        raise MergeBaseError(f"git {args[0]} failed: {stderr.strip()}")
+    return result.stdout
+
+
+def resolve_merge_base(target: str, base: Optional[str] = None) -> Tuple[str, str, str]:
+    """(repo root, base ref, merge-base commit) for the checkout containing target"""
+    try:
+        root = git(['rev-parse', '--show-toplevel'], target).strip()
+    except (MergeBaseError, OSError) as e:
+        raise MergeBaseError(f"{target} is not inside a git checkout: {e}")
+
+    for ref in [base] if base else DEFAULT_BASES:
+        try:
+            git(['rev-parse', '--verify', '--quiet', f'{ref}^{{commit}}'], root)
+        except MergeBaseError:
+            continue
+        try:
+            return root, ref, git(['merge-base', 'HEAD', ref], root).strip()
+        except MergeBaseError as e:
+            # shallow CI clones often lack the shared history
+            raise MergeBaseError(f"No merge-base between HEAD and {ref} (fetch more history, e.g. git fetch --unshallow): {e}")
+    raise MergeBaseError(f"Base branch not found: {base}" if base else f"No base branch found (tried {', '.join(DEFAULT_BASES)}); pass --base")
+
+
+def export_commit(root: str, commit: str, rel_path: str, destination: str):
+    """Write the tree of rel_path at commit into destination"""
+    args = ['archive', '--format=tar', commit] + ([rel_path] if rel_path != '.' else [])
+    with tarfile.open(fileobj=io.BytesIO(git(args, root, text=False))) as archive:
+        if hasattr(tarfile, 'data_filter'):
+            archive.extractall(destination, filter='data')
+        else:
+            archive.extractall(destination)
+
+
+def changed_lines(root: str, commit: str, rel_path: str) -> Tuple[Dict[str, Set[int]], Set[str]]:
+    """Lines added or modified since commit in the working tree (repo-relative paths), and files that are new
+    altogether (added since commit, or untracked)"""
+    diff = git(['diff', '-U0', '--no-color', '--no-ext-diff', commit, '--', rel_path], root)
+    lines: Dict[str, Set[int]] = {}
+    current = None
+    for line in diff.split('\n'):
+        if line.startswith('+++ '):
+            current = line[6:] if line.startswith('+++ b/') else None
+            if current is not None:
+                lines.setdefault(current, set())
+        elif current is not None:
+            hunk = HUNK.match(line)
+            if hunk:
+                start, count = int(hunk.group(1)), int(hunk.group(2) or 1)
+                lines[current].update(range(start, start + count))
+
+    added = set(git(['diff', '--name-only', '--diff-filter=A', commit, '--', rel_path], root).split('\n'))
+    added |= set(git(['ls-files', '--others', '--exclude-standard', '--', rel_path], root).split('\n'))
+    return lines, {path for path in added if path}
+
+
+def is_fingerprinted(vuln: Dict[str, Any]) -> bool:
+    """Static rule findings have a stable fingerprint (rule, file, snippet); LLM findings do not"""
+    return bool(vuln.get("rule_id"))
+
+
+def compare_with_merge_base(report: Dict[str, Any], target: str, base: Optional[str],
+                            scan: Callable[[str, str], Dict[str, Any]]) -> Dict[str, Any]:
+    """Mark the report's findings that already exist at the merge-base as in_baseline (so the gate skips
+    them) and record the comparison under report["baseline"]. scan(session_id, directory) runs the static
+    rules over the exported merge-base tree."""
+    target = os.path.abspath(target)
+    root, ref, commit = resolve_merge_base(target, base)
+    rel_path = os.path.relpath(target, root)
+
+    with tempfile.TemporaryDirectory(prefix="sastscan-base-") as workdir:
+        try:
+            git(['cat-file', '-e', f'{commit}:{rel_path if rel_path != "." else ""}'], root)
+            export_commit(root, commit, rel_path, workdir)
+        except MergeBaseError:
+            logger.info(f"{rel_path} does not exist at {commit[:12]}; every finding is new")
+        base_target = os.path.join(workdir, rel_path) if rel_path != '.' else workdir
+        os.makedirs(base_target, exist_ok=True)
+        base_report = scan(f"base_{int(time.time())}", base_target)
+        known = {finding_fingerprint(v, base_target) for v in base_report.get("vulnerabilities", []) if is_fingerprinted(v)}
+
+    modified, added = changed_lines(root, commit, rel_path)
+    current = set()
+    for vuln in report.get("vulnerabilities", []):
+        file_path = os.path.relpath(os.path.abspath(vuln.get("file_path", "")), root)
+        if is_fingerprinted(vuln):
+            fingerprint = finding_fingerprint(vuln, target)
+            vuln["in_baseline"] = fingerprint in known
+            current.add(fingerprint)
+        else:
+            vuln["in_baseline"] = file_path not in added and vuln.get("line_number") not in modified.get(file_path, set())
+
+    report["baseline"] = {
+        "merge_base": commit,
+        "base_ref": ref,
+        "new": len([v for v in report.get("vulnerabilities", []) if not v["in_baseline"]]),
+        "existing": len(known & current) + len([v for v in report.get("vulnerabilities", []) if not is_fingerprinted(v) and v["in_baseline"]]),
+        "fixed": len(known - current)
+    }
+    logger.info(f"Merge-base {commit[:12]} ({ref}): {report['baseline']['new']} new, {report['baseline']['existing']} existing, {report['baseline']['fixed']} fixed")
+    return report["baseline"]
@@ -0,0 +1,20 @@
+"""
+Notifications - Post scan summaries to chat webhooks and email after each scan
+"""
+
+from .channels import Channel, SlackChannel, TeamsChannel, WebhookChannel, build_channel
+from .email import EmailChannel
+from .notifier import Notifier, ScanSummary, diff_against_previous, load_notifier
+
+__all__ = [
+    'Channel',
+    'SlackChannel',
+    'TeamsChannel',
+    'WebhookChannel',
+    'EmailChannel',
+    'build_channel',
+    'Notifier',
+    'ScanSummary',
+    'diff_against_previous',
+    'load_notifier',
+]
@@ -0,0 +1,152 @@
+"""
+Notification channels - Slack, Microsoft Teams, and generic JSON webhooks
+"""
+
+import logging
+from typing import TYPE_CHECKING, Any, Dict, List, Optional
+
+import httpx
+
+if TYPE_CHECKING:
+    from .notifier import ScanSummary
+
+logger = logging.getLogger(__name__)
+
+SEVERITIES = ["critical", "high", "medium", "low"]
+SEVERITY_COLORS = {"critical": "8B0000", "high": "DC2626", "medium": "D97706", "low": "2563EB"}
+
+
+class Channel:
+    kind = ""
+
+    def __init__(self, url: str, severities: Optional[List[str]] = None, on_fixed: bool = True, always: bool = False, name: str = "",
+                 teams: Optional[List[str]] = None, critical_paths: bool = False):
+        self.url = url
+        self.severities = [s for s in (severities or SEVERITIES) if s in SEVERITIES]
+        self.on_fixed = on_fixed
+        self.always = always
+        self.name = name or self.kind
+        self.teams = [teams] if isinstance(teams, str) else list(teams or [])
+        self.critical_paths = critical_paths  # the security channel: every finding in a critical path, at any severity
+
+    def new(self, summary: "ScanSummary") -> List[Dict[str, Any]]:
+        return summary.new_at(self.severities, self.critical_paths)
+
+    def fixed(self, summary: "ScanSummary") -> List[Dict[str, Any]]:
+        return summary.fixed_at(self.severities, self.critical_paths)
+
+    def counts(self, summary: "ScanSummary") -> str:
+        return summary.counts_line(self.severities, self.critical_paths)
+
+    def wants(self, summary: "ScanSummary") -> bool:
+        """Route by severity: only scans with new (or fixed) findings at a routed severity (or, for the
+        security channel, in a critical path) or a failed trend gate are sent"""
+        if self.always or summary.trend_failures():
+            return True
+        if self.new(summary):
+            return True
+        return self.on_fixed and bool(self.fixed(summary))
+
+    def payload(self, summary: "ScanSummary") -> Dict[str, Any]:
+        raise NotImplementedError
+
+    async def send(self, summary: "ScanSummary") -> bool:
+        try:
+            async with httpx.AsyncClient(timeout=30) as client:
+                response = await client.post(self.url, json=self.payload(summary))
+            if response.status_code >= 400:
+                logger.warning(f"{self.name} notification rejected: {response.status_code} {response.text[:200]}")
+                return False
+            return True
+        except Exception as e:
+            logger.warning(f"{self.name} notification failed: {e}")
+            return False
+
+
+class SlackChannel(Channel):
+    kind = "slack"
+
+    def payload(self, summary: "ScanSummary") -> Dict[str, Any]:
+        lines = [f"*{summary.title()}*", self.counts(summary)]
+        for failure in summary.trend_failures():
+            lines.append(f"• :chart_with_upwards_trend: trend gate {failure}")
+        for vuln in self.new(summary)[:10]:
+            critical = f" (critical path `{vuln['critical_path']}`)" if vuln.get("critical_path") else ""
+            lines.append(f"• :rotating_light: `{vuln.get('severity')}` {vuln.get('vuln_type')} at `{vuln.get('file_path')}:{vuln.get('line_number')}`{critical}")
+        if self.on_fixed:
+            for vuln in self.fixed(summary)[:5]:
+                lines.append(f"• :white_check_mark: fixed {vuln.get('vuln_type')} in `{vuln.get('file_path')}`")
+        if summary.report_url:
+            lines.append(f"<{summary.report_url}|View report {summary.session_id}>")
+
+        text = '\n'.join(lines)
+        return {
+            "text": f"{summary.title()}: {self.counts(summary)}",
+            "blocks": [{"type": "section", "text": {"type": "mrkdwn", "text": text}}]
+        }
+
+
+class TeamsChannel(Channel):
+    kind = "teams"
+
+    def payload(self, summary: "ScanSummary") -> Dict[str, Any]:
+        new = self.new(summary)
+        worst = next((s for s in SEVERITIES if any(v.get("severity") == s for v in new)), None)
+        facts = [{"name": f"New {s}", "value": str(len([v for v in new if v.get("severity") == s]))} for s in self.severities]
+        if self.critical_paths:
+            facts.append({"name": "New in critical paths", "value": str(len([v for v in new if v.get("critical_path")]))})
+        facts.append({"name": "Fixed", "value": str(len(self.fixed(summary)))})
+        facts += [{"name": "Trend gate", "value": failure} for failure in summary.trend_failures()]
+
+        card: Dict[str, Any] = {
+            "@type": "MessageCard",
+            "@context": "https://schema.org/extensions",
+            "summary": summary.title(),
+            "themeColor": SEVERITY_COLORS.get(worst, "D97706" if summary.trend_failures() else "16A34A"),
+            "title": summary.title(),
+            "sections": [{
+                "facts": facts,
+                "text": '<br>'.join(
+                    f"**{v.get('severity')}** {v.get('vuln_type')} at `{v.get('file_path')}:{v.get('line_number')}`"
+                    for v in new[:10]
+                )
+            }]
+        }
+        if summary.report_url:
+            card["potentialAction"] = [{
+                "@type": "OpenUri",
+                "name": "View report",
+                "targets": [{"os": "default", "uri": summary.report_url}]
+            }]
+        return card
+
+
+class WebhookChannel(Channel):
+    kind = "webhook"
+
+    def payload(self, summary: "ScanSummary") -> Dict[str, Any]:
+        return summary.to_dict()
+
+
+CHANNEL_TYPES = {cls.kind: cls for cls in (SlackChannel, TeamsChannel, WebhookChannel)}
+
+
+def build_channel(config: Dict[str, Any]) -> Channel:
+    kind = config.get("type", "webhook")
+    if kind == "email":
+        from .email import EmailChannel
+        return EmailChannel.from_config(config)
+    if kind not in CHANNEL_TYPES:
+        raise ValueError(f"Unknown notification type: {kind} (choose from {', '.join(list(CHANNEL_TYPES) + ['email'])})")
+    url = config.get("webhook_url") or config.get("url")
+    if not url:
+        raise ValueError(f"{kind} notification needs a webhook_url")
+    return CHANNEL_TYPES[kind](
+        url,
+        severities=config.get("severities"),
+        on_fixed=config.get("on_fixed", True),
+        always=config.get("always", False),
+        name=config.get("name", ""),
+        teams=config.get("teams"),
+        critical_paths=config.get("critical_paths", False)
+    )
@@ -0,0 +1,158 @@
+"""
+Email channel - Send the scan summary (Markdown text + HTML) over SMTP
+"""
+
+import asyncio
+import html
+import logging
+import smtplib
+from email.message import EmailMessage
+from typing import TYPE_CHECKING, Any, Dict, List, Optional
+
+from ..config.settings import get_settings
+from .channels import Channel
+
+if TYPE_CHECKING:
+    from .notifier import ScanSummary
+
+logger = logging.getLogger(__name__)
+
+
+class EmailChannel(Channel):
+    kind = "email"
+
+    def __init__(
+        self,
+        recipients: List[str],
+        smtp_host: str,
+        smtp_port: int = 587,
+        username: Optional[str] = None,
+        password: Optional[str] = None,
+        sender: Optional[str] = None,
+        starttls: bool = True,
+        attach_report: bool = True,
+        **kwargs
+    ):
+        super().__init__(url=f"smtp://{smtp_host}:{smtp_port}", **kwargs)
+        self.recipients = recipients
+        self.smtp_host = smtp_host
+        self.smtp_port = smtp_port
+        self.username = username
+        self.password = password
+        self.sender = sender or username or "scanner@localhost"
+        self.starttls = starttls
+        self.attach_report = attach_report
+
+    @classmethod
+    def from_config(cls, config: Dict[str, Any]) -> "EmailChannel":
+        settings = get_settings()
+        recipients = config.get("to") or config.get("recipients") or []
+        if isinstance(recipients, str):
+            recipients = [recipients]
+        if not recipients:
+            raise ValueError("email notification needs at least one recipient in 'to'")
+
+        host = config.get("smtp_host") or settings.smtp_host
+        if not host:
+            raise ValueError("email notification needs smtp_host (or SMTP_HOST)")
+
+        return cls(
+            recipients=recipients,
+            smtp_host=host,
+            smtp_port=int(config.get("smtp_port") or settings.smtp_port),
+            username=config.get("smtp_username") or settings.smtp_username,
+            password=config.get("smtp_password") or settings.smtp_password,
+            sender=config.get("from") or settings.smtp_from,
+            starttls=config.get("starttls", settings.smtp_starttls),
+            attach_report=config.get("attach_report", True),
+            severities=config.get("severities"),
+            on_fixed=config.get("on_fixed", True),
+            always=config.get("always", False),
+            name=config.get("name", ""),
+            teams=config.get("teams"),
+            critical_paths=config.get("critical_paths", False)
+        )
+
+    def markdown(self, summary: "ScanSummary") -> str:
+        lines = [f"# {summary.title()}", "", self.counts(summary), ""]
+        failures = summary.trend_failures()
+        if failures:
+            lines += ["## Trend gate failed", ""] + [f"- {failure}" for failure in failures] + [""]
+        new = self.new(summary)
+        if new:
+            lines += ["## New findings", ""]
+            lines += [
+                f"- **{v.get('severity')}** {v.get('vuln_type')} at `{v.get('file_path')}:{v.get('line_number')}`"
+                + (f" (critical path `{v['critical_path']}`)" if v.get("critical_path") else "")
+                for v in new
+            ]
+            lines.append("")
+        fixed = self.fixed(summary)
+        if fixed and self.on_fixed:
+            lines += ["## Fixed", ""]
+            lines += [f"- {v.get('vuln_type')} in `{v.get('file_path')}`" for v in fixed]
+            lines.append("")
+        if summary.report_url:
+            lines.append(f"Full report: {summary.report_url}")
+        return '\n'.join(lines) + '\n'
+
+    def html_body(self, summary: "ScanSummary") -> str:
+        e = html.escape
+        rows = ''.join(
+            f"<tr><td><b>{e(v.get('severity', ''))}</b></td><td>{e(v.get('vuln_type', ''))}</td>"
+            f"<td><code>{e(str(v.get('file_path', '')))}:{v.get('line_number', '')}</code></td></tr>"
+            for v in self.new(summary)
+        )
+        parts = [
+            f"<h2>{e(summary.title())}</h2>",
+            f"<p>{e(self.counts(summary))}</p>",
+        ]
+        failures = summary.trend_failures()
+        if failures:
+            parts.append("<h3>Trend gate failed</h3><ul>" + ''.join(f"<li>{e(failure)}</li>" for failure in failures) + "</ul>")
+        if rows:
+            parts.append(f"<h3>New findings</h3><table cellpadding=\"4\"><tr><th>Severity</th><th>Type</th><th>Location</th></tr>{rows}</table>")
+        fixed = self.fixed(summary)
+        if fixed and self.on_fixed:
+            parts.append("<h3>Fixed</h3><ul>" + ''.join(
+                f"<li>{e(v.get('vuln_type', ''))} in <code>{e(str(v.get('file_path', '')))}</code></li>" for v in fixed
+            ) + "</ul>")
+        if summary.report_url:
+            parts.append(f"<p><a href=\"{e(summary.report_url)}\">View the full report</a></p>")
+        return "<html><body>" + ''.join(parts) + "</body></html>"
+
+    def message(self, summary: "ScanSummary") -> EmailMessage:
+        message = EmailMessage()
+        new = self.new(summary)
+        message["Subject"] = f"[scanner] {summary.title()}: {len(new)} new finding(s)" + (", trend gate failed" if summary.trend_failures() else "")
+        message["From"] = self.sender
+        message["To"] = ', '.join(self.recipients)
+        message.set_content(self.markdown(summary))
+        message.add_alternative(self.html_body(summary), subtype="html")
+
+        if self.attach_report and summary.report:
+            from ..formatters import render_html
+
+            message.add_attachment(
+                render_html(summary.report).encode(),
+                maintype="text",
+                subtype="html",
+                filename=f"{summary.session_id}.html"
+            )
+        return message
+
+    def _deliver(self, message: EmailMessage):
+        with smtplib.SMTP(self.smtp_host, self.smtp_port, timeout=30) as smtp:
+            if self.starttls:
+                smtp.starttls()
+            if self.username and self.password:
+                smtp.login(self.username, self.password)
+            smtp.send_message(message)
+
+    async def send(self, summary: "ScanSummary") -> bool:
+        try:
+            await asyncio.to_thread(self._deliver, self.message(summary))
+            return True
+        except (smtplib.SMTPException, OSError) as e:
+            logger.warning(f"{self.name} notification to {', '.join(self.recipients)} failed: {e}")
+            return False
@@ -0,0 +1,162 @@
+"""
+Notifier - Build a scan summary and route it to the configured channels
+"""
+
+import asyncio
+import logging
+from dataclasses import dataclass, field, replace
+from typing import Any, Dict, List, Optional
+
+import yaml
+
+from ..codeowners import owned_by, team_report
+from ..config.offline import is_offline
+from ..reports import finding_fingerprint, list_report_ids, load_report
+from ..trends import describe_trend_gate
+from .channels import SEVERITIES, Channel, build_channel
+
+logger = logging.getLogger(__name__)
+
+
+@dataclass
+class ScanSummary:
+    session_id: str
+    target: str
+    new_findings: List[Dict[str, Any]] = field(default_factory=list)
+    fixed_findings: List[Dict[str, Any]] = field(default_factory=list)
+    by_severity: Dict[str, int] = field(default_factory=dict)
+    report_url: Optional[str] = None
+    status: str = "completed"
+    teams: List[str] = field(default_factory=list)  # set when scoped to the findings of some CODEOWNERS teams
+    trend_gate: Optional[Dict[str, Any]] = None
+    report: Optional[Dict[str, Any]] = field(default=None, repr=False)
+
+    @classmethod
+    def from_report(cls, report: Dict[str, Any], changes: Dict[str, List[Dict[str, Any]]], report_url: Optional[str] = None) -> "ScanSummary":
+        by_severity: Dict[str, int] = {}
+        for vuln in report.get("vulnerabilities", []):
+            by_severity[vuln.get("severity", "")] = by_severity.get(vuln.get("severity", ""), 0) + 1
+        daemon = report.get("daemon") or {}
+        target = f"{daemon['repo']} ({daemon['branch']})" if daemon else str(report.get("target") or report.get("project_path") or "")
+        return cls(
+            session_id=report.get("session_id", ""),
+            target=target,
+            new_findings=changes.get("new", []),
+            fixed_findings=changes.get("fixed", []),
+            by_severity=by_severity,
+            report_url=report_url,
+            status=report.get("status", "completed"),
+            trend_gate=report.get("trend_gate"),
+            report=report
+        )
+
+    def new_at(self, severities: List[str], critical_paths: bool = False) -> List[Dict[str, Any]]:
+        """New findings at those severities, and with critical_paths every one in a critical path"""
+        ordered = sorted(self.new_findings, key=lambda v: SEVERITIES.index(v["severity"]) if v.get("severity") in SEVERITIES else 4)
+        return [v for v in ordered if v.get("severity") in severities or (critical_paths and v.get("critical_path"))]
+
+    def fixed_at(self, severities: List[str], critical_paths: bool = False) -> List[Dict[str, Any]]:
+        return [v for v in self.fixed_findings if v.get("severity") in severities or (critical_paths and v.get("critical_path"))]
+
+    def for_teams(self, teams: List[str]) -> "ScanSummary":
+        """The new and fixed findings those teams own, for a channel routed to them"""
+        return replace(
+            self,
+            new_findings=[v for v in self.new_findings if owned_by(v, teams)],
+            fixed_findings=[v for v in self.fixed_findings if owned_by(v, teams)],
+            teams=list(teams),
+            report=team_report(self.report, teams) if self.report else None
+        )
+
+    def trend_failures(self) -> List[str]:
+        """One line per failed trend gate; the gates cover the whole project, so team-scoped summaries keep them"""
+        if not self.trend_gate or self.trend_gate.get("passed", True):
+            return []
+        return [describe_trend_gate(r) for r in self.trend_gate.get("gates", []) if not r["passed"]]
+
+    def title(self) -> str:
+        if self.teams:
+            return f"Security scan of {self.target} for {', '.join(self.teams)}"
+        return f"Security scan of {self.target}"
+
+    def counts_line(self, severities: List[str], critical_paths: bool = False) -> str:
+        new = self.new_at(severities, critical_paths)
+        parts = [f"{len([v for v in new if v.get('severity') == s])} new {s}" for s in severities]
+        if critical_paths:
+            parts.append(f"{len([v for v in new if v.get('critical_path')])} new in critical paths")
+        parts.append(f"{len(self.fixed_at(severities, critical_paths))} fixed")
+        return ', '.join(parts)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "session_id": self.session_id,
+            "target": self.target,
+            "status": self.status,
+            "new_findings": self.new_findings,
+            "fixed_findings": self.fixed_findings,
+            "by_severity": self.by_severity,
+            "report_url": self.report_url,
+            "teams": self.teams,
+            "trend_gate": self.trend_gate
+        }
+
+
+def diff_against_previous(report: Dict[str, Any]) -> Dict[str, List[Dict[str, Any]]]:
+    """Compare a report with the newest earlier report of the same target"""
+    target = report.get("target")
+    current = {finding_fingerprint(v): v for v in report.get("vulnerabilities", [])}
+
+    previous = None
+    for session_id in list_report_ids():
+        if session_id == report.get("session_id"):
+            continue
+        candidate = load_report(session_id)
+        if candidate and candidate.get("target") == target and candidate.get("status") == "completed" \
+                and candidate.get("started_at", 0) < report.get("started_at", 0):
+            previous = candidate
+            break
+
+    if previous is None:
+        return {"new": list(current.values()), "fixed": []}
+
+    before = {finding_fingerprint(v): v for v in previous.get("vulnerabilities", [])}
+    return {
+        "new": [v for fp, v in current.items() if fp not in before],
+        "fixed": [v for fp, v in before.items() if fp not in current]
+    }
+
+
+class Notifier:
+
+    def __init__(self, channels: List[Channel]):
+        self.channels = channels
+
+    @classmethod
+    def from_config(cls, entries: List[Dict[str, Any]]) -> "Notifier":
+        return cls([build_channel(entry) for entry in entries or []])
+
+    async def notify(self, summary: ScanSummary) -> int:
+        # team-routed channels only hear about the findings CODEOWNERS assigns to their teams
+        scoped = [(c, summary.for_teams(c.teams) if c.teams else summary) for c in self.channels]
+        targets = [(c, s) for c, s in scoped if c.wants(s)]
+        if not targets:
+            return 0
+        if is_offline():
+            logger.info(f"[{summary.session_id}] Offline mode: not sending {len(targets)} notification(s)")
+            return 0
+        results = await asyncio.gather(*(c.send(s) for c, s in targets))
+        logger.info(f"[{summary.session_id}] Sent {sum(results)}/{len(targets)} notification(s)")
+        return sum(results)
+
+
+def load_notifier(path: Optional[str]) -> Optional[Notifier]:
+    """Load the 'notifications' list from a YAML file"""
+    if not path:
+        return None
+    try:
+        with open(path, 'r') as f:
+            data = yaml.safe_load(f) or {}
+        return Notifier.from_config(data.get("notifications", []))
+    except (OSError, ValueError, yaml.YAMLError) as e:
+        logger.warning(f"Ignoring notification config {path}: {e}")
+        return None
@@ -0,0 +1,142 @@
+"""
+PDF writer - Just enough PDF 1.4 for the executive report: A4 pages with text in the standard Helvetica and
+Courier fonts, filled rectangles, and lines
+No dependency: the fonts are the 14 standard fonts every viewer has, so nothing is embedded, and text is
+encoded as WinAnsi (characters outside it print as "?"). Coordinates are in points from the top-left corner
+of the page, unlike PDF's own bottom-left origin.
+"""
+
+import time
+import zlib
+from typing import List, Optional, Tuple
+
+PAGE_WIDTH, PAGE_HEIGHT = 595.28, 841.89  # A4
+MARGIN = 50
+
+Color = Tuple[float, float, float]
+BLACK: Color = (0, 0, 0)
+
+FONTS = {"regular": ("F1", "Helvetica"), "bold": ("F2", "Helvetica-Bold"), "mono": ("F3", "Courier")}
+
+# Helvetica advance widths (1/1000 em) for characters 32-126
+HELVETICA_WIDTHS = [
+    278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+    556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+    1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+    667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+    333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+    556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+]
+BOLD_FACTOR = 1.07  # Helvetica-Bold runs about this much wider
+
+
+def text_width(text: str, size: float, font: str = "regular") -> float:
+    if font == "mono":
+        return len(text) * 0.6 * size
+    units = sum(HELVETICA_WIDTHS[ord(c) - 32] if 32 <= ord(c) <= 126 else 556 for c in text)
+    return units * size / 1000 * (BOLD_FACTOR if font == "bold" else 1)
+
+
+def wrap(text: str, width: float, size: float, font: str = "regular") -> List[str]:
+    """Lines of text that fit width; words longer than a line are cut"""
+    lines = []
+    for paragraph in str(text).split('\n'):
+        line = ""
+        for word in paragraph.split():
+            candidate = f"{line} {word}" if line else word
+            if text_width(candidate, size, font) <= width:
+                line = candidate
+                continue
+            if line:
+                lines.append(line)
+            while text_width(word, size, font) > width:
+                cut = max(1, int(len(word) * width / text_width(word, size, font)))
+                lines.append(word[:cut])
+                word = word[cut:]
+            line = word
+        lines.append(line)
+    return lines
+
+
+def truncate(text: str, width: float, size: float, font: str = "regular") -> str:
+    text = str(text)
+    if text_width(text, size, font) <= width:
+        return text
+    while text and text_width(text + "...", size, font) > width:
+        text = text[:-1]
+    return text + "..."
+
+
+def pdf_string(text: str) -> bytes:
+    encoded = str(text).encode('cp1252', errors='replace')
+    return b"(" + encoded.replace(b"\\", b"\\\\").replace(b"(", b"\\(").replace(b")", b"\\)").replace(b"\r", b"").replace(b"\n", b" ") + b")"
+
+
+class PdfDocument:
+    """Pages are drawn in order; new_page() starts the next one"""
+
+    def __init__(self, title: str = "", author: str = ""):
+        self.title = title
+        self.author = author
+        self.pages: List[List[bytes]] = []
+        self.new_page()
+
+    @property
+    def page_count(self) -> int:
+        return len(self.pages)
+
+    def new_page(self):
+        self.pages.append([])
+
+    def _op(self, operation: str, page: Optional[int] = None):
+        self.pages[-1 if page is None else page].append(operation.encode('latin-1'))
+
+    def text(self, x: float, y: float, text: str, size: float = 10, font: str = "regular", color: Color = BLACK, page: Optional[int] = None):
+        """Draw text with its baseline y points below the top of the page"""
+        name = FONTS[font][0]
+        r, g, b = color
+        operation = f"BT {r:.3f} {g:.3f} {b:.3f} rg /{name} {size:g} Tf {x:.2f} {PAGE_HEIGHT - y:.2f} Td "
+        self.pages[-1 if page is None else page].append(operation.encode('latin-1') + pdf_string(text) + b" Tj ET")
+
+    def rect(self, x: float, y: float, width: float, height: float, color: Color):
+        """Filled rectangle with its top-left corner at (x, y)"""
+        r, g, b = color
+        self._op(f"{r:.3f} {g:.3f} {b:.3f} rg {x:.2f} {PAGE_HEIGHT - y - height:.2f} {width:.2f} {height:.2f} re f")
+
+    def line(self, x1: float, y1: float, x2: float, y2: float, color: Color = BLACK, width: float = 0.5):
+        r, g, b = color
+        self._op(f"{r:.3f} {g:.3f} {b:.3f} RG {width:g} w {x1:.2f} {PAGE_HEIGHT - y1:.2f} m {x2:.2f} {PAGE_HEIGHT - y2:.2f} l S")
+
+    def to_bytes(self) -> bytes:
+        fonts = list(FONTS.values())
+        # 1 catalog, 2 page tree, 3 info, then the fonts, then a page and its content stream per page
+        first_page = 4 + len(fonts)
+        page_ids = [first_page + 2 * i for i in range(len(self.pages))]
+        font_resources = " ".join(f"/{name} {4 + i} 0 R" for i, (name, _) in enumerate(fonts))
+        created = time.strftime("D:%Y%m%d%H%M%SZ", time.gmtime())
+
+        objects = [
+            b"<< /Type /Catalog /Pages 2 0 R >>",
+            f"<< /Type /Pages /Kids [{' '.join(f'{p} 0 R' for p in page_ids)}] /Count {len(page_ids)} >>".encode(),
+            b"<< /Title " + pdf_string(self.title) + b" /Author " + pdf_string(self.author)
+            + b" /Producer " + pdf_string(self.author) + f" /CreationDate ({created}) >>".encode(),
+        ]
+        objects += [f"<< /Type /Font /Subtype /Type1 /BaseFont /{base} /Encoding /WinAnsiEncoding >>".encode() for _, base in fonts]
+        for page_id, operations in zip(page_ids, self.pages):
+            content = zlib.compress(b"\n".join(operations))
+            objects.append(
+                f"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 {PAGE_WIDTH} {PAGE_HEIGHT}] "
+                f"/Resources << /Font << {font_resources} >> >> /Contents {page_id + 1} 0 R >>".encode()
+            )
+            objects.append(f"<< /Length {len(content)} /Filter /FlateDecode >>\nstream\n".encode() + content + b"\nendstream")
+
+        output = bytearray(b"%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+        offsets = []
+        for number, body in enumerate(objects, start=1):
+            offsets.append(len(output))
+            output += f"{number} 0 obj\n".encode() + body + b"\nendobj\n"
+        xref = len(output)
+        output += f"xref\n0 {len(objects) + 1}\n0000000000 65535 f \n".encode()
+        output += b"".join(f"{offset:010d} 00000 n \n".encode() for offset in offsets)
+        output += f"trailer\n<< /Size {len(objects) + 1} /Root 1 0 R /Info 3 0 R >>\nstartxref\n{xref}\n%%EOF\n".encode()
+        return bytes(output)
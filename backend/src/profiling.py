@@ -0,0 +1,197 @@
+"""
+Scan profiling - Where a scan spends its time and memory (scanner scan --profile)
+A scan opens a ScanProfile with profile_scan(); while it is open (in the same task, or in tasks started from
+it) every static rule's run time is added up per rule, each file's analysis is timed, and the pipeline
+stages wrapped in stage() (call graph, retrieval index, agent steps, ...) are timed. Stages can overlap, e.g.
+the diff analyzer runs alongside the per-file analysis, so their times do not add up to the scan's.
+
+The CPU profile (cProfile, main thread only) and the heap profile (tracemalloc) cover the whole scan. They
+are written to analysis-reports/profiles/<session>.cpu.prof (pstats format, readable by snakeviz or
+`python -m pstats`) and <session>.heap (tracemalloc.Snapshot.load), and the report's profile block lists
+their top entries. Both slow the scan down, so compare timings between profiled runs only.
+"""
+
+import contextvars
+import cProfile
+import os
+import pstats
+import time
+import tracemalloc
+from contextlib import contextmanager
+from dataclasses import dataclass, field
+from typing import Any, Dict, Iterator, List, Optional
+
+from .reports import REPORTS_DIR
+
+PROFILES_DIR = os.path.join(REPORTS_DIR, 'profiles')
+TOP_ENTRIES = 25
+HEAP_FRAMES = 5
+
+_profile: contextvars.ContextVar[Optional["ScanProfile"]] = contextvars.ContextVar("scan_profile", default=None)
+
+
+@dataclass
+class ScanProfile:
+    session_id: str
+    root: Optional[str] = None  # file timings are reported relative to it
+    started_at: float = field(default_factory=time.perf_counter)
+    started_cpu: float = field(default_factory=time.process_time)
+    wall_seconds: float = 0.0
+    cpu_seconds: float = 0.0
+    stages: Dict[str, Dict[str, Any]] = field(default_factory=dict)
+    rules: Dict[str, Dict[str, Any]] = field(default_factory=dict)
+    files: Dict[str, float] = field(default_factory=dict)
+    cpu: Dict[str, Any] = field(default_factory=dict)
+    heap: Dict[str, Any] = field(default_factory=dict)
+
+    def add_stage(self, name: str, seconds: float):
+        entry = self.stages.setdefault(name, {"seconds": 0.0, "calls": 0})
+        entry["seconds"] += seconds
+        entry["calls"] += 1
+
+    def add_rule(self, rule_id: str, seconds: float, findings: int):
+        entry = self.rules.setdefault(rule_id, {"seconds": 0.0, "files": 0, "findings": 0})
+        entry["seconds"] += seconds
+        entry["files"] += 1
+        entry["findings"] += findings
+
+    def add_file(self, file_path: str, seconds: float):
+        name = os.path.relpath(file_path, self.root) if self.root and os.path.isabs(file_path) else file_path
+        self.files[name] = self.files.get(name, 0.0) + seconds
+
+    def to_dict(self) -> Dict[str, Any]:
+        def ordered(entries: Dict[str, Dict[str, Any]]) -> Dict[str, Dict[str, Any]]:
+            return {
+                name: {**entry, "seconds": round(entry["seconds"], 4)}
+                for name, entry in sorted(entries.items(), key=lambda item: (-item[1]["seconds"], item[0]))
+            }
+
+        slowest = sorted(self.files.items(), key=lambda item: (-item[1], item[0]))[:TOP_ENTRIES]
+        return {
+            "wall_seconds": round(self.wall_seconds, 3),
+            "cpu_seconds": round(self.cpu_seconds, 3),
+            "stages": ordered(self.stages),
+            "rules": ordered(self.rules),
+            "rule_seconds": round(sum(entry["seconds"] for entry in self.rules.values()), 4),
+            "files": {name: round(seconds, 4) for name, seconds in slowest},
+            "files_timed": len(self.files),
+            "cpu": self.cpu,
+            "heap": self.heap
+        }
+
+
+def current_profile() -> Optional[ScanProfile]:
+    return _profile.get()
+
+
+@contextmanager
+def stage(name: str) -> Iterator[None]:
+    """Time the block as one run of a pipeline stage of the open profile (nothing without one)"""
+    profile = _profile.get()
+    if profile is None:
+        yield
+        return
+    started = time.perf_counter()
+    try:
+        yield
+    finally:
+        profile.add_stage(name, time.perf_counter() - started)
+
+
+@contextmanager
+def timed_file(file_path: str) -> Iterator[None]:
+    """Time the block as (part of) the analysis of one file"""
+    profile = _profile.get()
+    if profile is None:
+        yield
+        return
+    started = time.perf_counter()
+    try:
+        yield
+    finally:
+        profile.add_file(file_path, time.perf_counter() - started)
+
+
+def cpu_summary(profiler: cProfile.Profile, path: str) -> Dict[str, Any]:
+    profiler.dump_stats(path)
+    stats = pstats.Stats(profiler)
+    top = []
+    # stats maps (file, line, function) -> (primitive calls, calls, own time, cumulative time, callers)
+    for (file_name, line, function), (_, calls, own, cumulative, _) in sorted(
+            stats.stats.items(), key=lambda item: -item[1][3])[:TOP_ENTRIES]:
+        top.append({
+            "function": f"{function} ({os.path.basename(file_name)}:{line})" if line else function,
+            "calls": calls,
+            "own_seconds": round(own, 4),
+            "cumulative_seconds": round(cumulative, 4)
+        })
+    return {"path": path, "top": top}
+
+
+def heap_summary(snapshot: tracemalloc.Snapshot, peak: int, path: str) -> Dict[str, Any]:
+    snapshot.dump(path)
+    # the scan's own allocations, not tracemalloc's
+    statistics = snapshot.filter_traces([tracemalloc.Filter(False, tracemalloc.__file__)]).statistics('lineno')
+    top = [
+        {"location": f"{stat.traceback[0].filename}:{stat.traceback[0].lineno}", "bytes": stat.size, "blocks": stat.count}
+        for stat in statistics[:TOP_ENTRIES]
+    ]
+    return {"path": path, "peak_bytes": peak, "retained_bytes": sum(stat.size for stat in statistics), "top": top}
+
+
+@contextmanager
+def profile_scan(session_id: str, root: Optional[str] = None, enabled: bool = True) -> Iterator[Optional[ScanProfile]]:
+    """Profile the scan run inside the block; yields None (and profiles nothing) unless enabled. The profile's
+    cpu and heap blocks are filled in when the block exits."""
+    if not enabled:
+        yield None
+        return
+    profile = ScanProfile(session_id, root)
+    token = _profile.set(profile)
+    profiler = cProfile.Profile()
+    tracing = tracemalloc.is_tracing()
+    if not tracing:
+        tracemalloc.start(HEAP_FRAMES)
+    profiler.enable()
+    try:
+        yield profile
+    finally:
+        profiler.disable()
+        profile.wall_seconds = time.perf_counter() - profile.started_at
+        profile.cpu_seconds = time.process_time() - profile.started_cpu
+        snapshot = tracemalloc.take_snapshot()
+        _, peak = tracemalloc.get_traced_memory()
+        if not tracing:
+            tracemalloc.stop()
+        _profile.reset(token)
+
+        os.makedirs(PROFILES_DIR, exist_ok=True)
+        base = os.path.abspath(os.path.join(PROFILES_DIR, session_id))
+        profile.cpu = cpu_summary(profiler, f"{base}.cpu.prof")
+        profile.heap = heap_summary(snapshot, peak, f"{base}.heap")
+
+
+def format_profile(profile: Dict[str, Any], limit: int = 10) -> List[str]:
+    """Text lines summarizing a report's profile block"""
+    lines = [f"Profile: {profile['wall_seconds']:.2f}s wall, {profile['cpu_seconds']:.2f}s CPU, "
+             f"{profile.get('heap', {}).get('peak_bytes', 0) / (1024 * 1024):.1f} MiB peak heap"]
+    sections = [
+        ("Stages", [(name, entry["seconds"], f"{entry['calls']} run(s)") for name, entry in profile["stages"].items()]),
+        ("Rules", [(name, entry["seconds"], f"{entry['files']} file(s), {entry['findings']} finding(s)") for name, entry in profile["rules"].items()]),
+        ("Slowest files", [(name, seconds, "") for name, seconds in profile["files"].items()]),
+        ("CPU (cumulative)", [(entry["function"], entry["cumulative_seconds"], f"{entry['calls']} call(s)") for entry in profile.get("cpu", {}).get("top", [])]),
+    ]
+    for title, rows in sections:
+        if not rows:
+            continue
+        lines.append(f"  {title}:")
+        lines.extend(f"    {seconds:>9.3f}s  {name}{'  (' + detail + ')' if detail else ''}" for name, seconds, detail in rows[:limit])
+    heap = profile.get("heap") or {}
+    if heap.get("top"):
+        lines.append("  Heap (retained at the end):")
+        lines.extend(f"    {entry['bytes'] / 1024:>8.0f} KiB  {entry['location']}" for entry in heap["top"][:limit])
+    if (profile.get("cpu") or {}).get("path"):
+        lines.append(f"  CPU profile: {profile['cpu']['path']}")
+    if heap.get("path"):
+        lines.append(f"  Heap snapshot: {heap['path']}")
+    return lines
@@ -0,0 +1,166 @@
+"""
+Project store - Registered repositories with their own config overrides, baseline, and report history
+"""
+
+import json
+import os
+import re
+import threading
+import time
+from dataclasses import asdict, dataclass, field
+from typing import Any, Dict, List, Optional
+
+from .config.project import ProjectConfig, load_project_config
+from .reports import finding_fingerprint, list_report_ids, load_report
+
+
+class ProjectError(Exception):
+    pass
+
+
+@dataclass
+class Project:
+    project_id: str
+    name: str
+    target: str  # local checkout scanned for this project
+    repo_url: Optional[str] = None
+    config: Dict[str, Any] = field(default_factory=dict)  # same keys as .sastscan.yaml, layered over the repo's file
+    baseline_session_id: Optional[str] = None
+    created_at: float = 0.0
+
+    def scan_config(self) -> ProjectConfig:
+        return load_project_config(self.target, self.config)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+def project_slug(name: str) -> str:
+    return re.sub(r'[^a-z0-9-]+', '-', name.lower()).strip('-') or "project"
+
+
+class ProjectStore:
+    """Projects persisted as JSON next to the API key store"""
+
+    def __init__(self, path: str):
+        self.path = path
+        self._lock = threading.Lock()
+
+    def _read(self) -> Dict[str, Project]:
+        if not os.path.exists(self.path):
+            return {}
+        with open(self.path, 'r') as f:
+            data = json.load(f)
+        return {p["project_id"]: Project(**p) for p in data.get("projects", [])}
+
+    def _write(self, projects: Dict[str, Project]):
+        os.makedirs(os.path.dirname(os.path.abspath(self.path)), exist_ok=True)
+        tmp = f"{self.path}.tmp"
+        with open(tmp, 'w') as f:
+            json.dump({"projects": [p.to_dict() for p in projects.values()]}, f, indent=2)
+        os.replace(tmp, self.path)
+
+    def list(self) -> List[Project]:
+        return sorted(self._read().values(), key=lambda p: p.project_id)
+
+    def get(self, project_id: str) -> Optional[Project]:
+        return self._read().get(project_id)
+
+    def create(self, name: str, target: str, repo_url: Optional[str] = None, config: Optional[Dict[str, Any]] = None, project_id: Optional[str] = None) -> Project:
+        project_id = project_slug(project_id or name)
+        if not os.path.isdir(target):
+            raise ProjectError(f"Project path is not a directory: {target}")
+        with self._lock:
+            projects = self._read()
+            if project_id in projects:
+                raise ProjectError(f"Project {project_id} already exists")
+            project = Project(
+                project_id=project_id,
+                name=name,
+                target=os.path.abspath(target),
+                repo_url=repo_url,
+                config=config or {},
+                created_at=time.time()
+            )
+            projects[project_id] = project
+            self._write(projects)
+        return project
+
+    def update(self, project_id: str, **changes) -> Project:
+        if "target" in changes and not os.path.isdir(changes["target"]):
+            raise ProjectError(f"Project path is not a directory: {changes['target']}")
+        with self._lock:
+            projects = self._read()
+            if project_id not in projects:
+                raise ProjectError(f"No project {project_id}")
+            project = projects[project_id]
+            for name, value in changes.items():
+                if name in ("name", "target", "repo_url", "config", "baseline_session_id") and value is not None:
+                    setattr(project, name, os.path.abspath(value) if name == "target" else value)
+            self._write(projects)
+            return project
+
+    def delete(self, project_id: str) -> Project:
+        with self._lock:
+            projects = self._read()
+            if project_id not in projects:
+                raise ProjectError(f"No project {project_id}")
+            project = projects.pop(project_id)
+            self._write(projects)
+            return project
+
+
+def get_project_store() -> ProjectStore:
+    from .config.settings import get_settings
+
+    return ProjectStore(get_settings().projects_file)
+
+
+def project_reports(project_id: str, status: Optional[str] = None) -> List[Dict[str, Any]]:
+    """Reports of one project, newest first"""
+    reports = []
+    for session_id in list_report_ids():
+        report = load_report(session_id)
+        if not report or report.get("project_id") != project_id:
+            continue
+        if status and report.get("status") != status:
+            continue
+        reports.append(report)
+    return reports
+
+
+def report_summary(report: Dict[str, Any]) -> Dict[str, Any]:
+    return {
+        "session_id": report.get("session_id"),
+        "status": report.get("status"),
+        "started_at": report.get("started_at"),
+        "completed_at": report.get("completed_at"),
+        "total_vulnerabilities": len(report.get("vulnerabilities", [])),
+        "by_severity": report.get("summary", {}).get("by_severity", {}),
+        "baseline": report.get("baseline"),
+    }
+
+
+def attach_project(report: Dict[str, Any], project: Project):
+    """Tag a report with its project and mark which findings were already in the project's baseline"""
+    report["project_id"] = project.project_id
+    if not project.baseline_session_id or project.baseline_session_id == report.get("session_id"):
+        return
+
+    baseline = load_report(project.baseline_session_id)
+    if not baseline:
+        return
+
+    known = {finding_fingerprint(v, project.target) for v in baseline.get("vulnerabilities", [])}
+    current = set()
+    for vuln in report.get("vulnerabilities", []):
+        fingerprint = finding_fingerprint(vuln, project.target)
+        vuln["in_baseline"] = fingerprint in known
+        current.add(fingerprint)
+
+    report["baseline"] = {
+        "session_id": project.baseline_session_id,
+        "new": len([v for v in report.get("vulnerabilities", []) if not v["in_baseline"]]),
+        "existing": len(known & current),
+        "fixed": len(known - current)
+    }
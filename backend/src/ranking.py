@@ -0,0 +1,87 @@
+"""
+Fix ranking - Order findings by fix ROI: how much risk a fix removes for the work it takes
+
+    score = severity weight x confidence x reachability / fix effort
+
+Severity weights are critical 10, high 6, medium 3, low 1. Reachability counts 1 for findings reachable from
+an entry point, 0.5 for unreachable ones, and 0.8 when it is unknown. Fix effort comes from the fix
+complexity: trivial 1 (a flag, a config value), localized 2 (a change inside one function), architectural 5
+(a design change across components). The triage agent estimates complexity per finding; without an LLM
+triage, the rule's own estimate is used. Findings triaged as false positives are not ranked.
+"""
+
+from typing import Any, Dict, List, Optional, Tuple
+
+from .analysis.rules.base import FIX_COMPLEXITIES as COMPLEXITIES
+
+EFFORT = {"trivial": 1.0, "localized": 2.0, "architectural": 5.0}
+SEVERITY_WEIGHT = {"critical": 10.0, "high": 6.0, "medium": 3.0, "low": 1.0}
+REACHABILITY = {True: 1.0, False: 0.5, None: 0.8}
+
+SORT_ORDERS = ("severity", "roi")
+
+
+def normalize_complexity(value: Optional[str]) -> Optional[str]:
+    value = (value or "").strip().lower()
+    return value if value in COMPLEXITIES else None
+
+
+def rule_complexity(rule_id: str) -> Optional[str]:
+    from .analysis.interop import RULE_ID as INTEROP_RULE_ID
+    from .analysis.rules import get_rule
+
+    if rule_id == INTEROP_RULE_ID:
+        return "architectural"  # the fix spans two services or processes
+    rule = get_rule(rule_id)
+    return rule.fix_complexity if rule else None
+
+
+def fix_complexity(vuln: Dict[str, Any], estimates: Dict[str, str], pack_rules: Dict[str, str]) -> Tuple[str, str]:
+    """(complexity, who estimated it): the triage agent's estimate when there is one, else the rule's"""
+    estimate = normalize_complexity(estimates.get(vuln.get("vuln_id")))
+    if estimate:
+        return estimate, "triage"
+    rule_id = vuln.get("rule_id")
+    if rule_id in pack_rules:
+        return pack_rules[rule_id], "rule"
+    previous = vuln.get("fix_priority")
+    if previous and previous.get("estimated_by") == "rule":
+        return previous["fix_complexity"], "rule"  # re-ranking a saved report, whose pack rules may not be loaded
+    estimate = rule_complexity(rule_id) if rule_id else None
+    if estimate:
+        return estimate, "rule"
+    return "localized", "default"
+
+
+def fix_score(vuln: Dict[str, Any], complexity: str) -> float:
+    reachable = (vuln.get("reachability") or {}).get("reachable")
+    score = (SEVERITY_WEIGHT.get(vuln.get("severity"), 3.0) * float(vuln.get("confidence") or 0.5)
+             * REACHABILITY[reachable] / EFFORT[complexity])
+    return round(score, 3)
+
+
+def rank_findings(report: Dict[str, Any], pack_rules: Optional[List[Any]] = None) -> int:
+    """Set each finding's fix_priority (rank 1 = best fix ROI); returns the number of findings ranked"""
+    estimates = {
+        result["vulnerability_id"]: result.get("fix_complexity")
+        for result in report.get("triage_results", []) if result.get("fix_complexity")
+    }
+    packs = {rule.rule_id: rule.fix_complexity for rule in pack_rules or []}
+
+    ranked = []
+    for vuln in report.get("vulnerabilities", []):
+        if (vuln.get("triage") or {}).get("verdict") == "false_positive":
+            vuln.pop("fix_priority", None)
+            continue
+        complexity, estimated_by = fix_complexity(vuln, estimates, packs)
+        vuln["fix_priority"] = {
+            "fix_complexity": complexity,
+            "estimated_by": estimated_by,
+            "score": fix_score(vuln, complexity)
+        }
+        ranked.append(vuln)
+
+    ranked.sort(key=lambda v: (-v["fix_priority"]["score"], v.get("file_path", ""), v.get("line_number", 0)))
+    for rank, vuln in enumerate(ranked, 1):
+        vuln["fix_priority"]["rank"] = rank
+    return len(ranked)
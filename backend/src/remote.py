@@ -0,0 +1,168 @@
+"""
+Remote repositories - Scan https://host/org/repo[@ref] (or git@host:org/repo[@ref]) without a local checkout
+The ref is fetched at depth 1 into a temporary directory that is removed after the scan. GitHub and GitLab
+tokens (GITHUB_TOKEN, GITLAB_TOKEN) are sent as an HTTP header through the environment, so they never
+appear in the clone URL, the process list, .git/config, or error messages.
+"""
+
+import base64
+import logging
+import os
+import re
+import shutil
+import subprocess
+import tempfile
+from dataclasses import dataclass
+from typing import Any, Dict, List, Optional
+from urllib.parse import urlparse
+
+from .config.settings import get_settings
+from .isolation import git_command, mark_untrusted, release_untrusted
+from .reports import relativize_paths
+
+logger = logging.getLogger(__name__)
+
+# the ref follows the last "@" of the path; "user@" before the host is credentials, not a ref
+HTTPS_REMOTE = re.compile(r'^(https?://(?:[^/@\s]+@)?[^/@\s]+/[^@\s]+?)(?:@([^@/\s][^@\s]*))?$')
+SSH_REMOTE = re.compile(r'^((?:ssh://)?[\w.-]+@[\w.-]+(?::\d+)?[:/][^@\s]+?)(?:@([^@/\s][^@\s]*))?$')
+
+
+class RemoteError(Exception):
+    pass
+
+
+@dataclass
+class RemoteRepo:
+    url: str
+    ref: Optional[str] = None  # branch, tag, or commit; None for the default branch
+
+    @property
+    def host(self) -> str:
+        if self.url.startswith(('http://', 'https://', 'ssh://')):
+            return urlparse(self.url).hostname or ''
+        return self.url.split('@', 1)[1].split(':', 1)[0]
+
+    @property
+    def display_url(self) -> str:
+        """The URL without any credentials embedded in it"""
+        if self.url.startswith(('http://', 'https://')):
+            return re.sub(r'^(https?://)[^/@]+@', r'\1', self.url)
+        return self.url
+
+    def __str__(self) -> str:
+        return f"{self.display_url}@{self.ref}" if self.ref else self.display_url
+
+
+def parse_remote(target: str) -> Optional[RemoteRepo]:
+    """A remote repository spec, or None for anything else (local paths win over look-alike names)"""
+    if not target or os.path.exists(target):
+        return None
+    match = HTTPS_REMOTE.match(target) or SSH_REMOTE.match(target)
+    if not match:
+        return None
+    return RemoteRepo(url=match.group(1), ref=match.group(2))
+
+
+def is_remote(target: str) -> bool:
+    return parse_remote(target) is not None
+
+
+def gitlab_hosts() -> List[str]:
+    """Hosts GITLAB_TOKEN may be sent to: the GITLAB_URL host plus GITLAB_HOSTS, matched exactly, so a
+    look-alike such as gitlab.attacker.io never receives the token"""
+    settings = get_settings()
+    hosts = [urlparse(settings.gitlab_url).hostname] + [str(h) for h in settings.gitlab_hosts or []]
+    return [h.lower() for h in hosts if h]
+
+
+def token_for(host: str) -> Optional[Dict[str, str]]:
+    """Basic-auth user and token for a GitHub or GitLab host, when one is configured"""
+    settings = get_settings()
+    github_host = urlparse(settings.github_api_url).hostname if settings.github_api_url else None
+    host = host.lower()
+    if settings.github_token and host in ("github.com", github_host):
+        return {"user": "x-access-token", "token": settings.github_token}
+    if settings.gitlab_token and host in gitlab_hosts():
+        return {"user": "oauth2", "token": settings.gitlab_token}
+    return None
+
+
+def git_env(url: str) -> Dict[str, str]:
+    """Environment for git commands against url: no interactive prompts, and the host's token (if any)
+    as an Authorization header via GIT_CONFIG_* rather than on the command line"""
+    env = {**os.environ, "GIT_TERMINAL_PROMPT": "0", "GIT_LFS_SKIP_SMUDGE": "1"}
+    if not url.startswith('https://') or re.match(r'^https://[^/@]+@', url):
+        return env
+    credentials = token_for(urlparse(url).hostname or '')
+    if credentials:
+        basic = base64.b64encode(f"{credentials['user']}:{credentials['token']}".encode()).decode()
+        index = int(env.get("GIT_CONFIG_COUNT", "0") or 0)
+        env.update({
+            "GIT_CONFIG_COUNT": str(index + 1),
+            f"GIT_CONFIG_KEY_{index}": "http.extraHeader",
+            f"GIT_CONFIG_VALUE_{index}": f"Authorization: Basic {basic}"
+        })
+    return env
+
+
+def run_git(args: List[str], env: Dict[str, str], cwd: Optional[str] = None, timeout: Optional[int] = None) -> str:
+    try:
+        result = subprocess.run(git_command(args), cwd=cwd, env=env, capture_output=True, text=True,
+                                timeout=timeout or get_settings().remote_clone_timeout)
+    except subprocess.TimeoutExpired:
+        raise RemoteError(f"git {args[0]} timed out (REMOTE_CLONE_TIMEOUT)")
+    if result.returncode != 0:
+        raise RemoteError(f"git {args[0]} failed: {result.stderr.strip()}")
+    return result.stdout.strip()
+
+
+def fetch_checkout(repo: RemoteRepo, directory: str) -> str:
+    """Check out repo.ref (or the default branch) at depth 1 into directory. Returns the commit."""
+    env = git_env(repo.url)
+    run_git(['init', '--quiet', directory], env)
+    run_git(['remote', 'add', 'origin', repo.url], env, directory)
+    # fetching the ref itself (rather than clone --branch) works for branches, tags, and commit ids alike
+    run_git(['fetch', '--quiet', '--depth', '1', '--no-tags', 'origin', repo.ref or 'HEAD'], env, directory)
+    run_git(['-c', 'advice.detachedHead=false', 'checkout', '--quiet', 'FETCH_HEAD'], env, directory)
+    return run_git(['rev-parse', 'HEAD'], env, directory)
+
+
+class RemoteCheckout:
+    """Context manager: a shallow checkout of a remote repository in a temp directory, removed on exit"""
+
+    def __init__(self, repo: RemoteRepo):
+        self.repo = repo
+        self.root = ""
+        self.commit = ""
+
+    def open(self) -> "RemoteCheckout":
+        self.root = tempfile.mkdtemp(prefix="sastscan-remote-")
+        mark_untrusted(self.root)
+        try:
+            self.commit = fetch_checkout(self.repo, self.root)
+        except BaseException:
+            self.close()
+            raise
+        logger.info(f"Checked out {self.repo} at {self.commit[:12]}")
+        return self
+
+    def close(self):
+        if self.root:
+            shutil.rmtree(self.root, ignore_errors=True)
+            release_untrusted(self.root)
+
+    def __enter__(self) -> "RemoteCheckout":
+        return self.open()
+
+    def __exit__(self, *exc):
+        self.close()
+        return False
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {"url": self.repo.display_url, "ref": self.repo.ref, "commit": self.commit}
+
+    def relocate(self, report: Dict[str, Any]):
+        """Point the report at the repository instead of the temp checkout, with repo-relative finding paths"""
+        relativize_paths(report, self.root)
+        report["target"] = str(self.repo)
+        report["remote"] = self.to_dict()
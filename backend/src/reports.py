@@ -0,0 +1,140 @@
+"""
+Report store - Read and write analysis reports saved under analysis-reports/
+"""
+
+import hashlib
+import json
+import os
+import re
+from typing import Any, Dict, List, Optional, Tuple
+
+from .config.policy import severity_rank
+from .schema import REPORT_SCHEMA_VERSION
+
+REPORTS_DIR = os.path.join(os.path.dirname(__file__), '..', 'analysis-reports')
+STATS_FILE = os.path.join(REPORTS_DIR, 'stats.json')
+
+
+def report_path(session_id: str) -> str:
+    return os.path.join(REPORTS_DIR, f"{session_id}.json")
+
+
+def list_report_ids() -> List[str]:
+    if not os.path.exists(REPORTS_DIR):
+        return []
+    ids = [f[:-len('.json')] for f in os.listdir(REPORTS_DIR) if f.endswith('.json') and f != 'stats.json']
+    ids.sort(key=lambda i: os.path.getmtime(report_path(i)), reverse=True)
+    return ids
+
+
+def load_report(session_id: str) -> Optional[Dict[str, Any]]:
+    path = report_path(session_id)
+    if not os.path.exists(path):
+        return None
+    with open(path, 'r') as f:
+        return json.load(f)
+
+
+def save_report(report: Dict[str, Any]) -> str:
+    """Write the report with its finding snippets redacted: LLM and diff findings quote code the way the
+    model returned it"""
+    from .redaction import redact_report_findings
+
+    redact_report_findings(report)
+    os.makedirs(REPORTS_DIR, exist_ok=True)
+    report.setdefault("schema_version", REPORT_SCHEMA_VERSION)
+    path = report_path(report["session_id"])
+    with open(path, 'w') as f:
+        json.dump(report, f, indent=2)
+    return path
+
+
+def find_finding(vuln_id: str, session_id: Optional[str] = None) -> Tuple[Optional[Dict[str, Any]], Optional[Dict[str, Any]]]:
+    """Find a finding by id in one report, or in the newest report that contains it"""
+    session_ids = [session_id] if session_id else list_report_ids()
+
+    for sid in session_ids:
+        report = load_report(sid)
+        if not report:
+            continue
+        for vuln in report.get("vulnerabilities", []):
+            if vuln.get("vuln_id") == vuln_id:
+                return report, vuln
+
+    return None, None
+
+
+def report_history(report: Dict[str, Any], limit: int = 10) -> List[Dict[str, Any]]:
+    """The last completed scans of the report's project (or, outside a project, its target) up to and
+    including the report itself, oldest first"""
+    def same_scope(candidate: Dict[str, Any]) -> bool:
+        if report.get("project_id"):
+            return candidate.get("project_id") == report["project_id"]
+        return candidate.get("target") == report.get("target")
+
+    history = [report]
+    for session_id in list_report_ids():
+        if session_id == report.get("session_id"):
+            continue
+        candidate = load_report(session_id)
+        if candidate and candidate.get("status") == "completed" and same_scope(candidate) \
+                and candidate.get("started_at", 0) < report.get("started_at", 0):
+            history.append(candidate)
+    history.sort(key=lambda r: r.get("started_at", 0))
+    return history[-limit:]
+
+
+def report_link(report: Dict[str, Any]) -> str:
+    from .config.settings import get_settings
+
+    settings = get_settings()
+    base = settings.report_base_url or f"http://{settings.host}:{settings.port}"
+    return f"{base.rstrip('/')}/api/v1/reports/{report.get('session_id', '')}"
+
+
+def evaluate_gate(report: Dict[str, Any], fail_on: Optional[str] = None) -> Optional[Dict[str, Any]]:
+    """Fail the report if it has findings at or above the threshold (the stricter of fail_on and the
+    project config's); findings accepted in the project baseline or triaged as false positives do not block"""
+    configured = (report.get("project_config") or {}).get("fail_on")
+    thresholds = [s for s in (fail_on, configured) if s]
+    if not thresholds:
+        return None
+
+    threshold = max(thresholds, key=severity_rank)
+    blocking = [
+        v for v in report.get("vulnerabilities", [])
+        if severity_rank(v.get("severity")) <= severity_rank(threshold) and not v.get("in_baseline")
+        and (v.get("triage") or {}).get("verdict") != "false_positive"
+    ]
+    report["gate"] = {
+        "fail_on": threshold,
+        "passed": not blocking,
+        "blocking": [v.get("vuln_id") for v in blocking]
+    }
+    return report["gate"]
+
+
+def relativize_paths(report: Dict[str, Any], root: str):
+    """Rewrite finding and trace paths under root (a temporary checkout or unpacked archive, gone after
+    the scan) relative to it, which also keeps their fingerprints stable from run to run"""
+    prefix = os.path.abspath(root) + os.sep
+
+    def relative(file_path: str) -> str:
+        if file_path and os.path.isabs(file_path) and file_path.startswith(prefix):
+            return os.path.relpath(file_path, root)
+        return file_path
+
+    for vuln in report.get("vulnerabilities", []):
+        vuln["file_path"] = relative(vuln.get("file_path", ""))
+        for step in vuln.get("trace") or []:
+            step["file_path"] = relative(step.get("file_path", ""))
+
+
+def finding_fingerprint(vuln: Dict[str, Any], root: Optional[str] = None) -> str:
+    """Stable id for a finding across scans: ignores line shifts and checkout location"""
+    file_path = vuln.get("file_path", "")
+    if root and os.path.isabs(file_path):
+        file_path = os.path.relpath(file_path, root)
+    snippet = re.sub(r'\s+', ' ', vuln.get("code_snippet") or "").strip()
+    key = '|'.join([vuln.get("rule_id") or vuln.get("vuln_type", ""), file_path, snippet or str(vuln.get("line_number", 0))])
+    return hashlib.sha1(key.encode()).hexdigest()[:16]
@@ -0,0 +1,324 @@
+"""
+Context retrieval - Embeddings index of a repo's functions, types, and config for the analyzer agent
+For each candidate finding the callers and callees of the enclosing function and the most similar code
+elsewhere in the repo (sanitizer helpers, config constants) are added to the agent prompt, so it can judge
+reachability and sanitization without being sent every file. Embeddings come from EMBEDDING_MODEL when set
+(any litellm embedding model, e.g. text-embedding-3-small or ollama/nomic-embed-text), or a built-in lexical one.
+"""
+
+import hashlib
+import json
+import logging
+import math
+import os
+import re
+import time
+from dataclasses import asdict, dataclass, field
+from typing import Any, Dict, List, Optional, Sequence, Union
+
+from .analysis import parse_code
+from .redaction import redact
+from .reports import REPORTS_DIR
+
+logger = logging.getLogger(__name__)
+
+INDEX_DIR = os.path.join(REPORTS_DIR, 'embeddings')
+CONFIG_EXTENSIONS = ('.yaml', '.yml', '.json', '.toml', '.ini', '.cfg', '.conf', '.properties', '.env')
+LOCAL_EMBEDDING = "lexical"
+LEXICAL_DIMENSIONS = 4096
+CHUNK_CHARS = 2000
+CONFIG_WINDOW = 40  # lines per config chunk
+EMBED_BATCH = 64
+
+IDENTIFIER = re.compile(r'[A-Za-z_][A-Za-z0-9_]*')
+SUBWORD = re.compile(r'[A-Z]+(?=[A-Z][a-z])|[A-Z]?[a-z]+|[A-Z]+|\d+')
+# language keywords carry no meaning for retrieval
+STOPWORDS = frozenset("""
+    if else elif for while return def func function class struct type var let const self this
+    import from package public private protected static void int string bool true false none null nil
+    new try catch except finally with as in is not and or async await err error
+""".split())
+
+Vector = Union[List[float], Dict[str, float]]  # dense from an embedding API, sparse from the lexical embedding
+
+
+@dataclass
+class CodeChunk:
+    chunk_id: str  # <file>:<start>-<end>
+    file_path: str  # relative to the repo root
+    kind: str  # function, class, struct, module, or config
+    name: str
+    start_line: int
+    end_line: int
+    text: str
+    digest: str = ""
+    vector: Vector = field(default_factory=list)
+
+    def embedding_text(self) -> str:
+        return f"{self.kind} {self.name}\n{self.text}"
+
+    def label(self) -> str:
+        return f"{self.file_path}:{self.start_line}-{self.end_line} ({self.kind} {self.name})"
+
+
+def chunk_digest(text: str) -> str:
+    return hashlib.sha256(text.encode('utf-8', errors='ignore')).hexdigest()[:16]
+
+
+def make_chunk(file_path: str, kind: str, name: str, start: int, end: int, text: str) -> CodeChunk:
+    # chunks are sent to the embedding model, saved in the index, and quoted in prompts: mask secrets once here
+    text = redact(text[:CHUNK_CHARS])
+    return CodeChunk(f"{file_path}:{start}-{end}", file_path, kind, name, start, end, text, chunk_digest(f"{kind}:{name}:{text}"))
+
+
+def chunk_code(code: str, file_path: str, rel_path: str) -> List[CodeChunk]:
+    """Functions and types, plus the file head (imports and constants)"""
+    lines = code.split('\n')
+    chunks = []
+    members = [m for m in parse_code(code, file_path) if m.member_type != 'module']
+    for member in members:
+        body = member.body
+        if member.member_type != 'function':
+            # methods are chunked on their own; a type's declaration and fields are what matter here
+            body = '\n'.join(body.split('\n')[:CONFIG_WINDOW])
+        chunks.append(make_chunk(rel_path, member.member_type, member.name, member.start_line, member.end_line, body))
+
+    first_member = min((m.start_line for m in members), default=len(lines) + 1)
+    head_end = min(first_member - 1, CONFIG_WINDOW) if members else min(len(lines), CONFIG_WINDOW)
+    head = '\n'.join(lines[:head_end])
+    if head.strip():
+        chunks.append(make_chunk(rel_path, 'module', os.path.basename(rel_path), 1, head_end, head))
+    return chunks
+
+
+def chunk_config(code: str, rel_path: str) -> List[CodeChunk]:
+    lines = code.split('\n')
+    return [
+        make_chunk(rel_path, 'config', os.path.basename(rel_path), start + 1, min(len(lines), start + CONFIG_WINDOW),
+                   '\n'.join(lines[start:start + CONFIG_WINDOW]))
+        for start in range(0, len(lines), CONFIG_WINDOW)
+        if '\n'.join(lines[start:start + CONFIG_WINDOW]).strip()
+    ]
+
+
+def terms(text: str) -> List[str]:
+    """Identifiers and their camelCase / snake_case parts, lowercased"""
+    found = []
+    for identifier in IDENTIFIER.findall(text):
+        lowered = identifier.lower()
+        if lowered not in STOPWORDS and len(lowered) > 1:
+            found.append(lowered)
+        parts = [p.lower() for part in identifier.split('_') for p in SUBWORD.findall(part)]
+        if len(parts) > 1:
+            found.extend(p for p in parts if p not in STOPWORDS and len(p) > 2)
+    return found
+
+
+def lexical_embedding(text: str) -> Dict[str, float]:
+    """Hashed bag of identifier terms with log term frequency, L2-normalized"""
+    counts: Dict[str, int] = {}
+    for term in terms(text):
+        bucket = str(int(hashlib.md5(term.encode()).hexdigest()[:8], 16) % LEXICAL_DIMENSIONS)
+        counts[bucket] = counts.get(bucket, 0) + 1
+    weights = {k: 1 + math.log(v) for k, v in counts.items()}
+    norm = math.sqrt(sum(w * w for w in weights.values())) or 1.0
+    return {k: w / norm for k, w in weights.items()}
+
+
+def similarity(a: Vector, b: Vector) -> float:
+    if isinstance(a, dict):
+        if len(a) > len(b):
+            a, b = b, a
+        return sum(w * b.get(k, 0.0) for k, w in a.items())
+    dot = sum(x * y for x, y in zip(a, b))
+    norm = math.sqrt(sum(x * x for x in a)) * math.sqrt(sum(y * y for y in b))
+    return dot / norm if norm else 0.0
+
+
+async def embed_texts(texts: Sequence[str], model: str) -> List[Vector]:
+    if model == LOCAL_EMBEDDING:
+        return [lexical_embedding(t) for t in texts]
+
+    from litellm import aembedding
+
+    from .llm import get_llm_config
+
+    kwargs = {"api_base": get_llm_config().ollama_base_url} if model.startswith("ollama/") else {}
+    texts = [redact(t) for t in texts]  # search queries are raw code too
+    vectors: List[Vector] = []
+    for start in range(0, len(texts), EMBED_BATCH):
+        response = await aembedding(model=model, input=list(texts[start:start + EMBED_BATCH]), **kwargs)
+        for item in response.data:
+            vectors.append(list(item["embedding"] if isinstance(item, dict) else item.embedding))
+    return vectors
+
+
+def embedding_model() -> str:
+    from .config.offline import is_offline
+    from .config.settings import get_settings
+
+    model = get_settings().embedding_model
+    if model and is_offline():
+        logger.info(f"Offline mode: using the built-in lexical embedding instead of {model}")
+        return LOCAL_EMBEDDING
+    return model or LOCAL_EMBEDDING
+
+
+@dataclass
+class RepoIndex:
+    root: str
+    model: str
+    chunks: List[CodeChunk] = field(default_factory=list)
+    built_at: float = field(default_factory=time.time)
+
+    @staticmethod
+    def path_for(root: str) -> str:
+        return os.path.join(INDEX_DIR, f"{chunk_digest(os.path.abspath(root))}.json")
+
+    @classmethod
+    def load(cls, root: str) -> Optional['RepoIndex']:
+        path = cls.path_for(root)
+        if not os.path.exists(path):
+            return None
+        try:
+            with open(path, 'r') as f:
+                data = json.load(f)
+            chunks = [CodeChunk(**c) for c in data.pop("chunks", [])]
+            return cls(**data, chunks=chunks)
+        except (OSError, ValueError, TypeError) as e:
+            logger.warning(f"Ignoring unreadable embeddings index {path}: {e}")
+            return None
+
+    def save(self):
+        os.makedirs(INDEX_DIR, exist_ok=True)
+        path = self.path_for(self.root)
+        tmp = f"{path}.tmp"
+        with open(tmp, 'w') as f:
+            json.dump(asdict(self), f)
+        os.replace(tmp, path)
+
+    async def search(self, query: str, k: int = 5, exclude: Optional[List[CodeChunk]] = None) -> List[CodeChunk]:
+        if not self.chunks:
+            return []
+        vector = (await embed_texts([query], self.model))[0]
+        skipped = {c.chunk_id for c in exclude or []}
+        ranked = sorted(
+            ((similarity(vector, c.vector), c) for c in self.chunks if c.chunk_id not in skipped),
+            key=lambda pair: pair[0], reverse=True
+        )
+        return [c for score, c in ranked[:k] if score > 0]
+
+    def callers(self, name: str, exclude_file: str, k: int = 3) -> List[CodeChunk]:
+        """Functions in other files that call `name`"""
+        call = re.compile(rf'(?<![\w.])(?:\w+\.)*{re.escape(name)}\s*\(')
+        return [
+            c for c in self.chunks
+            if c.kind == 'function' and c.file_path != exclude_file and c.name != name and call.search(c.text)
+        ][:k]
+
+    async def context_for(self, code: str, file_path: str, candidates: Sequence[Any], max_chars: int, graph: Optional[Any] = None) -> str:
+        """Prompt text with the repo code most relevant to one file's candidate findings
+        (objects with line_number, vuln_type, and code_snippet, e.g. static rule hits); with a call
+        graph, the direct callers and callees of each finding's function come first"""
+        rel_path = os.path.relpath(file_path, self.root) if os.path.isabs(file_path) else file_path
+        functions = [m for m in parse_code(code, file_path) if m.member_type == 'function']
+        function_chunks = {(c.file_path, c.start_line): c for c in self.chunks if c.kind == 'function'}
+
+        picked: Dict[str, str] = {}  # chunk id -> why it was picked
+
+        def pick(chunks: List[CodeChunk], why: str):
+            for chunk in chunks:
+                picked.setdefault(chunk.chunk_id, why)
+
+        def neighbours(name: str, line: int):
+            node = graph.function_at(file_path, line) if graph else None
+            if not node:
+                pick(self.callers(name, rel_path), f"calls {name}()")
+                return
+            for relation, others in (("calls", graph.callers(node.key)), ("called by", graph.callees(node.key))):
+                chunks = [function_chunks.get((os.path.relpath(n.file_path, self.root), n.start_line)) for n in others]
+                pick([c for c in chunks if c and c.file_path != rel_path][:3], f"{relation} {node.label()}()")
+
+        # the file itself is already in the prompt
+        own = [c for c in self.chunks if c.file_path == rel_path]
+        for candidate in list(candidates)[:8]:
+            line = getattr(candidate, "line_number", 0)
+            enclosing = next((m for m in functions if m.start_line <= line <= m.end_line), None)
+            if enclosing:
+                neighbours(enclosing.name, line)
+            query = f"{getattr(candidate, 'vuln_type', '')} sanitize validate escape\n{getattr(candidate, 'code_snippet', '')}\n"
+            query += enclosing.body[:CHUNK_CHARS] if enclosing else ""
+            pick(await self.search(query, k=3, exclude=own), f"related to the {getattr(candidate, 'vuln_type', 'finding')} at line {line}")
+
+        if not candidates:
+            # nothing flagged yet: orient the agent with the file's callers and what its code most depends on
+            for function in functions[:5]:
+                neighbours(function.name, function.start_line)
+            pick(await self.search(code[:4 * CHUNK_CHARS], k=4, exclude=own), "related to this file")
+
+        by_id = {c.chunk_id: c for c in self.chunks}
+        sections, used = [], 0
+        for chunk_id, why in picked.items():
+            chunk = by_id[chunk_id]
+            section = f"--- {chunk.label()}, {why}\n{chunk.text}"
+            if used + len(section) > max_chars:
+                continue
+            sections.append(section)
+            used += len(section)
+
+        if not sections:
+            return ""
+        return ("Related code from elsewhere in the repository (callers, helpers, and config retrieved for this file; "
+                "use it to judge whether input is reachable or already sanitized, but only report findings in the file under analysis):\n"
+                + '\n'.join(sections))
+
+
+async def build_index(root: str, code_files: Sequence[str], config_files: Sequence[str] = ()) -> RepoIndex:
+    """Index a repo, re-embedding only chunks that changed since the last build"""
+    model = embedding_model()
+    previous = RepoIndex.load(root)
+    reusable = {(c.chunk_id, c.digest): c.vector for c in previous.chunks} if previous and previous.model == model else {}
+
+    chunks: List[CodeChunk] = []
+    for path in list(code_files) + list(config_files):
+        try:
+            with open(path, 'r', encoding='utf-8', errors='ignore') as f:
+                code = f.read()
+        except OSError:
+            continue
+        rel_path = os.path.relpath(path, root)
+        chunks.extend(chunk_config(code, rel_path) if path.endswith(CONFIG_EXTENSIONS) else chunk_code(code, path, rel_path))
+
+    stale = [c for c in chunks if (c.chunk_id, c.digest) not in reusable]
+    try:
+        vectors = await embed_texts([c.embedding_text() for c in stale], model)
+    except Exception as e:
+        if model == LOCAL_EMBEDDING:
+            raise
+        # vectors from different models cannot be compared, so the whole index falls back
+        logger.warning(f"Embedding with {model} failed ({e}); using the built-in lexical embedding")
+        model, stale = LOCAL_EMBEDDING, chunks
+        vectors = await embed_texts([c.embedding_text() for c in stale], model)
+    for chunk, vector in zip(stale, vectors):
+        chunk.vector = vector
+    for chunk in chunks:
+        if not chunk.vector:
+            chunk.vector = reusable[(chunk.chunk_id, chunk.digest)]
+
+    index = RepoIndex(root=os.path.abspath(root), model=model, chunks=chunks)
+    index.save()
+    logger.info(f"Embeddings index of {root}: {len(chunks)} chunks, {len(stale)} embedded with {model}")
+    return index
+
+
+def context_budget() -> int:
+    """Characters of retrieved code per file; smaller for local models so the file itself still fits"""
+    from .config.settings import get_settings
+    from .llm import get_llm_config
+    from .llm.local import CHARS_PER_TOKEN, get_capabilities, is_local_model
+
+    budget = get_settings().retrieval_context_chars
+    model = get_llm_config().default_model
+    if is_local_model(model):
+        budget = min(budget, int(get_capabilities(model).context_window * CHARS_PER_TOKEN / 8))
+    return budget
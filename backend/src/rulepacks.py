@@ -0,0 +1,405 @@
+"""
+Rule packs - Install signed packs of YAML rules (see analysis/rules/dsl.py) and pin them in the project config
+Pack specs:
+    <name>[@<version>]                   from the registry at RULE_REGISTRY_URL: <registry>/<name>/<version>/pack.yaml
+                                         (+ pack.yaml.sig); without a version, the newest in <registry>/<name>/index.json
+    git+<repo-url>#<path>[@<ref>]        pack.yaml (+ pack.yaml.sig) in <path> of the repo at ref
+    ./path/to/pack                       a pack kept in the project itself; loaded in place, no signature needed
+
+Remote packs must carry a detached Ed25519 signature of pack.yaml (raw or base64) by a key listed in
+RULE_PACK_KEYS_FILE ("<key-name> <base64 public key>" per line). Installed packs live under
+RULE_PACK_DIR/<name>/<version>/, and scans only ever read that cache: a pinned pack that is not
+installed is reported, never fetched mid-scan.
+"""
+
+import base64
+import hashlib
+import json
+import logging
+import os
+import re
+import time
+from dataclasses import dataclass, field
+from typing import Any, Dict, List, Optional, Tuple
+
+import yaml
+
+from .analysis.rules.dsl import PACK_VERSION, PatternRule, RuleDSLError, parse_pack
+from .config.offline import require_network
+from .config.settings import get_settings
+
+logger = logging.getLogger(__name__)
+
+PACK_FILE = 'pack.yaml'
+SIGNATURE_SUFFIX = '.sig'
+INSTALL_FILE = 'install.json'
+REGISTRY_SPEC = re.compile(r'^([a-z0-9][a-z0-9._-]*)(?:@([\w.+-]+))?$')
+PIN_KEY = re.compile(r'^rule_packs\s*:\s*(?:#.*)?$')
+PIN_ITEM = re.compile(r'^(\s+)-\s*(.+?)\s*$')
+
+
+class RulePackError(Exception):
+    pass
+
+
+@dataclass
+class PackSpec:
+    spec: str
+    kind: str  # registry, git, or local
+    name: Optional[str] = None  # known up front for registry specs only
+    version: Optional[str] = None
+    repo: Optional[str] = None
+    path: Optional[str] = None
+    ref: Optional[str] = None
+
+
+def parse_spec(spec: str, base_dir: str = ".") -> PackSpec:
+    spec = spec.strip()
+    if spec.startswith('git+'):
+        repo, _, rest = spec[len('git+'):].partition('#')
+        path, _, ref = rest.partition('@')
+        if not repo:
+            raise RulePackError(f"Git rule pack needs a repository: git+<repo-url>#<path>[@<ref>], got {spec}")
+        return PackSpec(spec, "git", repo=repo, path=path.strip('/') or '.', ref=ref or None)
+    if spec.startswith(('.', '/', '~')) or os.path.exists(os.path.join(base_dir, spec)):
+        return PackSpec(spec, "local", path=os.path.join(base_dir, os.path.expanduser(spec)))
+    match = REGISTRY_SPEC.match(spec)
+    if not match:
+        raise RulePackError(f"Not a rule pack spec: {spec} (expected <name>[@<version>], git+<repo-url>#<path>[@<ref>], or a path)")
+    if match.group(2) and not PACK_VERSION.match(match.group(2)):
+        raise RulePackError(f"Invalid rule pack version in {spec}")
+    return PackSpec(spec, "registry", name=match.group(1), version=match.group(2))
+
+
+def version_key(version: str) -> Tuple:
+    """1.10.0 sorts after 1.9.0; pre-release suffixes sort before the release"""
+    parts = re.split(r'[.+-]', version)
+    return tuple((0, int(p), '') if p.isdigit() else (-1, 0, p) for p in parts)
+
+
+def trusted_keys() -> Dict[str, bytes]:
+    path = os.path.expanduser(get_settings().rule_pack_keys_file)
+    keys = {}
+    if not os.path.isfile(path):
+        return keys
+    with open(path, 'r') as f:
+        for number, line in enumerate(f, 1):
+            line = line.split('#', 1)[0].strip()
+            if not line:
+                continue
+            name, _, encoded = line.rpartition(' ')
+            try:
+                key = base64.b64decode(encoded, validate=True)
+            except ValueError:
+                key = b''
+            if len(key) != 32:
+                logger.warning(f"{path}:{number}: not a base64 Ed25519 public key")
+                continue
+            keys[name.strip() or encoded[:12]] = key
+    return keys
+
+
+def verify_signature(content: bytes, signature: Optional[bytes]) -> str:
+    """Name of the trusted key that signed content"""
+    from cryptography.exceptions import InvalidSignature
+    from cryptography.hazmat.primitives.asymmetric.ed25519 import Ed25519PublicKey
+
+    if not signature:
+        raise RulePackError(f"The pack has no {PACK_FILE}{SIGNATURE_SUFFIX} signature (pass --allow-unsigned to install it anyway)")
+    if len(signature) != 64:
+        try:
+            signature = base64.b64decode(signature.strip(), validate=True)
+        except ValueError:
+            raise RulePackError("The signature is neither a raw nor a base64 Ed25519 signature")
+    keys = trusted_keys()
+    if not keys:
+        raise RulePackError(f"No trusted rule pack keys in {get_settings().rule_pack_keys_file}")
+    for name, key in keys.items():
+        try:
+            Ed25519PublicKey.from_public_bytes(key).verify(signature, content)
+            return name
+        except InvalidSignature:
+            continue
+    raise RulePackError("The signature does not match any trusted key")
+
+
+def fetch_registry(spec: PackSpec) -> Tuple[bytes, Optional[bytes], Dict[str, Any]]:
+    import httpx
+
+    settings = get_settings()
+    if not settings.rule_registry_url:
+        raise RulePackError("RULE_REGISTRY_URL is not configured")
+    base = f"{settings.rule_registry_url.rstrip('/')}/{spec.name}"
+    headers = {"Authorization": f"Bearer {settings.rule_registry_token}"} if settings.rule_registry_token else {}
+
+    try:
+        with httpx.Client(headers=headers, timeout=30, follow_redirects=True) as client:
+            version = spec.version
+            if not version:
+                response = client.get(f"{base}/index.json")
+                if response.status_code >= 400:
+                    raise RulePackError(f"Pack {spec.name} not found in the registry: HTTP {response.status_code}")
+                versions = [str(v) for v in response.json().get("versions") or [] if PACK_VERSION.match(str(v))]
+                if not versions:
+                    raise RulePackError(f"Pack {spec.name} has no published versions")
+                version = max(versions, key=version_key)
+            response = client.get(f"{base}/{version}/{PACK_FILE}")
+            if response.status_code >= 400:
+                raise RulePackError(f"Pack {spec.name}@{version} not found in the registry: HTTP {response.status_code}")
+            content = response.content
+            response = client.get(f"{base}/{version}/{PACK_FILE}{SIGNATURE_SUFFIX}")
+            signature = response.content if response.status_code < 400 else None
+    except httpx.HTTPError as e:
+        raise RulePackError(f"Registry {settings.rule_registry_url} is unreachable: {e}")
+    return content, signature, {"registry": settings.rule_registry_url}
+
+
+def read_pack_dir(directory: str) -> Tuple[bytes, Optional[bytes]]:
+    path = directory if os.path.isfile(directory) else os.path.join(directory, PACK_FILE)
+    if not os.path.isfile(path):
+        raise RulePackError(f"No {PACK_FILE} in {directory}")
+    with open(path, 'rb') as f:
+        content = f.read()
+    signature = None
+    if os.path.isfile(path + SIGNATURE_SUFFIX):
+        with open(path + SIGNATURE_SUFFIX, 'rb') as f:
+            signature = f.read()
+    return content, signature
+
+
+def fetch_git(spec: PackSpec) -> Tuple[bytes, Optional[bytes], Dict[str, Any]]:
+    from .remote import RemoteCheckout, RemoteError, RemoteRepo
+
+    try:
+        with RemoteCheckout(RemoteRepo(spec.repo, spec.ref)) as checkout:
+            content, signature = read_pack_dir(os.path.join(checkout.root, spec.path))
+            return content, signature, {"repo": checkout.repo.display_url, "ref": spec.ref, "commit": checkout.commit}
+    except RemoteError as e:
+        raise RulePackError(str(e))
+
+
+def load_content(content: bytes, source: str) -> Tuple[Dict[str, Any], List[PatternRule]]:
+    try:
+        return parse_pack(yaml.safe_load(content))
+    except yaml.YAMLError as e:
+        raise RulePackError(f"{source}: invalid YAML: {e}")
+    except RuleDSLError as e:
+        raise RulePackError(f"{source}: {e}")
+
+
+def pack_dir(name: str, version: str, spec: PackSpec) -> str:
+    """<rule_pack_dir>/<name>/<version>; git installs get a suffix so they never replace a registry copy"""
+    if not PACK_VERSION.match(version):
+        raise RulePackError(f"Invalid rule pack version {version!r}")
+    if spec.kind != "registry":
+        version = f"{version}+{hashlib.sha256(spec.spec.encode()).hexdigest()[:8]}"
+    return os.path.join(os.path.expanduser(get_settings().rule_pack_dir), name, version)
+
+
+@dataclass
+class InstalledPack:
+    metadata: Dict[str, Any]
+    spec: str
+    directory: str
+    signed_by: Optional[str] = None
+    source: Dict[str, Any] = field(default_factory=dict)
+    rules: List[PatternRule] = field(default_factory=list)
+    sha256: Optional[str] = None  # of pack.yaml
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "spec": self.spec,
+            "name": self.metadata["name"],
+            "version": self.metadata["version"],
+            "rules": [rule.rule_id for rule in self.rules],
+            "signed_by": self.signed_by,
+            "source": self.source,
+            "sha256": self.sha256
+        }
+
+
+def install_pack(spec_text: str, allow_unsigned: bool = False, base_dir: str = ".") -> InstalledPack:
+    """Fetch, verify, and cache a pack. Local packs are only validated; they are read in place."""
+    spec = parse_spec(spec_text, base_dir)
+    if spec.kind == "local":
+        content, _ = read_pack_dir(spec.path)
+        metadata, rules = load_content(content, spec.path)
+        return InstalledPack(metadata, spec.spec, spec.path, source={"path": spec.path}, rules=rules,
+                             sha256=hashlib.sha256(content).hexdigest())
+
+    require_network("Installing a rule pack")
+    content, signature, source = fetch_registry(spec) if spec.kind == "registry" else fetch_git(spec)
+    metadata, rules = load_content(content, spec.spec)
+    if spec.kind == "registry" and metadata["name"] != spec.name:
+        raise RulePackError(f"The registry served pack {metadata['name']} for {spec.name}")
+    if spec.version and metadata["version"] != spec.version:
+        raise RulePackError(f"The registry served version {metadata['version']} for {spec.name}@{spec.version}")
+
+    try:
+        signed_by = verify_signature(content, signature)
+    except RulePackError:
+        if not allow_unsigned:
+            raise
+        signed_by = None
+        logger.warning(f"Installing {metadata['name']}@{metadata['version']} without a verified signature")
+
+    # registry pins name the exact version, so "acme-go" installs and pins as acme-go@<newest>
+    pinned = f"{metadata['name']}@{metadata['version']}" if spec.kind == "registry" else spec.spec
+    directory = pack_dir(metadata["name"], metadata["version"], spec)
+    record_digest = hashlib.sha256(content).hexdigest()
+    os.makedirs(directory, exist_ok=True)
+    with open(os.path.join(directory, PACK_FILE), 'wb') as f:
+        f.write(content)
+    if signature:
+        with open(os.path.join(directory, PACK_FILE + SIGNATURE_SUFFIX), 'wb') as f:
+            f.write(signature)
+    with open(os.path.join(directory, INSTALL_FILE), 'w') as f:
+        json.dump({
+            "spec": pinned,
+            "sha256": record_digest,
+            "signed_by": signed_by,
+            "source": source,
+            "installed_at": time.time()
+        }, f, indent=2)
+    return InstalledPack(metadata, pinned, directory, signed_by, source, rules, record_digest)
+
+
+def installed_packs() -> List[Tuple[str, Dict[str, Any]]]:
+    """(directory, install record) of every installed pack"""
+    root = os.path.expanduser(get_settings().rule_pack_dir)
+    found = []
+    if not os.path.isdir(root):
+        return found
+    for name in sorted(os.listdir(root)):
+        for version in sorted(os.listdir(os.path.join(root, name)) if os.path.isdir(os.path.join(root, name)) else [], key=version_key):
+            record = os.path.join(root, name, version, INSTALL_FILE)
+            if os.path.isfile(record):
+                with open(record, 'r') as f:
+                    found.append((os.path.dirname(record), json.load(f)))
+    return found
+
+
+def load_installed(spec_text: str, base_dir: str = ".") -> InstalledPack:
+    """A pinned pack from the local cache (or, for a local pin, the project), checked against the digest
+    recorded at install time"""
+    spec = parse_spec(spec_text, base_dir)
+    if spec.kind == "local":
+        content, _ = read_pack_dir(spec.path)
+        metadata, rules = load_content(content, spec.path)
+        return InstalledPack(metadata, spec.spec, spec.path, source={"path": spec.path}, rules=rules,
+                             sha256=hashlib.sha256(content).hexdigest())
+
+    for directory, record in installed_packs():
+        if record.get("spec") != spec.spec:
+            continue
+        with open(os.path.join(directory, PACK_FILE), 'rb') as f:
+            content = f.read()
+        if hashlib.sha256(content).hexdigest() != record.get("sha256"):
+            raise RulePackError(f"{spec.spec} was modified after it was installed ({directory}); reinstall it")
+        metadata, rules = load_content(content, spec.spec)
+        return InstalledPack(metadata, spec.spec, directory, record.get("signed_by"), record.get("source") or {}, rules, record["sha256"])
+    raise RulePackError(f"{spec.spec} is pinned but not installed; run: scanner rules install")
+
+
+def load_pinned_packs(pins: List[str], base_dir: str) -> Tuple[List[InstalledPack], List[str]]:
+    """The installed packs for a project's pins, and an error per pin that could not be loaded"""
+    packs, errors = [], []
+    seen: Dict[str, str] = {}
+    for pin in pins:
+        try:
+            pack = load_installed(pin, base_dir)
+        except (RulePackError, OSError, ValueError) as e:
+            errors.append(str(e))
+            continue
+        name = pack.metadata["name"]
+        if name in seen:
+            errors.append(f"{pin}: pack {name} is already pinned as {seen[name]}")
+            continue
+        seen[name] = pin
+        packs.append(pack)
+    for error in errors:
+        logger.warning(f"Rule pack: {error}")
+    return packs, errors
+
+
+def pin_name(pin: str, base_dir: str) -> Optional[str]:
+    """The pack name a pin refers to, as far as it can be told without fetching"""
+    try:
+        spec = parse_spec(pin, base_dir)
+    except RulePackError:
+        return None
+    if spec.name:
+        return spec.name
+    if spec.kind == "local":
+        try:
+            return load_content(read_pack_dir(spec.path)[0], spec.path)[0]["name"]
+        except (RulePackError, OSError):
+            return None
+    return next((record_dir.split(os.sep)[-2] for record_dir, record in installed_packs() if record.get("spec") == pin), None)
+
+
+def write_pin(config_path: str, pack: InstalledPack) -> str:
+    """Pin the pack in the project config's rule_packs list, replacing any other pin of the same pack.
+    The file is edited line by line so comments and formatting survive."""
+    base_dir = os.path.dirname(config_path)
+    lines = []
+    if os.path.isfile(config_path):
+        with open(config_path, 'r') as f:
+            lines = f.read().split('\n')
+        if lines and lines[-1] == '':
+            lines.pop()
+
+    name = pack.metadata["name"]
+    entry = json.dumps(pack.spec)
+    start = next((i for i, line in enumerate(lines) if PIN_KEY.match(line)), None)
+    if start is None and any(line.startswith('rule_packs') for line in lines):
+        # flow style (rule_packs: [a, b]) cannot be edited in place: rewrite the document
+        with open(config_path, 'r') as f:
+            data = yaml.safe_load(f) or {}
+        pins = [p for p in data.get('rule_packs') or [] if pin_name(str(p), base_dir) != name]
+        data['rule_packs'] = pins + [pack.spec]
+        logger.warning(f"Rewriting {config_path} to pin {pack.spec}; comments in it are not preserved")
+        text = yaml.safe_dump(data, sort_keys=False)
+    elif start is None:
+        lines += ([''] if lines else []) + ['rule_packs:', f'  - {entry}']
+        text = '\n'.join(lines) + '\n'
+    else:
+        end, indent, kept = start + 1, '  ', []
+        while end < len(lines) and (not lines[end].strip() or lines[end].startswith((' ', '\t'))):
+            end += 1
+        for line in lines[start + 1:end]:
+            item = PIN_ITEM.match(line.split(' #', 1)[0])
+            if item:
+                indent = item.group(1)
+                value = yaml.safe_load(item.group(2))
+                if pin_name(str(value), base_dir) == name or value == pack.spec:
+                    continue
+            kept.append(line)
+        while kept and not kept[-1].strip():
+            kept.pop()
+        lines[start + 1:end] = kept + [f'{indent}- {entry}']
+        text = '\n'.join(lines) + '\n'
+
+    with open(config_path, 'w') as f:
+        f.write(text)
+    return config_path
+
+
+def project_pins(project_dir: str) -> Tuple[Optional[str], List[str]]:
+    """The project config file for project_dir and the packs it pins (without applying any org policy)"""
+    from .config.project import find_project_config
+
+    path = find_project_config(project_dir)
+    if not path:
+        return None, []
+    with open(path, 'r') as f:
+        data = yaml.safe_load(f) or {}
+    if not isinstance(data, dict):
+        raise RulePackError(f"Invalid project config {path}: expected a mapping at the top level")
+    return path, [str(p) for p in data.get('rule_packs') or []]
+
+
+def project_pack_rules(project_dir: str) -> List[PatternRule]:
+    path, pins = project_pins(project_dir)
+    packs, _ = load_pinned_packs(pins, os.path.dirname(path)) if pins else ([], [])
+    return [rule for pack in packs for rule in pack.rules]
@@ -0,0 +1,28 @@
+"""
+Report schema - Versioned JSON Schema for the native report format and a results loader
+"""
+
+import json
+import os
+from typing import Any, Dict
+
+from .loader import REPORT_SCHEMA_VERSION, Finding, ScanResults, SchemaVersionError, load_results, upgrade_report
+
+SCHEMA_FILE = os.path.join(os.path.dirname(__file__), 'report.schema.json')
+
+
+def load_schema() -> Dict[str, Any]:
+    with open(SCHEMA_FILE, 'r') as f:
+        return json.load(f)
+
+
+__all__ = [
+    'REPORT_SCHEMA_VERSION',
+    'SCHEMA_FILE',
+    'load_schema',
+    'Finding',
+    'ScanResults',
+    'SchemaVersionError',
+    'load_results',
+    'upgrade_report',
+]
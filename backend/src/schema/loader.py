@@ -0,0 +1,132 @@
+"""
+Results Loader - Backward-compatible parsing of saved analysis reports
+Older reports are upgraded step by step to the current schema; unknown fields are kept, not rejected
+"""
+
+import copy
+import json
+from dataclasses import dataclass, field, fields
+from typing import Any, Callable, Dict, List, Optional, Tuple, Union
+
+REPORT_SCHEMA_VERSION = "1.0"
+LEGACY_VERSION = "0.0"  # reports written before schema_version existed
+
+
+class SchemaVersionError(ValueError):
+    pass
+
+
+@dataclass
+class Finding:
+    vuln_id: str
+    vuln_type: str
+    severity: str
+    description: str
+    file_path: str
+    line_number: int
+    code_snippet: str = ""
+    column: Optional[int] = None
+    end_column: Optional[int] = None
+    cwe_id: Optional[str] = None
+    confidence: float = 0.0
+    remediation: Optional[str] = None
+    rule_id: Optional[str] = None
+    original_severity: Optional[str] = None
+    trace: List[Dict[str, Any]] = field(default_factory=list)
+    extra: Dict[str, Any] = field(default_factory=dict)
+
+    @classmethod
+    def from_dict(cls, data: Dict[str, Any]) -> "Finding":
+        known = {f.name for f in fields(cls)} - {"extra"}
+        values = {k: v for k, v in data.items() if k in known and v is not None}
+        extra = {k: v for k, v in data.items() if k not in known}
+        return cls(**values, extra=extra)
+
+
+@dataclass
+class ScanResults:
+    schema_version: str
+    session_id: str
+    status: str
+    analysis_type: str = ""
+    target: Optional[str] = None
+    started_at: Optional[float] = None
+    completed_at: Optional[float] = None
+    findings: List[Finding] = field(default_factory=list)
+    summary: Dict[str, Any] = field(default_factory=dict)
+    errors: List[str] = field(default_factory=list)
+    raw: Dict[str, Any] = field(default_factory=dict)
+
+    def by_severity(self, severity: str) -> List[Finding]:
+        return [f for f in self.findings if f.severity == severity]
+
+
+def _upgrade_legacy(report: Dict[str, Any]) -> Dict[str, Any]:
+    report.setdefault("status", "completed")
+    report.setdefault("vulnerabilities", [])
+    if "target" not in report and "project_path" in report:
+        report["target"] = report["project_path"]
+
+    for vuln in report["vulnerabilities"]:
+        # diff reports used new_code/recommendation before findings were unified
+        if "code_snippet" not in vuln and vuln.get("new_code"):
+            vuln["code_snippet"] = vuln["new_code"]
+        if "remediation" not in vuln and vuln.get("recommendation"):
+            vuln["remediation"] = vuln["recommendation"]
+        vuln.setdefault("trace", [])
+    return report
+
+
+# from-version -> (to-version, upgrade step)
+MIGRATIONS: Dict[str, Tuple[str, Callable[[Dict[str, Any]], Dict[str, Any]]]] = {
+    LEGACY_VERSION: ("1.0", _upgrade_legacy),
+}
+
+
+def _major(version: str) -> int:
+    try:
+        return int(version.split('.')[0])
+    except ValueError:
+        raise SchemaVersionError(f"Malformed schema_version: {version!r}")
+
+
+def upgrade_report(report: Dict[str, Any]) -> Dict[str, Any]:
+    """Return a copy of report upgraded to the current schema version"""
+    report = copy.deepcopy(report)
+    version = str(report.get("schema_version", LEGACY_VERSION))
+
+    while version in MIGRATIONS:
+        version, step = MIGRATIONS[version]
+        report = step(report)
+
+    # a newer minor version only adds optional fields, which are carried along untouched
+    if _major(version) != _major(REPORT_SCHEMA_VERSION):
+        raise SchemaVersionError(
+            f"Report schema {version} is not compatible with {REPORT_SCHEMA_VERSION}"
+            + ("; upgrade the scanner" if _major(version) > _major(REPORT_SCHEMA_VERSION) else "")
+        )
+
+    report["schema_version"] = version
+    return report
+
+
+def load_results(source: Union[str, Dict[str, Any]]) -> ScanResults:
+    """Load a report from a JSON file path or an already parsed dict"""
+    if isinstance(source, str):
+        with open(source, 'r') as f:
+            source = json.load(f)
+
+    report = upgrade_report(source)
+    return ScanResults(
+        schema_version=report["schema_version"],
+        session_id=report.get("session_id", ""),
+        status=report.get("status", ""),
+        analysis_type=report.get("analysis_type", ""),
+        target=report.get("target"),
+        started_at=report.get("started_at"),
+        completed_at=report.get("completed_at"),
+        findings=[Finding.from_dict(v) for v in report.get("vulnerabilities", [])],
+        summary=report.get("summary", {}),
+        errors=report.get("errors", []),
+        raw=report
+    )
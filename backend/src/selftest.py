@@ -0,0 +1,156 @@
+"""
+Rule selftest - Run the static rules over annotated fixture files and check every expectation
+Annotations are comments; on their own line they apply to the next code line, trailing ones to their line:
+    // sast:expect websocket-any-origin          the rule must flag this line (true positive)
+    // sast:expect-not handler-panic, log-injection   these rules must not flag it (known negative)
+Any finding in a fixture file without a matching sast:expect annotation is a failure too.
+"""
+
+import os
+import re
+from dataclasses import dataclass, field
+from typing import Any, Dict, List, Optional, Set, Tuple
+
+from .analysis.rules import get_rules, run_rules
+
+FIXTURES_DIR = os.path.abspath(os.path.join(os.path.dirname(__file__), '..', '..', 'test-vul'))
+
+ANNOTATION = re.compile(r'(?://|#)\s*sast:(expect|expect-not)\s+([\w-]+(?:\s*,\s*[\w-]+)*)')
+# C preprocessor lines (#define ...) start with '#' but are code
+STANDALONE_COMMENT = re.compile(r'^\s*(?://|#(?!\s*(?:define|undef|include|if|ifdef|ifndef|elif|else|endif|pragma)\b))')
+
+Location = Tuple[str, int]
+
+
+@dataclass
+class RuleResult:
+    rule_id: str
+    true_positives: List[Location] = field(default_factory=list)
+    known_negatives: List[Location] = field(default_factory=list)
+    missed: List[Location] = field(default_factory=list)  # expected but not reported
+    false_positives: List[Location] = field(default_factory=list)  # reported on an expect-not line
+    unexpected: List[Location] = field(default_factory=list)  # reported on an unannotated line
+
+    @property
+    def covered(self) -> bool:
+        return bool(self.true_positives or self.missed)
+
+    @property
+    def passed(self) -> bool:
+        return not (self.missed or self.false_positives or self.unexpected)
+
+    def to_dict(self) -> Dict[str, Any]:
+        def where(locations):
+            return [f"{path}:{line}" for path, line in locations]
+
+        return {
+            "rule_id": self.rule_id,
+            "passed": self.passed,
+            "true_positives": len(self.true_positives),
+            "known_negatives": len(self.known_negatives),
+            "missed": where(self.missed),
+            "false_positives": where(self.false_positives),
+            "unexpected": where(self.unexpected)
+        }
+
+
+@dataclass
+class SelftestReport:
+    files: List[str] = field(default_factory=list)
+    rules: Dict[str, RuleResult] = field(default_factory=dict)
+    unknown_rules: List[Tuple[str, int, str]] = field(default_factory=list)  # annotations naming no rule
+
+    @property
+    def uncovered(self) -> List[str]:
+        return sorted(r.rule_id for r in self.rules.values() if not r.covered)
+
+    def passed(self, strict: bool = False) -> bool:
+        if self.unknown_rules or not all(r.passed for r in self.rules.values()):
+            return False
+        return not (strict and self.uncovered)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "files": self.files,
+            "passed": self.passed(),
+            "rules": [r.to_dict() for r in sorted(self.rules.values(), key=lambda r: r.rule_id)],
+            "uncovered": self.uncovered,
+            "unknown_rules": [f"{path}:{line} {rule_id}" for path, line, rule_id in self.unknown_rules]
+        }
+
+
+def parse_annotations(code: str) -> Dict[int, Dict[str, Set[str]]]:
+    """line number -> {"expect": rule ids, "expect-not": rule ids}"""
+    annotations: Dict[int, Dict[str, Set[str]]] = {}
+    pending: List[Tuple[str, Set[str]]] = []
+
+    for number, line in enumerate(code.split('\n'), 1):
+        match = ANNOTATION.search(line)
+        if match and STANDALONE_COMMENT.match(line):
+            pending.append((match.group(1), {r.strip() for r in match.group(2).split(',')}))
+            continue
+        if not line.strip() or STANDALONE_COMMENT.match(line):
+            continue
+
+        entries = pending + ([(match.group(1), {r.strip() for r in match.group(2).split(',')})] if match else [])
+        pending = []
+        for kind, rule_ids in entries:
+            annotations.setdefault(number, {"expect": set(), "expect-not": set()})[kind] |= rule_ids
+
+    return annotations
+
+
+def fixture_files(paths: List[str]) -> List[str]:
+    files = []
+    for path in paths:
+        if os.path.isfile(path):
+            files.append(path)
+            continue
+        for root, dirs, names in os.walk(path):
+            dirs[:] = sorted(d for d in dirs if not d.startswith('.'))
+            files.extend(os.path.join(root, n) for n in sorted(names) if not n.startswith('.'))
+    return files
+
+
+def run_selftest(paths: Optional[List[str]] = None, rule_ids: Optional[List[str]] = None) -> SelftestReport:
+    rules = [r for r in get_rules() if rule_ids is None or r.rule_id in rule_ids]
+    known = {r.rule_id for r in get_rules()}
+    report = SelftestReport(rules={r.rule_id: RuleResult(r.rule_id) for r in rules})
+
+    for file_path in fixture_files(paths or [FIXTURES_DIR]):
+        try:
+            with open(file_path, 'r', encoding='utf-8') as f:
+                code = f.read()
+        except (OSError, UnicodeDecodeError):
+            continue
+
+        annotations = parse_annotations(code)
+        findings = run_rules(code, file_path, rule_ids=list(report.rules))
+        if not annotations and not findings:
+            continue
+
+        display = os.path.relpath(file_path, FIXTURES_DIR) if file_path.startswith(FIXTURES_DIR) else file_path
+        report.files.append(display)
+        reported = {(f.rule_id, f.line_number) for f in findings}
+
+        for line, expectations in sorted(annotations.items()):
+            for kind in ("expect", "expect-not"):
+                for rule_id in sorted(expectations[kind]):
+                    if rule_id not in known:
+                        report.unknown_rules.append((display, line, rule_id))
+                        continue
+                    if rule_id not in report.rules:
+                        continue
+                    result = report.rules[rule_id]
+                    hit = (rule_id, line) in reported
+                    if kind == "expect":
+                        (result.true_positives if hit else result.missed).append((display, line))
+                    else:
+                        (result.false_positives if hit else result.known_negatives).append((display, line))
+
+        for rule_id, line in sorted(reported, key=lambda r: (r[1], r[0])):
+            expectations = annotations.get(line)
+            if not expectations or rule_id not in expectations["expect"] | expectations["expect-not"]:
+                report.rules[rule_id].unexpected.append((display, line))
+
+    return report
@@ -0,0 +1,71 @@
+"""
+Trend gates - Severity budgets checked against the scan history: instead of an absolute threshold, the
+number of findings at or above a severity may not grow over a window (e.g. week-over-week), so existing
+debt can be paid down gradually while new debt is blocked
+"""
+
+import time
+from datetime import datetime
+from typing import Any, Dict, Optional
+
+from .config.policy import TrendGate, severity_rank
+
+
+def trend_counts(report: Dict[str, Any]) -> Dict[str, int]:
+    """Findings per severity, leaving out those triaged as false positives: dismissing a finding pays the
+    debt down just as fixing it does"""
+    counts: Dict[str, int] = {}
+    for vuln in report.get("vulnerabilities", []):
+        if (vuln.get("triage") or {}).get("verdict") != "false_positive":
+            counts[vuln.get("severity", "")] = counts.get(vuln.get("severity", ""), 0) + 1
+    return counts
+
+
+def severity_total(by_severity: Dict[str, int], severity: str) -> int:
+    """Findings at or above severity in per-severity counts"""
+    return sum(count for level, count in by_severity.items() if severity_rank(level) <= severity_rank(severity))
+
+
+async def evaluate_trend_gates(report: Dict[str, Any], db, target: str) -> Optional[Dict[str, Any]]:
+    """Compare the report with the newest completed scan of target that is at least each gate's window
+    old; a gate with no scan that old yet passes (the history is still building up)"""
+    configured = (report.get("project_config") or {}).get("trend_gates") or []
+    if not configured:
+        return None
+
+    current_counts = trend_counts(report)
+    started_at = report.get("started_at") or time.time()
+    results = []
+    for gate in (TrendGate.from_dict(entry, "report") for entry in configured):
+        current = severity_total(current_counts, gate.severity)
+        result = {**gate.to_dict(), "current": current, "previous": None, "previous_session": None, "previous_at": None, "passed": True}
+
+        previous = await db.get_session_before(target, started_at - gate.window_seconds)
+        if previous:
+            metadata = previous.to_dict()["metadata"]
+            # scans recorded before trend_counts was stored only have the summary, which counts false positives
+            counts = metadata["trend_counts"] if "trend_counts" in metadata else (metadata.get("summary") or {}).get("by_severity") or {}
+            result.update(
+                previous=severity_total(counts, gate.severity),
+                previous_session=previous.session_id,
+                previous_at=previous.started_at
+            )
+            result["passed"] = current <= result["previous"] + gate.max_increase
+        results.append(result)
+
+    report["trend_gate"] = {
+        "target": target,
+        "passed": all(r["passed"] for r in results),
+        "gates": results
+    }
+    return report["trend_gate"]
+
+
+def describe_trend_gate(result: Dict[str, Any]) -> str:
+    """One line per gate, e.g. 'high and above over 7d: 12 now, 10 on 2026-10-08 (budget +0): FAILED'"""
+    budget = f"{result['severity']} and above over {result['window']}: {result['current']} now"
+    if result["previous"] is None:
+        return f"{budget}, no scan that old yet: passed"
+    since = datetime.fromtimestamp(result["previous_at"]).strftime('%Y-%m-%d')
+    verdict = "passed" if result["passed"] else "FAILED"
+    return f"{budget}, {result['previous']} on {since} (budget +{result['max_increase']}): {verdict}"
@@ -0,0 +1,182 @@
+"""
+Triage store - Confirmed / false-positive decisions on findings, reused in later scans of the same repo
+Decisions are keyed by the finding fingerprint, so a dismissed finding stays dismissed across line shifts;
+similar prior decisions are also handed to the analyzer agent so it stops re-reporting the same patterns.
+"""
+
+import json
+import os
+import secrets
+import threading
+import time
+from dataclasses import asdict, dataclass
+from typing import Any, Dict, List, Optional
+
+from .reports import finding_fingerprint
+
+VERDICTS = ("confirmed", "false_positive")
+
+
+class TriageError(Exception):
+    pass
+
+
+@dataclass
+class TriageDecision:
+    decision_id: str
+    repo: str  # project id, or the scanned directory for scans outside a project
+    fingerprint: str
+    verdict: str
+    reason: str
+    rule_id: Optional[str] = None
+    vuln_type: str = ""
+    cwe_id: Optional[str] = None
+    file_path: str = ""  # relative to the repo root
+    code_snippet: str = ""
+    session_id: Optional[str] = None
+    vuln_id: Optional[str] = None
+    decided_by: Optional[str] = None
+    created_at: float = 0.0
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+def report_root(report: Dict[str, Any]) -> str:
+    target = str(report.get("target") or "")
+    if report.get("analysis_type") == "file" or os.path.isfile(target):
+        return os.path.dirname(os.path.abspath(target))
+    return os.path.abspath(target) if target else ""
+
+
+def repo_key(report: Dict[str, Any]) -> str:
+    return report.get("project_id") or report_root(report)
+
+
+def relative_path(file_path: str, root: str) -> str:
+    if root and os.path.isabs(file_path):
+        return os.path.relpath(file_path, root)
+    return file_path
+
+
+class TriageStore:
+    """Triage decisions persisted as JSON next to the project store"""
+
+    def __init__(self, path: str):
+        self.path = path
+        self._lock = threading.Lock()
+
+    def _read(self) -> List[TriageDecision]:
+        if not os.path.exists(self.path):
+            return []
+        with open(self.path, 'r') as f:
+            data = json.load(f)
+        return [TriageDecision(**d) for d in data.get("decisions", [])]
+
+    def _write(self, decisions: List[TriageDecision]):
+        os.makedirs(os.path.dirname(os.path.abspath(self.path)), exist_ok=True)
+        tmp = f"{self.path}.tmp"
+        with open(tmp, 'w') as f:
+            json.dump({"decisions": [d.to_dict() for d in decisions]}, f, indent=2)
+        os.replace(tmp, self.path)
+
+    def list(self, repo: Optional[str] = None, verdict: Optional[str] = None) -> List[TriageDecision]:
+        decisions = [
+            d for d in self._read()
+            if (repo is None or d.repo == repo) and (verdict is None or d.verdict == verdict)
+        ]
+        return sorted(decisions, key=lambda d: d.created_at, reverse=True)
+
+    def for_repo(self, repo: str) -> Dict[str, TriageDecision]:
+        """fingerprint -> latest decision"""
+        return {d.fingerprint: d for d in reversed(self.list(repo))}
+
+    def record(self, report: Dict[str, Any], vuln: Dict[str, Any], verdict: str, reason: str, decided_by: Optional[str] = None) -> TriageDecision:
+        """Record a decision on a report finding, replacing any earlier decision on the same finding"""
+        if verdict not in VERDICTS:
+            raise TriageError(f"Invalid verdict {verdict!r} (choose from {', '.join(VERDICTS)})")
+        if not reason or not reason.strip():
+            raise TriageError("A reason is required so later scans know why the decision was made")
+
+        root = report_root(report)
+        decision = TriageDecision(
+            decision_id=f"tri_{secrets.token_hex(6)}",
+            repo=repo_key(report),
+            fingerprint=finding_fingerprint(vuln, root),
+            verdict=verdict,
+            reason=reason.strip(),
+            rule_id=vuln.get("rule_id"),
+            vuln_type=vuln.get("vuln_type", ""),
+            cwe_id=vuln.get("cwe_id"),
+            file_path=relative_path(vuln.get("file_path", ""), root),
+            code_snippet=(vuln.get("code_snippet") or "").strip(),
+            session_id=report.get("session_id"),
+            vuln_id=vuln.get("vuln_id"),
+            decided_by=decided_by,
+            created_at=time.time()
+        )
+        with self._lock:
+            decisions = [
+                d for d in self._read()
+                if not (d.repo == decision.repo and d.fingerprint == decision.fingerprint)
+            ]
+            decisions.append(decision)
+            self._write(decisions)
+        return decision
+
+    def delete(self, decision_id: str) -> TriageDecision:
+        with self._lock:
+            decisions = self._read()
+            match = next((d for d in decisions if d.decision_id == decision_id), None)
+            if not match:
+                raise TriageError(f"No triage decision {decision_id}")
+            self._write([d for d in decisions if d is not match])
+            return match
+
+
+def get_triage_store() -> TriageStore:
+    from .config.settings import get_settings
+
+    return TriageStore(get_settings().triage_file)
+
+
+def related_decisions(decisions: Dict[str, TriageDecision], file_path: str, root: str, limit: int = 10) -> List[TriageDecision]:
+    """Prior decisions worth showing the analyzer for one file: that file's own decisions first,
+    then false positives of the same kinds elsewhere in the repo"""
+    path = relative_path(file_path, root)
+    same_file = [d for d in decisions.values() if d.file_path == path]
+    kinds = {(d.rule_id or d.vuln_type) for d in same_file}
+    elsewhere = [
+        d for d in decisions.values()
+        if d.file_path != path and d.verdict == "false_positive" and (not kinds or (d.rule_id or d.vuln_type) in kinds)
+    ]
+    ranked = sorted(same_file, key=lambda d: -d.created_at) + sorted(elsewhere, key=lambda d: -d.created_at)
+    return ranked[:limit]
+
+
+def prompt_context(decisions: List[TriageDecision]) -> str:
+    """Prior decisions as prompt text for the analyzer agent"""
+    if not decisions:
+        return ""
+    lines = ["Earlier triage decisions by this repository's reviewers (do not re-report findings they marked false_positive unless the code changed in a way that invalidates their reason):"]
+    for d in decisions:
+        snippet = ' '.join(d.code_snippet.split())[:160]
+        lines.append(f"- [{d.verdict}] {d.rule_id or d.vuln_type} in {d.file_path}: `{snippet}` - {d.reason}")
+    return '\n'.join(lines)
+
+
+def apply_triage(report: Dict[str, Any], store: Optional[TriageStore] = None):
+    """Carry earlier decisions over to matching findings; findings marked false positive stop blocking the gate"""
+    decisions = (store or get_triage_store()).for_repo(repo_key(report))
+    if not decisions:
+        return
+
+    root = report_root(report)
+    counts = {verdict: 0 for verdict in VERDICTS}
+    for vuln in report.get("vulnerabilities", []):
+        decision = decisions.get(finding_fingerprint(vuln, root))
+        if decision:
+            vuln["triage"] = {"decision_id": decision.decision_id, "verdict": decision.verdict, "reason": decision.reason}
+            counts[decision.verdict] += 1
+
+    report["triage"] = {"prior_decisions": len(decisions), **counts}
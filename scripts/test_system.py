@@ -9,8 +9,9 @@ import sys
 import os
 import tempfile
 
-# Add the backend src directory to Python path
+# Add the backend src directory to Python path (and backend, for modules imported as the src package)
 sys.path.insert(0, os.path.join(os.path.dirname(__file__), '../backend/src'))
+sys.path.insert(0, os.path.join(os.path.dirname(__file__), '../backend'))
 
 from agents import create_agent_manager_with_defaults
 from agents.vuln_analyzer import Vulnerability
@@ -205,6 +206,274 @@ def test_database_schema():
         return False
 
 
+def test_remote_credentials():
+    """Test that git tokens are only sent to the configured hosts"""
+    print("\n🔑 Testing Remote Credentials...")
+    
+    from src.config.settings import get_settings
+    from src.remote import git_env, token_for
+    
+    settings = get_settings()
+    settings.gitlab_token, settings.gitlab_url, settings.gitlab_hosts = "glpat-test", "https://gitlab.com", ["git.example.com"]
+    
+    checks = [
+        ("gitlab.com gets the token", token_for("gitlab.com") is not None),
+        ("GITLAB_HOSTS entry gets the token", token_for("git.example.com") is not None),
+        ("gitlab.attacker.io gets nothing", token_for("gitlab.attacker.io") is None),
+        ("mygitlab.com gets nothing", token_for("mygitlab.com") is None),
+        ("no header for a look-alike host", "GIT_CONFIG_VALUE_0" not in git_env("https://gitlab.attacker.io/acme/app.git")),
+    ]
+    for name, passed in checks:
+        print(f"  {'✅' if passed else '❌'} {name}")
+    return all(passed for _, passed in checks)
+
+
+def test_redacted_locations():
+    """Test that LLM findings quoting redacted secrets still match their line"""
+    print("\n🕵️ Testing Redacted Finding Locations...")
+    
+    from src.config.settings import get_settings
+    from src.redaction import redact
+    from src.untrusted import locate
+    
+    get_settings().redact_secrets = True
+    code = 'import os\n\npassword = "hunter2-Pa55"\nprint(password)\n'
+    quote = redact(code).split('\n')[2]
+    
+    checks = [
+        ("the quote is masked", "hunter2" not in quote),
+        ("redacted quote is located", locate(code, 3, quote) == 3),
+        ("raw quote is located", locate(code, 3, 'password = "hunter2-Pa55"') == 3),
+        ("made-up quote is rejected", locate(code, 3, 'api_key = "sk-test"') is None),
+    ]
+    for name, passed in checks:
+        print(f"  {'✅' if passed else '❌'} {name}")
+    return all(passed for _, passed in checks)
+
+
+def test_key_rotation():
+    """Test that a rotated key's grace period never outlives its original expiry"""
+    print("\n🔁 Testing Key Rotation...")
+    
+    import time
+    from src.auth.keys import KeyStore
+    
+    with tempfile.TemporaryDirectory() as directory:
+        store = KeyStore(os.path.join(directory, "api_keys.json"))
+        expiring, _ = store.create("ci", ["read"], expires_in=60)
+        lasting, _ = store.create("dashboard", ["read"])
+        time.sleep(1)
+        replacement, _ = store.rotate(expiring.key_id, grace_seconds=86400)
+        store.rotate(lasting.key_id, grace_seconds=3600)
+        
+        checks = [
+            ("replacement keeps the original expiry", abs(replacement.expires_at - expiring.expires_at) < 1),
+            ("grace capped at the original expiry", store.get(expiring.key_id).expires_at <= expiring.expires_at),
+            ("key without expiry gets the grace period", abs(store.get(lasting.key_id).expires_at - (time.time() + 3600)) < 60),
+        ]
+    for name, passed in checks:
+        print(f"  {'✅' if passed else '❌'} {name}")
+    return all(passed for _, passed in checks)
+
+
+def test_cron_schedules():
+    """Test that daemon schedules follow cron's day, weekday, and leap-day rules"""
+    print("\n⏰ Testing Cron Schedules...")
+    
+    from datetime import datetime
+    from src.daemon import CronSchedule, DaemonConfigError
+    
+    now = datetime(2026, 10, 15, 12, 30)  # a Thursday
+    try:
+        CronSchedule("0 0 31 2 *").next_after(now)
+        impossible_rejected = False
+    except DaemonConfigError:
+        impossible_rejected = True
+    
+    checks = [
+        ("every 15 minutes", CronSchedule("*/15 * * * *").next_after(now) == datetime(2026, 10, 15, 12, 45)),
+        ("day or weekday when both are set", CronSchedule("0 9 1 * 1").next_after(now) == datetime(2026, 10, 19, 9, 0)),
+        ("weekday 7 is Sunday", CronSchedule("0 9 * * 7").next_after(now) == datetime(2026, 10, 18, 9, 0)),
+        ("day and weekday when one starts with *", CronSchedule("0 9 */2 * 5").next_after(now) == datetime(2026, 10, 23, 9, 0)),
+        ("29 February is found", CronSchedule("0 0 29 2 *").next_after(now) == datetime(2028, 2, 29, 0, 0)),
+        ("a date that never comes is rejected", impossible_rejected),
+    ]
+    for name, passed in checks:
+        print(f"  {'✅' if passed else '❌'} {name}")
+    return all(passed for _, passed in checks)
+
+
+def test_policy_extends():
+    """Test that a scanned repository can only extend a policy inside itself"""
+    print("\n📜 Testing Policy Extends...")
+    
+    from src.config.policy import PolicyError
+    from src.config.project import load_project_config
+    from src.isolation import mark_untrusted, release_untrusted
+    
+    def refused(directory):
+        try:
+            load_project_config(directory)
+            return False
+        except PolicyError:
+            return True
+    
+    with tempfile.TemporaryDirectory() as directory:
+        os.makedirs(os.path.join(directory, ".git"))
+        config = os.path.join(directory, ".sastscan.yaml")
+        with open(os.path.join(directory, "policy.yaml"), "w") as f:
+            f.write("fail_on: high\n")
+        
+        checks = []
+        with open(config, "w") as f:
+            f.write("extends: policy.yaml\n")
+        checks.append(("policy inside the repo is applied", load_project_config(directory).policy is not None))
+        for outside in ("/etc/passwd", "~/.ssh/config", "../policy.yaml"):
+            with open(config, "w") as f:
+                f.write(f"extends: {outside}\n")
+            checks.append((f"{outside} is refused", refused(directory)))
+        
+        with open(config, "w") as f:
+            f.write("extends: http://169.254.169.254/latest/meta-data\nrule_packs: [/opt/packs/acme]\n")
+        mark_untrusted(directory)
+        try:
+            untrusted = load_project_config(directory)
+        finally:
+            release_untrusted(directory)
+        checks.append(("URL ignored for an unpacked archive", untrusted.policy is None))
+        checks.append(("absolute local pack pin refused", untrusted.rule_packs == [] and len(untrusted.pack_errors) == 1))
+    for name, passed in checks:
+        print(f"  {'✅' if passed else '❌'} {name}")
+    return all(passed for _, passed in checks)
+
+
+def test_key_scopes():
+    """Test that keys are limited to their scopes and projects"""
+    print("\n🔑 Testing Key Scopes...")
+    
+    from src.auth.dependencies import Principal
+    from src.auth.keys import AuthError, KeyStore
+    
+    with tempfile.TemporaryDirectory() as directory:
+        store = KeyStore(os.path.join(directory, "api_keys.json"))
+        reader, token = store.create("dashboard", ["read"], projects=["payments"])
+        admin, _ = store.create("ops", ["admin"], projects=["payments"])
+        try:
+            store.create("typo", ["raed"])
+            invalid_rejected = False
+        except AuthError:
+            invalid_rejected = True
+        verified = store.verify(token)
+        store.revoke(reader.key_id)
+        
+        checks = [
+            ("token verifies to its key", verified is not None and verified.key_id == reader.key_id),
+            ("read key cannot scan", reader.allows("read") and not reader.allows("scan")),
+            ("admin implies every scope", admin.allows("scan") and admin.allows("admin")),
+            ("project key sees only its projects", reader.can_access("payments") and not reader.can_access("billing")),
+            ("project key cannot list everything", not Principal(reader).can_access(None)),
+            ("admin key sees every project", admin.can_access("billing")),
+            ("unknown scopes rejected", invalid_rejected),
+            ("revoked key no longer verifies", store.verify(token) is None),
+        ]
+    for name, passed in checks:
+        print(f"  {'✅' if passed else '❌'} {name}")
+    return all(passed for _, passed in checks)
+
+
+def test_archive_limits():
+    """Test that archive uploads are bounded in size and entry count"""
+    print("\n📦 Testing Archive Limits...")
+    
+    import zipfile
+    from src.archives import ArchiveError, extract_archive
+    
+    def rejected(path, **limits):
+        with tempfile.TemporaryDirectory() as destination:
+            try:
+                extract_archive(path, destination, **limits)
+            except ArchiveError:
+                return True
+        return False
+    
+    with tempfile.TemporaryDirectory() as directory:
+        path = os.path.join(directory, "upload.zip")
+        with zipfile.ZipFile(path, 'w') as archive:
+            archive.writestr("app/main.go", "package main\n" * 100)
+            archive.writestr("app/util.go", "package main\n")
+            archive.writestr("app/main.go/shadow.go", "package main\n")
+            archive.writestr("../escape.go", "package main\n")
+            archive.writestr(".git/config", "[core]\n")
+        
+        with tempfile.TemporaryDirectory() as destination:
+            info = extract_archive(path, destination, max_bytes=1 << 20, max_entries=10)
+            escaped = os.path.exists(os.path.join(os.path.dirname(destination), "escape.go"))
+        
+        checks = [
+            ("size limit enforced", rejected(path, max_bytes=100, max_entries=10)),
+            ("entry limit enforced", rejected(path, max_bytes=1 << 20, max_entries=2)),
+            ("files within the limits extracted", info.files == 2),
+            ("conflicting, unsafe, and git entries skipped", len(info.skipped) == 3),
+            ("nothing written outside the destination", not escaped),
+        ]
+    for name, passed in checks:
+        print(f"  {'✅' if passed else '❌'} {name}")
+    return all(passed for _, passed in checks)
+
+
+def test_tracker_export():
+    """Test that tracker export files each finding once and closes only after completed scans"""
+    print("\n🎫 Testing Tracker Export...")
+    
+    from src.integrations.trackers import TrackerStore, export_findings
+    
+    class FakeTracker:
+        name = "webhook"
+        
+        def __init__(self):
+            self.created, self.closed = [], []
+        
+        def find(self, fingerprint):
+            return None
+        
+        def create(self, title, description, vuln, fingerprint):
+            self.created.append(fingerprint)
+            return fingerprint, ""  # like a receiver that returns no key
+        
+        def update(self, key, title, description, vuln, fingerprint):
+            pass
+        
+        def close(self, key, comment):
+            self.closed.append(key)
+    
+    finding = {"vuln_id": "V1", "rule_id": "go-sql-injection", "file_path": "db.go", "line_number": 12,
+               "severity": "high", "code_snippet": "db.Query(q)", "triage": {"verdict": "confirmed"}}
+    
+    def scan(project_id, status="completed", findings=True):
+        return {"session_id": f"{project_id}-{status}", "analysis_type": "project", "status": status,
+                "project_id": project_id, "target": "/src", "vulnerabilities": [dict(finding)] if findings else []}
+    
+    with tempfile.TemporaryDirectory() as directory:
+        store, tracker = TrackerStore(os.path.join(directory, "trackers.json")), FakeTracker()
+        export_findings(scan("payments"), tracker, store)
+        export_findings(scan("payments"), tracker, store)
+        export_findings(scan("billing"), tracker, store)
+        export_findings(scan("payments", status="failed", findings=False), tracker, store)
+        closed_after_failure = list(tracker.closed)
+        export_findings(scan("payments", findings=False), tracker, store)
+        
+        checks = [
+            ("finding filed once per repo", len(tracker.created) == 2),
+            ("same key in two repos kept apart", all(store.for_repo("webhook", repo) for repo in ("payments", "billing"))),
+            ("failed scan closes nothing", not closed_after_failure),
+            ("fixed finding closed after a completed scan", len(tracker.closed) == 1),
+            ("other repo's issue stays open", store.for_repo("webhook", "billing")[tracker.created[1]].status == "open"),
+        ]
+    for name, passed in checks:
+        print(f"  {'✅' if passed else '❌'} {name}")
+    return all(passed for _, passed in checks)
+
+
 async def main():
     """Run all tests"""
     print("🚀 Starting Vulnerability Analysis System Tests\n")
@@ -213,6 +482,14 @@ async def main():
         ("Agent System", test_agents()),
         ("Pattern Analysis", test_pattern_analysis()),
         ("Database Schema", test_database_schema()),
+        ("Remote Credentials", test_remote_credentials()),
+        ("Redacted Finding Locations", test_redacted_locations()),
+        ("Key Rotation", test_key_rotation()),
+        ("Cron Schedules", test_cron_schedules()),
+        ("Policy Extends", test_policy_extends()),
+        ("Key Scopes", test_key_scopes()),
+        ("Archive Limits", test_archive_limits()),
+        ("Tracker Export", test_tracker_export()),
     ]
     
     results = []
@@ -18,7 +18,7 @@ public class UserService {
     // VULN: Hardcoded credentials
     private static final String DB_URL = "jdbc:mysql://localhost:3306/users";
     private static final String DB_USER = "root";
-    private static final String DB_PASSWORD = "admin123";  // VULN: Hardcoded password
+    private static final String DB_PASSWORD = "admin123";  // VULN: Hardcoded password // sast:expect hardcoded-secret
     
     private Connection connection;
     
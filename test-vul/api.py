@@ -16,12 +16,12 @@ from functools import wraps
 app = Flask(__name__)
 
 # VULN: Hardcoded secret key
-app.secret_key = 'super_secret_key_123'
+app.secret_key = 'super_secret_key_123'  # sast:expect hardcoded-secret
 
 # VULN: Hardcoded database credentials
 DB_HOST = 'localhost'
 DB_USER = 'admin'
-DB_PASSWORD = 'password123'  # VULN: Hardcoded password
+DB_PASSWORD = 'password123'  # VULN: Hardcoded password # sast:expect hardcoded-secret
 
 
 def get_db():
@@ -208,7 +208,7 @@ def get_user(user_id):
 
 
 # VULN: Hardcoded API Key
-API_KEY = 'sk-1234567890abcdef'  # VULN: Hardcoded secret
+API_KEY = 'sk-1234567890abcdef'  # VULN: Hardcoded secret # sast:expect hardcoded-secret
 
 @app.route('/api/external-service')
 def call_external():
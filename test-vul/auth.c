@@ -9,7 +9,7 @@
 #include <unistd.h>
 
 #define MAX_USERS 100
-#define ADMIN_PASSWORD "admin123"  // VULN: Hardcoded credentials
+#define ADMIN_PASSWORD "admin123"  // VULN: Hardcoded credentials // sast:expect hardcoded-secret
 
 typedef struct {
     char username[32];
@@ -26,17 +26,17 @@ void login(char *username, char *password) {
     char query[256];
     
     // VULN: strcpy without length check - buffer overflow
-    strcpy(buffer, username);
-    strcat(buffer, ":");
-    strcat(buffer, password);
+    strcpy(buffer, username);  // sast:expect c-unbounded-copy
+    strcat(buffer, ":");  // sast:expect-not c-unbounded-copy
+    strcat(buffer, password);  // sast:expect c-unbounded-copy
     
     // VULN: Format string vulnerability
     printf("Attempting login: ");
-    printf(username);  // User input directly in format string
-    printf("\n");
+    printf(username);  // User input directly in format string  // sast:expect c-format-string
+    printf("\n");  // sast:expect-not c-format-string
     
     // VULN: sprintf without bounds - buffer overflow
-    sprintf(query, "SELECT * FROM users WHERE username='%s' AND password='%s'", username, password);
+    sprintf(query, "SELECT * FROM users WHERE username='%s' AND password='%s'", username, password);  // sast:expect c-unbounded-copy
     
     // Check hardcoded admin
     if (strcmp(username, "admin") == 0 && strcmp(password, ADMIN_PASSWORD) == 0) {
@@ -63,8 +63,8 @@ void register_user(char *username, char *password) {
     }
     
     // VULN: strcpy without bounds checking
-    strcpy(users[user_count].username, username);
-    strcpy(users[user_count].password, password);  // VULN: Storing plaintext password
+    strcpy(users[user_count].username, username);  // sast:expect c-unbounded-copy
+    strcpy(users[user_count].password, password);  // VULN: Storing plaintext password  // sast:expect c-unbounded-copy
     users[user_count].is_admin = 0;
     user_count++;
     
@@ -76,14 +76,14 @@ void check_user_files(char *username) {
     char command[256];
     
     // VULN: User input directly in system command
-    sprintf(command, "ls -la /home/%s", username);
+    sprintf(command, "ls -la /home/%s", username);  // sast:expect c-unbounded-copy
     system(command);  // Command injection vulnerability
 }
 
 // VULN: Integer overflow
 void allocate_session(int size) {
     // VULN: No check for negative or overflow
-    char *session = (char *)malloc(size * sizeof(char));
+    char *session = (char *)malloc(size * sizeof(char));  // sast:expect c-allocation-overflow
     if (session == NULL) {
         printf("Allocation failed\n");
         return;
@@ -103,7 +103,7 @@ void process_token(char *token) {
     }
     
     // VULN: Use after free - token_copy may have been freed
-    printf("Processing token: %s\n", token_copy);
+    printf("Processing token: %s\n", token_copy);  // sast:expect c-use-after-free
 }
 
 // VULN: Race condition in file access
@@ -139,10 +139,10 @@ int main(int argc, char *argv[]) {
     
     // VULN: gets() is dangerous - removed from C11
     printf("Username: ");
-    scanf("%s", username);  // VULN: No length limit
+    scanf("%s", username);  // VULN: No length limit  // sast:expect c-unbounded-copy
     
     printf("Password: ");
-    scanf("%s", password);  // VULN: Password visible, no length limit
+    scanf("%s", password);  // VULN: Password visible, no length limit  // sast:expect c-unbounded-copy
     
     switch(choice) {
         case 1:
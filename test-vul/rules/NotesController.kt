@@ -0,0 +1,75 @@
+package fixtures
+
+import android.database.sqlite.SQLiteDatabase
+import com.fasterxml.jackson.databind.ObjectMapper
+import io.ktor.server.application.ApplicationCall
+import org.springframework.jdbc.core.JdbcTemplate
+import org.springframework.web.bind.annotation.GetMapping
+import org.springframework.web.bind.annotation.PathVariable
+import org.springframework.web.bind.annotation.RequestParam
+import org.springframework.web.bind.annotation.RestController
+import java.io.ObjectInputStream
+import javax.servlet.http.HttpServletRequest
+
+// sast:expect hardcoded-secret
+const val API_TOKEN = "9f8e7d6c5b4a39281706f5e4d3c2b1a0"
+
+// sast:expect hardcoded-secret
+private val dbPassword: String = "Tr0ub4dor-and-3"
+
+// sast:expect-not hardcoded-secret
+val tokenHeader: String = "X-Auth-Token"
+
+@RestController
+class NotesController(private val jdbc: JdbcTemplate) {
+
+    @GetMapping("/notes")
+    fun search(@RequestParam("q") query: String, @RequestParam sort: String): List<Map<String, Any>> {
+        val order = if (sort == "title") "title" else "created_at"
+        // sast:expect kotlin-sql-injection
+        val rows = jdbc.queryForList("SELECT * FROM notes WHERE title LIKE '%$query%' ORDER BY $order")
+        // sast:expect-not kotlin-sql-injection
+        jdbc.queryForList("SELECT * FROM notes WHERE title LIKE ?", "%$query%")
+        return rows
+    }
+
+    @GetMapping("/notes/{id}")
+    fun show(@PathVariable id: String): Map<String, Any> {
+        // sast:expect-not kotlin-sql-injection
+        return jdbc.queryForMap("SELECT * FROM notes WHERE id = " + id.toLong())
+    }
+
+    fun export(request: HttpServletRequest): String {
+        val name = request.getParameter("name")
+        val archive = "/tmp/" + name + ".tgz"
+        // sast:expect kotlin-command-injection
+        ProcessBuilder("sh", "-c", "tar -czf $archive /var/notes").start()
+        // sast:expect-not kotlin-command-injection
+        ProcessBuilder("tar", "-czf", archive, "/var/notes").start()
+        // sast:expect kotlin-command-injection
+        Runtime.getRuntime().exec("convert " + request.getParameter("file") + " out.pdf")
+        return archive
+    }
+
+    fun restore(request: HttpServletRequest): Any? {
+        // sast:expect kotlin-deserialization
+        return ObjectInputStream(request.inputStream).readObject()
+    }
+}
+
+suspend fun ktorLookup(call: ApplicationCall, db: SQLiteDatabase, table: String) {
+    val title = call.parameters["title"]
+    // sast:expect kotlin-sql-injection
+    db.rawQuery("SELECT * FROM notes WHERE title = '" + title + "'", null)
+    // sast:expect-not kotlin-sql-injection
+    db.rawQuery("SELECT * FROM notes WHERE title = ?", arrayOf(title))
+    // sast:expect kotlin-sql-injection
+    db.execSQL("DELETE FROM $table")
+}
+
+fun mapper(): ObjectMapper {
+    val mapper = ObjectMapper()
+    // sast:expect kotlin-deserialization
+    mapper.activateDefaultTyping(mapper.polymorphicTypeValidator)
+    return mapper
+}
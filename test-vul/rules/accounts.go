@@ -0,0 +1,69 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Account struct {
+	ID       uint   `json:"id"`
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+	IsAdmin  bool   `json:"is_admin"`
+	Balance  int64  `json:"balance"`
+	Password string `json:"-"`
+}
+
+type Profile struct {
+	Name    string `json:"name"`
+	Bio     string `json:"bio"`
+	IsAdmin bool   `json:"-"`
+}
+
+type profileUpdate struct {
+	Name string `json:"name"`
+	Bio  string `json:"bio"`
+}
+
+type accountHandlers struct {
+	db *gorm.DB
+}
+
+func (h *accountHandlers) signup(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	var account Account
+	// sast:expect mass-assignment
+	if err := json.NewDecoder(r.Body).Decode(&account); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	h.db.Create(&account)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *accountHandlers) updateProfile(c *gin.Context) {
+	var profile Profile
+	// sast:expect-not mass-assignment
+	if err := c.ShouldBindJSON(&profile); err != nil {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	h.db.Save(&profile)
+}
+
+func (h *accountHandlers) rename(c *gin.Context) {
+	var update profileUpdate
+	// sast:expect-not mass-assignment
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	var account Account
+	h.db.First(&account, "id = ?", c.GetUint("account_id"))
+	account.Name = update.Name
+	h.db.Save(&account)
+}
@@ -0,0 +1,25 @@
+package fixtures
+
+import (
+	"net/http"
+	"strconv"
+)
+
+const maxBuffer = 1 << 20
+
+func allocate(w http.ResponseWriter, r *http.Request) {
+	n, _ := strconv.Atoi(r.FormValue("n"))
+	// sast:expect user-sized-allocation
+	buf := make([]byte, n)
+	w.Write(buf)
+}
+
+func allocateBounded(w http.ResponseWriter, r *http.Request) {
+	n, _ := strconv.Atoi(r.FormValue("n"))
+	if n > maxBuffer {
+		n = maxBuffer
+	}
+	// sast:expect-not user-sized-allocation
+	buf := make([]byte, n)
+	w.Write(buf)
+}
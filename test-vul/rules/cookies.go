@@ -0,0 +1,68 @@
+package fixtures
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/gin-gonic/gin"
+)
+
+var sessionManager = scs.New()
+
+func setSession(w http.ResponseWriter, token string) {
+	// sast:expect insecure-cookie
+	http.SetCookie(w, &http.Cookie{Name: "session", Value: token, Path: "/"})
+}
+
+func setHardenedSession(w http.ResponseWriter, token string) {
+	// sast:expect-not insecure-cookie
+	cookie := &http.Cookie{Name: "session", Value: token, Path: "/"}
+	cookie.Secure = true
+	cookie.HttpOnly = true
+	cookie.SameSite = http.SameSiteStrictMode
+	http.SetCookie(w, cookie)
+}
+
+func clearSession(w http.ResponseWriter) {
+	// sast:expect-not insecure-cookie
+	http.SetCookie(w, &http.Cookie{Name: "session", MaxAge: -1, Expires: time.Unix(0, 0)})
+}
+
+func setPreference(c *gin.Context) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	// sast:expect-not insecure-cookie
+	c.SetCookie("theme", "dark", 3600, "/", "", true, true)
+	// sast:expect insecure-cookie
+	c.SetCookie("auth_token", c.GetString("token"), 3600, "/", "", false, true)
+}
+
+func resumeSession(w http.ResponseWriter, r *http.Request) {
+	// sast:expect session-token-in-url
+	sid := r.URL.Query().Get("sessionid")
+	// sast:expect-not session-token-in-url
+	page := r.URL.Query().Get("page")
+	// sast:expect session-token-in-url
+	http.Redirect(w, r, "/app?sid="+sid+"&page="+url.QueryEscape(page), http.StatusFound)
+}
+
+func login(w http.ResponseWriter, r *http.Request) {
+	userID, ok := checkPassword(r.PostFormValue("user"), r.PostFormValue("password"))
+	if !ok {
+		http.Error(w, "invalid credentials", http.StatusUnauthorized)
+		return
+	}
+	// sast:expect session-fixation
+	sessionManager.Put(r.Context(), "userID", userID)
+}
+
+func loginRenewed(w http.ResponseWriter, r *http.Request) {
+	userID, ok := checkPassword(r.PostFormValue("user"), r.PostFormValue("password"))
+	if !ok || sessionManager.RenewToken(r.Context()) != nil {
+		http.Error(w, "invalid credentials", http.StatusUnauthorized)
+		return
+	}
+	// sast:expect-not session-fixation
+	sessionManager.Put(r.Context(), "userID", userID)
+}
@@ -0,0 +1,45 @@
+package fixtures
+
+import (
+	"net/http"
+
+	"github.com/rs/cors"
+)
+
+func permissiveCORS() http.Handler {
+	// sast:expect cors-wildcard-credentials
+	c := cors.New(cors.Options{
+		AllowedOrigins:   []string{"*"},
+		AllowCredentials: true,
+	})
+	return c.Handler(http.DefaultServeMux)
+}
+
+func allowlistedCORS() http.Handler {
+	// sast:expect-not cors-wildcard-credentials
+	c := cors.New(cors.Options{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowCredentials: true,
+	})
+	return c.Handler(http.DefaultServeMux)
+}
+
+func publicCORS() http.Handler {
+	// sast:expect-not cors-wildcard-credentials
+	c := cors.New(cors.Options{
+		AllowedOrigins: []string{"*"},
+	})
+	return c.Handler(http.DefaultServeMux)
+}
+
+func reflectOrigin(w http.ResponseWriter, r *http.Request) {
+	// sast:expect cors-wildcard-credentials
+	w.Header().Set("Access-Control-Allow-Origin", r.Header.Get("Origin"))
+	w.Header().Set("Access-Control-Allow-Credentials", "true")
+}
+
+func publicAsset(w http.ResponseWriter, r *http.Request) {
+	// sast:expect-not cors-wildcard-credentials
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Write([]byte("ok"))
+}
@@ -0,0 +1,32 @@
+package fixtures
+
+import (
+	crand "crypto/rand"
+	"database/sql"
+	"math/rand"
+)
+
+type cache struct {
+	items map[string]string
+}
+
+func (c *cache) Exec(key string) error { return nil }
+
+type store struct {
+	db    *sql.DB
+	cache *cache
+}
+
+func (s *store) purge(token []byte) {
+	// sast:expect ignored-security-error
+	s.db.Exec("DELETE FROM sessions")
+	// sast:expect ignored-security-error
+	_, _ = crand.Read(token)
+
+	// an Exec method of a project type, not database/sql
+	db := s.cache
+	// sast:expect-not ignored-security-error
+	db.Exec("sessions")
+	// sast:expect-not ignored-security-error
+	_, _ = rand.Read(token)
+}
@@ -0,0 +1,82 @@
+package fixtures
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+func writeUploads(data []byte) error {
+	// sast:expect world-writable-permissions
+	if err := os.MkdirAll("/var/app/uploads", 0777); err != nil {
+		return err
+	}
+	// sast:expect-not world-writable-permissions
+	return os.WriteFile("/var/app/uploads/latest", data, 0644)
+}
+
+func saveKey(pem []byte) error {
+	// sast:expect credential-file-permissions
+	if err := ioutil.WriteFile("server.key", pem, 0644); err != nil {
+		return err
+	}
+	// sast:expect-not credential-file-permissions
+	return ioutil.WriteFile("backup.key", pem, 0600)
+}
+
+func createToken() (*os.File, error) {
+	// sast:expect credential-file-permissions
+	return os.Create("token.json")
+}
+
+func sessionCache() (*os.File, error) {
+	// sast:expect predictable-temp-file
+	return os.Create(filepath.Join(os.TempDir(), "session.dat"))
+}
+
+func scratchFile() (*os.File, error) {
+	// sast:expect-not predictable-temp-file
+	return os.CreateTemp("", "scratch-*")
+}
+
+func leakToken(apiToken string) error {
+	// sast:expect temp-file-secret-not-removed
+	f, err := os.CreateTemp("", "cfg")
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(apiToken)
+	return err
+}
+
+func cleanToken(apiToken string) error {
+	// sast:expect-not temp-file-secret-not-removed
+	f, err := os.CreateTemp("", "cfg")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(apiToken)
+	return err
+}
+
+func createIfMissing(path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		// sast:expect toctou-file-check
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		return f.Close()
+	}
+	return nil
+}
+
+func createExclusive(path string) error {
+	// sast:expect-not toctou-file-check
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
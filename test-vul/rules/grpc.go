@@ -0,0 +1,75 @@
+package fixtures
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	pb "example.com/fixtures/proto"
+)
+
+const paymentsAddr = "payments.internal:443"
+
+func dialPayments() (*grpc.ClientConn, error) {
+	// sast:expect grpc-insecure-channel
+	return grpc.Dial(paymentsAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+}
+
+func dialSidecar() (*grpc.ClientConn, error) {
+	// sast:expect-not grpc-insecure-channel
+	return grpc.Dial("localhost:15001", grpc.WithInsecure())
+}
+
+func dialAgent(ctx context.Context) (*grpc.ClientConn, error) {
+	// sast:expect-not grpc-insecure-channel
+	return grpc.DialContext(ctx, "unix:///var/run/agent.sock", grpc.WithTransportCredentials(insecure.NewCredentials()))
+}
+
+func dialBilling(cfg *tls.Config) (*grpc.ClientConn, error) {
+	// sast:expect-not grpc-insecure-channel
+	return grpc.NewClient("billing.internal:443", grpc.WithTransportCredentials(credentials.NewTLS(cfg)))
+}
+
+func serveAdmin() error {
+	lis, err := net.Listen("tcp", ":50051")
+	if err != nil {
+		return err
+	}
+	// sast:expect grpc-server-no-tls
+	s := grpc.NewServer(grpc.UnaryInterceptor(loggingInterceptor))
+	// sast:expect grpc-missing-auth-interceptor
+	pb.RegisterAdminServer(s, &adminServer{})
+	// sast:expect-not grpc-missing-auth-interceptor
+	pb.RegisterGreeterServer(s, &greeterServer{})
+	return s.Serve(lis)
+}
+
+func serveAccounts(cfg *tls.Config) error {
+	lis, err := net.Listen("tcp", ":50052")
+	if err != nil {
+		return err
+	}
+	// sast:expect-not grpc-server-no-tls
+	s := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(cfg)),
+		grpc.ChainUnaryInterceptor(loggingInterceptor, authInterceptor),
+	)
+	// sast:expect-not grpc-missing-auth-interceptor
+	pb.RegisterAccountServer(s, &accountServer{})
+	return s.Serve(lis)
+}
+
+func serveLocal() error {
+	lis, err := net.Listen("tcp", "127.0.0.1:50053")
+	if err != nil {
+		return err
+	}
+	// sast:expect-not grpc-server-no-tls
+	s := grpc.NewServer()
+	pb.RegisterGreeterServer(s, &greeterServer{})
+	return s.Serve(lis)
+}
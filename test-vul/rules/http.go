@@ -0,0 +1,64 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+var upgrader = websocket.Upgrader{
+	// sast:expect-not websocket-any-origin
+	CheckOrigin: func(r *http.Request) bool {
+		return r.Header.Get("Origin") == "https://app.example.com"
+	},
+}
+
+func apiClient() *http.Client {
+	// sast:expect-not http-client-no-timeout
+	return &http.Client{Timeout: 10 * time.Second}
+}
+
+func decodeLimited(w http.ResponseWriter, r *http.Request) {
+	var payload map[string]interface{}
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	// sast:expect-not unbounded-body-read
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+	}
+}
+
+func readUpstream(resp *http.Response) ([]byte, error) {
+	// sast:expect-not unbounded-body-read
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+func limitedSocket(w http.ResponseWriter, r *http.Request) {
+	// sast:expect-not websocket-missing-read-limit
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(64 * 1024)
+
+	var msg map[string]interface{}
+	for conn.ReadJSON(&msg) == nil {
+		conn.WriteJSON(msg)
+	}
+}
+
+func serve(handler http.Handler) error {
+	server := &http.Server{
+		Addr:              ":8443",
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       120 * time.Second,
+	}
+	// sast:expect-not http-server-no-timeout
+	return server.ListenAndServeTLS("cert.pem", "key.pem")
+}
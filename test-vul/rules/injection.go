@@ -0,0 +1,51 @@
+package fixtures
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+)
+
+const usersTable = "users"
+
+func listUsers(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	order := "name"
+	if r.FormValue("sort") == "age" {
+		order = "age"
+	}
+	// dynamic-looking, but every value folds to a constant
+	query := fmt.Sprintf("SELECT id, name FROM %s ORDER BY %s", usersTable, order)
+	// sast:expect-not sql-injection
+	rows, err := db.Query(query)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	columns := strings.Join([]string{"id", "email"}, ", ")
+	// sast:expect-not sql-injection
+	// sast:expect idor
+	row := db.QueryRow("SELECT " + columns + " FROM " + usersTable + " WHERE id = ?", r.FormValue("id"))
+
+	filter := "active = 1"
+	if name := r.FormValue("name"); name != "" {
+		filter = "name = '" + name + "'"
+	}
+	// sast:expect sql-injection
+	rows, err = db.Query("SELECT id FROM " + usersTable + " WHERE " + filter)
+	if err == nil {
+		rows.Close()
+	}
+	fmt.Fprintln(w, row.Err())
+}
+
+func archive(dir string) error {
+	// sast:expect-not command-injection
+	if err := exec.Command("tar", "-czf", "/tmp/backup.tgz", dir).Run(); err != nil {
+		return err
+	}
+	// sast:expect command-injection
+	return exec.Command("sh", "-c", "tar -czf /tmp/backup.tgz "+dir).Run()
+}
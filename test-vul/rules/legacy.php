@@ -0,0 +1,53 @@
+<?php
+
+// sast:expect hardcoded-secret
+define('DB_PASSWORD', 'Tr0ub4dor-and-3');
+
+$config = [
+    // sast:expect hardcoded-secret
+    'api_key' => '9f8e7d6c5b4a39281706f5e4d3c2b1a0',
+    // sast:expect-not hardcoded-secret
+    'password_file' => '/run/secrets/db-password',
+];
+
+$pdo = new PDO('mysql:host=localhost;dbname=app', 'app', getenv('DB_PASSWORD'));
+
+$id = $_GET['id'];
+// sast:expect php-sql-injection
+$user = $pdo->query("SELECT * FROM users WHERE id = $id")->fetch();
+// sast:expect-not php-sql-injection
+$user = $pdo->query("SELECT * FROM users WHERE id = " . intval($id))->fetch();
+// sast:expect-not php-sql-injection
+$stmt = $pdo->prepare('SELECT * FROM users WHERE id = ?');
+$stmt->execute([$id]);
+
+// sast:expect php-file-inclusion
+include 'pages/' . $_GET['page'] . '.php';
+// sast:expect-not php-file-inclusion
+require_once __DIR__ . '/lib/helpers.php';
+
+function archive($conn, $dir)
+{
+    $host = $_POST['host'];
+    // sast:expect php-command-injection
+    system("ping -c 4 " . $host);
+    // sast:expect-not php-command-injection
+    system('ping -c 4 ' . escapeshellarg($host));
+    // sast:expect-not php-command-injection
+    exec('tar -czf /tmp/backup.tgz /var/app');
+    // sast:expect php-sql-injection
+    mysqli_query($conn, "DELETE FROM logs WHERE dir = '{$dir}'");
+}
+
+function preferences($request)
+{
+    // sast:expect php-deserialization
+    $prefs = unserialize($_COOKIE['prefs']);
+    // sast:expect-not php-deserialization
+    $cached = unserialize($request->session()->get('prefs'), ['allowed_classes' => false]);
+    $expr = $request->input('expr');
+    // sast:expect php-code-injection
+    eval('$total = ' . $expr . ';');
+    // sast:expect php-sql-injection
+    return DB::select("SELECT * FROM prefs WHERE owner = '" . $request->input('owner') . "'");
+}
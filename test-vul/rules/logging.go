@@ -0,0 +1,30 @@
+package fixtures
+
+import (
+	"log"
+	"net/http"
+	"strings"
+)
+
+func login(w http.ResponseWriter, r *http.Request) {
+	user := r.FormValue("user")
+	password := r.FormValue("password")
+	// sast:expect sensitive-data-logged, log-injection
+	log.Printf("login user=%s password=%s", user, password)
+	// sast:expect log-injection
+	log.Printf("login attempt for %s", user)
+}
+
+func search(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	// sast:expect-not log-injection
+	log.Printf("search for %q", q)
+	clean := strings.ReplaceAll(q, "\n", "")
+	// sast:expect-not log-injection, sensitive-data-logged
+	log.Printf("normalized search %s", strings.ReplaceAll(clean, "\r", ""))
+}
+
+func startup(port string) {
+	// sast:expect-not sensitive-data-logged, log-injection
+	log.Printf("listening on %s", port)
+}
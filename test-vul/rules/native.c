@@ -0,0 +1,126 @@
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <stdint.h>
+#include <syslog.h>
+#include <sys/socket.h>
+
+#define BANNER "native-helper 1.4"
+#define LOG_FORMAT "%s: %d\n"
+// sast:expect hardcoded-secret
+#define SERVICE_PASSWORD "n4tive-Helper-77"
+// sast:expect-not hardcoded-secret
+#define PASSWORD_PROMPT "Enter password: "
+
+typedef struct {
+    uint32_t count;
+    char *name;
+    char *payload;
+} packet_t;
+
+int greet(int argc, char **argv)
+{
+    char name[64];
+    char line[128];
+    const char *home = getenv("HOME");
+
+    // sast:expect c-unbounded-copy
+    strcpy(name, argv[1]);
+    // sast:expect c-unbounded-copy
+    strcat(name, home);
+    // sast:expect-not c-unbounded-copy
+    strcpy(name, BANNER);
+    // sast:expect c-unbounded-copy
+    gets(line);
+    // sast:expect-not c-unbounded-copy
+    scanf("%63s", name);
+    // sast:expect-not c-unbounded-copy
+    snprintf(name, sizeof(name), "%s", argv[1]);
+    // sast:expect-not c-unbounded-copy
+    sprintf(line, "%d items, %.32s", argc, argv[1]);
+
+    // sast:expect c-format-string
+    printf(argv[1]);
+    // sast:expect c-format-string
+    syslog(LOG_INFO, home);
+    // sast:expect-not c-format-string
+    printf(LOG_FORMAT, name, argc);
+    // sast:expect-not c-format-string
+    fprintf(stderr, "%s\n", argv[1]); /* printf(argv[1]) in a comment is not a call */
+    return 0;
+}
+
+char *duplicate(const char *source)
+{
+    char *copy = malloc(strlen(source) + 1);
+    if (copy == NULL) {
+        return NULL;
+    }
+    // sast:expect-not c-unbounded-copy
+    strcpy(copy, source);
+    return copy;
+}
+
+packet_t *read_packet(int sock)
+{
+    uint32_t count;
+    packet_t *packet = calloc(1, sizeof(packet_t));
+
+    recv(sock, &count, sizeof(count), 0);
+    // sast:expect c-allocation-overflow
+    packet->payload = malloc(count * sizeof(uint64_t));
+    // sast:expect-not c-allocation-overflow
+    packet->name = malloc(strlen(BANNER) + 1);
+    // sast:expect-not c-allocation-overflow
+    char *items = calloc(count, sizeof(uint64_t));
+    packet->count = count;
+    free(items);
+    return packet;
+}
+
+void *grow(void *items, size_t count, size_t size)
+{
+    if (size != 0 && count > SIZE_MAX / size) {
+        return NULL;
+    }
+    // sast:expect-not c-allocation-overflow
+    return realloc(items, count * size);
+}
+
+void release(packet_t *packet, int failed)
+{
+    free(packet->payload);
+    if (failed) {
+        // sast:expect c-double-free
+        free(packet->payload);
+    }
+
+    free(packet);
+    // sast:expect c-use-after-free
+    syslog(LOG_INFO, "released %u bytes", packet->count);
+}
+
+void retry(packet_t *packet, int attempts)
+{
+    if (attempts > 3) {
+        free(packet->name);
+        packet->name = NULL;
+    }
+    // sast:expect-not c-use-after-free
+    printf("%s\n", packet->name);
+
+    if (packet->count == 0) {
+        free(packet);
+        return;
+    }
+    // sast:expect-not c-use-after-free
+    printf("%u\n", packet->count);
+
+    char *buffer = malloc(64);
+    if (attempts == 0) {
+        free(buffer);
+    } else {
+        // sast:expect-not c-use-after-free
+        buffer[0] = '\0';
+    }
+}
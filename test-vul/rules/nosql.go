@@ -0,0 +1,64 @@
+package fixtures
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type userQuery struct {
+	Name string `json:"name"`
+}
+
+func searchUsers(w http.ResponseWriter, r *http.Request, users *mongo.Collection) {
+	var filter bson.M
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	if err := json.NewDecoder(r.Body).Decode(&filter); err != nil {
+		return
+	}
+	// sast:expect nosql-injection
+	cursor, err := users.Find(r.Context(), filter)
+	if err == nil {
+		cursor.Close(r.Context())
+	}
+}
+
+func findByName(w http.ResponseWriter, r *http.Request, users *mongo.Collection) {
+	var query userQuery
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		return
+	}
+	// typed field: a string, never an operator document
+	// sast:expect-not nosql-injection
+	users.FindOne(context.TODO(), bson.M{"name": query.Name})
+
+	name := r.URL.Query().Get("name")
+	// sast:expect-not nosql-injection
+	users.FindOne(context.TODO(), bson.M{"name": name})
+
+	// sast:expect-not nosql-injection
+	users.FindOne(context.TODO(), bson.M{"name": bson.M{"$regex": "^" + regexp.QuoteMeta(name)}})
+
+	// sast:expect nosql-injection
+	users.FindOne(context.TODO(), bson.M{"$where": "this.name == '" + name + "'"})
+}
+
+func login(w http.ResponseWriter, r *http.Request, users *mongo.Collection) {
+	body := map[string]interface{}{}
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return
+	}
+	filter := bson.D{
+		// sast:expect nosql-injection
+		{"username", body["username"]},
+		// sast:expect nosql-injection
+		{"password", body["password"]},
+	}
+	users.FindOne(r.Context(), filter)
+}
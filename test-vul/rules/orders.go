@@ -0,0 +1,66 @@
+package fixtures
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Order struct {
+	ID     int
+	UserID int
+	Total  int
+}
+
+type orderHandlers struct {
+	db  *gorm.DB
+	sql *sql.DB
+}
+
+func (h *orderHandlers) getOrder(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var order Order
+	// sast:expect idor
+	h.db.First(&order, id)
+	c.JSON(http.StatusOK, order)
+}
+
+func (h *orderHandlers) deleteOrder(w http.ResponseWriter, r *http.Request) {
+	orderID := r.PathValue("orderID")
+	// sast:expect idor
+	if _, err := h.sql.Exec("DELETE FROM orders WHERE id = $1", orderID); err != nil {
+		http.Error(w, "delete failed", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *orderHandlers) getOwnOrder(c *gin.Context) {
+	id := c.Param("id")
+	var order Order
+	// sast:expect-not idor
+	h.db.Where("id = ? AND user_id = ?", id, c.GetInt("user_id")).First(&order)
+	c.JSON(http.StatusOK, order)
+}
+
+func (h *orderHandlers) updateOrder(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var order Order
+	// sast:expect-not idor
+	h.db.First(&order, id)
+	if err := h.requireOwner(c, order); err != nil {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusOK, order)
+}
+
+func (h *orderHandlers) requireOwner(c *gin.Context, order Order) error {
+	if order.UserID != c.GetInt("user_id") {
+		return http.ErrNoLocation
+	}
+	return nil
+}
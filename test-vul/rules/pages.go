@@ -0,0 +1,32 @@
+package fixtures
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+)
+
+var pageTemplates = template.Must(template.ParseGlob("templates/*.html"))
+
+func renderDashboard(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	pageTemplates.ExecuteTemplate(w, "dashboard.html", nil)
+}
+
+func servePages() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", renderDashboard)
+	mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
+
+	// sast:expect missing-security-headers
+	server := &http.Server{
+		Addr:              ":8080",
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		IdleTimeout:       60 * time.Second,
+	}
+	log.Fatal(server.ListenAndServe())
+}
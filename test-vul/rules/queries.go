@@ -0,0 +1,64 @@
+package fixtures
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/antchfu/xmlquery"
+	"github.com/go-ldap/ldap/v3"
+	"gorm.io/gorm"
+)
+
+type account struct {
+	ID   int
+	Name string
+}
+
+func listAccounts(w http.ResponseWriter, r *http.Request, db *gorm.DB) {
+	var accounts []account
+	name := r.FormValue("name")
+	// sast:expect-not orm-injection
+	db.Where("name = ?", name).Find(&accounts)
+	// sast:expect-not orm-injection
+	db.Where(&account{Name: name}).Find(&accounts)
+
+	// sast:expect orm-injection
+	db.Where("name = '" + name + "'").Find(&accounts)
+	// sast:expect orm-injection
+	db.Model(&account{}).Order(r.URL.Query().Get("sort")).Find(&accounts)
+
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		return
+	}
+	var found account
+	// sast:expect-not orm-injection
+	// sast:expect idor
+	db.First(&found, id)
+	// sast:expect orm-injection
+	db.First(&found, "id = "+r.FormValue("id"))
+}
+
+func lookupUser(conn *ldap.Conn, r *http.Request) error {
+	user := r.FormValue("user")
+	// sast:expect-not ldap-injection
+	safe := ldap.NewSearchRequest("dc=example,dc=com", ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		"(&(objectClass=person)(uid="+ldap.EscapeFilter(user)+"))", []string{"dn"}, nil)
+	if _, err := conn.Search(safe); err != nil {
+		return err
+	}
+	// sast:expect ldap-injection
+	unsafe := ldap.NewSearchRequest("dc=example,dc=com", ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		"(&(objectClass=person)(uid="+user+"))", []string{"dn"}, nil)
+	_, err := conn.Search(unsafe)
+	return err
+}
+
+func findProduct(doc *xmlquery.Node, r *http.Request) *xmlquery.Node {
+	// sast:expect-not xpath-injection
+	if node := xmlquery.FindOne(doc, "//product[@featured='true']"); node != nil {
+		return node
+	}
+	// sast:expect xpath-injection
+	return xmlquery.FindOne(doc, "//product[name='"+r.FormValue("name")+"']")
+}
@@ -0,0 +1,51 @@
+package fixtures
+
+import (
+	"net/http"
+	"net/url"
+	"os/exec"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/httprate"
+)
+
+func login(w http.ResponseWriter, r *http.Request) {}
+
+func listProducts(w http.ResponseWriter, r *http.Request) {}
+
+func previewLink(w http.ResponseWriter, r *http.Request) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get("https://preview.example.com/render?url=" + url.QueryEscape(r.URL.Query().Get("url")))
+	if err != nil {
+		http.Error(w, "fetch failed", http.StatusBadGateway)
+		return
+	}
+	resp.Body.Close()
+}
+
+func convertDocument(w http.ResponseWriter, r *http.Request) {
+	if err := exec.Command("libreoffice", "--headless", "--convert-to", "pdf", "/tmp/in.docx").Run(); err != nil {
+		http.Error(w, "conversion failed", http.StatusInternalServerError)
+	}
+}
+
+func routes() http.Handler {
+	r := chi.NewRouter()
+	// sast:expect missing-rate-limit
+	r.Post("/login", login)
+	// sast:expect-not missing-rate-limit
+	r.Get("/products", listProducts)
+	// sast:expect missing-rate-limit
+	r.Get("/preview", previewLink)
+
+	// sast:expect-not missing-rate-limit
+	r.With(httprate.LimitByIP(10, time.Minute)).Post("/password/reset", login)
+
+	r.Group(func(limited chi.Router) {
+		limited.Use(httprate.LimitByIP(5, time.Minute))
+		// sast:expect-not missing-rate-limit
+		limited.Post("/convert", convertDocument)
+	})
+	return r
+}
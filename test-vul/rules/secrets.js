@@ -0,0 +1,13 @@
+const config = {
+    // sast:expect hardcoded-secret
+    jwtSecret: 'a8f5f167f44f4964e6c998dee827110c',
+    // sast:expect-not hardcoded-secret
+    sessionSecret: process.env.SESSION_SECRET,
+    // sast:expect-not hardcoded-secret
+    password: 'changeme',
+};
+
+// sast:expect hardcoded-secret
+const API_TOKEN = "Zx81-Qw77-Lp93-Mn20";
+
+module.exports = { config, API_TOKEN };
@@ -0,0 +1,26 @@
+import os
+
+from flask import Flask
+
+app = Flask(__name__)
+
+# sast:expect hardcoded-secret
+app.secret_key = 'f3a9c1e07b6d4c52'
+
+# sast:expect-not hardcoded-secret
+app.config['SECRET_KEY'] = os.environ['SECRET_KEY']
+
+DATABASES = {
+    'default': {
+        # sast:expect hardcoded-secret
+        'PASSWORD': 'Tr0ub4dor-and-3',
+        # sast:expect-not hardcoded-secret
+        'PASSWORD_FILE': '/run/secrets/db-password',
+    }
+}
+
+
+def connect(user, password):
+    # sast:expect-not hardcoded-secret
+    query = f"SELECT id FROM users WHERE name = '{user}' AND password = '{password}'"
+    return query
@@ -0,0 +1,23 @@
+package fixtures
+
+import (
+	"github.com/gin-contrib/secure"
+	"github.com/gin-gonic/gin"
+)
+
+func serveSecurePages() {
+	// sast:expect-not missing-security-headers
+	router := gin.New()
+	router.Use(secure.New(secure.Config{
+		STSSeconds:            31536000,
+		STSIncludeSubdomains:  true,
+		FrameDeny:             true,
+		ContentTypeNosniff:    true,
+		ContentSecurityPolicy: "default-src 'self'; frame-ancestors 'none'",
+	}))
+	router.LoadHTMLGlob("templates/*")
+	router.GET("/", func(c *gin.Context) {
+		c.HTML(200, "index.html", nil)
+	})
+	router.RunTLS(":8443", "cert.pem", "key.pem")
+}
@@ -0,0 +1,75 @@
+package fixtures
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const uploadDir = "/var/lib/app/uploads"
+
+var allowedExtensions = map[string]bool{".png": true, ".jpg": true, ".pdf": true}
+
+func uploadAvatar(w http.ResponseWriter, r *http.Request) {
+	file, header, err := r.FormFile("avatar")
+	if err != nil {
+		http.Error(w, "bad upload", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	target := filepath.Join(uploadDir, header.Filename)
+	// sast:expect unrestricted-file-upload
+	dst, err := os.Create(target)
+	if err != nil {
+		http.Error(w, "cannot store upload", http.StatusInternalServerError)
+		return
+	}
+	defer dst.Close()
+	io.Copy(dst, file)
+}
+
+func uploadAttachment(c *gin.Context) {
+	header, err := c.FormFile("attachment")
+	if err != nil {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	// sast:expect unrestricted-file-upload
+	c.SaveUploadedFile(header, filepath.Join(uploadDir, uuid.NewString()))
+}
+
+func uploadDocument(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 10<<20)
+	file, header, err := r.FormFile("document")
+	if err != nil {
+		http.Error(w, "bad upload", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	if !allowedUpload(header.Filename, file) {
+		http.Error(w, "unsupported file type", http.StatusUnsupportedMediaType)
+		return
+	}
+	name := uuid.NewString() + filepath.Ext(header.Filename)
+	// sast:expect-not unrestricted-file-upload
+	dst, err := os.Create(filepath.Join(uploadDir, name))
+	if err != nil {
+		http.Error(w, "cannot store upload", http.StatusInternalServerError)
+		return
+	}
+	defer dst.Close()
+	io.Copy(dst, file)
+}
+
+func allowedUpload(filename string, file io.ReadSeeker) bool {
+	head := make([]byte, 512)
+	n, _ := file.Read(head)
+	file.Seek(0, io.SeekStart)
+	sniffed := http.DetectContentType(head[:n])
+	return allowedExtensions[strings.ToLower(filepath.Ext(filename))] && sniffed != "text/html; charset=utf-8"
+}
@@ -0,0 +1,127 @@
+package fixtures
+
+import (
+	"database/sql"
+	"net/http"
+	"os"
+
+	"github.com/gorilla/websocket"
+)
+
+var (
+	sockets = websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 1024}
+	store   *sql.DB
+)
+
+type adminMessage struct {
+	Action string `json:"action"`
+	Target string `json:"target"`
+}
+
+func adminSocket(w http.ResponseWriter, r *http.Request) {
+	// sast:expect websocket-missing-auth
+	conn, err := sockets.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(4096)
+
+	for {
+		var msg adminMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		switch msg.Action {
+		case "purge":
+			store.Exec("DELETE FROM sessions WHERE user_id = $1", msg.Target)
+		case "export":
+			exportUser(msg.Target)
+		}
+	}
+}
+
+func exportUser(id string) {
+	os.WriteFile("/var/exports/"+id+".json", []byte("{}"), 0o600)
+}
+
+func cookieSocket(w http.ResponseWriter, r *http.Request) {
+	if _, err := r.Cookie("session"); err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	// sast:expect-not websocket-missing-auth
+	conn, err := sockets.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(4096)
+
+	for {
+		var msg adminMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		switch msg.Action {
+		case "purge":
+			store.Exec("DELETE FROM sessions WHERE user_id = $1", msg.Target)
+		}
+	}
+}
+
+func protectedSocket(w http.ResponseWriter, r *http.Request) {
+	// sast:expect-not websocket-missing-auth
+	conn, err := sockets.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(4096)
+
+	for {
+		var msg adminMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		switch msg.Action {
+		case "purge":
+			store.Exec("DELETE FROM sessions WHERE user_id = $1", msg.Target)
+		}
+	}
+}
+
+func echoSocket(w http.ResponseWriter, r *http.Request) {
+	// sast:expect-not websocket-missing-auth
+	conn, err := sockets.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(4096)
+
+	for {
+		kind, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.WriteMessage(kind, data)
+	}
+}
+
+func protect(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Cookie("session"); err != nil {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func routes(mux *http.ServeMux) {
+	mux.HandleFunc("/admin/ws", adminSocket)
+	mux.HandleFunc("/cookie/ws", cookieSocket)
+	mux.HandleFunc("/protected/ws", protect(protectedSocket))
+	mux.HandleFunc("/echo", echoSocket)
+}
@@ -14,13 +14,13 @@ const app = express();
 app.use(express.json());
 console.log("hello")
 // VULN: Hardcoded secret key
-const JWT_SECRET = 'super_secret_key_123';
+const JWT_SECRET = 'super_secret_key_123';  // sast:expect hardcoded-secret
 
 // VULN: Hardcoded database credentials
 const db = mysql.createConnection({
     host: 'localhost',
     user: 'root',
-    password: 'password123',  // VULN: Hardcoded password
+    password: 'password123',  // VULN: Hardcoded password // sast:expect hardcoded-secret
     database: 'users_db'
 });
 
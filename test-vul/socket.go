@@ -27,15 +27,16 @@ import (
 const (
 	DB_HOST     = "localhost"
 	DB_USER     = "postgres"
-	DB_PASSWORD = "admin123" // VULN: Hardcoded password
+	DB_PASSWORD = "admin123" // VULN: Hardcoded password // sast:expect hardcoded-secret
 	DB_NAME     = "users"
-	SECRET_KEY  = "my_secret_key_123" // VULN: Hardcoded secret
+	SECRET_KEY  = "my_secret_key_123" // VULN: Hardcoded secret // sast:expect hardcoded-secret
 )
 
 var (
 	db       *sql.DB
 	upgrader = websocket.Upgrader{
 		// VULN: Allowing all origins - no CORS protection
+		// sast:expect websocket-any-origin
 		CheckOrigin: func(r *http.Request) bool {
 			return true
 		},
@@ -69,6 +70,7 @@ func incrementCounter() {
 // VULN: Race condition in balance update
 func withdraw(amount int) bool {
 	// VULN: TOCTOU race condition
+	// sast:expect toctou-shared-state
 	if balance >= amount {
 		// Time gap allows double spending
 		balance -= amount
@@ -85,6 +87,7 @@ func loginHandler(w http.ResponseWriter, r *http.Request) {
 	// VULN: SQL Injection through string concatenation
 	query := fmt.Sprintf("SELECT * FROM users WHERE username = '%s' AND password = '%s'", username, password)
 
+	// sast:expect sql-injection
 	rows, err := db.Query(query)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -94,6 +97,7 @@ func loginHandler(w http.ResponseWriter, r *http.Request) {
 
 	if rows.Next() {
 		var user User
+		// sast:expect ignored-security-error
 		rows.Scan(&user.ID, &user.Username, &user.Password, &user.Email, &user.IsAdmin)
 		// VULN: Returning password in response
 		json.NewEncoder(w).Encode(user)
@@ -107,6 +111,7 @@ func pingHandler(w http.ResponseWriter, r *http.Request) {
 	host := r.URL.Query().Get("host")
 
 	// VULN: User input directly in command execution
+	// sast:expect command-injection
 	cmd := exec.Command("sh", "-c", "ping -c 4 "+host)
 	output, err := cmd.Output()
 
@@ -140,6 +145,7 @@ func createInsecureClient() *http.Client {
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 	}
+	// sast:expect http-client-no-timeout
 	return &http.Client{Transport: tr}
 }
 
@@ -156,6 +162,7 @@ func fetchHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer resp.Body.Close()
 
+	// sast:expect unbounded-body-read
 	body, _ := ioutil.ReadAll(resp.Body)
 	w.Write(body)
 }
@@ -172,6 +179,7 @@ func redirectHandler(w http.ResponseWriter, r *http.Request) {
 	target := r.URL.Query().Get("url")
 
 	// VULN: Open redirect - no validation
+	// sast:expect header-injection
 	http.Redirect(w, r, target, http.StatusFound)
 }
 
@@ -192,6 +200,7 @@ func debugHandler(w http.ResponseWriter, r *http.Request) {
 
 // WebSocket handler with vulnerabilities
 func wsHandler(w http.ResponseWriter, r *http.Request) {
+	// sast:expect websocket-missing-read-limit, websocket-missing-auth
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Println("Upgrade error:", err)
@@ -209,19 +218,24 @@ func wsHandler(w http.ResponseWriter, r *http.Request) {
 		switch msg.Type {
 		case "command":
 			// VULN: Command injection via WebSocket
+			// sast:expect handler-panic
 			cmd := msg.Payload.(string)
+			// sast:expect ignored-security-error, command-injection
 			output, _ := exec.Command("sh", "-c", cmd).Output()
 			conn.WriteJSON(Message{Type: "result", Payload: string(output)})
 
 		case "query":
 			// VULN: SQL Injection via WebSocket
+			// sast:expect handler-panic
 			query := msg.Payload.(string)
+			// sast:expect ignored-security-error, sql-injection
 			rows, _ := db.Query(query)
 			defer rows.Close()
 			conn.WriteJSON(Message{Type: "result", Payload: "Query executed"})
 
 		case "file":
 			// VULN: Path traversal via WebSocket
+			// sast:expect handler-panic
 			filename := msg.Payload.(string)
 			data, _ := ioutil.ReadFile("/data/" + filename)
 			conn.WriteJSON(Message{Type: "result", Payload: string(data)})
@@ -233,6 +247,7 @@ func wsHandler(w http.ResponseWriter, r *http.Request) {
 func processBuffer(data []byte) {
 	// VULN: No bounds checking
 	result := make([]byte, 10)
+	// sast:expect unchecked-slice-bounds
 	copy(result, data[:20]) // Panic if data < 20 bytes
 	fmt.Println(string(result))
 }
@@ -285,16 +300,22 @@ func main() {
 	}
 	defer db.Close()
 
+	// sast:expect missing-rate-limit
 	http.HandleFunc("/login", loginHandler)
+	// sast:expect missing-rate-limit
 	http.HandleFunc("/ping", pingHandler)
 	http.HandleFunc("/file", fileHandler)
+	// sast:expect missing-rate-limit
 	http.HandleFunc("/fetch", fetchHandler)
 	http.HandleFunc("/redirect", redirectHandler)
 	http.HandleFunc("/debug", debugHandler)
+	// sast:expect missing-rate-limit
 	http.HandleFunc("/ws", wsHandler)
 	http.HandleFunc("/leaky", leakyHandler)
 
 	// VULN: Running on all interfaces without TLS
 	log.Println("Starting vulnerable server on :8080")
+	// sast:expect http-server-no-timeout
+	// sast:expect-not handler-panic
 	log.Fatal(http.ListenAndServe(":8080", nil))
 }